@@ -23,6 +23,11 @@ func main(){
 	defer app.Log.Sync()
 
 	engine := gin.New()
+	// rate limits and sessions key on the client IP, so X-Forwarded-For is only believed from configured proxies
+	if err := engine.SetTrustedProxies(app.Config.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies %s", err)
+	}
+	engine.TrustedPlatform = app.Config.Server.TrustedPlatform
 
 	// Setup global middleware
 	app.Middleware.SetupGlobalMiddlewares(engine)
@@ -1,8 +1,26 @@
 package main
 
+import (
+	"be/internal/app"
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
 func main() {
-	// c := cron.New(cron.WithSeconds())
-	
+	// Initialize app
+	app, err := app.InitializeApplication()
+	if err != nil {
+		log.Fatalf("Failed to initialize application %s", err)
+	}
+	defer app.Log.Sync()
 
-}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	app.Log.Info("Starting worker")
+	app.Worker.Run(ctx)
+	app.Log.Info("Worker stopped")
+}
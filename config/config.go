@@ -18,9 +18,16 @@ type AppConfig struct {
 	AllowedOrigins string
 }
 type ServerConfig struct {
-	Host    string
-	Port    int
-	Timeout time.Duration
+	Host      string
+	Port      int
+	Timeout   time.Duration
+	PublicURL string
+	// TrustedProxies are the addresses or CIDRs whose X-Forwarded-For the client IP is read from; by default no
+	// proxy is trusted and the client IP is the remote address.
+	TrustedProxies []string
+	// TrustedPlatform names a header set by the platform in front of the server, such as CF-Connecting-IP, that
+	// carries the client IP instead.
+	TrustedPlatform string
 }
 
 type TLSConfig struct {
@@ -30,9 +37,16 @@ type TLSConfig struct {
 }
 
 type JwtConfig struct {
+	// Secret never signs tokens; it only protects the stored signing keys.
 	Secret          string
 	AccessTokenTTL  time.Duration
 	RefreshTokenTTL time.Duration
+	// SigningAlgorithm is ES256 or EdDSA and applies to keys created from now on.
+	SigningAlgorithm string
+	// KeyRotationPeriod is how long a signing key signs before the next one takes over.
+	KeyRotationPeriod time.Duration
+	// KeyPublishDelay is how long a new key is published in the JWKS before it signs, so verifiers can fetch it first.
+	KeyPublishDelay time.Duration
 }
 type ZapConfig struct {
 	Level    string
@@ -62,10 +76,12 @@ type MongoConfig struct {
 }
 
 type ElasticsearchConfig struct {
-	Host     string
-	Port     int
-	Username string
-	Password string
+	Enabled     bool
+	Host        string
+	Port        int
+	Username    string
+	Password    string
+	SchemaIndex string
 }
 
 type RedisConfig struct {
@@ -146,7 +162,112 @@ type CircuitConfig struct {
 type Iden3Config struct {
 	VerifierPrivateKey string
 }
+
+// RoleConfig controls how identities obtain the issuer, verifier and admin roles. DID lists are comma separated.
+type RoleConfig struct {
+	// AdminDIDs are granted the admin role when they log in.
+	AdminDIDs string
+	// RootIssuerDIDs may accredit issuers and verifiers with an accreditation credential.
+	RootIssuerDIDs string
+	// AccreditationType is the credential type an accreditation credential must carry.
+	AccreditationType string
+}
+
+// OIDCConfig configures the OpenID Connect provider. Issuer defaults to the public URL of the server.
+type OIDCConfig struct {
+	Issuer         string
+	CodeTTL        time.Duration
+	AccessTokenTTL time.Duration
+	IDTokenTTL     time.Duration
+	// ClaimScopes are the scopes a relying party can ask for that are answered with a credential proof.
+	ClaimScopes []OIDCClaimScope
+}
+
+// OIDCClaimScope maps a scope to a boolean claim that holds when the holder proves a credentialAtomicQueryV3
+// query. With MinAge set the query is Field < the birth date MinAge years ago, in DateFormat ("unix" or
+// "yyyymmdd"); otherwise it is Field Operator Value.
+type OIDCClaimScope struct {
+	Scope          string      `mapstructure:"scope"`
+	Claim          string      `mapstructure:"claim"`
+	CredentialType string      `mapstructure:"credential_type"`
+	Context        string      `mapstructure:"context"`
+	Field          string      `mapstructure:"field"`
+	Operator       string      `mapstructure:"operator"`
+	Value          interface{} `mapstructure:"value"`
+	MinAge         int         `mapstructure:"min_age"`
+	DateFormat     string      `mapstructure:"date_format"`
+	AllowedIssuers []string    `mapstructure:"allowed_issuers"`
+}
+
+// SecurityConfig is the security profile of the HTTP API. Zero values take the defaults of the middleware package,
+// and Routes tighten or loosen it for the paths they match.
+type SecurityConfig struct {
+	// HSTSMaxAge is how long browsers keep to HTTPS; a negative value sends no HSTS header.
+	HSTSMaxAge            time.Duration
+	HSTSIncludeSubdomains bool
+	HSTSPreload           bool
+	ContentSecurityPolicy string
+	FrameOptions          string
+	ReferrerPolicy        string
+	// MaxBodySize is the largest request body accepted, in bytes.
+	MaxBodySize int64
+	// ContentTypes are the media types a request with a body may be sent as.
+	ContentTypes []string
+	Routes       []SecurityRoute
+}
+
+// SecurityRoute overrides the security profile for requests whose path starts with PathPrefix; the longest
+// matching prefix wins. Empty fields keep the value of the profile.
+type SecurityRoute struct {
+	PathPrefix string `mapstructure:"path_prefix"`
+	// AllowedOrigins may contain "*", which is never combined with credentials.
+	AllowedOrigins   []string `mapstructure:"allowed_origins"`
+	AllowCredentials *bool    `mapstructure:"allow_credentials"`
+	MaxBodySize      int64    `mapstructure:"max_body_size"`
+	ContentTypes     []string `mapstructure:"content_types"`
+}
+
+// APIKeyConfig limits the API keys verifiers create for their backends. Rate limits are requests per minute.
+type APIKeyConfig struct {
+	DefaultRateLimit int
+	MaxRateLimit     int
+	MaxKeysPerDID    int
+}
+
+// RateLimitConfig configures the Redis-backed rate limiter. Policies override the built-in policies of the same
+// name; route groups pick the policy they are limited by.
+type RateLimitConfig struct {
+	Disabled bool
+	Policies map[string]RateLimitPolicy
+}
+
+// RateLimitPolicy allows Limit requests per sliding Window, counted by Key: "ip", "did" or "api_key". Requests
+// that carry no DID or API key are counted by IP.
+type RateLimitPolicy struct {
+	Limit  int           `mapstructure:"limit"`
+	Window time.Duration `mapstructure:"window"`
+	Key    string        `mapstructure:"key"`
+}
+
 type CronConfig struct {
+	// PointRestoreInterval is how often deducted licence points that are due are restored.
+	PointRestoreInterval time.Duration
+	// PointRestoreAfter is how long deducted licence points stay deducted.
+	PointRestoreAfter time.Duration
+	// AutoApprovalInterval is how often pending credential requests are checked against auto approval rules.
+	AutoApprovalInterval time.Duration
+	// KeyRotationInterval is how often the signing keys are checked for rotation.
+	KeyRotationInterval time.Duration
+	// IssuanceBatchSweepInterval is how often issuance batches abandoned by a stopped process are failed.
+	IssuanceBatchSweepInterval time.Duration
+	// IssuanceBatchStaleAfter is how long a running issuance batch may go without a heartbeat before it counts as abandoned.
+	IssuanceBatchStaleAfter time.Duration
+	// ImportJobSweepInterval is how often document import jobs abandoned by a stopped process are failed.
+	ImportJobSweepInterval time.Duration
+	// ImportJobStaleAfter is how long a running import job may go without progress before it counts as abandoned.
+	ImportJobStaleAfter time.Duration
+	// SchemaReindexInterval is how often every schema is written to the search index again.
+	SchemaReindexInterval time.Duration
 }
 
 type Config struct {
@@ -167,6 +288,11 @@ type Config struct {
 	IPFS          PinataConfig
 	Circuit       CircuitConfig
 	Iden3         Iden3Config
+	Role          RoleConfig
+	OIDC          OIDCConfig
+	APIKey        APIKeyConfig
+	RateLimit     RateLimitConfig
+	Security      SecurityConfig
 }
 
 func NewConfig() (*Config, error) {
@@ -197,9 +323,13 @@ func NewConfig() (*Config, error) {
 			AllowedOrigins: viper.GetString("app.allowed_origins"),
 		},
 		Server: ServerConfig{
-			Host:    viper.GetString("server.host"),
-			Port:    viper.GetInt("server.port"),
-			Timeout: viper.GetDuration("server.timeout"),
+			Host:      viper.GetString("server.host"),
+			Port:      viper.GetInt("server.port"),
+			Timeout:   viper.GetDuration("server.timeout"),
+			PublicURL: viper.GetString("server.public_url"),
+
+			TrustedProxies:  viper.GetStringSlice("server.trusted_proxies"),
+			TrustedPlatform: viper.GetString("server.trusted_platform"),
 		},
 		TLS: TLSConfig{
 			Enabled:  viper.GetBool("tls.enabled"),
@@ -227,10 +357,12 @@ func NewConfig() (*Config, error) {
 			Timeout:     viper.GetDuration("mongo.timeout"),
 		},
 		Elasticsearch: ElasticsearchConfig{
-			Host:     viper.GetString("elasticsearch.host"),
-			Port:     viper.GetInt("elasticsearch.port"),
-			Username: viper.GetString("elasticsearch.username"),
-			Password: viper.GetString("elasticsearch.password"),
+			Enabled:     viper.GetBool("elasticsearch.enabled"),
+			Host:        viper.GetString("elasticsearch.host"),
+			Port:        viper.GetInt("elasticsearch.port"),
+			Username:    viper.GetString("elasticsearch.username"),
+			Password:    viper.GetString("elasticsearch.password"),
+			SchemaIndex: viper.GetString("elasticsearch.schema_index"),
 		},
 		Redis: RedisConfig{
 			Host:           viper.GetString("redis.host"),
@@ -273,9 +405,12 @@ func NewConfig() (*Config, error) {
 			},
 		},
 		JWT: JwtConfig{
-			Secret:          viper.GetString("jwt.secret"),
-			AccessTokenTTL:  viper.GetDuration("jwt.access_token_ttl"),
-			RefreshTokenTTL: viper.GetDuration("jwt.refresh_token_ttl"),
+			Secret:            viper.GetString("jwt.secret"),
+			AccessTokenTTL:    viper.GetDuration("jwt.access_token_ttl"),
+			RefreshTokenTTL:   viper.GetDuration("jwt.refresh_token_ttl"),
+			SigningAlgorithm:  viper.GetString("jwt.signing_algorithm"),
+			KeyRotationPeriod: viper.GetDuration("jwt.key_rotation_period"),
+			KeyPublishDelay:   viper.GetDuration("jwt.key_publish_delay"),
 		},
 		Zap: ZapConfig{
 			Level:    viper.GetString("zap.level"),
@@ -287,6 +422,17 @@ func NewConfig() (*Config, error) {
 			Protocol: viper.GetString("fluent.protocol"),
 			Timeout:  viper.GetDuration("fluent.timeout"),
 		},
+		Cron: CronConfig{
+			PointRestoreInterval:       viper.GetDuration("cron.point_restore_interval"),
+			PointRestoreAfter:          viper.GetDuration("cron.point_restore_after"),
+			AutoApprovalInterval:       viper.GetDuration("cron.auto_approval_interval"),
+			KeyRotationInterval:        viper.GetDuration("cron.key_rotation_interval"),
+			IssuanceBatchSweepInterval: viper.GetDuration("cron.issuance_batch_sweep_interval"),
+			IssuanceBatchStaleAfter:    viper.GetDuration("cron.issuance_batch_stale_after"),
+			ImportJobSweepInterval:     viper.GetDuration("cron.import_job_sweep_interval"),
+			ImportJobStaleAfter:        viper.GetDuration("cron.import_job_stale_after"),
+			SchemaReindexInterval:      viper.GetDuration("cron.schema_reindex_interval"),
+		},
 		Blockchain: BlockchainConfig{
 			RPC:           viper.GetString("blockchain.polygon.amoy.rpc"),
 			Resolver:      viper.GetString("blockchain.polygon.amoy.resolver"),
@@ -308,6 +454,44 @@ func NewConfig() (*Config, error) {
 		Iden3: Iden3Config{
 			VerifierPrivateKey: viper.GetString("iden3.verifier.private_key"),
 		},
+		Role: RoleConfig{
+			AdminDIDs:         viper.GetString("roles.admin_dids"),
+			RootIssuerDIDs:    viper.GetString("roles.root_issuer_dids"),
+			AccreditationType: viper.GetString("roles.accreditation_type"),
+		},
+		OIDC: OIDCConfig{
+			Issuer:         viper.GetString("oidc.issuer"),
+			CodeTTL:        viper.GetDuration("oidc.code_ttl"),
+			AccessTokenTTL: viper.GetDuration("oidc.access_token_ttl"),
+			IDTokenTTL:     viper.GetDuration("oidc.id_token_ttl"),
+		},
+		APIKey: APIKeyConfig{
+			DefaultRateLimit: viper.GetInt("api_keys.default_rate_limit"),
+			MaxRateLimit:     viper.GetInt("api_keys.max_rate_limit"),
+			MaxKeysPerDID:    viper.GetInt("api_keys.max_keys_per_did"),
+		},
+		RateLimit: RateLimitConfig{
+			Disabled: viper.GetBool("rate_limits.disabled"),
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            viper.GetDuration("security.hsts_max_age"),
+			HSTSIncludeSubdomains: viper.GetBool("security.hsts_include_subdomains"),
+			HSTSPreload:           viper.GetBool("security.hsts_preload"),
+			ContentSecurityPolicy: viper.GetString("security.content_security_policy"),
+			FrameOptions:          viper.GetString("security.frame_options"),
+			ReferrerPolicy:        viper.GetString("security.referrer_policy"),
+			MaxBodySize:           viper.GetInt64("security.max_body_size"),
+			ContentTypes:          viper.GetStringSlice("security.content_types"),
+		},
+	}
+	if err := viper.UnmarshalKey("oidc.claim_scopes", &config.OIDC.ClaimScopes); err != nil {
+		return nil, fmt.Errorf("invalid oidc.claim_scopes: %w", err)
+	}
+	if err := viper.UnmarshalKey("rate_limits.policies", &config.RateLimit.Policies); err != nil {
+		return nil, fmt.Errorf("invalid rate_limits.policies: %w", err)
+	}
+	if err := viper.UnmarshalKey("security.routes", &config.Security.Routes); err != nil {
+		return nil, fmt.Errorf("invalid security.routes: %w", err)
 	}
 	return config, nil
 }
@@ -316,6 +500,46 @@ func (config *Config) GetBaseURL() string {
 	return fmt.Sprintf("%s:%d", config.Server.Host, config.Server.Port)
 }
 
+// GetPublicURL is the address holders reach the API on, used in links embedded in credentials.
+func (config *Config) GetPublicURL() string {
+	if config.Server.PublicURL != "" {
+		return strings.TrimSuffix(config.Server.PublicURL, "/")
+	}
+	return "http://" + config.GetBaseURL()
+}
+
+// IsDevelopment reports whether app.env names a local development setup, where cookies may travel over plain http.
+func (config *Config) IsDevelopment() bool {
+	switch strings.ToLower(config.App.Env) {
+	case "dev", "development", "local":
+		return true
+	}
+	return false
+}
+
+// GetAllowedOrigins lists the origins of app.allowed_origins, which is comma separated.
+func (config *Config) GetAllowedOrigins() []string {
+	return splitList(config.App.AllowedOrigins)
+}
+
+func (config *Config) GetAdminDIDs() []string {
+	return splitList(config.Role.AdminDIDs)
+}
+
+func (config *Config) GetRootIssuerDIDs() []string {
+	return splitList(config.Role.RootIssuerDIDs)
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (config *Config) GetPostgresDSN() string {
 	host := config.Postgres.Host
 	port := config.Postgres.Port
@@ -14,6 +14,7 @@ type App struct {
 	Router     *router.Router
 	Middleware *middleware.Middleware
 	Server     *Server
+	Worker     *Worker
 	Log        *logger.ZapLogger
 	// Fluent *fluent.Fluent
 	Postgres *postgres.PostgresDB
@@ -7,6 +7,7 @@ import (
 	"be/config"
 	"be/internal/infrastructure/blockchain/ether"
 	"be/internal/infrastructure/cache/redis"
+	"be/internal/infrastructure/database/elasticsearch"
 	"be/internal/infrastructure/database/postgres"
 	"be/internal/infrastructure/database/repository"
 	"be/internal/infrastructure/ipfs"
@@ -26,7 +27,7 @@ var logSet = wire.NewSet(logger.NewLogger)
 var configSet = wire.NewSet(config.NewConfig)
 
 // Infra Set
-var dbSet = wire.NewSet(postgres.NewDB)
+var dbSet = wire.NewSet(postgres.NewDB, elasticsearch.NewDB)
 var migrateSet = wire.NewSet()
 var cacheSet = wire.NewSet(redis.NewCache)
 var ipfsSet = wire.NewSet(ipfs.NewPinata)
@@ -44,35 +45,69 @@ var handlerSet = wire.NewSet(
 	handler.NewProofHandler,
 	handler.NewCircuitHandler,
 	handler.NewStatisticHandler,
+	handler.NewHolderHandler,
+	handler.NewRoleHandler,
+	handler.NewAdminHandler,
+	handler.NewOIDCHandler,
+	handler.NewAPIKeyHandler,
 )
 
 // Service Set
 var serviceSet = wire.NewSet(
 	service.NewAuthJWTService,
 	service.NewAuthZkService,
+	service.NewAutoApprovalService,
+	service.NewCredentialBundleService,
+	service.NewCredentialVerificationService,
+	service.NewCorrectionService,
+	service.NewRoleService,
+	service.NewModerationService,
+	service.NewOIDCService,
+	service.NewAPIKeyService,
 	service.NewCredentialService,
 	service.NewDocumentService,
+	service.NewImportService,
+	service.NewLicensePointService,
+	service.NewNumberingService,
 	service.NewProofService,
 	service.NewSchemaService,
+	service.NewSigningKeyService,
 	service.NewIdentityService,
 	service.NewVerifierService,
 	service.NewCircuitService,
 	service.NewStatisticService,
+	service.NewNotificationService,
 )
 
 // Repository Set
 var repositorySet = wire.NewSet(
 	repository.NewAcademicDegreeRepository,
+	repository.NewAutoApprovalRuleRepository,
+	repository.NewImportedCredentialRepository,
 	repository.NewCitizenIdentityRepository,
+	repository.NewCorrectionRequestRepository,
 	repository.NewCredentialRequestRepository,
+	repository.NewCredentialReviewRepository,
 	repository.NewDriverLicenseRepository,
+	repository.NewDriverLicensePointRepository,
 	repository.NewHealthInsuranceRepository,
 	repository.NewIdentityRepository,
+	repository.NewImportJobRepository,
+	repository.NewDocumentNumberRepository,
+	repository.NewDocumentRevisionRepository,
+	repository.NewIssuanceBatchRepository,
 	repository.NewMerkletreeRepository,
+	repository.NewNotificationRepository,
 	repository.NewPassportRepository,
 	repository.NewProofRepository,
 	repository.NewSchemaAttributeRepository,
 	repository.NewSchemaRepository,
+	repository.NewSchemaSearchRepository,
+	repository.NewSigningKeyRepository,
+	repository.NewOIDCClientRepository,
+	repository.NewIdentityAPIKeyRepository,
+	repository.NewIdentityRoleRepository,
+	repository.NewRoleApplicationRepository,
 	repository.NewStateTransitionRepository,
 	repository.NewUserRepository,
 	repository.NewVerifiableCredentialRepository,
@@ -83,11 +118,14 @@ var repositorySet = wire.NewSet(
 var routerSet = wire.NewSet(router.NewRouter)
 
 // Middleware Set
-var middlewareSet = wire.NewSet(middleware.NewMiddleware)
+var middlewareSet = wire.NewSet(middleware.NewMiddleware, middleware.NewRateLimiter)
 
 // Server Set
 var serverSet = wire.NewSet(NewServer)
 
+// Worker Set
+var workerSet = wire.NewSet(NewWorker)
+
 func InitializeApplication() (App, error) {
 	panic(wire.Build(
 		configSet,
@@ -101,6 +139,7 @@ func InitializeApplication() (App, error) {
 		routerSet,
 		middlewareSet,
 		serverSet,
+		workerSet,
 		wire.Struct(new(App), "*"),
 	))
 }
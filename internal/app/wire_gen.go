@@ -10,6 +10,7 @@ import (
 	"be/config"
 	"be/internal/infrastructure/blockchain/ether"
 	"be/internal/infrastructure/cache/redis"
+	"be/internal/infrastructure/database/elasticsearch"
 	"be/internal/infrastructure/database/postgres"
 	"be/internal/infrastructure/database/repository"
 	"be/internal/infrastructure/ipfs"
@@ -41,16 +42,33 @@ func InitializeApplication() (App, error) {
 		return App{}, err
 	}
 	iUserRepository := repository.NewUserRepository(postgresDB, zapLogger)
-	iAuthJWTService := service.NewAuthJWTService(configConfig, zapLogger, redisCache, iUserRepository)
-	authJWTHandler := handler.NewAuthJWTHandler(iAuthJWTService, zapLogger)
 	iIdentityRepository := repository.NewIdentityRepository(postgresDB)
 	imtRepository := repository.NewMerkletreeRepository(configConfig, postgresDB)
-	iIdentityService := service.NewIdentityService(configConfig, iIdentityRepository, imtRepository)
+	iStateTransition := repository.NewStateTransitionRepository(postgresDB)
+	iIdentityService := service.NewIdentityService(configConfig, iIdentityRepository, imtRepository, iStateTransition)
+	iSigningKeyRepository := repository.NewSigningKeyRepository(postgresDB)
+	iSigningKeyService, err := service.NewSigningKeyService(configConfig, zapLogger, iSigningKeyRepository)
+	if err != nil {
+		return App{}, err
+	}
+	iAuthJWTService, err := service.NewAuthJWTService(configConfig, zapLogger, redisCache, iUserRepository, iIdentityService, iSigningKeyService)
+	if err != nil {
+		return App{}, err
+	}
+	authJWTHandler := handler.NewAuthJWTHandler(iAuthJWTService, iSigningKeyService, zapLogger)
 	iVerifierService, err := service.NewVerifierService(configConfig)
 	if err != nil {
 		return App{}, err
 	}
-	iAuthZkService, err := service.NewAuthZkService(configConfig, zapLogger, redisCache, iIdentityService, iVerifierService)
+	iIdentityRoleRepository := repository.NewIdentityRoleRepository(postgresDB)
+	iRoleApplicationRepository := repository.NewRoleApplicationRepository(postgresDB)
+	iSchemaRepository := repository.NewSchemaRepository(postgresDB)
+	iVerifiableCredentialRepository := repository.NewVerifiableCredentialRepository(postgresDB, configConfig)
+	iCredentialVerificationService := service.NewCredentialVerificationService(zapLogger, iIdentityService, iSchemaRepository, iVerifiableCredentialRepository)
+	iNotificationRepository := repository.NewNotificationRepository(postgresDB)
+	iNotificationService := service.NewNotificationService(iNotificationRepository)
+	iRoleService := service.NewRoleService(postgresDB, configConfig, zapLogger, iIdentityRepository, iIdentityRoleRepository, iRoleApplicationRepository, iCredentialVerificationService, iNotificationService)
+	iAuthZkService, err := service.NewAuthZkService(configConfig, zapLogger, redisCache, iIdentityService, iVerifierService, iRoleService, iSigningKeyService)
 	if err != nil {
 		return App{}, err
 	}
@@ -60,16 +78,34 @@ func InitializeApplication() (App, error) {
 	iHealthInsuranceRepository := repository.NewHealthInsuranceRepository(postgresDB, zapLogger)
 	iDriverLicenseRepository := repository.NewDriverLicenseRepository(postgresDB, zapLogger)
 	iPassportRepository := repository.NewPassportRepository(postgresDB, zapLogger)
-	iDocumentService := service.NewDocumentService(configConfig, iCitizenIdentityRepository, iAcademicDegreeRepository, iHealthInsuranceRepository, iDriverLicenseRepository, iPassportRepository)
-	documentHandler := handler.NewDocumentHandler(iDocumentService)
+	iDocumentRevisionRepository := repository.NewDocumentRevisionRepository(postgresDB)
+	iDocumentNumberRepository := repository.NewDocumentNumberRepository(postgresDB)
+	iNumberingService := service.NewNumberingService(iDocumentNumberRepository)
+	iDocumentService := service.NewDocumentService(configConfig, postgresDB, iCitizenIdentityRepository, iAcademicDegreeRepository, iHealthInsuranceRepository, iDriverLicenseRepository, iPassportRepository, iDocumentRevisionRepository, iVerifiableCredentialRepository, iNumberingService)
+	iImportJobRepository := repository.NewImportJobRepository(postgresDB, zapLogger)
+	iImportService := service.NewImportService(configConfig, postgresDB, zapLogger, iImportJobRepository, iIdentityRepository, iCitizenIdentityRepository, iAcademicDegreeRepository, iHealthInsuranceRepository, iDriverLicenseRepository, iPassportRepository, iDocumentRevisionRepository, iVerifiableCredentialRepository, iNumberingService)
+	iDriverLicensePointRepository := repository.NewDriverLicensePointRepository(postgresDB)
+	iLicensePointService := service.NewLicensePointService(configConfig, postgresDB, zapLogger, iDriverLicenseRepository, iDriverLicensePointRepository, iDocumentRevisionRepository, iVerifiableCredentialRepository, iIdentityService)
+	iCorrectionRequestRepository := repository.NewCorrectionRequestRepository(postgresDB)
+	iCorrectionService := service.NewCorrectionService(postgresDB, iCorrectionRequestRepository, iDocumentService, iNotificationService)
+	documentHandler := handler.NewDocumentHandler(iDocumentService, iImportService, iLicensePointService, iCorrectionService)
 	iCredentialRequestRepository := repository.NewCredentialRequestRepository(postgresDB)
-	iVerifiableCredentialRepository := repository.NewVerifiableCredentialRepository(postgresDB, configConfig)
-	iSchemaRepository := repository.NewSchemaRepository(postgresDB)
-	iCredentialService := service.NewCredentialService(configConfig, iIdentityService, iDocumentService, iCredentialRequestRepository, iVerifiableCredentialRepository, iSchemaRepository)
-	credentialHandler := handler.NewCredentialHandler(iCredentialService)
-	pinata := ipfs.NewPinata(configConfig)
+	iIssuanceBatchRepository := repository.NewIssuanceBatchRepository(postgresDB)
+	iCredentialReviewRepository := repository.NewCredentialReviewRepository(postgresDB)
+	iCredentialService := service.NewCredentialService(configConfig, postgresDB, zapLogger, iIdentityService, iDocumentService, iCredentialRequestRepository, iVerifiableCredentialRepository, iSchemaRepository, iIssuanceBatchRepository, iCredentialReviewRepository)
+	iAutoApprovalRuleRepository := repository.NewAutoApprovalRuleRepository(postgresDB)
+	iAutoApprovalService := service.NewAutoApprovalService(postgresDB, zapLogger, iAutoApprovalRuleRepository, iSchemaRepository, iCredentialService, iDocumentService)
+	iImportedCredentialRepository := repository.NewImportedCredentialRepository(postgresDB)
+	iCredentialBundleService := service.NewCredentialBundleService(postgresDB, zapLogger, iVerifiableCredentialRepository, iImportedCredentialRepository, iCredentialVerificationService)
+	credentialHandler := handler.NewCredentialHandler(iCredentialService, iAutoApprovalService, iCredentialBundleService, iCredentialVerificationService)
+	pinata := ipfs.NewPinata(configConfig, zapLogger)
 	iSchemaAttributeRepository := repository.NewSchemaAttributeRepository(configConfig, postgresDB)
-	iSchemaService := service.NewSchemaService(configConfig, pinata, iIdentityRepository, iSchemaRepository, iSchemaAttributeRepository)
+	elasticsearchDB, err := elasticsearch.NewDB(configConfig, zapLogger)
+	if err != nil {
+		return App{}, err
+	}
+	iSchemaSearchRepository := repository.NewSchemaSearchRepository(configConfig, elasticsearchDB, zapLogger)
+	iSchemaService := service.NewSchemaService(configConfig, zapLogger, postgresDB, pinata, iIdentityRepository, iSchemaRepository, iSchemaAttributeRepository, iSchemaSearchRepository)
 	schemaHandler := handler.NewSchemaHandler(iSchemaService)
 	iProofRepository := repository.NewProofRepository(postgresDB)
 	iProofService := service.NewProofService(configConfig, zapLogger, iVerifierService, iIdentityService, iSchemaRepository, iProofRepository)
@@ -79,14 +115,27 @@ func InitializeApplication() (App, error) {
 	iStatisticRepository := repository.NewStatisticRepository(postgresDB, configConfig)
 	iStatisticService := service.NewStatisticService(configConfig, iStatisticRepository)
 	statisticHandler := handler.NewStatisticHandler(iStatisticService)
-	routerRouter := router.NewRouter(postgresDB, authJWTHandler, authZkHandler, documentHandler, credentialHandler, schemaHandler, proofHandler, circuitHandler, statisticHandler, iAuthZkService)
+	holderHandler := handler.NewHolderHandler(iDocumentService, iCorrectionService, iNotificationService)
+	roleHandler := handler.NewRoleHandler(iRoleService)
+	iModerationService := service.NewModerationService(zapLogger, iIdentityRepository, iAuthZkService, iSchemaService)
+	adminHandler := handler.NewAdminHandler(iModerationService)
+	ioidcClientRepository := repository.NewOIDCClientRepository(postgresDB)
+	ioidcService := service.NewOIDCService(configConfig, zapLogger, redisCache, ioidcClientRepository, iAuthZkService, iSigningKeyService)
+	oidcHandler := handler.NewOIDCHandler(configConfig, ioidcService)
+	iIdentityAPIKeyRepository := repository.NewIdentityAPIKeyRepository(postgresDB)
+	iapiKeyService := service.NewAPIKeyService(configConfig, zapLogger, redisCache, iIdentityAPIKeyRepository, iIdentityRepository)
+	apiKeyHandler := handler.NewAPIKeyHandler(iapiKeyService)
+	rateLimiter := middleware.NewRateLimiter(configConfig, zapLogger, redisCache)
+	routerRouter := router.NewRouter(postgresDB, authJWTHandler, authZkHandler, documentHandler, credentialHandler, schemaHandler, proofHandler, circuitHandler, statisticHandler, holderHandler, roleHandler, adminHandler, oidcHandler, apiKeyHandler, iAuthZkService, iapiKeyService, rateLimiter)
 	middlewareMiddleware := middleware.NewMiddleware(configConfig, zapLogger)
 	server := NewServer(configConfig, zapLogger)
+	worker := NewWorker(configConfig, zapLogger, iLicensePointService, iAutoApprovalService, iSigningKeyService, iCredentialService, iImportService, iSchemaService)
 	app := App{
 		Config:     configConfig,
 		Router:     routerRouter,
 		Middleware: middlewareMiddleware,
 		Server:     server,
+		Worker:     worker,
 		Log:        zapLogger,
 		Postgres:   postgresDB,
 		Redis:      redisCache,
@@ -103,7 +152,7 @@ var logSet = wire.NewSet(logger.NewLogger)
 var configSet = wire.NewSet(config.NewConfig)
 
 // Infra Set
-var dbSet = wire.NewSet(postgres.NewDB)
+var dbSet = wire.NewSet(postgres.NewDB, elasticsearch.NewDB)
 
 var migrateSet = wire.NewSet()
 
@@ -115,19 +164,22 @@ var ipfsSet = wire.NewSet(ipfs.NewPinata)
 var etherSet = wire.NewSet(ether.NewEther)
 
 // Handler Set
-var handlerSet = wire.NewSet(handler.NewAuthJWTHandler, handler.NewAuthZkHandler, handler.NewDocumentHandler, handler.NewSchemaHandler, handler.NewCredentialHandler, handler.NewProofHandler, handler.NewCircuitHandler, handler.NewStatisticHandler)
+var handlerSet = wire.NewSet(handler.NewAuthJWTHandler, handler.NewAuthZkHandler, handler.NewDocumentHandler, handler.NewSchemaHandler, handler.NewCredentialHandler, handler.NewProofHandler, handler.NewCircuitHandler, handler.NewStatisticHandler, handler.NewHolderHandler, handler.NewRoleHandler, handler.NewAdminHandler, handler.NewOIDCHandler, handler.NewAPIKeyHandler)
 
 // Service Set
-var serviceSet = wire.NewSet(service.NewAuthJWTService, service.NewAuthZkService, service.NewCredentialService, service.NewDocumentService, service.NewProofService, service.NewSchemaService, service.NewIdentityService, service.NewVerifierService, service.NewCircuitService, service.NewStatisticService)
+var serviceSet = wire.NewSet(service.NewAuthJWTService, service.NewAuthZkService, service.NewAutoApprovalService, service.NewCredentialBundleService, service.NewCredentialVerificationService, service.NewCorrectionService, service.NewRoleService, service.NewModerationService, service.NewOIDCService, service.NewAPIKeyService, service.NewCredentialService, service.NewDocumentService, service.NewImportService, service.NewLicensePointService, service.NewNumberingService, service.NewProofService, service.NewSchemaService, service.NewSigningKeyService, service.NewIdentityService, service.NewVerifierService, service.NewCircuitService, service.NewStatisticService, service.NewNotificationService)
 
 // Repository Set
-var repositorySet = wire.NewSet(repository.NewAcademicDegreeRepository, repository.NewCitizenIdentityRepository, repository.NewCredentialRequestRepository, repository.NewDriverLicenseRepository, repository.NewHealthInsuranceRepository, repository.NewIdentityRepository, repository.NewMerkletreeRepository, repository.NewPassportRepository, repository.NewProofRepository, repository.NewSchemaAttributeRepository, repository.NewSchemaRepository, repository.NewStateTransitionRepository, repository.NewUserRepository, repository.NewVerifiableCredentialRepository, repository.NewStatisticRepository)
+var repositorySet = wire.NewSet(repository.NewAcademicDegreeRepository, repository.NewAutoApprovalRuleRepository, repository.NewImportedCredentialRepository, repository.NewCitizenIdentityRepository, repository.NewCorrectionRequestRepository, repository.NewCredentialRequestRepository, repository.NewCredentialReviewRepository, repository.NewDriverLicenseRepository, repository.NewDriverLicensePointRepository, repository.NewHealthInsuranceRepository, repository.NewIdentityRepository, repository.NewImportJobRepository, repository.NewDocumentNumberRepository, repository.NewDocumentRevisionRepository, repository.NewIssuanceBatchRepository, repository.NewMerkletreeRepository, repository.NewNotificationRepository, repository.NewPassportRepository, repository.NewProofRepository, repository.NewSchemaAttributeRepository, repository.NewSchemaRepository, repository.NewSchemaSearchRepository, repository.NewSigningKeyRepository, repository.NewOIDCClientRepository, repository.NewIdentityAPIKeyRepository, repository.NewIdentityRoleRepository, repository.NewRoleApplicationRepository, repository.NewStateTransitionRepository, repository.NewUserRepository, repository.NewVerifiableCredentialRepository, repository.NewStatisticRepository)
 
 // Router Set
 var routerSet = wire.NewSet(router.NewRouter)
 
 // Middleware Set
-var middlewareSet = wire.NewSet(middleware.NewMiddleware)
+var middlewareSet = wire.NewSet(middleware.NewMiddleware, middleware.NewRateLimiter)
 
 // Server Set
 var serverSet = wire.NewSet(NewServer)
+
+// Worker Set
+var workerSet = wire.NewSet(NewWorker)
@@ -0,0 +1,153 @@
+package app
+
+import (
+	"be/config"
+	"be/internal/service"
+	"be/pkg/logger"
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Worker runs the scheduled jobs of the platform until its context is cancelled.
+type Worker struct {
+	config              *config.Config
+	logger              *logger.ZapLogger
+	licensePointService service.ILicensePointService
+	autoApprovalService service.IAutoApprovalService
+	signingKeyService   service.ISigningKeyService
+	credentialService   service.ICredentialService
+	importService       service.IImportService
+	schemaService       service.ISchemaService
+}
+
+func NewWorker(
+	cfg *config.Config,
+	logger *logger.ZapLogger,
+	licensePointService service.ILicensePointService,
+	autoApprovalService service.IAutoApprovalService,
+	signingKeyService service.ISigningKeyService,
+	credentialService service.ICredentialService,
+	importService service.IImportService,
+	schemaService service.ISchemaService,
+) *Worker {
+	return &Worker{
+		config:              cfg,
+		logger:              logger,
+		licensePointService: licensePointService,
+		autoApprovalService: autoApprovalService,
+		signingKeyService:   signingKeyService,
+		credentialService:   credentialService,
+		importService:       importService,
+		schemaService:       schemaService,
+	}
+}
+
+// Run starts every job on its own interval and returns once all of them have stopped.
+func (w *Worker) Run(ctx context.Context) {
+	jobs := []struct {
+		interval time.Duration
+		fallback time.Duration
+		run      func(ctx context.Context)
+	}{
+		{w.config.Cron.PointRestoreInterval, time.Hour, w.restoreDuePoints},
+		{w.config.Cron.AutoApprovalInterval, time.Minute, w.applyAutoApprovalRules},
+		{w.config.Cron.KeyRotationInterval, time.Hour, w.rotateSigningKeys},
+		{w.config.Cron.IssuanceBatchSweepInterval, time.Minute, w.failStaleIssuanceBatches},
+		{w.config.Cron.ImportJobSweepInterval, time.Minute, w.failStaleImportJobs},
+		// runs once at startup as well, so schemas created while the index was down are backfilled
+		{w.config.Cron.SchemaReindexInterval, 24 * time.Hour, w.reindexSchemas},
+	}
+
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		interval := job.interval
+		if interval <= 0 {
+			interval = job.fallback
+		}
+		wg.Add(1)
+		go func(run func(ctx context.Context)) {
+			defer wg.Done()
+			w.schedule(ctx, interval, run)
+		}(job.run)
+	}
+	wg.Wait()
+}
+
+func (w *Worker) schedule(ctx context.Context, interval time.Duration, run func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	run(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			run(ctx)
+		}
+	}
+}
+
+func (w *Worker) restoreDuePoints(ctx context.Context) {
+	restored, err := w.licensePointService.RestoreDuePoints(ctx)
+	if err != nil {
+		w.logger.Error("failed to restore due license points", zap.Error(err))
+		return
+	}
+	if restored > 0 {
+		w.logger.Info("restored due license points", zap.Int("entries", restored))
+	}
+}
+
+func (w *Worker) applyAutoApprovalRules(ctx context.Context) {
+	approved, err := w.autoApprovalService.ApplyRules(ctx)
+	if err != nil {
+		w.logger.Error("failed to apply auto approval rules", zap.Error(err))
+		return
+	}
+	if approved > 0 {
+		w.logger.Info("auto approved credential requests", zap.Int("requests", approved))
+	}
+}
+
+func (w *Worker) rotateSigningKeys(ctx context.Context) {
+	if _, err := w.signingKeyService.RotateKeys(ctx); err != nil {
+		w.logger.Error("failed to rotate signing keys", zap.Error(err))
+	}
+}
+
+func (w *Worker) failStaleIssuanceBatches(ctx context.Context) {
+	failed, err := w.credentialService.FailStaleIssuanceBatches(ctx)
+	if err != nil {
+		w.logger.Error("failed to fail stale issuance batches", zap.Error(err))
+		return
+	}
+	if failed > 0 {
+		w.logger.Warn("failed abandoned issuance batches", zap.Int("batches", failed))
+	}
+}
+
+func (w *Worker) failStaleImportJobs(ctx context.Context) {
+	failed, err := w.importService.FailStaleImportJobs(ctx)
+	if err != nil {
+		w.logger.Error("failed to fail stale import jobs", zap.Error(err))
+		return
+	}
+	if failed > 0 {
+		w.logger.Warn("failed abandoned import jobs", zap.Int("jobs", failed))
+	}
+}
+
+func (w *Worker) reindexSchemas(ctx context.Context) {
+	indexed, err := w.schemaService.ReindexSchemas(ctx)
+	if err != nil {
+		w.logger.Error("failed to reindex schemas", zap.Int("schemas", indexed), zap.Error(err))
+		return
+	}
+	if indexed > 0 {
+		w.logger.Info("reindexed schemas", zap.Int("schemas", indexed))
+	}
+}
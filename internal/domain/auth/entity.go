@@ -5,14 +5,58 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
+// User is an operator account. It signs in with a password and a TOTP code and can be linked to the DIDs its
+// operator controls.
 type User struct {
-	ID        int64             `gorm:"primaryKey" json:"id"`
-	PublicID  uuid.UUID         `gorm:"type:uuid;uniqueIndex" json:"public_id"`
-	Name      string            `gorm:"type:varchar(100);not null" json:"name"`
-	Email     string            `gorm:"type:varchar(100);not null" json:"email"`
-	Password  string            `gorm:"type:varchar(100);not null" json:"password"`
-	Role      constant.UserRole `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
-	CreatedAt time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	ID       int64             `gorm:"primaryKey" json:"id"`
+	PublicID uuid.UUID         `gorm:"type:uuid;uniqueIndex" json:"public_id"`
+	Name     string            `gorm:"type:varchar(100);not null" json:"name"`
+	Email    string            `gorm:"type:varchar(100);not null" json:"email"`
+	Password string            `gorm:"type:varchar(100);not null" json:"-"`
+	Role     constant.UserRole `gorm:"type:varchar(20);not null;default:'user'" json:"role"`
+	// TOTPSecret is stored sealed. It is set on enrollment and only used for login once TOTPEnabledAt is set.
+	TOTPSecret    []byte     `gorm:"column:totp_secret;type:bytea" json:"-"`
+	TOTPEnabledAt *time.Time `gorm:"column:totp_enabled_at" json:"totp_enabled_at"`
+	// TOTPLastCounter is the last time step a code was accepted for, so a code cannot be replayed.
+	TOTPLastCounter int64           `gorm:"column:totp_last_counter;not null;default:0" json:"-"`
+	CreatedAt       time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	Identities      []*UserIdentity `gorm:"foreignKey:UserID" json:"identities,omitempty"`
+}
+
+// UserIdentity links an operator account to a DID whose holder confirmed the link from a ZK session.
+type UserIdentity struct {
+	ID       int64     `gorm:"primaryKey" json:"id"`
+	UserID   int64     `gorm:"not null;uniqueIndex:idx_user_identities_user_did" json:"user_id"`
+	DID      string    `gorm:"column:did;type:varchar(255);not null;uniqueIndex:idx_user_identities_user_did" json:"did"`
+	LinkedAt time.Time `gorm:"not null" json:"linked_at"`
+}
+
+// SigningKey is one key of the ring that signs access and refresh tokens. The private key is stored sealed; the
+// key is published in the JWKS until it expires.
+type SigningKey struct {
+	ID          int64      `gorm:"primaryKey" json:"id"`
+	KID         string     `gorm:"column:kid;type:varchar(64);uniqueIndex;not null" json:"kid"`
+	Algorithm   string     `gorm:"type:varchar(20);not null" json:"algorithm"`
+	PrivateKey  []byte     `gorm:"type:bytea;not null" json:"-"`
+	ActivatedAt time.Time  `gorm:"not null" json:"activated_at"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OIDCClient is a relying party registered with the OpenID Connect provider. Public clients have no secret and
+// rely on PKCE alone.
+type OIDCClient struct {
+	ID           int64                       `gorm:"primaryKey" json:"id"`
+	ClientID     string                      `gorm:"type:varchar(64);uniqueIndex;not null" json:"client_id"`
+	Name         string                      `gorm:"type:varchar(255);not null" json:"name"`
+	SecretHash   string                      `gorm:"type:varchar(100)" json:"-"`
+	RedirectURIs datatypes.JSONSlice[string] `gorm:"column:redirect_uris;type:jsonb;not null" json:"redirect_uris"`
+	Scopes       datatypes.JSONSlice[string] `gorm:"type:jsonb;not null" json:"scopes"`
+	CreatedBy    string                      `gorm:"type:varchar(100);not null" json:"created_by"`
+	CreatedAt    time.Time                   `gorm:"autoCreateTime" json:"created_at"`
+	DisabledAt   *time.Time                  `json:"disabled_at"`
 }
@@ -1,11 +1,33 @@
 package auth
 
-import "context"
+import (
+	"be/internal/shared/helper"
+	"context"
+	"time"
+)
 
 type IUserRepository interface {
 	FindUserById(ctx context.Context, id int64) (*User, error)
 	FindUserByPublicId(ctx context.Context, id string) (*User, error)
 	FindUserByEmail(ctx context.Context, email string) (*User, error)
-	FindAllUsers(ctx context.Context) ([]*User, error)
+	FindAllUsers(ctx context.Context, spec *helper.QuerySpec) ([]*User, int64, error)
 	SaveUser(ctx context.Context, user *User) (*User, error)
+	UpdateUser(ctx context.Context, user *User, changes map[string]interface{}) error
+	AdvanceTOTPCounter(ctx context.Context, user *User, counter int64) (bool, error)
+	ExistsUserIdentity(ctx context.Context, userID int64, did string) (bool, error)
+	CreateUserIdentity(ctx context.Context, link *UserIdentity) (*UserIdentity, error)
+	DeleteUserIdentity(ctx context.Context, userID int64, did string) (int64, error)
+}
+
+type ISigningKeyRepository interface {
+	FindUnexpiredSigningKeys(ctx context.Context, now time.Time) ([]*SigningKey, error)
+	CreateSigningKey(ctx context.Context, key *SigningKey) (*SigningKey, error)
+	ExpireSigningKeys(ctx context.Context, exceptKID string, expiresAt time.Time) (int64, error)
+}
+
+type IOIDCClientRepository interface {
+	FindOIDCClientByClientID(ctx context.Context, clientID string) (*OIDCClient, error)
+	FindAllOIDCClients(ctx context.Context, spec *helper.QuerySpec) ([]*OIDCClient, int64, error)
+	CreateOIDCClient(ctx context.Context, client *OIDCClient) (*OIDCClient, error)
+	UpdateOIDCClient(ctx context.Context, client *OIDCClient, changes map[string]interface{}) error
 }
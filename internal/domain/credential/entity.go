@@ -10,17 +10,21 @@ import (
 )
 
 type CredentialRequest struct {
-	ID          uint                             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
-	PublicID    uuid.UUID                        `gorm:"column:public_id;type:uuid;uniqueIndex;not null;default:gen_random_uuid()" json:"public_id" validate:"required"`
-	ThreadID    string                           `gorm:"column:thread_id;type:varchar(255);not null;index" json:"thread_id" validate:"required"`
-	HolderDID   string                           `gorm:"column:holder_did;type:varchar(255);not null;index" json:"holder_did" validate:"required,startswith=did:"`
-	IssuerDID   string                           `gorm:"column:issuer_did;type:varchar(255);not null;index" json:"issuer_did" validate:"required,startswith=did:"`
-	SchemaID    uint                             `gorm:"column:schema_id;not null;index" json:"schema_id" validate:"required,gt=0"`
-	SchemaHash  string                           `gorm:"column:schema_hash;type:varchar(128);not null" json:"schema_hash" validate:"required"`
-	Status      constant.CredentialRequestStatus `gorm:"column:status;type:varchar(20);not null;default:'pending'" json:"status" validate:"required"`
-	Expiration  int64                            `gorm:"column:expiration;type:bigint" json:"expiration"`
-	CreatedTime *int64                           `gorm:"column:created_time;type:bigint" json:"created_time,omitempty" validate:"omitempty"`
-	ExpiresTime *int64                           `gorm:"column:expires_time;type:bigint" json:"expires_time,omitempty" validate:"omitempty"`
+	ID         uint                             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID   uuid.UUID                        `gorm:"column:public_id;type:uuid;uniqueIndex;not null;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	ThreadID   string                           `gorm:"column:thread_id;type:varchar(255);not null;index" json:"thread_id" validate:"required"`
+	HolderDID  string                           `gorm:"column:holder_did;type:varchar(255);not null;index" json:"holder_did" validate:"required,startswith=did:"`
+	IssuerDID  string                           `gorm:"column:issuer_did;type:varchar(255);not null;index" json:"issuer_did" validate:"required,startswith=did:"`
+	SchemaID   uint                             `gorm:"column:schema_id;not null;index" json:"schema_id" validate:"required,gt=0"`
+	SchemaHash string                           `gorm:"column:schema_hash;type:varchar(128);not null" json:"schema_hash" validate:"required"`
+	Status     constant.CredentialRequestStatus `gorm:"column:status;type:varchar(20);not null;default:'pending'" json:"status" validate:"required"`
+	// StatusReason is the reason given for the latest status change, shown to the holder.
+	StatusReason string `gorm:"column:status_reason;type:text" json:"status_reason,omitempty"`
+	Expiration   int64  `gorm:"column:expiration;type:bigint" json:"expiration"`
+	CreatedTime  *int64 `gorm:"column:created_time;type:bigint" json:"created_time,omitempty" validate:"omitempty"`
+	ExpiresTime  *int64 `gorm:"column:expires_time;type:bigint" json:"expires_time,omitempty" validate:"omitempty"`
+	// RefreshOfID is the credential this request reissues; issuing the request revokes it.
+	RefreshOfID *uint `gorm:"column:refresh_of_id;index" json:"refresh_of_id,omitempty" validate:"omitempty"`
 
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" validate:"-"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
@@ -29,6 +33,7 @@ type CredentialRequest struct {
 	Issuer               *schema.Identity      `gorm:"foreignKey:IssuerDID;references:DID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"issuer,omitempty"`
 	Schema               *schema.Schema        `gorm:"foreignKey:SchemaID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"schema,omitempty"`
 	VerifiableCredential *VerifiableCredential `gorm:"foreignKey:CRID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"verifiable_credential,omitempty"`
+	RefreshOf            *VerifiableCredential `gorm:"foreignKey:RefreshOfID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"refresh_of,omitempty"`
 }
 
 type VerifiableCredential struct {
@@ -57,6 +62,10 @@ type VerifiableCredential struct {
 	IssuanceDate      *time.Time                          `gorm:"column:issuance_date;type:timestamptz" json:"issuance_date,omitempty" validate:"omitempty"`
 	ExpirationDate    *time.Time                          `gorm:"column:expiration_date;type:timestamptz" json:"expiration_date,omitempty" validate:"omitempty"`
 	Status            constant.VerifiableCredentialStatus `gorm:"column:status;type:varchar(30);default:'issued'" json:"status" validate:"required"`
+	ReissueRequired   bool                                `gorm:"column:reissue_required;not null;default:false" json:"reissue_required" validate:"-"`
+	RefreshService    string                              `gorm:"column:refresh_service;type:varchar(255)" json:"refresh_service,omitempty" validate:"omitempty,url"`
+	// PreviousID is the credential this one replaced when it was issued from a refresh request.
+	PreviousID *uint `gorm:"column:previous_id;index" json:"previous_id,omitempty" validate:"omitempty"`
 
 	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at" validate:"-"`
 	UpdatedAt time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
@@ -67,3 +76,135 @@ type VerifiableCredential struct {
 	CredentialRequest *CredentialRequest `gorm:"foreignKey:CRID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"credential_request,omitempty"`
 	Schema            *schema.Schema     `gorm:"foreignKey:SchemaID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"schema,omitempty"`
 }
+
+// CredentialRequestReview records one decision on a credential request. Decision is the status the reviewer
+// asked for; ToStatus is where the request ended up, which stays under_review until enough reviewers approve.
+type CredentialRequestReview struct {
+	ID          uint                             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID    uuid.UUID                        `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	CRID        uint                             `gorm:"column:crid;index;not null" json:"crid" validate:"required,gt=0"`
+	Decision    constant.CredentialRequestStatus `gorm:"column:decision;type:varchar(20);not null" json:"decision" validate:"required"`
+	FromStatus  constant.CredentialRequestStatus `gorm:"column:from_status;type:varchar(20);not null" json:"from_status" validate:"required"`
+	ToStatus    constant.CredentialRequestStatus `gorm:"column:to_status;type:varchar(20);not null" json:"to_status" validate:"required"`
+	ReviewerDID string                           `gorm:"column:reviewer_did;type:varchar(255);not null" json:"reviewer_did" validate:"required,startswith=did:"`
+	Reason      string                           `gorm:"column:reason;type:text;not null" json:"reason" validate:"required"`
+	CreatedAt   time.Time                        `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+}
+
+func (CredentialRequestReview) TableName() string {
+	return "credential_request_reviews"
+}
+
+// CredentialReviewPolicy is an issuer's review setup: how many distinct reviewers must approve a request and
+// which identities, besides the issuer itself, may review its requests.
+type CredentialReviewPolicy struct {
+	ID                uint                        `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	IssuerDID         string                      `gorm:"column:issuer_did;type:varchar(255);uniqueIndex;not null" json:"issuer_did" validate:"required,startswith=did:"`
+	RequiredApprovals int                         `gorm:"column:required_approvals;not null;default:1" json:"required_approvals" validate:"min=1,max=2"`
+	Reviewers         datatypes.JSONSlice[string] `gorm:"column:reviewers;type:jsonb;not null" json:"reviewers" validate:"dive,startswith=did:"`
+	CreatedAt         time.Time                   `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt         time.Time                   `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+}
+
+func (CredentialReviewPolicy) TableName() string {
+	return "credential_review_policies"
+}
+
+// AutoApprovalRule lets the worker approve an issuer's pending credential requests for one schema when the
+// condition holds for the requesting holder.
+type AutoApprovalRule struct {
+	ID        uint                           `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID  uuid.UUID                      `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IssuerDID string                         `gorm:"column:issuer_did;type:varchar(255);index;not null" json:"issuer_did" validate:"required,startswith=did:"`
+	SchemaID  uint                           `gorm:"column:schema_id;uniqueIndex;not null" json:"schema_id" validate:"required,gt=0"`
+	Condition constant.AutoApprovalCondition `gorm:"column:condition;type:varchar(50);not null" json:"condition" validate:"required"`
+	Enabled   bool                           `gorm:"column:enabled;not null;default:true" json:"enabled"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+
+	Schema *schema.Schema `gorm:"foreignKey:SchemaID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"schema,omitempty"`
+}
+
+func (AutoApprovalRule) TableName() string {
+	return "auto_approval_rules"
+}
+
+// AutoApprovalDecision is the audit entry of one evaluation of a rule against a credential request.
+type AutoApprovalDecision struct {
+	ID       uint                         `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID uuid.UUID                    `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	RuleID   uint                         `gorm:"column:rule_id;index;not null" json:"rule_id" validate:"required,gt=0"`
+	CRID     uint                         `gorm:"column:crid;index;not null" json:"crid" validate:"required,gt=0"`
+	Outcome  constant.AutoApprovalOutcome `gorm:"column:outcome;type:varchar(20);not null" json:"outcome" validate:"required"`
+	Reason   string                       `gorm:"column:reason;type:text;not null" json:"reason" validate:"required"`
+	// RequestStatus is the status the request was left in by the decision
+	RequestStatus constant.CredentialRequestStatus `gorm:"column:request_status;type:varchar(20)" json:"request_status,omitempty"`
+	CreatedAt     time.Time                        `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+
+	CredentialRequest *CredentialRequest `gorm:"foreignKey:CRID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"credential_request,omitempty"`
+}
+
+func (AutoApprovalDecision) TableName() string {
+	return "auto_approval_decisions"
+}
+
+type IssuanceBatch struct {
+	ID             uint                         `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID       uuid.UUID                    `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IssuerDID      string                       `gorm:"column:issuer_did;type:varchar(255);index;not null" json:"issuer_did" validate:"required,startswith=did:"`
+	Status         constant.IssuanceBatchStatus `gorm:"column:status;type:varchar(20);not null;default:'pending'" json:"status" validate:"required"`
+	TotalItems     int                          `gorm:"column:total_items;not null;default:0" json:"total_items"`
+	IssuedItems    int                          `gorm:"column:issued_items;not null;default:0" json:"issued_items"`
+	FailedItems    int                          `gorm:"column:failed_items;not null;default:0" json:"failed_items"`
+	OldState       string                       `gorm:"column:old_state;type:varchar(66)" json:"old_state,omitempty"`
+	NewState       string                       `gorm:"column:new_state;type:varchar(66)" json:"new_state,omitempty"`
+	ClaimsTreeRoot string                       `gorm:"column:claims_tree_root;type:char(66)" json:"claims_tree_root,omitempty"`
+	RevTreeRoot    string                       `gorm:"column:rev_tree_root;type:char(66)" json:"rev_tree_root,omitempty"`
+	RootsTreeRoot  string                       `gorm:"column:roots_tree_root;type:char(66)" json:"roots_tree_root,omitempty"`
+	Error          string                       `gorm:"column:error;type:text" json:"error,omitempty"`
+
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+	CompletedAt *time.Time `gorm:"type:timestamptz" json:"completed_at,omitempty" validate:"omitempty"`
+
+	Items []*IssuanceBatchItem `gorm:"foreignKey:BatchID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE" json:"items,omitempty"`
+}
+
+// IssuanceBatchItem keeps everything needed to rebuild the same core claim when a batch is resumed.
+type IssuanceBatchItem struct {
+	ID                uint                             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	BatchID           uint                             `gorm:"column:batch_id;index;not null" json:"batch_id" validate:"required,gt=0"`
+	CRID              uint                             `gorm:"column:crid;index;not null" json:"crid" validate:"required,gt=0"`
+	Status            constant.IssuanceBatchItemStatus `gorm:"column:status;type:varchar(20);not null;default:'pending'" json:"status" validate:"required"`
+	CredentialStatus  datatypes.JSONMap                `gorm:"column:credential_status;type:jsonb;not null" json:"credential_status" validate:"required"`
+	CredentialSubject datatypes.JSONMap                `gorm:"column:credential_subject;type:jsonb" json:"credential_subject,omitempty"`
+	Signature         string                           `gorm:"column:signature;type:text;not null" json:"signature" validate:"required"`
+	CredentialID      string                           `gorm:"column:credential_id;type:varchar(255)" json:"credential_id,omitempty"`
+	IssuanceDate      *time.Time                       `gorm:"column:issuance_date;type:timestamptz" json:"issuance_date,omitempty"`
+	ClaimHex          string                           `gorm:"column:claim_hex;type:text" json:"claim_hex,omitempty"`
+	Error             string                           `gorm:"column:error;type:text" json:"error,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+
+	CredentialRequest *CredentialRequest `gorm:"foreignKey:CRID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"credential_request,omitempty"`
+}
+
+// ImportedCredential is a credential brought in from another deployment's export bundle. It is kept as
+// exported, with the issuer state it was proven against and the schema documents it needs.
+type ImportedCredential struct {
+	ID           uint                                `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID     uuid.UUID                           `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	HolderDID    string                              `gorm:"column:holder_did;type:varchar(255);not null;uniqueIndex:idx_imported_credentials_holder_credential" json:"holder_did" validate:"required,startswith=did:"`
+	CredentialID string                              `gorm:"column:credential_id;type:varchar(255);not null;uniqueIndex:idx_imported_credentials_holder_credential" json:"credential_id" validate:"required"`
+	IssuerDID    string                              `gorm:"column:issuer_did;type:varchar(255);not null" json:"issuer_did" validate:"required,startswith=did:"`
+	SchemaType   string                              `gorm:"column:schema_type;type:varchar(255);not null" json:"schema_type" validate:"required"`
+	Status       constant.VerifiableCredentialStatus `gorm:"column:status;type:varchar(30);not null" json:"status" validate:"required"`
+	Credential   datatypes.JSONMap                   `gorm:"column:credential;type:jsonb;not null" json:"credential" validate:"required"`
+	IssuerState  datatypes.JSONMap                   `gorm:"column:issuer_state;type:jsonb;not null" json:"issuer_state" validate:"required"`
+	Documents    datatypes.JSONMap                   `gorm:"column:documents;type:jsonb;not null" json:"documents" validate:"required"`
+	ExportedAt   time.Time                           `gorm:"column:exported_at;type:timestamptz;not null" json:"exported_at" validate:"required"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+}
@@ -1,26 +1,71 @@
 package credential
 
 import (
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"context"
+	"time"
 )
 
 type IVerifiableCredentialRepository interface {
 	FindVerifiableCredentialByPublicId(ctx context.Context, publicId string) (*VerifiableCredential, error)
 	FindVerifiableCredentialByCredentialId(ctx context.Context, id string) (*VerifiableCredential, error)
-	FindAllVerifiableCredentialsByHolderDID(ctx context.Context, did string) ([]*VerifiableCredential, error)
-	FindAllVerifiableCredentialsByIssuerDID(ctx context.Context, did string) ([]*VerifiableCredential, error)
+	FindVerifiableCredentialById(ctx context.Context, id uint) (*VerifiableCredential, error)
+	LockVerifiableCredential(ctx context.Context, id uint) (*VerifiableCredential, error)
+	FindAllVerifiableCredentialsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*VerifiableCredential, int64, error)
+	FindAllVerifiableCredentialsForExport(ctx context.Context, holderDID string) ([]*VerifiableCredential, error)
+	FindAllVerifiableCredentialsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*VerifiableCredential, int64, error)
 	CreateVerifiableCredential(ctx context.Context, entity *VerifiableCredential) (*VerifiableCredential, error)
 	SaveVerifiableCredential(ctx context.Context, entity *VerifiableCredential) (*VerifiableCredential, error)
 	UpdateVerifiableCredential(ctx context.Context, entity *VerifiableCredential, changes map[string]interface{}) error
+	FlagVerifiableCredentialsForReissue(ctx context.Context, holderDID string, documentType constant.DocumentType) (int64, error)
+	LockIssuedVerifiableCredentialsByDocument(ctx context.Context, holderDID string, documentType constant.DocumentType) ([]*VerifiableCredential, error)
 }
 
 type ICredentialRequestRepository interface {
 	FindCredentialRequestByPublicId(ctx context.Context, publicId string) (*CredentialRequest, error)
 	FindCredentialRequestByThreadId(ctx context.Context, threadId string) (*CredentialRequest, error)
-	FindAllCredentialRequests(ctx context.Context) ([]*CredentialRequest, error)
-	FindAllCredentialRequestsByIssuerDID(ctx context.Context, did string) ([]*CredentialRequest, error)
-	FindAllCredentialRequestsByHolderDID(ctx context.Context, did string) ([]*CredentialRequest, error)
+	FindCredentialRequestsByPublicIds(ctx context.Context, publicIds []string) ([]*CredentialRequest, error)
+	FindAllCredentialRequestsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*CredentialRequest, int64, error)
+	FindAllCredentialRequestsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*CredentialRequest, int64, error)
 	CreateCredentialRequest(ctx context.Context, entity *CredentialRequest) (*CredentialRequest, error)
 	SaveCredentialRequest(ctx context.Context, entity *CredentialRequest) (*CredentialRequest, error)
 	UpdateCredentialRequest(ctx context.Context, entity *CredentialRequest, changes map[string]interface{}) error
+	LockCredentialRequest(ctx context.Context, id uint) (*CredentialRequest, error)
+	ExistsOpenRefreshRequest(ctx context.Context, vcID uint) (bool, error)
+}
+
+type ICredentialReviewRepository interface {
+	FindAllCredentialRequestReviews(ctx context.Context, crid uint) ([]*CredentialRequestReview, error)
+	CreateCredentialRequestReview(ctx context.Context, entity *CredentialRequestReview) (*CredentialRequestReview, error)
+	FindReviewPolicyByIssuerDID(ctx context.Context, issuerDID string) (*CredentialReviewPolicy, error)
+	SaveReviewPolicy(ctx context.Context, entity *CredentialReviewPolicy) (*CredentialReviewPolicy, error)
+}
+
+type IAutoApprovalRuleRepository interface {
+	FindAutoApprovalRuleByPublicId(ctx context.Context, publicId string) (*AutoApprovalRule, error)
+	FindAllAutoApprovalRulesByIssuerDID(ctx context.Context, did string) ([]*AutoApprovalRule, error)
+	FindAllEnabledAutoApprovalRules(ctx context.Context) ([]*AutoApprovalRule, error)
+	ExistsAutoApprovalRuleBySchemaID(ctx context.Context, schemaID uint) (bool, error)
+	CreateAutoApprovalRule(ctx context.Context, entity *AutoApprovalRule) (*AutoApprovalRule, error)
+	UpdateAutoApprovalRule(ctx context.Context, entity *AutoApprovalRule, changes map[string]interface{}) error
+	FindUndecidedCredentialRequests(ctx context.Context, rule *AutoApprovalRule, limit int) ([]*CredentialRequest, error)
+	FindAllAutoApprovalDecisions(ctx context.Context, ruleID uint, spec *helper.QuerySpec) ([]*AutoApprovalDecision, int64, error)
+	CreateAutoApprovalDecision(ctx context.Context, entity *AutoApprovalDecision) (*AutoApprovalDecision, error)
+}
+
+type IImportedCredentialRepository interface {
+	FindAllImportedCredentialsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*ImportedCredential, int64, error)
+	ExistsImportedCredential(ctx context.Context, holderDID string, credentialID string) (bool, error)
+	CreateImportedCredential(ctx context.Context, entity *ImportedCredential) (*ImportedCredential, error)
+}
+
+type IIssuanceBatchRepository interface {
+	FindIssuanceBatchByPublicId(ctx context.Context, publicId string) (*IssuanceBatch, error)
+	ExistsActiveIssuanceBatch(ctx context.Context, issuerDID string) (bool, error)
+	CreateIssuanceBatch(ctx context.Context, entity *IssuanceBatch) (*IssuanceBatch, error)
+	UpdateIssuanceBatch(ctx context.Context, entity *IssuanceBatch, changes map[string]interface{}) error
+	UpdateIssuanceBatchItem(ctx context.Context, entity *IssuanceBatchItem, changes map[string]interface{}) error
+	TouchIssuanceBatch(ctx context.Context, id uint) error
+	FailStaleIssuanceBatches(ctx context.Context, before time.Time, reason string) (int64, error)
 }
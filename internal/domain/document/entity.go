@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/datatypes"
 )
 
 type CitizenIdentity struct {
@@ -17,6 +18,7 @@ type CitizenIdentity struct {
 	DateOfBirth      int64                   `gorm:"column:date_of_birth;type:bigint;not null" json:"date_of_birth" validate:"required"`
 	PlaceOfBirth     string                  `gorm:"column:place_of_birth;type:text;not null" json:"place_of_birth" validate:"required,max=255"`
 	Status           constant.DocumentStatus `gorm:"column:status;type:varchar(30);default:'active'" json:"status" validate:"required"`
+	Revision         int                     `gorm:"column:revision;not null;default:1" json:"revision" validate:"-"`
 	IssueDate        int64                   `gorm:"column:issue_date;type:bigint;not null" json:"issue_date" validate:"required"`
 	ExpiryDate       int64                   `gorm:"column:expiry_date;type:bigint;not null" json:"expiry_date" validate:"required,gtefield=IssueDate"`
 	HolderDID        string                  `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
@@ -46,6 +48,7 @@ type AcademicDegree struct {
 	GPA            float32                 `gorm:"column:gpa;type:decimal(4,2);not null" json:"gpa" validate:"required,gte=0,lte=4"`
 	Classification string                  `gorm:"column:classification;type:varchar(50);not null" json:"classification" validate:"required"`
 	Status         constant.DocumentStatus `gorm:"column:status;type:varchar(30);default:'active'" json:"status" validate:"required"`
+	Revision       int                     `gorm:"column:revision;not null;default:1" json:"revision" validate:"-"`
 	IssueDate      int64                   `gorm:"column:issue_date;type:bigint;not null" json:"issue_date" validate:"required"`
 	HolderDID      string                  `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
 	IssuerDID      string                  `gorm:"column:issuer_did;type:varchar(255);index;not null" json:"issuer_did" validate:"required,startswith=did:"`
@@ -67,6 +70,7 @@ type HealthInsurance struct {
 	InsuranceType   string                  `gorm:"column:insurance_type;type:varchar(100);not null" json:"insurance_type" validate:"required,max=100"`
 	Hospital        string                  `gorm:"column:hospital;type:varchar(255);not null" json:"hospital" validate:"required,max=255"`
 	Status          constant.DocumentStatus `gorm:"column:status;type:varchar(30);default:'active'" json:"status" validate:"required"`
+	Revision        int                     `gorm:"column:revision;not null;default:1" json:"revision" validate:"-"`
 	StartDate       int64                   `gorm:"column:start_date;type:bigint;not null" json:"start_date" validate:"required"`
 	ExpiryDate      int64                   `gorm:"column:expiry_date;type:bigint;not null" json:"expiry_date" validate:"required,gtefield=StartDate"`
 	HolderDID       string                  `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
@@ -89,6 +93,8 @@ type DriverLicense struct {
 	Class         string                  `gorm:"column:class;type:varchar(20);not null" json:"class" validate:"required"`
 	Point         uint                    `gorm:"column:point;type:smallint;default:12" json:"point" validate:"gte=0,lte=12"`
 	Status        constant.DocumentStatus `gorm:"column:status;type:varchar(30);default:'active'" json:"status" validate:"required"`
+	Revision      int                     `gorm:"column:revision;not null;default:1" json:"revision" validate:"-"`
+	SupersededBy  *uuid.UUID              `gorm:"column:superseded_by;type:uuid" json:"superseded_by,omitempty" validate:"-"`
 	IssueDate     int64                   `gorm:"column:issue_date;type:bigint;not null" json:"issue_date" validate:"required"`
 	ExpiryDate    int64                   `gorm:"column:expiry_date;type:bigint;not null" json:"expiry_date" validate:"required,gtefield=IssueDate"`
 	HolderDID     string                  `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
@@ -103,6 +109,28 @@ func (DriverLicense) TableName() string {
 	return "driver_licenses"
 }
 
+// DriverLicensePointEntry is one change of a licence's points. Deductions with a RestoreAt are given back
+// automatically once due, which sets RestoredAt and adds a matching restoration entry.
+type DriverLicensePointEntry struct {
+	ID         uint                           `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID   uuid.UUID                      `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	LicenseID  uint                           `gorm:"column:license_id;not null;index" json:"license_id" validate:"required"`
+	Kind       constant.LicensePointEntryKind `gorm:"column:kind;type:varchar(20);not null" json:"kind" validate:"required"`
+	Points     int                            `gorm:"column:points;not null" json:"points" validate:"gte=-12,lte=12"`
+	Balance    int                            `gorm:"column:balance;not null" json:"balance" validate:"gte=0,lte=12"`
+	Reason     string                         `gorm:"column:reason;type:text;not null" json:"reason" validate:"required,max=1000"`
+	Reference  string                         `gorm:"column:reference;type:varchar(100)" json:"reference,omitempty" validate:"max=100"`
+	RestoreAt  *time.Time                     `gorm:"column:restore_at;type:timestamptz" json:"restore_at,omitempty" validate:"omitempty"`
+	RestoredAt *time.Time                     `gorm:"column:restored_at;type:timestamptz" json:"restored_at,omitempty" validate:"omitempty"`
+	CreatedBy  string                         `gorm:"column:created_by;type:varchar(255);not null" json:"created_by" validate:"required,startswith=did:"`
+	CreatedAt  time.Time                      `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	License    *DriverLicense                 `gorm:"foreignKey:LicenseID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"license,omitempty"`
+}
+
+func (DriverLicensePointEntry) TableName() string {
+	return "driver_license_point_entries"
+}
+
 type Passport struct {
 	ID             uint                    `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
 	PublicID       uuid.UUID               `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
@@ -110,8 +138,10 @@ type Passport struct {
 	PassportNumber string                  `gorm:"column:passport_number;type:varchar(15);uniqueIndex" json:"passport_number" validate:"required,max=15,alphanum"`
 	PassportType   constant.PassportType   `gorm:"column:passport_type;type:varchar(100);not null" json:"passport_type" validate:"required"`
 	Nationality    string                  `gorm:"column:nationality;type:char(3);not null" json:"nationality" validate:"required,len=3,alpha"`
-	MRZ            string                  `gorm:"column:mrz;type:text;not null" json:"mrz" validate:"required,min=72,max=90"`
+	MRZ            string                  `gorm:"column:mrz;type:text;not null" json:"mrz" validate:"omitempty,min=88,max=89"`
 	Status         constant.DocumentStatus `gorm:"column:status;type:varchar(30);default:'active'" json:"status" validate:"required"`
+	Revision       int                     `gorm:"column:revision;not null;default:1" json:"revision" validate:"-"`
+	SupersededBy   *uuid.UUID              `gorm:"column:superseded_by;type:uuid" json:"superseded_by,omitempty" validate:"-"`
 	IssueDate      int64                   `gorm:"column:issue_date;type:bigint;not null" json:"issue_date" validate:"required"`
 	ExpiryDate     int64                   `gorm:"column:expiry_date;type:bigint;not null" json:"expiry_date" validate:"required,gtefield=IssueDate"`
 	HolderDID      string                  `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
@@ -125,3 +155,86 @@ type Passport struct {
 func (Passport) TableName() string {
 	return "passports"
 }
+
+// CorrectionRequest is a holder's proposal to change one field of a document issued to them. The issuer of the
+// document approves it, which applies a new revision, or rejects it.
+type CorrectionRequest struct {
+	ID               uint                             `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID         uuid.UUID                        `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	DocumentType     constant.DocumentType            `gorm:"column:document_type;type:varchar(50);not null" json:"document_type" validate:"required"`
+	DocumentPublicID uuid.UUID                        `gorm:"column:document_public_id;type:uuid;not null;index" json:"document_public_id" validate:"required"`
+	Field            string                           `gorm:"column:field;type:varchar(100);not null" json:"field" validate:"required,max=100"`
+	CurrentValue     string                           `gorm:"column:current_value;type:text" json:"current_value"`
+	ProposedValue    string                           `gorm:"column:proposed_value;type:text;not null" json:"proposed_value" validate:"required"`
+	Evidence         string                           `gorm:"column:evidence;type:text" json:"evidence,omitempty" validate:"max=2000"`
+	Status           constant.CorrectionRequestStatus `gorm:"column:status;type:varchar(20);default:'pending'" json:"status" validate:"required"`
+	ReviewNote       string                           `gorm:"column:review_note;type:text" json:"review_note,omitempty"`
+	HolderDID        string                           `gorm:"column:holder_did;type:varchar(255);index;not null" json:"holder_did" validate:"required,startswith=did:"`
+	IssuerDID        string                           `gorm:"column:issuer_did;type:varchar(255);index;not null" json:"issuer_did" validate:"required,startswith=did:"`
+	ReviewedBy       string                           `gorm:"column:reviewed_by;type:varchar(255)" json:"reviewed_by,omitempty"`
+	CreatedAt        time.Time                        `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt        time.Time                        `gorm:"autoUpdateTime" json:"updated_at" validate:"-"`
+	ReviewedAt       *time.Time                       `gorm:"type:timestamptz" json:"reviewed_at,omitempty" validate:"omitempty"`
+}
+
+func (CorrectionRequest) TableName() string {
+	return "correction_requests"
+}
+
+// DocumentNumber reserves a document number so that concurrent issuers never hand out the same one.
+type DocumentNumber struct {
+	DocumentType constant.DocumentType `gorm:"column:document_type;type:varchar(50);primaryKey" json:"document_type"`
+	Number       string                `gorm:"column:number;type:varchar(50);primaryKey" json:"number"`
+	CreatedAt    time.Time             `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (DocumentNumber) TableName() string {
+	return "document_numbers"
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportJob struct {
+	ID            uint                                `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID      uuid.UUID                           `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IssuerDID     string                              `gorm:"column:issuer_did;type:varchar(255);index;not null" json:"issuer_did" validate:"required,startswith=did:"`
+	DocumentType  constant.DocumentType               `gorm:"column:document_type;type:varchar(50);not null" json:"document_type" validate:"required"`
+	FileName      string                              `gorm:"column:file_name;type:varchar(255);not null" json:"file_name" validate:"required"`
+	Status        constant.ImportJobStatus            `gorm:"column:status;type:varchar(20);default:'pending'" json:"status" validate:"required"`
+	TotalRows     int                                 `gorm:"column:total_rows;not null;default:0" json:"total_rows"`
+	ProcessedRows int                                 `gorm:"column:processed_rows;not null;default:0" json:"processed_rows"`
+	CreatedRows   int                                 `gorm:"column:created_rows;not null;default:0" json:"created_rows"`
+	UpdatedRows   int                                 `gorm:"column:updated_rows;not null;default:0" json:"updated_rows"`
+	FailedRows    int                                 `gorm:"column:failed_rows;not null;default:0" json:"failed_rows"`
+	Errors        datatypes.JSONSlice[ImportRowError] `gorm:"column:errors;type:jsonb" json:"errors,omitempty"`
+	CreatedAt     time.Time                           `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt     time.Time                           `gorm:"autoUpdateTime" json:"updated_at" validate:"-"`
+	CompletedAt   *time.Time                          `gorm:"type:timestamptz" json:"completed_at,omitempty" validate:"omitempty"`
+}
+
+func (ImportJob) TableName() string {
+	return "import_jobs"
+}
+
+// DocumentRevision is one entry of a document's history. Snapshot holds the document as saved by the revision
+// and Changes the fields that differ from the previous revision.
+type DocumentRevision struct {
+	ID           uint                            `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID     uuid.UUID                       `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	DocumentType constant.DocumentType           `gorm:"column:document_type;type:varchar(50);not null" json:"document_type" validate:"required"`
+	DocumentID   uint                            `gorm:"column:document_id;not null;index" json:"document_id" validate:"required"`
+	Revision     int                             `gorm:"column:revision;not null" json:"revision" validate:"required,gt=0"`
+	Action       constant.DocumentRevisionAction `gorm:"column:action;type:varchar(20);not null" json:"action" validate:"required"`
+	Changes      datatypes.JSONMap               `gorm:"column:changes;type:jsonb" json:"changes,omitempty"`
+	Snapshot     datatypes.JSONMap               `gorm:"column:snapshot;type:jsonb;not null" json:"snapshot" validate:"required"`
+	ChangedBy    string                          `gorm:"column:changed_by;type:varchar(255);not null" json:"changed_by" validate:"required,startswith=did:"`
+	CreatedAt    time.Time                       `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+}
+
+func (DocumentRevision) TableName() string {
+	return "document_revisions"
+}
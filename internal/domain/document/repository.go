@@ -1,14 +1,19 @@
 package document
 
 import (
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"context"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type ICitizenIdentityRepository interface {
 	FindCitizenIdentityByPublicId(ctx context.Context, publicId string) (*CitizenIdentity, error)
 	FindCitizenIdentityByIdNumber(ctx context.Context, idNumber string) (*CitizenIdentity, error)
 	FindCitizenIdentityByHolderDID(ctx context.Context, holderDID string) (*CitizenIdentity, error)
-	FindAllCitizenIdentities(ctx context.Context) ([]*CitizenIdentity, error)
+	FindAllCitizenIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*CitizenIdentity, int64, error)
 	CreateCitizenIdentity(ctx context.Context, entity *CitizenIdentity) (*CitizenIdentity, error)
 	SaveCitizenIdentity(ctx context.Context, entity *CitizenIdentity) (*CitizenIdentity, error)
 	UpdateCitizenIdentity(ctx context.Context, entity *CitizenIdentity, changes map[string]interface{}) error
@@ -17,7 +22,7 @@ type IAcademicDegreeRepository interface {
 	FindAcademicDegreeByPublicId(ctx context.Context, publicId string) (*AcademicDegree, error)
 	FindAcademicDegreeByDegreeNumber(ctx context.Context, degreeNumber string) (*AcademicDegree, error)
 	FindAcademicDegreeByHolderDID(ctx context.Context, holderDID string) (*AcademicDegree, error)
-	FindAllAcademicDegrees(ctx context.Context) ([]*AcademicDegree, error)
+	FindAllAcademicDegrees(ctx context.Context, spec *helper.QuerySpec) ([]*AcademicDegree, int64, error)
 	CreateAcademicDegree(ctx context.Context, entity *AcademicDegree) (*AcademicDegree, error)
 	SaveAcademicDegree(ctx context.Context, entity *AcademicDegree) (*AcademicDegree, error)
 	UpdateAcademicDegree(ctx context.Context, entity *AcademicDegree, changes map[string]interface{}) error
@@ -27,7 +32,7 @@ type IHealthInsuranceRepository interface {
 	FindHealthInsuranceByPublicId(ctx context.Context, publicId string) (*HealthInsurance, error)
 	FindHealthInsuranceByInsuranceNumber(ctx context.Context, insuranceNumber string) (*HealthInsurance, error)
 	FindHealthInsuranceByHolderDID(ctx context.Context, holderDID string) (*HealthInsurance, error)
-	FindAllHealthInsurances(ctx context.Context) ([]*HealthInsurance, error)
+	FindAllHealthInsurances(ctx context.Context, spec *helper.QuerySpec) ([]*HealthInsurance, int64, error)
 	CreateHealthInsurance(ctx context.Context, entity *HealthInsurance) (*HealthInsurance, error)
 	SaveHealthInsurance(ctx context.Context, entity *HealthInsurance) (*HealthInsurance, error)
 	UpdateHealthInsurance(ctx context.Context, entity *HealthInsurance, changes map[string]interface{}) error
@@ -37,18 +42,52 @@ type IDriverLicenseRepository interface {
 	FindDriverLicenseByPublicId(ctx context.Context, publicId string) (*DriverLicense, error)
 	FindDriverLicenseByLicenseId(ctx context.Context, licenseNumber string) (*DriverLicense, error)
 	FindDriverLicenseByHolderDID(ctx context.Context, holderDID string) (*DriverLicense, error)
-	FindAllDriverLicenses(ctx context.Context) ([]*DriverLicense, error)
+	FindAllDriverLicenses(ctx context.Context, spec *helper.QuerySpec) ([]*DriverLicense, int64, error)
 	CreateDriverLicense(ctx context.Context, entity *DriverLicense) (*DriverLicense, error)
 	SaveDriverLicense(ctx context.Context, entity *DriverLicense) (*DriverLicense, error)
 	UpdateDriverLicense(ctx context.Context, entity *DriverLicense, changes map[string]interface{}) error
+	LockDriverLicense(ctx context.Context, id uint) (*DriverLicense, error)
+}
+
+type IDriverLicensePointRepository interface {
+	FindAllPointEntriesByLicenseID(ctx context.Context, licenseID uint, spec *helper.QuerySpec) ([]*DriverLicensePointEntry, int64, error)
+	FindDuePointEntries(ctx context.Context, now time.Time, limit int) ([]*DriverLicensePointEntry, error)
+	CreatePointEntry(ctx context.Context, entity *DriverLicensePointEntry) (*DriverLicensePointEntry, error)
+	MarkPointEntryRestored(ctx context.Context, entity *DriverLicensePointEntry, restoredAt time.Time) (bool, error)
 }
 
 type IPassportRepository interface {
 	FindPassportByPublicId(ctx context.Context, publicId string) (*Passport, error)
 	FindPassportByPassportNumber(ctx context.Context, passportNumber string) (*Passport, error)
 	FindPassportByHolderDID(ctx context.Context, holderDID string) (*Passport, error)
-	FindAllPassports(ctx context.Context) ([]*Passport, error)
+	FindAllPassports(ctx context.Context, spec *helper.QuerySpec) ([]*Passport, int64, error)
 	CreatePassport(ctx context.Context, entity *Passport) (*Passport, error)
 	SavePassport(ctx context.Context, entity *Passport) (*Passport, error)
 	UpdatePassport(ctx context.Context, entity *Passport, changes map[string]interface{}) error
 }
+
+type IImportJobRepository interface {
+	FindImportJobByPublicId(ctx context.Context, publicId string) (*ImportJob, error)
+	CreateImportJob(ctx context.Context, entity *ImportJob) (*ImportJob, error)
+	UpdateImportJob(ctx context.Context, entity *ImportJob, changes map[string]interface{}) error
+	FailStaleImportJobs(ctx context.Context, before time.Time, reason string) (int64, error)
+}
+
+type IDocumentRevisionRepository interface {
+	FindAllDocumentRevisions(ctx context.Context, documentType constant.DocumentType, documentID uint, spec *helper.QuerySpec) ([]*DocumentRevision, int64, error)
+	CreateDocumentRevision(ctx context.Context, entity *DocumentRevision) (*DocumentRevision, error)
+	LockDocumentRevision(ctx context.Context, documentType constant.DocumentType, documentID uint) (int, error)
+}
+
+type IDocumentNumberRepository interface {
+	ReserveDocumentNumber(ctx context.Context, documentType constant.DocumentType, number string) (bool, error)
+}
+
+type ICorrectionRequestRepository interface {
+	FindCorrectionRequestByPublicId(ctx context.Context, publicId string) (*CorrectionRequest, error)
+	FindAllCorrectionRequestsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*CorrectionRequest, int64, error)
+	FindAllCorrectionRequestsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*CorrectionRequest, int64, error)
+	ExistsPendingCorrectionRequest(ctx context.Context, documentPublicID uuid.UUID, field string) (bool, error)
+	CreateCorrectionRequest(ctx context.Context, entity *CorrectionRequest) (*CorrectionRequest, error)
+	ReviewPendingCorrectionRequest(ctx context.Context, entity *CorrectionRequest, changes map[string]interface{}) (bool, error)
+}
@@ -4,4 +4,5 @@ import "context"
 
 type IStateTransition interface {
 	CreateStateTransition(ctx context.Context, entity *StateTransition) (*StateTransition, error)
+	ExistsStateTransition(ctx context.Context, identityID uint, state string) (bool, error)
 }
@@ -0,0 +1,25 @@
+package notification
+
+import (
+	"be/internal/shared/constant"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Notification tells an identity about something that happened to its documents or credentials.
+// Reference holds the public id of the record the notification is about.
+type Notification struct {
+	ID           uint                      `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID     uuid.UUID                 `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	RecipientDID string                    `gorm:"column:recipient_did;type:varchar(255);index;not null" json:"recipient_did" validate:"required,startswith=did:"`
+	Kind         constant.NotificationKind `gorm:"column:kind;type:varchar(50);not null" json:"kind" validate:"required"`
+	Message      string                    `gorm:"column:message;type:text;not null" json:"message" validate:"required"`
+	Reference    string                    `gorm:"column:reference;type:varchar(100)" json:"reference,omitempty"`
+	CreatedAt    time.Time                 `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	ReadAt       *time.Time                `gorm:"type:timestamptz" json:"read_at,omitempty" validate:"omitempty"`
+}
+
+func (Notification) TableName() string {
+	return "notifications"
+}
@@ -0,0 +1,13 @@
+package notification
+
+import (
+	"be/internal/shared/helper"
+	"context"
+)
+
+type INotificationRepository interface {
+	FindNotificationByPublicId(ctx context.Context, publicId string) (*Notification, error)
+	FindAllNotificationsByRecipientDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*Notification, int64, error)
+	CreateNotification(ctx context.Context, entity *Notification) (*Notification, error)
+	UpdateNotification(ctx context.Context, entity *Notification, changes map[string]interface{}) error
+}
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"be/internal/shared/constant"
+	"slices"
 	"time"
 
 	"gorm.io/datatypes"
@@ -23,6 +24,106 @@ type Identity struct {
 	RootsMTID  uint64                `gorm:"column:roots_mt_id;index;not null" json:"roots_mt_id" validate:"required"`
 	CreatedAt  time.Time             `gorm:"autoCreateTime" json:"created_at" validate:"-"`
 	UpdatedAt  time.Time             `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+	// SuspendedAt is set while an operator has suspended the identity; it cannot sign in until reinstated.
+	SuspendedAt   *time.Time `gorm:"column:suspended_at" json:"suspended_at,omitempty" validate:"-"`
+	SuspendedBy   string     `gorm:"column:suspended_by;type:varchar(255)" json:"suspended_by,omitempty" validate:"-"`
+	SuspendReason string     `gorm:"column:suspend_reason;type:text" json:"suspend_reason,omitempty" validate:"-"`
+	// Roles holds the role grants loaded with the identity; repositories load only the active ones.
+	Roles []*IdentityRoleGrant `gorm:"foreignKey:IdentityID" json:"roles,omitempty" validate:"-"`
+}
+
+// ActiveRoles lists the roles the identity holds now. Role is only the role it registered for.
+func (i *Identity) ActiveRoles() []constant.IdentityRole {
+	roles := make([]constant.IdentityRole, 0, len(i.Roles))
+	for _, grant := range i.Roles {
+		if grant.RevokedAt == nil {
+			roles = append(roles, grant.Role)
+		}
+	}
+	return roles
+}
+
+// ActingRole is the role the identity acts in: the role it registered for once granted, holder until then.
+func (i *Identity) ActingRole() constant.IdentityRole {
+	active := i.ActiveRoles()
+	if slices.Contains(active, i.Role) {
+		return i.Role
+	}
+	if slices.Contains(active, constant.IdentityHolderRole) || len(active) == 0 {
+		return constant.IdentityHolderRole
+	}
+	return active[0]
+}
+
+// IdentityRoleGrant is one grant of a role to an identity. Revoking keeps the row, so the table is the history of
+// who held which role, how they got it and why they lost it.
+type IdentityRoleGrant struct {
+	ID            uint                     `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID      uuid.UUID                `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IdentityID    uint                     `gorm:"column:identity_id;not null;index" json:"identity_id" validate:"required"`
+	DID           string                   `gorm:"column:did;type:varchar(255);index;not null" json:"did" validate:"required,startswith=did:"`
+	Role          constant.IdentityRole    `gorm:"column:role;type:varchar(100);not null" json:"role" validate:"required"`
+	Method        constant.RoleGrantMethod `gorm:"column:method;type:varchar(50);not null" json:"method" validate:"required"`
+	ApplicationID *uint                    `gorm:"column:application_id" json:"application_id,omitempty" validate:"-"`
+	GrantedBy     string                   `gorm:"column:granted_by;type:varchar(255);not null" json:"granted_by" validate:"required"`
+	GrantedAt     time.Time                `gorm:"column:granted_at;not null" json:"granted_at" validate:"required"`
+	RevokedBy     string                   `gorm:"column:revoked_by;type:varchar(255)" json:"revoked_by,omitempty"`
+	RevokeReason  string                   `gorm:"column:revoke_reason;type:text" json:"revoke_reason,omitempty"`
+	RevokedAt     *time.Time               `gorm:"type:timestamptz" json:"revoked_at,omitempty" validate:"omitempty"`
+}
+
+func (IdentityRoleGrant) TableName() string {
+	return "identity_roles"
+}
+
+// IdentityAPIKey lets the backend of a verifier call the API without a ZK login. Only the SHA-256 of the key is
+// stored; Prefix is kept in clear so owners can tell their keys apart.
+type IdentityAPIKey struct {
+	ID         uint                        `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID   uuid.UUID                   `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IdentityID uint                        `gorm:"column:identity_id;not null;index" json:"identity_id" validate:"required"`
+	DID        string                      `gorm:"column:did;type:varchar(255);index;not null" json:"did" validate:"required,startswith=did:"`
+	Name       string                      `gorm:"column:name;type:varchar(255);not null" json:"name" validate:"required,max=255"`
+	Prefix     string                      `gorm:"column:prefix;type:varchar(20);not null" json:"prefix" validate:"required"`
+	KeyHash    string                      `gorm:"column:key_hash;type:varchar(64);uniqueIndex;not null" json:"-" validate:"required,len=64"`
+	Scopes     datatypes.JSONSlice[string] `gorm:"column:scopes;type:jsonb;not null" json:"scopes" validate:"required"`
+	RateLimit  int                         `gorm:"column:rate_limit;not null" json:"rate_limit" validate:"required,min=1"`
+	LastUsedAt *time.Time                  `gorm:"type:timestamptz" json:"last_used_at,omitempty" validate:"-"`
+	LastUsedIP string                      `gorm:"column:last_used_ip;type:varchar(64)" json:"last_used_ip,omitempty" validate:"-"`
+	ExpiresAt  *time.Time                  `gorm:"type:timestamptz" json:"expires_at,omitempty" validate:"-"`
+	CreatedAt  time.Time                   `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	RevokedAt  *time.Time                  `gorm:"type:timestamptz" json:"revoked_at,omitempty" validate:"-"`
+}
+
+func (IdentityAPIKey) TableName() string {
+	return "identity_api_keys"
+}
+
+// HasScope reports whether the key was created with scope.
+func (k *IdentityAPIKey) HasScope(scope constant.APIKeyScope) bool {
+	return slices.Contains(k.Scopes, string(scope))
+}
+
+// RoleApplication asks for the issuer or verifier role. It is approved by an admin, or on the spot when it comes
+// with a valid accreditation credential from a root issuer.
+type RoleApplication struct {
+	ID         uint                           `gorm:"primaryKey;autoIncrement" json:"id,omitempty" validate:"-"`
+	PublicID   uuid.UUID                      `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	IdentityID uint                           `gorm:"column:identity_id;not null;index" json:"identity_id" validate:"required"`
+	DID        string                         `gorm:"column:did;type:varchar(255);index;not null" json:"did" validate:"required,startswith=did:"`
+	Role       constant.IdentityRole          `gorm:"column:role;type:varchar(100);not null" json:"role" validate:"required"`
+	Status     constant.RoleApplicationStatus `gorm:"column:status;type:varchar(20);default:'pending'" json:"status" validate:"required"`
+	Note       string                         `gorm:"column:note;type:text" json:"note,omitempty" validate:"max=2000"`
+	Credential datatypes.JSONMap              `gorm:"column:credential;type:jsonb" json:"credential,omitempty"`
+	ReviewNote string                         `gorm:"column:review_note;type:text" json:"review_note,omitempty"`
+	ReviewedBy string                         `gorm:"column:reviewed_by;type:varchar(255)" json:"reviewed_by,omitempty"`
+	CreatedAt  time.Time                      `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt  time.Time                      `gorm:"autoUpdateTime" json:"updated_at" validate:"-"`
+	ReviewedAt *time.Time                     `gorm:"type:timestamptz" json:"reviewed_at,omitempty" validate:"omitempty"`
+}
+
+func (RoleApplication) TableName() string {
+	return "role_applications"
 }
 
 type Schema struct {
@@ -50,24 +151,27 @@ type Schema struct {
 }
 
 type SchemaAttribute struct {
-	ID          uint                   `gorm:"primaryKey;autoIncrement" json:"id" swagger:"-"`
-	PublicID    uuid.UUID              `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
-	SchemaID    uint                   `gorm:"column:schema_id;index;not null" json:"-" validate:"required"`
-	Name        string                 `gorm:"column:name;type:varchar(128);not null" json:"name" validate:"required,max=128"`
-	Title       string                 `gorm:"column:title;type:varchar(255);not null" json:"title" validate:"required,max=255"`
-	Type        constant.AttributeType `gorm:"column:type;type:varchar(64);not null" json:"type" validate:"required"`
-	Description string                 `gorm:"column:description;type:text;not null" json:"description" validate:"required,max=1000"`
-	Required    bool                   `gorm:"column:required;default:false" json:"required"`
-	Slot        constant.Slot          `gorm:"column:slot;type:varchar(64)" json:"slot,omitempty" validate:"omitempty"`
-	Format      string                 `gorm:"type:varchar(64)" json:"format,omitempty" validate:"omitempty,oneof=date date-time time uri email duration ipv4 ipv6 hostname"`
-	Pattern     string                 `gorm:"type:varchar(255)" json:"pattern,omitempty"`
-	MinLength   *int                   `gorm:"column:min_length" json:"min_length,omitempty"`
-	MaxLength   *int                   `gorm:"column:max_length" json:"max_length,omitempty"`
-	Minimum     *float64               `gorm:"column:minimum" json:"minimum,omitempty"`
-	Maximum     *float64               `gorm:"column:maximum" json:"maximum,omitempty"`
-	Enum        datatypes.JSONMap      `gorm:"type:jsonb" json:"enum,omitempty"`
-	CreatedAt   time.Time              `gorm:"autoCreateTime" json:"created_at" validate:"-"`
-	UpdatedAt   time.Time              `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
-	RevokedAt   *time.Time             `gorm:"type:timestamptz" json:"revoked_at,omitempty" validate:"omitempty"`
-	Schema      *Schema                `gorm:"foreignKey:SchemaID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"schema"`
+	ID            uint                      `gorm:"primaryKey;autoIncrement" json:"id" swagger:"-"`
+	PublicID      uuid.UUID                 `gorm:"column:public_id;type:uuid;uniqueIndex;default:gen_random_uuid()" json:"public_id" validate:"required"`
+	SchemaID      uint                      `gorm:"column:schema_id;index;not null" json:"-" validate:"required"`
+	Name          string                    `gorm:"column:name;type:varchar(128);not null" json:"name" validate:"required,max=128"`
+	Title         string                    `gorm:"column:title;type:varchar(255);not null" json:"title" validate:"required,max=255"`
+	Type          constant.AttributeType    `gorm:"column:type;type:varchar(64);not null" json:"type" validate:"required"`
+	Description   string                    `gorm:"column:description;type:text;not null" json:"description" validate:"required,max=1000"`
+	Required      bool                      `gorm:"column:required;default:false" json:"required"`
+	Slot          constant.Slot             `gorm:"column:slot;type:varchar(64)" json:"slot,omitempty" validate:"omitempty"`
+	Format        string                    `gorm:"type:varchar(64)" json:"format,omitempty" validate:"omitempty,oneof=date date-time time uri email duration ipv4 ipv6 hostname"`
+	Pattern       string                    `gorm:"type:varchar(255)" json:"pattern,omitempty"`
+	MinLength     *int                      `gorm:"column:min_length" json:"min_length,omitempty"`
+	MaxLength     *int                      `gorm:"column:max_length" json:"max_length,omitempty"`
+	Minimum       *float64                  `gorm:"column:minimum" json:"minimum,omitempty"`
+	Maximum       *float64                  `gorm:"column:maximum" json:"maximum,omitempty"`
+	Enum          datatypes.JSONMap         `gorm:"type:jsonb" json:"enum,omitempty"`
+	DocumentField string                    `gorm:"column:document_field;type:varchar(128)" json:"document_field,omitempty" validate:"omitempty,max=128"`
+	Transform     constant.MappingTransform `gorm:"column:transform;type:varchar(32);default:'none'" json:"transform,omitempty" validate:"omitempty"`
+	EnumCodes     datatypes.JSONMap         `gorm:"column:enum_codes;type:jsonb" json:"enum_codes,omitempty"`
+	CreatedAt     time.Time                 `gorm:"autoCreateTime" json:"created_at" validate:"-"`
+	UpdatedAt     time.Time                 `gorm:"autoUpdateTime" json:"updated_at,omitempty" validate:"-"`
+	RevokedAt     *time.Time                `gorm:"type:timestamptz" json:"revoked_at,omitempty" validate:"omitempty"`
+	Schema        *Schema                   `gorm:"foreignKey:SchemaID;constraint:OnUpdate:CASCADE,OnDelete:RESTRICT" json:"schema"`
 }
@@ -1,24 +1,70 @@
 package schema
 
 import (
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"context"
 )
 
+// SchemaFilter narrows a schema listing. Query is matched against title, description
+// and attribute names; Cursor is the id of the last schema of the previous page.
+type SchemaFilter struct {
+	IssuerDID    string
+	DocumentType constant.DocumentType
+	Status       constant.SchemaStatus
+	Type         string
+	IsMerklized  *bool
+	Query        string
+	Cursor       uint
+	Limit        int
+}
+
 type IIdentityRepository interface {
 	FindIdentityByPublicId(ctx context.Context, publicId string) (*Identity, error)
 	FindIdentityByDID(ctx context.Context, did string) (*Identity, error)
+	LockIdentityByDID(ctx context.Context, did string) (*Identity, error)
 	FindIdentityByPublicKey(ctx context.Context, publicKeyX, publicKeyY string) (*Identity, error)
 	FindIdentityByRole(ctx context.Context, role string) ([]*Identity, error)
-	FindAllIdentities(ctx context.Context) ([]*Identity, error)
+	FindAllIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*Identity, int64, error)
 	CreateIdentity(ctx context.Context, entity *Identity) (*Identity, error)
 	UpdateIdentity(ctx context.Context, entity *Identity, changes map[string]interface{}) error
 }
 
+type IIdentityRoleRepository interface {
+	FindRoleGrantByPublicId(ctx context.Context, publicId string) (*IdentityRoleGrant, error)
+	FindAllRoleGrants(ctx context.Context, spec *helper.QuerySpec) ([]*IdentityRoleGrant, int64, error)
+	FindAllRoleGrantsByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*IdentityRoleGrant, int64, error)
+	ExistsActiveRoleGrant(ctx context.Context, identityID uint, role constant.IdentityRole) (bool, error)
+	CreateRoleGrant(ctx context.Context, entity *IdentityRoleGrant) (*IdentityRoleGrant, error)
+	UpdateRoleGrant(ctx context.Context, entity *IdentityRoleGrant, changes map[string]interface{}) error
+}
+
+type IIdentityAPIKeyRepository interface {
+	FindAPIKeyByPublicId(ctx context.Context, publicId string) (*IdentityAPIKey, error)
+	FindAPIKeyByHash(ctx context.Context, keyHash string) (*IdentityAPIKey, error)
+	FindAllAPIKeysByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*IdentityAPIKey, int64, error)
+	CountActiveAPIKeys(ctx context.Context, identityID uint) (int64, error)
+	CreateAPIKey(ctx context.Context, entity *IdentityAPIKey) (*IdentityAPIKey, error)
+	UpdateAPIKey(ctx context.Context, entity *IdentityAPIKey, changes map[string]interface{}) error
+}
+
+type IRoleApplicationRepository interface {
+	FindRoleApplicationByPublicId(ctx context.Context, publicId string) (*RoleApplication, error)
+	LockRoleApplication(ctx context.Context, id uint) (*RoleApplication, error)
+	FindAllRoleApplications(ctx context.Context, spec *helper.QuerySpec) ([]*RoleApplication, int64, error)
+	FindAllRoleApplicationsByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*RoleApplication, int64, error)
+	ExistsPendingRoleApplication(ctx context.Context, identityID uint, role constant.IdentityRole) (bool, error)
+	CreateRoleApplication(ctx context.Context, entity *RoleApplication) (*RoleApplication, error)
+	UpdateRoleApplication(ctx context.Context, entity *RoleApplication, changes map[string]interface{}) error
+}
+
 type ISchemaRepository interface {
 	FindSchemaByPublicId(ctx context.Context, publicId string) (*Schema, error)
 	FindSchemaByHash(ctx context.Context, hash string) (*Schema, error)
 	FindSchemaByContextURL(ctx context.Context, hash string) (*Schema, error)
-	FindAllSchemas(ctx context.Context) ([]*Schema, error)
+	FindSchemasByIds(ctx context.Context, ids []uint) ([]*Schema, error)
+	FindSchemasAfterId(ctx context.Context, afterId uint, limit int) ([]*Schema, error)
+	SearchSchemas(ctx context.Context, filter *SchemaFilter) ([]*Schema, error)
 	CreateSchema(ctx context.Context, schema *Schema) (*Schema, error)
 	UpdateSchema(ctx context.Context, entity *Schema, changes map[string]interface{}) error
 }
@@ -27,4 +73,11 @@ type ISchemaAttributeRepository interface {
 	CreateSchemaAttributes(ctx context.Context, entities []*SchemaAttribute) ([]*SchemaAttribute, error)
 	FindSchemaAttributesBySchemaId(ctx context.Context, schemaId uint) ([]*SchemaAttribute, error)
 	UpdateAttributesBySchemaId(ctx context.Context, schemaId uint, change map[string]interface{}) error
+	UpdateSchemaAttribute(ctx context.Context, entity *SchemaAttribute, changes map[string]interface{}) error
+}
+
+type ISchemaSearchRepository interface {
+	IsAvailable() bool
+	IndexSchema(ctx context.Context, entity *Schema) error
+	SearchSchemaIds(ctx context.Context, filter *SchemaFilter) ([]uint, error)
 }
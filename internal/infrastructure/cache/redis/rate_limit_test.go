@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestCache connects to the Redis at REDIS_TEST_ADDR, since the sliding window runs as a Lua script inside Redis.
+func newTestCache(t *testing.T) *RedisCache {
+	t.Helper()
+	addr := os.Getenv("REDIS_TEST_ADDR")
+	if addr == "" {
+		t.Skip("REDIS_TEST_ADDR is not set")
+	}
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("redis at %s: %v", addr, err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return &RedisCache{client: client}
+}
+
+func TestAllowSlidingWindow(t *testing.T) {
+	cache := newTestCache(t)
+	ctx := context.Background()
+
+	type hit struct {
+		// wait is slept before the hit
+		wait          time.Duration
+		wantAllowed   bool
+		wantRemaining int
+	}
+	tests := []struct {
+		name   string
+		limit  int
+		window time.Duration
+		hits   []hit
+	}{
+		{name: "allows up to the limit", limit: 3, window: time.Minute, hits: []hit{
+			{wantAllowed: true, wantRemaining: 2},
+			{wantAllowed: true, wantRemaining: 1},
+			{wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+		}},
+		{name: "refused hits are not recorded", limit: 1, window: 300 * time.Millisecond, hits: []hit{
+			{wantAllowed: true, wantRemaining: 0},
+			{wait: 200 * time.Millisecond, wantAllowed: false, wantRemaining: 0},
+			// the refused hit above would still be in the window had it been recorded
+			{wait: 150 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+		}},
+		{name: "hits slide out of the window", limit: 2, window: 300 * time.Millisecond, hits: []hit{
+			{wantAllowed: true, wantRemaining: 1},
+			{wait: 200 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			{wait: 150 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			{wantAllowed: false, wantRemaining: 0},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := "test:ratelimit:" + uuid.NewString()
+			t.Cleanup(func() { _ = cache.Delete(ctx, key) })
+			for i, h := range tt.hits {
+				time.Sleep(h.wait)
+				result, err := cache.AllowSlidingWindow(ctx, key, tt.limit, tt.window)
+				if err != nil {
+					t.Fatalf("hit %d: %v", i, err)
+				}
+				if result.Allowed != h.wantAllowed || result.Remaining != h.wantRemaining || result.Limit != tt.limit {
+					t.Fatalf("hit %d = %+v, want allowed %v remaining %d", i, result, h.wantAllowed, h.wantRemaining)
+				}
+				if result.ResetAfter <= 0 || result.ResetAfter > tt.window {
+					t.Fatalf("hit %d reset after %s, want within (0, %s]", i, result.ResetAfter, tt.window)
+				}
+			}
+		})
+	}
+}
@@ -60,6 +60,38 @@ func (r *RedisCache) Delete(ctx context.Context, key string) error {
 	return r.client.Del(ctx, key).Err()
 }
 
+func (r *RedisCache) GetDel(ctx context.Context, key string) *redis.StringCmd {
+	return r.client.GetDel(ctx, key)
+}
+
+func (r *RedisCache) Incr(ctx context.Context, key string) (int64, error) {
+	return r.client.Incr(ctx, key).Result()
+}
+
+func (r *RedisCache) Expire(ctx context.Context, key string, expiration time.Duration) error {
+	return r.client.Expire(ctx, key, expiration).Err()
+}
+
+func (r *RedisCache) HSet(ctx context.Context, key string, values ...any) *redis.IntCmd {
+	return r.client.HSet(ctx, key, values...)
+}
+
+func (r *RedisCache) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.client.HGetAll(ctx, key).Result()
+}
+
+func (r *RedisCache) SAdd(ctx context.Context, key string, members ...any) error {
+	return r.client.SAdd(ctx, key, members...).Err()
+}
+
+func (r *RedisCache) SRem(ctx context.Context, key string, members ...any) error {
+	return r.client.SRem(ctx, key, members...).Err()
+}
+
+func (r *RedisCache) SMembers(ctx context.Context, key string) ([]string, error) {
+	return r.client.SMembers(ctx, key).Result()
+}
+
 func (r *RedisCache) Subscribe(ctx context.Context, channel string) (*redis.PubSub, error) {
 	return r.client.Subscribe(ctx, channel), nil
 }
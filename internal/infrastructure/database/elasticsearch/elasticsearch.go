@@ -3,9 +3,13 @@ package elasticsearch
 import (
 	"be/config"
 	"be/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"go.uber.org/zap"
 )
 
@@ -15,6 +19,11 @@ type ElasticsearchDB struct {
 }
 
 func NewDB(cfg *config.Config, logger *logger.ZapLogger) (*ElasticsearchDB, error) {
+	if !cfg.Elasticsearch.Enabled {
+		logger.Info("Elasticsearch is disabled")
+		return &ElasticsearchDB{logger: logger}, nil
+	}
+
 	client, err := elasticsearch.NewClient(elasticsearch.Config{
 		Addresses: cfg.GetElasticsearchAddress(),
 		Username:  cfg.Elasticsearch.Username,
@@ -45,3 +54,78 @@ func NewDB(cfg *config.Config, logger *logger.ZapLogger) (*ElasticsearchDB, erro
 func (es *ElasticsearchDB) Close() error {
 	return nil
 }
+
+type SearchHit struct {
+	ID     string          `json:"_id"`
+	Source json.RawMessage `json:"_source"`
+	Sort   []interface{}   `json:"sort"`
+}
+
+type SearchResult struct {
+	Total int64
+	Hits  []SearchHit
+}
+
+func (es *ElasticsearchDB) Enabled() bool {
+	return es != nil && es.client != nil
+}
+
+func (es *ElasticsearchDB) IndexDocument(ctx context.Context, index, id string, document interface{}) error {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return err
+	}
+
+	res, err := es.client.Index(index, bytes.NewReader(body),
+		es.client.Index.WithContext(ctx),
+		es.client.Index.WithDocumentID(id),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	return responseError(res)
+}
+
+func (es *ElasticsearchDB) Search(ctx context.Context, index string, query map[string]interface{}) (*SearchResult, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := es.client.Search(
+		es.client.Search.WithContext(ctx),
+		es.client.Search.WithIndex(index),
+		es.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if err := responseError(res); err != nil {
+		return nil, err
+	}
+
+	var decoded struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+			Hits []SearchHit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return &SearchResult{Total: decoded.Hits.Total.Value, Hits: decoded.Hits.Hits}, nil
+}
+
+func responseError(res *esapi.Response) error {
+	if !res.IsError() {
+		return nil
+	}
+	return fmt.Errorf("elasticsearch: %s", res.String())
+}
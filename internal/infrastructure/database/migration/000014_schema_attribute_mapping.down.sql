@@ -0,0 +1,4 @@
+ALTER TABLE schema_attributes
+    DROP COLUMN IF EXISTS enum_codes,
+    DROP COLUMN IF EXISTS transform,
+    DROP COLUMN IF EXISTS document_field;
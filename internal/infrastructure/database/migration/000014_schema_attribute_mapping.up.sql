@@ -0,0 +1,4 @@
+ALTER TABLE schema_attributes
+    ADD COLUMN document_field VARCHAR(128),
+    ADD COLUMN transform VARCHAR(32) NOT NULL DEFAULT 'none' CHECK (transform IN ('none', 'date_yyyymmdd', 'enum_code')),
+    ADD COLUMN enum_codes JSONB;
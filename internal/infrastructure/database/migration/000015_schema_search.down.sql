@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_schema_attributes_search;
+DROP INDEX IF EXISTS idx_schemas_document_type;
+DROP INDEX IF EXISTS idx_schemas_search;
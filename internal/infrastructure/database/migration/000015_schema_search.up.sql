@@ -0,0 +1,3 @@
+CREATE INDEX idx_schemas_search ON schemas USING GIN (to_tsvector('simple', title || ' ' || description));
+CREATE INDEX idx_schemas_document_type ON schemas(document_type);
+CREATE INDEX idx_schema_attributes_search ON schema_attributes USING GIN (to_tsvector('simple', name || ' ' || title));
@@ -0,0 +1,20 @@
+CREATE TABLE import_jobs (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    issuer_did VARCHAR(255) NOT NULL CHECK (issuer_did LIKE 'did:%'),
+    document_type VARCHAR(50) NOT NULL CHECK (document_type IN ('citizen_identity', 'academic_degree', 'health_insurance', 'driver_license', 'passport')),
+    file_name VARCHAR(255) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    total_rows INTEGER NOT NULL DEFAULT 0,
+    processed_rows INTEGER NOT NULL DEFAULT 0,
+    created_rows INTEGER NOT NULL DEFAULT 0,
+    updated_rows INTEGER NOT NULL DEFAULT 0,
+    failed_rows INTEGER NOT NULL DEFAULT 0,
+    errors JSONB,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_import_job_public_id ON import_jobs(public_id);
+CREATE INDEX idx_import_job_issuer_did ON import_jobs(issuer_did);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS issuance_batch_items;
+DROP TABLE IF EXISTS issuance_batches;
@@ -0,0 +1,40 @@
+CREATE TABLE issuance_batches (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    issuer_did VARCHAR(255) NOT NULL CHECK (issuer_did LIKE 'did:%') REFERENCES identities(did) ON UPDATE CASCADE ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    total_items INTEGER NOT NULL DEFAULT 0,
+    issued_items INTEGER NOT NULL DEFAULT 0,
+    failed_items INTEGER NOT NULL DEFAULT 0,
+    old_state VARCHAR(66),
+    new_state VARCHAR(66),
+    claims_tree_root CHAR(66),
+    rev_tree_root CHAR(66),
+    roots_tree_root CHAR(66),
+    error TEXT,
+    completed_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE issuance_batch_items (
+    id BIGSERIAL PRIMARY KEY,
+    batch_id BIGINT NOT NULL REFERENCES issuance_batches(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    crid BIGINT NOT NULL REFERENCES credential_requests(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'prepared', 'added', 'issued', 'failed')),
+    credential_status JSONB NOT NULL,
+    credential_subject JSONB,
+    signature TEXT NOT NULL,
+    credential_id VARCHAR(255),
+    issuance_date TIMESTAMPTZ,
+    claim_hex TEXT,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (batch_id, crid)
+);
+
+CREATE INDEX idx_issuance_batch_public_id ON issuance_batches(public_id);
+CREATE INDEX idx_issuance_batch_issuer_did ON issuance_batches(issuer_did);
+CREATE INDEX idx_issuance_batch_item_batch_id ON issuance_batch_items(batch_id);
+CREATE INDEX idx_issuance_batch_item_crid ON issuance_batch_items(crid);
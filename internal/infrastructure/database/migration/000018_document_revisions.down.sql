@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS idx_verifiable_credentials_reissue_required;
+ALTER TABLE verifiable_credentials DROP COLUMN IF EXISTS reissue_required;
+
+ALTER TABLE passports DROP CONSTRAINT IF EXISTS passports_status_check;
+ALTER TABLE passports ADD CONSTRAINT passports_status_check CHECK (status IN ('active', 'expired', 'revoked'));
+ALTER TABLE passports DROP COLUMN IF EXISTS superseded_by;
+ALTER TABLE passports DROP COLUMN IF EXISTS revision;
+
+ALTER TABLE driver_licenses DROP CONSTRAINT IF EXISTS driver_licenses_status_check;
+ALTER TABLE driver_licenses ADD CONSTRAINT driver_licenses_status_check CHECK (status IN ('active', 'expired', 'revoked'));
+ALTER TABLE driver_licenses DROP COLUMN IF EXISTS superseded_by;
+ALTER TABLE driver_licenses DROP COLUMN IF EXISTS revision;
+
+ALTER TABLE health_insurances DROP COLUMN IF EXISTS revision;
+ALTER TABLE academic_degrees DROP COLUMN IF EXISTS revision;
+ALTER TABLE citizen_identities DROP COLUMN IF EXISTS revision;
+
+DROP TABLE IF EXISTS document_revisions;
@@ -0,0 +1,32 @@
+CREATE TABLE document_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    document_type VARCHAR(50) NOT NULL CHECK (document_type IN ('citizen_identity', 'academic_degree', 'health_insurance', 'driver_license', 'passport')),
+    document_id BIGINT NOT NULL,
+    revision INTEGER NOT NULL CHECK (revision > 0),
+    action VARCHAR(20) NOT NULL CHECK (action IN ('created', 'amended', 'revoked', 'renewed', 'superseded')),
+    changes JSONB,
+    snapshot JSONB NOT NULL,
+    changed_by VARCHAR(255) NOT NULL CHECK (changed_by LIKE 'did:%'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (document_type, document_id, revision)
+);
+
+CREATE INDEX idx_document_revisions_document ON document_revisions(document_type, document_id);
+
+ALTER TABLE citizen_identities ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE academic_degrees ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE health_insurances ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+ALTER TABLE driver_licenses ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE driver_licenses ADD COLUMN superseded_by UUID;
+ALTER TABLE driver_licenses DROP CONSTRAINT IF EXISTS driver_licenses_status_check;
+ALTER TABLE driver_licenses ADD CONSTRAINT driver_licenses_status_check CHECK (status IN ('active', 'expired', 'revoked', 'revoke', 'superseded'));
+
+ALTER TABLE passports ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE passports ADD COLUMN superseded_by UUID;
+ALTER TABLE passports DROP CONSTRAINT IF EXISTS passports_status_check;
+ALTER TABLE passports ADD CONSTRAINT passports_status_check CHECK (status IN ('active', 'expired', 'revoked', 'revoke', 'superseded'));
+
+ALTER TABLE verifiable_credentials ADD COLUMN reissue_required BOOLEAN NOT NULL DEFAULT FALSE;
+CREATE INDEX idx_verifiable_credentials_reissue_required ON verifiable_credentials(holder_did) WHERE reissue_required;
@@ -0,0 +1,4 @@
+ALTER TABLE driver_licenses DROP CONSTRAINT IF EXISTS driver_licenses_status_check;
+ALTER TABLE driver_licenses ADD CONSTRAINT driver_licenses_status_check CHECK (status IN ('active', 'expired', 'revoked', 'revoke', 'superseded'));
+
+DROP TABLE IF EXISTS driver_license_point_entries;
@@ -0,0 +1,20 @@
+CREATE TABLE driver_license_point_entries (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    license_id BIGINT NOT NULL REFERENCES driver_licenses(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('deduction', 'restoration')),
+    points INTEGER NOT NULL,
+    balance SMALLINT NOT NULL CHECK (balance BETWEEN 0 AND 12),
+    reason TEXT NOT NULL,
+    reference VARCHAR(100),
+    restore_at TIMESTAMPTZ,
+    restored_at TIMESTAMPTZ,
+    created_by VARCHAR(255) NOT NULL CHECK (created_by LIKE 'did:%'),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_driver_license_point_entries_license_id ON driver_license_point_entries(license_id);
+CREATE INDEX idx_driver_license_point_entries_due ON driver_license_point_entries(restore_at) WHERE restored_at IS NULL;
+
+ALTER TABLE driver_licenses DROP CONSTRAINT IF EXISTS driver_licenses_status_check;
+ALTER TABLE driver_licenses ADD CONSTRAINT driver_licenses_status_check CHECK (status IN ('active', 'expired', 'revoked', 'revoke', 'superseded', 'suspended'));
@@ -0,0 +1,12 @@
+CREATE TABLE document_numbers (
+    document_type VARCHAR(50) NOT NULL CHECK (document_type IN ('citizen_identity', 'academic_degree', 'health_insurance', 'driver_license', 'passport')),
+    number VARCHAR(50) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (document_type, number)
+);
+
+INSERT INTO document_numbers (document_type, number) SELECT 'citizen_identity', id_number FROM citizen_identities WHERE id_number IS NOT NULL ON CONFLICT DO NOTHING;
+INSERT INTO document_numbers (document_type, number) SELECT 'academic_degree', degree_number FROM academic_degrees WHERE degree_number IS NOT NULL ON CONFLICT DO NOTHING;
+INSERT INTO document_numbers (document_type, number) SELECT 'health_insurance', insurance_number FROM health_insurances WHERE insurance_number IS NOT NULL ON CONFLICT DO NOTHING;
+INSERT INTO document_numbers (document_type, number) SELECT 'driver_license', license_number FROM driver_licenses WHERE license_number IS NOT NULL ON CONFLICT DO NOTHING;
+INSERT INTO document_numbers (document_type, number) SELECT 'passport', passport_number FROM passports WHERE passport_number IS NOT NULL ON CONFLICT DO NOTHING;
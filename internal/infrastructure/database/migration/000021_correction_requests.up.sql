@@ -0,0 +1,36 @@
+CREATE TABLE correction_requests (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    document_type VARCHAR(50) NOT NULL CHECK (document_type IN ('citizen_identity', 'academic_degree', 'health_insurance', 'driver_license', 'passport')),
+    document_public_id UUID NOT NULL,
+    field VARCHAR(100) NOT NULL,
+    current_value TEXT,
+    proposed_value TEXT NOT NULL,
+    evidence TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    review_note TEXT,
+    holder_did VARCHAR(255) NOT NULL CHECK (holder_did LIKE 'did:%'),
+    issuer_did VARCHAR(255) NOT NULL CHECK (issuer_did LIKE 'did:%'),
+    reviewed_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_correction_requests_document_public_id ON correction_requests(document_public_id);
+CREATE INDEX idx_correction_requests_holder_did ON correction_requests(holder_did);
+CREATE INDEX idx_correction_requests_issuer_did ON correction_requests(issuer_did);
+CREATE UNIQUE INDEX idx_correction_requests_pending_field ON correction_requests(document_public_id, field) WHERE status = 'pending';
+
+CREATE TABLE notifications (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    recipient_did VARCHAR(255) NOT NULL CHECK (recipient_did LIKE 'did:%'),
+    kind VARCHAR(50) NOT NULL,
+    message TEXT NOT NULL,
+    reference VARCHAR(100),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    read_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_notifications_recipient_did ON notifications(recipient_did);
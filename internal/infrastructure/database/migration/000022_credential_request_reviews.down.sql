@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS credential_review_policies;
+DROP TABLE IF EXISTS credential_request_reviews;
+
+UPDATE credential_requests SET status = 'approved' WHERE status = 'issued';
+UPDATE credential_requests SET status = 'pending' WHERE status = 'under_review';
+UPDATE credential_requests SET status = 'rejected' WHERE status = 'expired';
+
+ALTER TABLE credential_requests DROP COLUMN IF EXISTS status_reason;
+ALTER TABLE credential_requests DROP CONSTRAINT IF EXISTS credential_requests_status_check;
+ALTER TABLE credential_requests ADD CONSTRAINT credential_requests_status_check CHECK (status IN ('pending', 'approved', 'rejected'));
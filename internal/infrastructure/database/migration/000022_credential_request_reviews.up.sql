@@ -0,0 +1,30 @@
+ALTER TABLE credential_requests DROP CONSTRAINT IF EXISTS credential_requests_status_check;
+ALTER TABLE credential_requests ADD CONSTRAINT credential_requests_status_check CHECK (status IN ('pending', 'under_review', 'approved', 'rejected', 'issued', 'expired'));
+ALTER TABLE credential_requests ADD COLUMN status_reason TEXT;
+
+-- requests were marked approved when their credential was issued
+UPDATE credential_requests SET status = 'issued'
+WHERE status = 'approved' AND id IN (SELECT crid FROM verifiable_credentials);
+
+CREATE TABLE credential_request_reviews (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    crid BIGINT NOT NULL REFERENCES credential_requests(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    decision VARCHAR(20) NOT NULL,
+    from_status VARCHAR(20) NOT NULL,
+    to_status VARCHAR(20) NOT NULL,
+    reviewer_did VARCHAR(255) NOT NULL CHECK (reviewer_did LIKE 'did:%'),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_credential_request_reviews_crid ON credential_request_reviews(crid);
+
+CREATE TABLE credential_review_policies (
+    id BIGSERIAL PRIMARY KEY,
+    issuer_did VARCHAR(255) NOT NULL UNIQUE CHECK (issuer_did LIKE 'did:%') REFERENCES identities(did) ON UPDATE CASCADE ON DELETE CASCADE,
+    required_approvals SMALLINT NOT NULL DEFAULT 1 CHECK (required_approvals BETWEEN 1 AND 2),
+    reviewers JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
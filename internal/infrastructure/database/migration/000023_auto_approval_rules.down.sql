@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS auto_approval_decisions;
+DROP TABLE IF EXISTS auto_approval_rules;
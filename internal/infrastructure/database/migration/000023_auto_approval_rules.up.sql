@@ -0,0 +1,25 @@
+CREATE TABLE auto_approval_rules (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    issuer_did VARCHAR(255) NOT NULL CHECK (issuer_did LIKE 'did:%') REFERENCES identities(did) ON UPDATE CASCADE ON DELETE RESTRICT,
+    schema_id BIGINT NOT NULL UNIQUE REFERENCES schemas(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    condition VARCHAR(50) NOT NULL CHECK (condition IN ('active_document')),
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_auto_approval_rules_issuer_did ON auto_approval_rules(issuer_did);
+
+CREATE TABLE auto_approval_decisions (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    rule_id BIGINT NOT NULL REFERENCES auto_approval_rules(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    crid BIGINT NOT NULL REFERENCES credential_requests(id) ON UPDATE CASCADE ON DELETE RESTRICT,
+    outcome VARCHAR(20) NOT NULL CHECK (outcome IN ('approved', 'skipped')),
+    reason TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (rule_id, crid)
+);
+
+CREATE INDEX idx_auto_approval_decisions_crid ON auto_approval_decisions(crid);
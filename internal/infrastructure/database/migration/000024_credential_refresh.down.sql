@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_verifiable_credentials_previous_id;
+ALTER TABLE verifiable_credentials DROP COLUMN IF EXISTS previous_id;
+ALTER TABLE verifiable_credentials DROP COLUMN IF EXISTS refresh_service;
+
+DROP INDEX IF EXISTS idx_credential_requests_open_refresh;
+DROP INDEX IF EXISTS idx_credential_requests_refresh_of_id;
+ALTER TABLE credential_requests DROP COLUMN IF EXISTS refresh_of_id;
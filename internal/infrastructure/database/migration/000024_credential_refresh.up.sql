@@ -0,0 +1,10 @@
+ALTER TABLE credential_requests ADD COLUMN refresh_of_id BIGINT REFERENCES verifiable_credentials(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+CREATE INDEX idx_credential_requests_refresh_of_id ON credential_requests(refresh_of_id);
+
+-- a credential has at most one refresh request in flight
+CREATE UNIQUE INDEX idx_credential_requests_open_refresh ON credential_requests(refresh_of_id)
+WHERE refresh_of_id IS NOT NULL AND status IN ('pending', 'under_review', 'approved');
+
+ALTER TABLE verifiable_credentials ADD COLUMN refresh_service VARCHAR(255);
+ALTER TABLE verifiable_credentials ADD COLUMN previous_id BIGINT REFERENCES verifiable_credentials(id) ON UPDATE CASCADE ON DELETE RESTRICT;
+CREATE INDEX idx_verifiable_credentials_previous_id ON verifiable_credentials(previous_id);
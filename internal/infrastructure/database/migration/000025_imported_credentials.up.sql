@@ -0,0 +1,16 @@
+CREATE TABLE imported_credentials (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    holder_did VARCHAR(255) NOT NULL CHECK (holder_did LIKE 'did:%'),
+    credential_id VARCHAR(255) NOT NULL,
+    issuer_did VARCHAR(255) NOT NULL CHECK (issuer_did LIKE 'did:%'),
+    schema_type VARCHAR(255) NOT NULL,
+    status VARCHAR(30) NOT NULL CHECK (status IN ('issued', 'revoked', 'expired')),
+    credential JSONB NOT NULL,
+    issuer_state JSONB NOT NULL,
+    documents JSONB NOT NULL DEFAULT '{}',
+    exported_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_imported_credentials_holder_credential ON imported_credentials(holder_did, credential_id);
@@ -0,0 +1,12 @@
+CREATE TABLE signing_keys (
+    id BIGSERIAL PRIMARY KEY,
+    kid VARCHAR(64) NOT NULL UNIQUE,
+    algorithm VARCHAR(20) NOT NULL CHECK (algorithm IN ('ES256', 'EdDSA')),
+    private_key BYTEA NOT NULL,
+    activated_at TIMESTAMPTZ NOT NULL,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (expires_at IS NULL OR expires_at > activated_at)
+);
+
+CREATE INDEX idx_signing_keys_expires_at ON signing_keys(expires_at);
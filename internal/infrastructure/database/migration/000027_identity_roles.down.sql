@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS identity_roles;
+DROP TABLE IF EXISTS role_applications;
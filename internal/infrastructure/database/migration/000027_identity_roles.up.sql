@@ -0,0 +1,42 @@
+CREATE TABLE role_applications (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    identity_id BIGINT NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    did VARCHAR(255) NOT NULL CHECK (did LIKE 'did:%'),
+    role VARCHAR(100) NOT NULL CHECK (role IN ('issuer', 'verifier')),
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+    note TEXT,
+    credential JSONB,
+    review_note TEXT,
+    reviewed_by VARCHAR(255),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_role_applications_identity_id ON role_applications(identity_id);
+CREATE INDEX idx_role_applications_did ON role_applications(did);
+CREATE UNIQUE INDEX idx_role_applications_pending_role ON role_applications(identity_id, role) WHERE status = 'pending';
+
+CREATE TABLE identity_roles (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    identity_id BIGINT NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    did VARCHAR(255) NOT NULL CHECK (did LIKE 'did:%'),
+    role VARCHAR(100) NOT NULL CHECK (role IN ('holder', 'issuer', 'verifier', 'admin')),
+    method VARCHAR(50) NOT NULL CHECK (method IN ('registration', 'admin_approval', 'accreditation_credential', 'bootstrap', 'legacy')),
+    application_id BIGINT REFERENCES role_applications(id) ON DELETE SET NULL,
+    granted_by VARCHAR(255) NOT NULL,
+    granted_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_by VARCHAR(255),
+    revoke_reason TEXT,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_identity_roles_identity_id ON identity_roles(identity_id);
+CREATE INDEX idx_identity_roles_did ON identity_roles(did);
+CREATE UNIQUE INDEX idx_identity_roles_active_role ON identity_roles(identity_id, role) WHERE revoked_at IS NULL;
+
+-- roles declared at registration so far are kept, marked legacy so admins can review them
+INSERT INTO identity_roles (identity_id, did, role, method, granted_by, granted_at)
+SELECT id, did, role, 'legacy', 'system', created_at FROM identities;
@@ -0,0 +1,14 @@
+ALTER TABLE identities
+    DROP COLUMN IF EXISTS suspend_reason,
+    DROP COLUMN IF EXISTS suspended_by,
+    DROP COLUMN IF EXISTS suspended_at;
+
+DROP TABLE IF EXISTS user_identities;
+
+DROP INDEX IF EXISTS idx_users_email;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS updated_at,
+    DROP COLUMN IF EXISTS totp_last_counter,
+    DROP COLUMN IF EXISTS totp_enabled_at,
+    DROP COLUMN IF EXISTS totp_secret;
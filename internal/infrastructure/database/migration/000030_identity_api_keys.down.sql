@@ -0,0 +1 @@
+DROP TABLE IF EXISTS identity_api_keys;
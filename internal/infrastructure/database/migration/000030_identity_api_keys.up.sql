@@ -0,0 +1,19 @@
+CREATE TABLE identity_api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    public_id UUID NOT NULL DEFAULT gen_random_uuid() UNIQUE,
+    identity_id BIGINT NOT NULL REFERENCES identities(id) ON DELETE CASCADE,
+    did VARCHAR(255) NOT NULL CHECK (did LIKE 'did:%'),
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes JSONB NOT NULL,
+    rate_limit INTEGER NOT NULL CHECK (rate_limit > 0),
+    last_used_at TIMESTAMPTZ,
+    last_used_ip VARCHAR(64),
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_identity_api_keys_identity_id ON identity_api_keys(identity_id);
+CREATE INDEX idx_identity_api_keys_did ON identity_api_keys(did);
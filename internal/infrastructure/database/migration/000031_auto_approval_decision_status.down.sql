@@ -0,0 +1,2 @@
+ALTER TABLE auto_approval_decisions
+    DROP COLUMN IF EXISTS request_status;
@@ -0,0 +1,3 @@
+-- the status the credential request ended in; earlier decisions did not record it
+ALTER TABLE auto_approval_decisions
+    ADD COLUMN request_status VARCHAR(20);
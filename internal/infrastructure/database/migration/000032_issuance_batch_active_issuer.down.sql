@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_issuance_batch_active_issuer_did;
@@ -0,0 +1,2 @@
+-- an issuer has at most one batch waiting or running, since a batch publishes the claims it adds in a single state
+CREATE UNIQUE INDEX idx_issuance_batch_active_issuer_did ON issuance_batches(issuer_did) WHERE status IN ('pending', 'processing');
@@ -45,7 +45,7 @@ func NewDB(cfg *config.Config, logger *logger.ZapLogger) (*PostgresDB, error) {
 	// gorm
 	gormDB, err := gorm.Open(postgres.New(postgres.Config{
 		Conn: sqlDB,
-	}), &gorm.Config{})
+	}), &gorm.Config{Logger: logger.GormLogger()})
 
 	if err != nil {
 		logger.Error(fmt.Sprintf("Failed to connect to database: %s", err))
@@ -79,3 +79,8 @@ func (p *PostgresDB) GetGormDB() *gorm.DB {
 func (p *PostgresDB) GetPgxPool() *pgxpool.Pool {
 	return p.pgxPool
 }
+
+// NewGormOnlyDB wraps a gorm connection opened elsewhere, such as by tests, without a pgx pool.
+func NewGormOnlyDB(gormDB *gorm.DB) *PostgresDB {
+	return &PostgresDB{gormDB: gormDB}
+}
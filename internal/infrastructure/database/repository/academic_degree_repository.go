@@ -7,8 +7,17 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var academicDegreeColumns = &helper.QueryColumns{
+	Table:        "academic_degrees",
+	StatusColumn: "status",
+	Sortable:     []string{"degree_number", "degree_type", "graduate_year", "gpa", "issue_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type AcademicDegreeRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -45,12 +54,19 @@ func (r *AcademicDegreeRepository) FindAcademicDegreeByHolderDID(ctx context.Con
 	return &entity, nil
 }
 
-func (r *AcademicDegreeRepository) FindAllAcademicDegrees(ctx context.Context) ([]*document.AcademicDegree, error) {
-	var entities []*credential.AcademicDegree
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+func (r *AcademicDegreeRepository) FindAllAcademicDegrees(ctx context.Context, spec *helper.QuerySpec) ([]*document.AcademicDegree, int64, error) {
+	var (
+		entities []*document.AcademicDegree
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.AcademicDegree{}).Scopes(spec.Filter(academicDegreeColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(academicDegreeColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entities, nil
+	return entities, total, nil
 }
 
 func (r *AcademicDegreeRepository) CreateAcademicDegree(ctx context.Context, entity *document.AcademicDegree) (*document.AcademicDegree, error) {
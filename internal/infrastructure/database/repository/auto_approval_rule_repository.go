@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var autoApprovalDecisionColumns = &helper.QueryColumns{
+	Table:        "auto_approval_decisions",
+	StatusColumn: "outcome",
+	Sortable:     []string{"created_at"},
+	DateColumn:   "created_at",
+}
+
+type AutoApprovalRuleRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewAutoApprovalRuleRepository(db *postgres.PostgresDB) credential.IAutoApprovalRuleRepository {
+	return &AutoApprovalRuleRepository{
+		db: db,
+	}
+}
+
+func (r *AutoApprovalRuleRepository) FindAutoApprovalRuleByPublicId(ctx context.Context, publicId string) (*credential.AutoApprovalRule, error) {
+	var entity credential.AutoApprovalRule
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *AutoApprovalRuleRepository) FindAllAutoApprovalRulesByIssuerDID(ctx context.Context, did string) ([]*credential.AutoApprovalRule, error) {
+	var entities []*credential.AutoApprovalRule
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("issuer_did = ?", did).Order("id ASC").Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *AutoApprovalRuleRepository) FindAllEnabledAutoApprovalRules(ctx context.Context) ([]*credential.AutoApprovalRule, error) {
+	var entities []*credential.AutoApprovalRule
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema.SchemaAttributes").Where("enabled = ?", true).Order("id ASC").Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *AutoApprovalRuleRepository) ExistsAutoApprovalRuleBySchemaID(ctx context.Context, schemaID uint) (bool, error) {
+	var count int64
+	if err := r.db.GetGormDB().WithContext(ctx).Model(&credential.AutoApprovalRule{}).Where("schema_id = ?", schemaID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *AutoApprovalRuleRepository) CreateAutoApprovalRule(ctx context.Context, entity *credential.AutoApprovalRule) (*credential.AutoApprovalRule, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *AutoApprovalRuleRepository) UpdateAutoApprovalRule(ctx context.Context, entity *credential.AutoApprovalRule, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// FindUndecidedCredentialRequests returns the pending requests the rule applies to and has not yet decided on.
+func (r *AutoApprovalRuleRepository) FindUndecidedCredentialRequests(ctx context.Context, rule *credential.AutoApprovalRule, limit int) ([]*credential.CredentialRequest, error) {
+	var entities []*credential.CredentialRequest
+	if err := r.db.GetGormDB().WithContext(ctx).
+		Where("issuer_did = ? AND schema_id = ? AND status = ?", rule.IssuerDID, rule.SchemaID, constant.CredentialRequestPendingStatus).
+		Where("NOT EXISTS (SELECT 1 FROM auto_approval_decisions d WHERE d.rule_id = ? AND d.crid = credential_requests.id)", rule.ID).
+		Order("id ASC").Limit(limit).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *AutoApprovalRuleRepository) FindAllAutoApprovalDecisions(ctx context.Context, ruleID uint, spec *helper.QuerySpec) ([]*credential.AutoApprovalDecision, int64, error) {
+	var (
+		entities []*credential.AutoApprovalDecision
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.AutoApprovalDecision{}).Where("rule_id = ?", ruleID).Scopes(spec.Filter(autoApprovalDecisionColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Preload("CredentialRequest").Scopes(spec.Paginate(autoApprovalDecisionColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *AutoApprovalRuleRepository) CreateAutoApprovalDecision(ctx context.Context, entity *credential.AutoApprovalDecision) (*credential.AutoApprovalDecision, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
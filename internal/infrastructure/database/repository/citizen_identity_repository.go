@@ -6,8 +6,17 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var citizenIdentityColumns = &helper.QueryColumns{
+	Table:        "citizen_identities",
+	StatusColumn: "status",
+	Sortable:     []string{"id_number", "first_name", "last_name", "date_of_birth", "issue_date", "expiry_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type CitizenIdentityRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -44,12 +53,19 @@ func (r *CitizenIdentityRepository) FindCitizenIdentityByHolderDID(ctx context.C
 	return &entity, nil
 }
 
-func (r *CitizenIdentityRepository) FindAllCitizenIdentities(ctx context.Context) ([]*document.CitizenIdentity, error) {
-	var entities []*document.CitizenIdentity
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+func (r *CitizenIdentityRepository) FindAllCitizenIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*document.CitizenIdentity, int64, error) {
+	var (
+		entities []*document.CitizenIdentity
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.CitizenIdentity{}).Scopes(spec.Filter(citizenIdentityColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(citizenIdentityColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entities, nil
+	return entities, total, nil
 }
 
 func (r *CitizenIdentityRepository) CreateCitizenIdentity(ctx context.Context, entity *document.CitizenIdentity) (*document.CitizenIdentity, error) {
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var correctionRequestColumns = &helper.QueryColumns{
+	Table:        "correction_requests",
+	StatusColumn: "status",
+	Sortable:     []string{"document_type", "field", "created_at", "reviewed_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+	DateColumn:   "created_at",
+}
+
+type CorrectionRequestRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewCorrectionRequestRepository(db *postgres.PostgresDB) document.ICorrectionRequestRepository {
+	return &CorrectionRequestRepository{
+		db: db,
+	}
+}
+
+func (r *CorrectionRequestRepository) FindCorrectionRequestByPublicId(ctx context.Context, publicId string) (*document.CorrectionRequest, error) {
+	var entity document.CorrectionRequest
+	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *CorrectionRequestRepository) FindAllCorrectionRequestsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*document.CorrectionRequest, int64, error) {
+	return r.findAll(ctx, "holder_did = ?", did, spec)
+}
+
+func (r *CorrectionRequestRepository) FindAllCorrectionRequestsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*document.CorrectionRequest, int64, error) {
+	return r.findAll(ctx, "issuer_did = ?", did, spec)
+}
+
+func (r *CorrectionRequestRepository) findAll(ctx context.Context, query string, did string, spec *helper.QuerySpec) ([]*document.CorrectionRequest, int64, error) {
+	var (
+		entities []*document.CorrectionRequest
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.CorrectionRequest{}).Where(query, did).Scopes(spec.Filter(correctionRequestColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(correctionRequestColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *CorrectionRequestRepository) ExistsPendingCorrectionRequest(ctx context.Context, documentPublicID uuid.UUID, field string) (bool, error) {
+	var count int64
+	if err := r.db.GetGormDB().WithContext(ctx).Model(&document.CorrectionRequest{}).
+		Where("document_public_id = ? AND field = ? AND status = ?", documentPublicID, field, constant.CorrectionRequestPendingStatus).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *CorrectionRequestRepository) CreateCorrectionRequest(ctx context.Context, entity *document.CorrectionRequest) (*document.CorrectionRequest, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// ReviewPendingCorrectionRequest applies the review changes to a request that is still pending.
+// It reports false when another review got to the request first.
+func (r *CorrectionRequestRepository) ReviewPendingCorrectionRequest(ctx context.Context, entity *document.CorrectionRequest, changes map[string]interface{}) (bool, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Model(entity).Where("status = ?", constant.CorrectionRequestPendingStatus).Updates(changes)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -3,10 +3,21 @@ package repository
 import (
 	"be/internal/domain/credential"
 	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var credentialRequestColumns = &helper.QueryColumns{
+	Table:        "credential_requests",
+	StatusColumn: "status",
+	Sortable:     []string{"expiration", "created_time", "expires_time", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type CredentialRequestRepository struct {
 	db *postgres.PostgresDB
 }
@@ -21,7 +32,7 @@ func NewCredentialRequestRepository(
 
 func (r *CredentialRequestRepository) FindCredentialRequestByPublicId(ctx context.Context, publicId string) (*credential.CredentialRequest, error) {
 	var credentialRequest credential.CredentialRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Preload("Issuer").Preload("Holder").Where("public_id = ?", publicId).First(&credentialRequest).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Preload("Issuer").Preload("Holder").Preload("RefreshOf").Where("public_id = ?", publicId).First(&credentialRequest).Error; err != nil {
 		return nil, err
 	}
 
@@ -36,28 +47,42 @@ func (r *CredentialRequestRepository) FindCredentialRequestByThreadId(ctx contex
 	return &credentialRequest, nil
 }
 
-func (r *CredentialRequestRepository) FindAllCredentialRequests(ctx context.Context) ([]*credential.CredentialRequest, error) {
-	var credentialRequests []*credential.CredentialRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Preload("Issuer").Preload("Holder").Find(&credentialRequests).Error; err != nil {
+func (r *CredentialRequestRepository) FindCredentialRequestsByPublicIds(ctx context.Context, publicIds []string) ([]*credential.CredentialRequest, error) {
+	var entities []*credential.CredentialRequest
+	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id IN ?", publicIds).Find(&entities).Error; err != nil {
 		return nil, err
 	}
-	return credentialRequests, nil
+	return entities, nil
 }
 
-func (r *CredentialRequestRepository) FindAllCredentialRequestsByHolderDID(ctx context.Context, did string) ([]*credential.CredentialRequest, error) {
-	var credentialRequests []*credential.CredentialRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Preload("Issuer").Preload("Holder").Where("holder_did", did).Find(&credentialRequests).Error; err != nil {
-		return nil, err
+func (r *CredentialRequestRepository) FindAllCredentialRequestsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*credential.CredentialRequest, int64, error) {
+	var (
+		entities []*credential.CredentialRequest
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.CredentialRequest{}).Where("holder_did = ?", did).Scopes(spec.Filter(credentialRequestColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Preload("Schema").Preload("Issuer").Preload("Holder").Scopes(spec.Paginate(credentialRequestColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return credentialRequests, nil
+	return entities, total, nil
 }
 
-func (r *CredentialRequestRepository) FindAllCredentialRequestsByIssuerDID(ctx context.Context, did string) ([]*credential.CredentialRequest, error) {
-	var credentialRequests []*credential.CredentialRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Preload("Issuer").Preload("Holder").Where("issuer_did", did).Find(&credentialRequests).Error; err != nil {
-		return nil, err
+func (r *CredentialRequestRepository) FindAllCredentialRequestsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*credential.CredentialRequest, int64, error) {
+	var (
+		entities []*credential.CredentialRequest
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.CredentialRequest{}).Where("issuer_did = ?", did).Scopes(spec.Filter(credentialRequestColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Preload("Schema").Preload("Issuer").Preload("Holder").Scopes(spec.Paginate(credentialRequestColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return credentialRequests, nil
+	return entities, total, nil
 }
 
 func (r *CredentialRequestRepository) CreateCredentialRequest(ctx context.Context, entity *credential.CredentialRequest) (*credential.CredentialRequest, error) {
@@ -83,3 +108,29 @@ func (r *CredentialRequestRepository) UpdateCredentialRequest(ctx context.Contex
 	}
 	return nil
 }
+
+// LockCredentialRequest loads the request with a row lock so concurrent reviews are applied one at a time.
+// It must be called inside a transaction.
+func (r *CredentialRequestRepository) LockCredentialRequest(ctx context.Context, id uint) (*credential.CredentialRequest, error) {
+	var entity credential.CredentialRequest
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// ExistsOpenRefreshRequest reports whether the credential already has a refresh request that is not yet issued or rejected.
+func (r *CredentialRequestRepository) ExistsOpenRefreshRequest(ctx context.Context, vcID uint) (bool, error) {
+	var count int64
+	statuses := []constant.CredentialRequestStatus{
+		constant.CredentialRequestPendingStatus,
+		constant.CredentialRequestUnderReviewStatus,
+		constant.CredentialRequestApprovedStatus,
+	}
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&credential.CredentialRequest{}).Where("refresh_of_id = ? AND status IN ?", vcID, statuses).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+)
+
+type CredentialReviewRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewCredentialReviewRepository(db *postgres.PostgresDB) credential.ICredentialReviewRepository {
+	return &CredentialReviewRepository{
+		db: db,
+	}
+}
+
+func (r *CredentialReviewRepository) FindAllCredentialRequestReviews(ctx context.Context, crid uint) ([]*credential.CredentialRequestReview, error) {
+	var entities []*credential.CredentialRequestReview
+	if err := r.db.GetGormDB().WithContext(ctx).Where("crid = ?", crid).Order("id ASC").Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *CredentialReviewRepository) CreateCredentialRequestReview(ctx context.Context, entity *credential.CredentialRequestReview) (*credential.CredentialRequestReview, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *CredentialReviewRepository) FindReviewPolicyByIssuerDID(ctx context.Context, issuerDID string) (*credential.CredentialReviewPolicy, error) {
+	var entity credential.CredentialReviewPolicy
+	if err := r.db.GetGormDB().WithContext(ctx).Where("issuer_did = ?", issuerDID).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *CredentialReviewRepository) SaveReviewPolicy(ctx context.Context, entity *credential.CredentialReviewPolicy) (*credential.CredentialReviewPolicy, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Save(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
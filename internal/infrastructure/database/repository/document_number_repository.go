@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm/clause"
+)
+
+type DocumentNumberRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewDocumentNumberRepository(db *postgres.PostgresDB) document.IDocumentNumberRepository {
+	return &DocumentNumberRepository{
+		db: db,
+	}
+}
+
+// ReserveDocumentNumber inserts the number and reports false when it is already taken.
+func (r *DocumentNumberRepository) ReserveDocumentNumber(ctx context.Context, documentType constant.DocumentType, number string) (bool, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&document.DocumentNumber{
+		DocumentType: documentType,
+		Number:       number,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+	"fmt"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var documentRevisionColumns = &helper.QueryColumns{
+	Table:    "document_revisions",
+	Sortable: []string{"revision", "created_at"},
+}
+
+// documentModels are the entities whose rows hold the revision of each document type.
+var documentModels = map[constant.DocumentType]interface{}{
+	constant.CitizenIdentity: &document.CitizenIdentity{},
+	constant.AcademicDegree:  &document.AcademicDegree{},
+	constant.HealthInsurance: &document.HealthInsurance{},
+	constant.DriverLicense:   &document.DriverLicense{},
+	constant.Passport:        &document.Passport{},
+}
+
+type DocumentRevisionRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewDocumentRevisionRepository(db *postgres.PostgresDB) document.IDocumentRevisionRepository {
+	return &DocumentRevisionRepository{
+		db: db,
+	}
+}
+
+func (r *DocumentRevisionRepository) FindAllDocumentRevisions(ctx context.Context, documentType constant.DocumentType, documentID uint, spec *helper.QuerySpec) ([]*document.DocumentRevision, int64, error) {
+	var (
+		entities []*document.DocumentRevision
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.DocumentRevision{}).
+		Where("document_type = ? AND document_id = ?", documentType, documentID).
+		Scopes(spec.Filter(documentRevisionColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(documentRevisionColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *DocumentRevisionRepository) CreateDocumentRevision(ctx context.Context, entity *document.DocumentRevision) (*document.DocumentRevision, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// LockDocumentRevision loads the current revision of a document with a row lock, so changes of the same document
+// are saved one at a time. It must be called inside a transaction.
+func (r *DocumentRevisionRepository) LockDocumentRevision(ctx context.Context, documentType constant.DocumentType, documentID uint) (int, error) {
+	model, ok := documentModels[documentType]
+	if !ok {
+		return 0, fmt.Errorf("unknown document type %q", documentType)
+	}
+	var revisions []int
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(model).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", documentID).Pluck("revision", &revisions).Error; err != nil {
+		return 0, err
+	}
+	if len(revisions) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return revisions[0], nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var driverLicensePointColumns = &helper.QueryColumns{
+	Table:    "driver_license_point_entries",
+	Sortable: []string{"kind", "points", "balance", "restore_at", "created_at"},
+}
+
+type DriverLicensePointRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewDriverLicensePointRepository(db *postgres.PostgresDB) document.IDriverLicensePointRepository {
+	return &DriverLicensePointRepository{
+		db: db,
+	}
+}
+
+func (r *DriverLicensePointRepository) FindAllPointEntriesByLicenseID(ctx context.Context, licenseID uint, spec *helper.QuerySpec) ([]*document.DriverLicensePointEntry, int64, error) {
+	var (
+		entities []*document.DriverLicensePointEntry
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.DriverLicensePointEntry{}).
+		Where("license_id = ?", licenseID).
+		Scopes(spec.Filter(driverLicensePointColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(driverLicensePointColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+// FindDuePointEntries returns deductions whose restore time has passed and that have not been restored yet.
+func (r *DriverLicensePointRepository) FindDuePointEntries(ctx context.Context, now time.Time, limit int) ([]*document.DriverLicensePointEntry, error) {
+	var entities []*document.DriverLicensePointEntry
+	if err := r.db.GetGormDB().WithContext(ctx).
+		Where("restore_at <= ? AND restored_at IS NULL", now).
+		Order("restore_at ASC").Limit(limit).
+		Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *DriverLicensePointRepository) CreatePointEntry(ctx context.Context, entity *document.DriverLicensePointEntry) (*document.DriverLicensePointEntry, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+// MarkPointEntryRestored sets restored_at on a deduction that has not been restored yet.
+// It reports false when another run restored the deduction first.
+func (r *DriverLicensePointRepository) MarkPointEntryRestored(ctx context.Context, entity *document.DriverLicensePointEntry, restoredAt time.Time) (bool, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Model(entity).Where("restored_at IS NULL").Update("restored_at", restoredAt)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
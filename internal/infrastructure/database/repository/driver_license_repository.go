@@ -6,8 +6,18 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var driverLicenseColumns = &helper.QueryColumns{
+	Table:        "driver_licenses",
+	StatusColumn: "status",
+	Sortable:     []string{"license_number", "class", "point", "issue_date", "expiry_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type DriverLicenseRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -38,18 +48,25 @@ func (r *DriverLicenseRepository) FindDriverLicenseByLicenseId(ctx context.Conte
 
 func (r *DriverLicenseRepository) FindDriverLicenseByHolderDID(ctx context.Context, holderDID string) (*document.DriverLicense, error) {
 	var entity document.DriverLicense
-	if err := r.db.GetGormDB().WithContext(ctx).Where("holder_did = ?", holderDID).First(&entity).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Where("holder_did = ? AND superseded_by IS NULL", holderDID).First(&entity).Error; err != nil {
 		return nil, err
 	}
 	return &entity, nil
 }
 
-func (r *DriverLicenseRepository) FindAllDriverLicenses(ctx context.Context) ([]*document.DriverLicense, error) {
-	var entities []*document.DriverLicense
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+func (r *DriverLicenseRepository) FindAllDriverLicenses(ctx context.Context, spec *helper.QuerySpec) ([]*document.DriverLicense, int64, error) {
+	var (
+		entities []*document.DriverLicense
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.DriverLicense{}).Scopes(spec.Filter(driverLicenseColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(driverLicenseColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entities, nil
+	return entities, total, nil
 }
 
 func (r *DriverLicenseRepository) CreateDriverLicense(ctx context.Context, entity *document.DriverLicense) (*document.DriverLicense, error) {
@@ -75,3 +92,14 @@ func (r *DriverLicenseRepository) UpdateDriverLicense(ctx context.Context, entit
 	}
 	return nil
 }
+
+// LockDriverLicense loads the licence with a row lock so concurrent point changes are applied one at a time.
+// It must be called inside a transaction.
+func (r *DriverLicenseRepository) LockDriverLicense(ctx context.Context, id uint) (*document.DriverLicense, error) {
+	var entity document.DriverLicense
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
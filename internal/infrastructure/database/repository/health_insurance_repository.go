@@ -6,8 +6,17 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var healthInsuranceColumns = &helper.QueryColumns{
+	Table:        "health_insurances",
+	StatusColumn: "status",
+	Sortable:     []string{"insurance_number", "insurance_type", "start_date", "expiry_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type HealthInsuranceRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -44,12 +53,19 @@ func (r *HealthInsuranceRepository) FindHealthInsuranceByHolderDID(ctx context.C
 	return &entity, nil
 }
 
-func (r *HealthInsuranceRepository) FindAllHealthInsurances(ctx context.Context) ([]*document.HealthInsurance, error) {
-	var entities []*document.HealthInsurance
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+func (r *HealthInsuranceRepository) FindAllHealthInsurances(ctx context.Context, spec *helper.QuerySpec) ([]*document.HealthInsurance, int64, error) {
+	var (
+		entities []*document.HealthInsurance
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.HealthInsurance{}).Scopes(spec.Filter(healthInsuranceColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(healthInsuranceColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entities, nil
+	return entities, total, nil
 }
 
 func (r *HealthInsuranceRepository) CreateHealthInsurance(ctx context.Context, entity *document.HealthInsurance) (*document.HealthInsurance, error) {
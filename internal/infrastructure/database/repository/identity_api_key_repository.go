@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var identityAPIKeyColumns = &helper.QueryColumns{
+	Table:      "identity_api_keys",
+	Sortable:   []string{"name", "created_at", "last_used_at", "revoked_at"},
+	DIDColumns: []string{"did"},
+	DateColumn: "created_at",
+}
+
+type IdentityAPIKeyRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewIdentityAPIKeyRepository(db *postgres.PostgresDB) schema.IIdentityAPIKeyRepository {
+	return &IdentityAPIKeyRepository{
+		db: db,
+	}
+}
+
+func (r *IdentityAPIKeyRepository) FindAPIKeyByPublicId(ctx context.Context, publicId string) (*schema.IdentityAPIKey, error) {
+	var entity schema.IdentityAPIKey
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *IdentityAPIKeyRepository) FindAPIKeyByHash(ctx context.Context, keyHash string) (*schema.IdentityAPIKey, error) {
+	var entity schema.IdentityAPIKey
+	if err := r.db.GetGormDB().WithContext(ctx).Where("key_hash = ?", keyHash).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *IdentityAPIKeyRepository) FindAllAPIKeysByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*schema.IdentityAPIKey, int64, error) {
+	var (
+		entities []*schema.IdentityAPIKey
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&schema.IdentityAPIKey{}).
+		Where("did = ?", did).
+		Scopes(spec.Filter(identityAPIKeyColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(identityAPIKeyColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *IdentityAPIKeyRepository) CountActiveAPIKeys(ctx context.Context, identityID uint) (int64, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&schema.IdentityAPIKey{}).
+		Where("identity_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", identityID, time.Now().UTC()).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *IdentityAPIKeyRepository) CreateAPIKey(ctx context.Context, entity *schema.IdentityAPIKey) (*schema.IdentityAPIKey, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *IdentityAPIKeyRepository) UpdateAPIKey(ctx context.Context, entity *schema.IdentityAPIKey, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -5,8 +5,23 @@ import (
 	"be/internal/infrastructure/database/postgres"
 	"be/internal/shared/helper"
 	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var identityColumns = &helper.QueryColumns{
+	Table:      "identities",
+	Sortable:   []string{"name", "role", "created_at", "suspended_at"},
+	DIDColumns: []string{"did"},
+	DateColumn: "created_at",
+}
+
+// withActiveRoles loads the roles an identity currently holds; revoked grants stay in the table as history.
+func withActiveRoles(db *gorm.DB) *gorm.DB {
+	return db.Preload("Roles", "revoked_at IS NULL")
+}
+
 type IdentityRepository struct {
 	db *postgres.PostgresDB
 }
@@ -19,7 +34,7 @@ func NewIdentityRepository(db *postgres.PostgresDB) schema.IIdentityRepository {
 
 func (r *IdentityRepository) FindIdentityByPublicId(ctx context.Context, publicId string) (*schema.Identity, error) {
 	var identity schema.Identity
-	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", publicId).First(&identity).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withActiveRoles).Where("public_id = ?", publicId).First(&identity).Error; err != nil {
 		return nil, err
 	}
 	return &identity, nil
@@ -27,7 +42,7 @@ func (r *IdentityRepository) FindIdentityByPublicId(ctx context.Context, publicI
 
 func (r *IdentityRepository) FindIdentityByPublicKey(ctx context.Context, publicKeyX string, publicKeyY string) (*schema.Identity, error) {
 	var identity schema.Identity
-	if err := r.db.GetGormDB().WithContext(ctx).Where("public_key_x = ? AND public_key_y = ?", publicKeyX, publicKeyY).First(&identity).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withActiveRoles).Where("public_key_x = ? AND public_key_y = ?", publicKeyX, publicKeyY).First(&identity).Error; err != nil {
 		return nil, err
 	}
 	return &identity, nil
@@ -35,28 +50,47 @@ func (r *IdentityRepository) FindIdentityByPublicKey(ctx context.Context, public
 
 func (r *IdentityRepository) FindIdentityByDID(ctx context.Context, did string) (*schema.Identity, error) {
 	var identity schema.Identity
-	if err := r.db.GetGormDB().WithContext(ctx).Where("did = ?", did).First(&identity).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withActiveRoles).Where("did = ?", did).First(&identity).Error; err != nil {
 		return nil, err
 	}
 	return &identity, nil
 }
 
-func (r *IdentityRepository) FindIdentityByRole(ctx context.Context, role string) ([]*schema.Identity, error) {
-	var identities []*schema.Identity
-	if err := r.db.GetGormDB().WithContext(ctx).Where("role = ?", role).Find(&identities).Error; err != nil {
+// LockIdentityByDID loads the identity with a row lock so changes to its trees are made one at a time.
+// It must be called inside a transaction.
+func (r *IdentityRepository) LockIdentityByDID(ctx context.Context, did string) (*schema.Identity, error) {
+	var identity schema.Identity
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("did = ?", did).First(&identity).Error; err != nil {
 		return nil, err
 	}
-	return identities, nil
+	return &identity, nil
 }
 
-func (r *IdentityRepository) FindAllIdentities(ctx context.Context) ([]*schema.Identity, error) {
+func (r *IdentityRepository) FindIdentityByRole(ctx context.Context, role string) ([]*schema.Identity, error) {
 	var identities []*schema.Identity
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&identities).Error; err != nil {
+	grants := r.db.GetGormDB().Model(&schema.IdentityRoleGrant{}).Select("identity_id").Where("role = ? AND revoked_at IS NULL", role)
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withActiveRoles).Where("id IN (?)", grants).Find(&identities).Error; err != nil {
 		return nil, err
 	}
 	return identities, nil
 }
 
+func (r *IdentityRepository) FindAllIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*schema.Identity, int64, error) {
+	var (
+		entities []*schema.Identity
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&schema.Identity{}).Scopes(spec.Filter(identityColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(identityColumns), withActiveRoles).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
 func (r *IdentityRepository) CreateIdentity(ctx context.Context, entity *schema.Identity) (*schema.Identity, error) {
 	db := helper.WithTx(ctx, r.db.GetGormDB())
 	if err := db.Create(entity).Error; err != nil {
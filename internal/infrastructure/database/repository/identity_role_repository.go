@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var identityRoleColumns = &helper.QueryColumns{
+	Table:      "identity_roles",
+	Sortable:   []string{"role", "method", "granted_at", "revoked_at"},
+	DIDColumns: []string{"did"},
+	DateColumn: "granted_at",
+}
+
+type IdentityRoleRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewIdentityRoleRepository(db *postgres.PostgresDB) schema.IIdentityRoleRepository {
+	return &IdentityRoleRepository{
+		db: db,
+	}
+}
+
+func (r *IdentityRoleRepository) FindRoleGrantByPublicId(ctx context.Context, publicId string) (*schema.IdentityRoleGrant, error) {
+	var entity schema.IdentityRoleGrant
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *IdentityRoleRepository) FindAllRoleGrants(ctx context.Context, spec *helper.QuerySpec) ([]*schema.IdentityRoleGrant, int64, error) {
+	return r.findAll(ctx, spec)
+}
+
+func (r *IdentityRoleRepository) FindAllRoleGrantsByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*schema.IdentityRoleGrant, int64, error) {
+	return r.findAll(ctx, spec, "did = ?", did)
+}
+
+func (r *IdentityRoleRepository) findAll(ctx context.Context, spec *helper.QuerySpec, conds ...interface{}) ([]*schema.IdentityRoleGrant, int64, error) {
+	var (
+		entities []*schema.IdentityRoleGrant
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&schema.IdentityRoleGrant{})
+	if len(conds) > 0 {
+		db = db.Where(conds[0], conds[1:]...)
+	}
+	db = db.Scopes(spec.Filter(identityRoleColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(identityRoleColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *IdentityRoleRepository) ExistsActiveRoleGrant(ctx context.Context, identityID uint, role constant.IdentityRole) (bool, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&schema.IdentityRoleGrant{}).
+		Where("identity_id = ? AND role = ? AND revoked_at IS NULL", identityID, role).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *IdentityRoleRepository) CreateRoleGrant(ctx context.Context, entity *schema.IdentityRoleGrant) (*schema.IdentityRoleGrant, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *IdentityRoleRepository) UpdateRoleGrant(ctx context.Context, entity *schema.IdentityRoleGrant, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
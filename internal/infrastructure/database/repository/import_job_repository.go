@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/pkg/logger"
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ImportJobRepository struct {
+	db     *postgres.PostgresDB
+	logger *logger.ZapLogger
+}
+
+func NewImportJobRepository(db *postgres.PostgresDB, logger *logger.ZapLogger) document.IImportJobRepository {
+	return &ImportJobRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+func (r *ImportJobRepository) FindImportJobByPublicId(ctx context.Context, publicId string) (*document.ImportJob, error) {
+	var entity document.ImportJob
+	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *ImportJobRepository) CreateImportJob(ctx context.Context, entity *document.ImportJob) (*document.ImportJob, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *ImportJobRepository) UpdateImportJob(ctx context.Context, entity *document.ImportJob, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// FailStaleImportJobs fails the jobs waiting or running that were last updated before the given time, which are left
+// behind by a process that stopped while running them. The reason is added to the job's errors without a row.
+func (r *ImportJobRepository) FailStaleImportJobs(ctx context.Context, before time.Time, reason string) (int64, error) {
+	rowErrors, err := json.Marshal([]document.ImportRowError{{Message: reason}})
+	if err != nil {
+		return 0, err
+	}
+	result := r.db.GetGormDB().WithContext(ctx).Model(&document.ImportJob{}).
+		Where("status IN ? AND updated_at < ?", []constant.ImportJobStatus{constant.ImportJobPendingStatus, constant.ImportJobProcessingStatus}, before).
+		Updates(map[string]interface{}{
+			"status":       constant.ImportJobFailedStatus,
+			"errors":       gorm.Expr("COALESCE(errors, '[]'::jsonb) || ?::jsonb", string(rowErrors)),
+			"completed_at": time.Now().UTC(),
+		})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var importedCredentialColumns = &helper.QueryColumns{
+	Table:        "imported_credentials",
+	StatusColumn: "status",
+	Sortable:     []string{"exported_at", "created_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
+type ImportedCredentialRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewImportedCredentialRepository(db *postgres.PostgresDB) credential.IImportedCredentialRepository {
+	return &ImportedCredentialRepository{
+		db: db,
+	}
+}
+
+func (r *ImportedCredentialRepository) FindAllImportedCredentialsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*credential.ImportedCredential, int64, error) {
+	var (
+		entities []*credential.ImportedCredential
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.ImportedCredential{}).Where("holder_did = ?", did).Scopes(spec.Filter(importedCredentialColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(importedCredentialColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *ImportedCredentialRepository) ExistsImportedCredential(ctx context.Context, holderDID string, credentialID string) (bool, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&credential.ImportedCredential{}).Where("holder_did = ? AND credential_id = ?", holderDID, credentialID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *ImportedCredentialRepository) CreateImportedCredential(ctx context.Context, entity *credential.ImportedCredential) (*credential.ImportedCredential, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type IssuanceBatchRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewIssuanceBatchRepository(db *postgres.PostgresDB) credential.IIssuanceBatchRepository {
+	return &IssuanceBatchRepository{
+		db: db,
+	}
+}
+
+func (r *IssuanceBatchRepository) FindIssuanceBatchByPublicId(ctx context.Context, publicId string) (*credential.IssuanceBatch, error) {
+	var entity credential.IssuanceBatch
+	if err := r.db.GetGormDB().WithContext(ctx).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		Preload("Items.CredentialRequest.Schema").
+		Where("public_id = ?", publicId).
+		First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// ExistsActiveIssuanceBatch reports whether the issuer has a batch waiting or running.
+func (r *IssuanceBatchRepository) ExistsActiveIssuanceBatch(ctx context.Context, issuerDID string) (bool, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&credential.IssuanceBatch{}).
+		Where("issuer_did = ? AND status IN ?", issuerDID, []constant.IssuanceBatchStatus{constant.IssuanceBatchPendingStatus, constant.IssuanceBatchProcessingStatus}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *IssuanceBatchRepository) CreateIssuanceBatch(ctx context.Context, entity *credential.IssuanceBatch) (*credential.IssuanceBatch, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *IssuanceBatchRepository) UpdateIssuanceBatch(ctx context.Context, entity *credential.IssuanceBatch, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *IssuanceBatchRepository) UpdateIssuanceBatchItem(ctx context.Context, entity *credential.IssuanceBatchItem, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// TouchIssuanceBatch marks a running batch as still alive.
+func (r *IssuanceBatchRepository) TouchIssuanceBatch(ctx context.Context, id uint) error {
+	return r.db.GetGormDB().WithContext(ctx).Model(&credential.IssuanceBatch{}).
+		Where("id = ? AND status = ?", id, constant.IssuanceBatchProcessingStatus).
+		Update("updated_at", time.Now().UTC()).Error
+}
+
+// FailStaleIssuanceBatches fails the batches waiting or running that were last updated before the given time,
+// which are left behind by a process that stopped while running them.
+func (r *IssuanceBatchRepository) FailStaleIssuanceBatches(ctx context.Context, before time.Time, reason string) (int64, error) {
+	result := r.db.GetGormDB().WithContext(ctx).Model(&credential.IssuanceBatch{}).
+		Where("status IN ? AND updated_at < ?", []constant.IssuanceBatchStatus{constant.IssuanceBatchPendingStatus, constant.IssuanceBatchProcessingStatus}, before).
+		Updates(map[string]interface{}{
+			"status": constant.IssuanceBatchFailedStatus,
+			"error":  reason,
+		})
+	return result.RowsAffected, result.Error
+}
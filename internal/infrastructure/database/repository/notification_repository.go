@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"be/internal/domain/notification"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var notificationColumns = &helper.QueryColumns{
+	Table:      "notifications",
+	Sortable:   []string{"kind", "created_at", "read_at"},
+	DateColumn: "created_at",
+}
+
+type NotificationRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewNotificationRepository(db *postgres.PostgresDB) notification.INotificationRepository {
+	return &NotificationRepository{
+		db: db,
+	}
+}
+
+func (r *NotificationRepository) FindNotificationByPublicId(ctx context.Context, publicId string) (*notification.Notification, error) {
+	var entity notification.Notification
+	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *NotificationRepository) FindAllNotificationsByRecipientDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*notification.Notification, int64, error) {
+	var (
+		entities []*notification.Notification
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&notification.Notification{}).Where("recipient_did = ?", did).Scopes(spec.Filter(notificationColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(notificationColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *NotificationRepository) CreateNotification(ctx context.Context, entity *notification.Notification) (*notification.Notification, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *NotificationRepository) UpdateNotification(ctx context.Context, entity *notification.Notification, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
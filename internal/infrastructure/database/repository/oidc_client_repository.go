@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"be/internal/domain/auth"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+var oidcClientColumns = &helper.QueryColumns{
+	Table:      "oidc_clients",
+	Sortable:   []string{"name", "created_at"},
+	DateColumn: "created_at",
+}
+
+type OIDCClientRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewOIDCClientRepository(db *postgres.PostgresDB) auth.IOIDCClientRepository {
+	return &OIDCClientRepository{
+		db: db,
+	}
+}
+
+func (r *OIDCClientRepository) FindOIDCClientByClientID(ctx context.Context, clientID string) (*auth.OIDCClient, error) {
+	var client auth.OIDCClient
+	if err := r.db.GetGormDB().WithContext(ctx).Where("client_id = ?", clientID).First(&client).Error; err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+func (r *OIDCClientRepository) FindAllOIDCClients(ctx context.Context, spec *helper.QuerySpec) ([]*auth.OIDCClient, int64, error) {
+	var (
+		entities []*auth.OIDCClient
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&auth.OIDCClient{}).Scopes(spec.Filter(oidcClientColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(oidcClientColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *OIDCClientRepository) CreateOIDCClient(ctx context.Context, client *auth.OIDCClient) (*auth.OIDCClient, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(client).Error; err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (r *OIDCClientRepository) UpdateOIDCClient(ctx context.Context, client *auth.OIDCClient, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(client).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
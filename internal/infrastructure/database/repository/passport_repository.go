@@ -6,8 +6,17 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var passportColumns = &helper.QueryColumns{
+	Table:        "passports",
+	StatusColumn: "status",
+	Sortable:     []string{"passport_number", "passport_type", "nationality", "issue_date", "expiry_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type PassportRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -38,18 +47,25 @@ func (r *PassportRepository) FindPassportByPassportNumber(ctx context.Context, p
 
 func (r *PassportRepository) FindPassportByHolderDID(ctx context.Context, holderDID string) (*document.Passport, error) {
 	var entity document.Passport
-	if err := r.db.GetGormDB().WithContext(ctx).Where("holder_did = ?", holderDID).First(&entity).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Where("holder_did = ? AND superseded_by IS NULL", holderDID).First(&entity).Error; err != nil {
 		return nil, err
 	}
 	return &entity, nil
 }
 
-func (r *PassportRepository) FindAllPassports(ctx context.Context) ([]*document.Passport, error) {
-	var entities []*document.Passport
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&entities).Error; err != nil {
-		return nil, err
+func (r *PassportRepository) FindAllPassports(ctx context.Context, spec *helper.QuerySpec) ([]*document.Passport, int64, error) {
+	var (
+		entities []*document.Passport
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&document.Passport{}).Scopes(spec.Filter(passportColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(passportColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entities, nil
+	return entities, total, nil
 }
 
 func (r *PassportRepository) CreatePassport(ctx context.Context, entity *document.Passport) (*document.Passport, error) {
@@ -5,8 +5,24 @@ import (
 	"be/internal/infrastructure/database/postgres"
 	"be/internal/shared/helper"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var proofRequestColumns = &helper.QueryColumns{
+	Table:        "proof_requests",
+	StatusColumn: "status",
+	Sortable:     []string{"created_time", "expires_time", "created_at", "updated_at"},
+	DIDColumns:   []string{"verifier_did"},
+}
+
+var proofSubmissionColumns = &helper.QueryColumns{
+	Table:        "proof_submissions",
+	StatusColumn: "status",
+	Sortable:     []string{"created_time", "expires_time", "verified_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did"},
+}
+
 type ProofRepository struct {
 	db *postgres.PostgresDB
 }
@@ -33,19 +49,33 @@ func (r *ProofRepository) FindProofRequestByThreadId(ctx context.Context, thread
 	return &entity, nil
 }
 
-func (r *ProofRepository) FindAllProofRequests(ctx context.Context) ([]*proof.ProofRequest, error) {
-	var entity []*proof.ProofRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Verifier").Preload("Schema").Find(&entity).Error; err != nil {
-		return nil, err
+func (r *ProofRepository) FindAllProofRequests(ctx context.Context, spec *helper.QuerySpec) ([]*proof.ProofRequest, int64, error) {
+	var (
+		entities []*proof.ProofRequest
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&proof.ProofRequest{}).Scopes(spec.Filter(proofRequestColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return entity, nil
+	if err := db.Preload("Verifier").Preload("Schema").Scopes(spec.Paginate(proofRequestColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
 }
-func (r *ProofRepository) FindAllProofRequestsByVerifierDID(ctx context.Context, did string) ([]*proof.ProofRequest, error) {
-	var entity []*proof.ProofRequest
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Verifier").Preload("Schema").Where("verifier_did = ?", did).Find(&entity).Error; err != nil {
-		return nil, err
+func (r *ProofRepository) FindAllProofRequestsByVerifierDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*proof.ProofRequest, int64, error) {
+	var (
+		entities []*proof.ProofRequest
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&proof.ProofRequest{}).Where("verifier_did = ?", did).Scopes(spec.Filter(proofRequestColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return entity, nil
+	if err := db.Preload("Verifier").Preload("Schema").Scopes(spec.Paginate(proofRequestColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
 }
 
 func (r *ProofRepository) CreateProofRequest(ctx context.Context, entity *proof.ProofRequest) (*proof.ProofRequest, error) {
@@ -72,28 +102,34 @@ func (r *ProofRepository) FindProofSubmissionByPublicId(ctx context.Context, pub
 	return &entity, nil
 }
 
-func (r *ProofRepository) FindAllProofSubmissions(ctx context.Context) ([]*proof.ProofSubmission, error) {
-	var entity []*proof.ProofSubmission
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("ProofRequest").Preload("Holder").Find(&entity).Error; err != nil {
-		return nil, err
+func (r *ProofRepository) FindAllProofSubmissionsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*proof.ProofSubmission, int64, error) {
+	var (
+		entities []*proof.ProofSubmission
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&proof.ProofSubmission{}).Where("holder_did = ?", did).Scopes(spec.Filter(proofSubmissionColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return entity, nil
-}
-
-func (r *ProofRepository) FindAllProofSubmissionsByHolderDID(ctx context.Context, did string) ([]*proof.ProofSubmission, error) {
-	var entity []*proof.ProofSubmission
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Holder").Where("holder_did = ?", did).Preload("ProofRequest.Verifier").Find(&entity).Error; err != nil {
-		return nil, err
+	if err := db.Preload("Holder").Preload("ProofRequest.Verifier").Scopes(spec.Paginate(proofSubmissionColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
 	}
-	return entity, nil
+	return entities, total, nil
 }
 
-func (r *ProofRepository) FindAllProofSubmissionsByVerifierDID(ctx context.Context, did string) ([]*proof.ProofSubmission, error) {
-	var entity []*proof.ProofSubmission
-	if err := r.db.GetGormDB().WithContext(ctx).Joins("ProofRequest").Where("verifier_did = ?", did).Preload("ProofRequest.Verifier").Preload("Holder").Find(&entity).Error; err != nil {
-		return nil, err
+func (r *ProofRepository) FindAllProofSubmissionsByVerifierDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*proof.ProofSubmission, int64, error) {
+	var (
+		entities []*proof.ProofSubmission
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&proof.ProofSubmission{}).Joins("ProofRequest").Where("\"ProofRequest\".verifier_did = ?", did).Scopes(spec.Filter(proofSubmissionColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-	return entity, nil
+	if err := db.Preload("ProofRequest.Verifier").Preload("Holder").Scopes(spec.Paginate(proofSubmissionColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
 }
 
 func (r *ProofRepository) CreateProofSubmission(ctx context.Context, entity *proof.ProofSubmission) (*proof.ProofSubmission, error) {
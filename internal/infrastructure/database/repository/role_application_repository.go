@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var roleApplicationColumns = &helper.QueryColumns{
+	Table:        "role_applications",
+	StatusColumn: "status",
+	Sortable:     []string{"role", "created_at", "reviewed_at"},
+	DIDColumns:   []string{"did"},
+	DateColumn:   "created_at",
+}
+
+type RoleApplicationRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewRoleApplicationRepository(db *postgres.PostgresDB) schema.IRoleApplicationRepository {
+	return &RoleApplicationRepository{
+		db: db,
+	}
+}
+
+func (r *RoleApplicationRepository) FindRoleApplicationByPublicId(ctx context.Context, publicId string) (*schema.RoleApplication, error) {
+	var entity schema.RoleApplication
+	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", publicId).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// LockRoleApplication reloads the application with a row lock for the rest of the transaction in ctx, so two
+// admins cannot review it at once.
+func (r *RoleApplicationRepository) LockRoleApplication(ctx context.Context, id uint) (*schema.RoleApplication, error) {
+	var entity schema.RoleApplication
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&entity, id).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+func (r *RoleApplicationRepository) FindAllRoleApplications(ctx context.Context, spec *helper.QuerySpec) ([]*schema.RoleApplication, int64, error) {
+	return r.findAll(ctx, spec)
+}
+
+func (r *RoleApplicationRepository) FindAllRoleApplicationsByDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*schema.RoleApplication, int64, error) {
+	return r.findAll(ctx, spec, "did = ?", did)
+}
+
+func (r *RoleApplicationRepository) findAll(ctx context.Context, spec *helper.QuerySpec, conds ...interface{}) ([]*schema.RoleApplication, int64, error) {
+	var (
+		entities []*schema.RoleApplication
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&schema.RoleApplication{})
+	if len(conds) > 0 {
+		db = db.Where(conds[0], conds[1:]...)
+	}
+	db = db.Scopes(spec.Filter(roleApplicationColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Scopes(spec.Paginate(roleApplicationColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
+func (r *RoleApplicationRepository) ExistsPendingRoleApplication(ctx context.Context, identityID uint, role constant.IdentityRole) (bool, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&schema.RoleApplication{}).
+		Where("identity_id = ? AND role = ? AND status = ?", identityID, role, constant.RoleApplicationPendingStatus).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *RoleApplicationRepository) CreateRoleApplication(ctx context.Context, entity *schema.RoleApplication) (*schema.RoleApplication, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(entity).Error; err != nil {
+		return nil, err
+	}
+	return entity, nil
+}
+
+func (r *RoleApplicationRepository) UpdateRoleApplication(ctx context.Context, entity *schema.RoleApplication, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -43,3 +43,11 @@ func (r *SchemaAttributeRepository) UpdateAttributesBySchemaId(ctx context.Conte
 	}
 	return nil
 }
+
+func (r *SchemaAttributeRepository) UpdateSchemaAttribute(ctx context.Context, entity *schema.SchemaAttribute, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(entity).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
@@ -44,9 +44,63 @@ func (r *SchemaRepository) FindSchemaByContextURL(ctx context.Context, url strin
 	return &entity, nil
 }
 
-func (r *SchemaRepository) FindAllSchemas(ctx context.Context) ([]*schema.Schema, error) {
+func (r *SchemaRepository) FindSchemasByIds(ctx context.Context, ids []uint) ([]*schema.Schema, error) {
 	var entities []*schema.Schema
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Issuer").Preload("SchemaAttributes").Find(&entities).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Issuer").Preload("SchemaAttributes").Where("id IN ?", ids).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+
+	byId := make(map[uint]*schema.Schema, len(entities))
+	for _, entity := range entities {
+		byId[entity.ID] = entity
+	}
+	ordered := make([]*schema.Schema, 0, len(entities))
+	for _, id := range ids {
+		if entity, ok := byId[id]; ok {
+			ordered = append(ordered, entity)
+		}
+	}
+	return ordered, nil
+}
+
+// FindSchemasAfterId returns up to limit schemas with their attributes in id order, starting after afterId.
+func (r *SchemaRepository) FindSchemasAfterId(ctx context.Context, afterId uint, limit int) ([]*schema.Schema, error) {
+	var entities []*schema.Schema
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("SchemaAttributes").Where("id > ?", afterId).Order("id").Limit(limit).Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *SchemaRepository) SearchSchemas(ctx context.Context, filter *schema.SchemaFilter) ([]*schema.Schema, error) {
+	db := r.db.GetGormDB().WithContext(ctx).Preload("Issuer").Preload("SchemaAttributes")
+
+	if filter.IssuerDID != "" {
+		db = db.Where("issuer_did = ?", filter.IssuerDID)
+	}
+	if filter.DocumentType != "" {
+		db = db.Where("document_type = ?", filter.DocumentType)
+	}
+	if filter.Status != "" {
+		db = db.Where("status = ?", filter.Status)
+	}
+	if filter.Type != "" {
+		db = db.Where("type = ?", filter.Type)
+	}
+	if filter.IsMerklized != nil {
+		db = db.Where("is_merklized = ?", *filter.IsMerklized)
+	}
+	if filter.Query != "" {
+		db = db.Where(`(to_tsvector('simple', title || ' ' || description) @@ plainto_tsquery('simple', ?)
+			OR EXISTS (SELECT 1 FROM schema_attributes sa WHERE sa.schema_id = schemas.id
+				AND to_tsvector('simple', sa.name || ' ' || sa.title) @@ plainto_tsquery('simple', ?)))`, filter.Query, filter.Query)
+	}
+	if filter.Cursor > 0 {
+		db = db.Where("id < ?", filter.Cursor)
+	}
+
+	var entities []*schema.Schema
+	if err := db.Order("id DESC").Limit(filter.Limit).Find(&entities).Error; err != nil {
 		return nil, err
 	}
 	return entities, nil
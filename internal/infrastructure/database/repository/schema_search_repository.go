@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"be/config"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/elasticsearch"
+	"be/pkg/logger"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+type schemaSearchDocument struct {
+	ID           uint     `json:"id"`
+	PublicID     string   `json:"public_id"`
+	IssuerDID    string   `json:"issuer_did"`
+	DocumentType string   `json:"document_type"`
+	Status       string   `json:"status"`
+	Type         string   `json:"type"`
+	IsMerklized  bool     `json:"is_merklized"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Attributes   []string `json:"attributes"`
+}
+
+type SchemaSearchRepository struct {
+	config *config.Config
+	es     *elasticsearch.ElasticsearchDB
+	logger *logger.ZapLogger
+}
+
+func NewSchemaSearchRepository(config *config.Config, es *elasticsearch.ElasticsearchDB, logger *logger.ZapLogger) schema.ISchemaSearchRepository {
+	return &SchemaSearchRepository{config: config, es: es, logger: logger}
+}
+
+func (r *SchemaSearchRepository) IsAvailable() bool {
+	return r.es.Enabled()
+}
+
+func (r *SchemaSearchRepository) IndexSchema(ctx context.Context, entity *schema.Schema) error {
+	if !r.IsAvailable() {
+		return nil
+	}
+
+	attributes := make([]string, 0, len(entity.SchemaAttributes))
+	for _, item := range entity.SchemaAttributes {
+		attributes = append(attributes, item.Name, item.Title)
+	}
+
+	document := &schemaSearchDocument{
+		ID:           entity.ID,
+		PublicID:     entity.PublicID.String(),
+		IssuerDID:    entity.IssuerDID,
+		DocumentType: string(entity.DocumentType),
+		Status:       string(entity.Status),
+		Type:         entity.Type,
+		IsMerklized:  entity.IsMerklized,
+		Title:        entity.Title,
+		Description:  entity.Description,
+		Attributes:   attributes,
+	}
+
+	return r.es.IndexDocument(ctx, r.config.Elasticsearch.SchemaIndex, strconv.FormatUint(uint64(entity.ID), 10), document)
+}
+
+func (r *SchemaSearchRepository) SearchSchemaIds(ctx context.Context, filter *schema.SchemaFilter) ([]uint, error) {
+	filters := []map[string]interface{}{}
+	term := func(field string, value interface{}) {
+		filters = append(filters, map[string]interface{}{"term": map[string]interface{}{field: value}})
+	}
+
+	if filter.IssuerDID != "" {
+		term("issuer_did.keyword", filter.IssuerDID)
+	}
+	if filter.DocumentType != "" {
+		term("document_type.keyword", filter.DocumentType)
+	}
+	if filter.Status != "" {
+		term("status.keyword", filter.Status)
+	}
+	if filter.Type != "" {
+		term("type.keyword", filter.Type)
+	}
+	if filter.IsMerklized != nil {
+		term("is_merklized", *filter.IsMerklized)
+	}
+	if filter.Cursor > 0 {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{"id": map[string]interface{}{"lt": filter.Cursor}}})
+	}
+
+	query := map[string]interface{}{
+		"size":    filter.Limit,
+		"_source": []string{"id"},
+		"sort":    []map[string]interface{}{{"id": "desc"}},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must": map[string]interface{}{
+					"multi_match": map[string]interface{}{
+						"query":  filter.Query,
+						"fields": []string{"title^3", "attributes^2", "description"},
+					},
+				},
+				"filter": filters,
+			},
+		},
+	}
+
+	result, err := r.es.Search(ctx, r.config.Elasticsearch.SchemaIndex, query)
+	if err != nil {
+		r.logger.WithContext(ctx).Warn("schema search failed", zap.Error(err))
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		var document schemaSearchDocument
+		if err := json.Unmarshal(hit.Source, &document); err != nil {
+			return nil, err
+		}
+		ids = append(ids, document.ID)
+	}
+	return ids, nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"be/internal/domain/auth"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/helper"
+	"context"
+	"time"
+)
+
+type SigningKeyRepository struct {
+	db *postgres.PostgresDB
+}
+
+func NewSigningKeyRepository(db *postgres.PostgresDB) auth.ISigningKeyRepository {
+	return &SigningKeyRepository{
+		db: db,
+	}
+}
+
+func (r *SigningKeyRepository) FindUnexpiredSigningKeys(ctx context.Context, now time.Time) ([]*auth.SigningKey, error) {
+	var entities []*auth.SigningKey
+	db := r.db.GetGormDB().WithContext(ctx)
+	if err := db.Where("expires_at IS NULL OR expires_at > ?", now).Order("activated_at DESC").Find(&entities).Error; err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
+
+func (r *SigningKeyRepository) CreateSigningKey(ctx context.Context, key *auth.SigningKey) (*auth.SigningKey, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(key).Error; err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// ExpireSigningKeys schedules the expiry of every key other than exceptKID that has none yet.
+func (r *SigningKeyRepository) ExpireSigningKeys(ctx context.Context, exceptKID string, expiresAt time.Time) (int64, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Model(&auth.SigningKey{}).
+		Where("kid <> ? AND expires_at IS NULL", exceptKID).
+		Update("expires_at", expiresAt)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
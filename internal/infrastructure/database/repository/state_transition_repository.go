@@ -24,3 +24,13 @@ func (r *StateTransitionRepository) CreateStateTransition(ctx context.Context, e
 	}
 	return entity, nil
 }
+
+// ExistsStateTransition reports whether the identity ever transitioned from or to the state.
+func (r *StateTransitionRepository) ExistsStateTransition(ctx context.Context, identityID uint, state string) (bool, error) {
+	var count int64
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(&gist.StateTransition{}).Where("identity_id = ? AND (old_state = ? OR new_state = ?)", identityID, state, state).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -6,8 +6,15 @@ import (
 	"be/internal/shared/helper"
 	"be/pkg/logger"
 	"context"
+
+	"gorm.io/gorm"
 )
 
+var userColumns = &helper.QueryColumns{
+	Table:    "users",
+	Sortable: []string{"name", "email", "role", "created_at"},
+}
+
 type UserRepository struct {
 	db     *postgres.PostgresDB
 	logger *logger.ZapLogger
@@ -17,9 +24,14 @@ func NewUserRepository(db *postgres.PostgresDB, logger *logger.ZapLogger) auth.I
 	return &UserRepository{db: db, logger: logger}
 }
 
+// withIdentities loads the DIDs linked to an account.
+func withIdentities(db *gorm.DB) *gorm.DB {
+	return db.Preload("Identities")
+}
+
 func (r *UserRepository) FindUserById(ctx context.Context, id int64) (*auth.User, error) {
 	var user auth.User
-	if err := r.db.GetGormDB().WithContext(ctx).First(&user).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withIdentities).First(&user, id).Error; err != nil {
 		return nil, err
 	}
 
@@ -28,7 +40,7 @@ func (r *UserRepository) FindUserById(ctx context.Context, id int64) (*auth.User
 
 func (r *UserRepository) FindUserByPublicId(ctx context.Context, id string) (*auth.User, error) {
 	var user auth.User
-	if err := r.db.GetGormDB().WithContext(ctx).Where("public_id = ?", id).First(&user).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withIdentities).Where("public_id = ?", id).First(&user).Error; err != nil {
 		return nil, err
 	}
 
@@ -37,19 +49,25 @@ func (r *UserRepository) FindUserByPublicId(ctx context.Context, id string) (*au
 
 func (r *UserRepository) FindUserByEmail(ctx context.Context, email string) (*auth.User, error) {
 	var user auth.User
-	if err := r.db.GetGormDB().WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+	if err := r.db.GetGormDB().WithContext(ctx).Scopes(withIdentities).Where("email = ?", email).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *UserRepository) FindAllUsers(ctx context.Context) ([]*auth.User, error) {
-	var users []*auth.User
-	if err := r.db.GetGormDB().WithContext(ctx).Find(&users).Error; err != nil {
-		return nil, err
+func (r *UserRepository) FindAllUsers(ctx context.Context, spec *helper.QuerySpec) ([]*auth.User, int64, error) {
+	var (
+		entities []*auth.User
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&auth.User{}).Scopes(spec.Filter(userColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
 	}
-
-	return users, nil
+	if err := db.Scopes(spec.Paginate(userColumns), withIdentities).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
 }
 
 func (r *UserRepository) SaveUser(ctx context.Context, user *auth.User) (*auth.User, error) {
@@ -60,3 +78,48 @@ func (r *UserRepository) SaveUser(ctx context.Context, user *auth.User) (*auth.U
 
 	return user, nil
 }
+
+func (r *UserRepository) UpdateUser(ctx context.Context, user *auth.User, changes map[string]interface{}) error {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Model(user).Updates(changes).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+// AdvanceTOTPCounter records the time step of an accepted TOTP code. It reports false when the account already
+// accepted that step or a later one.
+func (r *UserRepository) AdvanceTOTPCounter(ctx context.Context, user *auth.User, counter int64) (bool, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Model(&auth.User{}).Where("id = ? AND totp_last_counter < ?", user.ID, counter).Update("totp_last_counter", counter)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *UserRepository) ExistsUserIdentity(ctx context.Context, userID int64, did string) (bool, error) {
+	var count int64
+	db := r.db.GetGormDB().WithContext(ctx)
+	if err := db.Model(&auth.UserIdentity{}).Where("user_id = ? AND did = ?", userID, did).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *UserRepository) CreateUserIdentity(ctx context.Context, link *auth.UserIdentity) (*auth.UserIdentity, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Create(link).Error; err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+func (r *UserRepository) DeleteUserIdentity(ctx context.Context, userID int64, did string) (int64, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	result := db.Where("user_id = ? AND did = ?", userID, did).Delete(&auth.UserIdentity{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	return result.RowsAffected, nil
+}
@@ -3,11 +3,23 @@ package repository
 import (
 	"be/config"
 	"be/internal/domain/credential"
+	"be/internal/domain/schema"
 	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var verifiableCredentialColumns = &helper.QueryColumns{
+	Table:        "verifiable_credentials",
+	StatusColumn: "status",
+	Sortable:     []string{"issuance_date", "expiration_date", "created_at", "updated_at"},
+	DIDColumns:   []string{"holder_did", "issuer_did"},
+}
+
 type VerifiableCredentialRepository struct {
 	db     *postgres.PostgresDB
 	config *config.Config
@@ -36,24 +48,64 @@ func (r *VerifiableCredentialRepository) FindVerifiableCredentialByCredentialId(
 	return &entity, nil
 }
 
-func (r *VerifiableCredentialRepository) FindAllVerifiableCredentialsByHolderDID(ctx context.Context, did string) ([]*credential.VerifiableCredential, error) {
-	var entities []*credential.VerifiableCredential
+func (r *VerifiableCredentialRepository) FindVerifiableCredentialById(ctx context.Context, id uint) (*credential.VerifiableCredential, error) {
+	var entity credential.VerifiableCredential
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("id = ?", id).First(&entity).Error; err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
 
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("holder_did = ?", did).Find(&entities).Error; err != nil {
+// LockVerifiableCredential loads the credential with a row lock so it is refreshed or revoked once.
+// It must be called inside a transaction.
+func (r *VerifiableCredentialRepository) LockVerifiableCredential(ctx context.Context, id uint) (*credential.VerifiableCredential, error) {
+	var entity credential.VerifiableCredential
+	db := helper.WithTx(ctx, r.db.GetGormDB())
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&entity).Error; err != nil {
 		return nil, err
 	}
-	return entities, nil
+	return &entity, nil
 }
 
-func (r *VerifiableCredentialRepository) FindAllVerifiableCredentialsByIssuerDID(ctx context.Context, did string) ([]*credential.VerifiableCredential, error) {
-	var entities []*credential.VerifiableCredential
+func (r *VerifiableCredentialRepository) FindAllVerifiableCredentialsByHolderDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*credential.VerifiableCredential, int64, error) {
+	var (
+		entities []*credential.VerifiableCredential
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.VerifiableCredential{}).Where("holder_did = ?", did).Scopes(spec.Filter(verifiableCredentialColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Preload("Schema").Scopes(spec.Paginate(verifiableCredentialColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
 
-	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("issuer_did = ?", did).Find(&entities).Error; err != nil {
+// FindAllVerifiableCredentialsForExport loads every credential of the holder, whatever its status, with its schema.
+func (r *VerifiableCredentialRepository) FindAllVerifiableCredentialsForExport(ctx context.Context, holderDID string) ([]*credential.VerifiableCredential, error) {
+	var entities []*credential.VerifiableCredential
+	if err := r.db.GetGormDB().WithContext(ctx).Preload("Schema").Where("holder_did = ?", holderDID).Order("id").Find(&entities).Error; err != nil {
 		return nil, err
 	}
 	return entities, nil
 }
 
+func (r *VerifiableCredentialRepository) FindAllVerifiableCredentialsByIssuerDID(ctx context.Context, did string, spec *helper.QuerySpec) ([]*credential.VerifiableCredential, int64, error) {
+	var (
+		entities []*credential.VerifiableCredential
+		total    int64
+	)
+	db := r.db.GetGormDB().WithContext(ctx).Model(&credential.VerifiableCredential{}).Where("issuer_did = ?", did).Scopes(spec.Filter(verifiableCredentialColumns)).Session(&gorm.Session{})
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := db.Preload("Schema").Scopes(spec.Paginate(verifiableCredentialColumns)).Find(&entities).Error; err != nil {
+		return nil, 0, err
+	}
+	return entities, total, nil
+}
+
 func (r *VerifiableCredentialRepository) CreateVerifiableCredential(ctx context.Context, entity *credential.VerifiableCredential) (*credential.VerifiableCredential, error) {
 	db := helper.WithTx(ctx, r.db.GetGormDB())
 	if err := db.Create(entity).Error; err != nil {
@@ -77,3 +129,29 @@ func (r *VerifiableCredentialRepository) UpdateVerifiableCredential(ctx context.
 	}
 	return nil
 }
+
+// FlagVerifiableCredentialsForReissue marks the holder's issued credentials built from documents of the given type.
+// An empty document type flags every issued credential of the holder.
+func (r *VerifiableCredentialRepository) FlagVerifiableCredentialsForReissue(ctx context.Context, holderDID string, documentType constant.DocumentType) (int64, error) {
+	db := helper.WithTx(ctx, r.db.GetGormDB()).Model(&credential.VerifiableCredential{}).
+		Where("holder_did = ? AND status = ? AND reissue_required = ?", holderDID, constant.VerifiableCredentialIssuedStatus, false)
+	if documentType != "" {
+		db = db.Where("schema_id IN (?)", r.db.GetGormDB().Model(&schema.Schema{}).Select("id").Where("document_type = ?", documentType))
+	}
+	result := db.Update("reissue_required", true)
+	return result.RowsAffected, result.Error
+}
+
+// LockIssuedVerifiableCredentialsByDocument loads the holder's issued credentials built from documents of the given
+// type with row locks, so they can be revoked. It must be called inside a transaction.
+func (r *VerifiableCredentialRepository) LockIssuedVerifiableCredentialsByDocument(ctx context.Context, holderDID string, documentType constant.DocumentType) ([]*credential.VerifiableCredential, error) {
+	var entities []*credential.VerifiableCredential
+	err := helper.WithTx(ctx, r.db.GetGormDB()).Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("holder_did = ? AND status = ?", holderDID, constant.VerifiableCredentialIssuedStatus).
+		Where("schema_id IN (?)", r.db.GetGormDB().Model(&schema.Schema{}).Select("id").Where("document_type = ?", documentType)).
+		Order("id").Find(&entities).Error
+	if err != nil {
+		return nil, err
+	}
+	return entities, nil
+}
@@ -2,6 +2,7 @@ package ipfs
 
 import (
 	"be/config"
+	"be/pkg/logger"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -10,10 +11,13 @@ import (
 	"net/http"
 	"net/url"
 	"time"
+
+	"go.uber.org/zap"
 )
 
 type Pinata struct {
 	config *config.Config
+	logger *logger.ZapLogger
 }
 
 type PinataResponse struct {
@@ -24,10 +28,12 @@ type PinataResponse struct {
 
 func NewPinata(
 	config *config.Config,
+	logger *logger.ZapLogger,
 ) *Pinata {
 
 	return &Pinata{
 		config: config,
+		logger: logger,
 	}
 }
 
@@ -100,8 +106,7 @@ func (i *Pinata) Upload(fileName string, content []byte) (string, error) {
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
-	fmt.Println("Pinata response status:", resp.StatusCode)
-	fmt.Println("Pinata response body:", string(respBody))
+	i.logger.Debug("pinata upload response", zap.String("file_name", fileName), zap.Int("status", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		return "", fmt.Errorf("Pinata upload failed, status %d: %s", resp.StatusCode, string(respBody))
@@ -113,7 +118,7 @@ func (i *Pinata) Upload(fileName string, content []byte) (string, error) {
 		return "", fmt.Errorf("failed to unmarshal Pinata response: %w", err)
 	}
 
-	fmt.Println("[DEBUG] Upload success, IpfsHash:", result.IpfsHash)
+	i.logger.Info("pinned file to ipfs", zap.String("file_name", fileName), zap.String("cid", result.IpfsHash))
 	return result.IpfsHash, nil
 }
 
@@ -137,9 +142,7 @@ func (i *Pinata) Remove(cid string) error {
 	defer resp.Body.Close()
 
 	respBody, _ := io.ReadAll(resp.Body)
-
-	fmt.Println("Pinata response status:", resp.StatusCode)
-	fmt.Println("Pinata response body:", string(respBody))
+	i.logger.Debug("pinata unpin response", zap.String("cid", cid), zap.Int("status", resp.StatusCode))
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("pinata delete error %d: %s", resp.StatusCode, string(respBody))
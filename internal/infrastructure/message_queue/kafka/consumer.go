@@ -8,6 +8,7 @@ import (
 	"sync"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"go.uber.org/zap"
 )
 
 type Handler func(ctx context.Context, msg *Message) error
@@ -101,22 +102,25 @@ func (c *Consumer) handleMessage(ctx context.Context, msg *kafka.Message) {
 	for _, h := range msg.Headers {
 		consumerMsg.Headers[h.Key] = string(h.Value)
 	}
+	if requestID := consumerMsg.Headers[logger.RequestIDHeader]; requestID != "" {
+		ctx = logger.WithRequestID(ctx, requestID)
+	}
 
 	if !exists {
-		c.logger.Error(fmt.Sprintf("No handler for topic:%s", topic))
+		c.logger.WithContext(ctx).Error("no handler for topic", zap.String("topic", topic))
 		return
 	}
 
 	err := handler(ctx, consumerMsg)
 	if err != nil {
-		c.logger.Error(fmt.Sprintf("Handler error for topic:%s", topic))
+		c.logger.WithContext(ctx).Error("handler failed", zap.String("topic", topic), zap.Error(err))
 		return
 	}
 
 	if !c.config.Kafka.Consumer.EnableAutoCommit {
 		_, err := c.consumer.CommitMessage(msg)
 		if err != nil {
-			c.logger.Error(fmt.Sprintf("Commit Failed: %s", err))
+			c.logger.WithContext(ctx).Error("commit failed", zap.String("topic", topic), zap.Error(err))
 			return
 		}
 	}
@@ -38,7 +38,7 @@ func NewProducer(config *config.Config, logger *logger.ZapLogger, clientID strin
 	return p, err
 }
 
-func (p *Producer) SendMessage(msg *Message) error {
+func (p *Producer) SendMessage(ctx context.Context, msg *Message) error {
 	value, err := p.serialize(msg.Value)
 
 	if err != nil {
@@ -55,19 +55,9 @@ func (p *Producer) SendMessage(msg *Message) error {
 			Topic:     &msg.Topic,
 			Partition: partition,
 		},
-		Key:   []byte(msg.Key),
-		Value: value,
-	}
-
-	if len(msg.Headers) > 0 {
-		headers := make([]kafka.Header, 0, len(msg.Headers))
-		for k, v := range msg.Headers {
-			headers = append(kafkaMsg.Headers, kafka.Header{
-				Key:   k,
-				Value: []byte(v),
-			})
-		}
-		kafkaMsg.Headers = headers
+		Key:     []byte(msg.Key),
+		Value:   value,
+		Headers: messageHeaders(ctx, msg.Headers),
 	}
 
 	return p.producer.Produce(kafkaMsg, nil)
@@ -92,19 +82,11 @@ func (p *Producer) SendMessageSync(ctx context.Context, msg *Message) (*Delivery
 			Topic:     &msg.Topic,
 			Partition: parition,
 		},
-		Key:   []byte(msg.Key),
-		Value: value,
+		Key:     []byte(msg.Key),
+		Value:   value,
+		Headers: messageHeaders(ctx, msg.Headers),
 	}
 
-	if len(msg.Headers) > 0 {
-		kafkaMsg.Headers = make([]kafka.Header, 0, len(msg.Headers))
-		for k, v := range msg.Headers {
-			kafkaMsg.Headers = append(kafkaMsg.Headers, kafka.Header{
-				Key:   k,
-				Value: []byte(v),
-			})
-		}
-	}
 	err = p.producer.Produce(kafkaMsg, deliveryChan)
 
 	if err != nil {
@@ -128,12 +110,12 @@ func (p *Producer) SendMessageSync(ctx context.Context, msg *Message) (*Delivery
 	}
 }
 
-func (p *Producer) SendJSON(topic, key string, data interface{}) error {
+func (p *Producer) SendJSON(ctx context.Context, topic, key string, data interface{}) error {
 	value, err := p.serialize(data)
 	if err != nil {
 		return fmt.Errorf("serialize error: %w", err)
 	}
-	return p.SendMessage(&Message{
+	return p.SendMessage(ctx, &Message{
 		Topic: topic,
 		Key:   key,
 		Value: value,
@@ -158,12 +140,12 @@ func (p *Producer) SendJSONSync(ctx context.Context, topic, key string, data int
 	})
 }
 
-func (p *Producer) SendString(topic, key, data string) error {
+func (p *Producer) SendString(ctx context.Context, topic, key, data string) error {
 	value, err := p.serialize(data)
 	if err != nil {
 		return fmt.Errorf("serialize error: %w", err)
 	}
-	return p.SendMessage(&Message{
+	return p.SendMessage(ctx, &Message{
 		Topic: topic,
 		Key:   key,
 		Value: value,
@@ -197,6 +179,33 @@ func (p *Producer) handleDeliveryReports() {
 	}
 }
 
+// messageHeaders converts the headers of a message, adding the request ID of ctx so consumers log under the ID of
+// the request that produced the message.
+func messageHeaders(ctx context.Context, headers map[string]string) []kafka.Header {
+	requestID := logger.RequestIDFromContext(ctx)
+	if len(headers) == 0 && requestID == "" {
+		return nil
+	}
+
+	kafkaHeaders := make([]kafka.Header, 0, len(headers)+1)
+	for k, v := range headers {
+		if k == logger.RequestIDHeader && requestID != "" {
+			continue
+		}
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{
+			Key:   k,
+			Value: []byte(v),
+		})
+	}
+	if requestID != "" {
+		kafkaHeaders = append(kafkaHeaders, kafka.Header{
+			Key:   logger.RequestIDHeader,
+			Value: []byte(requestID),
+		})
+	}
+	return kafkaHeaders
+}
+
 func (p *Producer) serialize(value interface{}) ([]byte, error) {
 	switch v := value.(type) {
 	case string:
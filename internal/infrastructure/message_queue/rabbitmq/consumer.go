@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/rabbitmq/amqp091-go"
+	"go.uber.org/zap"
 )
 
 type Consumer struct {
@@ -35,19 +36,24 @@ func (c *Consumer) Consume(ctx context.Context, queue, tag string) error {
 				if !ok {
 					return
 				}
-				err := HandleMessage(ctx, msg)
+				msgCtx := ctx
+				if requestID, ok := msg.Headers[logger.RequestIDHeader].(string); ok && requestID != "" {
+					msgCtx = logger.WithRequestID(ctx, requestID)
+				}
+				msgLogger := c.logger.WithContext(msgCtx)
+				err := HandleMessage(msgCtx, msg)
 				if err != nil {
-					c.logger.Error(fmt.Sprintf("Failed to handle message: %s", err))
+					msgLogger.Error(fmt.Sprintf("Failed to handle message: %s", err))
 					if err := msg.Nack(false, true); err != nil {
-						c.logger.Error(fmt.Sprintf("Failed to nack message: %s", err))
+						msgLogger.Error(fmt.Sprintf("Failed to nack message: %s", err))
 					}
 					continue
 				}
 
 				if err := msg.Ack(false); err != nil {
-					c.logger.Info(fmt.Sprintf("Failed to ack message: %s", err))
+					msgLogger.Info(fmt.Sprintf("Failed to ack message: %s", err))
 				} else {
-					c.logger.Info(fmt.Sprintf("Message processed and acked: %s", msg.Body))
+					msgLogger.Info("Message processed and acked", zap.String("message_id", msg.MessageId), zap.String("routing_key", msg.RoutingKey))
 				}
 			case <-ctx.Done():
 				c.logger.Info("Context cancelled in goroutine !")
@@ -20,9 +20,10 @@ func NewProducer(queue *RabbitQueue, logger *logger.ZapLogger) (*Producer, error
 }
 
 func (p *Producer) Publish(ctx context.Context, exchange string, message Message) error {
+	message.Headers = messageHeaders(ctx, message.Headers)
 	body, err := json.Marshal(message)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to marshal message: %s", err))
+		p.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to marshal message: %s", err))
 		return err
 	}
 
@@ -36,7 +37,7 @@ func (p *Producer) Publish(ctx context.Context, exchange string, message Message
 	})
 
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to publish: %s", err))
+		p.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to publish: %s", err))
 		return err
 	}
 
@@ -45,15 +46,16 @@ func (p *Producer) Publish(ctx context.Context, exchange string, message Message
 
 func (p *Producer) PublishWithAck(ctx context.Context, exchange, routingKey string, message Message) error {
 	if err := p.queue.channel.Confirm(false); err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to put channel in confirm mode: %s", err))
+		p.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to put channel in confirm mode: %s", err))
 		return err
 	}
 
 	confirms := p.queue.channel.NotifyPublish(make(chan amqp091.Confirmation, 1))
 
+	message.Headers = messageHeaders(ctx, message.Headers)
 	body, err := json.Marshal(message)
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to marshal message: %s", err))
+		p.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to marshal message: %s", err))
 		return err
 	}
 
@@ -67,14 +69,29 @@ func (p *Producer) PublishWithAck(ctx context.Context, exchange, routingKey stri
 	})
 
 	if err != nil {
-		p.logger.Error(fmt.Sprintf("Failed to publish: %s", err))
+		p.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to publish: %s", err))
 		return err
 	}
 
 	confirmed := <-confirms
 	if !confirmed.Ack {
-		p.logger.Error("Failed to receive confirm")
+		p.logger.WithContext(ctx).Error("Failed to receive confirm")
 	}
 
 	return nil
 }
+
+// messageHeaders returns a copy of headers carrying the request ID of ctx, so consumers log under the ID of the
+// request that published the message.
+func messageHeaders(ctx context.Context, headers amqp091.Table) amqp091.Table {
+	requestID := logger.RequestIDFromContext(ctx)
+	if requestID == "" {
+		return headers
+	}
+	table := make(amqp091.Table, len(headers)+1)
+	for k, v := range headers {
+		table[k] = v
+	}
+	table[logger.RequestIDHeader] = requestID
+	return table
+}
@@ -0,0 +1,244 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/cache/redis"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// IAPIKeyService manages the API keys verifier backends call the proof APIs with instead of a ZK login.
+type IAPIKeyService interface {
+	CreateAPIKey(ctx context.Context, request *dto.APIKeyCreatedRequestDto) (*dto.APIKeyResponseDto, error)
+	GetAPIKeys(ctx context.Context, did string, spec *helper.QuerySpec) ([]*dto.APIKeyResponseDto, *helper.Pagination, error)
+	RevokeAPIKey(ctx context.Context, did string, id string) error
+	AuthenticateAPIKey(ctx context.Context, key string, ip string) (*dto.ZKClaims, *dto.APIKeyResponseDto, error)
+}
+
+const (
+	// APIKeyPrefix starts every API key, so a key can be told from a JWT without parsing it.
+	APIKeyPrefix = "zkv_"
+	// apiKeyPrefixLength is how much of a key is kept in clear to identify it.
+	apiKeyPrefixLength = len(APIKeyPrefix) + 8
+
+	defaultAPIKeyRateLimit = 60
+	maxAPIKeyRateLimit     = 600
+	defaultMaxAPIKeys      = 10
+
+	apiKeyRateWindow = time.Minute
+	// last use is written at most this often, so each call does not cost a write
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var apiKeyScopes = []constant.APIKeyScope{constant.APIKeyProofCreateScope, constant.APIKeyProofReadScope, constant.APIKeyProofVerifyScope}
+
+func apiKeyRateRedisKey(keyID string) string {
+	return "apikey:rate:" + keyID
+}
+
+func hashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
+
+type APIKeyService struct {
+	config       *config.Config
+	logger       *logger.ZapLogger
+	redis        *redis.RedisCache
+	apiKeyRepo   schema.IIdentityAPIKeyRepository
+	identityRepo schema.IIdentityRepository
+}
+
+func NewAPIKeyService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	redis *redis.RedisCache,
+	apiKeyRepo schema.IIdentityAPIKeyRepository,
+	identityRepo schema.IIdentityRepository,
+) IAPIKeyService {
+	return &APIKeyService{
+		config:       config,
+		logger:       logger,
+		redis:        redis,
+		apiKeyRepo:   apiKeyRepo,
+		identityRepo: identityRepo,
+	}
+}
+
+func (s *APIKeyService) maxRateLimit() int {
+	if s.config.APIKey.MaxRateLimit > 0 {
+		return s.config.APIKey.MaxRateLimit
+	}
+	return maxAPIKeyRateLimit
+}
+
+// CreateAPIKey issues a key for a verifier. The key is returned once; only its hash is stored.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, request *dto.APIKeyCreatedRequestDto) (*dto.APIKeyResponseDto, error) {
+	scopes := make([]string, 0, len(request.Scopes))
+	for _, scope := range request.Scopes {
+		if !slices.Contains(apiKeyScopes, scope) {
+			return nil, &constant.APIKeyScopeInvalid
+		}
+		if !slices.Contains(scopes, string(scope)) {
+			scopes = append(scopes, string(scope))
+		}
+	}
+	rateLimit := request.RateLimit
+	if rateLimit == 0 {
+		rateLimit = s.config.APIKey.DefaultRateLimit
+		if rateLimit <= 0 {
+			rateLimit = defaultAPIKeyRateLimit
+		}
+	}
+	if rateLimit < 1 || rateLimit > s.maxRateLimit() {
+		return nil, &constant.APIKeyRateLimitInvalid
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, &constant.BadRequest
+	}
+
+	identity, err := s.identityRepo.FindIdentityByDID(ctx, request.DID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.IdentityNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	maxKeys := s.config.APIKey.MaxKeysPerDID
+	if maxKeys <= 0 {
+		maxKeys = defaultMaxAPIKeys
+	}
+	count, err := s.apiKeyRepo.CountActiveAPIKeys(ctx, identity.ID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if count >= int64(maxKeys) {
+		return nil, &constant.APIKeyLimitReached
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	key := APIKeyPrefix + secret
+	entity, err := s.apiKeyRepo.CreateAPIKey(ctx, &schema.IdentityAPIKey{
+		IdentityID: identity.ID,
+		DID:        identity.DID,
+		Name:       request.Name,
+		Prefix:     key[:apiKeyPrefixLength],
+		KeyHash:    hashAPIKey(key),
+		Scopes:     scopes,
+		RateLimit:  rateLimit,
+		ExpiresAt:  request.ExpiresAt,
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	s.logger.WithContext(ctx).Info("api key created", zap.String("did", entity.DID), zap.String("key_id", entity.PublicID.String()))
+
+	resp := dto.ToAPIKeyResponseDto(entity)
+	resp.Key = key
+	return resp, nil
+}
+
+func (s *APIKeyService) GetAPIKeys(ctx context.Context, did string, spec *helper.QuerySpec) ([]*dto.APIKeyResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.apiKeyRepo.FindAllAPIKeysByDID(ctx, did, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.APIKeyResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToAPIKeyResponseDto(item))
+		lastID = item.ID
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+// RevokeAPIKey stops a key of the DID from authenticating. Revoking a revoked key is a no-op.
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, did string, id string) error {
+	entity, err := s.apiKeyRepo.FindAPIKeyByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &constant.APIKeyNotFound
+		}
+		return &constant.InternalServer
+	}
+	if entity.DID != did {
+		return &constant.APIKeyNotFound
+	}
+	if entity.RevokedAt != nil {
+		return nil
+	}
+	if err := s.apiKeyRepo.UpdateAPIKey(ctx, entity, map[string]interface{}{"revoked_at": time.Now().UTC()}); err != nil {
+		return &constant.InternalServer
+	}
+	s.logger.WithContext(ctx).Info("api key revoked", zap.String("did", did), zap.String("key_id", id))
+	return nil
+}
+
+// AuthenticateAPIKey resolves a key to claims of the identity that owns it, the way a ZK access token would. The
+// owner must still be an unsuspended verifier, and each call counts against the rate limit of the key.
+func (s *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string, ip string) (*dto.ZKClaims, *dto.APIKeyResponseDto, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, nil, &constant.APIKeyInvalid
+	}
+	entity, err := s.apiKeyRepo.FindAPIKeyByHash(ctx, hashAPIKey(key))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, &constant.APIKeyInvalid
+		}
+		return nil, nil, &constant.InternalServer
+	}
+	now := time.Now().UTC()
+	if entity.RevokedAt != nil || (entity.ExpiresAt != nil && !entity.ExpiresAt.After(now)) {
+		return nil, nil, &constant.APIKeyInvalid
+	}
+
+	rate, err := s.redis.AllowSlidingWindow(ctx, apiKeyRateRedisKey(entity.PublicID.String()), entity.RateLimit, apiKeyRateWindow)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+	if !rate.Allowed {
+		return nil, nil, &constant.APIKeyRateLimited
+	}
+
+	identity, err := s.identityRepo.FindIdentityByDID(ctx, entity.DID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, &constant.APIKeyInvalid
+		}
+		return nil, nil, &constant.InternalServer
+	}
+	if identity.SuspendedAt != nil {
+		return nil, nil, &constant.IdentitySuspended
+	}
+	if !slices.Contains(identity.ActiveRoles(), constant.IdentityVerifierRole) {
+		return nil, nil, &constant.Forbidden
+	}
+
+	if entity.LastUsedAt == nil || now.Sub(*entity.LastUsedAt) >= apiKeyLastUsedInterval {
+		changes := map[string]interface{}{"last_used_at": now, "last_used_ip": ip}
+		if err := s.apiKeyRepo.UpdateAPIKey(ctx, entity, changes); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update api key last use", zap.String("key_id", entity.PublicID.String()), zap.Error(err))
+		}
+	}
+
+	claims := &dto.ZKClaims{APIKeyID: entity.PublicID.String()}
+	setIdentityClaims(claims, dto.ToIdentityResponseDto(identity))
+	return claims, dto.ToAPIKeyResponseDto(entity), nil
+}
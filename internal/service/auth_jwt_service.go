@@ -5,94 +5,158 @@ import (
 	"be/internal/domain/auth"
 	"be/internal/infrastructure/cache/redis"
 	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/shared/utils"
 	"be/internal/transport/http/dto"
 	"be/pkg/logger"
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+const (
+	totpSealPurpose = "operator-totp-secrets"
+	// operatorTokenAudience keeps operator tokens and ZK session tokens, signed by the same key ring, apart
+	operatorTokenAudience = "operator"
+
+	mfaTokenTTL       = 5 * time.Minute
+	identityLinkTTL   = 10 * time.Minute
+	defaultTOTPIssuer = "be"
+
+	// dummyPasswordHash is compared against when the email is unknown, so that login takes as long as for a
+	// wrong password. It uses bcrypt.DefaultCost like the stored hashes.
+	dummyPasswordHash = "$2a$10$bpSOrH0bPGMMfKnSdN1jIO/GFyDTeZz0JZEpjrWiYoVvdcVs6X9/q"
+)
+
 type IAuthJWTService interface {
-	GetAllUsers(ctx context.Context) ([]*dto.UserResponse, error)
+	GetAllUsers(ctx context.Context, spec *helper.QuerySpec) ([]*dto.UserResponse, *helper.Pagination, error)
 	GetProfile(ctx context.Context, id string) (*dto.UserResponse, error)
 	UpdateProfile(ctx context.Context, id string, user *dto.UserRequest) (*dto.UserResponse, error)
+	UpdateUserRole(ctx context.Context, id string, role constant.UserRole, actorID string) (*dto.UserResponse, error)
 	Register(ctx context.Context, email, password, name string) (string, string, error)
-	Login(ctx context.Context, email, password string) (string, string, error)
+	Login(ctx context.Context, email, password string) (*dto.LoginResponse, error)
+	LoginTOTP(ctx context.Context, mfaToken, code string) (*dto.LoginResponse, error)
+	EnrollTOTP(ctx context.Context, id string) (*dto.TOTPEnrollmentResponse, error)
+	ConfirmTOTP(ctx context.Context, id string, code string) error
+	CreateIdentityLinkCode(ctx context.Context, id string) (*dto.IdentityLinkCodeResponse, error)
+	LinkIdentity(ctx context.Context, code string, did string) (*dto.UserResponse, error)
+	UnlinkIdentity(ctx context.Context, id string, did string) error
 	RefreshToken(ctx context.Context, tokenString string) (string, string, error)
 	VerifyToken(tokenString string, tokenType constant.TokenType) (*dto.Claims, error)
 }
 
 type AuthJWTService struct {
-	logger   *logger.ZapLogger
-	config   *config.Config
-	redis    *redis.RedisCache
-	userRepo auth.IUserRepository
+	logger            *logger.ZapLogger
+	config            *config.Config
+	redis             *redis.RedisCache
+	userRepo          auth.IUserRepository
+	identityService   IIdentityService
+	signingKeyService ISigningKeyService
+	totpSealKey       []byte
 }
 
-func NewAuthJWTService(config *config.Config, logger *logger.ZapLogger, redis *redis.RedisCache, userRepo auth.IUserRepository) IAuthJWTService {
-	return &AuthJWTService{
-		config:   config,
-		logger:   logger,
-		redis:    redis,
-		userRepo: userRepo,
+func NewAuthJWTService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	redis *redis.RedisCache,
+	userRepo auth.IUserRepository,
+	identityService IIdentityService,
+	signingKeyService ISigningKeyService,
+) (IAuthJWTService, error) {
+	totpSealKey, err := utils.DeriveSealKey(config.JWT.Secret, totpSealPurpose)
+	if err != nil {
+		return nil, fmt.Errorf("jwt.secret is required to protect the totp secrets: %w", err)
 	}
+	return &AuthJWTService{
+		config:            config,
+		logger:            logger,
+		redis:             redis,
+		userRepo:          userRepo,
+		identityService:   identityService,
+		signingKeyService: signingKeyService,
+		totpSealKey:       totpSealKey,
+	}, nil
+}
+
+func operatorLoginFailuresRedisKey(email string) string {
+	return "authjwt:login:failures:" + strings.ToLower(email)
+}
+
+func mfaTokenRedisKey(token string) string {
+	return "authjwt:mfa:" + token
 }
 
-func (s *AuthJWTService) GetAllUsers(ctx context.Context) ([]*dto.UserResponse, error) {
-	users, err := s.userRepo.FindAllUsers(ctx)
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, &constant.UserNotFound
+func identityLinkRedisKey(code string) string {
+	return "authjwt:identity_link:" + code
+}
+
+func (s *AuthJWTService) GetAllUsers(ctx context.Context, spec *helper.QuerySpec) ([]*dto.UserResponse, *helper.Pagination, error) {
+	users, total, err := s.userRepo.FindAllUsers(ctx, spec)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, &constant.UserNotFound
+		}
+		return nil, nil, &constant.InternalServer
 	}
 	var resp []*dto.UserResponse
+	var lastID uint
 	for _, u := range users {
-		resp = append(resp, &dto.UserResponse{
-			ID:    u.PublicID.String(),
-			Name:  u.Name,
-			Email: u.Email,
-		})
+		resp = append(resp, dto.ToUserResponse(u))
+		lastID = uint(u.ID)
 	}
 
-	return resp, nil
+	return resp, spec.Pagination(total, len(users), lastID), nil
 }
 
 func (s *AuthJWTService) GetProfile(ctx context.Context, id string) (*dto.UserResponse, error) {
-	user, err := s.userRepo.FindUserByPublicId(ctx, id)
-	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, &constant.UserNotFound
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
 	}
-	return &dto.UserResponse{
-		ID:    id,
-		Name:  user.Name,
-		Email: user.Email,
-	}, nil
+	return dto.ToUserResponse(user), nil
 }
 
 func (s *AuthJWTService) UpdateProfile(ctx context.Context, id string, userRequest *dto.UserRequest) (*dto.UserResponse, error) {
-	user, err := s.userRepo.FindUserByPublicId(ctx, id)
+	user, err := s.findUser(ctx, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.UserNotFound
-		}
-		return nil, &constant.InternalServer
+		return nil, err
 	}
 
-	publicId, err := uuid.Parse(id)
-	if err != nil {
+	changes := map[string]interface{}{"name": userRequest.Name, "email": userRequest.Email}
+	if err := s.userRepo.UpdateUser(ctx, user, changes); err != nil {
 		return nil, &constant.InternalServer
 	}
+	user.Name = userRequest.Name
+	user.Email = userRequest.Email
+	return dto.ToUserResponse(user), nil
+}
 
-	userUpdated, err := s.userRepo.SaveUser(ctx, &auth.User{ID: user.ID, PublicID: publicId, Name: userRequest.Name, Email: userRequest.Email})
-
+// UpdateUserRole sets the role stored on an account. Operators cannot change their own role.
+func (s *AuthJWTService) UpdateUserRole(ctx context.Context, id string, role constant.UserRole, actorID string) (*dto.UserResponse, error) {
+	if id == actorID {
+		return nil, &constant.Forbidden
+	}
+	user, err := s.findUser(ctx, id)
 	if err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.UpdateUser(ctx, user, map[string]interface{}{"role": role}); err != nil {
 		return nil, &constant.InternalServer
 	}
-	return &dto.UserResponse{Name: userUpdated.Name, Email: userUpdated.Email}, nil
+	user.Role = role
+	return dto.ToUserResponse(user), nil
 }
 
 func (s *AuthJWTService) Register(ctx context.Context, email, password, name string) (string, string, error) {
@@ -123,113 +187,306 @@ func (s *AuthJWTService) Register(ctx context.Context, email, password, name str
 		return "", "", &constant.InternalServer
 	}
 
-	accessToken, err := s.GetToken(&dto.Claims{
-		ID:    userCreated.PublicID.String(),
-		Email: email,
-		Name:  name,
-	}, constant.AccessToken)
+	return s.issueTokens(ctx, userCreated, false)
+}
 
-	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate access token %s", err))
-		return "", "", &constant.InternalServer
+// Login checks the password of an account. An account with TOTP enabled gets an MFA token to finish the login
+// with LoginTOTP; any other gets tokens that cannot reach the admin APIs.
+func (s *AuthJWTService) Login(ctx context.Context, email, password string) (*dto.LoginResponse, error) {
+	if err := s.checkLoginFailures(ctx, email); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindUserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, &constant.InternalServer
+	}
+	// an unknown email fails the same way and in the same time as a wrong password, so accounts cannot be discovered
+	passwordHash := dummyPasswordHash
+	if user != nil {
+		passwordHash = user.Password
+	}
+	if bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(password)) != nil || user == nil {
+		s.recordLoginFailure(ctx, email)
+		return nil, &constant.Unauthorized
 	}
 
-	refreshToken, err := s.GetToken(&dto.Claims{
-		ID:    userCreated.PublicID.String(),
-		Email: email,
-		Name:  name,
-	}, constant.RefreshToken)
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := randomToken()
+		if err != nil {
+			return nil, &constant.InternalServer
+		}
+		if err := s.redis.Set(ctx, mfaTokenRedisKey(mfaToken), user.PublicID.String(), mfaTokenTTL); err != nil {
+			return nil, &constant.InternalServer
+		}
+		return &dto.LoginResponse{MFARequired: true, MFAToken: mfaToken}, nil
+	}
 
+	s.resetLoginFailures(ctx, email)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user, false)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate refresh token %s", err))
-		return "", "", &constant.InternalServer
+		return nil, err
 	}
-
-	return accessToken, refreshToken, nil
+	return &dto.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
-func (s *AuthJWTService) Login(ctx context.Context, email, password string) (string, string, error) {
-	user, err := s.userRepo.FindUserByEmail(ctx, email)
+// LoginTOTP finishes a password login with a TOTP code. The MFA token is single-use: a wrong code means logging in
+// with the password again.
+func (s *AuthJWTService) LoginTOTP(ctx context.Context, mfaToken, code string) (*dto.LoginResponse, error) {
+	userID, err := s.redis.GetDel(ctx, mfaTokenRedisKey(mfaToken)).Result()
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return "", "", &constant.UserNotFound
+		if errors.Is(err, goredis.Nil) {
+			return nil, &constant.Unauthorized
 		}
-		return "", "", &constant.InternalServer
+		return nil, &constant.InternalServer
+	}
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLoginFailures(ctx, user.Email); err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt == nil {
+		return nil, &constant.Unauthorized
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		s.recordLoginFailure(ctx, user.Email)
+		return nil, err
+	}
+
+	s.resetLoginFailures(ctx, user.Email)
+	accessToken, refreshToken, err := s.issueTokens(ctx, user, true)
 	if err != nil {
-		return "", "", &constant.InternalServer
+		return nil, err
 	}
+	return &dto.LoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
 
-	if string(hashedPassword) != user.Password {
-		return "", "", &constant.Unauthorized
+// EnrollTOTP starts TOTP enrollment with a new secret. The secret only guards logins once ConfirmTOTP has seen a
+// code generated from it; enrolling again before that replaces it.
+func (s *AuthJWTService) EnrollTOTP(ctx context.Context, id string) (*dto.TOTPEnrollmentResponse, error) {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabledAt != nil {
+		return nil, &constant.TOTPAlreadyEnabled
 	}
 
-	accessToken, err := s.GetToken(&dto.Claims{
-		ID:    user.PublicID.String(),
-		Email: email,
-		Name:  user.Name,
-	}, constant.AccessToken)
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	sealed, err := utils.SealWithKey(secret, s.totpSealKey, []byte(user.PublicID.String()))
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if err := s.userRepo.UpdateUser(ctx, user, map[string]interface{}{"totp_secret": sealed, "totp_last_counter": 0}); err != nil {
+		return nil, &constant.InternalServer
+	}
 
+	issuer := s.config.App.Name
+	if issuer == "" {
+		issuer = defaultTOTPIssuer
+	}
+	return &dto.TOTPEnrollmentResponse{
+		Secret: utils.EncodeTOTPSecret(secret),
+		URI:    utils.TOTPURI(issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP enables TOTP once the operator proves their authenticator produces codes for the enrolled secret.
+// Tokens issued before stay without MFA; the next login asks for a code.
+func (s *AuthJWTService) ConfirmTOTP(ctx context.Context, id string, code string) error {
+	user, err := s.findUser(ctx, id)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate access token %s", err))
-		return "", "", &constant.InternalServer
+		return err
+	}
+	if user.TOTPEnabledAt != nil {
+		return &constant.TOTPAlreadyEnabled
+	}
+	if len(user.TOTPSecret) == 0 {
+		return &constant.TOTPNotEnrolled
 	}
+	if err := s.verifyTOTP(ctx, user, code); err != nil {
+		return err
+	}
+	if err := s.userRepo.UpdateUser(ctx, user, map[string]interface{}{"totp_enabled_at": time.Now().UTC()}); err != nil {
+		return &constant.InternalServer
+	}
+	return nil
+}
 
-	refreshToken, err := s.GetToken(&dto.Claims{
-		ID:    user.PublicID.String(),
-		Email: email,
-		Name:  user.Name,
-	}, constant.RefreshToken)
+// verifyTOTP checks a code against the secret of the account and records its time step, so it cannot be used again.
+func (s *AuthJWTService) verifyTOTP(ctx context.Context, user *auth.User, code string) error {
+	secret, err := utils.OpenWithKey(user.TOTPSecret, s.totpSealKey, []byte(user.PublicID.String()))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to open totp secret", zap.String("user_id", user.PublicID.String()), zap.Error(err))
+		return &constant.InternalServer
+	}
+	counter, ok := utils.VerifyTOTP(secret, code, time.Now(), user.TOTPLastCounter)
+	if !ok {
+		return &constant.TOTPInvalid
+	}
+	// a concurrent request may have accepted the same or a later step since the account was read
+	advanced, err := s.userRepo.AdvanceTOTPCounter(ctx, user, counter)
+	if err != nil {
+		return &constant.InternalServer
+	}
+	if !advanced {
+		return &constant.TOTPInvalid
+	}
+	user.TOTPLastCounter = counter
+	return nil
+}
 
+// CreateIdentityLinkCode issues a single-use code that links the account to a DID when it is presented from a ZK
+// session of that DID, so a link always proves control of both sides.
+func (s *AuthJWTService) CreateIdentityLinkCode(ctx context.Context, id string) (*dto.IdentityLinkCodeResponse, error) {
+	user, err := s.findUser(ctx, id)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate refresh token %s", err))
-		return "", "", &constant.InternalServer
+		return nil, err
+	}
+	code, err := randomToken()
+	if err != nil {
+		return nil, &constant.InternalServer
 	}
+	if err := s.redis.Set(ctx, identityLinkRedisKey(code), user.PublicID.String(), identityLinkTTL); err != nil {
+		return nil, &constant.InternalServer
+	}
+	return &dto.IdentityLinkCodeResponse{
+		Code:      code,
+		ExpiresAt: time.Now().UTC().Add(identityLinkTTL),
+	}, nil
+}
 
-	return accessToken, refreshToken, nil
+// LinkIdentity consumes a link code on behalf of the DID of the ZK session presenting it.
+func (s *AuthJWTService) LinkIdentity(ctx context.Context, code string, did string) (*dto.UserResponse, error) {
+	userID, err := s.redis.GetDel(ctx, identityLinkRedisKey(code)).Result()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, &constant.IdentityLinkInvalid
+		}
+		return nil, &constant.InternalServer
+	}
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	linked, err := s.userRepo.ExistsUserIdentity(ctx, user.ID, did)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if !linked {
+		link, err := s.userRepo.CreateUserIdentity(ctx, &auth.UserIdentity{
+			UserID:   user.ID,
+			DID:      did,
+			LinkedAt: time.Now().UTC(),
+		})
+		if err != nil {
+			return nil, &constant.InternalServer
+		}
+		user.Identities = append(user.Identities, link)
+	}
+	return dto.ToUserResponse(user), nil
 }
 
+func (s *AuthJWTService) UnlinkIdentity(ctx context.Context, id string, did string) error {
+	user, err := s.findUser(ctx, id)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.userRepo.DeleteUserIdentity(ctx, user.ID, did)
+	if err != nil {
+		return &constant.InternalServer
+	}
+	if deleted == 0 {
+		return &constant.IdentityLinkNotFound
+	}
+	return nil
+}
+
+// RefreshToken rotates the tokens of an account. The role and linked DIDs are read again; whether the login
+// passed TOTP carries over.
 func (s *AuthJWTService) RefreshToken(ctx context.Context, tokenString string) (string, string, error) {
 	claims, err := s.VerifyToken(tokenString, constant.RefreshToken)
 	if err != nil {
 		return "", "", &constant.InvalidToken
 	}
 
-	accessToken, err := s.GetToken(claims, constant.AccessToken)
+	user, err := s.findUser(ctx, claims.ID)
+	if err != nil {
+		return "", "", &constant.InvalidToken
+	}
+	return s.issueTokens(ctx, user, claims.MFA)
+}
+
+func (s *AuthJWTService) issueTokens(ctx context.Context, user *auth.User, mfa bool) (string, string, error) {
+	dids := make([]string, 0, len(user.Identities))
+	for _, link := range user.Identities {
+		dids = append(dids, link.DID)
+	}
+	claims := &dto.Claims{
+		ID:    user.PublicID.String(),
+		Email: user.Email,
+		Name:  user.Name,
+		Role:  s.operatorRole(ctx, user),
+		MFA:   mfa,
+		DIDs:  dids,
+	}
 
+	accessToken, err := s.GetToken(claims, constant.AccessToken)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate access token %s", err))
+		s.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to generate access token %s", err))
 		return "", "", &constant.InternalServer
 	}
 
 	refreshToken, err := s.GetToken(claims, constant.RefreshToken)
-
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate refresh token %s", err))
+		s.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to generate refresh token %s", err))
 		return "", "", &constant.InternalServer
 	}
+
 	return accessToken, refreshToken, nil
 }
 
+// operatorRole is the role stored on the account, raised to admin when a linked DID holds the admin identity role
+// and is not suspended. The configured admin DIDs can so bootstrap operator admins without touching the database.
+func (s *AuthJWTService) operatorRole(ctx context.Context, user *auth.User) constant.UserRole {
+	if user.Role == constant.UserRoleAdmin {
+		return user.Role
+	}
+	for _, link := range user.Identities {
+		identity, err := s.identityService.GetIdentityByDID(ctx, link.DID)
+		if err != nil {
+			continue
+		}
+		if identity.SuspendedAt == nil && slices.Contains(identity.Roles, constant.IdentityAdminRole) {
+			return constant.UserRoleAdmin
+		}
+	}
+	return user.Role
+}
+
 func (s *AuthJWTService) GetToken(claims *dto.Claims, tokenType constant.TokenType) (string, error) {
 	now := time.Now().UTC()
-	expiration := time.Now().UTC()
-	if tokenType == constant.AccessToken {
-		expiration = expiration.Add(s.config.JWT.AccessTokenTTL)
-	} else {
-		expiration = expiration.Add(s.config.JWT.RefreshTokenTTL)
+	ttl := s.config.JWT.AccessTokenTTL
+	if tokenType == constant.RefreshToken {
+		ttl = s.config.JWT.RefreshTokenTTL
 	}
 
 	claims.RegisteredClaims = jwt.RegisteredClaims{
-		ExpiresAt: jwt.NewNumericDate(expiration),
+		Subject:   string(tokenType),
+		Audience:  jwt.ClaimStrings{operatorTokenAudience},
+		ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
 		IssuedAt:  jwt.NewNumericDate(now),
 		NotBefore: jwt.NewNumericDate(now),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.config.JWT.Secret)
+	tokenString, err := s.signingKeyService.SignToken(claims)
 
 	if err != nil {
 		return "", err
@@ -237,7 +494,7 @@ func (s *AuthJWTService) GetToken(claims *dto.Claims, tokenType constant.TokenTy
 
 	tokenRedisKey := string(tokenType) + "_" + claims.ID
 
-	if err := s.redis.Set(context.Background(), tokenRedisKey, tokenString, s.config.JWT.AccessTokenTTL); err != nil {
+	if err := s.redis.Set(context.Background(), tokenRedisKey, tokenString, ttl); err != nil {
 		return "", err
 	}
 
@@ -246,16 +503,11 @@ func (s *AuthJWTService) GetToken(claims *dto.Claims, tokenType constant.TokenTy
 
 func (s *AuthJWTService) VerifyToken(tokenString string, tokenType constant.TokenType) (*dto.Claims, error) {
 	var claims dto.Claims
-	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.Secret), nil
-	})
+	token, err := s.signingKeyService.ParseToken(tokenString, &claims)
 	if err != nil {
 		return nil, err
 	}
-	if !token.Valid {
+	if !token.Valid || claims.Subject != string(tokenType) || !slices.Contains(claims.Audience, operatorTokenAudience) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
@@ -271,3 +523,54 @@ func (s *AuthJWTService) VerifyToken(tokenString string, tokenType constant.Toke
 
 	return &claims, nil
 }
+
+func (s *AuthJWTService) findUser(ctx context.Context, id string) (*auth.User, error) {
+	user, err := s.userRepo.FindUserByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.UserNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	return user, nil
+}
+
+func (s *AuthJWTService) checkLoginFailures(ctx context.Context, email string) error {
+	failures, err := s.redis.Get(ctx, operatorLoginFailuresRedisKey(email)).Int64()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return &constant.InternalServer
+	}
+	if failures >= maxLoginFailures {
+		return &constant.LoginAttemptsExceeded
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed password or TOTP check of the account within the current failure window.
+func (s *AuthJWTService) recordLoginFailure(ctx context.Context, email string) {
+	key := operatorLoginFailuresRedisKey(email)
+	failures, err := s.redis.Incr(ctx, key)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to record login failure", zap.String("email", email), zap.Error(err))
+		return
+	}
+	if failures == 1 {
+		if err := s.redis.Expire(ctx, key, loginFailureWindow); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to expire login failures", zap.String("email", email), zap.Error(err))
+		}
+	}
+}
+
+func (s *AuthJWTService) resetLoginFailures(ctx context.Context, email string) {
+	if err := s.redis.Delete(ctx, operatorLoginFailuresRedisKey(email)); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to reset login failures", zap.String("email", email), zap.Error(err))
+	}
+}
+
+func randomToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -8,36 +8,82 @@ import (
 	"be/pkg/logger"
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"sort"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"github.com/iden3/go-circuits/v2"
 	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/constants"
 	"github.com/iden3/iden3comm/v2/protocol"
+	goredis "github.com/redis/go-redis/v9"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 type IAuthZkService interface {
 	Register(ctx context.Context, request *dto.IdentityCreatedRequestDto) (*dto.IdentityResponseDto, error)
-	Login(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage) (*dto.ZKLoginResponseDto, error)
-	Logout(ctx context.Context, id string) error
-	Challenge(ctx context.Context) (*protocol.AuthorizationRequestMessage, error)
+	Login(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.ZKLoginResponseDto, error)
+	Logout(ctx context.Context, identityID string, sessionID string) error
+	GetSessions(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ZKSessionResponseDto, error)
+	RevokeSession(ctx context.Context, identityID string, sessionID string) error
+	RevokeIdentitySessions(ctx context.Context, identityID string) error
+	Challenge(ctx context.Context) (*protocol.AuthorizationRequestMessage, string, error)
+	CreateChallenge(ctx context.Context, callbackURL string, scopes ...protocol.ZeroKnowledgeProofRequest) (*protocol.AuthorizationRequestMessage, string, error)
+	Authenticate(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.IdentityResponseDto, error)
 	GetIdentityByRole(ctx context.Context, role string) ([]*dto.IdentityResponseDto, error)
 	GetIdentityByDID(ctx context.Context, did string) (*dto.IdentityResponseDto, error)
 	RefreshZKToken(ctx context.Context, refreshToken string) (*dto.RefreshTokenResponseDto, error)
-	VerifyZKToken(tokenString string, tokenType constant.TokenType) (*dto.ZKClaims, error)
+	VerifyZKToken(ctx context.Context, tokenString string, tokenType constant.TokenType) (*dto.ZKClaims, error)
+}
+
+const (
+	loginChallengeTTL = 5 * time.Minute
+	// failed logins of a DID are counted over this window and refused once they reach maxLoginFailures
+	loginFailureWindow = 15 * time.Minute
+	maxLoginFailures   = 5
+
+	// last-seen is written at most this often, so authenticated requests do not each cost a write
+	sessionLastSeenInterval = time.Minute
+
+	sessionIdentityField   = "identity_id"
+	sessionUserAgentField  = "user_agent"
+	sessionIPField         = "ip"
+	sessionCreatedAtField  = "created_at"
+	sessionLastSeenField   = "last_seen_at"
+	sessionAccessJTIField  = "access_jti"
+	sessionRefreshJTIField = "refresh_jti"
+)
+
+func sessionRedisKey(sessionID string) string {
+	return "authzk:session:" + sessionID
+}
+
+func identitySessionsRedisKey(identityID string) string {
+	return "authzk:sessions:" + identityID
+}
+
+func refreshTokenRedisKey(tokenID string) string {
+	return "authzk:" + string(constant.RefreshToken) + ":" + tokenID
 }
 
 type AuthZkService struct {
-	config          *config.Config
-	logger          *logger.ZapLogger
-	redis           *redis.RedisCache
-	verifier        *Verifier
-	identityService IIdentityService
+	config            *config.Config
+	logger            *logger.ZapLogger
+	redis             *redis.RedisCache
+	verifier          *Verifier
+	identityService   IIdentityService
+	roleService       IRoleService
+	signingKeyService ISigningKeyService
 }
 
 func NewAuthZkService(
@@ -46,62 +92,83 @@ func NewAuthZkService(
 	redis *redis.RedisCache,
 	identityService IIdentityService,
 	verifierService IVerifierService,
+	roleService IRoleService,
+	signingKeyService ISigningKeyService,
 ) (IAuthZkService, error) {
 	return &AuthZkService{
-		config:          config,
-		logger:          logger,
-		redis:           redis,
-		verifier:        verifierService.GetVerifier(),
-		identityService: identityService,
+		config:            config,
+		logger:            logger,
+		redis:             redis,
+		verifier:          verifierService.GetVerifier(),
+		identityService:   identityService,
+		roleService:       roleService,
+		signingKeyService: signingKeyService,
 	}, nil
 }
 
+// Register creates a holder identity. Registering as issuer or verifier only files an application for that role:
+// the identity acts as a holder until an admin approves it or it proves an accreditation.
 func (s *AuthZkService) Register(ctx context.Context, request *dto.IdentityCreatedRequestDto) (*dto.IdentityResponseDto, error) {
-	return s.identityService.CreateIdentity(ctx, request)
-
-}
-
-func (s *AuthZkService) Login(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage) (*dto.ZKLoginResponseDto, error) {
-	redisKey := fmt.Sprintf("authzk:request:id:%s", authResponse.ID)
-	redisValue, err := s.redis.Get(ctx, redisKey).Bytes()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get value redis with key: %s", redisKey)
+	switch request.Role {
+	case "":
+		request.Role = constant.IdentityHolderRole
+	case constant.IdentityHolderRole, constant.IdentityIssuerRole, constant.IdentityVerifierRole:
+	default:
+		return nil, &constant.RoleInvalid
 	}
-	var authRequest protocol.AuthorizationRequestMessage
-	err = json.Unmarshal(redisValue, &authRequest)
+
+	identity, err := s.identityService.CreateIdentity(ctx, request)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal auth request")
+		return nil, err
 	}
-	start := time.Now()
-	err = s.verifier.VerifyAuthResponse(ctx, *authResponse, authRequest)
-	elapsed := time.Since(start)
-	fmt.Println("Elapsed ZK Login:", elapsed)
-	if err != nil {
-		s.logger.Info(fmt.Sprintf("Unauthorize: %s", err))
-		return nil, fmt.Errorf("request is unauthorize")
+	if isOnboardedRole(request.Role) {
+		_, err := s.roleService.ApplyForRole(ctx, &dto.RoleApplicationCreatedRequestDto{
+			Role: request.Role,
+			Note: "Requested at registration",
+			DID:  identity.DID,
+		})
+		if err != nil {
+			s.logger.WithContext(ctx).Error("failed to file role application", zap.String("did", identity.DID), zap.Error(err))
+		}
 	}
-	s.logger.Info("Authorized !")
-	fromDID := authResponse.From
+	return identity, nil
+}
 
-	identity, err := s.identityService.GetIdentityByDID(ctx, fromDID)
+// loginChallenge is what Challenge keeps in Redis until the login that answers it, or until it expires.
+type loginChallenge struct {
+	Request   protocol.AuthorizationRequestMessage `json:"request"`
+	Challenge string                               `json:"challenge"`
+	// BindingHash is the SHA-256 of the binding handed to the client that asked for the challenge.
+	BindingHash string `json:"binding_hash"`
+}
+
+func loginChallengeRedisKey(requestID string) string {
+	return "authzk:request:id:" + requestID
+}
+
+// loginFailuresRedisKey counts failures per DID and client IP, so nobody can lock a DID out of logins from
+// elsewhere.
+func loginFailuresRedisKey(did string, ip string) string {
+	return "authzk:login:failures:" + did + ":" + ip
+}
+
+func (s *AuthZkService) Login(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.ZKLoginResponseDto, error) {
+	identity, err := s.Authenticate(ctx, authResponse, client)
 	if err != nil {
-		return nil, fmt.Errorf("get identity error")
+		return nil, err
 	}
 
-	claims := &dto.ZKClaims{
-		ID:    identity.PublicID,
-		Name:  identity.Name,
-		DID:   identity.DID,
-		Role:  identity.Role,
-		State: identity.State,
-	}
-	accessToken, err := s.GetZKToken(claims, constant.AccessToken)
+	sessionID, err := s.createSession(ctx, identity.PublicID, client)
 	if err != nil {
-		s.logger.Info("get access token invalid: ", zap.String("access token:", accessToken), zap.Error(err))
+		s.logger.WithContext(ctx).Error("failed to create session", zap.String("identity_id", identity.PublicID), zap.Error(err))
+		return nil, &constant.InternalServer
 	}
-	refreshToken, err := s.GetZKToken(claims, constant.RefreshToken)
+	claims := &dto.ZKClaims{SessionID: sessionID}
+	setIdentityClaims(claims, identity)
+	accessToken, refreshToken, err := s.issueSessionTokens(ctx, claims)
 	if err != nil {
-		s.logger.Error("get refresh token invalid:", zap.String("refresh token:", refreshToken), zap.Error(err))
+		s.logger.WithContext(ctx).Error("failed to issue session tokens", zap.String("session_id", sessionID), zap.Error(err))
+		return nil, &constant.InternalServer
 	}
 	publicKey := dto.PublicKeyDto{
 		X: identity.PublicKeyX,
@@ -116,65 +183,260 @@ func (s *AuthZkService) Login(ctx context.Context, authResponse *protocol.Author
 	}, nil
 }
 
-func (s *AuthZkService) Challenge(ctx context.Context) (*protocol.AuthorizationRequestMessage, error) {
+// Authenticate checks a response to a challenge from CreateChallenge and returns the identity it proves control
+// of. The challenge is consumed whatever the outcome. Only a proof that fails for a live challenge presented with
+// its binding counts towards the lockout of the DID from the client's IP.
+func (s *AuthZkService) Authenticate(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.IdentityResponseDto, error) {
+	fromDID := authResponse.From
+	if fromDID == "" {
+		return nil, &constant.ChallengeInvalid
+	}
+	failuresKey := loginFailuresRedisKey(fromDID, client.IP)
+	failures, err := s.redis.Get(ctx, failuresKey).Int64()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return nil, &constant.InternalServer
+	}
+	if failures >= maxLoginFailures {
+		return nil, &constant.LoginAttemptsExceeded
+	}
+
+	// the challenge is consumed before anything else, so the same proof can never be presented twice
+	redisValue, err := s.redis.GetDel(ctx, loginChallengeRedisKey(authResponse.ID)).Bytes()
+	if err != nil {
+		if !errors.Is(err, goredis.Nil) {
+			return nil, &constant.InternalServer
+		}
+		return nil, &constant.ChallengeInvalid
+	}
+	var challenge loginChallenge
+	if err := json.Unmarshal(redisValue, &challenge); err != nil {
+		return nil, &constant.InternalServer
+	}
+	bindingHash := sha256.Sum256([]byte(client.ChallengeBinding))
+	if subtle.ConstantTimeCompare([]byte(hex.EncodeToString(bindingHash[:])), []byte(challenge.BindingHash)) != 1 {
+		return nil, &constant.ChallengeInvalid
+	}
+
+	start := time.Now()
+	err = s.verifier.VerifyAuthResponse(ctx, *authResponse, challenge.Request)
+	s.logger.WithContext(ctx).Debug("zk login verified", zap.String("from_did", fromDID), zap.Duration("elapsed", time.Since(start)), zap.Bool("valid", err == nil))
+	if err == nil {
+		err = verifyLoginChallenge(authResponse, &challenge)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Info(fmt.Sprintf("Unauthorize: %s", err))
+		s.recordLoginFailure(ctx, failuresKey)
+		return nil, &constant.Unauthorized
+	}
+	s.logger.WithContext(ctx).Info("Authorized !")
+	if err := s.redis.Delete(ctx, failuresKey); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to reset login failures", zap.String("did", fromDID), zap.Error(err))
+	}
+
+	identity, err := s.loadIdentity(ctx, fromDID)
+	if err != nil {
+		return nil, fmt.Errorf("get identity error")
+	}
+	if identity.SuspendedAt != nil {
+		return nil, &constant.IdentitySuspended
+	}
+	return identity, nil
+}
+
+// verifyLoginChallenge checks that every auth proof was generated for the challenge that was issued. The verifier
+// checks the proofs themselves but not which challenge they commit to.
+func verifyLoginChallenge(authResponse *protocol.AuthorizationResponseMessage, challenge *loginChallenge) error {
+	expected, ok := new(big.Int).SetString(challenge.Challenge, 10)
+	if !ok {
+		return errors.New("stored challenge is malformed")
+	}
+	for _, proofRequest := range challenge.Request.Body.Scope {
+		// only auth proofs commit to the challenge; credential proofs requested alongside are bound by them
+		if proofRequest.CircuitID != string(circuits.AuthV3CircuitID) {
+			continue
+		}
+		proofResponse := findProofByRequestID(authResponse.Body.Scope, proofRequest.ID)
+		if proofResponse == nil {
+			return fmt.Errorf("proof for request id %d not found", proofRequest.ID)
+		}
+		pubSignals, err := json.Marshal(proofResponse.PubSignals)
+		if err != nil {
+			return err
+		}
+		var signals circuits.AuthV3PubSignals
+		if err := signals.PubSignalsUnmarshal(pubSignals); err != nil {
+			return err
+		}
+		if signals.Challenge == nil || signals.Challenge.Cmp(expected) != 0 {
+			return fmt.Errorf("proof for request id %d answers another challenge", proofRequest.ID)
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login under key within the current failure window.
+func (s *AuthZkService) recordLoginFailure(ctx context.Context, key string) {
+	failures, err := s.redis.Incr(ctx, key)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("failed to record login failure", zap.String("key", key), zap.Error(err))
+		return
+	}
+	if failures == 1 {
+		if err := s.redis.Expire(ctx, key, loginFailureWindow); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to expire login failures", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+// Challenge issues a single-use login challenge bound to the client that asked for it. The returned binding must
+// come back with the login; the challenge itself is a full-width field element, sent as a decimal string so
+// clients do not lose precision parsing it.
+func (s *AuthZkService) Challenge(ctx context.Context) (*protocol.AuthorizationRequestMessage, string, error) {
+	return s.CreateChallenge(ctx, "authzk/login")
+}
+
+// CreateChallenge issues a login challenge answered at callbackURL. Extra proof requests, such as credential
+// queries, are sent along with the auth proof request and verified by Authenticate with it.
+func (s *AuthZkService) CreateChallenge(ctx context.Context, callbackURL string, scopes ...protocol.ZeroKnowledgeProofRequest) (*protocol.AuthorizationRequestMessage, string, error) {
 	verifierPrivateKeyBytes, err := hex.DecodeString(s.config.Iden3.VerifierPrivateKey)
 	if err != nil {
-		s.logger.Info("error %w", zapcore.Field{String: err.Error()})
-		return nil, fmt.Errorf("failed decode private key %s", err)
+		s.logger.WithContext(ctx).Info("error %w", zapcore.Field{String: err.Error()})
+		return nil, "", fmt.Errorf("failed decode private key %s", err)
 	}
 	if len(verifierPrivateKeyBytes) != 32 {
-		s.logger.Info("private key > 32 char")
-		return nil, fmt.Errorf("invalid private key length: %d", len(verifierPrivateKeyBytes))
+		s.logger.WithContext(ctx).Info("private key > 32 char")
+		return nil, "", fmt.Errorf("invalid private key length: %d", len(verifierPrivateKeyBytes))
 	}
 	verifierPrivateKey := babyjub.PrivateKey(verifierPrivateKeyBytes)
 	verifierIdentityState, err := s.identityService.GetIdentityState(ctx, verifierPrivateKey.Public())
 	if err != nil {
-		s.logger.Info("error %w", zapcore.Field{String: err.Error()})
-		return nil, fmt.Errorf("failed to load verifier wallet %s", err)
+		s.logger.WithContext(ctx).Info("error %w", zapcore.Field{String: err.Error()})
+		return nil, "", fmt.Errorf("failed to load verifier wallet %s", err)
 	}
 	verifierDID := verifierIdentityState.GetDID()
 
-	callbackURL := "authzk/login"
-	challenge, _ := rand.Prime(rand.Reader, 32)
+	challenge, err := rand.Int(rand.Reader, constants.Q)
+	if err != nil {
+		return nil, "", &constant.InternalServer
+	}
+	scopeID, err := rand.Int(rand.Reader, big.NewInt(math.MaxUint32))
+	if err != nil {
+		return nil, "", &constant.InternalServer
+	}
+	bindingBytes := make([]byte, 32)
+	if _, err := rand.Read(bindingBytes); err != nil {
+		return nil, "", &constant.InternalServer
+	}
+	binding := hex.EncodeToString(bindingBytes)
+	bindingHash := sha256.Sum256([]byte(binding))
+
 	reason := "Authenticate"
 	message := "Please sign in with zk proof"
-	var scopes []protocol.ZeroKnowledgeProofRequest
-	scopes = append(scopes, protocol.ZeroKnowledgeProofRequest{
-		ID:        uint32(challenge.Uint64()),
+	scopes = append([]protocol.ZeroKnowledgeProofRequest{{
+		ID:        uint32(scopeID.Uint64()) + 1,
 		CircuitID: string(circuits.AuthV3CircuitID),
 		Params: map[string]interface{}{
-			"challenge": challenge,
+			"challenge": challenge.String(),
 		},
-	})
+	}}, scopes...)
 
 	authRequest := CreateAuthorizationRequestWithMessage(reason, message, verifierDID.String(), callbackURL)
 	authRequest.Body.Scope = scopes
 
-	redisKey := fmt.Sprintf("authzk:request:id:%s", authRequest.ID)
-	redisValue, err := json.Marshal(authRequest)
+	redisValue, err := json.Marshal(&loginChallenge{
+		Request:     authRequest,
+		Challenge:   challenge.String(),
+		BindingHash: hex.EncodeToString(bindingHash[:]),
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal auth request: %w", err)
+		return nil, "", fmt.Errorf("failed to marshal auth request: %w", err)
+	}
+	if err := s.redis.Set(ctx, loginChallengeRedisKey(authRequest.ID), redisValue, loginChallengeTTL); err != nil {
+		return nil, "", &constant.InternalServer
 	}
-	expiration := 5 * time.Minute
-	err = s.redis.Set(ctx, redisKey, redisValue, expiration)
 
-	return &authRequest, nil
+	return &authRequest, binding, nil
 }
 
-func (s *AuthZkService) Logout(ctx context.Context, id string) error {
-	accessTokenRedisKey := "authzk:" + string(constant.AccessToken) + ":" + id
-	refreshTokenRedisKey := "authzk:" + string(constant.RefreshToken) + ":" + id
+// Logout ends the session the request was made with; the other devices of the identity stay signed in.
+func (s *AuthZkService) Logout(ctx context.Context, identityID string, sessionID string) error {
+	return s.RevokeSession(ctx, identityID, sessionID)
+}
 
-	err := s.redis.Delete(ctx, accessTokenRedisKey)
+// GetSessions lists the live sessions of the identity, most recently used first.
+func (s *AuthZkService) GetSessions(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ZKSessionResponseDto, error) {
+	indexKey := identitySessionsRedisKey(claims.ID)
+	sessionIDs, err := s.redis.SMembers(ctx, indexKey)
 	if err != nil {
-		return err
+		return nil, &constant.InternalServer
 	}
 
-	err = s.redis.Delete(ctx, refreshTokenRedisKey)
+	resp := make([]*dto.ZKSessionResponseDto, 0, len(sessionIDs))
+	for _, sessionID := range sessionIDs {
+		session, err := s.redis.HGetAll(ctx, sessionRedisKey(sessionID))
+		if err != nil {
+			return nil, &constant.InternalServer
+		}
+		if len(session) == 0 {
+			// the session expired on its own, drop it from the index
+			if err := s.redis.SRem(ctx, indexKey, sessionID); err != nil {
+				s.logger.WithContext(ctx).Warn("failed to drop expired session", zap.String("session_id", sessionID), zap.Error(err))
+			}
+			continue
+		}
+		createdAt, _ := time.Parse(time.RFC3339Nano, session[sessionCreatedAtField])
+		lastSeenAt, _ := time.Parse(time.RFC3339Nano, session[sessionLastSeenField])
+		resp = append(resp, &dto.ZKSessionResponseDto{
+			ID:         sessionID,
+			UserAgent:  session[sessionUserAgentField],
+			IP:         session[sessionIPField],
+			CreatedAt:  createdAt,
+			LastSeenAt: lastSeenAt,
+			Current:    sessionID == claims.SessionID,
+		})
+	}
+	sort.Slice(resp, func(i, j int) bool {
+		return resp[i].LastSeenAt.After(resp[j].LastSeenAt)
+	})
+	return resp, nil
+}
+
+// RevokeSession ends one session of the identity. Its access and refresh tokens stop working at once.
+func (s *AuthZkService) RevokeSession(ctx context.Context, identityID string, sessionID string) error {
+	session, err := s.redis.HGetAll(ctx, sessionRedisKey(sessionID))
 	if err != nil {
-		return err
+		return &constant.InternalServer
+	}
+	if len(session) == 0 || session[sessionIdentityField] != identityID {
+		return &constant.SessionNotFound
+	}
+	if err := s.revokeSession(ctx, sessionID, session); err != nil {
+		s.logger.WithContext(ctx).Error("failed to revoke session", zap.String("session_id", sessionID), zap.Error(err))
+		return &constant.InternalServer
 	}
+	return nil
+}
 
+// RevokeIdentitySessions ends every session of the identity, on every device.
+func (s *AuthZkService) RevokeIdentitySessions(ctx context.Context, identityID string) error {
+	indexKey := identitySessionsRedisKey(identityID)
+	sessionIDs, err := s.redis.SMembers(ctx, indexKey)
+	if err != nil {
+		return &constant.InternalServer
+	}
+	for _, sessionID := range sessionIDs {
+		session, err := s.redis.HGetAll(ctx, sessionRedisKey(sessionID))
+		if err != nil {
+			return &constant.InternalServer
+		}
+		if len(session) == 0 {
+			session = map[string]string{sessionIdentityField: identityID}
+		}
+		if err := s.revokeSession(ctx, sessionID, session); err != nil {
+			s.logger.WithContext(ctx).Error("failed to revoke session", zap.String("session_id", sessionID), zap.Error(err))
+			return &constant.InternalServer
+		}
+	}
 	return nil
 }
 
@@ -186,23 +448,56 @@ func (s *AuthZkService) GetIdentityByDID(ctx context.Context, did string) (*dto.
 	return s.identityService.GetIdentityByDID(ctx, did)
 }
 
+// loadIdentity reads the identity a token is issued for, after granting any role the configuration bootstraps.
+func (s *AuthZkService) loadIdentity(ctx context.Context, did string) (*dto.IdentityResponseDto, error) {
+	if err := s.roleService.GrantBootstrapRoles(ctx, did); err != nil {
+		s.logger.WithContext(ctx).Error("failed to grant bootstrap roles", zap.String("did", did), zap.Error(err))
+	}
+	return s.identityService.GetIdentityByDID(ctx, did)
+}
+
+func setIdentityClaims(claims *dto.ZKClaims, identity *dto.IdentityResponseDto) {
+	claims.ID = identity.PublicID
+	claims.Name = identity.Name
+	claims.DID = identity.DID
+	claims.Role = identity.Role
+	claims.Roles = identity.Roles
+	claims.State = identity.State
+}
+
+// RefreshZKToken rotates the tokens of a session. Each refresh token can be used once: presenting one that was
+// already rotated means it leaked, so the whole session, with every token issued from that login, is revoked.
 func (s *AuthZkService) RefreshZKToken(ctx context.Context, refreshToken string) (*dto.RefreshTokenResponseDto, error) {
-	claims, err := s.VerifyZKToken(refreshToken, constant.RefreshToken)
+	claims, err := s.parseZKToken(refreshToken)
 	if err != nil {
 		return nil, &constant.InvalidToken
 	}
+	// only a refresh token may count as reused; an access token sent here must not revoke the session
+	if claims.Subject != string(constant.RefreshToken) {
+		return nil, &constant.InvalidToken
+	}
 
-	newAccessToken, err := s.GetZKToken(claims, constant.AccessToken)
+	sessionID, err := s.redis.GetDel(ctx, refreshTokenRedisKey(claims.RegisteredClaims.ID)).Result()
+	if err != nil || sessionID != claims.SessionID {
+		s.revokeReusedSession(ctx, claims)
+		return nil, &constant.InvalidToken
+	}
 
-	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate access token %s", err))
-		return nil, &constant.InternalServer
+	session, err := s.redis.HGetAll(ctx, sessionRedisKey(sessionID))
+	if err != nil || len(session) == 0 || session[sessionIdentityField] != claims.ID {
+		return nil, &constant.InvalidToken
 	}
 
-	newRefreshToken, err := s.GetZKToken(claims, constant.RefreshToken)
+	// roles are read again so that grants and revocations reach the session on its next refresh
+	identity, err := s.loadIdentity(ctx, claims.DID)
+	if err != nil || identity.SuspendedAt != nil {
+		return nil, &constant.InvalidToken
+	}
+	setIdentityClaims(claims, identity)
 
+	newAccessToken, newRefreshToken, err := s.issueSessionTokens(ctx, claims)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("Failed to generate refresh token %s", err))
+		s.logger.WithContext(ctx).Error(fmt.Sprintf("Failed to rotate session tokens %s", err))
 		return nil, &constant.InternalServer
 	}
 
@@ -212,9 +507,87 @@ func (s *AuthZkService) RefreshZKToken(ctx context.Context, refreshToken string)
 	}, nil
 }
 
-func (s *AuthZkService) GetZKToken(claims *dto.ZKClaims, tokenType constant.TokenType) (string, error) {
+// revokeReusedSession handles a refresh token that is validly signed but no longer current.
+func (s *AuthZkService) revokeReusedSession(ctx context.Context, claims *dto.ZKClaims) {
+	session, err := s.redis.HGetAll(ctx, sessionRedisKey(claims.SessionID))
+	if err != nil || len(session) == 0 || session[sessionIdentityField] != claims.ID {
+		return
+	}
+	s.logger.WithContext(ctx).Warn("refresh token reused, revoking session",
+		zap.String("identity_id", claims.ID), zap.String("session_id", claims.SessionID))
+	if err := s.revokeSession(ctx, claims.SessionID, session); err != nil {
+		s.logger.WithContext(ctx).Error("failed to revoke session", zap.String("session_id", claims.SessionID), zap.Error(err))
+	}
+}
+
+func (s *AuthZkService) createSession(ctx context.Context, identityID string, client dto.ZKSessionClientDto) (string, error) {
+	sessionID := uuid.NewString()
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	sessionKey := sessionRedisKey(sessionID)
+	err := s.redis.HSet(ctx, sessionKey,
+		sessionIdentityField, identityID,
+		sessionUserAgentField, client.UserAgent,
+		sessionIPField, client.IP,
+		sessionCreatedAtField, now,
+		sessionLastSeenField, now,
+	).Err()
+	if err != nil {
+		return "", err
+	}
+	if err := s.redis.Expire(ctx, sessionKey, s.config.JWT.RefreshTokenTTL); err != nil {
+		return "", err
+	}
+	if err := s.redis.SAdd(ctx, identitySessionsRedisKey(identityID), sessionID); err != nil {
+		return "", err
+	}
+	return sessionID, nil
+}
+
+// issueSessionTokens signs a new token pair for the session and makes it the only valid pair. The session lives as
+// long as its latest refresh token.
+func (s *AuthZkService) issueSessionTokens(ctx context.Context, claims *dto.ZKClaims) (string, string, error) {
+	accessToken, accessTokenID, err := s.GetZKToken(claims, constant.AccessToken)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, refreshTokenID, err := s.GetZKToken(claims, constant.RefreshToken)
+	if err != nil {
+		return "", "", err
+	}
+
+	ttl := s.config.JWT.RefreshTokenTTL
+	sessionKey := sessionRedisKey(claims.SessionID)
+	if err := s.redis.HSet(ctx, sessionKey, sessionAccessJTIField, accessTokenID, sessionRefreshJTIField, refreshTokenID).Err(); err != nil {
+		return "", "", err
+	}
+	if err := s.redis.Set(ctx, refreshTokenRedisKey(refreshTokenID), claims.SessionID, ttl); err != nil {
+		return "", "", err
+	}
+	if err := s.redis.Expire(ctx, sessionKey, ttl); err != nil {
+		return "", "", err
+	}
+	if err := s.redis.Expire(ctx, identitySessionsRedisKey(claims.ID), ttl); err != nil {
+		return "", "", err
+	}
+	return accessToken, refreshToken, nil
+}
+
+func (s *AuthZkService) revokeSession(ctx context.Context, sessionID string, session map[string]string) error {
+	if refreshTokenID := session[sessionRefreshJTIField]; refreshTokenID != "" {
+		if err := s.redis.Delete(ctx, refreshTokenRedisKey(refreshTokenID)); err != nil {
+			return err
+		}
+	}
+	if err := s.redis.Delete(ctx, sessionRedisKey(sessionID)); err != nil {
+		return err
+	}
+	return s.redis.SRem(ctx, identitySessionsRedisKey(session[sessionIdentityField]), sessionID)
+}
+
+// GetZKToken signs a token of the given type for the session in claims and returns it with its token ID.
+func (s *AuthZkService) GetZKToken(claims *dto.ZKClaims, tokenType constant.TokenType) (string, string, error) {
 	now := time.Now().UTC()
-	secretKey := []byte(s.config.JWT.Secret)
 
 	var durationTTL time.Duration
 	if tokenType == constant.AccessToken {
@@ -223,53 +596,79 @@ func (s *AuthZkService) GetZKToken(claims *dto.ZKClaims, tokenType constant.Toke
 		durationTTL = s.config.JWT.RefreshTokenTTL
 	}
 
+	tokenID := uuid.NewString()
 	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        tokenID,
+		Subject:   string(tokenType),
 		ExpiresAt: jwt.NewNumericDate(now.Add(durationTTL)),
 		IssuedAt:  jwt.NewNumericDate(now),
 		NotBefore: jwt.NewNumericDate(now),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(secretKey)
-
+	tokenString, err := s.signingKeyService.SignToken(claims)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
 
-	tokenRedisKey := "authzk:" + string(tokenType) + ":" + claims.ID
+	return tokenString, tokenID, nil
+}
 
-	if err := s.redis.Set(context.Background(), tokenRedisKey, tokenString, durationTTL); err != nil {
-		return "", err
+func (s *AuthZkService) parseZKToken(tokenString string) (*dto.ZKClaims, error) {
+	var claims dto.ZKClaims
+
+	token, err := s.signingKeyService.ParseToken(tokenString, &claims)
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid || claims.SessionID == "" || claims.RegisteredClaims.ID == "" {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return tokenString, nil
+	return &claims, nil
 }
 
-func (s *AuthZkService) VerifyZKToken(tokenString string, tokenType constant.TokenType) (*dto.ZKClaims, error) {
-	var claims dto.ZKClaims
-
-	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(s.config.JWT.Secret), nil
-	})
+// VerifyZKToken accepts a token only while it is the current token of its type in a live session. Access tokens
+// also refresh the last-seen time of the session.
+func (s *AuthZkService) VerifyZKToken(ctx context.Context, tokenString string, tokenType constant.TokenType) (*dto.ZKClaims, error) {
+	claims, err := s.parseZKToken(tokenString)
 	if err != nil {
 		return nil, err
 	}
-	if !token.Valid {
+	if claims.Subject != string(tokenType) {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	tokenRedisKey := "authzk:" + string(tokenType) + ":" + claims.ID
-	tokenRedisValue, err := s.redis.Get(context.Background(), tokenRedisKey).Result()
+	sessionKey := sessionRedisKey(claims.SessionID)
+	session, err := s.redis.HGetAll(ctx, sessionKey)
 	if err != nil {
 		return nil, err
 	}
+	if len(session) == 0 || session[sessionIdentityField] != claims.ID {
+		return nil, errors.New("session not found")
+	}
 
-	if tokenRedisValue != tokenString {
+	currentField := sessionAccessJTIField
+	if tokenType == constant.RefreshToken {
+		currentField = sessionRefreshJTIField
+	}
+	if session[currentField] != claims.RegisteredClaims.ID {
 		return nil, fmt.Errorf("invalid token")
 	}
 
-	return &claims, nil
+	if tokenType == constant.AccessToken {
+		now := time.Now().UTC()
+		lastSeenAt, _ := time.Parse(time.RFC3339Nano, session[sessionLastSeenField])
+		if now.Sub(lastSeenAt) >= sessionLastSeenInterval {
+			added, err := s.redis.HSet(ctx, sessionKey, sessionLastSeenField, now.Format(time.RFC3339Nano)).Result()
+			if err != nil {
+				s.logger.WithContext(ctx).Warn("failed to update session last seen", zap.String("session_id", claims.SessionID), zap.Error(err))
+			} else if added > 0 {
+				// the session was revoked since it was read, do not leave a stub behind
+				_ = s.redis.Delete(ctx, sessionKey)
+				return nil, errors.New("session not found")
+			}
+		}
+	}
+
+	return claims, nil
 }
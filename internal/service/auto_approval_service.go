@@ -0,0 +1,256 @@
+package service
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const autoApprovalBatchSize = 100
+
+type IAutoApprovalService interface {
+	CreateRule(ctx context.Context, request *dto.AutoApprovalRuleCreatedRequestDto) (*dto.AutoApprovalRuleResponseDto, error)
+	UpdateRule(ctx context.Context, id string, request *dto.AutoApprovalRuleUpdatedRequestDto) (*dto.AutoApprovalRuleResponseDto, error)
+	GetRules(ctx context.Context, issuerDID string) ([]*dto.AutoApprovalRuleResponseDto, error)
+	GetRuleDecisions(ctx context.Context, id string, issuerDID string, spec *helper.QuerySpec) ([]*dto.AutoApprovalDecisionResponseDto, *helper.Pagination, error)
+	ApplyRules(ctx context.Context) (int, error)
+}
+
+type AutoApprovalService struct {
+	db                *postgres.PostgresDB
+	logger            *logger.ZapLogger
+	ruleRepo          credential.IAutoApprovalRuleRepository
+	schemaRepo        schema.ISchemaRepository
+	credentialService ICredentialService
+	documentService   IDocumentService
+}
+
+func NewAutoApprovalService(
+	db *postgres.PostgresDB,
+	logger *logger.ZapLogger,
+	ruleRepo credential.IAutoApprovalRuleRepository,
+	schemaRepo schema.ISchemaRepository,
+	credentialService ICredentialService,
+	documentService IDocumentService,
+) IAutoApprovalService {
+	return &AutoApprovalService{
+		db:                db,
+		logger:            logger,
+		ruleRepo:          ruleRepo,
+		schemaRepo:        schemaRepo,
+		credentialService: credentialService,
+		documentService:   documentService,
+	}
+}
+
+func (s *AutoApprovalService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
+}
+
+// CreateRule adds an auto approval rule for one of the issuer's schemas. A schema has at most one rule.
+func (s *AutoApprovalService) CreateRule(ctx context.Context, request *dto.AutoApprovalRuleCreatedRequestDto) (*dto.AutoApprovalRuleResponseDto, error) {
+	if request.Condition != constant.AutoApprovalActiveDocumentCondition {
+		return nil, &constant.AutoApprovalRuleInvalid
+	}
+	schemaEntity, err := s.schemaRepo.FindSchemaByPublicId(ctx, request.SchemaID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.SchemaNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	// another issuer's schema is reported as missing rather than forbidden
+	if schemaEntity.IssuerDID != request.IssuerDID {
+		return nil, &constant.SchemaNotFound
+	}
+
+	exists, err := s.ruleRepo.ExistsAutoApprovalRuleBySchemaID(ctx, schemaEntity.ID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if exists {
+		return nil, &constant.AutoApprovalRuleExisted
+	}
+
+	enabled := true
+	if request.Enabled != nil {
+		enabled = *request.Enabled
+	}
+	rule, err := s.ruleRepo.CreateAutoApprovalRule(ctx, &credential.AutoApprovalRule{
+		PublicID:  uuid.New(),
+		IssuerDID: request.IssuerDID,
+		SchemaID:  schemaEntity.ID,
+		Condition: request.Condition,
+		Enabled:   enabled,
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	rule.Schema = schemaEntity
+	return dto.ToAutoApprovalRuleResponseDto(rule), nil
+}
+
+// UpdateRule enables or disables a rule. Rules are never deleted so that their decisions stay auditable.
+func (s *AutoApprovalService) UpdateRule(ctx context.Context, id string, request *dto.AutoApprovalRuleUpdatedRequestDto) (*dto.AutoApprovalRuleResponseDto, error) {
+	rule, err := s.findRule(ctx, id, request.IssuerDID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.UpdateAutoApprovalRule(ctx, rule, map[string]interface{}{"enabled": request.Enabled}); err != nil {
+		return nil, &constant.InternalServer
+	}
+	rule.Enabled = request.Enabled
+	return dto.ToAutoApprovalRuleResponseDto(rule), nil
+}
+
+func (s *AutoApprovalService) GetRules(ctx context.Context, issuerDID string) ([]*dto.AutoApprovalRuleResponseDto, error) {
+	rules, err := s.ruleRepo.FindAllAutoApprovalRulesByIssuerDID(ctx, issuerDID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	resps := make([]*dto.AutoApprovalRuleResponseDto, 0, len(rules))
+	for _, rule := range rules {
+		resps = append(resps, dto.ToAutoApprovalRuleResponseDto(rule))
+	}
+	return resps, nil
+}
+
+func (s *AutoApprovalService) GetRuleDecisions(ctx context.Context, id string, issuerDID string, spec *helper.QuerySpec) ([]*dto.AutoApprovalDecisionResponseDto, *helper.Pagination, error) {
+	rule, err := s.findRule(ctx, id, issuerDID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	decisions, total, err := s.ruleRepo.FindAllAutoApprovalDecisions(ctx, rule.ID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+	resps := make([]*dto.AutoApprovalDecisionResponseDto, 0, len(decisions))
+	var lastID uint
+	for _, decision := range decisions {
+		resps = append(resps, dto.ToAutoApprovalDecisionResponseDto(decision))
+		lastID = decision.ID
+	}
+	return resps, spec.Pagination(total, len(decisions), lastID), nil
+}
+
+// ApplyRules evaluates every enabled rule against the pending requests it has not decided on yet and returns
+// how many requests were approved. Each request is decided once per rule; skipped requests stay pending for
+// manual review. Approved requests are not issued here: a credential carries the issuer's signature, made with a
+// key the server never holds, so the issuer still signs and issues each approved request.
+func (s *AutoApprovalService) ApplyRules(ctx context.Context) (int, error) {
+	rules, err := s.ruleRepo.FindAllEnabledAutoApprovalRules(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	approved := 0
+	for _, rule := range rules {
+		requests, err := s.ruleRepo.FindUndecidedCredentialRequests(ctx, rule, autoApprovalBatchSize)
+		if err != nil {
+			return approved, err
+		}
+		for _, request := range requests {
+			outcome, err := s.decide(ctx, rule, request)
+			if err != nil {
+				s.logger.WithContext(ctx).Warn("failed to apply auto approval rule",
+					zap.String("rule_id", rule.PublicID.String()),
+					zap.String("request_id", request.PublicID.String()),
+					zap.Error(err))
+				continue
+			}
+			if outcome == constant.AutoApprovalApprovedOutcome {
+				approved++
+			}
+		}
+	}
+	return approved, nil
+}
+
+// decide evaluates the rule's condition for the request, approves the request when it holds and records the
+// decision, with the status the request ends in, in the same transaction. The approval is given in the issuer's
+// name, so a rule only applies while the issuer's review policy is satisfied by a single approval; otherwise the
+// request is skipped and left to the issuer's reviewers.
+func (s *AutoApprovalService) decide(ctx context.Context, rule *credential.AutoApprovalRule, request *credential.CredentialRequest) (constant.AutoApprovalOutcome, error) {
+	policy, err := s.credentialService.GetReviewPolicy(ctx, rule.IssuerDID)
+	if err != nil {
+		return "", err
+	}
+
+	outcome := constant.AutoApprovalApprovedOutcome
+	reason := fmt.Sprintf("holder has an active %s", rule.Schema.DocumentType)
+	if policy.RequiredApprovals > 1 {
+		outcome = constant.AutoApprovalSkippedOutcome
+		reason = fmt.Sprintf("the review policy requires %d approvals", policy.RequiredApprovals)
+	} else if _, err := s.documentService.BuildCredentialSubject(ctx, rule.Schema, request.HolderDID); err != nil {
+		// building the subject checks that the holder's document exists, is issuable and fills the schema
+		var appErr *constant.Errors
+		if !errors.As(err, &appErr) || appErr == &constant.InternalServer {
+			return "", err
+		}
+		outcome = constant.AutoApprovalSkippedOutcome
+		reason = appErr.Message
+	}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		status := request.Status
+		if outcome == constant.AutoApprovalApprovedOutcome {
+			reviewReason := fmt.Sprintf("auto-approved by rule %s: %s", rule.PublicID, reason)
+			for _, decision := range []constant.CredentialRequestStatus{constant.CredentialRequestUnderReviewStatus, constant.CredentialRequestApprovedStatus} {
+				resp, err := s.credentialService.UpdateCredentialRequest(ctx, request.PublicID.String(), &dto.CredentialRequestUpdatedRequestDto{
+					Status:      decision,
+					Reason:      reviewReason,
+					ReviewerDID: rule.IssuerDID,
+				})
+				if err != nil {
+					return err
+				}
+				status = resp.Status
+			}
+			// the policy may have changed since it was read; an approval that did not approve is rolled back
+			if status != constant.CredentialRequestApprovedStatus {
+				return fmt.Errorf("request left %s after auto approval", status)
+			}
+		}
+		_, err := s.ruleRepo.CreateAutoApprovalDecision(ctx, &credential.AutoApprovalDecision{
+			PublicID:      uuid.New(),
+			RuleID:        rule.ID,
+			CRID:          request.ID,
+			Outcome:       outcome,
+			Reason:        reason,
+			RequestStatus: status,
+		})
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return outcome, nil
+}
+
+func (s *AutoApprovalService) findRule(ctx context.Context, id string, issuerDID string) (*credential.AutoApprovalRule, error) {
+	rule, err := s.ruleRepo.FindAutoApprovalRuleByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.AutoApprovalRuleNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if rule.IssuerDID != issuerDID {
+		return nil, &constant.AutoApprovalRuleNotFound
+	}
+	return rule, nil
+}
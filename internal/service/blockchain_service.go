@@ -29,7 +29,7 @@ func NewBlockchainService(logger *logger.ZapLogger, ethclient *ether.Ether, bloc
 }
 
 func (s *BlockchainService) CrawlBlocks(ctx context.Context) error {
-	s.logger.Info("Starting block crawler...")
+	s.logger.WithContext(ctx).Info("Starting block crawler...")
 
 	lastBlockCrawled := s.blockchainRepo.GetLastBlock(ctx)
 
@@ -71,7 +71,7 @@ func (s *BlockchainService) CrawlBlocks(ctx context.Context) error {
 }
 
 func (s *BlockchainService) CrawlTransactions(ctx context.Context, address string) error {
-	s.logger.Info("Starting transaction crawler...")
+	s.logger.WithContext(ctx).Info("Starting transaction crawler...")
 
 	lastBlockCrawled := s.blockchainRepo.GetLastBlock(ctx)
 
@@ -141,7 +141,7 @@ func (s *BlockchainService) CrawlTransactions(ctx context.Context, address strin
 }
 
 func (s *BlockchainService) CrawlEvents(ctx context.Context, contractAddress string) error {
-	s.logger.Info("Starting event crawler...")
+	s.logger.WithContext(ctx).Info("Starting event crawler...")
 
 	lastBlockCrawled := s.blockchainRepo.GetLastBlock(ctx)
 
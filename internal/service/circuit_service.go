@@ -24,6 +24,7 @@ import (
 	"github.com/iden3/go-schema-processor/v2/loaders"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
@@ -93,7 +94,7 @@ func (s *CircuitService) GetCredentialAtomicQueryV3Input(ctx context.Context, re
 	w3c := dto.ToW3CCredential(vc)
 	input, err := s.generateCredentialAtomicQueryV3(ctx, &request.ScopeID, w3c, query, proofRequest.VerifierDID, vc.Signature)
 	if err != nil {
-		fmt.Println(err)
+		s.logger.WithContext(ctx).Error("failed to generate credential atomic query inputs", zap.Error(err))
 		return nil, &constant.InternalServer
 	}
 	var circuitInputs map[string]interface{}
@@ -307,7 +308,7 @@ func (s *CircuitService) generateCredentialAtomicQueryV3(
 	}
 
 	// Build circuit inputs
-	s.logger.Debug("Building circuit inputs")
+	s.logger.WithContext(ctx).Debug("Building circuit inputs")
 	inputs := circuits.AtomicQueryV3Inputs{
 		RequestID:                scopeId,
 		ID:                       &userId,
@@ -379,7 +380,7 @@ func (s *CircuitService) GenerateSignatureBasedInputs(
 	claimSignature *babyjub.Signature,
 	query circuits.Query,
 ) ([]byte, error) {
-	s.logger.Info("🔐 Generating Signature-Based Proof Inputs")
+	s.logger.WithContext(ctx).Info("🔐 Generating Signature-Based Proof Inputs")
 
 	// 1. calculate current state
 	currentState, err := issuerState.GetStateValue()
@@ -461,7 +462,7 @@ func (s *CircuitService) GenerateSignatureBasedInputs(
 		NullifierSessionID:       big.NewInt(0),
 		LinkNonce:                big.NewInt(0),
 	}
-	s.logger.Info("✅ Signature-based inputs generated successfully")
+	s.logger.WithContext(ctx).Info("✅ Signature-based inputs generated successfully")
 
 	return inputs.InputsMarshal()
 }
@@ -475,7 +476,7 @@ func (s *CircuitService) GenerateMTPBasedInputs(
 	claim *core.Claim,
 	query circuits.Query,
 ) ([]byte, error) {
-	s.logger.Info("🌳 Generating MTP-Based Proof Inputs")
+	s.logger.WithContext(ctx).Info("🌳 Generating MTP-Based Proof Inputs")
 	// 1. calculate current state
 	currentState, err := issuerState.GetStateValue()
 	if err != nil {
@@ -534,7 +535,7 @@ func (s *CircuitService) GenerateMTPBasedInputs(
 		LinkNonce:                big.NewInt(0),
 	}
 
-	s.logger.Info("✅ MTP-based inputs generated successfully")
+	s.logger.WithContext(ctx).Info("✅ MTP-based inputs generated successfully")
 
 	return inputs.InputsMarshal()
 }
@@ -547,7 +548,7 @@ func (s *CircuitService) GenerateAuthV3Inputs(
 	challenge *big.Int,
 	challengeSignature *babyjub.Signature,
 ) ([]byte, error) {
-	s.logger.Info("\n🔐 Generating AuthV3 Circuit Inputs")
+	s.logger.WithContext(ctx).Info("\n🔐 Generating AuthV3 Circuit Inputs")
 
 	// 1. calculate state
 	userStateValue, err := userState.GetStateValue()
@@ -612,7 +613,7 @@ func (s *CircuitService) GenerateStateTransitionInputs(
 	isOldStateGenesis bool,
 	authClaimSignature *babyjub.Signature,
 ) ([]byte, error) {
-	s.logger.Info("\n🔄 Generating StateTransition Circuit Inputs")
+	s.logger.WithContext(ctx).Info("\n🔄 Generating StateTransition Circuit Inputs")
 
 	// 1. calculate old state
 	oldStateValue, err := oldState.GetStateValue()
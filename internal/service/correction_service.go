@@ -0,0 +1,199 @@
+package service
+
+import (
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const maxCorrectionEvidenceLength = 2000
+
+type ICorrectionService interface {
+	CreateCorrectionRequest(ctx context.Context, request *dto.CorrectionRequestCreatedRequestDto) (*dto.CorrectionRequestResponseDto, error)
+	GetHolderCorrectionRequests(ctx context.Context, holderDID string, spec *helper.QuerySpec) ([]*dto.CorrectionRequestResponseDto, *helper.Pagination, error)
+	GetIssuerCorrectionRequests(ctx context.Context, issuerDID string, spec *helper.QuerySpec) ([]*dto.CorrectionRequestResponseDto, *helper.Pagination, error)
+	ReviewCorrectionRequest(ctx context.Context, id string, request *dto.CorrectionRequestReviewedRequestDto) (*dto.CorrectionRequestResponseDto, error)
+}
+
+type CorrectionService struct {
+	db                  *postgres.PostgresDB
+	correctionRepo      document.ICorrectionRequestRepository
+	documentService     IDocumentService
+	notificationService INotificationService
+}
+
+func NewCorrectionService(
+	db *postgres.PostgresDB,
+	correctionRepo document.ICorrectionRequestRepository,
+	documentService IDocumentService,
+	notificationService INotificationService,
+) ICorrectionService {
+	return &CorrectionService{
+		db:                  db,
+		correctionRepo:      correctionRepo,
+		documentService:     documentService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *CorrectionService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
+}
+
+// CreateCorrectionRequest files a holder's request to correct one field of their current document of the given
+// type. The request goes to the issuer of that document; only one request per field may be pending.
+func (s *CorrectionService) CreateCorrectionRequest(ctx context.Context, request *dto.CorrectionRequestCreatedRequestDto) (*dto.CorrectionRequestResponseDto, error) {
+	request.ProposedValue = strings.TrimSpace(request.ProposedValue)
+	if request.Field == "" || request.ProposedValue == "" || len(request.Evidence) > maxCorrectionEvidenceLength {
+		return nil, &constant.BadRequest
+	}
+
+	snapshot, err := s.documentService.GetHolderDocumentSnapshot(ctx, request.DocumentType, request.HolderDID)
+	if err != nil {
+		return nil, err
+	}
+	if !isCorrectableField(snapshot, request.Field) {
+		return nil, &constant.CorrectionFieldInvalid
+	}
+	documentPublicID, err := uuid.Parse(fmt.Sprint(snapshot["public_id"]))
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	pending, err := s.correctionRepo.ExistsPendingCorrectionRequest(ctx, documentPublicID, request.Field)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if pending {
+		return nil, &constant.CorrectionRequestPending
+	}
+
+	var currentValue string
+	if value := snapshot[request.Field]; value != nil {
+		currentValue = fmt.Sprint(value)
+	}
+	entity, err := s.correctionRepo.CreateCorrectionRequest(ctx, &document.CorrectionRequest{
+		PublicID:         uuid.New(),
+		DocumentType:     request.DocumentType,
+		DocumentPublicID: documentPublicID,
+		Field:            request.Field,
+		CurrentValue:     currentValue,
+		ProposedValue:    request.ProposedValue,
+		Evidence:         request.Evidence,
+		Status:           constant.CorrectionRequestPendingStatus,
+		HolderDID:        request.HolderDID,
+		IssuerDID:        fmt.Sprint(snapshot["issuer_did"]),
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	return dto.CorrectionRequestToResponse(entity), nil
+}
+
+func (s *CorrectionService) GetHolderCorrectionRequests(ctx context.Context, holderDID string, spec *helper.QuerySpec) ([]*dto.CorrectionRequestResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.correctionRepo.FindAllCorrectionRequestsByHolderDID(ctx, holderDID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+	return toCorrectionRequestResponses(entities), spec.Pagination(total, len(entities), lastCorrectionRequestID(entities)), nil
+}
+
+func (s *CorrectionService) GetIssuerCorrectionRequests(ctx context.Context, issuerDID string, spec *helper.QuerySpec) ([]*dto.CorrectionRequestResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.correctionRepo.FindAllCorrectionRequestsByIssuerDID(ctx, issuerDID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+	return toCorrectionRequestResponses(entities), spec.Pagination(total, len(entities), lastCorrectionRequestID(entities)), nil
+}
+
+// ReviewCorrectionRequest approves or rejects a pending correction. An approval applies the proposed value to the
+// document as a new revision. Either way the holder is notified.
+func (s *CorrectionService) ReviewCorrectionRequest(ctx context.Context, id string, request *dto.CorrectionRequestReviewedRequestDto) (*dto.CorrectionRequestResponseDto, error) {
+	if request.Status != constant.CorrectionRequestApprovedStatus && request.Status != constant.CorrectionRequestRejectedStatus {
+		return nil, &constant.BadRequest
+	}
+
+	entity, err := s.correctionRepo.FindCorrectionRequestByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.CorrectionRequestNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	// requests addressed to another issuer are reported as missing rather than forbidden
+	if entity.IssuerDID != request.ReviewedBy {
+		return nil, &constant.CorrectionRequestNotFound
+	}
+	if entity.Status != constant.CorrectionRequestPendingStatus {
+		return nil, &constant.CorrectionRequestReviewed
+	}
+
+	reviewedAt := time.Now().UTC()
+	kind := constant.CorrectionRejectedNotification
+	message := fmt.Sprintf("Your correction of %s on your %s was rejected", entity.Field, entity.DocumentType)
+	if request.Status == constant.CorrectionRequestApprovedStatus {
+		kind = constant.CorrectionApprovedNotification
+		message = fmt.Sprintf("Your correction of %s on your %s was approved", entity.Field, entity.DocumentType)
+	}
+	if request.Note != "" {
+		message += ": " + request.Note
+	}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		// the review is claimed first so a concurrent review of the same request waits here and then finds it reviewed
+		reviewed, err := s.correctionRepo.ReviewPendingCorrectionRequest(ctx, entity, map[string]interface{}{
+			"status":      request.Status,
+			"review_note": request.Note,
+			"reviewed_by": request.ReviewedBy,
+			"reviewed_at": reviewedAt,
+		})
+		if err != nil {
+			return err
+		}
+		if !reviewed {
+			return &constant.CorrectionRequestReviewed
+		}
+		if request.Status == constant.CorrectionRequestApprovedStatus {
+			if err := s.documentService.ApplyDocumentCorrection(ctx, entity.DocumentType, entity.DocumentPublicID.String(), entity.Field, entity.ProposedValue, request.ReviewedBy); err != nil {
+				return err
+			}
+		}
+		return s.notificationService.Notify(ctx, entity.HolderDID, kind, message, entity.PublicID.String())
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	entity.Status = request.Status
+	entity.ReviewNote = request.Note
+	entity.ReviewedBy = request.ReviewedBy
+	entity.ReviewedAt = &reviewedAt
+	return dto.CorrectionRequestToResponse(entity), nil
+}
+
+func toCorrectionRequestResponses(entities []*document.CorrectionRequest) []*dto.CorrectionRequestResponseDto {
+	resps := make([]*dto.CorrectionRequestResponseDto, 0, len(entities))
+	for _, entity := range entities {
+		resps = append(resps, dto.CorrectionRequestToResponse(entity))
+	}
+	return resps
+}
+
+func lastCorrectionRequestID(entities []*document.CorrectionRequest) uint {
+	if len(entities) == 0 {
+		return 0
+	}
+	return entities[len(entities)-1].ID
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"be/internal/domain/document"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeCorrectionRequestRepository struct {
+	document.ICorrectionRequestRepository
+	request *document.CorrectionRequest
+	// reviewedElsewhere makes the conditional update find the request already reviewed
+	reviewedElsewhere bool
+}
+
+func (r *fakeCorrectionRequestRepository) FindCorrectionRequestByPublicId(_ context.Context, publicId string) (*document.CorrectionRequest, error) {
+	if publicId != r.request.PublicID.String() {
+		return nil, gorm.ErrRecordNotFound
+	}
+	request := *r.request
+	return &request, nil
+}
+
+func (r *fakeCorrectionRequestRepository) ReviewPendingCorrectionRequest(_ context.Context, _ *document.CorrectionRequest, changes map[string]interface{}) (bool, error) {
+	if r.reviewedElsewhere || r.request.Status != constant.CorrectionRequestPendingStatus {
+		return false, nil
+	}
+	r.request.Status = changes["status"].(constant.CorrectionRequestStatus)
+	return true, nil
+}
+
+type fakeCorrectionDocumentService struct {
+	IDocumentService
+	applied int
+}
+
+func (s *fakeCorrectionDocumentService) ApplyDocumentCorrection(context.Context, constant.DocumentType, string, string, string, string) error {
+	s.applied++
+	return nil
+}
+
+type fakeNotificationService struct {
+	INotificationService
+	sent int
+}
+
+func (s *fakeNotificationService) Notify(context.Context, string, constant.NotificationKind, string, string) error {
+	s.sent++
+	return nil
+}
+
+func TestReviewCorrectionRequest(t *testing.T) {
+	tests := []struct {
+		name              string
+		status            constant.CorrectionRequestStatus
+		reviewedBy        string
+		reviewedElsewhere bool
+		wantError         error
+		wantApplied       int
+	}{
+		{name: "approved", status: constant.CorrectionRequestApprovedStatus, reviewedBy: testIssuerDID, wantApplied: 1},
+		{name: "rejected", status: constant.CorrectionRequestRejectedStatus, reviewedBy: testIssuerDID},
+		{name: "reviewed concurrently", status: constant.CorrectionRequestApprovedStatus, reviewedBy: testIssuerDID, reviewedElsewhere: true, wantError: &constant.CorrectionRequestReviewed},
+		{name: "another issuer", status: constant.CorrectionRequestApprovedStatus, reviewedBy: "did:example:other", wantError: &constant.CorrectionRequestNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeCorrectionRequestRepository{
+				request: &document.CorrectionRequest{
+					PublicID:     uuid.New(),
+					DocumentType: constant.CitizenIdentity,
+					Field:        "last_name",
+					Status:       constant.CorrectionRequestPendingStatus,
+					HolderDID:    testHolderDID,
+					IssuerDID:    testIssuerDID,
+				},
+				reviewedElsewhere: tt.reviewedElsewhere,
+			}
+			documentService := &fakeCorrectionDocumentService{}
+			notificationService := &fakeNotificationService{}
+			s := &CorrectionService{db: newTestDB(t), correctionRepo: repo, documentService: documentService, notificationService: notificationService}
+
+			_, err := s.ReviewCorrectionRequest(context.Background(), repo.request.PublicID.String(), &dto.CorrectionRequestReviewedRequestDto{
+				Status:     tt.status,
+				ReviewedBy: tt.reviewedBy,
+			})
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ReviewCorrectionRequest() error = %v, want %v", err, tt.wantError)
+			}
+			if documentService.applied != tt.wantApplied {
+				t.Fatalf("correction applied %d times, want %d", documentService.applied, tt.wantApplied)
+			}
+			wantSent := 1
+			if tt.wantError != nil {
+				wantSent = 0
+			}
+			if notificationService.sent != wantSent {
+				t.Fatalf("%d notifications sent, want %d", notificationService.sent, wantSent)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/shared/utils"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	credentialBundleVersion       = 1
+	credentialBundleKDF           = "argon2id"
+	minCredentialBundlePassphrase = 12
+	maxCredentialBundleEntries    = 10000
+	credentialBundleAssociatedID  = "credential-bundle"
+)
+
+type ICredentialBundleService interface {
+	ExportBundle(ctx context.Context, request *dto.CredentialBundleExportRequestDto) (*dto.CredentialBundleDto, error)
+	ImportBundle(ctx context.Context, request *dto.CredentialBundleImportRequestDto) (*dto.CredentialBundleImportResponseDto, error)
+	GetImportedCredentials(ctx context.Context, holderDID string, spec *helper.QuerySpec) ([]*dto.ImportedCredentialResponseDto, *helper.Pagination, error)
+}
+
+type CredentialBundleService struct {
+	db                  *postgres.PostgresDB
+	logger              *logger.ZapLogger
+	vcRepo              credential.IVerifiableCredentialRepository
+	importedRepo        credential.IImportedCredentialRepository
+	verificationService ICredentialVerificationService
+}
+
+func NewCredentialBundleService(
+	db *postgres.PostgresDB,
+	logger *logger.ZapLogger,
+	vcRepo credential.IVerifiableCredentialRepository,
+	importedRepo credential.IImportedCredentialRepository,
+	verificationService ICredentialVerificationService,
+) ICredentialBundleService {
+	return &CredentialBundleService{
+		db:                  db,
+		logger:              logger,
+		vcRepo:              vcRepo,
+		importedRepo:        importedRepo,
+		verificationService: verificationService,
+	}
+}
+
+func (s *CredentialBundleService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
+}
+
+// credentialBundleAssociatedData binds the ciphertext to the bundle header, so a bundle cannot be relabelled for
+// another holder or version.
+func credentialBundleAssociatedData(version int, holderDID string) []byte {
+	return fmt.Appendf(nil, "%s:v%d:%s", credentialBundleAssociatedID, version, holderDID)
+}
+
+// ExportBundle seals every credential of the holder, whatever its status, together with the issuer state each one
+// was proven against and the schema documents it refers to.
+func (s *CredentialBundleService) ExportBundle(ctx context.Context, request *dto.CredentialBundleExportRequestDto) (*dto.CredentialBundleDto, error) {
+	if len(request.Passphrase) < minCredentialBundlePassphrase {
+		return nil, &constant.CredentialBundlePassphraseWeak
+	}
+
+	entities, err := s.vcRepo.FindAllVerifiableCredentialsForExport(ctx, request.HolderDID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	exportedAt := time.Now().UTC()
+	content := &dto.CredentialBundleContentDto{
+		HolderDID:   request.HolderDID,
+		ExportedAt:  exportedAt,
+		Credentials: make([]*dto.CredentialBundleEntryDto, 0, len(entities)),
+		Documents:   make(map[string]map[string]interface{}),
+	}
+	for _, vc := range entities {
+		content.Credentials = append(content.Credentials, &dto.CredentialBundleEntryDto{
+			Credential: dto.ToW3CCredential(vc),
+			Status:     vc.Status,
+			IssuerState: dto.CredentialBundleIssuerStateDto{
+				State:          vc.IssuerState,
+				ClaimsTreeRoot: vc.ClaimsTreeRoot,
+				RevTreeRoot:    vc.RevTreeRoot,
+				RootsTreeRoot:  vc.RootsTreeRoot,
+			},
+		})
+		if vc.Schema.SchemaURL != "" {
+			content.Documents[vc.Schema.SchemaURL] = vc.Schema.JSONSchema
+		}
+		if vc.Schema.ContextURL != "" {
+			content.Documents[vc.Schema.ContextURL] = vc.Schema.JSONLDContext
+		}
+	}
+
+	plaintext, err := json.Marshal(content)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	kdf, err := utils.NewPassphraseKDF()
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	nonce, ciphertext, err := utils.SealWithPassphrase(plaintext, request.Passphrase, kdf, credentialBundleAssociatedData(credentialBundleVersion, request.HolderDID))
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to seal credential bundle", zap.String("holder_did", request.HolderDID), zap.Error(err))
+		return nil, &constant.InternalServer
+	}
+
+	return &dto.CredentialBundleDto{
+		Version:    credentialBundleVersion,
+		HolderDID:  request.HolderDID,
+		ExportedAt: exportedAt,
+		KDF: dto.CredentialBundleKDFDto{
+			Algorithm: credentialBundleKDF,
+			Time:      kdf.Time,
+			Memory:    kdf.Memory,
+			Threads:   kdf.Threads,
+			Salt:      kdf.Salt,
+		},
+		Nonce:      nonce,
+		Ciphertext: ciphertext,
+	}, nil
+}
+
+// ImportBundle opens a bundle exported for the same holder and stores its credentials. Credentials the holder
+// already has here, issued or imported, are skipped; the rest are stored in one transaction. Each credential keeps
+// its exported status only when its proofs verify against an issuer managed here; otherwise it is stored as
+// unverified.
+func (s *CredentialBundleService) ImportBundle(ctx context.Context, request *dto.CredentialBundleImportRequestDto) (*dto.CredentialBundleImportResponseDto, error) {
+	bundle := request.Bundle
+	if bundle.Version != credentialBundleVersion || bundle.KDF.Algorithm != credentialBundleKDF || bundle.HolderDID != request.HolderDID {
+		return nil, &constant.CredentialBundleInvalid
+	}
+
+	kdf := &utils.PassphraseKDF{
+		Time:    bundle.KDF.Time,
+		Memory:  bundle.KDF.Memory,
+		Threads: bundle.KDF.Threads,
+		Salt:    bundle.KDF.Salt,
+	}
+	plaintext, err := utils.OpenWithPassphrase(bundle.Ciphertext, bundle.Nonce, request.Passphrase, kdf, credentialBundleAssociatedData(bundle.Version, bundle.HolderDID))
+	if err != nil {
+		return nil, &constant.CredentialBundleInvalid
+	}
+
+	var content dto.CredentialBundleContentDto
+	if err := json.Unmarshal(plaintext, &content); err != nil {
+		return nil, &constant.CredentialBundleInvalid
+	}
+	if content.HolderDID != request.HolderDID || len(content.Credentials) > maxCredentialBundleEntries {
+		return nil, &constant.CredentialBundleInvalid
+	}
+	for _, entry := range content.Credentials {
+		if !isBundleEntryOf(entry, request.HolderDID) {
+			return nil, &constant.CredentialBundleInvalid
+		}
+	}
+
+	resp := &dto.CredentialBundleImportResponseDto{Skipped: []string{}}
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		for _, entry := range content.Credentials {
+			imported, err := s.importBundleEntry(ctx, request.HolderDID, entry, content.Documents, content.ExportedAt)
+			if err != nil {
+				return err
+			}
+			if imported {
+				resp.Imported++
+			} else {
+				resp.Skipped = append(resp.Skipped, entry.Credential.ID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return resp, nil
+}
+
+// isBundleEntryOf checks that an entry is a proven credential about the holder.
+func isBundleEntryOf(entry *dto.CredentialBundleEntryDto, holderDID string) bool {
+	if entry == nil || entry.Credential == nil {
+		return false
+	}
+	vc := entry.Credential
+	if vc.ID == "" || !strings.HasPrefix(vc.Issuer, "did:") || len(vc.Proof) == 0 {
+		return false
+	}
+	subjectID, _ := vc.CredentialSubject["id"].(string)
+	return subjectID == holderDID
+}
+
+func (s *CredentialBundleService) importBundleEntry(ctx context.Context, holderDID string, entry *dto.CredentialBundleEntryDto, documents map[string]map[string]interface{}, exportedAt time.Time) (bool, error) {
+	vc := entry.Credential
+
+	existing, err := s.vcRepo.FindVerifiableCredentialByCredentialId(ctx, vc.ID)
+	if err == nil && existing.HolderDID == holderDID {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	imported, err := s.importedRepo.ExistsImportedCredential(ctx, holderDID, vc.ID)
+	if err != nil {
+		return false, err
+	}
+	if imported {
+		return false, nil
+	}
+
+	credentialJSON, err := toJSONMap(vc)
+	if err != nil {
+		return false, &constant.CredentialBundleInvalid
+	}
+	issuerState, err := toJSONMap(entry.IssuerState)
+	if err != nil {
+		return false, &constant.CredentialBundleInvalid
+	}
+
+	// keep only the documents this credential refers to
+	entryDocuments := datatypes.JSONMap{}
+	for _, url := range append(slices.Clone(vc.Context), vc.CredentialSchema.ID) {
+		if document, ok := documents[url]; ok {
+			entryDocuments[url] = document
+		}
+	}
+
+	schemaType := ""
+	if len(vc.Type) > 0 {
+		schemaType = vc.Type[len(vc.Type)-1]
+	}
+	status := entry.Status
+	switch status {
+	case "":
+		status = constant.VerifiableCredentialIssuedStatus
+	case constant.VerifiableCredentialIssuedStatus, constant.VerifiableCredentialRevokedStatus, constant.VerifiableCredentialExpiredStatus:
+	default:
+		return false, &constant.CredentialBundleInvalid
+	}
+	result, err := s.verificationService.VerifyBundledCredential(ctx, vc, documents)
+	if err != nil {
+		return false, &constant.CredentialBundleInvalid
+	}
+	if !result.Valid {
+		status = constant.VerifiableCredentialUnverifiedStatus
+	}
+
+	_, err = s.importedRepo.CreateImportedCredential(ctx, &credential.ImportedCredential{
+		PublicID:     uuid.New(),
+		HolderDID:    holderDID,
+		CredentialID: vc.ID,
+		IssuerDID:    vc.Issuer,
+		SchemaType:   schemaType,
+		Status:       status,
+		Credential:   credentialJSON,
+		IssuerState:  issuerState,
+		Documents:    entryDocuments,
+		ExportedAt:   exportedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s *CredentialBundleService) GetImportedCredentials(ctx context.Context, holderDID string, spec *helper.QuerySpec) ([]*dto.ImportedCredentialResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.importedRepo.FindAllImportedCredentialsByHolderDID(ctx, holderDID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.ImportedCredentialResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToImportedCredentialResponseDto(item))
+		lastID = item.ID
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
@@ -4,57 +4,109 @@ import (
 	"be/config"
 	"be/internal/domain/credential"
 	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
 	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
+	"be/pkg/logger"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-schema-processor/v2/merklize"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/iden3/iden3comm/v2/protocol"
 	"github.com/piprate/json-gold/ld"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
+const (
+	maxIssuanceBatchItems  = 10000
+	issuanceBatchChunkSize = 100
+	// defaultIssuanceBatchStaleAfter is how long a batch may go without a heartbeat before it counts as abandoned.
+	defaultIssuanceBatchStaleAfter = 10 * time.Minute
+	issuanceBatchStaleReason       = "abandoned while processing"
+	credentialIssuedReason         = "credential issued"
+	// credentialRefreshPath is where holders send refresh requests; it is embedded in every issued credential.
+	credentialRefreshPath = "/api/v1/credentials/refresh"
+	// credentialRefreshWindow is how long before expiry a holder may refresh a credential that is not flagged for reissue.
+	credentialRefreshWindow = 30 * 24 * time.Hour
+)
+
+// credentialRequestTransitions lists the statuses a credential request may move to from each status. Only
+// issuance moves an approved request to issued.
+var credentialRequestTransitions = map[constant.CredentialRequestStatus][]constant.CredentialRequestStatus{
+	constant.CredentialRequestPendingStatus:     {constant.CredentialRequestUnderReviewStatus},
+	constant.CredentialRequestUnderReviewStatus: {constant.CredentialRequestApprovedStatus, constant.CredentialRequestRejectedStatus},
+	constant.CredentialRequestApprovedStatus:    {constant.CredentialRequestIssuedStatus},
+}
+
 type ICredentialService interface {
-	GetCredentialRequests(ctx context.Context, claims *dto.ZKClaims) ([]*dto.CredentialRequestResponseDto, error)
+	GetCredentialRequests(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.CredentialRequestResponseDto, *helper.Pagination, error)
 	CreateCredentialRequest(ctx context.Context, request *protocol.CredentialIssuanceRequestMessage) (*dto.CredentialRequestResponseDto, error)
-	UpdateCredentialRequest(ctx context.Context, id string, request *dto.CredentialRequestUpdatedRequestDto) error
-	GetVerifiableCredentials(ctx context.Context, claims *dto.ZKClaims) ([]*verifiable.W3CCredential, error)
+	UpdateCredentialRequest(ctx context.Context, id string, request *dto.CredentialRequestUpdatedRequestDto) (*dto.CredentialRequestResponseDto, error)
+	GetCredentialRequestReviews(ctx context.Context, id string, claims *dto.ZKClaims) ([]*dto.CredentialRequestReviewResponseDto, error)
+	GetReviewPolicy(ctx context.Context, issuerDID string) (*dto.CredentialReviewPolicyResponseDto, error)
+	UpdateReviewPolicy(ctx context.Context, request *dto.CredentialReviewPolicyRequestDto) (*dto.CredentialReviewPolicyResponseDto, error)
+	GetVerifiableCredentials(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*verifiable.W3CCredential, *helper.Pagination, error)
 	GetVerifiableCredentialById(ctx context.Context, id string) (*verifiable.W3CCredential, error)
+	GetCredentialSubject(ctx context.Context, id string, issuerDID string) (map[string]interface{}, error)
 	IssueVerifiableCredential(ctx context.Context, id string, request *dto.IssueVerifiableCredentialRequestDto) (*verifiable.W3CCredential, error)
 	UpdateVerifiableCredential(ctx context.Context, id string, request *dto.VerifiableUpdatedRequestDto) error
+	RefreshVerifiableCredential(ctx context.Context, request *dto.CredentialRefreshRequestDto) (*dto.CredentialRequestResponseDto, error)
+	IssueVerifiableCredentialBatch(ctx context.Context, issuerDID string, request *dto.IssueVerifiableCredentialBatchRequestDto) (*dto.IssuanceBatchResponseDto, error)
+	GetIssuanceBatch(ctx context.Context, id string, issuerDID string) (*dto.IssuanceBatchResponseDto, error)
+	ResumeIssuanceBatch(ctx context.Context, id string, issuerDID string) (*dto.IssuanceBatchResponseDto, error)
+	FailStaleIssuanceBatches(ctx context.Context) (int, error)
 }
 
 type CredentialService struct {
 	config                *config.Config
+	db                    *postgres.PostgresDB
+	logger                *logger.ZapLogger
 	identityService       IIdentityService
 	documentService       IDocumentService
 	credentialRequestRepo credential.ICredentialRequestRepository
 	vcRepo                credential.IVerifiableCredentialRepository
 	schemaRepo            schema.ISchemaRepository
+	issuanceBatchRepo     credential.IIssuanceBatchRepository
+	reviewRepo            credential.ICredentialReviewRepository
 	loader                ld.DocumentLoader
 }
 
 func NewCredentialService(
 	config *config.Config,
+	db *postgres.PostgresDB,
+	logger *logger.ZapLogger,
 	identityService IIdentityService,
 	documentService IDocumentService,
 	credentialRequestRepo credential.ICredentialRequestRepository,
 	vcRepo credential.IVerifiableCredentialRepository,
 	schemaRepo schema.ISchemaRepository,
+	issuanceBatchRepo credential.IIssuanceBatchRepository,
+	reviewRepo credential.ICredentialReviewRepository,
 ) ICredentialService {
 	return &CredentialService{
 		config:                config,
+		db:                    db,
+		logger:                logger,
 		identityService:       identityService,
 		documentService:       documentService,
 		credentialRequestRepo: credentialRequestRepo,
 		vcRepo:                vcRepo,
 		schemaRepo:            schemaRepo,
+		issuanceBatchRepo:     issuanceBatchRepo,
+		reviewRepo:            reviewRepo,
 		loader:                helper.NewCacheLoader(nil),
 	}
 }
@@ -120,71 +172,280 @@ func (s *CredentialService) CreateCredentialRequest(ctx context.Context, request
 	}, nil
 }
 
-func (s *CredentialService) GetCredentialRequests(ctx context.Context, claims *dto.ZKClaims) ([]*dto.CredentialRequestResponseDto, error) {
+func (s *CredentialService) GetCredentialRequests(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.CredentialRequestResponseDto, *helper.Pagination, error) {
 	var (
 		entities []*credential.CredentialRequest
+		total    int64
 		err      error
 	)
 	switch claims.Role {
 	case constant.IdentityHolderRole:
-		entities, err = s.credentialRequestRepo.FindAllCredentialRequestsByHolderDID(ctx, claims.DID)
+		entities, total, err = s.credentialRequestRepo.FindAllCredentialRequestsByHolderDID(ctx, claims.DID, spec)
 	case constant.IdentityIssuerRole:
-		entities, err = s.credentialRequestRepo.FindAllCredentialRequestsByIssuerDID(ctx, claims.DID)
+		entities, total, err = s.credentialRequestRepo.FindAllCredentialRequestsByIssuerDID(ctx, claims.DID, spec)
 	}
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.CredentialRequestNotFound
+			return nil, nil, &constant.CredentialRequestNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resp []*dto.CredentialRequestResponseDto
+	var lastID uint
 	for _, item := range entities {
 		resp = append(resp, dto.ToCredentialRequestResponseDto(item))
+		lastID = item.ID
 	}
-	return resp, nil
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+func (s *CredentialService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
 }
 
-func (s *CredentialService) UpdateCredentialRequest(ctx context.Context, id string, request *dto.CredentialRequestUpdatedRequestDto) error {
+// UpdateCredentialRequest moves a credential request through review: pending to under_review, then to approved
+// or rejected. When the issuer's policy asks for two approvals, the first approval is recorded and the request
+// stays under review until a second, different reviewer approves it.
+func (s *CredentialService) UpdateCredentialRequest(ctx context.Context, id string, request *dto.CredentialRequestUpdatedRequestDto) (*dto.CredentialRequestResponseDto, error) {
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return nil, &constant.CredentialRequestReasonRequired
+	}
+	if request.Status == constant.CredentialRequestIssuedStatus || request.Status == constant.CredentialRequestExpiredStatus {
+		return nil, &constant.CredentialRequestTransitionInvalid
+	}
+
 	entity, err := s.credentialRequestRepo.FindCredentialRequestByPublicId(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &constant.CredentialRequestNotFound
+			return nil, &constant.CredentialRequestNotFound
 		}
-		return &constant.InternalServer
+		return nil, &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status}
+	policy, err := s.findReviewPolicy(ctx, entity.IssuerDID)
+	if err != nil {
+		return nil, err
+	}
+	if !isCredentialReviewer(policy, request.ReviewerDID) {
+		return nil, &constant.CredentialReviewerNotAllowed
+	}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		locked, err := s.credentialRequestRepo.LockCredentialRequest(ctx, entity.ID)
+		if err != nil {
+			return err
+		}
+
+		var reviews []*credential.CredentialRequestReview
+		if request.Status == constant.CredentialRequestApprovedStatus && locked.Status == constant.CredentialRequestUnderReviewStatus {
+			if reviews, err = s.reviewRepo.FindAllCredentialRequestReviews(ctx, locked.ID); err != nil {
+				return err
+			}
+		}
+		status, err := reviewOutcome(locked.Status, request.Status, reviews, request.ReviewerDID, policy.RequiredApprovals)
+		if err != nil {
+			return err
+		}
+
+		if err := s.transitionCredentialRequest(ctx, locked, request.Status, status, request.ReviewerDID, reason); err != nil {
+			return err
+		}
+		entity.Status, entity.StatusReason = locked.Status, locked.StatusReason
+		return nil
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return dto.ToCredentialRequestResponseDto(entity), nil
+}
+
+// reviewOutcome returns the status a request in status current moves to when reviewerDID decides on it, given the
+// reviews recorded so far. An approval leaves the request under review until requiredApprovals distinct reviewers
+// have approved it, and a reviewer cannot approve twice.
+func reviewOutcome(
+	current constant.CredentialRequestStatus,
+	decision constant.CredentialRequestStatus,
+	reviews []*credential.CredentialRequestReview,
+	reviewerDID string,
+	requiredApprovals int,
+) (constant.CredentialRequestStatus, error) {
+	if !slices.Contains(credentialRequestTransitions[current], decision) {
+		return "", fmt.Errorf("%w: %s to %s", &constant.CredentialRequestTransitionInvalid, current, decision)
+	}
+	if decision != constant.CredentialRequestApprovedStatus || current != constant.CredentialRequestUnderReviewStatus {
+		return decision, nil
+	}
+
+	approvers := make(map[string]bool)
+	for _, review := range reviews {
+		if review.Decision == constant.CredentialRequestApprovedStatus {
+			approvers[review.ReviewerDID] = true
+		}
+	}
+	if approvers[reviewerDID] {
+		return "", &constant.CredentialRequestAlreadyReviewed
+	}
+	if len(approvers)+1 < requiredApprovals {
+		return constant.CredentialRequestUnderReviewStatus, nil
+	}
+	return decision, nil
+}
 
-	err = s.credentialRequestRepo.UpdateCredentialRequest(ctx, entity, changes)
+// transitionCredentialRequest records the reviewer's decision and moves the request to status, which is the
+// decision itself unless more approvals are still needed.
+func (s *CredentialService) transitionCredentialRequest(
+	ctx context.Context,
+	entity *credential.CredentialRequest,
+	decision constant.CredentialRequestStatus,
+	status constant.CredentialRequestStatus,
+	reviewerDID string,
+	reason string,
+) error {
+	if !slices.Contains(credentialRequestTransitions[entity.Status], decision) {
+		return fmt.Errorf("%w: %s to %s", &constant.CredentialRequestTransitionInvalid, entity.Status, decision)
+	}
+
+	changes := map[string]interface{}{"status": status, "status_reason": reason}
+	if err := s.credentialRequestRepo.UpdateCredentialRequest(ctx, entity, changes); err != nil {
+		return err
+	}
+	if _, err := s.reviewRepo.CreateCredentialRequestReview(ctx, &credential.CredentialRequestReview{
+		PublicID:    uuid.New(),
+		CRID:        entity.ID,
+		Decision:    decision,
+		FromStatus:  entity.Status,
+		ToStatus:    status,
+		ReviewerDID: reviewerDID,
+		Reason:      reason,
+	}); err != nil {
+		return err
+	}
+	entity.Status = status
+	entity.StatusReason = reason
+	return nil
+}
+
+// GetCredentialRequestReviews lists the decisions taken on a request, to its holder and to the issuer's reviewers.
+func (s *CredentialService) GetCredentialRequestReviews(ctx context.Context, id string, claims *dto.ZKClaims) ([]*dto.CredentialRequestReviewResponseDto, error) {
+	entity, err := s.credentialRequestRepo.FindCredentialRequestByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.CredentialRequestNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if entity.HolderDID != claims.DID {
+		policy, err := s.findReviewPolicy(ctx, entity.IssuerDID)
+		if err != nil {
+			return nil, err
+		}
+		if !isCredentialReviewer(policy, claims.DID) {
+			return nil, &constant.CredentialRequestNotFound
+		}
+	}
 
-	return err
+	reviews, err := s.reviewRepo.FindAllCredentialRequestReviews(ctx, entity.ID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	resps := make([]*dto.CredentialRequestReviewResponseDto, 0, len(reviews))
+	for _, review := range reviews {
+		resps = append(resps, dto.ToCredentialRequestReviewResponseDto(review))
+	}
+	return resps, nil
 }
 
-func (s *CredentialService) GetVerifiableCredentials(ctx context.Context, claims *dto.ZKClaims) ([]*verifiable.W3CCredential, error) {
+func (s *CredentialService) GetReviewPolicy(ctx context.Context, issuerDID string) (*dto.CredentialReviewPolicyResponseDto, error) {
+	policy, err := s.findReviewPolicy(ctx, issuerDID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToCredentialReviewPolicyResponseDto(policy), nil
+}
+
+// UpdateReviewPolicy sets how many reviewers must approve the issuer's credential requests and who, besides the
+// issuer, may review them. Reviewers must be registered issuer identities.
+func (s *CredentialService) UpdateReviewPolicy(ctx context.Context, request *dto.CredentialReviewPolicyRequestDto) (*dto.CredentialReviewPolicyResponseDto, error) {
+	reviewers := make([]string, 0, len(request.Reviewers))
+	for _, reviewerDID := range request.Reviewers {
+		if reviewerDID == request.IssuerDID || slices.Contains(reviewers, reviewerDID) {
+			continue
+		}
+		reviewer, err := s.identityService.GetIdentityByDID(ctx, reviewerDID)
+		if err != nil {
+			if errors.Is(err, &constant.IdentityNotFound) {
+				return nil, fmt.Errorf("%w: reviewer %s is not registered", &constant.CredentialReviewPolicyInvalid, reviewerDID)
+			}
+			return nil, err
+		}
+		if !slices.Contains(reviewer.Roles, constant.IdentityIssuerRole) {
+			return nil, fmt.Errorf("%w: reviewer %s is not an issuer", &constant.CredentialReviewPolicyInvalid, reviewerDID)
+		}
+		reviewers = append(reviewers, reviewerDID)
+	}
+	if request.RequiredApprovals < 1 || request.RequiredApprovals > len(reviewers)+1 || request.RequiredApprovals > 2 {
+		return nil, fmt.Errorf("%w: required approvals must be 1 or 2 and no more than the reviewers", &constant.CredentialReviewPolicyInvalid)
+	}
+
+	policy, err := s.findReviewPolicy(ctx, request.IssuerDID)
+	if err != nil {
+		return nil, err
+	}
+	policy.RequiredApprovals = request.RequiredApprovals
+	policy.Reviewers = reviewers
+	if _, err := s.reviewRepo.SaveReviewPolicy(ctx, policy); err != nil {
+		return nil, &constant.InternalServer
+	}
+	return dto.ToCredentialReviewPolicyResponseDto(policy), nil
+}
+
+// findReviewPolicy returns the issuer's review policy, or the default of a single approval by the issuer itself.
+func (s *CredentialService) findReviewPolicy(ctx context.Context, issuerDID string) (*credential.CredentialReviewPolicy, error) {
+	policy, err := s.reviewRepo.FindReviewPolicyByIssuerDID(ctx, issuerDID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &credential.CredentialReviewPolicy{IssuerDID: issuerDID, RequiredApprovals: 1, Reviewers: []string{}}, nil
+		}
+		return nil, &constant.InternalServer
+	}
+	return policy, nil
+}
+
+func isCredentialReviewer(policy *credential.CredentialReviewPolicy, did string) bool {
+	return did == policy.IssuerDID || slices.Contains(policy.Reviewers, did)
+}
+
+func (s *CredentialService) GetVerifiableCredentials(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*verifiable.W3CCredential, *helper.Pagination, error) {
 	var (
 		entities []*credential.VerifiableCredential
+		total    int64
 		err      error
 	)
 	switch claims.Role {
 	case constant.IdentityHolderRole:
-		entities, err = s.vcRepo.FindAllVerifiableCredentialsByHolderDID(ctx, claims.DID)
+		entities, total, err = s.vcRepo.FindAllVerifiableCredentialsByHolderDID(ctx, claims.DID, spec)
 	case constant.IdentityIssuerRole:
-		entities, err = s.vcRepo.FindAllVerifiableCredentialsByIssuerDID(ctx, claims.DID)
+		entities, total, err = s.vcRepo.FindAllVerifiableCredentialsByIssuerDID(ctx, claims.DID, spec)
 	}
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.VerifiableCredentialNotFound
+			return nil, nil, &constant.VerifiableCredentialNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var vcs []*verifiable.W3CCredential
+	var lastID uint
 	for _, item := range entities {
 		vcs = append(vcs, dto.ToW3CCredential(item))
+		lastID = item.ID
 	}
-	return vcs, nil
+	return vcs, spec.Pagination(total, len(entities), lastID), nil
 }
 
 func (s *CredentialService) GetVerifiableCredentialById(ctx context.Context, id string) (*verifiable.W3CCredential, error) {
@@ -198,7 +459,9 @@ func (s *CredentialService) GetVerifiableCredentialById(ctx context.Context, id
 	return dto.ToW3CCredential(vc), nil
 }
 
-func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id string, request *dto.IssueVerifiableCredentialRequestDto) (*verifiable.W3CCredential, error) {
+// GetCredentialSubject previews the subject built for one of the issuer's credential requests. Requests to other
+// issuers are reported as not found.
+func (s *CredentialService) GetCredentialSubject(ctx context.Context, id string, issuerDID string) (map[string]interface{}, error) {
 	credentialRequestEntity, err := s.credentialRequestRepo.FindCredentialRequestByPublicId(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -206,83 +469,351 @@ func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id st
 		}
 		return nil, &constant.InternalServer
 	}
+	if credentialRequestEntity.IssuerDID != issuerDID {
+		return nil, &constant.CredentialRequestNotFound
+	}
 
-	issuanceDate := time.Now().UTC()
-	expirationDate := time.Unix(credentialRequestEntity.Expiration, 0).UTC()
+	return s.buildCredentialSubject(ctx, credentialRequestEntity, nil)
+}
 
-	verifiableCredential := &verifiable.W3CCredential{
-		ID: "urn:uuid:" + uuid.New().String(),
-		Context: []string{
-			verifiable.JSONLDSchemaW3CCredential2018,
-			verifiable.JSONLDSchemaIden3Credential,
-			credentialRequestEntity.Schema.ContextURL,
-		},
-		Type: []string{
-			verifiable.TypeW3CVerifiableCredential,
-			credentialRequestEntity.Schema.Type,
-		},
-		IssuanceDate: &issuanceDate,
-		Expiration:   &expirationDate,
+// buildCredentialSubject merges the attributes mapped from the holder's document over the
+// subject supplied by the issuer. Schemas without mapped attributes keep the supplied subject.
+func (s *CredentialService) buildCredentialSubject(ctx context.Context, credentialRequestEntity *credential.CredentialRequest, supplied map[string]interface{}) (map[string]interface{}, error) {
+	schemaEntity, err := s.schemaRepo.FindSchemaByPublicId(ctx, credentialRequestEntity.Schema.PublicID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.SchemaNotFound
+		}
+		return nil, &constant.InternalServer
+	}
 
-		Issuer: credentialRequestEntity.IssuerDID,
-		CredentialSchema: verifiable.CredentialSchema{
-			ID:   credentialRequestEntity.Schema.SchemaURL,
-			Type: verifiable.JSONSchema2023,
-		},
-		CredentialStatus:  request.CredentialStatus,
-		CredentialSubject: request.CredentialSubject,
+	credentialSubject := make(map[string]interface{}, len(supplied))
+	for key, value := range supplied {
+		credentialSubject[key] = value
 	}
 
-	options := &verifiable.CoreClaimOptions{
-		RevNonce:              request.CredentialStatus.RevocationNonce,
-		Version:               0,
-		SubjectPosition:       verifiable.CredentialSubjectPositionIndex,
-		MerklizedRootPosition: verifiable.CredentialMerklizedRootPositionNone,
-		Updatable:             false,
+	mapped := false
+	for _, attribute := range schemaEntity.SchemaAttributes {
+		if attribute.DocumentField != "" {
+			mapped = true
+			break
+		}
+	}
+	if !mapped {
+		return credentialSubject, nil
 	}
-	// documentLoader := ld.NewDefaultDocumentLoader(nil)
-	options.MerklizerOpts = []merklize.MerklizeOption{merklize.WithDocumentLoader(s.loader)}
 
-	coreClaim, err := verifiableCredential.ToCoreClaim(ctx, options)
+	documentSubject, err := s.documentService.BuildCredentialSubject(ctx, schemaEntity, credentialRequestEntity.HolderDID)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range documentSubject {
+		credentialSubject[key] = value
+	}
+	credentialSubject["id"] = credentialRequestEntity.HolderDID
+	credentialSubject["type"] = schemaEntity.Type
+
+	return credentialSubject, nil
+}
+
+// IssueVerifiableCredential adds the credential's claim to the issuer's trees and stores the credential. The trees
+// are not part of the database transaction, so the entries added to them are deleted again when storing fails.
+// The issuer stays locked meanwhile, so no batch or other issuance changes the same trees.
+func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id string, request *dto.IssueVerifiableCredentialRequestDto) (_ *verifiable.W3CCredential, err error) {
+	credentialRequestEntity, err := s.credentialRequestRepo.FindCredentialRequestByPublicId(ctx, id)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.CredentialRequestNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if credentialRequestEntity.Status != constant.CredentialRequestApprovedStatus {
+		return nil, &constant.CredentialRequestTransitionInvalid
+	}
 
-		return nil, fmt.Errorf("failed to create claim %w", err)
+	credentialSubject, err := s.buildCredentialSubject(ctx, credentialRequestEntity, request.CredentialSubject)
+	if err != nil {
+		return nil, err
 	}
 
-	hi, hv, err := coreClaim.HiHv()
+	issuanceDate := time.Now().UTC()
+	verifiableCredential := newW3CCredential(credentialRequestEntity, "urn:uuid:"+uuid.New().String(), credentialSubject, request.CredentialStatus, issuanceDate, s.refreshServiceURL())
+
+	coreClaim, err := s.toCoreClaim(ctx, verifiableCredential, request.CredentialStatus.RevocationNonce)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get HiHv: %w", err)
+		return nil, err
 	}
 
-	fmt.Println("hi", hi)
-	fmt.Println("hv", hv)
+	var identityState *IdentityState
+	changes := &treeChanges{}
+	defer func() {
+		if err != nil && identityState != nil {
+			undoTreeChanges(ctx, s.logger, identityState, changes)
+		}
+	}()
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.identityService.LockIdentity(ctx, credentialRequestEntity.IssuerDID); err != nil {
+			return err
+		}
+		state, err := s.identityService.GetIdentityStateByDID(ctx, credentialRequestEntity.IssuerDID)
+		if err != nil {
+			return fmt.Errorf("failed to get identity state: %w", err)
+		}
+		identityState = state
+
+		// a refresh revokes the replaced claim in the same state the new claim is added to
+		if err := s.revokeRefreshedClaim(ctx, credentialRequestEntity, identityState, changes); err != nil {
+			return err
+		}
+
+		// mtp
+		if err := changes.addClaim(ctx, identityState, coreClaim); err != nil {
+			return err
+		}
+
+		incProof, err := identityState.GetIncMTProof(ctx, coreClaim)
+		if err != nil {
+			return fmt.Errorf("failed to generate inclusion proof: %w", err)
+		}
+
+		snapshot, err := newIssuerSnapshot(ctx, identityState, identityState.ClaimsTree.Root(), identityState.RevTree.Root(), identityState.RootsTree.Root())
+		if err != nil {
+			return err
+		}
+
+		entity, err := newVerifiableCredentialEntity(credentialRequestEntity, verifiableCredential, coreClaim, incProof, snapshot, request.CredentialStatus, request.Signature)
+		if err != nil {
+			return err
+		}
 
-	identityState, err := s.identityService.GetIdentityStateByDID(ctx, credentialRequestEntity.IssuerDID)
+		if _, err := s.vcRepo.CreateVerifiableCredential(ctx, entity); err != nil {
+			return err
+		}
+		if err := s.supersedeVerifiableCredential(ctx, credentialRequestEntity); err != nil {
+			return err
+		}
+		return s.transitionCredentialRequest(ctx, credentialRequestEntity, constant.CredentialRequestIssuedStatus, constant.CredentialRequestIssuedStatus, credentialRequestEntity.IssuerDID, credentialIssuedReason)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get identity state: %w", err)
+		return nil, toServiceError(err)
 	}
 
-	claimSubject, err := coreClaim.GetID()
+	return verifiableCredential, nil
+}
+
+func (s *CredentialService) refreshServiceURL() string {
+	return s.config.GetPublicURL() + credentialRefreshPath
+}
+
+// RefreshVerifiableCredential opens a request to reissue one of the holder's credentials. The credential must be
+// flagged for reissue or expire within the refresh window. The request is approved from the start, since the
+// credential it replaces was already reviewed; issuing it revokes the old credential.
+func (s *CredentialService) RefreshVerifiableCredential(ctx context.Context, request *dto.CredentialRefreshRequestDto) (*dto.CredentialRequestResponseDto, error) {
+	vc, err := s.vcRepo.FindVerifiableCredentialByCredentialId(ctx, request.CredentialID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get credentialSubject")
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.VerifiableCredentialNotFound
+		}
+		return nil, &constant.InternalServer
 	}
+	if vc.HolderDID != request.HolderDID {
+		return nil, &constant.VerifiableCredentialNotFound
+	}
+
+	var refreshRequest *credential.CredentialRequest
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		locked, err := s.vcRepo.LockVerifiableCredential(ctx, vc.ID)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if !isRefreshable(locked, now) {
+			return &constant.VerifiableCredentialNotRefreshable
+		}
+		exists, err := s.credentialRequestRepo.ExistsOpenRefreshRequest(ctx, locked.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return &constant.VerifiableCredentialRefreshExisted
+		}
 
-	// mtp
-	err = identityState.AddClaim(ctx, coreClaim)
+		createdTime := now.Unix()
+		refreshRequest, err = s.credentialRequestRepo.CreateCredentialRequest(ctx, &credential.CredentialRequest{
+			PublicID:     uuid.New(),
+			ThreadID:     uuid.New().String(),
+			HolderDID:    locked.HolderDID,
+			IssuerDID:    locked.IssuerDID,
+			SchemaID:     locked.SchemaID,
+			SchemaHash:   locked.SchemaHash,
+			Status:       constant.CredentialRequestApprovedStatus,
+			StatusReason: "refresh of credential " + locked.CredentialID,
+			Expiration:   refreshedExpiration(locked, now),
+			CreatedTime:  &createdTime,
+			RefreshOfID:  &locked.ID,
+		})
+		return err
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to add claim: %w", err)
+		return nil, toServiceError(err)
 	}
 
-	coreClaimHex, err := coreClaim.Hex()
+	created, err := s.credentialRequestRepo.FindCredentialRequestByPublicId(ctx, refreshRequest.PublicID.String())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get core claim hex %w", err)
+		return nil, &constant.InternalServer
+	}
+	return dto.ToCredentialRequestResponseDto(created), nil
+}
+
+// isRefreshable reports whether the holder may ask for the credential to be reissued.
+func isRefreshable(vc *credential.VerifiableCredential, now time.Time) bool {
+	if vc.Status != constant.VerifiableCredentialIssuedStatus && vc.Status != constant.VerifiableCredentialExpiredStatus {
+		return false
+	}
+	if vc.ReissueRequired {
+		return true
 	}
+	return vc.ExpirationDate != nil && vc.ExpirationDate.Sub(now) <= credentialRefreshWindow
+}
 
-	incProof, err := identityState.GetIncMTProof(ctx, coreClaim)
+// refreshedExpiration gives the reissued credential the validity period of the one it replaces, counted from now.
+func refreshedExpiration(vc *credential.VerifiableCredential, now time.Time) int64 {
+	if vc.ExpirationDate == nil {
+		return 0
+	}
+	if vc.IssuanceDate == nil {
+		return vc.ExpirationDate.Unix()
+	}
+	return now.Add(vc.ExpirationDate.Sub(*vc.IssuanceDate)).Unix()
+}
+
+// treeChanges collects the entries an issuance adds to the issuer's trees, so they can be deleted again when the
+// credentials they prove are not stored. Node rows written on the way stay behind unreferenced.
+type treeChanges struct {
+	claims      []*big.Int
+	revocations []*big.Int
+	roots       []*big.Int
+}
+
+// addClaim adds the claim to the claims tree and the new claims root to the roots tree.
+func (c *treeChanges) addClaim(ctx context.Context, identityState *IdentityState, claim *core.Claim) error {
+	hi, hv, err := claim.HiHv()
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate inclusion proof: %w", err)
+		return fmt.Errorf("failed to get HiHv: %w", err)
+	}
+	if err := identityState.ClaimsTree.Add(ctx, hi, hv); err != nil {
+		return fmt.Errorf("failed to add claim: %w", err)
+	}
+	c.claims = append(c.claims, hi)
+	return c.addClaimsRoot(ctx, identityState)
+}
+
+// addClaimsRoot adds the current claims root to the roots tree. A root already in the tree is left as is.
+func (c *treeChanges) addClaimsRoot(ctx context.Context, identityState *IdentityState) error {
+	claimsRoot := identityState.ClaimsTree.Root().BigInt()
+	err := identityState.RootsTree.Add(ctx, claimsRoot, big.NewInt(1))
+	if errors.Is(err, merkletree.ErrEntryIndexAlreadyExists) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add claims root: %w", err)
+	}
+	c.roots = append(c.roots, claimsRoot)
+	return nil
+}
+
+// revokeClaim adds the claim's revocation nonce to the revocation tree. A nonce already in the tree is left as is.
+func (c *treeChanges) revokeClaim(ctx context.Context, identityState *IdentityState, claim *core.Claim) error {
+	revNonce := new(big.Int).SetUint64(claim.GetRevocationNonce())
+	err := identityState.RevTree.Add(ctx, revNonce, big.NewInt(0))
+	if errors.Is(err, merkletree.ErrEntryIndexAlreadyExists) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to revoke claim: %w", err)
+	}
+	c.revocations = append(c.revocations, revNonce)
+	return nil
+}
+
+// undo deletes the collected entries, the roots first, so the trees return to the roots they had before.
+func (c *treeChanges) undo(ctx context.Context, identityState *IdentityState) error {
+	for _, changed := range []struct {
+		tree *merkletree.MerkleTree
+		keys []*big.Int
+	}{
+		{identityState.RootsTree, c.roots},
+		{identityState.ClaimsTree, c.claims},
+		{identityState.RevTree, c.revocations},
+	} {
+		for i := len(changed.keys) - 1; i >= 0; i-- {
+			if err := changed.tree.Delete(ctx, changed.keys[i]); err != nil && !errors.Is(err, merkletree.ErrKeyNotFound) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// undoTreeChanges undoes the tree writes of a failed issuance or revocation. A failure is only logged, so the error
+// that caused the undo is what the caller sees.
+func undoTreeChanges(ctx context.Context, logger *logger.ZapLogger, identityState *IdentityState, changes *treeChanges) {
+	if err := changes.undo(ctx, identityState); err != nil {
+		logger.WithContext(ctx).Error("failed to undo tree changes", zap.String("issuer_did", identityState.GetDID().String()), zap.Error(err))
 	}
+}
+
+// revokeRefreshedClaim adds the revocation nonce of the credential a refresh request replaces to the revocation tree.
+// A nonce already in the tree, from a resumed batch, is left as is.
+func (s *CredentialService) revokeRefreshedClaim(ctx context.Context, credentialRequestEntity *credential.CredentialRequest, identityState *IdentityState, changes *treeChanges) error {
+	if credentialRequestEntity.RefreshOfID == nil {
+		return nil
+	}
+	vc, err := s.vcRepo.FindVerifiableCredentialById(ctx, *credentialRequestEntity.RefreshOfID)
+	if err != nil {
+		return fmt.Errorf("failed to get refreshed credential: %w", err)
+	}
+
+	var coreClaim core.Claim
+	if err := coreClaim.FromHex(vc.ClaimHex); err != nil {
+		return fmt.Errorf("failed to parse core claim: %w", err)
+	}
+	return changes.revokeClaim(ctx, identityState, &coreClaim)
+}
 
+// supersedeVerifiableCredential revokes the credential a refresh request replaces. It runs in the transaction that
+// stores the new credential; when that transaction fails, the revocation nonce added to the tree is deleted again.
+func (s *CredentialService) supersedeVerifiableCredential(ctx context.Context, credentialRequestEntity *credential.CredentialRequest) error {
+	if credentialRequestEntity.RefreshOfID == nil {
+		return nil
+	}
+	vc, err := s.vcRepo.LockVerifiableCredential(ctx, *credentialRequestEntity.RefreshOfID)
+	if err != nil {
+		return err
+	}
+	if vc.Status == constant.VerifiableCredentialRevokedStatus {
+		return nil
+	}
+	return s.vcRepo.UpdateVerifiableCredential(ctx, vc, map[string]interface{}{
+		"status":           constant.VerifiableCredentialRevokedStatus,
+		"revoked_at":       time.Now(),
+		"reissue_required": false,
+	})
+}
+
+// issuerSnapshot is the issuer state a credential is proven against.
+type issuerSnapshot struct {
+	did            string
+	state          string
+	claimsTreeRoot string
+	revTreeRoot    string
+	rootsTreeRoot  string
+	authClaimHex   string
+	authIncProof   *merkletree.Proof
+}
+
+func newIssuerSnapshot(ctx context.Context, identityState *IdentityState, claimsRoot, revRoot, rootsRoot *merkletree.Hash) (*issuerSnapshot, error) {
 	// sig
 	authClaim, err := identityState.GetAuthClaim()
 	if err != nil {
@@ -294,59 +825,135 @@ func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id st
 		return nil, fmt.Errorf("failed to get auth claim hex %w", err)
 	}
 
-	authIncProof, err := identityState.GetIncMTProof(ctx, authClaim)
+	authIncProof, err := identityState.GetIncMTProofAt(ctx, authClaim, claimsRoot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate inclusion proof: %w", err)
 	}
 
 	// state
-	stateHash, err := identityState.GetStateValue()
+	stateHash, err := merkletree.HashElems(claimsRoot.BigInt(), revRoot.BigInt(), rootsRoot.BigInt())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get state: %w", err)
 	}
-	hashString := stateHash.Hex()
 
-	// root
-	claimsTreeRoot := identityState.ClaimsTree.Root().Hex()
-	revTreeRoot := identityState.RevTree.Root().Hex()
-	rootsTreeRoot := identityState.RootsTree.Root().Hex()
+	return &issuerSnapshot{
+		did:            identityState.GetDID().String(),
+		state:          stateHash.Hex(),
+		claimsTreeRoot: claimsRoot.Hex(),
+		revTreeRoot:    revRoot.Hex(),
+		rootsTreeRoot:  rootsRoot.Hex(),
+		authClaimHex:   authClaimHex,
+		authIncProof:   authIncProof,
+	}, nil
+}
 
-	iden3SparseMerkleProof := &verifiable.Iden3SparseMerkleTreeProof{
-		Type: verifiable.Iden3SparseMerkleTreeProofType,
-		IssuerData: verifiable.IssuerData{
-			ID: identityState.GetDID().String(),
-			State: verifiable.State{
-				Value:              &hashString,
-				ClaimsTreeRoot:     &claimsTreeRoot,
-				RevocationTreeRoot: &revTreeRoot,
-				RootOfRoots:        &rootsTreeRoot,
-				Status:             string(constant.VerifiableCredentialIssuedStatus),
-			},
-			AuthCoreClaim:    authClaimHex,
-			MTP:              authIncProof,
-			CredentialStatus: request.CredentialStatus,
+func (snapshot *issuerSnapshot) issuerData(credentialStatus verifiable.CredentialStatus) verifiable.IssuerData {
+	return verifiable.IssuerData{
+		ID: snapshot.did,
+		State: verifiable.State{
+			Value:              &snapshot.state,
+			ClaimsTreeRoot:     &snapshot.claimsTreeRoot,
+			RevocationTreeRoot: &snapshot.revTreeRoot,
+			RootOfRoots:        &snapshot.rootsTreeRoot,
+			Status:             string(constant.VerifiableCredentialIssuedStatus),
 		},
-		CoreClaim: coreClaimHex,
-		MTP:       incProof,
+		AuthCoreClaim:    snapshot.authClaimHex,
+		MTP:              snapshot.authIncProof,
+		CredentialStatus: credentialStatus,
 	}
+}
 
-	bjjSignatureProof := &verifiable.BJJSignatureProof2021{
-		Type: verifiable.BJJSignatureProofType,
-		IssuerData: verifiable.IssuerData{
-			ID: identityState.GetDID().String(),
-			State: verifiable.State{
-				Value:              &hashString,
-				ClaimsTreeRoot:     &claimsTreeRoot,
-				RevocationTreeRoot: &revTreeRoot,
-				RootOfRoots:        &rootsTreeRoot,
-				Status:             string(constant.VerifiableCredentialIssuedStatus),
-			},
-			AuthCoreClaim:    authClaimHex,
-			MTP:              authIncProof,
-			CredentialStatus: request.CredentialStatus,
+func newW3CCredential(credentialRequestEntity *credential.CredentialRequest, credentialID string, credentialSubject map[string]interface{}, credentialStatus verifiable.CredentialStatus, issuanceDate time.Time, refreshServiceURL string) *verifiable.W3CCredential {
+	expirationDate := time.Unix(credentialRequestEntity.Expiration, 0).UTC()
+
+	var refreshService *verifiable.RefreshService
+	if refreshServiceURL != "" {
+		refreshService = &verifiable.RefreshService{
+			ID:   refreshServiceURL,
+			Type: verifiable.Iden3RefreshService2023,
+		}
+	}
+
+	return &verifiable.W3CCredential{
+		ID: credentialID,
+		Context: []string{
+			verifiable.JSONLDSchemaW3CCredential2018,
+			verifiable.JSONLDSchemaIden3Credential,
+			credentialRequestEntity.Schema.ContextURL,
+		},
+		Type: []string{
+			verifiable.TypeW3CVerifiableCredential,
+			credentialRequestEntity.Schema.Type,
+		},
+		IssuanceDate: &issuanceDate,
+		Expiration:   &expirationDate,
+
+		Issuer: credentialRequestEntity.IssuerDID,
+		CredentialSchema: verifiable.CredentialSchema{
+			ID:   credentialRequestEntity.Schema.SchemaURL,
+			Type: verifiable.JSONSchema2023,
 		},
-		CoreClaim: coreClaimHex,
-		Signature: request.Signature,
+		CredentialStatus:  credentialStatus,
+		CredentialSubject: credentialSubject,
+		RefreshService:    refreshService,
+	}
+}
+
+func (s *CredentialService) toCoreClaim(ctx context.Context, verifiableCredential *verifiable.W3CCredential, revNonce uint64) (*core.Claim, error) {
+	options := &verifiable.CoreClaimOptions{
+		RevNonce:              revNonce,
+		Version:               0,
+		SubjectPosition:       verifiable.CredentialSubjectPositionIndex,
+		MerklizedRootPosition: verifiable.CredentialMerklizedRootPositionNone,
+		Updatable:             false,
+		MerklizerOpts:         []merklize.MerklizeOption{merklize.WithDocumentLoader(s.loader)},
+	}
+
+	coreClaim, err := verifiableCredential.ToCoreClaim(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create claim %w", err)
+	}
+	return coreClaim, nil
+}
+
+// newVerifiableCredentialEntity attaches the inclusion and signature proofs to the credential and
+// builds the row stored for it.
+func newVerifiableCredentialEntity(
+	credentialRequestEntity *credential.CredentialRequest,
+	verifiableCredential *verifiable.W3CCredential,
+	coreClaim *core.Claim,
+	incProof *merkletree.Proof,
+	snapshot *issuerSnapshot,
+	credentialStatus verifiable.CredentialStatus,
+	signature string,
+) (*credential.VerifiableCredential, error) {
+	hi, hv, err := coreClaim.HiHv()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HiHv: %w", err)
+	}
+
+	claimSubject, err := coreClaim.GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get credentialSubject")
+	}
+
+	coreClaimHex, err := coreClaim.Hex()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get core claim hex %w", err)
+	}
+
+	iden3SparseMerkleProof := &verifiable.Iden3SparseMerkleTreeProof{
+		Type:       verifiable.Iden3SparseMerkleTreeProofType,
+		IssuerData: snapshot.issuerData(credentialStatus),
+		CoreClaim:  coreClaimHex,
+		MTP:        incProof,
+	}
+
+	bjjSignatureProof := &verifiable.BJJSignatureProof2021{
+		Type:       verifiable.BJJSignatureProofType,
+		IssuerData: snapshot.issuerData(credentialStatus),
+		CoreClaim:  coreClaimHex,
+		Signature:  signature,
 	}
 
 	verifiableCredential.Proof = []verifiable.CredentialProof{iden3SparseMerkleProof, bjjSignatureProof}
@@ -356,12 +963,17 @@ func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id st
 		return nil, fmt.Errorf("failed to marshal core claim proof: %w", err)
 	}
 
-	authProofJSON, err := authIncProof.MarshalJSON()
+	authProofJSON, err := snapshot.authIncProof.MarshalJSON()
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal auth claim proof: %w", err)
 	}
 
-	_, err = s.vcRepo.CreateVerifiableCredential(ctx, &credential.VerifiableCredential{
+	var refreshService string
+	if verifiableCredential.RefreshService != nil {
+		refreshService = verifiableCredential.RefreshService.ID
+	}
+
+	return &credential.VerifiableCredential{
 		PublicID:          uuid.New(),
 		CRID:              credentialRequestEntity.ID,
 		HolderDID:         credentialRequestEntity.HolderDID,
@@ -369,46 +981,552 @@ func (s *CredentialService) IssueVerifiableCredential(ctx context.Context, id st
 		SchemaID:          credentialRequestEntity.SchemaID,
 		SchemaHash:        credentialRequestEntity.SchemaHash,
 		CredentialID:      verifiableCredential.ID,
-		CredentialSubject: request.CredentialSubject,
+		CredentialSubject: verifiableCredential.CredentialSubject,
 		ClaimHi:           hi.String(),
 		ClaimHv:           hv.String(),
 		ClaimHex:          coreClaimHex,
 		ClaimSubject:      claimSubject.String(),
 		ClaimMTP:          incProofJSON,
-		RevNonce:          request.CredentialStatus.RevocationNonce,
-		AuthClaimHex:      authClaimHex,
+		RevNonce:          credentialStatus.RevocationNonce,
+		AuthClaimHex:      snapshot.authClaimHex,
 		AuthClaimMTP:      authProofJSON,
-		IssuerState:       hashString,
-		ClaimsTreeRoot:    claimsTreeRoot,
-		RevTreeRoot:       revTreeRoot,
-		RootsTreeRoot:     rootsTreeRoot,
+		IssuerState:       snapshot.state,
+		ClaimsTreeRoot:    snapshot.claimsTreeRoot,
+		RevTreeRoot:       snapshot.revTreeRoot,
+		RootsTreeRoot:     snapshot.rootsTreeRoot,
 		Status:            constant.VerifiableCredentialIssuedStatus,
-		IssuanceDate:      &issuanceDate,
-		ExpirationDate:    &expirationDate,
-		Signature:         request.Signature,
+		IssuanceDate:      verifiableCredential.IssuanceDate,
+		ExpirationDate:    verifiableCredential.Expiration,
+		Signature:         signature,
+		RefreshService:    refreshService,
+		PreviousID:        credentialRequestEntity.RefreshOfID,
+	}, nil
+}
+
+func (s *CredentialService) UpdateVerifiableCredential(ctx context.Context, id string, request *dto.VerifiableUpdatedRequestDto) error {
+	vc, err := s.vcRepo.FindVerifiableCredentialByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &constant.VerifiableCredentialNotFound
+		}
+		return &constant.InternalServer
+	}
+
+	changes := map[string]interface{}{"status": request.Status}
+	return s.vcRepo.UpdateVerifiableCredential(ctx, vc, changes)
+}
+
+// IssueVerifiableCredentialBatch stores a batch of credential requests to issue and processes it in the background.
+// All claims of a batch are added to the claims tree in one pass and published with a single state transition.
+func (s *CredentialService) IssueVerifiableCredentialBatch(ctx context.Context, issuerDID string, request *dto.IssueVerifiableCredentialBatchRequestDto) (*dto.IssuanceBatchResponseDto, error) {
+	if len(request.Items) == 0 || len(request.Items) > maxIssuanceBatchItems {
+		return nil, &constant.BadRequest
+	}
+
+	requestIds := make([]string, 0, len(request.Items))
+	seen := make(map[string]bool, len(request.Items))
+	for _, item := range request.Items {
+		if item == nil || item.RequestID == "" || item.Signature == "" || seen[item.RequestID] {
+			return nil, &constant.BadRequest
+		}
+		seen[item.RequestID] = true
+		requestIds = append(requestIds, item.RequestID)
+	}
+
+	credentialRequests, err := s.credentialRequestRepo.FindCredentialRequestsByPublicIds(ctx, requestIds)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if len(credentialRequests) != len(requestIds) {
+		return nil, &constant.CredentialRequestNotFound
+	}
+	credentialRequestByPublicId := make(map[string]*credential.CredentialRequest, len(credentialRequests))
+	for _, credentialRequestEntity := range credentialRequests {
+		if credentialRequestEntity.IssuerDID != issuerDID {
+			return nil, &constant.CredentialRequestNotFound
+		}
+		if credentialRequestEntity.Status != constant.CredentialRequestApprovedStatus {
+			return nil, &constant.CredentialRequestTransitionInvalid
+		}
+		credentialRequestByPublicId[credentialRequestEntity.PublicID.String()] = credentialRequestEntity
+	}
+
+	items := make([]*credential.IssuanceBatchItem, 0, len(request.Items))
+	for _, item := range request.Items {
+		credentialStatus, err := toJSONMap(item.CredentialStatus)
+		if err != nil {
+			return nil, &constant.BadRequest
+		}
+		items = append(items, &credential.IssuanceBatchItem{
+			CRID:              credentialRequestByPublicId[item.RequestID].ID,
+			Status:            constant.IssuanceBatchItemPendingStatus,
+			CredentialStatus:  credentialStatus,
+			CredentialSubject: item.CredentialSubject,
+			Signature:         item.Signature,
+		})
+	}
+
+	var batch *credential.IssuanceBatch
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.lockIssuanceBatches(ctx, issuerDID); err != nil {
+			return err
+		}
+		created, err := s.issuanceBatchRepo.CreateIssuanceBatch(ctx, &credential.IssuanceBatch{
+			PublicID:   uuid.New(),
+			IssuerDID:  issuerDID,
+			Status:     constant.IssuanceBatchPendingStatus,
+			TotalItems: len(items),
+			Items:      items,
+		})
+		batch = created
+		return err
 	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	go s.runIssuanceBatch(context.WithoutCancel(ctx), batch.PublicID.String())
+
+	return dto.ToIssuanceBatchResponseDto(batch), nil
+}
+
+func (s *CredentialService) GetIssuanceBatch(ctx context.Context, id string, issuerDID string) (*dto.IssuanceBatchResponseDto, error) {
+	batch, err := s.findIssuanceBatch(ctx, id, issuerDID)
 	if err != nil {
 		return nil, err
 	}
+	return dto.ToIssuanceBatchResponseDto(batch), nil
+}
 
-	changes := map[string]interface{}{"status": constant.CredentialRequestApprovedStatus}
-	err = s.credentialRequestRepo.UpdateCredentialRequest(ctx, credentialRequestEntity, changes)
+// ResumeIssuanceBatch restarts a failed batch. Items already added to the tree or issued are not processed again.
+func (s *CredentialService) ResumeIssuanceBatch(ctx context.Context, id string, issuerDID string) (*dto.IssuanceBatchResponseDto, error) {
+	batch, err := s.findIssuanceBatch(ctx, id, issuerDID)
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, err
+	}
+	if batch.Status != constant.IssuanceBatchFailedStatus {
+		return nil, &constant.IssuanceBatchNotResumable
 	}
 
-	return verifiableCredential, nil
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.lockIssuanceBatches(ctx, issuerDID); err != nil {
+			return err
+		}
+		return s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{
+			"status": constant.IssuanceBatchPendingStatus,
+			"error":  "",
+		})
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	go s.runIssuanceBatch(context.WithoutCancel(ctx), batch.PublicID.String())
+
+	return dto.ToIssuanceBatchResponseDto(batch), nil
 }
 
-func (s *CredentialService) UpdateVerifiableCredential(ctx context.Context, id string, request *dto.VerifiableUpdatedRequestDto) error {
-	vc, err := s.vcRepo.FindVerifiableCredentialByPublicId(ctx, id)
+// lockIssuanceBatches locks the issuer and fails when it already has a batch waiting or running, so a new one can be
+// started in the same transaction.
+func (s *CredentialService) lockIssuanceBatches(ctx context.Context, issuerDID string) error {
+	if err := s.identityService.LockIdentity(ctx, issuerDID); err != nil {
+		return err
+	}
+	active, err := s.issuanceBatchRepo.ExistsActiveIssuanceBatch(ctx, issuerDID)
+	if err != nil {
+		return err
+	}
+	if active {
+		return &constant.IssuanceBatchInProgress
+	}
+	return nil
+}
+
+func (s *CredentialService) findIssuanceBatch(ctx context.Context, id string, issuerDID string) (*credential.IssuanceBatch, error) {
+	batch, err := s.issuanceBatchRepo.FindIssuanceBatchByPublicId(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &constant.VerifiableCredentialNotFound
+			return nil, &constant.IssuanceBatchNotFound
 		}
-		return &constant.InternalServer
+		return nil, &constant.InternalServer
+	}
+	if batch.IssuerDID != issuerDID {
+		return nil, &constant.IssuanceBatchNotFound
 	}
+	return batch, nil
+}
 
-	changes := map[string]interface{}{"status": request.Status}
-	return s.vcRepo.UpdateVerifiableCredential(ctx, vc, changes)
+func (s *CredentialService) runIssuanceBatch(ctx context.Context, id string) {
+	batch, err := s.issuanceBatchRepo.FindIssuanceBatchByPublicId(ctx, id)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to load issuance batch", zap.String("batch_id", id), zap.Error(err))
+		return
+	}
+
+	if err := s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{"status": constant.IssuanceBatchProcessingStatus}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to start issuance batch", zap.String("batch_id", id), zap.Error(err))
+		return
+	}
+
+	heartbeatCtx, stopHeartbeat := context.WithCancel(ctx)
+	defer stopHeartbeat()
+	go s.keepIssuanceBatchAlive(heartbeatCtx, batch.ID)
+
+	if err := s.processIssuanceBatch(ctx, batch); err != nil {
+		s.logger.WithContext(ctx).Error("issuance batch failed", zap.String("batch_id", id), zap.Error(err))
+		_ = s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{
+			"status": constant.IssuanceBatchFailedStatus,
+			"error":  err.Error(),
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	if err := s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{
+		"status":       constant.IssuanceBatchCompletedStatus,
+		"completed_at": now,
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to complete issuance batch", zap.String("batch_id", id), zap.Error(err))
+	}
+}
+
+// keepIssuanceBatchAlive touches the batch while it runs, so FailStaleIssuanceBatches leaves it alone.
+func (s *CredentialService) keepIssuanceBatchAlive(ctx context.Context, id uint) {
+	ticker := time.NewTicker(s.issuanceBatchStaleAfter() / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.issuanceBatchRepo.TouchIssuanceBatch(ctx, id); err != nil && ctx.Err() == nil {
+				s.logger.WithContext(ctx).Warn("failed to touch issuance batch", zap.Uint("batch_id", id), zap.Error(err))
+			}
+		}
+	}
+}
+
+func (s *CredentialService) issuanceBatchStaleAfter() time.Duration {
+	if s.config.Cron.IssuanceBatchStaleAfter > 0 {
+		return s.config.Cron.IssuanceBatchStaleAfter
+	}
+	return defaultIssuanceBatchStaleAfter
+}
+
+// FailStaleIssuanceBatches fails the batches a stopped process left waiting or running, so the issuer can resume
+// them or start new ones, and returns how many were failed.
+func (s *CredentialService) FailStaleIssuanceBatches(ctx context.Context) (int, error) {
+	failed, err := s.issuanceBatchRepo.FailStaleIssuanceBatches(ctx, time.Now().UTC().Add(-s.issuanceBatchStaleAfter()), issuanceBatchStaleReason)
+	if err != nil {
+		return 0, err
+	}
+	return int(failed), nil
+}
+
+// processIssuanceBatch runs the three phases of a batch. Every phase only picks up items left by the previous one,
+// so a failed batch continues where it stopped when resumed.
+func (s *CredentialService) processIssuanceBatch(ctx context.Context, batch *credential.IssuanceBatch) error {
+	// claims
+	for _, item := range batch.Items {
+		if item.Status != constant.IssuanceBatchItemPendingStatus {
+			continue
+		}
+		if err := s.prepareIssuanceBatchItem(ctx, item); err != nil {
+			if err := s.failIssuanceBatchItem(ctx, item, err); err != nil {
+				return err
+			}
+		}
+	}
+
+	// state
+	if batch.NewState == "" {
+		if err := s.transitIssuanceBatchState(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	// proofs are taken against the roots recorded for the batch, so the trees may have moved on since
+	identityState, err := s.identityService.GetIdentityStateByDID(ctx, batch.IssuerDID)
+	if err != nil {
+		return fmt.Errorf("failed to get identity state: %w", err)
+	}
+	return s.issueIssuanceBatchItems(ctx, batch, identityState)
+}
+
+// prepareIssuanceBatchItem builds the credential and its core claim and stores them, so the exact same claim is used on resume.
+func (s *CredentialService) prepareIssuanceBatchItem(ctx context.Context, item *credential.IssuanceBatchItem) error {
+	credentialRequestEntity := item.CredentialRequest
+	if credentialRequestEntity.Status != constant.CredentialRequestApprovedStatus {
+		return fmt.Errorf("credential request is %s", credentialRequestEntity.Status)
+	}
+
+	credentialStatus, err := fromJSONMap[verifiable.CredentialStatus](item.CredentialStatus)
+	if err != nil {
+		return fmt.Errorf("invalid credential status: %w", err)
+	}
+
+	credentialSubject, err := s.buildCredentialSubject(ctx, credentialRequestEntity, item.CredentialSubject)
+	if err != nil {
+		return err
+	}
+
+	issuanceDate := time.Now().UTC()
+	verifiableCredential := newW3CCredential(credentialRequestEntity, "urn:uuid:"+uuid.New().String(), credentialSubject, *credentialStatus, issuanceDate, s.refreshServiceURL())
+
+	coreClaim, err := s.toCoreClaim(ctx, verifiableCredential, credentialStatus.RevocationNonce)
+	if err != nil {
+		return err
+	}
+
+	coreClaimHex, err := coreClaim.Hex()
+	if err != nil {
+		return fmt.Errorf("failed to get core claim hex %w", err)
+	}
+
+	changes := map[string]interface{}{
+		"status":             constant.IssuanceBatchItemPreparedStatus,
+		"credential_id":      verifiableCredential.ID,
+		"credential_subject": datatypes.JSONMap(credentialSubject),
+		"issuance_date":      issuanceDate,
+		"claim_hex":          coreClaimHex,
+	}
+	return s.issuanceBatchRepo.UpdateIssuanceBatchItem(ctx, item, changes)
+}
+
+// transitIssuanceBatchState adds every prepared claim to the claims tree, then records one state transition for the batch.
+// The issuer stays locked throughout, so the transition starts from its current state. When the transition is not
+// recorded, the tree entries added here are deleted again and a resumed batch adds them anew.
+func (s *CredentialService) transitIssuanceBatchState(ctx context.Context, batch *credential.IssuanceBatch) (err error) {
+	var identityState *IdentityState
+	changes := &treeChanges{}
+	defer func() {
+		if err != nil && identityState != nil {
+			undoTreeChanges(ctx, s.logger, identityState, changes)
+		}
+	}()
+
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.identityService.LockIdentity(ctx, batch.IssuerDID); err != nil {
+			return err
+		}
+		state, err := s.identityService.GetIdentityStateByDID(ctx, batch.IssuerDID)
+		if err != nil {
+			return fmt.Errorf("failed to get identity state: %w", err)
+		}
+		identityState = state
+
+		oldState, err := identityState.GetStateValue()
+		if err != nil {
+			return fmt.Errorf("failed to get state: %w", err)
+		}
+
+		for _, item := range batch.Items {
+			if item.Status != constant.IssuanceBatchItemPreparedStatus && item.Status != constant.IssuanceBatchItemAddedStatus {
+				continue
+			}
+			if err := s.addIssuanceBatchClaim(ctx, item, identityState, changes); err != nil {
+				return err
+			}
+		}
+
+		claimsRoot := identityState.ClaimsTree.Root()
+		if err := changes.addClaimsRoot(ctx, identityState); err != nil {
+			return err
+		}
+
+		newState, err := identityState.GetStateValue()
+		if err != nil {
+			return fmt.Errorf("failed to get state: %w", err)
+		}
+
+		if newState.Hex() != oldState.Hex() {
+			if err := s.identityService.TransitState(ctx, batch.IssuerDID, oldState.Hex(), newState.Hex()); err != nil {
+				return err
+			}
+		}
+		return s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{
+			"old_state":        oldState.Hex(),
+			"new_state":        newState.Hex(),
+			"claims_tree_root": claimsRoot.Hex(),
+			"rev_tree_root":    identityState.RevTree.Root().Hex(),
+			"roots_tree_root":  identityState.RootsTree.Root().Hex(),
+		})
+	})
+}
+
+// addIssuanceBatchClaim adds the item's claim to the claims tree and revokes the claim it refreshes.
+func (s *CredentialService) addIssuanceBatchClaim(ctx context.Context, item *credential.IssuanceBatchItem, identityState *IdentityState, changes *treeChanges) error {
+	var coreClaim core.Claim
+	if err := coreClaim.FromHex(item.ClaimHex); err != nil {
+		return fmt.Errorf("failed to parse core claim: %w", err)
+	}
+	hi, hv, err := coreClaim.HiHv()
+	if err != nil {
+		return fmt.Errorf("failed to get HiHv: %w", err)
+	}
+
+	// a batch stopped before its transition was recorded may already have added the claim
+	_, value, _, err := identityState.ClaimsTree.Get(ctx, hi)
+	switch {
+	case errors.Is(err, merkletree.ErrKeyNotFound):
+		if err := identityState.ClaimsTree.Add(ctx, hi, hv); err != nil {
+			return fmt.Errorf("failed to add claim: %w", err)
+		}
+		changes.claims = append(changes.claims, hi)
+	case err != nil:
+		return fmt.Errorf("failed to get claim: %w", err)
+	case value.Cmp(hv) != 0:
+		return s.failIssuanceBatchItem(ctx, item, fmt.Errorf("claim index already used"))
+	}
+
+	if err := s.revokeRefreshedClaim(ctx, item.CredentialRequest, identityState, changes); err != nil {
+		return err
+	}
+
+	if item.Status != constant.IssuanceBatchItemAddedStatus {
+		return s.issuanceBatchRepo.UpdateIssuanceBatchItem(ctx, item, map[string]interface{}{"status": constant.IssuanceBatchItemAddedStatus})
+	}
+	return nil
+}
+
+// issueIssuanceBatchItems proves every added claim against the batch roots and stores the credentials.
+func (s *CredentialService) issueIssuanceBatchItems(ctx context.Context, batch *credential.IssuanceBatch, identityState *IdentityState) error {
+	claimsRoot, err := merkletree.NewHashFromHex(batch.ClaimsTreeRoot)
+	if err != nil {
+		return fmt.Errorf("invalid claims tree root: %w", err)
+	}
+	revRoot, err := merkletree.NewHashFromHex(batch.RevTreeRoot)
+	if err != nil {
+		return fmt.Errorf("invalid revocation tree root: %w", err)
+	}
+	rootsRoot, err := merkletree.NewHashFromHex(batch.RootsTreeRoot)
+	if err != nil {
+		return fmt.Errorf("invalid roots tree root: %w", err)
+	}
+
+	snapshot, err := newIssuerSnapshot(ctx, identityState, claimsRoot, revRoot, rootsRoot)
+	if err != nil {
+		return err
+	}
+
+	added := make([]*credential.IssuanceBatchItem, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		if item.Status == constant.IssuanceBatchItemAddedStatus {
+			added = append(added, item)
+		}
+	}
+
+	for start := 0; start < len(added); start += issuanceBatchChunkSize {
+		end := min(start+issuanceBatchChunkSize, len(added))
+
+		err := s.db.GetGormDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txCtx := helper.InjectTx(ctx, tx)
+			for _, item := range added[start:end] {
+				if err := s.issueIssuanceBatchItem(txCtx, item, identityState, claimsRoot, snapshot); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, item := range added[start:end] {
+			item.Status = constant.IssuanceBatchItemIssuedStatus
+		}
+		if err := s.updateIssuanceBatchCounts(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return s.updateIssuanceBatchCounts(ctx, batch)
+}
+
+func (s *CredentialService) issueIssuanceBatchItem(ctx context.Context, item *credential.IssuanceBatchItem, identityState *IdentityState, claimsRoot *merkletree.Hash, snapshot *issuerSnapshot) error {
+	credentialRequestEntity := item.CredentialRequest
+
+	credentialStatus, err := fromJSONMap[verifiable.CredentialStatus](item.CredentialStatus)
+	if err != nil {
+		return fmt.Errorf("invalid credential status: %w", err)
+	}
+
+	var coreClaim core.Claim
+	if err := coreClaim.FromHex(item.ClaimHex); err != nil {
+		return fmt.Errorf("failed to parse core claim: %w", err)
+	}
+
+	incProof, err := identityState.GetIncMTProofAt(ctx, &coreClaim, claimsRoot)
+	if err != nil {
+		return fmt.Errorf("failed to generate inclusion proof: %w", err)
+	}
+
+	verifiableCredential := newW3CCredential(credentialRequestEntity, item.CredentialID, item.CredentialSubject, *credentialStatus, *item.IssuanceDate, s.refreshServiceURL())
+
+	entity, err := newVerifiableCredentialEntity(credentialRequestEntity, verifiableCredential, &coreClaim, incProof, snapshot, *credentialStatus, item.Signature)
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.vcRepo.CreateVerifiableCredential(ctx, entity); err != nil {
+		return err
+	}
+
+	if err := s.supersedeVerifiableCredential(ctx, credentialRequestEntity); err != nil {
+		return err
+	}
+
+	if err := s.transitionCredentialRequest(ctx, credentialRequestEntity, constant.CredentialRequestIssuedStatus, constant.CredentialRequestIssuedStatus, credentialRequestEntity.IssuerDID, credentialIssuedReason); err != nil {
+		return err
+	}
+
+	return s.issuanceBatchRepo.UpdateIssuanceBatchItem(ctx, item, map[string]interface{}{"status": constant.IssuanceBatchItemIssuedStatus})
+}
+
+func (s *CredentialService) failIssuanceBatchItem(ctx context.Context, item *credential.IssuanceBatchItem, cause error) error {
+	s.logger.WithContext(ctx).Warn("failed to issue batch item", zap.Uint("item_id", item.ID), zap.Error(cause))
+	return s.issuanceBatchRepo.UpdateIssuanceBatchItem(ctx, item, map[string]interface{}{
+		"status": constant.IssuanceBatchItemFailedStatus,
+		"error":  cause.Error(),
+	})
+}
+
+func (s *CredentialService) updateIssuanceBatchCounts(ctx context.Context, batch *credential.IssuanceBatch) error {
+	var issued, failed int
+	for _, item := range batch.Items {
+		switch item.Status {
+		case constant.IssuanceBatchItemIssuedStatus:
+			issued++
+		case constant.IssuanceBatchItemFailedStatus:
+			failed++
+		}
+	}
+	return s.issuanceBatchRepo.UpdateIssuanceBatch(ctx, batch, map[string]interface{}{
+		"issued_items": issued,
+		"failed_items": failed,
+	})
+}
+
+func toJSONMap(value interface{}) (datatypes.JSONMap, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result datatypes.JSONMap
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func fromJSONMap[T any](value datatypes.JSONMap) (*T, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result T
+	if err := json.Unmarshal(raw, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
 }
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/credential"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	"gorm.io/gorm"
+)
+
+func TestReviewOutcome(t *testing.T) {
+	const (
+		issuer   = "did:example:issuer"
+		reviewer = "did:example:reviewer"
+	)
+	approvedBy := func(did string) *credential.CredentialRequestReview {
+		return &credential.CredentialRequestReview{Decision: constant.CredentialRequestApprovedStatus, ReviewerDID: did}
+	}
+	tests := []struct {
+		name      string
+		current   constant.CredentialRequestStatus
+		decision  constant.CredentialRequestStatus
+		reviews   []*credential.CredentialRequestReview
+		reviewer  string
+		required  int
+		want      constant.CredentialRequestStatus
+		wantError *constant.Errors
+	}{
+		{name: "start review", current: constant.CredentialRequestPendingStatus, decision: constant.CredentialRequestUnderReviewStatus,
+			reviewer: issuer, required: 1, want: constant.CredentialRequestUnderReviewStatus},
+		{name: "approve pending", current: constant.CredentialRequestPendingStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviewer: issuer, required: 1, wantError: &constant.CredentialRequestTransitionInvalid},
+		{name: "single approval", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviewer: issuer, required: 1, want: constant.CredentialRequestApprovedStatus},
+		{name: "reject", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestRejectedStatus,
+			reviewer: reviewer, required: 2, want: constant.CredentialRequestRejectedStatus},
+		{name: "first of two approvals", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviewer: issuer, required: 2, want: constant.CredentialRequestUnderReviewStatus},
+		{name: "second approval", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviews: []*credential.CredentialRequestReview{approvedBy(issuer)}, reviewer: reviewer, required: 2,
+			want: constant.CredentialRequestApprovedStatus},
+		{name: "same reviewer twice", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviews: []*credential.CredentialRequestReview{approvedBy(issuer)}, reviewer: issuer, required: 2,
+			wantError: &constant.CredentialRequestAlreadyReviewed},
+		{name: "rejections do not count", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestApprovedStatus,
+			reviews:  []*credential.CredentialRequestReview{{Decision: constant.CredentialRequestRejectedStatus, ReviewerDID: reviewer}},
+			reviewer: issuer, required: 2, want: constant.CredentialRequestUnderReviewStatus},
+		{name: "reopen rejected", current: constant.CredentialRequestRejectedStatus, decision: constant.CredentialRequestUnderReviewStatus,
+			reviewer: issuer, required: 1, wantError: &constant.CredentialRequestTransitionInvalid},
+		{name: "issue approved", current: constant.CredentialRequestApprovedStatus, decision: constant.CredentialRequestIssuedStatus,
+			reviewer: issuer, required: 1, want: constant.CredentialRequestIssuedStatus},
+		{name: "issue under review", current: constant.CredentialRequestUnderReviewStatus, decision: constant.CredentialRequestIssuedStatus,
+			reviewer: issuer, required: 1, wantError: &constant.CredentialRequestTransitionInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := reviewOutcome(tt.current, tt.decision, tt.reviews, tt.reviewer, tt.required)
+			if tt.wantError != nil {
+				if !errors.Is(err, tt.wantError) {
+					t.Fatalf("reviewOutcome() error = %v, want %v", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reviewOutcome() error = %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("reviewOutcome() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeChangesUndo(t *testing.T) {
+	ctx := context.Background()
+	newTree := func() *merkletree.MerkleTree {
+		mt, err := merkletree.NewMerkleTree(ctx, memory.NewMemoryStorage(), 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mt
+	}
+	newClaim := func(slot, revNonce uint64) *core.Claim {
+		claim, err := core.NewClaim(core.SchemaHash{1}, core.WithIndexDataInts(new(big.Int).SetUint64(slot), nil), core.WithRevocationNonce(revNonce))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return claim
+	}
+	identityState := &IdentityState{ClaimsTree: newTree(), RevTree: newTree(), RootsTree: newTree()}
+	existing := newClaim(1, 10)
+	if err := identityState.AddClaim(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+	before, err := identityState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes := &treeChanges{}
+	if err := changes.revokeClaim(ctx, identityState, existing); err != nil {
+		t.Fatal(err)
+	}
+	if err := changes.addClaim(ctx, identityState, newClaim(2, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if err := changes.addClaim(ctx, identityState, newClaim(3, 30)); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.claims) != 2 || len(changes.roots) != 2 || len(changes.revocations) != 1 {
+		t.Fatalf("got %d claims %d roots %d revocations", len(changes.claims), len(changes.roots), len(changes.revocations))
+	}
+	// a nonce already in the tree is not recorded, so undo keeps it
+	if err := changes.revokeClaim(ctx, identityState, existing); err != nil || len(changes.revocations) != 1 {
+		t.Fatalf("revoking twice: error = %v, %d revocations", err, len(changes.revocations))
+	}
+
+	if err := changes.undo(ctx, identityState); err != nil {
+		t.Fatalf("undo() error = %v", err)
+	}
+	after, err := identityState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !after.Equals(before) {
+		t.Fatalf("state after undo = %s, want %s", after.Hex(), before.Hex())
+	}
+}
+
+type fakeCredentialRequestRepository struct {
+	credential.ICredentialRequestRepository
+	requests []*credential.CredentialRequest
+}
+
+func (r *fakeCredentialRequestRepository) FindCredentialRequestsByPublicIds(_ context.Context, publicIds []string) ([]*credential.CredentialRequest, error) {
+	var found []*credential.CredentialRequest
+	for _, request := range r.requests {
+		for _, publicId := range publicIds {
+			if request.PublicID.String() == publicId {
+				found = append(found, request)
+			}
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeCredentialRequestRepository) FindCredentialRequestByPublicId(_ context.Context, publicId string) (*credential.CredentialRequest, error) {
+	for _, request := range r.requests {
+		if request.PublicID.String() == publicId {
+			return request, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+type fakeIssuanceBatchRepository struct {
+	credential.IIssuanceBatchRepository
+	mu      sync.Mutex
+	batches []*credential.IssuanceBatch
+	touched int
+	before  time.Time
+	// failOn fails the batch update that sets this column
+	failOn string
+}
+
+func (r *fakeIssuanceBatchRepository) FindIssuanceBatchByPublicId(_ context.Context, publicId string) (*credential.IssuanceBatch, error) {
+	for _, batch := range r.batches {
+		if batch.PublicID.String() == publicId {
+			return batch, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeIssuanceBatchRepository) ExistsActiveIssuanceBatch(_ context.Context, issuerDID string) (bool, error) {
+	for _, batch := range r.batches {
+		if batch.IssuerDID == issuerDID && (batch.Status == constant.IssuanceBatchPendingStatus || batch.Status == constant.IssuanceBatchProcessingStatus) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *fakeIssuanceBatchRepository) UpdateIssuanceBatch(_ context.Context, entity *credential.IssuanceBatch, changes map[string]interface{}) error {
+	if _, ok := changes[r.failOn]; ok {
+		return errors.New("update failed")
+	}
+	for column, value := range changes {
+		switch column {
+		case "status":
+			entity.Status = value.(constant.IssuanceBatchStatus)
+		case "old_state":
+			entity.OldState = value.(string)
+		case "new_state":
+			entity.NewState = value.(string)
+		case "claims_tree_root":
+			entity.ClaimsTreeRoot = value.(string)
+		}
+	}
+	return nil
+}
+
+func (r *fakeIssuanceBatchRepository) UpdateIssuanceBatchItem(_ context.Context, entity *credential.IssuanceBatchItem, changes map[string]interface{}) error {
+	if status, ok := changes["status"]; ok {
+		entity.Status = status.(constant.IssuanceBatchItemStatus)
+	}
+	return nil
+}
+
+func (r *fakeIssuanceBatchRepository) TouchIssuanceBatch(context.Context, uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.touched++
+	return nil
+}
+
+func (r *fakeIssuanceBatchRepository) FailStaleIssuanceBatches(_ context.Context, before time.Time, _ string) (int64, error) {
+	r.before = before
+	return 1, nil
+}
+
+type issuanceBatchFixture struct {
+	service  *CredentialService
+	requests *fakeCredentialRequestRepository
+	batches  *fakeIssuanceBatchRepository
+	issuers  *fakeIdentityService
+}
+
+func newIssuanceBatchFixture(t *testing.T) *issuanceBatchFixture {
+	t.Helper()
+	f := &issuanceBatchFixture{
+		requests: &fakeCredentialRequestRepository{},
+		batches:  &fakeIssuanceBatchRepository{},
+		issuers:  &fakeIdentityService{states: map[string]*IdentityState{testIssuerDID: newTestIdentityState(t)}},
+	}
+	f.service = &CredentialService{
+		config:                &config.Config{},
+		db:                    newTestDB(t),
+		logger:                newTestLogger(t),
+		identityService:       f.issuers,
+		credentialRequestRepo: f.requests,
+		issuanceBatchRepo:     f.batches,
+	}
+	return f
+}
+
+func (f *issuanceBatchFixture) approvedRequest() *credential.CredentialRequest {
+	request := &credential.CredentialRequest{ID: uint(len(f.requests.requests) + 1), PublicID: uuid.New(), IssuerDID: testIssuerDID, Status: constant.CredentialRequestApprovedStatus}
+	f.requests.requests = append(f.requests.requests, request)
+	return request
+}
+
+func preparedBatchItem(t *testing.T, revNonce uint64) *credential.IssuanceBatchItem {
+	t.Helper()
+	claim, err := core.NewClaim(core.SchemaHash{1}, core.WithIndexDataInts(new(big.Int).SetUint64(revNonce), nil), core.WithRevocationNonce(revNonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimHex, err := claim.Hex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &credential.IssuanceBatchItem{Status: constant.IssuanceBatchItemPreparedStatus, ClaimHex: claimHex, CredentialRequest: &credential.CredentialRequest{}}
+}
+
+func TestIssuanceBatchRefusedWhileAnotherIsActive(t *testing.T) {
+	tests := []struct {
+		name      string
+		active    constant.IssuanceBatchStatus
+		issuerDID string
+		wantError *constant.Errors
+	}{
+		{name: "pending batch", active: constant.IssuanceBatchPendingStatus, issuerDID: testIssuerDID, wantError: &constant.IssuanceBatchInProgress},
+		{name: "processing batch", active: constant.IssuanceBatchProcessingStatus, issuerDID: testIssuerDID, wantError: &constant.IssuanceBatchInProgress},
+		{name: "unknown issuer", active: constant.IssuanceBatchFailedStatus, issuerDID: "did:example:unknown", wantError: &constant.IdentityNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newIssuanceBatchFixture(t)
+			f.batches.batches = []*credential.IssuanceBatch{{IssuerDID: testIssuerDID, Status: tt.active}}
+			request := f.approvedRequest()
+			request.IssuerDID = tt.issuerDID
+
+			_, err := f.service.IssueVerifiableCredentialBatch(context.Background(), tt.issuerDID, &dto.IssueVerifiableCredentialBatchRequestDto{
+				Items: []*dto.IssueVerifiableCredentialBatchItemDto{{RequestID: request.PublicID.String(), Signature: "signature"}},
+			})
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("IssueVerifiableCredentialBatch() error = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestResumeIssuanceBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    constant.IssuanceBatchStatus
+		active    bool
+		wantError *constant.Errors
+	}{
+		{name: "processing batch", status: constant.IssuanceBatchProcessingStatus, wantError: &constant.IssuanceBatchNotResumable},
+		{name: "completed batch", status: constant.IssuanceBatchCompletedStatus, wantError: &constant.IssuanceBatchNotResumable},
+		{name: "failed batch while another is active", status: constant.IssuanceBatchFailedStatus, active: true, wantError: &constant.IssuanceBatchInProgress},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newIssuanceBatchFixture(t)
+			batch := &credential.IssuanceBatch{PublicID: uuid.New(), IssuerDID: testIssuerDID, Status: tt.status}
+			f.batches.batches = append(f.batches.batches, batch)
+			if tt.active {
+				f.batches.batches = append(f.batches.batches, &credential.IssuanceBatch{IssuerDID: testIssuerDID, Status: constant.IssuanceBatchPendingStatus})
+			}
+
+			_, err := f.service.ResumeIssuanceBatch(context.Background(), batch.PublicID.String(), testIssuerDID)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ResumeIssuanceBatch() error = %v, want %v", err, tt.wantError)
+			}
+			if batch.Status != tt.status {
+				t.Fatalf("status = %s, want %s unchanged", batch.Status, tt.status)
+			}
+		})
+	}
+}
+
+func TestTransitIssuanceBatchState(t *testing.T) {
+	f := newIssuanceBatchFixture(t)
+	issuerState := f.issuers.states[testIssuerDID]
+	batch := &credential.IssuanceBatch{IssuerDID: testIssuerDID, Status: constant.IssuanceBatchProcessingStatus,
+		Items: []*credential.IssuanceBatchItem{preparedBatchItem(t, 1), preparedBatchItem(t, 2)}}
+	before, err := issuerState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.service.transitIssuanceBatchState(context.Background(), batch); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := issuerState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.issuers.locked) != 1 || f.issuers.locked[0] != testIssuerDID {
+		t.Fatalf("locked issuers = %v, want %s", f.issuers.locked, testIssuerDID)
+	}
+	if len(f.issuers.transitions) != 1 || f.issuers.transitions[0] != [2]string{before.Hex(), after.Hex()} {
+		t.Fatalf("transitions = %v, want one from %s to %s", f.issuers.transitions, before.Hex(), after.Hex())
+	}
+	if batch.OldState != before.Hex() || batch.NewState != after.Hex() {
+		t.Fatalf("batch states = %s, %s, want %s, %s", batch.OldState, batch.NewState, before.Hex(), after.Hex())
+	}
+	for i, item := range batch.Items {
+		if item.Status != constant.IssuanceBatchItemAddedStatus {
+			t.Fatalf("item %d is %s, want added", i, item.Status)
+		}
+	}
+}
+
+func TestTransitIssuanceBatchStateUndoesClaimsOnFailure(t *testing.T) {
+	f := newIssuanceBatchFixture(t)
+	issuerState := f.issuers.states[testIssuerDID]
+	item := preparedBatchItem(t, 1)
+	batch := &credential.IssuanceBatch{IssuerDID: testIssuerDID, Status: constant.IssuanceBatchProcessingStatus, Items: []*credential.IssuanceBatchItem{item}}
+	claimsRoot, rootsRoot := issuerState.ClaimsTree.Root().Hex(), issuerState.RootsTree.Root().Hex()
+	f.batches.failOn = "new_state"
+
+	if err := f.service.transitIssuanceBatchState(context.Background(), batch); err == nil {
+		t.Fatal("transitIssuanceBatchState() error = nil, want the failed update")
+	}
+
+	if issuerState.ClaimsTree.Root().Hex() != claimsRoot || issuerState.RootsTree.Root().Hex() != rootsRoot {
+		t.Fatal("claims of a failed transition are still in the issuer's trees")
+	}
+	var claim core.Claim
+	if err := claim.FromHex(item.ClaimHex); err != nil {
+		t.Fatal(err)
+	}
+	hi, _, err := claim.HiHv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := issuerState.ClaimsTree.Get(context.Background(), hi); !errors.Is(err, merkletree.ErrKeyNotFound) {
+		t.Fatalf("claims tree lookup error = %v, want %v", err, merkletree.ErrKeyNotFound)
+	}
+}
+
+func TestFailStaleIssuanceBatches(t *testing.T) {
+	tests := []struct {
+		name       string
+		staleAfter time.Duration
+		want       time.Duration
+	}{
+		{name: "default", want: defaultIssuanceBatchStaleAfter},
+		{name: "configured", staleAfter: time.Hour, want: time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newIssuanceBatchFixture(t)
+			f.service.config.Cron.IssuanceBatchStaleAfter = tt.staleAfter
+
+			start := time.Now().UTC()
+			failed, err := f.service.FailStaleIssuanceBatches(context.Background())
+			if err != nil || failed != 1 {
+				t.Fatalf("FailStaleIssuanceBatches() = %d, %v, want 1", failed, err)
+			}
+			if cutoff := start.Sub(f.batches.before); cutoff > tt.want || cutoff < tt.want-time.Second {
+				t.Fatalf("batches failed when untouched for %s, want %s", cutoff, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeepIssuanceBatchAlive(t *testing.T) {
+	f := newIssuanceBatchFixture(t)
+	f.service.config.Cron.IssuanceBatchStaleAfter = 30 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		f.service.keepIssuanceBatchAlive(ctx, 1)
+		close(done)
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	f.batches.mu.Lock()
+	defer f.batches.mu.Unlock()
+	if f.batches.touched == 0 {
+		t.Fatal("running batch was never touched")
+	}
+}
+
+func TestGetCredentialSubjectOfAnotherIssuer(t *testing.T) {
+	f := newIssuanceBatchFixture(t)
+	request := f.approvedRequest()
+
+	// the request of another issuer is refused before its schema is loaded
+	_, err := f.service.GetCredentialSubject(context.Background(), request.PublicID.String(), "did:example:other")
+	if !errors.Is(err, &constant.CredentialRequestNotFound) {
+		t.Fatalf("GetCredentialSubject() error = %v, want %v", err, &constant.CredentialRequestNotFound)
+	}
+}
@@ -0,0 +1,544 @@
+package service
+
+import (
+	"be/internal/domain/credential"
+	"be/internal/domain/schema"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+
+	core "github.com/iden3/go-iden3-core/v2"
+	"github.com/iden3/go-iden3-core/v2/w3c"
+	"github.com/iden3/go-iden3-crypto/babyjub"
+	"github.com/iden3/go-iden3-crypto/poseidon"
+	"github.com/iden3/go-merkletree-sql/v2"
+	jsonSchema "github.com/iden3/go-schema-processor/v2/json"
+	"github.com/iden3/go-schema-processor/v2/merklize"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"github.com/piprate/json-gold/ld"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// names of the checks reported by VerifyCredential
+const (
+	checkExpiration           = "expiration"
+	checkSignatureCoreClaim   = "signature_core_claim"
+	checkSignatureIssuerState = "signature_issuer_state"
+	checkSignatureAuthClaim   = "signature_auth_claim"
+	checkSignature            = "signature"
+	checkInclusionCoreClaim   = "inclusion_core_claim"
+	checkInclusionIssuerState = "inclusion_issuer_state"
+	checkInclusion            = "inclusion"
+	checkSchema               = "schema"
+	checkRevocation           = "revocation"
+	checkAuthClaimRevocation  = "auth_claim_revocation"
+	checkIssuerRecord         = "issuer_record"
+	checkIssuerStateBinding   = "issuer_state_binding"
+)
+
+// bindingChecks tie the credential to an issuer state and a record this deployment can vouch for. A credential
+// that skipped any of them is unverifiable here, even when no check failed.
+var bindingChecks = []string{checkIssuerStateBinding, checkRevocation, checkIssuerRecord}
+
+type ICredentialVerificationService interface {
+	VerifyCredential(ctx context.Context, verifiableCredential *verifiable.W3CCredential) (*dto.CredentialVerificationResponseDto, error)
+	VerifyBundledCredential(ctx context.Context, verifiableCredential *verifiable.W3CCredential, documents map[string]map[string]interface{}) (*dto.CredentialVerificationResponseDto, error)
+}
+
+type CredentialVerificationService struct {
+	logger          *logger.ZapLogger
+	identityService IIdentityService
+	schemaRepo      schema.ISchemaRepository
+	vcRepo          credential.IVerifiableCredentialRepository
+}
+
+func NewCredentialVerificationService(
+	logger *logger.ZapLogger,
+	identityService IIdentityService,
+	schemaRepo schema.ISchemaRepository,
+	vcRepo credential.IVerifiableCredentialRepository,
+) ICredentialVerificationService {
+	return &CredentialVerificationService{
+		logger:          logger,
+		identityService: identityService,
+		schemaRepo:      schemaRepo,
+		vcRepo:          vcRepo,
+	}
+}
+
+// contextLoader resolves the JSON-LD contexts of schemas published on this deployment, then the documents that came
+// with the credential, so verifying a credential never fetches a URL the credential names.
+type contextLoader struct {
+	ctx        context.Context
+	schemaRepo schema.ISchemaRepository
+	documents  map[string]map[string]interface{}
+}
+
+func (l *contextLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	schemaEntity, err := l.schemaRepo.FindSchemaByContextURL(l.ctx, url)
+	if err == nil {
+		return &ld.RemoteDocument{DocumentURL: url, Document: map[string]interface{}(schemaEntity.JSONLDContext)}, nil
+	}
+	if document, ok := l.documents[url]; ok {
+		return &ld.RemoteDocument{DocumentURL: url, Document: document}, nil
+	}
+	return nil, fmt.Errorf("context %s is not published on this deployment", url)
+}
+
+// documentLoader returns a loader for one verification. It is not shared, so nothing a credential refers to
+// outlives the call.
+func (s *CredentialVerificationService) documentLoader(ctx context.Context, documents map[string]map[string]interface{}) ld.DocumentLoader {
+	return helper.NewEmbeddedLoader(&contextLoader{ctx: ctx, schemaRepo: s.schemaRepo, documents: documents})
+}
+
+// credentialChecks collects the outcome of each check in the order they ran.
+type credentialChecks struct {
+	checks []*dto.CredentialCheckDto
+}
+
+func (c *credentialChecks) add(name string, err error) {
+	if err != nil {
+		c.checks = append(c.checks, &dto.CredentialCheckDto{Name: name, Status: constant.CredentialCheckFailedStatus, Message: err.Error()})
+		return
+	}
+	c.checks = append(c.checks, &dto.CredentialCheckDto{Name: name, Status: constant.CredentialCheckPassedStatus})
+}
+
+func (c *credentialChecks) skip(name string, reason string) {
+	c.checks = append(c.checks, &dto.CredentialCheckDto{Name: name, Status: constant.CredentialCheckSkippedStatus, Message: reason})
+}
+
+func (c *credentialChecks) result() *dto.CredentialVerificationResponseDto {
+	failed, unverifiable := false, false
+	for _, check := range c.checks {
+		switch {
+		case check.Status == constant.CredentialCheckFailedStatus:
+			failed = true
+		case check.Status == constant.CredentialCheckSkippedStatus && slices.Contains(bindingChecks, check.Name):
+			unverifiable = true
+		}
+	}
+	return &dto.CredentialVerificationResponseDto{
+		Valid:        !failed && !unverifiable,
+		Unverifiable: !failed && unverifiable,
+		Checks:       c.checks,
+	}
+}
+
+// VerifyCredential checks a credential from its embedded proofs: the BJJ signature against the issuer's auth claim
+// and its inclusion proof, and the sparse merkle tree inclusion against the stated issuer state. The stated state
+// must be the issuer's genesis state or one this deployment recorded for it; the chain is not queried. The subject
+// is validated against the schema and revocation is checked when the schema or the issuer is known here. A
+// credential from an issuer this deployment does not manage is never valid, only unverifiable.
+func (s *CredentialVerificationService) VerifyCredential(ctx context.Context, verifiableCredential *verifiable.W3CCredential) (*dto.CredentialVerificationResponseDto, error) {
+	return s.verifyCredential(ctx, verifiableCredential, nil)
+}
+
+// VerifyBundledCredential verifies a credential from an exported bundle. The contexts the bundle carries are used
+// for those not published here.
+func (s *CredentialVerificationService) VerifyBundledCredential(ctx context.Context, verifiableCredential *verifiable.W3CCredential, documents map[string]map[string]interface{}) (*dto.CredentialVerificationResponseDto, error) {
+	return s.verifyCredential(ctx, verifiableCredential, documents)
+}
+
+func (s *CredentialVerificationService) verifyCredential(ctx context.Context, verifiableCredential *verifiable.W3CCredential, documents map[string]map[string]interface{}) (*dto.CredentialVerificationResponseDto, error) {
+	if verifiableCredential == nil || len(verifiableCredential.Proof) == 0 {
+		return nil, &constant.BadRequest
+	}
+	checks := &credentialChecks{}
+	loader := s.documentLoader(ctx, documents)
+
+	if verifiableCredential.Expiration != nil && verifiableCredential.Expiration.Before(time.Now()) {
+		checks.add(checkExpiration, fmt.Errorf("credential expired at %s", verifiableCredential.Expiration.UTC().Format(time.RFC3339)))
+	} else {
+		checks.add(checkExpiration, nil)
+	}
+
+	var (
+		signatureProof *verifiable.BJJSignatureProof2021
+		inclusionProof *verifiable.Iden3SparseMerkleTreeProof
+		coreClaim      *core.Claim
+		states         []string
+	)
+	for _, proof := range verifiableCredential.Proof {
+		switch p := proof.(type) {
+		case *verifiable.BJJSignatureProof2021:
+			signatureProof = p
+		case *verifiable.Iden3SparseMerkleTreeProof:
+			inclusionProof = p
+		}
+	}
+
+	if signatureProof == nil {
+		for _, name := range []string{checkSignatureCoreClaim, checkSignatureIssuerState, checkSignatureAuthClaim, checkSignature} {
+			checks.skip(name, "credential has no BJJSignatureProof2021")
+		}
+	} else {
+		claim, err := parseCoreClaim(signatureProof.CoreClaim)
+		checks.add(checkSignatureCoreClaim, firstError(err, func() error { return verifyCoreClaim(ctx, loader, verifiableCredential, claim) }))
+		checks.add(checkSignatureIssuerState, verifyIssuerState(signatureProof.IssuerData, verifiableCredential.Issuer))
+		states = appendState(states, signatureProof.IssuerData)
+		checks.add(checkSignatureAuthClaim, verifyAuthClaimInclusion(signatureProof.IssuerData))
+		checks.add(checkSignature, firstError(err, func() error { return verifyClaimSignature(claim, signatureProof) }))
+		if err == nil {
+			coreClaim = claim
+		}
+	}
+
+	if inclusionProof == nil {
+		for _, name := range []string{checkInclusionCoreClaim, checkInclusionIssuerState, checkInclusion} {
+			checks.skip(name, "credential has no Iden3SparseMerkleTreeProof")
+		}
+	} else {
+		claim, err := parseCoreClaim(inclusionProof.CoreClaim)
+		checks.add(checkInclusionCoreClaim, firstError(err, func() error { return verifyCoreClaim(ctx, loader, verifiableCredential, claim) }))
+		checks.add(checkInclusionIssuerState, verifyIssuerState(inclusionProof.IssuerData, verifiableCredential.Issuer))
+		states = appendState(states, inclusionProof.IssuerData)
+		checks.add(checkInclusion, firstError(err, func() error { return verifyClaimInclusion(claim, inclusionProof) }))
+		if err == nil && coreClaim == nil {
+			coreClaim = claim
+		}
+	}
+
+	record, err := s.vcRepo.FindVerifiableCredentialByCredentialId(ctx, verifiableCredential.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		s.logger.WithContext(ctx).Warn("failed to load credential record for verification", zap.String("credential_id", verifiableCredential.ID), zap.Error(err))
+	}
+	if err != nil || record.IssuerDID != verifiableCredential.Issuer {
+		record = nil
+	}
+
+	s.verifyStateBinding(ctx, verifiableCredential, states, record, checks)
+	s.verifySchema(ctx, verifiableCredential, checks)
+	s.verifyRevocation(ctx, verifiableCredential, coreClaim, signatureProof, record, err, checks)
+
+	return checks.result(), nil
+}
+
+func firstError(err error, next func() error) error {
+	if err != nil {
+		return err
+	}
+	return next()
+}
+
+func parseCoreClaim(claimHex string) (*core.Claim, error) {
+	var claim core.Claim
+	if err := claim.FromHex(claimHex); err != nil {
+		return nil, fmt.Errorf("invalid core claim: %w", err)
+	}
+	return &claim, nil
+}
+
+// verifyCoreClaim rebuilds the core claim from the credential with the options the proof's claim was built with,
+// so a proof cannot be moved onto another credential.
+func verifyCoreClaim(ctx context.Context, loader ld.DocumentLoader, verifiableCredential *verifiable.W3CCredential, claim *core.Claim) error {
+	merklizedPosition, err := claim.GetMerklizedPosition()
+	if err != nil {
+		return fmt.Errorf("invalid merklized root position: %w", err)
+	}
+	idPosition, err := claim.GetIDPosition()
+	if err != nil {
+		return fmt.Errorf("invalid subject position: %w", err)
+	}
+
+	options := &verifiable.CoreClaimOptions{
+		RevNonce:      claim.GetRevocationNonce(),
+		Version:       claim.GetVersion(),
+		Updatable:     claim.GetFlagUpdatable(),
+		MerklizerOpts: []merklize.MerklizeOption{merklize.WithDocumentLoader(loader)},
+	}
+	switch merklizedPosition {
+	case core.MerklizedRootPositionIndex:
+		options.MerklizedRootPosition = verifiable.CredentialMerklizedRootPositionIndex
+	case core.MerklizedRootPositionValue:
+		options.MerklizedRootPosition = verifiable.CredentialMerklizedRootPositionValue
+	default:
+		options.MerklizedRootPosition = verifiable.CredentialMerklizedRootPositionNone
+	}
+	switch idPosition {
+	case core.IDPositionIndex:
+		options.SubjectPosition = verifiable.CredentialSubjectPositionIndex
+	case core.IDPositionValue:
+		options.SubjectPosition = verifiable.CredentialSubjectPositionValue
+	}
+
+	rebuilt, err := verifiableCredential.ToCoreClaim(ctx, options)
+	if err != nil {
+		return fmt.Errorf("failed to build core claim from credential: %w", err)
+	}
+	rebuiltHex, err := rebuilt.Hex()
+	if err != nil {
+		return err
+	}
+	claimHex, err := claim.Hex()
+	if err != nil {
+		return err
+	}
+	if rebuiltHex != claimHex {
+		return errors.New("proof was generated for another credential")
+	}
+	return nil
+}
+
+// verifyIssuerState checks that the stated issuer state is the hash of the stated tree roots.
+func verifyIssuerState(issuerData verifiable.IssuerData, issuer string) error {
+	if issuerData.ID != issuer {
+		return fmt.Errorf("proof is from %s, credential is from %s", issuerData.ID, issuer)
+	}
+	state := issuerData.State
+	if state.Value == nil || state.ClaimsTreeRoot == nil || state.RevocationTreeRoot == nil || state.RootOfRoots == nil {
+		return errors.New("issuer state is incomplete")
+	}
+
+	roots := make([]*big.Int, 0, 3)
+	for _, root := range []string{*state.ClaimsTreeRoot, *state.RevocationTreeRoot, *state.RootOfRoots} {
+		hash, err := merkletree.NewHashFromHex(root)
+		if err != nil {
+			return fmt.Errorf("invalid tree root: %w", err)
+		}
+		roots = append(roots, hash.BigInt())
+	}
+	expected, err := merkletree.HashElems(roots...)
+	if err != nil {
+		return err
+	}
+	value, err := merkletree.NewHashFromHex(*state.Value)
+	if err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	if expected.BigInt().Cmp(value.BigInt()) != 0 {
+		return errors.New("issuer state does not match its tree roots")
+	}
+	return nil
+}
+
+// appendState adds the state value of a proof, when it has one, to the states the credential is proven against.
+func appendState(states []string, issuerData verifiable.IssuerData) []string {
+	if issuerData.State.Value == nil || slices.Contains(states, *issuerData.State.Value) {
+		return states
+	}
+	return append(states, *issuerData.State.Value)
+}
+
+// verifyStateBinding ties every state the credential is proven against to the issuer's DID.
+func (s *CredentialVerificationService) verifyStateBinding(ctx context.Context, verifiableCredential *verifiable.W3CCredential, states []string, record *credential.VerifiableCredential, checks *credentialChecks) {
+	if len(states) == 0 {
+		checks.skip(checkIssuerStateBinding, "credential states no issuer state")
+		return
+	}
+	did, err := w3c.ParseDID(verifiableCredential.Issuer)
+	if err != nil {
+		checks.add(checkIssuerStateBinding, fmt.Errorf("invalid issuer DID: %w", err))
+		return
+	}
+	id, err := core.IDFromDID(*did)
+	if err != nil {
+		checks.add(checkIssuerStateBinding, fmt.Errorf("invalid issuer DID: %w", err))
+		return
+	}
+	for _, state := range states {
+		if err := s.verifyKnownState(ctx, verifiableCredential.Issuer, id, state, record); err != nil {
+			checks.add(checkIssuerStateBinding, err)
+			return
+		}
+	}
+	checks.add(checkIssuerStateBinding, nil)
+}
+
+// verifyKnownState accepts the issuer's genesis state, which the DID is derived from, and the states this deployment
+// recorded for an issuer it manages: the state the credential was issued against, the current one and those of
+// past transitions.
+func (s *CredentialVerificationService) verifyKnownState(ctx context.Context, issuer string, id core.ID, state string, record *credential.VerifiableCredential) error {
+	value, err := merkletree.NewHashFromHex(state)
+	if err != nil {
+		return fmt.Errorf("invalid state: %w", err)
+	}
+	genesis, err := core.CheckGenesisStateID(id.BigInt(), value.BigInt())
+	if err != nil {
+		return fmt.Errorf("failed to check genesis state: %w", err)
+	}
+	if genesis || (record != nil && record.IssuerState == state) {
+		return nil
+	}
+
+	known, err := s.identityService.IsKnownState(ctx, issuer, state)
+	switch {
+	case errors.Is(err, &constant.IdentityNotFound):
+		return fmt.Errorf("state %s is not the genesis state of %s and the issuer is not managed by this deployment", state, issuer)
+	case err != nil:
+		return fmt.Errorf("failed to look the state up: %w", err)
+	case !known:
+		return fmt.Errorf("%s never held state %s", issuer, state)
+	}
+	return nil
+}
+
+// verifyAuthClaimInclusion checks that the issuer's auth claim is in the claims tree of the stated state.
+func verifyAuthClaimInclusion(issuerData verifiable.IssuerData) error {
+	authClaim, err := parseCoreClaim(issuerData.AuthCoreClaim)
+	if err != nil {
+		return err
+	}
+	if issuerData.MTP == nil || issuerData.State.ClaimsTreeRoot == nil {
+		return errors.New("auth claim proof is missing")
+	}
+	return verifyInclusion(authClaim, issuerData.MTP, *issuerData.State.ClaimsTreeRoot)
+}
+
+// verifyClaimSignature checks the BJJ signature over the claim's hash with the key in the issuer's auth claim.
+func verifyClaimSignature(claim *core.Claim, proof *verifiable.BJJSignatureProof2021) error {
+	authClaim, err := parseCoreClaim(proof.IssuerData.AuthCoreClaim)
+	if err != nil {
+		return err
+	}
+	signature, err := helper.GetSignatureFromString(proof.Signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+
+	hi, hv, err := claim.HiHv()
+	if err != nil {
+		return err
+	}
+	claimHash, err := poseidon.Hash([]*big.Int{hi, hv})
+	if err != nil {
+		return err
+	}
+
+	// the issuer's public key is in index slots A and B of the auth claim
+	slots := authClaim.RawSlotsAsInts()
+	publicKey := &babyjub.PublicKey{X: slots[2], Y: slots[3]}
+	if !publicKey.VerifyPoseidon(claimHash, signature) {
+		return errors.New("signature does not match the issuer's auth claim")
+	}
+	return nil
+}
+
+func verifyClaimInclusion(claim *core.Claim, proof *verifiable.Iden3SparseMerkleTreeProof) error {
+	if proof.MTP == nil || proof.IssuerData.State.ClaimsTreeRoot == nil {
+		return errors.New("inclusion proof is missing")
+	}
+	return verifyInclusion(claim, proof.MTP, *proof.IssuerData.State.ClaimsTreeRoot)
+}
+
+func verifyInclusion(claim *core.Claim, proof *merkletree.Proof, claimsTreeRoot string) error {
+	root, err := merkletree.NewHashFromHex(claimsTreeRoot)
+	if err != nil {
+		return fmt.Errorf("invalid claims tree root: %w", err)
+	}
+	hi, hv, err := claim.HiHv()
+	if err != nil {
+		return err
+	}
+	if !proof.Existence || !merkletree.VerifyProof(root, proof, hi, hv) {
+		return errors.New("claim is not in the issuer's claims tree")
+	}
+	return nil
+}
+
+// verifySchema validates the credential against the JSON schema of its context, when the schema was published here.
+func (s *CredentialVerificationService) verifySchema(ctx context.Context, verifiableCredential *verifiable.W3CCredential, checks *credentialChecks) {
+	var schemaEntity *schema.Schema
+	for _, contextURL := range verifiableCredential.Context {
+		if contextURL == verifiable.JSONLDSchemaW3CCredential2018 || contextURL == verifiable.JSONLDSchemaIden3Credential {
+			continue
+		}
+		found, err := s.schemaRepo.FindSchemaByContextURL(ctx, contextURL)
+		if err == nil {
+			schemaEntity = found
+			break
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			checks.skip(checkSchema, "schema lookup failed")
+			return
+		}
+	}
+	if schemaEntity == nil {
+		checks.skip(checkSchema, "schema is not published on this deployment")
+		return
+	}
+	if schemaEntity.SchemaURL != verifiableCredential.CredentialSchema.ID {
+		checks.add(checkSchema, fmt.Errorf("credential schema %s does not belong to its context", verifiableCredential.CredentialSchema.ID))
+		return
+	}
+
+	data, err := json.Marshal(verifiableCredential)
+	if err != nil {
+		checks.add(checkSchema, err)
+		return
+	}
+	schemaBytes, err := json.Marshal(schemaEntity.JSONSchema)
+	if err != nil {
+		checks.add(checkSchema, err)
+		return
+	}
+	checks.add(checkSchema, jsonSchema.Validator{}.ValidateData(data, schemaBytes))
+}
+
+// verifyRevocation looks the claim and the issuer's auth claim up in the issuer's current revocation tree, and the
+// credential in the issuer's records. Both need the issuer to be managed by this deployment.
+func (s *CredentialVerificationService) verifyRevocation(
+	ctx context.Context,
+	verifiableCredential *verifiable.W3CCredential,
+	claim *core.Claim,
+	signatureProof *verifiable.BJJSignatureProof2021,
+	record *credential.VerifiableCredential,
+	recordErr error,
+	checks *credentialChecks,
+) {
+	identityState, err := s.identityService.GetIdentityStateByDID(ctx, verifiableCredential.Issuer)
+	if err != nil {
+		if !errors.Is(err, &constant.IdentityNotFound) {
+			s.logger.WithContext(ctx).Warn("failed to load issuer state for verification", zap.String("issuer_did", verifiableCredential.Issuer), zap.Error(err))
+		}
+		for _, name := range []string{checkRevocation, checkAuthClaimRevocation, checkIssuerRecord} {
+			checks.skip(name, "issuer is not managed by this deployment")
+		}
+		return
+	}
+
+	if claim == nil {
+		checks.skip(checkRevocation, "credential has no readable core claim")
+	} else {
+		checks.add(checkRevocation, verifyNotRevoked(ctx, identityState, claim.GetRevocationNonce(), "credential"))
+	}
+
+	if signatureProof == nil {
+		checks.skip(checkAuthClaimRevocation, "credential has no BJJSignatureProof2021")
+	} else if authClaim, err := parseCoreClaim(signatureProof.IssuerData.AuthCoreClaim); err != nil {
+		checks.add(checkAuthClaimRevocation, err)
+	} else {
+		checks.add(checkAuthClaimRevocation, verifyNotRevoked(ctx, identityState, authClaim.GetRevocationNonce(), "issuer auth claim"))
+	}
+
+	switch {
+	case recordErr != nil && !errors.Is(recordErr, gorm.ErrRecordNotFound):
+		checks.skip(checkIssuerRecord, "credential lookup failed")
+	case record == nil:
+		checks.add(checkIssuerRecord, errors.New("issuer has no record of this credential"))
+	case record.Status == constant.VerifiableCredentialRevokedStatus:
+		checks.add(checkIssuerRecord, errors.New("issuer revoked this credential"))
+	default:
+		checks.add(checkIssuerRecord, nil)
+	}
+}
+
+func verifyNotRevoked(ctx context.Context, identityState *IdentityState, revNonce uint64, subject string) error {
+	proof, _, err := identityState.RevTree.GenerateProof(ctx, new(big.Int).SetUint64(revNonce), identityState.RevTree.Root())
+	if err != nil {
+		return fmt.Errorf("failed to read revocation tree: %w", err)
+	}
+	if proof.Existence {
+		return fmt.Errorf("%s is revoked", subject)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCredentialChecksResult(t *testing.T) {
+	failed := errors.New("failed")
+	tests := []struct {
+		name             string
+		run              func(c *credentialChecks)
+		wantValid        bool
+		wantUnverifiable bool
+	}{
+		{name: "all passed", run: func(c *credentialChecks) {
+			c.add(checkSignature, nil)
+			c.add(checkIssuerStateBinding, nil)
+			c.add(checkRevocation, nil)
+			c.add(checkIssuerRecord, nil)
+		}, wantValid: true},
+		{name: "unpublished schema", run: func(c *credentialChecks) {
+			c.add(checkSignature, nil)
+			c.add(checkIssuerStateBinding, nil)
+			c.skip(checkSchema, "schema is not published on this deployment")
+			c.add(checkRevocation, nil)
+			c.add(checkIssuerRecord, nil)
+		}, wantValid: true},
+		{name: "unmanaged issuer", run: func(c *credentialChecks) {
+			c.add(checkSignature, nil)
+			c.add(checkIssuerStateBinding, nil)
+			c.skip(checkRevocation, "issuer is not managed by this deployment")
+			c.skip(checkIssuerRecord, "issuer is not managed by this deployment")
+		}, wantUnverifiable: true},
+		{name: "no issuer state", run: func(c *credentialChecks) {
+			c.skip(checkIssuerStateBinding, "credential states no issuer state")
+			c.add(checkRevocation, nil)
+			c.add(checkIssuerRecord, nil)
+		}, wantUnverifiable: true},
+		{name: "failed check wins over skipped", run: func(c *credentialChecks) {
+			c.add(checkSignature, failed)
+			c.skip(checkRevocation, "issuer is not managed by this deployment")
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := &credentialChecks{}
+			tt.run(checks)
+			got := checks.result()
+			if got.Valid != tt.wantValid || got.Unverifiable != tt.wantUnverifiable {
+				t.Fatalf("result() valid = %v unverifiable = %v, want %v %v", got.Valid, got.Unverifiable, tt.wantValid, tt.wantUnverifiable)
+			}
+		})
+	}
+}
@@ -2,14 +2,23 @@ package service
 
 import (
 	"be/config"
+	"be/internal/domain/credential"
 	"be/internal/domain/document"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
 	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"be/internal/shared/utils"
 	"be/internal/transport/http/dto"
 	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -21,7 +30,7 @@ type IDocumentService interface {
 	GetCitizenIdentityByPublicId(ctx context.Context, id string) (*dto.CitizenIdentityResponseDto, error)
 	GetCitizenIdentityByIdNumber(ctx context.Context, idNumber string) (*dto.CitizenIdentityResponseDto, error)
 	GetCitizenIdentityByHolderDID(ctx context.Context, holderDID string) (*dto.CitizenIdentityResponseDto, error)
-	GetCitizenIdentities(ctx context.Context) ([]*dto.CitizenIdentityResponseDto, error)
+	GetCitizenIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*dto.CitizenIdentityResponseDto, *helper.Pagination, error)
 
 	CreateAcademicDegree(ctx context.Context, request *dto.AcademicDegreeCreatedRequestDto) (*dto.AcademicDegreeResponseDto, error)
 	UpdateAcademicDegree(ctx context.Context, id string, request *dto.AcademicDegreeUpdatedRequestDto) (*dto.AcademicDegreeResponseDto, error)
@@ -29,7 +38,7 @@ type IDocumentService interface {
 	GetAcademicDegreeByPublicId(ctx context.Context, id string) (*dto.AcademicDegreeResponseDto, error)
 	GetAcademicDegreeByDegreeNumber(ctx context.Context, degreeNumber string) (*dto.AcademicDegreeResponseDto, error)
 	GetAcademicDegreeByHolderDID(ctx context.Context, holderDID string) (*dto.AcademicDegreeResponseDto, error)
-	GetAcademicDegrees(ctx context.Context) ([]*dto.AcademicDegreeResponseDto, error)
+	GetAcademicDegrees(ctx context.Context, spec *helper.QuerySpec) ([]*dto.AcademicDegreeResponseDto, *helper.Pagination, error)
 
 	CreateHealthInsurance(ctx context.Context, request *dto.HealthInsuranceCreatedRequestDto) (*dto.HealthInsuranceResponseDto, error)
 	UpdateHealthInsurance(ctx context.Context, id string, request *dto.HealthInsuranceUpdatedRequestDto) (*dto.HealthInsuranceResponseDto, error)
@@ -37,7 +46,7 @@ type IDocumentService interface {
 	GetHealthInsuranceByPublicId(ctx context.Context, id string) (*dto.HealthInsuranceResponseDto, error)
 	GetHealthInsuranceByInsuranceNumber(ctx context.Context, insuranceNumber string) (*dto.HealthInsuranceResponseDto, error)
 	GetHealthInsuranceByHolderDID(ctx context.Context, holderDID string) (*dto.HealthInsuranceResponseDto, error)
-	GetHealthInsurances(ctx context.Context) ([]*dto.HealthInsuranceResponseDto, error)
+	GetHealthInsurances(ctx context.Context, spec *helper.QuerySpec) ([]*dto.HealthInsuranceResponseDto, *helper.Pagination, error)
 
 	CreateDriverLicense(ctx context.Context, request *dto.DriverLicenseCreatedRequestDto) (*dto.DriverLicenseResponseDto, error)
 	UpdateDriverLicense(ctx context.Context, id string, request *dto.DriverLicenseUpdatedRequestDto) (*dto.DriverLicenseResponseDto, error)
@@ -45,7 +54,7 @@ type IDocumentService interface {
 	GetDriverLicenseByPublicId(ctx context.Context, id string) (*dto.DriverLicenseResponseDto, error)
 	GetDriverLicenseByLicenseNumber(ctx context.Context, licenseNumber string) (*dto.DriverLicenseResponseDto, error)
 	GetDriverLicenseByHolderDID(ctx context.Context, holderDID string) (*dto.DriverLicenseResponseDto, error)
-	GetDriverLicenses(ctx context.Context) ([]*dto.DriverLicenseResponseDto, error)
+	GetDriverLicenses(ctx context.Context, spec *helper.QuerySpec) ([]*dto.DriverLicenseResponseDto, *helper.Pagination, error)
 
 	CreatePassport(ctx context.Context, request *dto.PassportCreatedRequestDto) (*dto.PassportResponseDto, error)
 	UpdatePassport(ctx context.Context, id string, request *dto.PassportUpdatedRequestDto) (*dto.PassportResponseDto, error)
@@ -53,56 +62,170 @@ type IDocumentService interface {
 	GetPassportByPublicId(ctx context.Context, id string) (*dto.PassportResponseDto, error)
 	GetPassportByPassportNumber(ctx context.Context, passportNumber string) (*dto.PassportResponseDto, error)
 	GetPassportByHolderDID(ctx context.Context, holderDID string) (*dto.PassportResponseDto, error)
-	GetPassports(ctx context.Context) ([]*dto.PassportResponseDto, error)
+	GetPassports(ctx context.Context, spec *helper.QuerySpec) ([]*dto.PassportResponseDto, *helper.Pagination, error)
+
+	RenewDriverLicense(ctx context.Context, id string, request *dto.DriverLicenseRenewedRequestDto) (*dto.DriverLicenseResponseDto, error)
+	RenewPassport(ctx context.Context, id string, request *dto.PassportRenewedRequestDto) (*dto.PassportResponseDto, error)
+	GetDocumentRevisions(ctx context.Context, documentType constant.DocumentType, id string, spec *helper.QuerySpec) ([]*dto.DocumentRevisionResponseDto, *helper.Pagination, error)
+
+	GetHolderDocuments(ctx context.Context, holderDID string) (*dto.HolderDocumentsResponseDto, error)
+	GetHolderDocumentSnapshot(ctx context.Context, documentType constant.DocumentType, holderDID string) (map[string]interface{}, error)
+	ApplyDocumentCorrection(ctx context.Context, documentType constant.DocumentType, id string, field string, value string, changedBy string) error
+
+	BuildCredentialSubject(ctx context.Context, schemaEntity *schema.Schema, holderDID string) (map[string]interface{}, error)
 }
 
 type DocumentService struct {
 	config              *config.Config
+	db                  *postgres.PostgresDB
+	validate            *validator.Validate
 	citizenIdentityRepo document.ICitizenIdentityRepository
 	academicDegreeRepo  document.IAcademicDegreeRepository
 	healthInsuranceRepo document.IHealthInsuranceRepository
 	driverLicenseRepo   document.IDriverLicenseRepository
 	passportRepo        document.IPassportRepository
+	revisionRepo        document.IDocumentRevisionRepository
+	revisions           *documentRevisions
+	numberingService    INumberingService
 }
 
 func NewDocumentService(
 	config *config.Config,
+	db *postgres.PostgresDB,
 	citizenIdentityRepo document.ICitizenIdentityRepository,
 	academicDegreeRepo document.IAcademicDegreeRepository,
 	healthInsuranceRepo document.IHealthInsuranceRepository,
 	driverInsuranceRepo document.IDriverLicenseRepository,
 	passportRepo document.IPassportRepository,
+	revisionRepo document.IDocumentRevisionRepository,
+	vcRepo credential.IVerifiableCredentialRepository,
+	numberingService INumberingService,
 ) IDocumentService {
 	return &DocumentService{
 		config:              config,
+		db:                  db,
+		validate:            newDocumentValidator(),
 		citizenIdentityRepo: citizenIdentityRepo,
 		academicDegreeRepo:  academicDegreeRepo,
 		healthInsuranceRepo: healthInsuranceRepo,
 		driverLicenseRepo:   driverInsuranceRepo,
 		passportRepo:        passportRepo,
+		revisionRepo:        revisionRepo,
+		revisions:           &documentRevisions{revisionRepo: revisionRepo, vcRepo: vcRepo},
+		numberingService:    numberingService,
 	}
 }
 
-func (s *DocumentService) CreateCitizenIdentity(ctx context.Context, request *dto.CitizenIdentityCreatedRequestDto) (*dto.CitizenIdentityResponseDto, error) {
+// newDocumentValidator runs the validate tags of document entities and reports fields by their json names.
+func newDocumentValidator() *validator.Validate {
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return strings.Split(field.Tag.Get("json"), ",")[0]
+	})
+	return validate
+}
+
+// documentRevisions records every saved change of a document and flags the credentials issued from earlier revisions.
+type documentRevisions struct {
+	revisionRepo document.IDocumentRevisionRepository
+	vcRepo       credential.IVerifiableCredentialRepository
+}
+
+// record stores the document as just saved. before is the document prior to the change, nil for a new document.
+func (r *documentRevisions) record(
+	ctx context.Context,
+	documentType constant.DocumentType,
+	documentID uint,
+	revision int,
+	holderDID string,
+	action constant.DocumentRevisionAction,
+	changedBy string,
+	before map[string]interface{},
+	entity interface{},
+) error {
+	snapshot, err := utils.DocumentSnapshot(entity)
+	if err != nil {
+		return err
+	}
+
+	var changes map[string]interface{}
+	if before != nil {
+		changes = utils.DiffDocuments(before, snapshot)
+	}
+
+	if _, err := r.revisionRepo.CreateDocumentRevision(ctx, &document.DocumentRevision{
+		PublicID:     uuid.New(),
+		DocumentType: documentType,
+		DocumentID:   documentID,
+		Revision:     revision,
+		Action:       action,
+		Changes:      changes,
+		Snapshot:     snapshot,
+		ChangedBy:    changedBy,
+	}); err != nil {
+		return err
+	}
+
+	if action != constant.DocumentAmendedAction && action != constant.DocumentSupersededAction {
+		return nil
+	}
+	// citizen identity fields are shared by the credentials of every other document type
+	if documentType == constant.CitizenIdentity {
+		documentType = ""
+	}
+	_, err = r.vcRepo.FlagVerifiableCredentialsForReissue(ctx, holderDID, documentType)
+	return err
+}
+
+// lock takes a row lock on the document for the rest of the transaction and checks that revision, the revision
+// about to be recorded, still follows the stored one. A document changed since it was read fails with
+// DocumentChanged instead of being overwritten or recording the same revision twice.
+func (r *documentRevisions) lock(ctx context.Context, documentType constant.DocumentType, documentID uint, revision int) error {
+	current, err := r.revisionRepo.LockDocumentRevision(ctx, documentType, documentID)
+	if err != nil {
+		return err
+	}
+	if current+1 != revision {
+		return &constant.DocumentChanged
+	}
+	return nil
+}
+
+func (s *DocumentService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
+}
 
-	idNumber, _ := utils.GetIdNumber()
-	citizenCreated, err := s.citizenIdentityRepo.CreateCitizenIdentity(ctx, &document.CitizenIdentity{
+func (s *DocumentService) CreateCitizenIdentity(ctx context.Context, request *dto.CitizenIdentityCreatedRequestDto) (*dto.CitizenIdentityResponseDto, error) {
+	citizenCreated := &document.CitizenIdentity{
 		PublicID:     uuid.New(),
-		IDNumber:     idNumber,
 		FirstName:    request.FirstName,
 		LastName:     request.LastName,
 		Gender:       request.Gender,
 		DateOfBirth:  request.DateOfBirth,
 		PlaceOfBirth: request.PlaceOfBirth,
 		Status:       constant.DocumentActiveStatus,
+		Revision:     1,
 		IssueDate:    request.IssueDate,
 		ExpiryDate:   request.ExpiryDate,
 		HolderDID:    request.HolderDID,
 		IssuerDID:    request.IssuerDID,
+	}
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		idNumber, err := s.numberingService.NewIdNumber(ctx, citizenCreated)
+		if err != nil {
+			return err
+		}
+		citizenCreated.IDNumber = idNumber
+		if _, err := s.citizenIdentityRepo.CreateCitizenIdentity(ctx, citizenCreated); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.CitizenIdentity, citizenCreated.ID, citizenCreated.Revision, citizenCreated.HolderDID, constant.DocumentCreatedAction, request.IssuerDID, nil, citizenCreated)
 	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
 	return dto.CitizenIdentityToResponse(citizenCreated), nil
@@ -118,6 +241,11 @@ func (s *DocumentService) UpdateCitizenIdentity(ctx context.Context, id string,
 		return nil, &constant.InternalServer
 	}
 
+	before, err := utils.DocumentSnapshot(citizen)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
 	citizen.FirstName = request.FirstName
 	citizen.LastName = request.LastName
 	citizen.Gender = request.Gender
@@ -126,13 +254,25 @@ func (s *DocumentService) UpdateCitizenIdentity(ctx context.Context, id string,
 	citizen.IssueDate = request.IssueDate
 	citizen.ExpiryDate = request.ExpiryDate
 
-	citizenUpdated, err := s.citizenIdentityRepo.SaveCitizenIdentity(ctx, citizen)
+	citizen.Revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.CitizenIdentity, citizen.ID, citizen.Revision); err != nil {
+			return err
+		}
+		if _, err := s.citizenIdentityRepo.SaveCitizenIdentity(ctx, citizen); err != nil {
+			return err
+		}
+		if err := s.revisions.record(ctx, constant.CitizenIdentity, citizen.ID, citizen.Revision, citizen.HolderDID, constant.DocumentAmendedAction, request.ChangedBy, before, citizen); err != nil {
+			return err
+		}
+		return s.regeneratePassportMRZ(ctx, citizen, request.ChangedBy)
+	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
-	return dto.CitizenIdentityToResponse(citizenUpdated), nil
+	return dto.CitizenIdentityToResponse(citizen), nil
 
 }
 
@@ -144,8 +284,26 @@ func (s *DocumentService) RevokeCitizenIdentity(ctx context.Context, id string,
 		}
 		return &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status, "revoked_at": time.Now().UTC()}
-	return s.citizenIdentityRepo.UpdateCitizenIdentity(ctx, citizenIdentity, changes)
+
+	before, err := utils.DocumentSnapshot(citizenIdentity)
+	if err != nil {
+		return &constant.InternalServer
+	}
+
+	revokedAt := time.Now().UTC()
+	citizenIdentity.Status = constant.DocumentStatus(request.Status)
+	citizenIdentity.RevokedAt = &revokedAt
+	citizenIdentity.Revision++
+	changes := map[string]interface{}{"status": citizenIdentity.Status, "revoked_at": revokedAt, "revision": citizenIdentity.Revision}
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.CitizenIdentity, citizenIdentity.ID, citizenIdentity.Revision); err != nil {
+			return err
+		}
+		if err := s.citizenIdentityRepo.UpdateCitizenIdentity(ctx, citizenIdentity, changes); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.CitizenIdentity, citizenIdentity.ID, citizenIdentity.Revision, citizenIdentity.HolderDID, constant.DocumentRevokedAction, request.ChangedBy, before, citizenIdentity)
+	})
 }
 
 func (s *DocumentService) GetCitizenIdentityByPublicId(ctx context.Context, id string) (*dto.CitizenIdentityResponseDto, error) {
@@ -182,20 +340,22 @@ func (s *DocumentService) GetCitizenIdentityByIdNumber(ctx context.Context, idNu
 
 }
 
-func (s *DocumentService) GetCitizenIdentities(ctx context.Context) ([]*dto.CitizenIdentityResponseDto, error) {
-	citizens, err := s.citizenIdentityRepo.FindAllCitizenIdentities(ctx)
+func (s *DocumentService) GetCitizenIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*dto.CitizenIdentityResponseDto, *helper.Pagination, error) {
+	citizens, total, err := s.citizenIdentityRepo.FindAllCitizenIdentities(ctx, spec)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.CitizenIdentityNotFound
+			return nil, nil, &constant.CitizenIdentityNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resps []*dto.CitizenIdentityResponseDto
+	var lastID uint
 	for _, c := range citizens {
 		resps = append(resps, dto.CitizenIdentityToResponse(c))
+		lastID = c.ID
 	}
-	return resps, nil
+	return resps, spec.Pagination(total, len(citizens), lastID), nil
 }
 
 func (s *DocumentService) CreateAcademicDegree(ctx context.Context, request *dto.AcademicDegreeCreatedRequestDto) (*dto.AcademicDegreeResponseDto, error) {
@@ -208,11 +368,9 @@ func (s *DocumentService) CreateAcademicDegree(ctx context.Context, request *dto
 		return nil, err
 	}
 
-	degreeNumber, _ := utils.GetDegreeNumber()
-	academicDegreeCreated, err := s.academicDegreeRepo.CreateAcademicDegree(ctx, &document.AcademicDegree{
+	academicDegreeCreated := &document.AcademicDegree{
 		PublicID:       uuid.New(),
 		CID:            citizenIdentity.ID,
-		DegreeNumber:   degreeNumber,
 		DegreeType:     request.DegreeType,
 		Major:          request.Major,
 		University:     request.University,
@@ -220,13 +378,25 @@ func (s *DocumentService) CreateAcademicDegree(ctx context.Context, request *dto
 		GPA:            request.GPA,
 		Classification: request.Classification,
 		Status:         constant.DocumentActiveStatus,
+		Revision:       1,
 		IssueDate:      request.IssueDate,
 		HolderDID:      request.HolderDID,
 		IssuerDID:      request.IssuerDID,
+	}
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		degreeNumber, err := s.numberingService.NewDegreeNumber(ctx, academicDegreeCreated)
+		if err != nil {
+			return err
+		}
+		academicDegreeCreated.DegreeNumber = degreeNumber
+		if _, err := s.academicDegreeRepo.CreateAcademicDegree(ctx, academicDegreeCreated); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.AcademicDegree, academicDegreeCreated.ID, academicDegreeCreated.Revision, academicDegreeCreated.HolderDID, constant.DocumentCreatedAction, request.IssuerDID, nil, academicDegreeCreated)
 	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
 	return dto.AcademicDegreeToResponse(academicDegreeCreated), nil
@@ -241,6 +411,11 @@ func (s *DocumentService) UpdateAcademicDegree(ctx context.Context, id string, r
 		return nil, &constant.InternalServer
 	}
 
+	before, err := utils.DocumentSnapshot(academicDegree)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
 	academicDegree.DegreeType = request.DegreeType
 	academicDegree.Major = request.Major
 	academicDegree.University = request.University
@@ -249,13 +424,22 @@ func (s *DocumentService) UpdateAcademicDegree(ctx context.Context, id string, r
 	academicDegree.Classification = request.Classification
 	academicDegree.IssueDate = request.IssueDate
 
-	academicDegreeUpdated, err := s.academicDegreeRepo.SaveAcademicDegree(ctx, academicDegree)
+	academicDegree.Revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.AcademicDegree, academicDegree.ID, academicDegree.Revision); err != nil {
+			return err
+		}
+		if _, err := s.academicDegreeRepo.SaveAcademicDegree(ctx, academicDegree); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.AcademicDegree, academicDegree.ID, academicDegree.Revision, academicDegree.HolderDID, constant.DocumentAmendedAction, request.ChangedBy, before, academicDegree)
+	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
-	return dto.AcademicDegreeToResponse(academicDegreeUpdated), nil
+	return dto.AcademicDegreeToResponse(academicDegree), nil
 }
 
 func (s *DocumentService) RevokeAcademicDegree(ctx context.Context, id string, request *dto.AcademicDegreeOptionRequestDto) error {
@@ -266,8 +450,26 @@ func (s *DocumentService) RevokeAcademicDegree(ctx context.Context, id string, r
 		}
 		return &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status, "revoked_at": time.Now().UTC()}
-	return s.academicDegreeRepo.UpdateAcademicDegree(ctx, academicDegree, changes)
+
+	before, err := utils.DocumentSnapshot(academicDegree)
+	if err != nil {
+		return &constant.InternalServer
+	}
+
+	revokedAt := time.Now().UTC()
+	academicDegree.Status = constant.DocumentStatus(request.Status)
+	academicDegree.RevokedAt = &revokedAt
+	academicDegree.Revision++
+	changes := map[string]interface{}{"status": academicDegree.Status, "revoked_at": revokedAt, "revision": academicDegree.Revision}
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.AcademicDegree, academicDegree.ID, academicDegree.Revision); err != nil {
+			return err
+		}
+		if err := s.academicDegreeRepo.UpdateAcademicDegree(ctx, academicDegree, changes); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.AcademicDegree, academicDegree.ID, academicDegree.Revision, academicDegree.HolderDID, constant.DocumentRevokedAction, request.ChangedBy, before, academicDegree)
+	})
 }
 
 func (s *DocumentService) GetAcademicDegreeByPublicId(ctx context.Context, id string) (*dto.AcademicDegreeResponseDto, error) {
@@ -306,21 +508,22 @@ func (s *DocumentService) GetAcademicDegreeByDegreeNumber(ctx context.Context, d
 	return dto.AcademicDegreeToResponse(academicDegree), nil
 }
 
-func (s *DocumentService) GetAcademicDegrees(ctx context.Context) ([]*dto.AcademicDegreeResponseDto, error) {
-	academicDegrees, err := s.academicDegreeRepo.FindAllAcademicDegrees(ctx)
+func (s *DocumentService) GetAcademicDegrees(ctx context.Context, spec *helper.QuerySpec) ([]*dto.AcademicDegreeResponseDto, *helper.Pagination, error) {
+	academicDegrees, total, err := s.academicDegreeRepo.FindAllAcademicDegrees(ctx, spec)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.AcademicDegreeNotFound
+			return nil, nil, &constant.AcademicDegreeNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resps []*dto.AcademicDegreeResponseDto
+	var lastID uint
 	for _, a := range academicDegrees {
 		resps = append(resps, dto.AcademicDegreeToResponse(a))
+		lastID = a.ID
 	}
-
-	return resps, nil
+	return resps, spec.Pagination(total, len(academicDegrees), lastID), nil
 }
 
 func (s *DocumentService) CreateHealthInsurance(ctx context.Context, request *dto.HealthInsuranceCreatedRequestDto) (*dto.HealthInsuranceResponseDto, error) {
@@ -332,22 +535,32 @@ func (s *DocumentService) CreateHealthInsurance(ctx context.Context, request *dt
 		return nil, err
 	}
 
-	insuranceNumber, _ := utils.GetInsuranceNumber(request.InsuranceType)
-	healthInsuranceCreated, err := s.healthInsuranceRepo.CreateHealthInsurance(ctx, &document.HealthInsurance{
-		PublicID:        uuid.New(),
-		CID:             citizenIdentity.ID,
-		InsuranceNumber: insuranceNumber,
-		InsuranceType:   request.InsuranceType,
-		Hospital:        request.Hospital,
-		Status:          constant.DocumentActiveStatus,
-		StartDate:       request.StartDate,
-		ExpiryDate:      request.ExpiryDate,
-		HolderDID:       request.HolderDID,
-		IssuerDID:       request.IssuerDID,
+	healthInsuranceCreated := &document.HealthInsurance{
+		PublicID:      uuid.New(),
+		CID:           citizenIdentity.ID,
+		InsuranceType: request.InsuranceType,
+		Hospital:      request.Hospital,
+		Status:        constant.DocumentActiveStatus,
+		Revision:      1,
+		StartDate:     request.StartDate,
+		ExpiryDate:    request.ExpiryDate,
+		HolderDID:     request.HolderDID,
+		IssuerDID:     request.IssuerDID,
+	}
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		insuranceNumber, err := s.numberingService.NewInsuranceNumber(ctx, healthInsuranceCreated, citizenIdentity)
+		if err != nil {
+			return err
+		}
+		healthInsuranceCreated.InsuranceNumber = insuranceNumber
+		if _, err := s.healthInsuranceRepo.CreateHealthInsurance(ctx, healthInsuranceCreated); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.HealthInsurance, healthInsuranceCreated.ID, healthInsuranceCreated.Revision, healthInsuranceCreated.HolderDID, constant.DocumentCreatedAction, request.IssuerDID, nil, healthInsuranceCreated)
 	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
 	return dto.HealthInsuranceToResponse(healthInsuranceCreated), nil
@@ -362,18 +575,32 @@ func (s *DocumentService) UpdateHealthInsurance(ctx context.Context, id string,
 		return nil, &constant.InternalServer
 	}
 
+	before, err := utils.DocumentSnapshot(healthInsurance)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
 	healthInsurance.InsuranceType = request.InsuranceType
 	healthInsurance.Hospital = request.Hospital
 	healthInsurance.StartDate = request.StartDate
 	healthInsurance.ExpiryDate = request.ExpiryDate
 
-	healthInsuranceUpdated, err := s.healthInsuranceRepo.SaveHealthInsurance(ctx, healthInsurance)
+	healthInsurance.Revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.HealthInsurance, healthInsurance.ID, healthInsurance.Revision); err != nil {
+			return err
+		}
+		if _, err := s.healthInsuranceRepo.SaveHealthInsurance(ctx, healthInsurance); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.HealthInsurance, healthInsurance.ID, healthInsurance.Revision, healthInsurance.HolderDID, constant.DocumentAmendedAction, request.ChangedBy, before, healthInsurance)
+	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
-	return dto.HealthInsuranceToResponse(healthInsuranceUpdated), nil
+	return dto.HealthInsuranceToResponse(healthInsurance), nil
 }
 
 func (s *DocumentService) RevokeHealthInsurance(ctx context.Context, id string, request *dto.HealthInsuranceOptionRequestDto) error {
@@ -384,8 +611,26 @@ func (s *DocumentService) RevokeHealthInsurance(ctx context.Context, id string,
 		}
 		return &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status, "revoked_at": time.Now().UTC()}
-	return s.healthInsuranceRepo.UpdateHealthInsurance(ctx, healthInsurance, changes)
+
+	before, err := utils.DocumentSnapshot(healthInsurance)
+	if err != nil {
+		return &constant.InternalServer
+	}
+
+	revokedAt := time.Now().UTC()
+	healthInsurance.Status = constant.DocumentStatus(request.Status)
+	healthInsurance.RevokedAt = &revokedAt
+	healthInsurance.Revision++
+	changes := map[string]interface{}{"status": healthInsurance.Status, "revoked_at": revokedAt, "revision": healthInsurance.Revision}
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.HealthInsurance, healthInsurance.ID, healthInsurance.Revision); err != nil {
+			return err
+		}
+		if err := s.healthInsuranceRepo.UpdateHealthInsurance(ctx, healthInsurance, changes); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.HealthInsurance, healthInsurance.ID, healthInsurance.Revision, healthInsurance.HolderDID, constant.DocumentRevokedAction, request.ChangedBy, before, healthInsurance)
+	})
 }
 
 func (s *DocumentService) GetHealthInsuranceByPublicId(ctx context.Context, id string) (*dto.HealthInsuranceResponseDto, error) {
@@ -424,17 +669,19 @@ func (s *DocumentService) GetHealthInsuranceByHolderDID(ctx context.Context, did
 	return dto.HealthInsuranceToResponse(entity), nil
 }
 
-func (s *DocumentService) GetHealthInsurances(ctx context.Context) ([]*dto.HealthInsuranceResponseDto, error) {
-	healthInsurances, err := s.healthInsuranceRepo.FindAllHealthInsurances(ctx)
+func (s *DocumentService) GetHealthInsurances(ctx context.Context, spec *helper.QuerySpec) ([]*dto.HealthInsuranceResponseDto, *helper.Pagination, error) {
+	healthInsurances, total, err := s.healthInsuranceRepo.FindAllHealthInsurances(ctx, spec)
 	if err != nil && errors.Is(err, gorm.ErrRecordNotFound) {
-		return nil, &constant.HealthInsuranceNotFound
+		return nil, nil, &constant.HealthInsuranceNotFound
 	}
 
 	var resps []*dto.HealthInsuranceResponseDto
+	var lastID uint
 	for _, h := range healthInsurances {
 		resps = append(resps, dto.HealthInsuranceToResponse(h))
+		lastID = h.ID
 	}
-	return resps, nil
+	return resps, spec.Pagination(total, len(healthInsurances), lastID), nil
 }
 
 func (s *DocumentService) CreateDriverLicense(ctx context.Context, request *dto.DriverLicenseCreatedRequestDto) (*dto.DriverLicenseResponseDto, error) {
@@ -447,22 +694,32 @@ func (s *DocumentService) CreateDriverLicense(ctx context.Context, request *dto.
 		return nil, err
 	}
 
-	licenseNumber, _ := utils.GetLicenseNumber(request.Class)
-	driverLicenseCreated, err := s.driverLicenseRepo.CreateDriverLicense(ctx, &document.DriverLicense{
-		PublicID:      uuid.New(),
-		CID:           citizenIdentity.ID,
-		LicenseNumber: licenseNumber,
-		Class:         request.Class,
-		Point:         0,
-		Status:        constant.DocumentActiveStatus,
-		IssueDate:     request.IssueDate,
-		ExpiryDate:    request.ExpiryDate,
-		HolderDID:     request.HolderDID,
-		IssuerDID:     request.IssuerDID,
+	driverLicenseCreated := &document.DriverLicense{
+		PublicID:   uuid.New(),
+		CID:        citizenIdentity.ID,
+		Class:      request.Class,
+		Point:      maxLicensePoints,
+		Status:     constant.DocumentActiveStatus,
+		Revision:   1,
+		IssueDate:  request.IssueDate,
+		ExpiryDate: request.ExpiryDate,
+		HolderDID:  request.HolderDID,
+		IssuerDID:  request.IssuerDID,
+	}
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		licenseNumber, err := s.numberingService.NewLicenseNumber(ctx, driverLicenseCreated, citizenIdentity)
+		if err != nil {
+			return err
+		}
+		driverLicenseCreated.LicenseNumber = licenseNumber
+		if _, err := s.driverLicenseRepo.CreateDriverLicense(ctx, driverLicenseCreated); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.DriverLicense, driverLicenseCreated.ID, driverLicenseCreated.Revision, driverLicenseCreated.HolderDID, constant.DocumentCreatedAction, request.IssuerDID, nil, driverLicenseCreated)
 	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
 	return dto.DriverLicenseToResponse(driverLicenseCreated), nil
@@ -477,18 +734,31 @@ func (s *DocumentService) UpdateDriverLicense(ctx context.Context, id string, re
 		}
 		return nil, &constant.InternalServer
 	}
+
+	before, err := utils.DocumentSnapshot(driverLicense)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
 	driverLicense.Class = request.Class
-	driverLicense.Point = request.Point
 	driverLicense.IssueDate = request.IssueDate
 	driverLicense.ExpiryDate = request.ExpiryDate
 
-	driverLicenseUpdated, err := s.driverLicenseRepo.SaveDriverLicense(ctx, driverLicense)
+	driverLicense.Revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision); err != nil {
+			return err
+		}
+		if _, err := s.driverLicenseRepo.SaveDriverLicense(ctx, driverLicense); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision, driverLicense.HolderDID, constant.DocumentAmendedAction, request.ChangedBy, before, driverLicense)
+	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
-	return dto.DriverLicenseToResponse(driverLicenseUpdated), nil
+	return dto.DriverLicenseToResponse(driverLicense), nil
 }
 
 func (s *DocumentService) RevokeDriverLicense(ctx context.Context, id string, request *dto.DriverLicenseOptionRequestDto) error {
@@ -499,8 +769,26 @@ func (s *DocumentService) RevokeDriverLicense(ctx context.Context, id string, re
 		}
 		return &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status, "revoked_at": time.Now().UTC()}
-	return s.driverLicenseRepo.UpdateDriverLicense(ctx, driverLicense, changes)
+
+	before, err := utils.DocumentSnapshot(driverLicense)
+	if err != nil {
+		return &constant.InternalServer
+	}
+
+	revokedAt := time.Now().UTC()
+	driverLicense.Status = constant.DocumentStatus(request.Status)
+	driverLicense.RevokedAt = &revokedAt
+	driverLicense.Revision++
+	changes := map[string]interface{}{"status": driverLicense.Status, "revoked_at": revokedAt, "revision": driverLicense.Revision}
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision); err != nil {
+			return err
+		}
+		if err := s.driverLicenseRepo.UpdateDriverLicense(ctx, driverLicense, changes); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision, driverLicense.HolderDID, constant.DocumentRevokedAction, request.ChangedBy, before, driverLicense)
+	})
 }
 
 func (s *DocumentService) GetDriverLicenseByPublicId(ctx context.Context, id string) (*dto.DriverLicenseResponseDto, error) {
@@ -540,21 +828,23 @@ func (s *DocumentService) GetDriverLicenseByHolderDID(ctx context.Context, did s
 	return dto.DriverLicenseToResponse(entity), nil
 }
 
-func (s *DocumentService) GetDriverLicenses(ctx context.Context) ([]*dto.DriverLicenseResponseDto, error) {
-	driverLicenses, err := s.driverLicenseRepo.FindAllDriverLicenses(ctx)
+func (s *DocumentService) GetDriverLicenses(ctx context.Context, spec *helper.QuerySpec) ([]*dto.DriverLicenseResponseDto, *helper.Pagination, error) {
+	driverLicenses, total, err := s.driverLicenseRepo.FindAllDriverLicenses(ctx, spec)
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.DriverLicenseNotFound
+			return nil, nil, &constant.DriverLicenseNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resps []*dto.DriverLicenseResponseDto
+	var lastID uint
 	for _, d := range driverLicenses {
 		resps = append(resps, dto.DriverLicenseToResponse(d))
+		lastID = d.ID
 	}
-	return resps, nil
+	return resps, spec.Pagination(total, len(driverLicenses), lastID), nil
 }
 
 func (s *DocumentService) CreatePassport(ctx context.Context, request *dto.PassportCreatedRequestDto) (*dto.PassportResponseDto, error) {
@@ -567,23 +857,33 @@ func (s *DocumentService) CreatePassport(ctx context.Context, request *dto.Passp
 		return nil, err
 	}
 
-	passportNumber, _ := utils.GetPassportNumber()
-	passportCreated, err := s.passportRepo.CreatePassport(ctx, &document.Passport{
-		PublicID:       uuid.New(),
-		CID:            citizenIdentity.ID,
-		PassportNumber: passportNumber,
-		PassportType:   request.PassportType,
-		Nationality:    request.Nationality,
-		MRZ:            request.MRZ,
-		Status:         constant.DocumentActiveStatus,
-		IssueDate:      request.IssueDate,
-		ExpiryDate:     request.ExpiryDate,
-		HolderDID:      request.HolderDID,
-		IssuerDID:      request.IssuerDID,
+	passportCreated := &document.Passport{
+		PublicID:     uuid.New(),
+		CID:          citizenIdentity.ID,
+		PassportType: request.PassportType,
+		Nationality:  request.Nationality,
+		Status:       constant.DocumentActiveStatus,
+		Revision:     1,
+		IssueDate:    request.IssueDate,
+		ExpiryDate:   request.ExpiryDate,
+		HolderDID:    request.HolderDID,
+		IssuerDID:    request.IssuerDID,
+	}
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := assignPassportNumber(ctx, s.numberingService, passportCreated, request.MRZ); err != nil {
+			return err
+		}
+		if err := applyPassportMRZ(passportCreated, citizenIdentity, request.MRZ); err != nil {
+			return err
+		}
+		if _, err := s.passportRepo.CreatePassport(ctx, passportCreated); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.Passport, passportCreated.ID, passportCreated.Revision, passportCreated.HolderDID, constant.DocumentCreatedAction, request.IssuerDID, nil, passportCreated)
 	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 	return dto.PassportToResponse(passportCreated), nil
 }
@@ -596,19 +896,39 @@ func (s *DocumentService) UpdatePassport(ctx context.Context, id string, request
 		}
 		return nil, &constant.InternalServer
 	}
+
+	before, err := utils.DocumentSnapshot(passport)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	citizenIdentity, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, passport.HolderDID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
 	passport.PassportType = request.PassportType
 	passport.Nationality = request.Nationality
-	passport.MRZ = request.MRZ
 	passport.IssueDate = request.IssueDate
 	passport.ExpiryDate = request.ExpiryDate
+	if err := applyPassportMRZ(passport, citizenIdentity, request.MRZ); err != nil {
+		return nil, err
+	}
 
-	passportUpdated, err := s.passportRepo.SavePassport(ctx, passport)
+	passport.Revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.Passport, passport.ID, passport.Revision); err != nil {
+			return err
+		}
+		if _, err := s.passportRepo.SavePassport(ctx, passport); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.Passport, passport.ID, passport.Revision, passport.HolderDID, constant.DocumentAmendedAction, request.ChangedBy, before, passport)
+	})
 
 	if err != nil {
-		return nil, &constant.InternalServer
+		return nil, toServiceError(err)
 	}
 
-	return dto.PassportToResponse(passportUpdated), nil
+	return dto.PassportToResponse(passport), nil
 }
 
 func (s *DocumentService) RevokePassport(ctx context.Context, id string, request *dto.PassportOptionRequestDto) error {
@@ -619,8 +939,26 @@ func (s *DocumentService) RevokePassport(ctx context.Context, id string, request
 		}
 		return &constant.InternalServer
 	}
-	changes := map[string]interface{}{"status": request.Status, "revoked_at": time.Now().UTC()}
-	return s.passportRepo.UpdatePassport(ctx, passport, changes)
+
+	before, err := utils.DocumentSnapshot(passport)
+	if err != nil {
+		return &constant.InternalServer
+	}
+
+	revokedAt := time.Now().UTC()
+	passport.Status = constant.DocumentStatus(request.Status)
+	passport.RevokedAt = &revokedAt
+	passport.Revision++
+	changes := map[string]interface{}{"status": passport.Status, "revoked_at": revokedAt, "revision": passport.Revision}
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.Passport, passport.ID, passport.Revision); err != nil {
+			return err
+		}
+		if err := s.passportRepo.UpdatePassport(ctx, passport, changes); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.Passport, passport.ID, passport.Revision, passport.HolderDID, constant.DocumentRevokedAction, request.ChangedBy, before, passport)
+	})
 }
 
 func (s *DocumentService) GetPassportByPublicId(ctx context.Context, id string) (*dto.PassportResponseDto, error) {
@@ -658,18 +996,724 @@ func (s *DocumentService) GetPassportByHolderDID(ctx context.Context, did string
 	return dto.PassportToResponse(entity), nil
 }
 
-func (s *DocumentService) GetPassports(ctx context.Context) ([]*dto.PassportResponseDto, error) {
-	passports, err := s.passportRepo.FindAllPassports(ctx)
+func (s *DocumentService) GetPassports(ctx context.Context, spec *helper.QuerySpec) ([]*dto.PassportResponseDto, *helper.Pagination, error) {
+	passports, total, err := s.passportRepo.FindAllPassports(ctx, spec)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.PassportNotFound
+			return nil, nil, &constant.PassportNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resps []*dto.PassportResponseDto
+	var lastID uint
 	for _, p := range passports {
 		resps = append(resps, dto.PassportToResponse(p))
+		lastID = p.ID
+	}
+	return resps, spec.Pagination(total, len(passports), lastID), nil
+}
+
+// RenewDriverLicense issues a new licence number that supersedes the given licence. Points carry over.
+func (s *DocumentService) RenewDriverLicense(ctx context.Context, id string, request *dto.DriverLicenseRenewedRequestDto) (*dto.DriverLicenseResponseDto, error) {
+	driverLicense, err := s.driverLicenseRepo.FindDriverLicenseByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.DriverLicenseNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if err := checkDocumentRenewable(driverLicense.Status); err != nil {
+		return nil, err
+	}
+
+	before, err := utils.DocumentSnapshot(driverLicense)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	citizenIdentity, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, driverLicense.HolderDID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	class := request.Class
+	if class == "" {
+		class = driverLicense.Class
+	}
+	renewed := &document.DriverLicense{
+		PublicID:   uuid.New(),
+		CID:        driverLicense.CID,
+		Class:      class,
+		Point:      driverLicense.Point,
+		Status:     constant.DocumentActiveStatus,
+		Revision:   1,
+		IssueDate:  request.IssueDate,
+		ExpiryDate: request.ExpiryDate,
+		HolderDID:  driverLicense.HolderDID,
+		IssuerDID:  request.IssuerDID,
+	}
+
+	driverLicense.Status = constant.DocumentSupersededStatus
+	driverLicense.SupersededBy = &renewed.PublicID
+	driverLicense.Revision++
+	changes := map[string]interface{}{"status": driverLicense.Status, "superseded_by": renewed.PublicID, "revision": driverLicense.Revision}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision); err != nil {
+			return err
+		}
+		licenseNumber, err := s.numberingService.NewLicenseNumber(ctx, renewed, citizenIdentity)
+		if err != nil {
+			return err
+		}
+		renewed.LicenseNumber = licenseNumber
+		if err := s.driverLicenseRepo.UpdateDriverLicense(ctx, driverLicense, changes); err != nil {
+			return err
+		}
+		if err := s.revisions.record(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision, driverLicense.HolderDID, constant.DocumentSupersededAction, request.IssuerDID, before, driverLicense); err != nil {
+			return err
+		}
+		if _, err := s.driverLicenseRepo.CreateDriverLicense(ctx, renewed); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.DriverLicense, renewed.ID, renewed.Revision, renewed.HolderDID, constant.DocumentRenewedAction, request.IssuerDID, before, renewed)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return dto.DriverLicenseToResponse(renewed), nil
+}
+
+// RenewPassport issues a new passport number that supersedes the given passport.
+func (s *DocumentService) RenewPassport(ctx context.Context, id string, request *dto.PassportRenewedRequestDto) (*dto.PassportResponseDto, error) {
+	passport, err := s.passportRepo.FindPassportByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.PassportNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if err := checkDocumentRenewable(passport.Status); err != nil {
+		return nil, err
+	}
+
+	before, err := utils.DocumentSnapshot(passport)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	citizenIdentity, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, passport.HolderDID)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	passportType := request.PassportType
+	if passportType == "" {
+		passportType = passport.PassportType
+	}
+
+	renewed := &document.Passport{
+		PublicID:     uuid.New(),
+		CID:          passport.CID,
+		PassportType: passportType,
+		Nationality:  passport.Nationality,
+		Status:       constant.DocumentActiveStatus,
+		Revision:     1,
+		IssueDate:    request.IssueDate,
+		ExpiryDate:   request.ExpiryDate,
+		HolderDID:    passport.HolderDID,
+		IssuerDID:    request.IssuerDID,
+	}
+
+	passport.Status = constant.DocumentSupersededStatus
+	passport.SupersededBy = &renewed.PublicID
+	passport.Revision++
+	changes := map[string]interface{}{"status": passport.Status, "superseded_by": renewed.PublicID, "revision": passport.Revision}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, constant.Passport, passport.ID, passport.Revision); err != nil {
+			return err
+		}
+		if err := assignPassportNumber(ctx, s.numberingService, renewed, request.MRZ); err != nil {
+			return err
+		}
+		if err := applyPassportMRZ(renewed, citizenIdentity, request.MRZ); err != nil {
+			return err
+		}
+		if err := s.passportRepo.UpdatePassport(ctx, passport, changes); err != nil {
+			return err
+		}
+		if err := s.revisions.record(ctx, constant.Passport, passport.ID, passport.Revision, passport.HolderDID, constant.DocumentSupersededAction, request.IssuerDID, before, passport); err != nil {
+			return err
+		}
+		if _, err := s.passportRepo.CreatePassport(ctx, renewed); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, constant.Passport, renewed.ID, renewed.Revision, renewed.HolderDID, constant.DocumentRenewedAction, request.IssuerDID, before, renewed)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	return dto.PassportToResponse(renewed), nil
+}
+
+func (s *DocumentService) GetDocumentRevisions(ctx context.Context, documentType constant.DocumentType, id string, spec *helper.QuerySpec) ([]*dto.DocumentRevisionResponseDto, *helper.Pagination, error) {
+	documentID, err := s.findDocumentID(ctx, documentType, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revisions, total, err := s.revisionRepo.FindAllDocumentRevisions(ctx, documentType, documentID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resps := make([]*dto.DocumentRevisionResponseDto, 0, len(revisions))
+	var lastID uint
+	for _, revision := range revisions {
+		resps = append(resps, dto.DocumentRevisionToResponse(revision))
+		lastID = revision.ID
+	}
+	return resps, spec.Pagination(total, len(revisions), lastID), nil
+}
+
+// GetHolderDocuments returns the current documents held by the DID. Document types the holder has no document of
+// are left empty.
+func (s *DocumentService) GetHolderDocuments(ctx context.Context, holderDID string) (*dto.HolderDocumentsResponseDto, error) {
+	resp := &dto.HolderDocumentsResponseDto{}
+
+	citizen, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, holderDID)
+	switch {
+	case err == nil:
+		resp.CitizenIdentity = dto.CitizenIdentityToResponse(citizen)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, &constant.InternalServer
+	}
+
+	degree, err := s.academicDegreeRepo.FindAcademicDegreeByHolderDID(ctx, holderDID)
+	switch {
+	case err == nil:
+		resp.AcademicDegree = dto.AcademicDegreeToResponse(degree)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, &constant.InternalServer
+	}
+
+	insurance, err := s.healthInsuranceRepo.FindHealthInsuranceByHolderDID(ctx, holderDID)
+	switch {
+	case err == nil:
+		resp.HealthInsurance = dto.HealthInsuranceToResponse(insurance)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, &constant.InternalServer
+	}
+
+	license, err := s.driverLicenseRepo.FindDriverLicenseByHolderDID(ctx, holderDID)
+	switch {
+	case err == nil:
+		resp.DriverLicense = dto.DriverLicenseToResponse(license)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, &constant.InternalServer
+	}
+
+	passport, err := s.passportRepo.FindPassportByHolderDID(ctx, holderDID)
+	switch {
+	case err == nil:
+		resp.Passport = dto.PassportToResponse(passport)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, &constant.InternalServer
+	}
+
+	return resp, nil
+}
+
+// GetHolderDocumentSnapshot returns the holder's current document of the given type as stored in a revision snapshot.
+func (s *DocumentService) GetHolderDocumentSnapshot(ctx context.Context, documentType constant.DocumentType, holderDID string) (map[string]interface{}, error) {
+	var (
+		entity   interface{}
+		notFound *constant.Errors
+		err      error
+	)
+	switch documentType {
+	case constant.CitizenIdentity:
+		notFound = &constant.CitizenIdentityNotFound
+		entity, err = s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, holderDID)
+	case constant.AcademicDegree:
+		notFound = &constant.AcademicDegreeNotFound
+		entity, err = s.academicDegreeRepo.FindAcademicDegreeByHolderDID(ctx, holderDID)
+	case constant.HealthInsurance:
+		notFound = &constant.HealthInsuranceNotFound
+		entity, err = s.healthInsuranceRepo.FindHealthInsuranceByHolderDID(ctx, holderDID)
+	case constant.DriverLicense:
+		notFound = &constant.DriverLicenseNotFound
+		entity, err = s.driverLicenseRepo.FindDriverLicenseByHolderDID(ctx, holderDID)
+	case constant.Passport:
+		notFound = &constant.PassportNotFound
+		entity, err = s.passportRepo.FindPassportByHolderDID(ctx, holderDID)
+	default:
+		return nil, &constant.BadRequest
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, notFound
+		}
+		return nil, &constant.InternalServer
+	}
+	snapshot, err := utils.DocumentSnapshot(entity)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	return snapshot, nil
+}
+
+// ApplyDocumentCorrection sets one field of a document to a corrected value, parsed and validated the way an
+// imported value is, and records the change as an amended revision. A corrected passport gets a new MRZ, and so
+// does the holder's passport when their citizen identity is corrected.
+func (s *DocumentService) ApplyDocumentCorrection(ctx context.Context, documentType constant.DocumentType, id string, field string, value string, changedBy string) error {
+	var (
+		entity     interface{}
+		documentID uint
+		revision   *int
+		holderDID  string
+		status     constant.DocumentStatus
+		save       func(ctx context.Context) error
+		notFound   *constant.Errors
+		err        error
+	)
+	switch documentType {
+	case constant.CitizenIdentity:
+		notFound = &constant.CitizenIdentityNotFound
+		var citizen *document.CitizenIdentity
+		if citizen, err = s.citizenIdentityRepo.FindCitizenIdentityByPublicId(ctx, id); err == nil {
+			entity, documentID, revision, holderDID, status = citizen, citizen.ID, &citizen.Revision, citizen.HolderDID, citizen.Status
+			save = func(ctx context.Context) error {
+				if _, err := s.citizenIdentityRepo.SaveCitizenIdentity(ctx, citizen); err != nil {
+					return err
+				}
+				return s.regeneratePassportMRZ(ctx, citizen, changedBy)
+			}
+		}
+	case constant.AcademicDegree:
+		notFound = &constant.AcademicDegreeNotFound
+		var degree *document.AcademicDegree
+		if degree, err = s.academicDegreeRepo.FindAcademicDegreeByPublicId(ctx, id); err == nil {
+			entity, documentID, revision, holderDID, status = degree, degree.ID, &degree.Revision, degree.HolderDID, degree.Status
+			save = func(ctx context.Context) error {
+				_, err := s.academicDegreeRepo.SaveAcademicDegree(ctx, degree)
+				return err
+			}
+		}
+	case constant.HealthInsurance:
+		notFound = &constant.HealthInsuranceNotFound
+		var insurance *document.HealthInsurance
+		if insurance, err = s.healthInsuranceRepo.FindHealthInsuranceByPublicId(ctx, id); err == nil {
+			entity, documentID, revision, holderDID, status = insurance, insurance.ID, &insurance.Revision, insurance.HolderDID, insurance.Status
+			save = func(ctx context.Context) error {
+				_, err := s.healthInsuranceRepo.SaveHealthInsurance(ctx, insurance)
+				return err
+			}
+		}
+	case constant.DriverLicense:
+		notFound = &constant.DriverLicenseNotFound
+		var license *document.DriverLicense
+		if license, err = s.driverLicenseRepo.FindDriverLicenseByPublicId(ctx, id); err == nil {
+			entity, documentID, revision, holderDID, status = license, license.ID, &license.Revision, license.HolderDID, license.Status
+			save = func(ctx context.Context) error {
+				_, err := s.driverLicenseRepo.SaveDriverLicense(ctx, license)
+				return err
+			}
+		}
+	case constant.Passport:
+		notFound = &constant.PassportNotFound
+		var passport *document.Passport
+		if passport, err = s.passportRepo.FindPassportByPublicId(ctx, id); err == nil {
+			entity, documentID, revision, holderDID, status = passport, passport.ID, &passport.Revision, passport.HolderDID, passport.Status
+			save = func(ctx context.Context) error {
+				citizen, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, passport.HolderDID)
+				if err != nil {
+					return err
+				}
+				if err := applyPassportMRZ(passport, citizen, ""); err != nil {
+					return err
+				}
+				_, err = s.passportRepo.SavePassport(ctx, passport)
+				return err
+			}
+		}
+	default:
+		return &constant.BadRequest
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return notFound
+		}
+		return &constant.InternalServer
+	}
+	if err := checkDocumentRenewable(status); err != nil {
+		return err
+	}
+
+	before, err := utils.DocumentSnapshot(entity)
+	if err != nil {
+		return &constant.InternalServer
+	}
+	if !isCorrectableField(before, field) {
+		return &constant.CorrectionFieldInvalid
+	}
+	if _, err := utils.AssignRecord(entity, map[string]string{field: value}); err != nil {
+		return fmt.Errorf("%w: %v", &constant.CorrectionFieldInvalid, err)
+	}
+	if err := s.validate.Struct(entity); err != nil {
+		return fmt.Errorf("%w: %v", &constant.CorrectionFieldInvalid, err)
+	}
+	// the id number encodes the place of birth, gender and birth year, so a correction must still agree with it
+	if citizen, ok := entity.(*document.CitizenIdentity); ok {
+		if err := s.numberingService.ValidateIdNumber(citizen, citizen.IDNumber); err != nil {
+			return fmt.Errorf("%w: %s does not match the id number", &constant.CorrectionFieldInvalid, field)
+		}
+	}
+
+	*revision++
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.revisions.lock(ctx, documentType, documentID, *revision); err != nil {
+			return err
+		}
+		if err := save(ctx); err != nil {
+			return err
+		}
+		return s.revisions.record(ctx, documentType, documentID, *revision, holderDID, constant.DocumentAmendedAction, changedBy, before, entity)
+	})
+	if err != nil {
+		return toServiceError(err)
+	}
+	return nil
+}
+
+// regeneratePassportMRZ rebuilds the MRZ of the holder's passport in use after their citizen identity changed and
+// records a changed MRZ as an amended revision of the passport. Holders without such a passport are left alone.
+func (s *DocumentService) regeneratePassportMRZ(ctx context.Context, citizen *document.CitizenIdentity, changedBy string) error {
+	passport, err := s.passportRepo.FindPassportByHolderDID(ctx, citizen.HolderDID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if checkDocumentRenewable(passport.Status) != nil {
+		return nil
+	}
+
+	before, err := utils.DocumentSnapshot(passport)
+	if err != nil {
+		return err
+	}
+	mrz := passport.MRZ
+	if err := applyPassportMRZ(passport, citizen, ""); err != nil {
+		return err
+	}
+	if passport.MRZ == mrz {
+		return nil
+	}
+	passport.Revision++
+	if err := s.revisions.lock(ctx, constant.Passport, passport.ID, passport.Revision); err != nil {
+		return err
+	}
+	if _, err := s.passportRepo.SavePassport(ctx, passport); err != nil {
+		return err
+	}
+	return s.revisions.record(ctx, constant.Passport, passport.ID, passport.Revision, passport.HolderDID, constant.DocumentAmendedAction, changedBy, before, passport)
+}
+
+func (s *DocumentService) findDocumentID(ctx context.Context, documentType constant.DocumentType, id string) (uint, error) {
+	var (
+		documentID uint
+		notFound   *constant.Errors
+		err        error
+	)
+	switch documentType {
+	case constant.CitizenIdentity:
+		notFound = &constant.CitizenIdentityNotFound
+		var entity *document.CitizenIdentity
+		if entity, err = s.citizenIdentityRepo.FindCitizenIdentityByPublicId(ctx, id); err == nil {
+			documentID = entity.ID
+		}
+	case constant.AcademicDegree:
+		notFound = &constant.AcademicDegreeNotFound
+		var entity *document.AcademicDegree
+		if entity, err = s.academicDegreeRepo.FindAcademicDegreeByPublicId(ctx, id); err == nil {
+			documentID = entity.ID
+		}
+	case constant.HealthInsurance:
+		notFound = &constant.HealthInsuranceNotFound
+		var entity *document.HealthInsurance
+		if entity, err = s.healthInsuranceRepo.FindHealthInsuranceByPublicId(ctx, id); err == nil {
+			documentID = entity.ID
+		}
+	case constant.DriverLicense:
+		notFound = &constant.DriverLicenseNotFound
+		var entity *document.DriverLicense
+		if entity, err = s.driverLicenseRepo.FindDriverLicenseByPublicId(ctx, id); err == nil {
+			documentID = entity.ID
+		}
+	case constant.Passport:
+		notFound = &constant.PassportNotFound
+		var entity *document.Passport
+		if entity, err = s.passportRepo.FindPassportByPublicId(ctx, id); err == nil {
+			documentID = entity.ID
+		}
+	default:
+		return 0, &constant.BadRequest
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, notFound
+		}
+		return 0, &constant.InternalServer
+	}
+	return documentID, nil
+}
+
+// BuildCredentialSubject fills the mapped schema attributes from the holder's stored document.
+// Fields of the holder's citizen identity can be referenced as "citizen.<column>".
+func (s *DocumentService) BuildCredentialSubject(ctx context.Context, schemaEntity *schema.Schema, holderDID string) (map[string]interface{}, error) {
+	fields, err := s.getDocumentFields(ctx, schemaEntity.DocumentType, holderDID)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := make(map[string]interface{})
+	for _, attribute := range schemaEntity.SchemaAttributes {
+		if attribute.DocumentField == "" {
+			continue
+		}
+		value, ok := lookupDocumentField(fields, attribute.DocumentField)
+		if !ok {
+			return nil, &constant.SchemaMappingInvalid
+		}
+		transformed, err := utils.TransformDocumentValue(value, attribute.Transform, attribute.EnumCodes)
+		if err != nil {
+			return nil, &constant.SchemaMappingInvalid
+		}
+		subject[attribute.Name] = transformed
+	}
+	return subject, nil
+}
+
+func (s *DocumentService) getDocumentFields(ctx context.Context, documentType constant.DocumentType, holderDID string) (map[string]interface{}, error) {
+	citizen, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, holderDID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.CitizenIdentityNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+
+	var (
+		entity     interface{}
+		status     constant.DocumentStatus
+		expiryDate int64
+	)
+	switch documentType {
+	case constant.CitizenIdentity:
+		entity, status, expiryDate = citizen, citizen.Status, citizen.ExpiryDate
+	case constant.AcademicDegree:
+		degree, err := s.academicDegreeRepo.FindAcademicDegreeByHolderDID(ctx, holderDID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &constant.AcademicDegreeNotFound
+			}
+			return nil, &constant.InternalServer
+		}
+		entity, status = degree, degree.Status
+	case constant.HealthInsurance:
+		insurance, err := s.healthInsuranceRepo.FindHealthInsuranceByHolderDID(ctx, holderDID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &constant.HealthInsuranceNotFound
+			}
+			return nil, &constant.InternalServer
+		}
+		entity, status, expiryDate = insurance, insurance.Status, insurance.ExpiryDate
+	case constant.DriverLicense:
+		license, err := s.driverLicenseRepo.FindDriverLicenseByHolderDID(ctx, holderDID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &constant.DriverLicenseNotFound
+			}
+			return nil, &constant.InternalServer
+		}
+		entity, status, expiryDate = license, license.Status, license.ExpiryDate
+	case constant.Passport:
+		passport, err := s.passportRepo.FindPassportByHolderDID(ctx, holderDID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, &constant.PassportNotFound
+			}
+			return nil, &constant.InternalServer
+		}
+		entity, status, expiryDate = passport, passport.Status, passport.ExpiryDate
+	default:
+		return nil, &constant.SchemaMappingInvalid
+	}
+
+	if err := checkDocumentIssuable(status, expiryDate); err != nil {
+		return nil, err
+	}
+
+	fields, err := utils.DocumentToMap(entity)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if documentType != constant.CitizenIdentity {
+		citizenFields, err := utils.DocumentToMap(citizen)
+		if err != nil {
+			return nil, &constant.InternalServer
+		}
+		fields["citizen"] = citizenFields
+	}
+	return fields, nil
+}
+
+func checkDocumentIssuable(status constant.DocumentStatus, expiryDate int64) error {
+	switch {
+	case status == constant.DocumentRevokeStatus:
+		return &constant.DocumentRevoked
+	case status == constant.DocumentExpiredStatus:
+		return &constant.DocumentExpired
+	case status == constant.DocumentSupersededStatus:
+		return &constant.DocumentSuperseded
+	case status == constant.DocumentSuspendedStatus:
+		return &constant.DocumentSuspended
+	case expiryDate > 0 && expiryDate < time.Now().Unix():
+		return &constant.DocumentExpired
+	}
+	return nil
+}
+
+func checkDocumentRenewable(status constant.DocumentStatus) error {
+	switch status {
+	case constant.DocumentRevokeStatus:
+		return &constant.DocumentRevoked
+	case constant.DocumentSupersededStatus:
+		return &constant.DocumentSuperseded
+	case constant.DocumentSuspendedStatus:
+		return &constant.DocumentSuspended
+	}
+	return nil
+}
+
+// assignPassportNumber gives a new passport the document number of its supplied MRZ, for passports already
+// printed, or a newly reserved number when no MRZ is supplied.
+func assignPassportNumber(ctx context.Context, numberingService INumberingService, passport *document.Passport, mrz string) error {
+	if mrz == "" {
+		passportNumber, err := numberingService.NewPassportNumber(ctx, passport)
+		if err != nil {
+			return err
+		}
+		passport.PassportNumber = passportNumber
+		return nil
+	}
+
+	parsed, err := utils.ParsePassportMRZ(mrz)
+	if err != nil {
+		return fmt.Errorf("%w: %v", &constant.PassportMRZInvalid, err)
+	}
+	if err := numberingService.ReserveNumber(ctx, constant.Passport, parsed.DocumentNumber); err != nil {
+		return err
+	}
+	passport.PassportNumber = parsed.DocumentNumber
+	return nil
+}
+
+// applyPassportMRZ cross-checks a supplied MRZ against the passport and its citizen identity, or generates
+// the MRZ from them when none is supplied, and stores it on the passport.
+func applyPassportMRZ(passport *document.Passport, citizen *document.CitizenIdentity, mrz string) error {
+	expected := expectedPassportMRZ(passport, citizen)
+	if mrz == "" {
+		generated, err := utils.FormatPassportMRZ(expected)
+		if err != nil {
+			return fmt.Errorf("%w: %v", &constant.PassportMRZInvalid, err)
+		}
+		passport.MRZ = generated
+		return nil
+	}
+
+	parsed, err := utils.ParsePassportMRZ(mrz)
+	if err != nil {
+		return fmt.Errorf("%w: %v", &constant.PassportMRZInvalid, err)
+	}
+	if field := utils.ComparePassportMRZ(parsed, expected); field != "" {
+		return fmt.Errorf("%w: %s differs", &constant.PassportMRZMismatch, field)
+	}
+	// store the canonical two line form whatever layout the MRZ was supplied in
+	passport.MRZ, err = utils.FormatPassportMRZ(parsed)
+	return err
+}
+
+func expectedPassportMRZ(passport *document.Passport, citizen *document.CitizenIdentity) *utils.PassportMRZ {
+	documentCode := "P<"
+	switch passport.PassportType {
+	case constant.PassportDiplomaticType:
+		documentCode = "PD"
+	case constant.PassportOfficialType:
+		documentCode = "PO"
+	}
+	sex := "<"
+	switch citizen.Gender {
+	case constant.MaleGender:
+		sex = "M"
+	case constant.FemaleGender:
+		sex = "F"
+	}
+	return &utils.PassportMRZ{
+		DocumentCode:   documentCode,
+		IssuingState:   passport.Nationality,
+		Surname:        citizen.LastName,
+		GivenNames:     citizen.FirstName,
+		DocumentNumber: passport.PassportNumber,
+		Nationality:    passport.Nationality,
+		DateOfBirth:    utils.MRZDate(citizen.DateOfBirth),
+		Sex:            sex,
+		ExpiryDate:     utils.MRZDate(passport.ExpiryDate),
+		PersonalNumber: citizen.IDNumber,
+	}
+}
+
+// correctionProtectedColumns cannot be corrected on a holder's request: the columns an import may not set, the
+// citizen identity number and the MRZ, which is regenerated from the other fields.
+var correctionProtectedColumns = append([]string{"id_number", "mrz"}, importProtectedColumns...)
+
+// isCorrectableField reports whether field is a column of the document snapshot that a holder may ask to correct.
+func isCorrectableField(snapshot map[string]interface{}, field string) bool {
+	if _, ok := snapshot[field]; !ok {
+		return false
+	}
+	return !slices.Contains(correctionProtectedColumns, field)
+}
+
+// toServiceError keeps application errors raised inside a transaction and hides everything else.
+func toServiceError(err error) error {
+	var appErr *constant.Errors
+	if errors.As(err, &appErr) {
+		return err
+	}
+	return &constant.InternalServer
+}
+
+func lookupDocumentField(fields map[string]interface{}, path string) (interface{}, bool) {
+	parent, field, nested := strings.Cut(path, ".")
+	value, ok := fields[parent]
+	if !ok || !nested {
+		return value, ok
+	}
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
 	}
-	return resps, nil
+	value, ok = child[field]
+	return value, ok
 }
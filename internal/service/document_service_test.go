@@ -0,0 +1,351 @@
+package service
+
+import (
+	"be/internal/domain/document"
+	"be/internal/domain/schema"
+	"be/internal/shared/constant"
+	"be/internal/shared/utils"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeCitizenIdentityRepository struct {
+	document.ICitizenIdentityRepository
+	citizens []*document.CitizenIdentity
+}
+
+func (r *fakeCitizenIdentityRepository) FindCitizenIdentityByHolderDID(_ context.Context, holderDID string) (*document.CitizenIdentity, error) {
+	for _, citizen := range r.citizens {
+		if citizen.HolderDID == holderDID {
+			return citizen, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCitizenIdentityRepository) FindCitizenIdentityByPublicId(_ context.Context, publicId string) (*document.CitizenIdentity, error) {
+	for _, citizen := range r.citizens {
+		if citizen.PublicID.String() == publicId {
+			return citizen, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCitizenIdentityRepository) SaveCitizenIdentity(_ context.Context, entity *document.CitizenIdentity) (*document.CitizenIdentity, error) {
+	return entity, nil
+}
+
+type fakePassportRepository struct {
+	document.IPassportRepository
+	passports []*document.Passport
+	saved     int
+}
+
+func (r *fakePassportRepository) FindPassportByHolderDID(_ context.Context, holderDID string) (*document.Passport, error) {
+	for _, passport := range r.passports {
+		if passport.HolderDID == holderDID && passport.SupersededBy == nil {
+			return passport, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakePassportRepository) SavePassport(_ context.Context, entity *document.Passport) (*document.Passport, error) {
+	r.saved++
+	return entity, nil
+}
+
+type fakeDocumentNumberRepository struct {
+	document.IDocumentNumberRepository
+}
+
+func (fakeDocumentNumberRepository) ReserveDocumentNumber(context.Context, constant.DocumentType, string) (bool, error) {
+	return true, nil
+}
+
+func TestBuildCredentialSubject(t *testing.T) {
+	unix := func(year int, month time.Month, day int) int64 {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+	}
+	citizen := &document.CitizenIdentity{
+		HolderDID:   testHolderDID,
+		IDNumber:    "001096000001",
+		FirstName:   "An",
+		Gender:      constant.FemaleGender,
+		DateOfBirth: unix(1996, time.January, 31),
+		Status:      constant.DocumentActiveStatus,
+		ExpiryDate:  unix(2100, time.January, 1),
+	}
+	license := &document.DriverLicense{ID: 1, HolderDID: testHolderDID, Class: "B2", Point: 9, Status: constant.DocumentActiveStatus, ExpiryDate: unix(2100, time.January, 1)}
+	genderCodes := map[string]interface{}{"male": float64(1), "female": float64(2)}
+
+	tests := []struct {
+		name         string
+		documentType constant.DocumentType
+		attributes   []*schema.SchemaAttribute
+		licenseState constant.DocumentStatus
+		want         map[string]interface{}
+		wantError    error
+	}{
+		{name: "citizen identity", documentType: constant.CitizenIdentity, attributes: []*schema.SchemaAttribute{
+			{Name: "idNumber", DocumentField: "id_number", Transform: constant.MappingTransformNone},
+			{Name: "birthday", DocumentField: "date_of_birth", Transform: constant.MappingTransformDate},
+			{Name: "gender", DocumentField: "gender", Transform: constant.MappingTransformEnum, EnumCodes: genderCodes},
+			{Name: "unmapped"},
+		}, want: map[string]interface{}{"idNumber": "001096000001", "birthday": int64(19960131), "gender": int64(2)}},
+		{name: "driver licence with citizen fields", documentType: constant.DriverLicense, attributes: []*schema.SchemaAttribute{
+			{Name: "points", DocumentField: "point", Transform: constant.MappingTransformNone},
+			{Name: "birthday", DocumentField: "citizen.date_of_birth", Transform: constant.MappingTransformDate},
+		}, want: map[string]interface{}{"points": int64(9), "birthday": int64(19960131)}},
+		{name: "suspended driver licence", documentType: constant.DriverLicense, licenseState: constant.DocumentSuspendedStatus, attributes: []*schema.SchemaAttribute{
+			{Name: "points", DocumentField: "point"},
+		}, wantError: &constant.DocumentSuspended},
+		{name: "missing field", documentType: constant.CitizenIdentity, attributes: []*schema.SchemaAttribute{
+			{Name: "nickname", DocumentField: "nickname"},
+		}, wantError: &constant.SchemaMappingInvalid},
+		{name: "value without enum code", documentType: constant.CitizenIdentity, attributes: []*schema.SchemaAttribute{
+			{Name: "gender", DocumentField: "gender", Transform: constant.MappingTransformEnum, EnumCodes: map[string]interface{}{"male": 1}},
+		}, wantError: &constant.SchemaMappingInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holderLicense := *license
+			if tt.licenseState != "" {
+				holderLicense.Status = tt.licenseState
+			}
+			s := &DocumentService{
+				citizenIdentityRepo: &fakeCitizenIdentityRepository{citizens: []*document.CitizenIdentity{citizen}},
+				driverLicenseRepo:   &fakeDriverLicenseRepository{licenses: map[uint]*document.DriverLicense{1: &holderLicense}},
+			}
+
+			got, err := s.BuildCredentialSubject(context.Background(), &schema.Schema{DocumentType: tt.documentType, SchemaAttributes: tt.attributes}, testHolderDID)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("BuildCredentialSubject() error = %v, want %v", err, tt.wantError)
+			}
+			if tt.wantError == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("BuildCredentialSubject() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCitizenIdentityCorrection(t *testing.T) {
+	dateOfBirth := time.Date(1996, time.January, 31, 0, 0, 0, 0, time.UTC).Unix()
+	prefix, err := utils.IdNumberPrefix("Ha Noi", constant.FemaleGender, dateOfBirth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newCitizen := func() *document.CitizenIdentity {
+		return &document.CitizenIdentity{
+			ID:           1,
+			PublicID:     uuid.New(),
+			IDNumber:     prefix + "000001",
+			FirstName:    "An",
+			LastName:     "Nguyen",
+			Gender:       constant.FemaleGender,
+			DateOfBirth:  dateOfBirth,
+			PlaceOfBirth: "Ha Noi",
+			Status:       constant.DocumentActiveStatus,
+			Revision:     1,
+			IssueDate:    dateOfBirth,
+			ExpiryDate:   time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC).Unix(),
+			HolderDID:    testHolderDID,
+			IssuerDID:    testIssuerDID,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		field        string
+		value        string
+		noPassport   bool
+		wantError    error
+		wantMRZ      string
+		wantPassport bool
+	}{
+		{name: "name", field: "last_name", value: "Tran", wantMRZ: "TRAN<<AN", wantPassport: true},
+		{name: "birth day in the same year", field: "date_of_birth", value: "1996-02-01", wantMRZ: "960201", wantPassport: true},
+		{name: "field outside the mrz", field: "place_of_birth", value: "Thanh pho Ha Noi"},
+		{name: "holder without a passport", field: "last_name", value: "Tran", noPassport: true},
+		{name: "birth year", field: "date_of_birth", value: "1997-01-31", wantError: &constant.CorrectionFieldInvalid},
+		{name: "gender", field: "gender", value: "male", wantError: &constant.CorrectionFieldInvalid},
+		{name: "province of birth", field: "place_of_birth", value: "Cao Bang", wantError: &constant.CorrectionFieldInvalid},
+		{name: "id number", field: "id_number", value: prefix + "000002", wantError: &constant.CorrectionFieldInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			citizen := newCitizen()
+			passport := &document.Passport{
+				ID:             2,
+				PassportNumber: "B1234567",
+				PassportType:   constant.PassportOrdinaryType,
+				Nationality:    "VNM",
+				Status:         constant.DocumentActiveStatus,
+				Revision:       1,
+				ExpiryDate:     citizen.ExpiryDate,
+				HolderDID:      testHolderDID,
+			}
+			if err := applyPassportMRZ(passport, citizen, ""); err != nil {
+				t.Fatal(err)
+			}
+			mrz := passport.MRZ
+			passportRepo := &fakePassportRepository{passports: []*document.Passport{passport}}
+			if tt.noPassport {
+				passportRepo.passports = nil
+			}
+			revisionRepo := &fakeRevisionRepository{}
+			s := &DocumentService{
+				db:                  newTestDB(t),
+				validate:            newDocumentValidator(),
+				citizenIdentityRepo: &fakeCitizenIdentityRepository{citizens: []*document.CitizenIdentity{citizen}},
+				passportRepo:        passportRepo,
+				revisions:           &documentRevisions{revisionRepo: revisionRepo, vcRepo: &fakeVerifiableCredentialRepository{}},
+				numberingService:    &NumberingService{},
+			}
+
+			err := s.ApplyDocumentCorrection(context.Background(), constant.CitizenIdentity, citizen.PublicID.String(), tt.field, tt.value, testIssuerDID)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ApplyDocumentCorrection() error = %v, want %v", err, tt.wantError)
+			}
+			if tt.wantError != nil {
+				if len(revisionRepo.revisions) != 0 || passportRepo.saved != 0 {
+					t.Fatalf("a rejected correction recorded %d revisions and saved %d passports", len(revisionRepo.revisions), passportRepo.saved)
+				}
+				return
+			}
+
+			wantRevisions := 1
+			if tt.wantPassport {
+				wantRevisions = 2
+				if passportRepo.saved != 1 || passport.Revision != 2 || !strings.Contains(passport.MRZ, tt.wantMRZ) {
+					t.Fatalf("passport saved %d times at revision %d with MRZ %q, want %q in a new revision", passportRepo.saved, passport.Revision, passport.MRZ, tt.wantMRZ)
+				}
+			} else if passportRepo.saved != 0 || passport.MRZ != mrz {
+				t.Fatalf("passport saved %d times with MRZ %q, want it untouched", passportRepo.saved, passport.MRZ)
+			}
+			if len(revisionRepo.revisions) != wantRevisions || citizen.Revision != 2 {
+				t.Fatalf("%d revisions recorded and citizen at revision %d, want %d and 2", len(revisionRepo.revisions), citizen.Revision, wantRevisions)
+			}
+		})
+	}
+}
+
+func TestRenewDriverLicense(t *testing.T) {
+	issueDate := time.Date(2020, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+	renewDate := time.Date(2030, time.March, 1, 0, 0, 0, 0, time.UTC).Unix()
+	tests := []struct {
+		name       string
+		status     constant.DocumentStatus
+		class      string
+		concurrent int
+		wantError  error
+		wantClass  string
+	}{
+		{name: "same class", status: constant.DocumentActiveStatus, wantClass: "B2"},
+		{name: "new class", status: constant.DocumentActiveStatus, class: "C", wantClass: "C"},
+		{name: "expired licence", status: constant.DocumentExpiredStatus, wantClass: "B2"},
+		{name: "superseded licence", status: constant.DocumentSupersededStatus, wantError: &constant.DocumentSuperseded},
+		{name: "suspended licence", status: constant.DocumentSuspendedStatus, wantError: &constant.DocumentSuspended},
+		{name: "changed by another request", status: constant.DocumentActiveStatus, concurrent: 1, wantError: &constant.DocumentChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			license := &document.DriverLicense{
+				ID:            1,
+				PublicID:      uuid.New(),
+				CID:           1,
+				LicenseNumber: "B2-00120000001",
+				Class:         "B2",
+				Point:         7,
+				Status:        tt.status,
+				Revision:      1,
+				IssueDate:     issueDate,
+				ExpiryDate:    renewDate,
+				HolderDID:     testHolderDID,
+				IssuerDID:     testIssuerDID,
+			}
+			licenseRepo := &fakeDriverLicenseRepository{licenses: map[uint]*document.DriverLicense{1: license}}
+			revisionRepo := &fakeRevisionRepository{concurrent: tt.concurrent}
+			s := &DocumentService{
+				db:                  newTestDB(t),
+				citizenIdentityRepo: &fakeCitizenIdentityRepository{citizens: []*document.CitizenIdentity{{HolderDID: testHolderDID, IDNumber: "001196000001"}}},
+				driverLicenseRepo:   licenseRepo,
+				revisions:           &documentRevisions{revisionRepo: revisionRepo, vcRepo: &fakeVerifiableCredentialRepository{}},
+				numberingService:    &NumberingService{documentNumberRepo: fakeDocumentNumberRepository{}},
+			}
+
+			got, err := s.RenewDriverLicense(context.Background(), license.PublicID.String(), &dto.DriverLicenseRenewedRequestDto{
+				Class:      tt.class,
+				IssueDate:  renewDate,
+				ExpiryDate: renewDate + 10*365*24*60*60,
+				IssuerDID:  testIssuerDID,
+			})
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("RenewDriverLicense() error = %v, want %v", err, tt.wantError)
+			}
+			if tt.wantError != nil {
+				if len(licenseRepo.licenses) != 1 || len(revisionRepo.revisions) != 0 || licenseRepo.licenses[1].Status != tt.status {
+					t.Fatalf("a refused renewal left %d licences and %d revisions", len(licenseRepo.licenses), len(revisionRepo.revisions))
+				}
+				return
+			}
+
+			old, renewed := licenseRepo.licenses[1], licenseRepo.licenses[2]
+			if renewed == nil || got.PublicID != renewed.PublicID.String() {
+				t.Fatalf("RenewDriverLicense() = %+v, want the new licence", got)
+			}
+			if old.Status != constant.DocumentSupersededStatus || old.SupersededBy == nil || *old.SupersededBy != renewed.PublicID || old.Revision != 2 {
+				t.Fatalf("old licence %+v, want it superseded by the new one at revision 2", old)
+			}
+			if renewed.Class != tt.wantClass || renewed.Point != license.Point || renewed.Status != constant.DocumentActiveStatus || renewed.Revision != 1 ||
+				!strings.HasPrefix(renewed.LicenseNumber, tt.wantClass+"-00130") {
+				t.Fatalf("new licence %+v, want an active class %s licence at revision 1 with the points carried over", renewed, tt.wantClass)
+			}
+
+			if len(revisionRepo.revisions) != 2 {
+				t.Fatalf("%d revisions recorded, want 2", len(revisionRepo.revisions))
+			}
+			superseded, created := revisionRepo.revisions[0], revisionRepo.revisions[1]
+			if superseded.DocumentID != old.ID || superseded.Revision != 2 || superseded.Action != constant.DocumentSupersededAction {
+				t.Fatalf("first revision %+v, want revision 2 of the old licence superseded", superseded)
+			}
+			if created.DocumentID != renewed.ID || created.Revision != 1 || created.Action != constant.DocumentRenewedAction {
+				t.Fatalf("second revision %+v, want revision 1 of the new licence renewed", created)
+			}
+			if change, ok := superseded.Changes["status"].(map[string]interface{}); !ok || change["to"] != string(constant.DocumentSupersededStatus) {
+				t.Fatalf("superseded revision changes %v, want the status change", superseded.Changes)
+			}
+		})
+	}
+}
+
+func TestDocumentRevisionLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		revision   int
+		concurrent int
+		wantError  error
+	}{
+		{name: "next revision", revision: 2},
+		{name: "revision already recorded", revision: 1, wantError: &constant.DocumentChanged},
+		{name: "document changed since it was read", revision: 2, concurrent: 1, wantError: &constant.DocumentChanged},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revisions := &documentRevisions{revisionRepo: &fakeRevisionRepository{concurrent: tt.concurrent}}
+			err := revisions.lock(context.Background(), constant.Passport, 1, tt.revision)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("lock() error = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+}
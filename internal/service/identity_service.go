@@ -2,6 +2,7 @@ package service
 
 import (
 	"be/config"
+	"be/internal/domain/gist"
 	"be/internal/domain/schema"
 	"be/internal/infrastructure/database/repository"
 	"be/internal/shared/constant"
@@ -9,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"time"
 
 	"github.com/google/uuid"
 	core "github.com/iden3/go-iden3-core/v2"
@@ -25,19 +27,24 @@ type IIdentityService interface {
 
 	GetIdentityState(ctx context.Context, publicKey *babyjub.PublicKey) (*IdentityState, error)
 	GetIdentityStateByDID(ctx context.Context, didStr string) (*IdentityState, error)
+	LockIdentity(ctx context.Context, did string) error
+	TransitState(ctx context.Context, did string, oldState string, newState string) error
+	IsKnownState(ctx context.Context, did string, state string) (bool, error)
 }
 
 type IdentityService struct {
-	config       *config.Config
-	identityRepo schema.IIdentityRepository
-	mtRepo       repository.IMTRepository
+	config              *config.Config
+	identityRepo        schema.IIdentityRepository
+	mtRepo              repository.IMTRepository
+	stateTransitionRepo gist.IStateTransition
 }
 
-func NewIdentityService(config *config.Config, identityRepo schema.IIdentityRepository, mtRepo repository.IMTRepository) IIdentityService {
+func NewIdentityService(config *config.Config, identityRepo schema.IIdentityRepository, mtRepo repository.IMTRepository, stateTransitionRepo gist.IStateTransition) IIdentityService {
 	return &IdentityService{
-		config:       config,
-		identityRepo: identityRepo,
-		mtRepo:       mtRepo,
+		config:              config,
+		identityRepo:        identityRepo,
+		mtRepo:              mtRepo,
+		stateTransitionRepo: stateTransitionRepo,
 	}
 }
 
@@ -106,6 +113,15 @@ func (s *IdentityService) CreateIdentity(ctx context.Context, request *dto.Ident
 		RootsMTID:  state.RootsMTID,
 		PublicKeyX: publicKey.X.String(),
 		PublicKeyY: publicKey.Y.String(),
+		// every identity holds a wallet; issuer and verifier roles are granted separately
+		Roles: []*schema.IdentityRoleGrant{{
+			PublicID:  uuid.New(),
+			DID:       did.String(),
+			Role:      constant.IdentityHolderRole,
+			Method:    constant.RoleGrantRegistrationMethod,
+			GrantedBy: systemGrantor,
+			GrantedAt: time.Now().UTC(),
+		}},
 	}
 
 	identityCreated, err := s.identityRepo.CreateIdentity(ctx, identityEntity)
@@ -213,3 +229,55 @@ func (s *IdentityService) GetIdentityStateByDID(ctx context.Context, didStr stri
 
 	return identityState, nil
 }
+
+// TransitState records the identity moving from oldState to newState and stores the new state on the identity.
+// LockIdentity holds the identity's row until the transaction in ctx ends. Everything that changes the identity's
+// trees takes it first, and loads the trees only afterwards, so the changes are made one at a time on current roots.
+func (s *IdentityService) LockIdentity(ctx context.Context, did string) error {
+	if _, err := s.identityRepo.LockIdentityByDID(ctx, did); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &constant.IdentityNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *IdentityService) TransitState(ctx context.Context, did string, oldState string, newState string) error {
+	identity, err := s.identityRepo.FindIdentityByDID(ctx, did)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &constant.IdentityNotFound
+		}
+		return &constant.InternalServer
+	}
+
+	now := time.Now().UTC()
+	if _, err := s.stateTransitionRepo.CreateStateTransition(ctx, &gist.StateTransition{
+		PublicID:   uuid.New(),
+		IdentityID: identity.ID,
+		OldState:   oldState,
+		NewState:   newState,
+		Timestamp:  &now,
+	}); err != nil {
+		return err
+	}
+
+	return s.identityRepo.UpdateIdentity(ctx, identity, map[string]interface{}{"state": newState})
+}
+
+// IsKnownState reports whether the identity managed here ever held the state: its current state or one side of a
+// recorded transition.
+func (s *IdentityService) IsKnownState(ctx context.Context, did string, state string) (bool, error) {
+	identity, err := s.identityRepo.FindIdentityByDID(ctx, did)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, &constant.IdentityNotFound
+		}
+		return false, &constant.InternalServer
+	}
+	if identity.State == state {
+		return true, nil
+	}
+	return s.stateTransitionRepo.ExistsStateTransition(ctx, identity.ID, state)
+}
@@ -82,12 +82,17 @@ func (state *IdentityState) GetAuthClaim() (*core.Claim, error) {
 }
 
 func (state *IdentityState) GetIncMTProof(ctx context.Context, claim *core.Claim) (*merkletree.Proof, error) {
+	return state.GetIncMTProofAt(ctx, claim, state.ClaimsTree.Root())
+}
+
+// GetIncMTProofAt generates the inclusion proof of a claim against a given claims tree root.
+func (state *IdentityState) GetIncMTProofAt(ctx context.Context, claim *core.Claim, root *merkletree.Hash) (*merkletree.Proof, error) {
 	hi, _, err := claim.HiHv()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get key and value: %w", err)
 	}
 
-	proof, _, err := state.ClaimsTree.GenerateProof(ctx, hi, state.ClaimsTree.Root())
+	proof, _, err := state.ClaimsTree.GenerateProof(ctx, hi, root)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate auth claim proof: %w", err)
 	}
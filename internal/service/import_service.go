@@ -0,0 +1,708 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/credential"
+	"be/internal/domain/document"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/shared/utils"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	importBatchSize   = 100
+	maxImportFileSize = 10 << 20
+	maxImportRows     = 10000
+	// defaultImportJobStaleAfter is how long a job may go without progress before it counts as abandoned. A running
+	// job records its progress after every batch of importBatchSize rows.
+	defaultImportJobStaleAfter = 10 * time.Minute
+	importJobStaleReason       = "abandoned while processing, upload the file again"
+)
+
+// importProtectedColumns are never taken from the uploaded file; they are generated or owned by the import job.
+// id_number is the exception: a supplied citizen identity number is validated and reserved instead of generated.
+// Licence points only change through the points ledger.
+var importProtectedColumns = []string{
+	"id", "public_id", "cid", "status", "holder_did", "issuer_did", "created_at", "updated_at", "revoked_at",
+	"degree_number", "insurance_number", "license_number", "passport_number", "revision", "superseded_by", "point",
+}
+
+type IImportService interface {
+	CreateImportJob(ctx context.Context, issuerDID string, documentType constant.DocumentType, fileName string, content []byte) (*dto.ImportJobResponseDto, error)
+	GetImportJob(ctx context.Context, id string, issuerDID string) (*dto.ImportJobResponseDto, error)
+	GetImportJobErrorReport(ctx context.Context, id string, issuerDID string) ([]byte, error)
+	FailStaleImportJobs(ctx context.Context) (int, error)
+}
+
+type ImportService struct {
+	config              *config.Config
+	db                  *postgres.PostgresDB
+	logger              *logger.ZapLogger
+	validate            *validator.Validate
+	importJobRepo       document.IImportJobRepository
+	identityRepo        schema.IIdentityRepository
+	citizenIdentityRepo document.ICitizenIdentityRepository
+	academicDegreeRepo  document.IAcademicDegreeRepository
+	healthInsuranceRepo document.IHealthInsuranceRepository
+	driverLicenseRepo   document.IDriverLicenseRepository
+	passportRepo        document.IPassportRepository
+	revisions           *documentRevisions
+	numberingService    INumberingService
+}
+
+func NewImportService(
+	config *config.Config,
+	db *postgres.PostgresDB,
+	logger *logger.ZapLogger,
+	importJobRepo document.IImportJobRepository,
+	identityRepo schema.IIdentityRepository,
+	citizenIdentityRepo document.ICitizenIdentityRepository,
+	academicDegreeRepo document.IAcademicDegreeRepository,
+	healthInsuranceRepo document.IHealthInsuranceRepository,
+	driverLicenseRepo document.IDriverLicenseRepository,
+	passportRepo document.IPassportRepository,
+	revisionRepo document.IDocumentRevisionRepository,
+	vcRepo credential.IVerifiableCredentialRepository,
+	numberingService INumberingService,
+) IImportService {
+	return &ImportService{
+		config:              config,
+		db:                  db,
+		logger:              logger,
+		validate:            newDocumentValidator(),
+		importJobRepo:       importJobRepo,
+		identityRepo:        identityRepo,
+		citizenIdentityRepo: citizenIdentityRepo,
+		academicDegreeRepo:  academicDegreeRepo,
+		healthInsuranceRepo: healthInsuranceRepo,
+		driverLicenseRepo:   driverLicenseRepo,
+		passportRepo:        passportRepo,
+		revisions:           &documentRevisions{revisionRepo: revisionRepo, vcRepo: vcRepo},
+		numberingService:    numberingService,
+	}
+}
+
+// importRowFailure is a row level problem that is reported back to the issuer instead of aborting the job.
+type importRowFailure struct {
+	field   string
+	message string
+}
+
+func (e *importRowFailure) Error() string {
+	return e.message
+}
+
+// checkImportOwner refuses a row that would update a document another issuer owns.
+func checkImportOwner(ownerDID string, issuerDID string) error {
+	if ownerDID != issuerDID {
+		return &importRowFailure{field: "holder_did", message: "document is owned by another issuer"}
+	}
+	return nil
+}
+
+// CreateImportJob parses the uploaded file and processes its rows in the background.
+// The returned job can be polled for progress.
+func (s *ImportService) CreateImportJob(ctx context.Context, issuerDID string, documentType constant.DocumentType, fileName string, content []byte) (*dto.ImportJobResponseDto, error) {
+	switch documentType {
+	case constant.CitizenIdentity, constant.AcademicDegree, constant.HealthInsurance, constant.DriverLicense, constant.Passport:
+	default:
+		return nil, &constant.BadRequest
+	}
+	if len(content) == 0 || len(content) > maxImportFileSize {
+		return nil, &constant.ImportFileInvalid
+	}
+
+	var (
+		rows [][]string
+		err  error
+	)
+	// the header row comes on top of the data rows
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		rows, err = utils.ReadCSV(bytes.NewReader(content), maxImportRows+1)
+	case ".xlsx":
+		rows, err = utils.ReadXLSX(bytes.NewReader(content), int64(len(content)), maxImportRows+1)
+	default:
+		return nil, &constant.ImportFileInvalid
+	}
+	if err != nil {
+		return nil, &constant.ImportFileInvalid
+	}
+
+	_, records := utils.RowsToRecords(rows)
+	if len(records) == 0 || len(records) > maxImportRows {
+		return nil, &constant.ImportFileInvalid
+	}
+
+	job, err := s.importJobRepo.CreateImportJob(ctx, &document.ImportJob{
+		PublicID:     uuid.New(),
+		IssuerDID:    issuerDID,
+		DocumentType: documentType,
+		FileName:     filepath.Base(fileName),
+		Status:       constant.ImportJobPendingStatus,
+		TotalRows:    len(records),
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	// the job outlives the request but keeps its values, so its logs carry the request id
+	go s.runImportJob(context.WithoutCancel(ctx), job, records)
+
+	return dto.ImportJobToResponse(job), nil
+}
+
+func (s *ImportService) GetImportJob(ctx context.Context, id string, issuerDID string) (*dto.ImportJobResponseDto, error) {
+	job, err := s.findImportJob(ctx, id, issuerDID)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ImportJobToResponse(job), nil
+}
+
+// GetImportJobErrorReport renders the failed rows of a job as CSV.
+func (s *ImportService) GetImportJobErrorReport(ctx context.Context, id string, issuerDID string) ([]byte, error) {
+	job, err := s.findImportJob(ctx, id, issuerDID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	_ = writer.Write([]string{"row", "field", "message"})
+	for _, item := range job.Errors {
+		_ = writer.Write([]string{strconv.Itoa(item.Row), item.Field, item.Message})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, &constant.InternalServer
+	}
+	return buf.Bytes(), nil
+}
+
+// FailStaleImportJobs fails the jobs a stopped process left waiting or running and returns how many were failed.
+// Their rows are only kept in memory while they run, so they cannot be resumed.
+func (s *ImportService) FailStaleImportJobs(ctx context.Context) (int, error) {
+	staleAfter := s.config.Cron.ImportJobStaleAfter
+	if staleAfter <= 0 {
+		staleAfter = defaultImportJobStaleAfter
+	}
+	failed, err := s.importJobRepo.FailStaleImportJobs(ctx, time.Now().UTC().Add(-staleAfter), importJobStaleReason)
+	if err != nil {
+		return 0, err
+	}
+	return int(failed), nil
+}
+
+func (s *ImportService) findImportJob(ctx context.Context, id string, issuerDID string) (*document.ImportJob, error) {
+	job, err := s.importJobRepo.FindImportJobByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.ImportJobNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if job.IssuerDID != issuerDID {
+		return nil, &constant.ImportJobNotFound
+	}
+	return job, nil
+}
+
+// runImportJob upserts the records batch by batch. Each batch runs in its own transaction and each row
+// in its own savepoint, so a bad row is rolled back and reported without discarding the rest of its batch.
+func (s *ImportService) runImportJob(ctx context.Context, job *document.ImportJob, records []map[string]string) {
+	if err := s.importJobRepo.UpdateImportJob(ctx, job, map[string]interface{}{"status": constant.ImportJobProcessingStatus}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to start import job", zap.String("job_id", job.PublicID.String()), zap.Error(err))
+		return
+	}
+
+	var (
+		rowErrors []document.ImportRowError
+		created   int
+		updated   int
+		failed    int
+	)
+	seen := make(map[string]int, len(records))
+
+	for start := 0; start < len(records); start += importBatchSize {
+		end := min(start+importBatchSize, len(records))
+
+		err := s.db.GetGormDB().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			txCtx := helper.InjectTx(ctx, tx)
+			for i := start; i < end; i++ {
+				// header is row 1
+				row := i + 2
+				savepoint := fmt.Sprintf("import_row_%d", row)
+				if err := tx.SavePoint(savepoint).Error; err != nil {
+					return err
+				}
+
+				isNew, err := s.importRecord(txCtx, job, records[i], row, seen)
+				if err == nil {
+					if isNew {
+						created++
+					} else {
+						updated++
+					}
+					continue
+				}
+
+				if rollbackErr := tx.RollbackTo(savepoint).Error; rollbackErr != nil {
+					return rollbackErr
+				}
+				failed++
+				var failure *importRowFailure
+				if errors.As(err, &failure) {
+					rowErrors = append(rowErrors, document.ImportRowError{Row: row, Field: failure.field, Message: failure.message})
+				} else {
+					s.logger.WithContext(ctx).Warn("failed to import row", zap.String("job_id", job.PublicID.String()), zap.Int("row", row), zap.Error(err))
+					rowErrors = append(rowErrors, document.ImportRowError{Row: row, Message: "failed to save row"})
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			s.logger.WithContext(ctx).Error("import batch failed", zap.String("job_id", job.PublicID.String()), zap.Error(err))
+			now := time.Now().UTC()
+			_ = s.importJobRepo.UpdateImportJob(ctx, job, map[string]interface{}{
+				"status":       constant.ImportJobFailedStatus,
+				"errors":       datatypes.NewJSONSlice(rowErrors),
+				"completed_at": now,
+			})
+			return
+		}
+
+		if err := s.importJobRepo.UpdateImportJob(ctx, job, map[string]interface{}{
+			"processed_rows": end,
+			"created_rows":   created,
+			"updated_rows":   updated,
+			"failed_rows":    failed,
+			"errors":         datatypes.NewJSONSlice(rowErrors),
+		}); err != nil {
+			s.logger.WithContext(ctx).Warn("failed to update import progress", zap.String("job_id", job.PublicID.String()), zap.Error(err))
+		}
+	}
+
+	now := time.Now().UTC()
+	if err := s.importJobRepo.UpdateImportJob(ctx, job, map[string]interface{}{
+		"status":       constant.ImportJobCompletedStatus,
+		"completed_at": now,
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("failed to complete import job", zap.String("job_id", job.PublicID.String()), zap.Error(err))
+	}
+}
+
+// importRecord upserts one row, keyed by the holder DID, and reports whether a new document was created.
+func (s *ImportService) importRecord(ctx context.Context, job *document.ImportJob, record map[string]string, row int, seen map[string]int) (bool, error) {
+	if record == nil {
+		return false, &importRowFailure{message: "empty row"}
+	}
+
+	holderDID, citizen, err := s.resolveHolder(ctx, job.DocumentType, record)
+	if err != nil {
+		return false, err
+	}
+	if previous, ok := seen[holderDID]; ok {
+		return false, &importRowFailure{field: "holder_did", message: fmt.Sprintf("holder already imported in row %d", previous)}
+	}
+
+	values := make(map[string]string, len(record))
+	for key, value := range record {
+		values[key] = value
+	}
+	for _, key := range importProtectedColumns {
+		delete(values, key)
+	}
+
+	var isNew bool
+	switch job.DocumentType {
+	case constant.CitizenIdentity:
+		isNew, err = s.upsertCitizenIdentity(ctx, job.IssuerDID, holderDID, values)
+	case constant.AcademicDegree:
+		isNew, err = s.upsertAcademicDegree(ctx, job.IssuerDID, citizen, values)
+	case constant.HealthInsurance:
+		isNew, err = s.upsertHealthInsurance(ctx, job.IssuerDID, citizen, values)
+	case constant.DriverLicense:
+		isNew, err = s.upsertDriverLicense(ctx, job.IssuerDID, citizen, values)
+	case constant.Passport:
+		isNew, err = s.upsertPassport(ctx, job.IssuerDID, citizen, values)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	seen[holderDID] = row
+	return isNew, nil
+}
+
+// resolveHolder finds the holder DID of a row. Citizen identities must name a registered identity;
+// other documents may reference their citizen by holder_did or id_number.
+func (s *ImportService) resolveHolder(ctx context.Context, documentType constant.DocumentType, record map[string]string) (string, *document.CitizenIdentity, error) {
+	holderDID := record["holder_did"]
+
+	if documentType == constant.CitizenIdentity {
+		if holderDID == "" {
+			return "", nil, &importRowFailure{field: "holder_did", message: "holder_did is required"}
+		}
+		if _, err := s.identityRepo.FindIdentityByDID(ctx, holderDID); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return "", nil, &importRowFailure{field: "holder_did", message: "holder identity not found"}
+			}
+			return "", nil, err
+		}
+		return holderDID, nil, nil
+	}
+
+	var (
+		citizen *document.CitizenIdentity
+		err     error
+	)
+	switch {
+	case holderDID != "":
+		citizen, err = s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, holderDID)
+	case record["id_number"] != "":
+		citizen, err = s.citizenIdentityRepo.FindCitizenIdentityByIdNumber(ctx, record["id_number"])
+	default:
+		return "", nil, &importRowFailure{field: "holder_did", message: "holder_did or id_number is required"}
+	}
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", nil, &importRowFailure{field: "holder_did", message: "citizen identity not found"}
+		}
+		return "", nil, err
+	}
+	return citizen.HolderDID, citizen, nil
+}
+
+func (s *ImportService) upsertCitizenIdentity(ctx context.Context, issuerDID string, holderDID string, values map[string]string) (bool, error) {
+	entity, err := s.citizenIdentityRepo.FindCitizenIdentityByHolderDID(ctx, holderDID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return false, err
+	}
+	idNumber := values["id_number"]
+	delete(values, "id_number")
+	if !isNew && idNumber != "" && idNumber != entity.IDNumber {
+		return false, &importRowFailure{field: "id_number", message: "id_number cannot be changed"}
+	}
+	if isNew {
+		entity = &document.CitizenIdentity{
+			PublicID:  uuid.New(),
+			Status:    constant.DocumentActiveStatus,
+			Revision:  1,
+			HolderDID: holderDID,
+		}
+	}
+	var before map[string]interface{}
+	if !isNew {
+		if err := checkImportOwner(entity.IssuerDID, issuerDID); err != nil {
+			return false, err
+		}
+		if before, err = utils.DocumentSnapshot(entity); err != nil {
+			return false, err
+		}
+		entity.Revision++
+		if err := s.revisions.lock(ctx, constant.CitizenIdentity, entity.ID, entity.Revision); err != nil {
+			return false, err
+		}
+	}
+	entity.IssuerDID = issuerDID
+
+	var assignNumber func() error
+	if isNew {
+		assignNumber = func() error {
+			if idNumber == "" {
+				entity.IDNumber, err = s.numberingService.NewIdNumber(ctx, entity)
+				return err
+			}
+			if err := s.numberingService.ValidateIdNumber(entity, idNumber); err != nil {
+				return err
+			}
+			entity.IDNumber = idNumber
+			return s.numberingService.ReserveNumber(ctx, constant.CitizenIdentity, idNumber)
+		}
+	}
+	if err := s.assignAndValidate(entity, values, "id_number", assignNumber); err != nil {
+		return false, err
+	}
+	action := constant.DocumentAmendedAction
+	if isNew {
+		action = constant.DocumentCreatedAction
+		_, err = s.citizenIdentityRepo.CreateCitizenIdentity(ctx, entity)
+	} else {
+		_, err = s.citizenIdentityRepo.SaveCitizenIdentity(ctx, entity)
+	}
+	if err != nil {
+		return false, err
+	}
+	return isNew, s.revisions.record(ctx, constant.CitizenIdentity, entity.ID, entity.Revision, entity.HolderDID, action, issuerDID, before, entity)
+}
+
+func (s *ImportService) upsertAcademicDegree(ctx context.Context, issuerDID string, citizen *document.CitizenIdentity, values map[string]string) (bool, error) {
+	entity, err := s.academicDegreeRepo.FindAcademicDegreeByHolderDID(ctx, citizen.HolderDID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return false, err
+	}
+	if isNew {
+		entity = &document.AcademicDegree{
+			PublicID:  uuid.New(),
+			CID:       citizen.ID,
+			Status:    constant.DocumentActiveStatus,
+			Revision:  1,
+			HolderDID: citizen.HolderDID,
+		}
+	}
+	var before map[string]interface{}
+	if !isNew {
+		if err := checkImportOwner(entity.IssuerDID, issuerDID); err != nil {
+			return false, err
+		}
+		if before, err = utils.DocumentSnapshot(entity); err != nil {
+			return false, err
+		}
+		entity.Revision++
+		if err := s.revisions.lock(ctx, constant.AcademicDegree, entity.ID, entity.Revision); err != nil {
+			return false, err
+		}
+	}
+	entity.IssuerDID = issuerDID
+
+	var assignNumber func() error
+	if isNew {
+		assignNumber = func() error {
+			entity.DegreeNumber, err = s.numberingService.NewDegreeNumber(ctx, entity)
+			return err
+		}
+	}
+	if err := s.assignAndValidate(entity, values, "degree_number", assignNumber); err != nil {
+		return false, err
+	}
+	action := constant.DocumentAmendedAction
+	if isNew {
+		action = constant.DocumentCreatedAction
+		_, err = s.academicDegreeRepo.CreateAcademicDegree(ctx, entity)
+	} else {
+		_, err = s.academicDegreeRepo.SaveAcademicDegree(ctx, entity)
+	}
+	if err != nil {
+		return false, err
+	}
+	return isNew, s.revisions.record(ctx, constant.AcademicDegree, entity.ID, entity.Revision, entity.HolderDID, action, issuerDID, before, entity)
+}
+
+func (s *ImportService) upsertHealthInsurance(ctx context.Context, issuerDID string, citizen *document.CitizenIdentity, values map[string]string) (bool, error) {
+	entity, err := s.healthInsuranceRepo.FindHealthInsuranceByHolderDID(ctx, citizen.HolderDID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return false, err
+	}
+	if isNew {
+		entity = &document.HealthInsurance{
+			PublicID:  uuid.New(),
+			CID:       citizen.ID,
+			Status:    constant.DocumentActiveStatus,
+			Revision:  1,
+			HolderDID: citizen.HolderDID,
+		}
+	}
+	var before map[string]interface{}
+	if !isNew {
+		if err := checkImportOwner(entity.IssuerDID, issuerDID); err != nil {
+			return false, err
+		}
+		if before, err = utils.DocumentSnapshot(entity); err != nil {
+			return false, err
+		}
+		entity.Revision++
+		if err := s.revisions.lock(ctx, constant.HealthInsurance, entity.ID, entity.Revision); err != nil {
+			return false, err
+		}
+	}
+	entity.IssuerDID = issuerDID
+
+	var assignNumber func() error
+	if isNew {
+		assignNumber = func() error {
+			entity.InsuranceNumber, err = s.numberingService.NewInsuranceNumber(ctx, entity, citizen)
+			return err
+		}
+	}
+	if err := s.assignAndValidate(entity, values, "insurance_number", assignNumber); err != nil {
+		return false, err
+	}
+	action := constant.DocumentAmendedAction
+	if isNew {
+		action = constant.DocumentCreatedAction
+		_, err = s.healthInsuranceRepo.CreateHealthInsurance(ctx, entity)
+	} else {
+		_, err = s.healthInsuranceRepo.SaveHealthInsurance(ctx, entity)
+	}
+	if err != nil {
+		return false, err
+	}
+	return isNew, s.revisions.record(ctx, constant.HealthInsurance, entity.ID, entity.Revision, entity.HolderDID, action, issuerDID, before, entity)
+}
+
+func (s *ImportService) upsertDriverLicense(ctx context.Context, issuerDID string, citizen *document.CitizenIdentity, values map[string]string) (bool, error) {
+	entity, err := s.driverLicenseRepo.FindDriverLicenseByHolderDID(ctx, citizen.HolderDID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return false, err
+	}
+	if isNew {
+		entity = &document.DriverLicense{
+			PublicID:  uuid.New(),
+			CID:       citizen.ID,
+			Point:     maxLicensePoints,
+			Status:    constant.DocumentActiveStatus,
+			Revision:  1,
+			HolderDID: citizen.HolderDID,
+		}
+	}
+	var before map[string]interface{}
+	if !isNew {
+		if err := checkImportOwner(entity.IssuerDID, issuerDID); err != nil {
+			return false, err
+		}
+		if before, err = utils.DocumentSnapshot(entity); err != nil {
+			return false, err
+		}
+		entity.Revision++
+		if err := s.revisions.lock(ctx, constant.DriverLicense, entity.ID, entity.Revision); err != nil {
+			return false, err
+		}
+	}
+	entity.IssuerDID = issuerDID
+
+	var assignNumber func() error
+	if isNew {
+		assignNumber = func() error {
+			entity.LicenseNumber, err = s.numberingService.NewLicenseNumber(ctx, entity, citizen)
+			return err
+		}
+	}
+	if err := s.assignAndValidate(entity, values, "license_number", assignNumber); err != nil {
+		return false, err
+	}
+	action := constant.DocumentAmendedAction
+	if isNew {
+		action = constant.DocumentCreatedAction
+		_, err = s.driverLicenseRepo.CreateDriverLicense(ctx, entity)
+	} else {
+		_, err = s.driverLicenseRepo.SaveDriverLicense(ctx, entity)
+	}
+	if err != nil {
+		return false, err
+	}
+	return isNew, s.revisions.record(ctx, constant.DriverLicense, entity.ID, entity.Revision, entity.HolderDID, action, issuerDID, before, entity)
+}
+
+func (s *ImportService) upsertPassport(ctx context.Context, issuerDID string, citizen *document.CitizenIdentity, values map[string]string) (bool, error) {
+	entity, err := s.passportRepo.FindPassportByHolderDID(ctx, citizen.HolderDID)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return false, err
+	}
+	if isNew {
+		entity = &document.Passport{
+			PublicID:  uuid.New(),
+			CID:       citizen.ID,
+			Status:    constant.DocumentActiveStatus,
+			Revision:  1,
+			HolderDID: citizen.HolderDID,
+		}
+	}
+	var before map[string]interface{}
+	if !isNew {
+		if err := checkImportOwner(entity.IssuerDID, issuerDID); err != nil {
+			return false, err
+		}
+		if before, err = utils.DocumentSnapshot(entity); err != nil {
+			return false, err
+		}
+		entity.Revision++
+		if err := s.revisions.lock(ctx, constant.Passport, entity.ID, entity.Revision); err != nil {
+			return false, err
+		}
+	}
+	entity.IssuerDID = issuerDID
+	// without an mrz column the stored MRZ is regenerated from the imported fields
+	entity.MRZ = ""
+
+	var assignNumber func() error
+	if isNew {
+		assignNumber = func() error {
+			return assignPassportNumber(ctx, s.numberingService, entity, entity.MRZ)
+		}
+	}
+	if err := s.assignAndValidate(entity, values, "passport_number", assignNumber); err != nil {
+		return false, err
+	}
+	if err := applyPassportMRZ(entity, citizen, entity.MRZ); err != nil {
+		return false, toImportFailure("mrz", err)
+	}
+	action := constant.DocumentAmendedAction
+	if isNew {
+		action = constant.DocumentCreatedAction
+		_, err = s.passportRepo.CreatePassport(ctx, entity)
+	} else {
+		_, err = s.passportRepo.SavePassport(ctx, entity)
+	}
+	if err != nil {
+		return false, err
+	}
+	return isNew, s.revisions.record(ctx, constant.Passport, entity.ID, entity.Revision, entity.HolderDID, action, issuerDID, before, entity)
+}
+
+// assignAndValidate copies the row values onto the entity, lets assignNumber give a new document its number,
+// which may depend on the copied values, and runs the entity's validate struct tags.
+func (s *ImportService) assignAndValidate(entity interface{}, values map[string]string, numberField string, assignNumber func() error) error {
+	if field, err := utils.AssignRecord(entity, values); err != nil {
+		return &importRowFailure{field: field, message: err.Error()}
+	}
+	if assignNumber != nil {
+		if err := assignNumber(); err != nil {
+			return toImportFailure(numberField, err)
+		}
+	}
+
+	if err := s.validate.Struct(entity); err != nil {
+		var validationErrors validator.ValidationErrors
+		if errors.As(err, &validationErrors) && len(validationErrors) > 0 {
+			item := validationErrors[0]
+			return &importRowFailure{field: item.Field(), message: fmt.Sprintf("%s failed on the %s rule", item.Field(), item.Tag())}
+		}
+		return &importRowFailure{message: err.Error()}
+	}
+	return nil
+}
+
+// toImportFailure reports an application error as a failure of the row's field; other errors fail the row
+// without detail.
+func toImportFailure(field string, err error) error {
+	var appErr *constant.Errors
+	if errors.As(err, &appErr) && appErr != &constant.InternalServer {
+		return &importRowFailure{field: field, message: err.Error()}
+	}
+	return err
+}
@@ -0,0 +1,411 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/credential"
+	"be/internal/domain/document"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/shared/utils"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	core "github.com/iden3/go-iden3-core/v2"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	maxLicensePoints         = 12
+	pointRestoreBatchSize    = 100
+	scheduledRestorationNote = "scheduled restoration"
+)
+
+type ILicensePointService interface {
+	DeductPoints(ctx context.Context, id string, request *dto.DriverLicensePointRequestDto) (*dto.DriverLicensePointEntryResponseDto, error)
+	RestorePoints(ctx context.Context, id string, request *dto.DriverLicensePointRequestDto) (*dto.DriverLicensePointEntryResponseDto, error)
+	GetPointHistory(ctx context.Context, id string, spec *helper.QuerySpec) ([]*dto.DriverLicensePointEntryResponseDto, *helper.Pagination, error)
+	RestoreDuePoints(ctx context.Context) (int, error)
+}
+
+type LicensePointService struct {
+	config            *config.Config
+	db                *postgres.PostgresDB
+	logger            *logger.ZapLogger
+	driverLicenseRepo document.IDriverLicenseRepository
+	pointRepo         document.IDriverLicensePointRepository
+	vcRepo            credential.IVerifiableCredentialRepository
+	identityService   IIdentityService
+	revisions         *documentRevisions
+}
+
+func NewLicensePointService(
+	config *config.Config,
+	db *postgres.PostgresDB,
+	logger *logger.ZapLogger,
+	driverLicenseRepo document.IDriverLicenseRepository,
+	pointRepo document.IDriverLicensePointRepository,
+	revisionRepo document.IDocumentRevisionRepository,
+	vcRepo credential.IVerifiableCredentialRepository,
+	identityService IIdentityService,
+) ILicensePointService {
+	return &LicensePointService{
+		config:            config,
+		db:                db,
+		logger:            logger,
+		driverLicenseRepo: driverLicenseRepo,
+		pointRepo:         pointRepo,
+		vcRepo:            vcRepo,
+		identityService:   identityService,
+		revisions:         &documentRevisions{revisionRepo: revisionRepo, vcRepo: vcRepo},
+	}
+}
+
+// issuerRevocations collects the revocation nonces a transaction adds to the trees of issuers, which are not part of
+// the transaction, so they can be deleted again when it fails.
+type issuerRevocations struct {
+	states  []*IdentityState
+	changes []*treeChanges
+}
+
+func (r *issuerRevocations) add(identityState *IdentityState) *treeChanges {
+	changes := &treeChanges{}
+	r.states = append(r.states, identityState)
+	r.changes = append(r.changes, changes)
+	return changes
+}
+
+func (r *issuerRevocations) undo(ctx context.Context, logger *logger.ZapLogger) {
+	for i := len(r.changes) - 1; i >= 0; i-- {
+		undoTreeChanges(ctx, logger, r.states[i], r.changes[i])
+	}
+}
+
+func (s *LicensePointService) transaction(ctx context.Context, fn func(ctx context.Context, revocations *issuerRevocations) error) error {
+	revocations := &issuerRevocations{}
+	err := helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx), revocations)
+	})
+	if err != nil {
+		revocations.undo(ctx, s.logger)
+	}
+	return err
+}
+
+// DeductPoints takes points off the licence, never below zero. A licence left without points is suspended
+// and the credentials issued from it are revoked.
+func (s *LicensePointService) DeductPoints(ctx context.Context, id string, request *dto.DriverLicensePointRequestDto) (*dto.DriverLicensePointEntryResponseDto, error) {
+	if err := validatePointRequest(request); err != nil {
+		return nil, err
+	}
+	driverLicense, err := s.findDriverLicense(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *document.DriverLicensePointEntry
+	err = s.transaction(ctx, func(ctx context.Context, revocations *issuerRevocations) error {
+		locked, err := s.driverLicenseRepo.LockDriverLicense(ctx, driverLicense.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkDocumentIssuable(locked.Status, locked.ExpiryDate); err != nil {
+			return err
+		}
+
+		deducted := min(request.Points, int(locked.Point))
+		entry = &document.DriverLicensePointEntry{
+			PublicID:  uuid.New(),
+			LicenseID: locked.ID,
+			Kind:      constant.LicensePointDeductionKind,
+			Points:    -deducted,
+			Balance:   int(locked.Point) - deducted,
+			Reason:    request.Reason,
+			Reference: request.Reference,
+			CreatedBy: request.CreatedBy,
+		}
+		if deducted > 0 && s.config.Cron.PointRestoreAfter > 0 {
+			restoreAt := time.Now().UTC().Add(s.config.Cron.PointRestoreAfter)
+			entry.RestoreAt = &restoreAt
+		}
+		return s.applyPointEntry(ctx, locked, entry, revocations)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return dto.DriverLicensePointEntryToResponse(entry), nil
+}
+
+// RestorePoints gives points back to the licence, never above the maximum. A suspended licence becomes
+// active again once it has points.
+func (s *LicensePointService) RestorePoints(ctx context.Context, id string, request *dto.DriverLicensePointRequestDto) (*dto.DriverLicensePointEntryResponseDto, error) {
+	if err := validatePointRequest(request); err != nil {
+		return nil, err
+	}
+	driverLicense, err := s.findDriverLicense(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry *document.DriverLicensePointEntry
+	err = s.transaction(ctx, func(ctx context.Context, revocations *issuerRevocations) error {
+		locked, err := s.driverLicenseRepo.LockDriverLicense(ctx, driverLicense.ID)
+		if err != nil {
+			return err
+		}
+		if err := checkPointsRestorable(locked); err != nil {
+			return err
+		}
+
+		entry = newRestorationEntry(locked, request.Points, request.Reason, request.Reference, request.CreatedBy)
+		return s.applyPointEntry(ctx, locked, entry, revocations)
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return dto.DriverLicensePointEntryToResponse(entry), nil
+}
+
+func (s *LicensePointService) GetPointHistory(ctx context.Context, id string, spec *helper.QuerySpec) ([]*dto.DriverLicensePointEntryResponseDto, *helper.Pagination, error) {
+	driverLicense, err := s.findDriverLicense(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entries, total, err := s.pointRepo.FindAllPointEntriesByLicenseID(ctx, driverLicense.ID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resps := make([]*dto.DriverLicensePointEntryResponseDto, 0, len(entries))
+	var lastID uint
+	for _, entry := range entries {
+		resps = append(resps, dto.DriverLicensePointEntryToResponse(entry))
+		lastID = entry.ID
+	}
+	return resps, spec.Pagination(total, len(entries), lastID), nil
+}
+
+// RestoreDuePoints gives back every deduction whose restore time has passed and returns how many were restored.
+// A deduction that fails is logged and retried on the next run.
+func (s *LicensePointService) RestoreDuePoints(ctx context.Context) (int, error) {
+	restored := 0
+	for {
+		entries, err := s.pointRepo.FindDuePointEntries(ctx, time.Now().UTC(), pointRestoreBatchSize)
+		if err != nil {
+			return restored, err
+		}
+
+		progressed := 0
+		for _, entry := range entries {
+			if err := s.restoreDueEntry(ctx, entry); err != nil {
+				s.logger.WithContext(ctx).Error("failed to restore license points", zap.String("entry_id", entry.PublicID.String()), zap.Error(err))
+				continue
+			}
+			progressed++
+		}
+		restored += progressed
+
+		if len(entries) < pointRestoreBatchSize || progressed == 0 {
+			return restored, nil
+		}
+	}
+}
+
+func (s *LicensePointService) restoreDueEntry(ctx context.Context, deduction *document.DriverLicensePointEntry) error {
+	return s.transaction(ctx, func(ctx context.Context, revocations *issuerRevocations) error {
+		driverLicense, err := s.currentDriverLicense(ctx, deduction.LicenseID)
+		if err != nil {
+			return err
+		}
+
+		ok, err := s.pointRepo.MarkPointEntryRestored(ctx, deduction, time.Now().UTC())
+		if err != nil || !ok {
+			return err
+		}
+		// points of a revoked or expired licence stay where they are; the deduction is settled all the same
+		if checkPointsRestorable(driverLicense) != nil {
+			return nil
+		}
+
+		entry := newRestorationEntry(driverLicense, -deduction.Points, scheduledRestorationNote, deduction.PublicID.String(), driverLicense.IssuerDID)
+		return s.applyPointEntry(ctx, driverLicense, entry, revocations)
+	})
+}
+
+// currentDriverLicense locks the licence a deduction was made on, following renewals to the licence that replaced it.
+func (s *LicensePointService) currentDriverLicense(ctx context.Context, id uint) (*document.DriverLicense, error) {
+	driverLicense, err := s.driverLicenseRepo.LockDriverLicense(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	for driverLicense.SupersededBy != nil {
+		renewed, err := s.driverLicenseRepo.FindDriverLicenseByPublicId(ctx, driverLicense.SupersededBy.String())
+		if err != nil {
+			return nil, err
+		}
+		if driverLicense, err = s.driverLicenseRepo.LockDriverLicense(ctx, renewed.ID); err != nil {
+			return nil, err
+		}
+	}
+	return driverLicense, nil
+}
+
+// applyPointEntry saves the entry and the licence's new balance, suspending or reactivating the licence
+// when the balance reaches or leaves zero. It must run inside a transaction with the licence locked.
+func (s *LicensePointService) applyPointEntry(ctx context.Context, driverLicense *document.DriverLicense, entry *document.DriverLicensePointEntry, revocations *issuerRevocations) error {
+	if _, err := s.pointRepo.CreatePointEntry(ctx, entry); err != nil {
+		return err
+	}
+	status := driverLicense.Status
+	switch {
+	case entry.Balance == 0:
+		status = constant.DocumentSuspendedStatus
+	case status == constant.DocumentSuspendedStatus:
+		status = constant.DocumentActiveStatus
+	}
+	if entry.Points == 0 && status == driverLicense.Status {
+		return nil
+	}
+
+	before, err := utils.DocumentSnapshot(driverLicense)
+	if err != nil {
+		return err
+	}
+
+	driverLicense.Point = uint(entry.Balance)
+	driverLicense.Status = status
+	driverLicense.Revision++
+	changes := map[string]interface{}{"point": driverLicense.Point, "status": driverLicense.Status, "revision": driverLicense.Revision}
+	if err := s.driverLicenseRepo.UpdateDriverLicense(ctx, driverLicense, changes); err != nil {
+		return err
+	}
+	if driverLicense.Status == constant.DocumentSuspendedStatus {
+		if err := s.revokeLicenseCredentials(ctx, driverLicense.HolderDID, revocations); err != nil {
+			return err
+		}
+	}
+	return s.revisions.record(ctx, constant.DriverLicense, driverLicense.ID, driverLicense.Revision, driverLicense.HolderDID, constant.DocumentAmendedAction, entry.CreatedBy, before, driverLicense)
+}
+
+// revokeLicenseCredentials revokes the holder's issued credentials built from driver licences. Their revocation
+// nonces go into the revocation tree of each issuer, which moves to a new state, so proofs of non-revocation fail
+// as well. Credentials of issuers not managed here are only marked revoked.
+func (s *LicensePointService) revokeLicenseCredentials(ctx context.Context, holderDID string, revocations *issuerRevocations) error {
+	vcs, err := s.vcRepo.LockIssuedVerifiableCredentialsByDocument(ctx, holderDID, constant.DriverLicense)
+	if err != nil {
+		return err
+	}
+
+	issuers := make([]string, 0)
+	claimsByIssuer := make(map[string][]*core.Claim)
+	for _, vc := range vcs {
+		var coreClaim core.Claim
+		if err := coreClaim.FromHex(vc.ClaimHex); err != nil {
+			return fmt.Errorf("failed to parse core claim: %w", err)
+		}
+		if _, ok := claimsByIssuer[vc.IssuerDID]; !ok {
+			issuers = append(issuers, vc.IssuerDID)
+		}
+		claimsByIssuer[vc.IssuerDID] = append(claimsByIssuer[vc.IssuerDID], &coreClaim)
+	}
+	for _, issuerDID := range issuers {
+		if err := s.revokeIssuerClaims(ctx, issuerDID, claimsByIssuer[issuerDID], revocations); err != nil {
+			return err
+		}
+	}
+
+	revokedAt := time.Now()
+	for _, vc := range vcs {
+		if err := s.vcRepo.UpdateVerifiableCredential(ctx, vc, map[string]interface{}{
+			"status":     constant.VerifiableCredentialRevokedStatus,
+			"revoked_at": revokedAt,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeIssuerClaims adds the revocation nonces of the claims to the issuer's revocation tree and records the state
+// transition it makes.
+func (s *LicensePointService) revokeIssuerClaims(ctx context.Context, issuerDID string, claims []*core.Claim, revocations *issuerRevocations) error {
+	err := s.identityService.LockIdentity(ctx, issuerDID)
+	if errors.Is(err, &constant.IdentityNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	identityState, err := s.identityService.GetIdentityStateByDID(ctx, issuerDID)
+	if err != nil {
+		return fmt.Errorf("failed to get identity state: %w", err)
+	}
+	oldState, err := identityState.GetStateValue()
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+
+	changes := revocations.add(identityState)
+	for _, claim := range claims {
+		if err := changes.revokeClaim(ctx, identityState, claim); err != nil {
+			return err
+		}
+	}
+
+	newState, err := identityState.GetStateValue()
+	if err != nil {
+		return fmt.Errorf("failed to get state: %w", err)
+	}
+	if newState.Hex() == oldState.Hex() {
+		return nil
+	}
+	return s.identityService.TransitState(ctx, issuerDID, oldState.Hex(), newState.Hex())
+}
+
+func (s *LicensePointService) findDriverLicense(ctx context.Context, id string) (*document.DriverLicense, error) {
+	driverLicense, err := s.driverLicenseRepo.FindDriverLicenseByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.DriverLicenseNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	return driverLicense, nil
+}
+
+func newRestorationEntry(driverLicense *document.DriverLicense, points int, reason, reference, createdBy string) *document.DriverLicensePointEntry {
+	restored := min(points, maxLicensePoints-int(driverLicense.Point))
+	return &document.DriverLicensePointEntry{
+		PublicID:  uuid.New(),
+		LicenseID: driverLicense.ID,
+		Kind:      constant.LicensePointRestorationKind,
+		Points:    restored,
+		Balance:   int(driverLicense.Point) + restored,
+		Reason:    reason,
+		Reference: reference,
+		CreatedBy: createdBy,
+	}
+}
+
+func validatePointRequest(request *dto.DriverLicensePointRequestDto) error {
+	if request.Points <= 0 || request.Points > maxLicensePoints || strings.TrimSpace(request.Reason) == "" || len(request.Reference) > 100 {
+		return &constant.LicensePointsInvalid
+	}
+	return nil
+}
+
+// checkPointsRestorable allows restoring points on active and suspended licences only.
+func checkPointsRestorable(driverLicense *document.DriverLicense) error {
+	if driverLicense.Status == constant.DocumentSuspendedStatus {
+		return nil
+	}
+	return checkDocumentIssuable(driverLicense.Status, driverLicense.ExpiryDate)
+}
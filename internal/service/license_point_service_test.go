@@ -0,0 +1,393 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/credential"
+	"be/internal/domain/document"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	core "github.com/iden3/go-iden3-core/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	testHolderDID = "did:example:holder"
+	testIssuerDID = "did:example:issuer"
+)
+
+type fakeDriverLicenseRepository struct {
+	document.IDriverLicenseRepository
+	licenses map[uint]*document.DriverLicense
+}
+
+func (r *fakeDriverLicenseRepository) FindDriverLicenseByPublicId(_ context.Context, publicId string) (*document.DriverLicense, error) {
+	for _, driverLicense := range r.licenses {
+		if driverLicense.PublicID.String() == publicId {
+			copied := *driverLicense
+			return &copied, nil
+		}
+	}
+	return nil, errors.New("record not found")
+}
+
+func (r *fakeDriverLicenseRepository) FindDriverLicenseByHolderDID(_ context.Context, holderDID string) (*document.DriverLicense, error) {
+	for _, driverLicense := range r.licenses {
+		if driverLicense.HolderDID == holderDID {
+			return driverLicense, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeDriverLicenseRepository) LockDriverLicense(_ context.Context, id uint) (*document.DriverLicense, error) {
+	copied := *r.licenses[id]
+	return &copied, nil
+}
+
+func (r *fakeDriverLicenseRepository) UpdateDriverLicense(_ context.Context, entity *document.DriverLicense, _ map[string]interface{}) error {
+	copied := *entity
+	r.licenses[entity.ID] = &copied
+	return nil
+}
+
+func (r *fakeDriverLicenseRepository) CreateDriverLicense(_ context.Context, entity *document.DriverLicense) (*document.DriverLicense, error) {
+	entity.ID = uint(len(r.licenses) + 1)
+	copied := *entity
+	r.licenses[entity.ID] = &copied
+	return entity, nil
+}
+
+type fakePointRepository struct {
+	document.IDriverLicensePointRepository
+	entries []*document.DriverLicensePointEntry
+}
+
+func (r *fakePointRepository) CreatePointEntry(_ context.Context, entity *document.DriverLicensePointEntry) (*document.DriverLicensePointEntry, error) {
+	entity.ID = uint(len(r.entries) + 1)
+	r.entries = append(r.entries, entity)
+	return entity, nil
+}
+
+func (r *fakePointRepository) FindDuePointEntries(_ context.Context, now time.Time, limit int) ([]*document.DriverLicensePointEntry, error) {
+	due := make([]*document.DriverLicensePointEntry, 0)
+	for _, entry := range r.entries {
+		if entry.RestoreAt != nil && entry.RestoredAt == nil && !entry.RestoreAt.After(now) && len(due) < limit {
+			due = append(due, entry)
+		}
+	}
+	return due, nil
+}
+
+func (r *fakePointRepository) MarkPointEntryRestored(_ context.Context, entity *document.DriverLicensePointEntry, restoredAt time.Time) (bool, error) {
+	if entity.RestoredAt != nil {
+		return false, nil
+	}
+	entity.RestoredAt = &restoredAt
+	return true, nil
+}
+
+type fakeRevisionRepository struct {
+	document.IDocumentRevisionRepository
+	revisions []*document.DocumentRevision
+	err       error
+	// concurrent is the number of revisions other requests saved after the documents were read
+	concurrent int
+}
+
+// LockDocumentRevision reports the latest revision recorded for the document; documents start at revision 1.
+func (r *fakeRevisionRepository) LockDocumentRevision(_ context.Context, documentType constant.DocumentType, documentID uint) (int, error) {
+	current := 1
+	for _, revision := range r.revisions {
+		if revision.DocumentType == documentType && revision.DocumentID == documentID {
+			current = max(current, revision.Revision)
+		}
+	}
+	return current + r.concurrent, nil
+}
+
+func (r *fakeRevisionRepository) CreateDocumentRevision(_ context.Context, entity *document.DocumentRevision) (*document.DocumentRevision, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	r.revisions = append(r.revisions, entity)
+	return entity, nil
+}
+
+type fakeVerifiableCredentialRepository struct {
+	credential.IVerifiableCredentialRepository
+	vcs []*credential.VerifiableCredential
+}
+
+func (r *fakeVerifiableCredentialRepository) LockIssuedVerifiableCredentialsByDocument(_ context.Context, holderDID string, _ constant.DocumentType) ([]*credential.VerifiableCredential, error) {
+	issued := make([]*credential.VerifiableCredential, 0)
+	for _, vc := range r.vcs {
+		if vc.HolderDID == holderDID && vc.Status == constant.VerifiableCredentialIssuedStatus {
+			issued = append(issued, vc)
+		}
+	}
+	return issued, nil
+}
+
+func (r *fakeVerifiableCredentialRepository) UpdateVerifiableCredential(_ context.Context, entity *credential.VerifiableCredential, changes map[string]interface{}) error {
+	if status, ok := changes["status"].(constant.VerifiableCredentialStatus); ok {
+		entity.Status = status
+	}
+	return nil
+}
+
+func (r *fakeVerifiableCredentialRepository) FlagVerifiableCredentialsForReissue(context.Context, string, constant.DocumentType) (int64, error) {
+	return 0, nil
+}
+
+type fakeIdentityService struct {
+	IIdentityService
+	states      map[string]*IdentityState
+	locked      []string
+	transitions [][2]string
+}
+
+func (s *fakeIdentityService) LockIdentity(_ context.Context, did string) error {
+	if _, ok := s.states[did]; !ok {
+		return &constant.IdentityNotFound
+	}
+	s.locked = append(s.locked, did)
+	return nil
+}
+
+func (s *fakeIdentityService) GetIdentityStateByDID(_ context.Context, did string) (*IdentityState, error) {
+	identityState, ok := s.states[did]
+	if !ok {
+		return nil, &constant.IdentityNotFound
+	}
+	return identityState, nil
+}
+
+func (s *fakeIdentityService) TransitState(_ context.Context, _ string, oldState string, newState string) error {
+	s.transitions = append(s.transitions, [2]string{oldState, newState})
+	return nil
+}
+
+type licensePointFixture struct {
+	service   *LicensePointService
+	licenses  *fakeDriverLicenseRepository
+	points    *fakePointRepository
+	revisions *fakeRevisionRepository
+	vcs       *fakeVerifiableCredentialRepository
+	issuers   *fakeIdentityService
+	license   *document.DriverLicense
+}
+
+func newLicensePointFixture(t *testing.T, points uint, status constant.DocumentStatus) *licensePointFixture {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Cron.PointRestoreAfter = time.Hour
+	license := &document.DriverLicense{
+		ID:         1,
+		PublicID:   uuid.New(),
+		Point:      points,
+		Status:     status,
+		Revision:   1,
+		ExpiryDate: time.Now().AddDate(1, 0, 0).Unix(),
+		HolderDID:  testHolderDID,
+		IssuerDID:  testIssuerDID,
+	}
+	f := &licensePointFixture{
+		licenses:  &fakeDriverLicenseRepository{licenses: map[uint]*document.DriverLicense{1: license}},
+		points:    &fakePointRepository{},
+		revisions: &fakeRevisionRepository{},
+		vcs:       &fakeVerifiableCredentialRepository{},
+		issuers:   &fakeIdentityService{states: map[string]*IdentityState{testIssuerDID: newTestIdentityState(t)}},
+		license:   license,
+	}
+	f.service = NewLicensePointService(cfg, newTestDB(t), newTestLogger(t), f.licenses, f.points, f.revisions, f.vcs, f.issuers).(*LicensePointService)
+	return f
+}
+
+func (f *licensePointFixture) current() *document.DriverLicense {
+	return f.licenses.licenses[f.license.ID]
+}
+
+// issue stores an issued licence credential of the issuer for the holder.
+func (f *licensePointFixture) issue(t *testing.T, issuerDID string, revNonce uint64) *credential.VerifiableCredential {
+	t.Helper()
+	claim, err := core.NewClaim(core.SchemaHash{1}, core.WithIndexDataInts(new(big.Int).SetUint64(revNonce), nil), core.WithRevocationNonce(revNonce))
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimHex, err := claim.Hex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vc := &credential.VerifiableCredential{HolderDID: testHolderDID, IssuerDID: issuerDID, ClaimHex: claimHex, RevNonce: revNonce, Status: constant.VerifiableCredentialIssuedStatus}
+	f.vcs.vcs = append(f.vcs.vcs, vc)
+	return vc
+}
+
+func revoked(t *testing.T, identityState *IdentityState, revNonce uint64) bool {
+	t.Helper()
+	proof, _, err := identityState.RevTree.GenerateProof(context.Background(), new(big.Int).SetUint64(revNonce), identityState.RevTree.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof.Existence
+}
+
+func TestLicensePointLedger(t *testing.T) {
+	type step struct {
+		restore     bool
+		points      int
+		wantPoints  int
+		wantBalance int
+		wantStatus  constant.DocumentStatus
+		wantError   *constant.Errors
+	}
+	tests := []struct {
+		name   string
+		start  uint
+		status constant.DocumentStatus
+		steps  []step
+	}{
+		{name: "deduct and restore", start: maxLicensePoints, status: constant.DocumentActiveStatus, steps: []step{
+			{points: 5, wantPoints: -5, wantBalance: 7, wantStatus: constant.DocumentActiveStatus},
+			{restore: true, points: 3, wantPoints: 3, wantBalance: 10, wantStatus: constant.DocumentActiveStatus},
+			{restore: true, points: 5, wantPoints: 2, wantBalance: 12, wantStatus: constant.DocumentActiveStatus},
+		}},
+		{name: "suspends at zero", start: 4, status: constant.DocumentActiveStatus, steps: []step{
+			{points: 3, wantPoints: -3, wantBalance: 1, wantStatus: constant.DocumentActiveStatus},
+			{points: 6, wantPoints: -1, wantBalance: 0, wantStatus: constant.DocumentSuspendedStatus},
+			{points: 1, wantError: &constant.DocumentSuspended},
+			{restore: true, points: 2, wantPoints: 2, wantBalance: 2, wantStatus: constant.DocumentActiveStatus},
+		}},
+		{name: "revoked licence", start: 6, status: constant.DocumentRevokeStatus, steps: []step{
+			{points: 1, wantError: &constant.DocumentRevoked},
+			{restore: true, points: 1, wantError: &constant.DocumentRevoked},
+		}},
+		{name: "invalid request", start: 6, status: constant.DocumentActiveStatus, steps: []step{
+			{points: 0, wantError: &constant.LicensePointsInvalid},
+			{points: maxLicensePoints + 1, wantError: &constant.LicensePointsInvalid},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newLicensePointFixture(t, tt.start, tt.status)
+			for i, st := range tt.steps {
+				request := &dto.DriverLicensePointRequestDto{Points: st.points, Reason: "speeding", CreatedBy: testIssuerDID}
+				var entry *dto.DriverLicensePointEntryResponseDto
+				var err error
+				if st.restore {
+					entry, err = f.service.RestorePoints(context.Background(), f.license.PublicID.String(), request)
+				} else {
+					entry, err = f.service.DeductPoints(context.Background(), f.license.PublicID.String(), request)
+				}
+				if st.wantError != nil {
+					if !errors.Is(err, st.wantError) {
+						t.Fatalf("step %d: error = %v, want %v", i, err, st.wantError)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("step %d: %v", i, err)
+				}
+				if entry.Points != st.wantPoints || entry.Balance != st.wantBalance {
+					t.Fatalf("step %d: entry points %d balance %d, want %d %d", i, entry.Points, entry.Balance, st.wantPoints, st.wantBalance)
+				}
+				if got := f.current(); int(got.Point) != st.wantBalance || got.Status != st.wantStatus {
+					t.Fatalf("step %d: licence points %d status %s, want %d %s", i, got.Point, got.Status, st.wantBalance, st.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+func TestLicenseSuspensionRevokesCredentials(t *testing.T) {
+	f := newLicensePointFixture(t, 2, constant.DocumentActiveStatus)
+	issuerState := f.issuers.states[testIssuerDID]
+	first, second := f.issue(t, testIssuerDID, 10), f.issue(t, testIssuerDID, 11)
+	foreign := f.issue(t, "did:example:foreign", 12)
+	before, err := issuerState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := &dto.DriverLicensePointRequestDto{Points: 2, Reason: "speeding", CreatedBy: testIssuerDID}
+	if _, err := f.service.DeductPoints(context.Background(), f.license.PublicID.String(), request); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, vc := range []*credential.VerifiableCredential{first, second, foreign} {
+		if vc.Status != constant.VerifiableCredentialRevokedStatus {
+			t.Fatalf("credential with nonce %d is %s, want revoked", vc.RevNonce, vc.Status)
+		}
+	}
+	if !revoked(t, issuerState, first.RevNonce) || !revoked(t, issuerState, second.RevNonce) {
+		t.Fatal("revocation nonces are not in the issuer's revocation tree")
+	}
+	after, err := issuerState.GetStateValue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.issuers.transitions) != 1 || f.issuers.transitions[0] != [2]string{before.Hex(), after.Hex()} {
+		t.Fatalf("transitions = %v, want one from %s to %s", f.issuers.transitions, before.Hex(), after.Hex())
+	}
+	if len(f.issuers.locked) != 1 || f.issuers.locked[0] != testIssuerDID {
+		t.Fatalf("locked issuers = %v, want %s", f.issuers.locked, testIssuerDID)
+	}
+}
+
+func TestLicenseSuspensionUndoesRevocationsOnFailure(t *testing.T) {
+	f := newLicensePointFixture(t, 2, constant.DocumentActiveStatus)
+	issuerState := f.issuers.states[testIssuerDID]
+	vc := f.issue(t, testIssuerDID, 10)
+	revRoot := issuerState.RevTree.Root().Hex()
+	f.revisions.err = errors.New("insert failed")
+
+	request := &dto.DriverLicensePointRequestDto{Points: 2, Reason: "speeding", CreatedBy: testIssuerDID}
+	if _, err := f.service.DeductPoints(context.Background(), f.license.PublicID.String(), request); !errors.Is(err, &constant.InternalServer) {
+		t.Fatalf("error = %v, want %v", err, &constant.InternalServer)
+	}
+	if revoked(t, issuerState, vc.RevNonce) || issuerState.RevTree.Root().Hex() != revRoot {
+		t.Fatal("revocation of a failed suspension is still in the issuer's revocation tree")
+	}
+}
+
+func TestRestoreDuePoints(t *testing.T) {
+	f := newLicensePointFixture(t, 3, constant.DocumentActiveStatus)
+	request := &dto.DriverLicensePointRequestDto{Points: 3, Reason: "speeding", CreatedBy: testIssuerDID}
+	if _, err := f.service.DeductPoints(context.Background(), f.license.PublicID.String(), request); err != nil {
+		t.Fatal(err)
+	}
+	if f.current().Status != constant.DocumentSuspendedStatus {
+		t.Fatalf("status = %s, want suspended", f.current().Status)
+	}
+
+	restored, err := f.service.RestoreDuePoints(context.Background())
+	if err != nil || restored != 0 {
+		t.Fatalf("RestoreDuePoints() before due = %d, %v, want 0", restored, err)
+	}
+
+	past := time.Now().Add(-time.Minute)
+	f.points.entries[0].RestoreAt = &past
+	restored, err = f.service.RestoreDuePoints(context.Background())
+	if err != nil || restored != 1 {
+		t.Fatalf("RestoreDuePoints() when due = %d, %v, want 1", restored, err)
+	}
+	if got := f.current(); got.Point != 3 || got.Status != constant.DocumentActiveStatus {
+		t.Fatalf("licence points %d status %s, want 3 active", got.Point, got.Status)
+	}
+	entry := f.points.entries[len(f.points.entries)-1]
+	if entry.Kind != constant.LicensePointRestorationKind || entry.Points != 3 || entry.Reference != f.points.entries[0].PublicID.String() {
+		t.Fatalf("restoration entry = %+v, want 3 points referencing the deduction", entry)
+	}
+
+	restored, err = f.service.RestoreDuePoints(context.Background())
+	if err != nil || restored != 0 {
+		t.Fatalf("RestoreDuePoints() again = %d, %v, want 0", restored, err)
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"errors"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// IModerationService backs the operator admin APIs that act on identities and schemas of other parties.
+type IModerationService interface {
+	GetIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*dto.IdentityResponseDto, *helper.Pagination, error)
+	SuspendIdentity(ctx context.Context, id string, request *dto.IdentitySuspendedRequestDto) (*dto.IdentityResponseDto, error)
+	ReinstateIdentity(ctx context.Context, id string) (*dto.IdentityResponseDto, error)
+	RevokeSchema(ctx context.Context, id string) error
+}
+
+type ModerationService struct {
+	logger        *logger.ZapLogger
+	identityRepo  schema.IIdentityRepository
+	authZkService IAuthZkService
+	schemaService ISchemaService
+}
+
+func NewModerationService(
+	logger *logger.ZapLogger,
+	identityRepo schema.IIdentityRepository,
+	authZkService IAuthZkService,
+	schemaService ISchemaService,
+) IModerationService {
+	return &ModerationService{
+		logger:        logger,
+		identityRepo:  identityRepo,
+		authZkService: authZkService,
+		schemaService: schemaService,
+	}
+}
+
+func (s *ModerationService) GetIdentities(ctx context.Context, spec *helper.QuerySpec) ([]*dto.IdentityResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.identityRepo.FindAllIdentities(ctx, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.IdentityResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToIdentityResponseDto(item))
+		lastID = item.ID
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+// SuspendIdentity stops an identity from signing in and ends the sessions it has open. Its credentials and roles
+// are left as they are.
+func (s *ModerationService) SuspendIdentity(ctx context.Context, id string, request *dto.IdentitySuspendedRequestDto) (*dto.IdentityResponseDto, error) {
+	identity, err := s.findIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	suspendedAt := time.Now().UTC()
+	if identity.SuspendedAt != nil {
+		suspendedAt = *identity.SuspendedAt
+	}
+	changes := map[string]interface{}{
+		"suspended_at":   suspendedAt,
+		"suspended_by":   request.SuspendedBy,
+		"suspend_reason": request.Reason,
+	}
+	if err := s.identityRepo.UpdateIdentity(ctx, identity, changes); err != nil {
+		return nil, &constant.InternalServer
+	}
+	identity.SuspendedAt = &suspendedAt
+	identity.SuspendedBy = request.SuspendedBy
+	identity.SuspendReason = request.Reason
+
+	if err := s.authZkService.RevokeIdentitySessions(ctx, identity.PublicID.String()); err != nil {
+		return nil, err
+	}
+	s.logger.WithContext(ctx).Info("identity suspended", zap.String("did", identity.DID), zap.String("suspended_by", request.SuspendedBy))
+	return dto.ToIdentityResponseDto(identity), nil
+}
+
+func (s *ModerationService) ReinstateIdentity(ctx context.Context, id string) (*dto.IdentityResponseDto, error) {
+	identity, err := s.findIdentity(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if identity.SuspendedAt == nil {
+		return dto.ToIdentityResponseDto(identity), nil
+	}
+
+	changes := map[string]interface{}{
+		"suspended_at":   nil,
+		"suspended_by":   nil,
+		"suspend_reason": nil,
+	}
+	if err := s.identityRepo.UpdateIdentity(ctx, identity, changes); err != nil {
+		return nil, &constant.InternalServer
+	}
+	identity.SuspendedAt = nil
+	identity.SuspendedBy = ""
+	identity.SuspendReason = ""
+	return dto.ToIdentityResponseDto(identity), nil
+}
+
+// RevokeSchema takes a schema out of use the same way its issuer would.
+func (s *ModerationService) RevokeSchema(ctx context.Context, id string) error {
+	return s.schemaService.RemoveSchema(ctx, id)
+}
+
+func (s *ModerationService) findIdentity(ctx context.Context, id string) (*schema.Identity, error) {
+	identity, err := s.identityRepo.FindIdentityByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.IdentityNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	return identity, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"be/internal/domain/notification"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type INotificationService interface {
+	Notify(ctx context.Context, recipientDID string, kind constant.NotificationKind, message string, reference string) error
+	GetNotifications(ctx context.Context, recipientDID string, spec *helper.QuerySpec) ([]*dto.NotificationResponseDto, *helper.Pagination, error)
+	MarkNotificationRead(ctx context.Context, id string, recipientDID string) (*dto.NotificationResponseDto, error)
+}
+
+type NotificationService struct {
+	notificationRepo notification.INotificationRepository
+}
+
+func NewNotificationService(notificationRepo notification.INotificationRepository) INotificationService {
+	return &NotificationService{
+		notificationRepo: notificationRepo,
+	}
+}
+
+// Notify stores a notification for the recipient. It joins the caller's transaction, so a notification about a
+// change that is rolled back is never delivered.
+func (s *NotificationService) Notify(ctx context.Context, recipientDID string, kind constant.NotificationKind, message string, reference string) error {
+	_, err := s.notificationRepo.CreateNotification(ctx, &notification.Notification{
+		PublicID:     uuid.New(),
+		RecipientDID: recipientDID,
+		Kind:         kind,
+		Message:      message,
+		Reference:    reference,
+	})
+	return err
+}
+
+func (s *NotificationService) GetNotifications(ctx context.Context, recipientDID string, spec *helper.QuerySpec) ([]*dto.NotificationResponseDto, *helper.Pagination, error) {
+	notifications, total, err := s.notificationRepo.FindAllNotificationsByRecipientDID(ctx, recipientDID, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resps := make([]*dto.NotificationResponseDto, 0, len(notifications))
+	var lastID uint
+	for _, entity := range notifications {
+		resps = append(resps, dto.NotificationToResponse(entity))
+		lastID = entity.ID
+	}
+	return resps, spec.Pagination(total, len(notifications), lastID), nil
+}
+
+func (s *NotificationService) MarkNotificationRead(ctx context.Context, id string, recipientDID string) (*dto.NotificationResponseDto, error) {
+	entity, err := s.notificationRepo.FindNotificationByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.NotificationNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	// another identity's notification is reported as missing rather than forbidden
+	if entity.RecipientDID != recipientDID {
+		return nil, &constant.NotificationNotFound
+	}
+	if entity.ReadAt != nil {
+		return dto.NotificationToResponse(entity), nil
+	}
+
+	readAt := time.Now().UTC()
+	if err := s.notificationRepo.UpdateNotification(ctx, entity, map[string]interface{}{"read_at": readAt}); err != nil {
+		return nil, &constant.InternalServer
+	}
+	entity.ReadAt = &readAt
+	return dto.NotificationToResponse(entity), nil
+}
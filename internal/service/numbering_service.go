@@ -0,0 +1,105 @@
+package service
+
+import (
+	"be/internal/domain/document"
+	"be/internal/shared/constant"
+	"be/internal/shared/utils"
+	"context"
+	"errors"
+)
+
+// maxNumberAttempts bounds the retries when a generated serial collides with a reserved number.
+const maxNumberAttempts = 5
+
+// INumberingService hands out document numbers whose prefix encodes the document's own fields and whose
+// serial is random. Every number is reserved in the caller's transaction, so a rolled back document
+// releases its number.
+type INumberingService interface {
+	NewIdNumber(ctx context.Context, citizen *document.CitizenIdentity) (string, error)
+	NewDegreeNumber(ctx context.Context, degree *document.AcademicDegree) (string, error)
+	NewInsuranceNumber(ctx context.Context, insurance *document.HealthInsurance, citizen *document.CitizenIdentity) (string, error)
+	NewLicenseNumber(ctx context.Context, license *document.DriverLicense, citizen *document.CitizenIdentity) (string, error)
+	NewPassportNumber(ctx context.Context, passport *document.Passport) (string, error)
+	ReserveNumber(ctx context.Context, documentType constant.DocumentType, number string) error
+	ValidateIdNumber(citizen *document.CitizenIdentity, idNumber string) error
+}
+
+type NumberingService struct {
+	documentNumberRepo document.IDocumentNumberRepository
+}
+
+func NewNumberingService(documentNumberRepo document.IDocumentNumberRepository) INumberingService {
+	return &NumberingService{
+		documentNumberRepo: documentNumberRepo,
+	}
+}
+
+func (s *NumberingService) NewIdNumber(ctx context.Context, citizen *document.CitizenIdentity) (string, error) {
+	prefix, err := utils.IdNumberPrefix(citizen.PlaceOfBirth, citizen.Gender, citizen.DateOfBirth)
+	if err != nil {
+		if errors.Is(err, utils.ErrUnknownProvince) {
+			return "", &constant.ProvinceUnknown
+		}
+		return "", &constant.IdNumberInvalid
+	}
+	return s.next(ctx, constant.CitizenIdentity, prefix, utils.IdNumberSerialLength)
+}
+
+func (s *NumberingService) NewDegreeNumber(ctx context.Context, degree *document.AcademicDegree) (string, error) {
+	prefix := utils.DegreeNumberPrefix(degree.University, degree.GraduateYear)
+	return s.next(ctx, constant.AcademicDegree, prefix, utils.DegreeNumberSerialLength)
+}
+
+func (s *NumberingService) NewInsuranceNumber(ctx context.Context, insurance *document.HealthInsurance, citizen *document.CitizenIdentity) (string, error) {
+	prefix := utils.InsuranceNumberPrefix(citizen.IDNumber, insurance.StartDate)
+	return s.next(ctx, constant.HealthInsurance, prefix, utils.InsuranceNumberSerialLength)
+}
+
+func (s *NumberingService) NewLicenseNumber(ctx context.Context, license *document.DriverLicense, citizen *document.CitizenIdentity) (string, error) {
+	prefix := utils.LicenseNumberPrefix(license.Class, citizen.IDNumber, license.IssueDate)
+	return s.next(ctx, constant.DriverLicense, prefix, utils.LicenseNumberSerialLength)
+}
+
+func (s *NumberingService) NewPassportNumber(ctx context.Context, passport *document.Passport) (string, error) {
+	prefix := utils.PassportNumberPrefix(passport.PassportType)
+	return s.next(ctx, constant.Passport, prefix, utils.PassportNumberSerialLength)
+}
+
+// ReserveNumber reserves a number that was supplied rather than generated, such as one read from a printed MRZ.
+func (s *NumberingService) ReserveNumber(ctx context.Context, documentType constant.DocumentType, number string) error {
+	reserved, err := s.documentNumberRepo.ReserveDocumentNumber(ctx, documentType, number)
+	if err != nil {
+		return &constant.InternalServer
+	}
+	if !reserved {
+		return &constant.DocumentNumberExisted
+	}
+	return nil
+}
+
+func (s *NumberingService) ValidateIdNumber(citizen *document.CitizenIdentity, idNumber string) error {
+	if err := utils.ValidateIdNumber(idNumber, citizen.PlaceOfBirth, citizen.Gender, citizen.DateOfBirth); err != nil {
+		if errors.Is(err, utils.ErrUnknownProvince) {
+			return &constant.ProvinceUnknown
+		}
+		return &constant.IdNumberInvalid
+	}
+	return nil
+}
+
+func (s *NumberingService) next(ctx context.Context, documentType constant.DocumentType, prefix string, serialLength int) (string, error) {
+	for attempt := 0; attempt < maxNumberAttempts; attempt++ {
+		serial, err := utils.RandomDigits(serialLength)
+		if err != nil {
+			return "", &constant.InternalServer
+		}
+		reserved, err := s.documentNumberRepo.ReserveDocumentNumber(ctx, documentType, prefix+serial)
+		if err != nil {
+			return "", &constant.InternalServer
+		}
+		if reserved {
+			return prefix + serial, nil
+		}
+	}
+	return "", &constant.DocumentNumberExhausted
+}
@@ -0,0 +1,587 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/auth"
+	"be/internal/infrastructure/cache/redis"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/iden3/go-circuits/v2"
+	"github.com/iden3/iden3comm/v2/protocol"
+	goredis "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// IOIDCService is an OpenID Connect provider for relying parties. Users sign in with the ZK login flow and can
+// prove credential claims, such as being over 18, without disclosing the credential itself.
+type IOIDCService interface {
+	GetDiscovery() *dto.OIDCDiscoveryDto
+	Authorize(ctx context.Context, request *dto.OIDCAuthorizeRequestDto) (*dto.OIDCAuthorizationDto, string, error)
+	Approve(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.OIDCRedirectDto, error)
+	Token(ctx context.Context, request *dto.OIDCTokenRequestDto) (*dto.OIDCTokenResponseDto, error)
+	UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error)
+	GetClients(ctx context.Context, spec *helper.QuerySpec) ([]*dto.OIDCClientResponseDto, *helper.Pagination, error)
+	CreateClient(ctx context.Context, request *dto.OIDCClientCreatedRequestDto) (*dto.OIDCClientResponseDto, error)
+	DisableClient(ctx context.Context, clientID string) error
+}
+
+const (
+	oidcOpenIDScope  = "openid"
+	oidcProfileScope = "profile"
+
+	defaultOIDCCodeTTL        = time.Minute
+	defaultOIDCAccessTokenTTL = 10 * time.Minute
+	defaultOIDCIDTokenTTL     = 10 * time.Minute
+
+	// PKCE verifiers are 43 to 128 characters (RFC 7636), and so are their S256 challenges once encoded
+	minCodeChallengeLength = 43
+	maxCodeChallengeLength = 128
+)
+
+func oidcAuthorizationRedisKey(requestID string) string {
+	return "oidc:authorization:" + requestID
+}
+
+func oidcCodeRedisKey(code string) string {
+	return "oidc:code:" + code
+}
+
+func oidcAccessTokenRedisKey(tokenID string) string {
+	return "oidc:access_token:" + tokenID
+}
+
+// oidcAuthorization is an authorization request waiting for the user to answer its login challenge.
+type oidcAuthorization struct {
+	ClientID      string   `json:"client_id"`
+	RedirectURI   string   `json:"redirect_uri"`
+	Scopes        []string `json:"scopes"`
+	State         string   `json:"state"`
+	Nonce         string   `json:"nonce"`
+	CodeChallenge string   `json:"code_challenge"`
+	// ClaimRequests maps the id of each credential proof request to the claim it answers.
+	ClaimRequests map[uint32]string `json:"claim_requests"`
+}
+
+// oidcGrant is what an authorization code stands for until the relying party redeems it.
+type oidcGrant struct {
+	ClientID      string          `json:"client_id"`
+	RedirectURI   string          `json:"redirect_uri"`
+	Scopes        []string        `json:"scopes"`
+	Nonce         string          `json:"nonce"`
+	CodeChallenge string          `json:"code_challenge"`
+	DID           string          `json:"did"`
+	Name          string          `json:"name"`
+	Claims        map[string]bool `json:"claims"`
+	AuthTime      int64           `json:"auth_time"`
+}
+
+type OIDCService struct {
+	config            *config.Config
+	logger            *logger.ZapLogger
+	redis             *redis.RedisCache
+	clientRepo        auth.IOIDCClientRepository
+	authZkService     IAuthZkService
+	signingKeyService ISigningKeyService
+}
+
+func NewOIDCService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	redis *redis.RedisCache,
+	clientRepo auth.IOIDCClientRepository,
+	authZkService IAuthZkService,
+	signingKeyService ISigningKeyService,
+) IOIDCService {
+	return &OIDCService{
+		config:            config,
+		logger:            logger,
+		redis:             redis,
+		clientRepo:        clientRepo,
+		authZkService:     authZkService,
+		signingKeyService: signingKeyService,
+	}
+}
+
+func (s *OIDCService) issuer() string {
+	if s.config.OIDC.Issuer != "" {
+		return strings.TrimSuffix(s.config.OIDC.Issuer, "/")
+	}
+	return s.config.GetPublicURL()
+}
+
+func (s *OIDCService) userInfoEndpoint() string {
+	return s.issuer() + "/oauth2/userinfo"
+}
+
+func (s *OIDCService) findClaimScope(scope string) *config.OIDCClaimScope {
+	for i := range s.config.OIDC.ClaimScopes {
+		if s.config.OIDC.ClaimScopes[i].Scope == scope {
+			return &s.config.OIDC.ClaimScopes[i]
+		}
+	}
+	return nil
+}
+
+func (s *OIDCService) supportedScopes() []string {
+	scopes := []string{oidcOpenIDScope, oidcProfileScope}
+	for _, claimScope := range s.config.OIDC.ClaimScopes {
+		scopes = append(scopes, claimScope.Scope)
+	}
+	return scopes
+}
+
+func (s *OIDCService) GetDiscovery() *dto.OIDCDiscoveryDto {
+	issuer := s.issuer()
+	claims := []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "name"}
+	for _, claimScope := range s.config.OIDC.ClaimScopes {
+		claims = append(claims, claimScope.Claim)
+	}
+	return &dto.OIDCDiscoveryDto{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth2/authorize",
+		TokenEndpoint:                     issuer + "/oauth2/token",
+		UserInfoEndpoint:                  s.userInfoEndpoint(),
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		ScopesSupported:                   s.supportedScopes(),
+		ClaimsSupported:                   claims,
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{"authorization_code"},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{signingAlgorithm(s.config)},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{"S256"},
+	}
+}
+
+// Authorize checks an authorization request and answers it with a login challenge that also asks for a proof of
+// every claim scope requested. Claim proofs are optional: a user who declines one signs in without the claim.
+func (s *OIDCService) Authorize(ctx context.Context, request *dto.OIDCAuthorizeRequestDto) (*dto.OIDCAuthorizationDto, string, error) {
+	client, err := s.findActiveClient(ctx, request.ClientID)
+	if err != nil {
+		return nil, "", err
+	}
+	if !slices.Contains(client.RedirectURIs, request.RedirectURI) {
+		return nil, "", &constant.OIDCRedirectURIInvalid
+	}
+	if request.ResponseType != "code" || request.CodeChallengeMethod != "S256" ||
+		len(request.CodeChallenge) < minCodeChallengeLength || len(request.CodeChallenge) > maxCodeChallengeLength {
+		return nil, "", &constant.OIDCRequestInvalid
+	}
+
+	scopes := strings.Fields(request.Scope)
+	if !slices.Contains(scopes, oidcOpenIDScope) {
+		return nil, "", &constant.OIDCScopeInvalid
+	}
+	supported := s.supportedScopes()
+	for _, scope := range scopes {
+		if !slices.Contains(supported, scope) || (scope != oidcOpenIDScope && !slices.Contains(client.Scopes, scope)) {
+			return nil, "", &constant.OIDCScopeInvalid
+		}
+	}
+
+	claimRequests := make(map[uint32]string)
+	proofRequests := make([]protocol.ZeroKnowledgeProofRequest, 0)
+	for _, scope := range scopes {
+		claimScope := s.findClaimScope(scope)
+		if claimScope == nil {
+			continue
+		}
+		proofRequest, err := newClaimProofRequest(claimScope, time.Now().UTC())
+		if err != nil {
+			return nil, "", &constant.InternalServer
+		}
+		claimRequests[proofRequest.ID] = claimScope.Claim
+		proofRequests = append(proofRequests, *proofRequest)
+	}
+
+	authRequest, binding, err := s.authZkService.CreateChallenge(ctx, s.issuer()+"/oauth2/authorize/callback", proofRequests...)
+	if err != nil {
+		return nil, "", err
+	}
+	redisValue, err := json.Marshal(&oidcAuthorization{
+		ClientID:      client.ClientID,
+		RedirectURI:   request.RedirectURI,
+		Scopes:        scopes,
+		State:         request.State,
+		Nonce:         request.Nonce,
+		CodeChallenge: request.CodeChallenge,
+		ClaimRequests: claimRequests,
+	})
+	if err != nil {
+		return nil, "", &constant.InternalServer
+	}
+	if err := s.redis.Set(ctx, oidcAuthorizationRedisKey(authRequest.ID), redisValue, loginChallengeTTL); err != nil {
+		return nil, "", &constant.InternalServer
+	}
+
+	return &dto.OIDCAuthorizationDto{
+		ClientName: client.Name,
+		Scopes:     scopes,
+		Request:    authRequest,
+	}, binding, nil
+}
+
+// newClaimProofRequest builds the credentialAtomicQueryV3 request that proves a claim scope.
+func newClaimProofRequest(claimScope *config.OIDCClaimScope, now time.Time) (*protocol.ZeroKnowledgeProofRequest, error) {
+	requestID, err := rand.Int(rand.Reader, big.NewInt(math.MaxUint32))
+	if err != nil {
+		return nil, err
+	}
+
+	operator, value := claimScope.Operator, claimScope.Value
+	if claimScope.MinAge > 0 {
+		// born before this date means at least MinAge years old today
+		bornBefore := now.AddDate(-claimScope.MinAge, 0, 0)
+		operator = "$lt"
+		if claimScope.DateFormat == "yyyymmdd" {
+			value = bornBefore.Year()*10000 + int(bornBefore.Month())*100 + bornBefore.Day()
+		} else {
+			value = bornBefore.Unix()
+		}
+	}
+
+	allowedIssuers := claimScope.AllowedIssuers
+	if len(allowedIssuers) == 0 {
+		allowedIssuers = []string{"*"}
+	}
+	optional := true
+	return &protocol.ZeroKnowledgeProofRequest{
+		ID:        uint32(requestID.Uint64()) + 1,
+		CircuitID: string(circuits.AtomicQueryV3CircuitID),
+		Optional:  &optional,
+		Query: map[string]interface{}{
+			"allowedIssuers": allowedIssuers,
+			"context":        claimScope.Context,
+			"type":           claimScope.CredentialType,
+			"credentialSubject": map[string]interface{}{
+				claimScope.Field: map[string]interface{}{
+					operator: value,
+				},
+			},
+		},
+	}, nil
+}
+
+// Approve signs the user in with their answer to the challenge of an authorization request and returns where to
+// send them back to the relying party with an authorization code.
+func (s *OIDCService) Approve(ctx context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.OIDCRedirectDto, error) {
+	redisValue, err := s.redis.GetDel(ctx, oidcAuthorizationRedisKey(authResponse.ID)).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, &constant.ChallengeInvalid
+		}
+		return nil, &constant.InternalServer
+	}
+	var authorization oidcAuthorization
+	if err := json.Unmarshal(redisValue, &authorization); err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	identity, err := s.authZkService.Authenticate(ctx, authResponse, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// the verifier has checked every proof presented; a claim holds when its proof is among them
+	claims := make(map[string]bool, len(authorization.ClaimRequests))
+	for requestID, claim := range authorization.ClaimRequests {
+		claims[claim] = findProofByRequestID(authResponse.Body.Scope, requestID) != nil
+	}
+
+	code, err := randomToken()
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	grant, err := json.Marshal(&oidcGrant{
+		ClientID:      authorization.ClientID,
+		RedirectURI:   authorization.RedirectURI,
+		Scopes:        authorization.Scopes,
+		Nonce:         authorization.Nonce,
+		CodeChallenge: authorization.CodeChallenge,
+		DID:           identity.DID,
+		Name:          identity.Name,
+		Claims:        claims,
+		AuthTime:      time.Now().Unix(),
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	codeTTL := durationOr(s.config.OIDC.CodeTTL, defaultOIDCCodeTTL)
+	if err := s.redis.Set(ctx, oidcCodeRedisKey(code), grant, codeTTL); err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	redirectURI, err := url.Parse(authorization.RedirectURI)
+	if err != nil {
+		return nil, &constant.OIDCRedirectURIInvalid
+	}
+	query := redirectURI.Query()
+	query.Set("code", code)
+	if authorization.State != "" {
+		query.Set("state", authorization.State)
+	}
+	query.Set("iss", s.issuer())
+	redirectURI.RawQuery = query.Encode()
+
+	s.logger.WithContext(ctx).Info("oidc authorization granted", zap.String("client_id", authorization.ClientID), zap.String("did", identity.DID))
+	return &dto.OIDCRedirectDto{RedirectURI: redirectURI.String()}, nil
+}
+
+// Token redeems an authorization code for an ID token and an access token. Codes are single use: a code that
+// fails any check is spent all the same.
+func (s *OIDCService) Token(ctx context.Context, request *dto.OIDCTokenRequestDto) (*dto.OIDCTokenResponseDto, error) {
+	if request.GrantType != "authorization_code" {
+		return nil, &constant.OIDCRequestInvalid
+	}
+	redisValue, err := s.redis.GetDel(ctx, oidcCodeRedisKey(request.Code)).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, &constant.OIDCGrantInvalid
+		}
+		return nil, &constant.InternalServer
+	}
+	var grant oidcGrant
+	if err := json.Unmarshal(redisValue, &grant); err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	if request.ClientID != grant.ClientID {
+		return nil, &constant.OIDCClientInvalid
+	}
+	client, err := s.findActiveClient(ctx, request.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if client.SecretHash != "" && bcrypt.CompareHashAndPassword([]byte(client.SecretHash), []byte(request.ClientSecret)) != nil {
+		return nil, &constant.OIDCClientInvalid
+	}
+	if request.RedirectURI != grant.RedirectURI {
+		return nil, &constant.OIDCGrantInvalid
+	}
+	if subtle.ConstantTimeCompare([]byte(pkceChallenge(request.CodeVerifier)), []byte(grant.CodeChallenge)) != 1 {
+		return nil, &constant.OIDCGrantInvalid
+	}
+
+	now := time.Now()
+	issuer := s.issuer()
+	userInfo := map[string]interface{}{"sub": grant.DID}
+	if slices.Contains(grant.Scopes, oidcProfileScope) {
+		userInfo["name"] = grant.Name
+	}
+	for claim, proved := range grant.Claims {
+		userInfo[claim] = proved
+	}
+
+	idClaims := jwt.MapClaims{
+		"iss":       issuer,
+		"aud":       grant.ClientID,
+		"iat":       now.Unix(),
+		"exp":       now.Add(durationOr(s.config.OIDC.IDTokenTTL, defaultOIDCIDTokenTTL)).Unix(),
+		"auth_time": grant.AuthTime,
+	}
+	if grant.Nonce != "" {
+		idClaims["nonce"] = grant.Nonce
+	}
+	for claim, value := range userInfo {
+		idClaims[claim] = value
+	}
+	idToken, err := s.signingKeyService.SignToken(idClaims)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to sign id token", zap.String("client_id", grant.ClientID), zap.Error(err))
+		return nil, &constant.InternalServer
+	}
+
+	accessTokenTTL := durationOr(s.config.OIDC.AccessTokenTTL, defaultOIDCAccessTokenTTL)
+	accessClaims := &dto.OIDCAccessClaims{
+		Scope:    strings.Join(grant.Scopes, " "),
+		ClientID: grant.ClientID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    issuer,
+			Subject:   grant.DID,
+			Audience:  jwt.ClaimStrings{s.userInfoEndpoint()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
+		},
+	}
+	accessToken, err := s.signingKeyService.SignToken(accessClaims)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("failed to sign access token", zap.String("client_id", grant.ClientID), zap.Error(err))
+		return nil, &constant.InternalServer
+	}
+	userInfoValue, err := json.Marshal(userInfo)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	if err := s.redis.Set(ctx, oidcAccessTokenRedisKey(accessClaims.ID), userInfoValue, accessTokenTTL); err != nil {
+		return nil, &constant.InternalServer
+	}
+
+	return &dto.OIDCTokenResponseDto{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(accessTokenTTL.Seconds()),
+		IDToken:     idToken,
+		Scope:       accessClaims.Scope,
+	}, nil
+}
+
+// pkceChallenge derives the S256 code challenge of a PKCE code verifier (RFC 7636 section 4.2).
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+// UserInfo returns the claims granted with an access token while it is valid.
+func (s *OIDCService) UserInfo(ctx context.Context, accessToken string) (map[string]interface{}, error) {
+	var claims dto.OIDCAccessClaims
+	token, err := s.signingKeyService.ParseToken(accessToken, &claims)
+	if err != nil || !token.Valid || claims.ID == "" || !slices.Contains(claims.Audience, s.userInfoEndpoint()) {
+		return nil, &constant.InvalidToken
+	}
+
+	redisValue, err := s.redis.Get(ctx, oidcAccessTokenRedisKey(claims.ID)).Bytes()
+	if err != nil {
+		if errors.Is(err, goredis.Nil) {
+			return nil, &constant.InvalidToken
+		}
+		return nil, &constant.InternalServer
+	}
+	var userInfo map[string]interface{}
+	if err := json.Unmarshal(redisValue, &userInfo); err != nil {
+		return nil, &constant.InternalServer
+	}
+	return userInfo, nil
+}
+
+func (s *OIDCService) GetClients(ctx context.Context, spec *helper.QuerySpec) ([]*dto.OIDCClientResponseDto, *helper.Pagination, error) {
+	entities, total, err := s.clientRepo.FindAllOIDCClients(ctx, spec)
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.OIDCClientResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToOIDCClientResponseDto(item))
+		lastID = uint(item.ID)
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+// CreateClient registers a relying party. The secret of a confidential client is only returned here; it is
+// stored hashed.
+func (s *OIDCService) CreateClient(ctx context.Context, request *dto.OIDCClientCreatedRequestDto) (*dto.OIDCClientResponseDto, error) {
+	for _, redirectURI := range request.RedirectURIs {
+		if !validRedirectURI(redirectURI) {
+			return nil, &constant.OIDCRedirectURIInvalid
+		}
+	}
+	supported := s.supportedScopes()
+	for _, scope := range request.Scopes {
+		if !slices.Contains(supported, scope) {
+			return nil, &constant.OIDCScopeInvalid
+		}
+	}
+
+	client := &auth.OIDCClient{
+		ClientID:     uuid.NewString(),
+		Name:         request.Name,
+		RedirectURIs: datatypes.JSONSlice[string](request.RedirectURIs),
+		Scopes:       datatypes.JSONSlice[string](request.Scopes),
+		CreatedBy:    request.CreatedBy,
+	}
+	var secret string
+	if !request.Public {
+		var err error
+		if secret, err = randomToken(); err != nil {
+			return nil, &constant.InternalServer
+		}
+		secretHash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, &constant.InternalServer
+		}
+		client.SecretHash = string(secretHash)
+	}
+
+	client, err := s.clientRepo.CreateOIDCClient(ctx, client)
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	s.logger.WithContext(ctx).Info("oidc client created", zap.String("client_id", client.ClientID), zap.String("created_by", request.CreatedBy))
+	resp := dto.ToOIDCClientResponseDto(client)
+	resp.ClientSecret = secret
+	return resp, nil
+}
+
+// DisableClient stops a relying party from starting new sign-ins and redeeming codes. Tokens it already holds
+// stay valid until they expire.
+func (s *OIDCService) DisableClient(ctx context.Context, clientID string) error {
+	client, err := s.clientRepo.FindOIDCClientByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &constant.OIDCClientNotFound
+		}
+		return &constant.InternalServer
+	}
+	if client.DisabledAt != nil {
+		return nil
+	}
+	if err := s.clientRepo.UpdateOIDCClient(ctx, client, map[string]interface{}{"disabled_at": time.Now().UTC()}); err != nil {
+		return &constant.InternalServer
+	}
+	return nil
+}
+
+func (s *OIDCService) findActiveClient(ctx context.Context, clientID string) (*auth.OIDCClient, error) {
+	client, err := s.clientRepo.FindOIDCClientByClientID(ctx, clientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.OIDCClientInvalid
+		}
+		return nil, &constant.InternalServer
+	}
+	if client.DisabledAt != nil {
+		return nil, &constant.OIDCClientInvalid
+	}
+	return client, nil
+}
+
+// validRedirectURI accepts absolute https URIs without a fragment, and plain http on the loopback host for local
+// development.
+func validRedirectURI(redirectURI string) bool {
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || !parsed.IsAbs() || parsed.Host == "" || parsed.Fragment != "" {
+		return false
+	}
+	switch parsed.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := parsed.Hostname()
+		return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	default:
+		return false
+	}
+}
@@ -0,0 +1,32 @@
+package service
+
+import "testing"
+
+func TestPKCEChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		want     string
+	}{
+		// RFC 7636 appendix B
+		{name: "rfc 7636 example", verifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", want: "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"},
+		{name: "empty verifier", verifier: "", want: "47DEQpj8HBSa-_TImW-5JCeuQeRkm5NMpJWZG3hSuFU"},
+		{name: "padding is part of the verifier", verifier: "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk=", want: "20xwJMOrFO1xeQ7yiiV7MYQenAHee4IKa0W722ftl88"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pkceChallenge(tt.verifier); got != tt.want {
+				t.Fatalf("pkceChallenge(%q) = %q, want %q", tt.verifier, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPKCEChallengeLength(t *testing.T) {
+	// an S256 challenge is always 43 characters, the shortest Authorize accepts
+	for _, verifier := range []string{"a", "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk", string(make([]byte, 128))} {
+		if got := len(pkceChallenge(verifier)); got < minCodeChallengeLength || got > maxCodeChallengeLength {
+			t.Fatalf("len(pkceChallenge(%q)) = %d, want within [%d, %d]", verifier, got, minCodeChallengeLength, maxCodeChallengeLength)
+		}
+	}
+}
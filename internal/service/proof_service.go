@@ -5,6 +5,7 @@ import (
 	"be/internal/domain/proof"
 	"be/internal/domain/schema"
 	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
 	"be/pkg/logger"
 	"context"
@@ -16,16 +17,17 @@ import (
 	"github.com/google/uuid"
 	"github.com/iden3/go-iden3-auth/v2/pubsignals"
 	"github.com/iden3/iden3comm/v2/protocol"
+	"go.uber.org/zap"
 	"gorm.io/gorm"
 )
 
 type IProofService interface {
 	CreateProofRequest(ctx context.Context, request *protocol.AuthorizationRequestMessage) (*dto.ProofRequestResponseDto, error)
-	GetProofRequests(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ProofRequestResponseDto, error)
-	UpdateProofRequest(ctx context.Context, id string, request *dto.ProofRequestUpdatedRequestDto) error
-	VerifyZKProof(ctx context.Context, id string) (*dto.ProofSubmissionResponseDto, error)
+	GetProofRequests(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.ProofRequestResponseDto, *helper.Pagination, error)
+	UpdateProofRequest(ctx context.Context, id string, claims *dto.ZKClaims, request *dto.ProofRequestUpdatedRequestDto) error
+	VerifyZKProof(ctx context.Context, id string, claims *dto.ZKClaims) (*dto.ProofSubmissionResponseDto, error)
 	CreateProofSubmission(ctx context.Context, proofSubmission *protocol.AuthorizationResponseMessage) (*dto.ProofSubmissionResponseDto, error)
-	GetProofSubmissions(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ProofSubmissionResponseDto, error)
+	GetProofSubmissions(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.ProofSubmissionResponseDto, *helper.Pagination, error)
 }
 
 type ProofService struct {
@@ -149,32 +151,37 @@ func (s *ProofService) CreateProofRequest(
 	}, nil
 }
 
-func (s *ProofService) GetProofRequests(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ProofRequestResponseDto, error) {
+func (s *ProofService) GetProofRequests(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.ProofRequestResponseDto, *helper.Pagination, error) {
 	var (
 		proofRequests []*proof.ProofRequest
+		total         int64
 		err           error
 	)
 	if claims.Role != constant.IdentityIssuerRole {
-		proofRequests, err = s.proofRepo.FindAllProofRequests(ctx)
+		proofRequests, total, err = s.proofRepo.FindAllProofRequests(ctx, spec)
 	} else {
-		proofRequests, err = s.proofRepo.FindAllProofRequestsByVerifierDID(ctx, claims.DID)
+		proofRequests, total, err = s.proofRepo.FindAllProofRequestsByVerifierDID(ctx, claims.DID, spec)
 	}
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.ProofNotFound
+			return nil, nil, &constant.ProofNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resp []*dto.ProofRequestResponseDto
+	var lastID uint
 	for _, item := range proofRequests {
 		resp = append(resp, dto.ToProofRequestResponseDto(item))
+		lastID = item.ID
 	}
-	return resp, nil
+	return resp, spec.Pagination(total, len(proofRequests), lastID), nil
 }
 
-func (s *ProofService) UpdateProofRequest(ctx context.Context, id string, request *dto.ProofRequestUpdatedRequestDto) error {
+// UpdateProofRequest changes the status of one of the caller's proof requests. Requests of other verifiers are
+// reported as not found.
+func (s *ProofService) UpdateProofRequest(ctx context.Context, id string, claims *dto.ZKClaims, request *dto.ProofRequestUpdatedRequestDto) error {
 	entity, err := s.proofRepo.FindProofRequestByPublicId(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -182,12 +189,17 @@ func (s *ProofService) UpdateProofRequest(ctx context.Context, id string, reques
 		}
 		return &constant.InternalServer
 	}
+	if entity.VerifierDID != claims.DID {
+		return &constant.ProofNotFound
+	}
 
 	changes := map[string]interface{}{"status": request.Status}
 	return s.proofRepo.UpdateProofRequest(ctx, entity, changes)
 }
 
-func (s *ProofService) VerifyZKProof(ctx context.Context, id string) (*dto.ProofSubmissionResponseDto, error) {
+// VerifyZKProof verifies a submission made to one of the caller's proof requests. Submissions to requests of other
+// verifiers are reported as not found.
+func (s *ProofService) VerifyZKProof(ctx context.Context, id string, claims *dto.ZKClaims) (*dto.ProofSubmissionResponseDto, error) {
 	proofSubmissionEntity, err := s.proofRepo.FindProofSubmissionByPublicId(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -203,6 +215,9 @@ func (s *ProofService) VerifyZKProof(ctx context.Context, id string) (*dto.Proof
 		}
 		return nil, &constant.InternalServer
 	}
+	if proofRequestEntity.VerifierDID != claims.DID {
+		return nil, &constant.ProofNotFound
+	}
 
 	if proofRequestEntity.Status != constant.ProofRequestActiveStatus {
 		return nil, errors.New("proof request is not active")
@@ -212,12 +227,11 @@ func (s *ProofService) VerifyZKProof(ctx context.Context, id string) (*dto.Proof
 	var proofSubmission protocol.AuthorizationResponseMessage = dto.ToAuthorizationResponse(proofSubmissionEntity)
 	start := time.Now()
 	err = s.verifier.VerifyAuthResponse(ctx, proofSubmission, proofRequest)
-	elapsed := time.Since(start)
-	fmt.Println("Elapsed zk verification:", elapsed)
+	s.logger.WithContext(ctx).Debug("proof submission verified", zap.String("thread_id", proofSubmissionEntity.ThreadID), zap.Duration("elapsed", time.Since(start)), zap.Bool("valid", err == nil))
 	var changes map[string]interface{}
 	var status constant.ProofSubmissionStatus
 	if err != nil {
-		s.logger.Info(fmt.Sprintf("Verify Failed: %s", err))
+		s.logger.WithContext(ctx).Info(fmt.Sprintf("Verify Failed: %s", err))
 		status = constant.ProofSubmissionFailedStatus
 	}
 	status = constant.ProofSubmissionSuccessStatus
@@ -308,27 +322,30 @@ func (s *ProofService) CreateProofSubmission(ctx context.Context, proofSubmissio
 	}, nil
 }
 
-func (s *ProofService) GetProofSubmissions(ctx context.Context, claims *dto.ZKClaims) ([]*dto.ProofSubmissionResponseDto, error) {
+func (s *ProofService) GetProofSubmissions(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.ProofSubmissionResponseDto, *helper.Pagination, error) {
 	var (
 		proofSubmissions []*proof.ProofSubmission
+		total            int64
 		err              error
 	)
 	if claims.Role == constant.IdentityVerifierRole {
-		proofSubmissions, err = s.proofRepo.FindAllProofSubmissionsByVerifierDID(ctx, claims.DID)
+		proofSubmissions, total, err = s.proofRepo.FindAllProofSubmissionsByVerifierDID(ctx, claims.DID, spec)
 	} else if claims.Role == constant.IdentityHolderRole {
-		proofSubmissions, err = s.proofRepo.FindAllProofSubmissionsByHolderDID(ctx, claims.DID)
+		proofSubmissions, total, err = s.proofRepo.FindAllProofSubmissionsByHolderDID(ctx, claims.DID, spec)
 	}
 
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.ProofNotFound
+			return nil, nil, &constant.ProofNotFound
 		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.InternalServer
 	}
 
 	var resp []*dto.ProofSubmissionResponseDto
+	var lastID uint
 	for _, item := range proofSubmissions {
 		resp = append(resp, dto.ToProofSubmissionResponseDto(item))
+		lastID = item.ID
 	}
-	return resp, nil
+	return resp, spec.Pagination(total, len(proofSubmissions), lastID), nil
 }
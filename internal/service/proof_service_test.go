@@ -0,0 +1,93 @@
+package service
+
+import (
+	"be/internal/domain/proof"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+const testVerifierDID = "did:example:verifier"
+
+type fakeProofRepository struct {
+	proof.IProofRepository
+	request    *proof.ProofRequest
+	submission *proof.ProofSubmission
+	updates    int
+}
+
+func (r *fakeProofRepository) FindProofRequestByPublicId(_ context.Context, id string) (*proof.ProofRequest, error) {
+	if id != "request" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.request, nil
+}
+
+func (r *fakeProofRepository) FindProofRequestByThreadId(context.Context, string) (*proof.ProofRequest, error) {
+	return r.request, nil
+}
+
+func (r *fakeProofRepository) FindProofSubmissionByPublicId(_ context.Context, id string) (*proof.ProofSubmission, error) {
+	if id != "submission" {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return r.submission, nil
+}
+
+func (r *fakeProofRepository) UpdateProofRequest(_ context.Context, entity *proof.ProofRequest, changes map[string]interface{}) error {
+	r.updates++
+	entity.Status = changes["status"].(constant.ProofRequestStatus)
+	return nil
+}
+
+func newProofServiceFixture() (*ProofService, *fakeProofRepository) {
+	repo := &fakeProofRepository{
+		request:    &proof.ProofRequest{VerifierDID: testVerifierDID, ThreadID: "thread", Status: constant.ProofRequestActiveStatus},
+		submission: &proof.ProofSubmission{ThreadID: "thread"},
+	}
+	return &ProofService{proofRepo: repo}, repo
+}
+
+func TestUpdateProofRequestOwnership(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		callerDID string
+		wantError error
+	}{
+		{name: "own request", id: "request", callerDID: testVerifierDID},
+		{name: "request of another verifier", id: "request", callerDID: "did:example:other", wantError: &constant.ProofNotFound},
+		{name: "missing request", id: "missing", callerDID: testVerifierDID, wantError: &constant.ProofNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, repo := newProofServiceFixture()
+			request := &dto.ProofRequestUpdatedRequestDto{Status: constant.ProofRequestCancelledStatus}
+
+			err := s.UpdateProofRequest(context.Background(), tt.id, &dto.ZKClaims{DID: tt.callerDID}, request)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("UpdateProofRequest() error = %v, want %v", err, tt.wantError)
+			}
+			wantUpdates := 0
+			if tt.wantError == nil {
+				wantUpdates = 1
+			}
+			if repo.updates != wantUpdates {
+				t.Fatalf("%d updates, want %d", repo.updates, wantUpdates)
+			}
+		})
+	}
+}
+
+func TestVerifyZKProofOfAnotherVerifier(t *testing.T) {
+	s, _ := newProofServiceFixture()
+	// the verifier is never reached, so the fixture does not set one
+	_, err := s.VerifyZKProof(context.Background(), "submission", &dto.ZKClaims{DID: "did:example:other"})
+	if !errors.Is(err, &constant.ProofNotFound) {
+		t.Fatalf("VerifyZKProof() error = %v, want %v", err, &constant.ProofNotFound)
+	}
+}
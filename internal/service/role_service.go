@@ -0,0 +1,428 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAccreditationType = "AccreditationCredential"
+	maxRoleApplicationNote   = 2000
+	// systemGrantor records grants made by the platform itself rather than by an identity
+	systemGrantor = "system"
+)
+
+type IRoleService interface {
+	GrantBootstrapRoles(ctx context.Context, did string) error
+	ApplyForRole(ctx context.Context, request *dto.RoleApplicationCreatedRequestDto) (*dto.RoleApplicationResponseDto, error)
+	GetRoleApplications(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.RoleApplicationResponseDto, *helper.Pagination, error)
+	ReviewRoleApplication(ctx context.Context, id string, request *dto.RoleApplicationReviewedRequestDto) (*dto.RoleApplicationResponseDto, error)
+	GetRoleGrants(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.RoleGrantResponseDto, *helper.Pagination, error)
+	GrantRole(ctx context.Context, request *dto.RoleGrantCreatedRequestDto) (*dto.RoleGrantResponseDto, error)
+	RevokeRole(ctx context.Context, id string, request *dto.RoleGrantRevokedRequestDto) (*dto.RoleGrantResponseDto, error)
+}
+
+type RoleService struct {
+	db                  *postgres.PostgresDB
+	config              *config.Config
+	logger              *logger.ZapLogger
+	identityRepo        schema.IIdentityRepository
+	roleRepo            schema.IIdentityRoleRepository
+	applicationRepo     schema.IRoleApplicationRepository
+	verificationService ICredentialVerificationService
+	notificationService INotificationService
+}
+
+func NewRoleService(
+	db *postgres.PostgresDB,
+	config *config.Config,
+	logger *logger.ZapLogger,
+	identityRepo schema.IIdentityRepository,
+	roleRepo schema.IIdentityRoleRepository,
+	applicationRepo schema.IRoleApplicationRepository,
+	verificationService ICredentialVerificationService,
+	notificationService INotificationService,
+) IRoleService {
+	return &RoleService{
+		db:                  db,
+		config:              config,
+		logger:              logger,
+		identityRepo:        identityRepo,
+		roleRepo:            roleRepo,
+		applicationRepo:     applicationRepo,
+		verificationService: verificationService,
+		notificationService: notificationService,
+	}
+}
+
+func (s *RoleService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		return fn(helper.InjectTx(ctx, tx))
+	})
+}
+
+// GrantBootstrapRoles grants the admin role to a DID listed in the configuration. The configuration stays the
+// authority: an admin grant revoked by hand comes back on the next login while the DID is still listed.
+func (s *RoleService) GrantBootstrapRoles(ctx context.Context, did string) error {
+	if !slices.Contains(s.config.GetAdminDIDs(), did) {
+		return nil
+	}
+	identity, err := s.findIdentity(ctx, did)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(identity.ActiveRoles(), constant.IdentityAdminRole) {
+		return nil
+	}
+	_, err = s.grantRole(ctx, identity, constant.IdentityAdminRole, constant.RoleGrantBootstrapMethod, systemGrantor, nil)
+	if err != nil && !errors.Is(err, &constant.RoleAlreadyGranted) {
+		return toServiceError(err)
+	}
+	return nil
+}
+
+// ApplyForRole files an application for the issuer or verifier role. An application that carries a valid
+// accreditation credential from a root issuer is approved on the spot; any other waits for an admin.
+func (s *RoleService) ApplyForRole(ctx context.Context, request *dto.RoleApplicationCreatedRequestDto) (*dto.RoleApplicationResponseDto, error) {
+	if !isOnboardedRole(request.Role) || len(request.Note) > maxRoleApplicationNote {
+		return nil, &constant.RoleInvalid
+	}
+	identity, err := s.findIdentity(ctx, request.DID)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(identity.ActiveRoles(), request.Role) {
+		return nil, &constant.RoleAlreadyGranted
+	}
+
+	entity := &schema.RoleApplication{
+		PublicID:   uuid.New(),
+		IdentityID: identity.ID,
+		DID:        identity.DID,
+		Role:       request.Role,
+		Status:     constant.RoleApplicationPendingStatus,
+		Note:       request.Note,
+	}
+	if request.Credential != nil {
+		if err := s.verifyAccreditation(ctx, identity.DID, request.Role, request.Credential); err != nil {
+			s.logger.WithContext(ctx).Info("accreditation credential rejected", zap.String("did", identity.DID), zap.Error(err))
+			return nil, &constant.AccreditationCredentialInvalid
+		}
+		credentialJSON, err := toJSONMap(request.Credential)
+		if err != nil {
+			return nil, &constant.AccreditationCredentialInvalid
+		}
+		reviewedAt := time.Now().UTC()
+		entity.Status = constant.RoleApplicationApprovedStatus
+		entity.Credential = credentialJSON
+		entity.ReviewedBy = request.Credential.Issuer
+		entity.ReviewedAt = &reviewedAt
+	}
+
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		pending, err := s.applicationRepo.ExistsPendingRoleApplication(ctx, identity.ID, request.Role)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return &constant.RoleApplicationPending
+		}
+		if _, err := s.applicationRepo.CreateRoleApplication(ctx, entity); err != nil {
+			return err
+		}
+		if entity.Status != constant.RoleApplicationApprovedStatus {
+			return nil
+		}
+		_, err = s.grantRole(ctx, identity, entity.Role, constant.RoleGrantAccreditationMethod, entity.ReviewedBy, &entity.ID)
+		return err
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return dto.ToRoleApplicationResponseDto(entity), nil
+}
+
+// verifyAccreditation accepts a credential issued by a root issuer managed here, of the accreditation type, about
+// the applicant and naming the role applied for, that passes every verification check. A skipped check is a
+// rejection: the issuer state, revocation and issuer record must all have been checked.
+func (s *RoleService) verifyAccreditation(ctx context.Context, did string, role constant.IdentityRole, vc *verifiable.W3CCredential) error {
+	if !slices.Contains(s.config.GetRootIssuerDIDs(), vc.Issuer) {
+		return fmt.Errorf("issuer %s is not a root issuer", vc.Issuer)
+	}
+	if _, err := s.identityRepo.FindIdentityByDID(ctx, vc.Issuer); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("root issuer %s is not managed by this deployment", vc.Issuer)
+		}
+		return err
+	}
+	accreditationType := s.config.Role.AccreditationType
+	if accreditationType == "" {
+		accreditationType = defaultAccreditationType
+	}
+	if !slices.Contains(vc.Type, accreditationType) {
+		return fmt.Errorf("credential is not of type %s", accreditationType)
+	}
+	if subjectID, _ := vc.CredentialSubject["id"].(string); subjectID != did {
+		return errors.New("credential is about another identity")
+	}
+	if subjectRole, _ := vc.CredentialSubject["role"].(string); subjectRole != string(role) {
+		return fmt.Errorf("credential does not accredit the %s role", role)
+	}
+
+	result, err := s.verificationService.VerifyCredential(ctx, vc)
+	if err != nil {
+		return err
+	}
+	for _, check := range result.Checks {
+		switch check.Status {
+		case constant.CredentialCheckFailedStatus:
+			return fmt.Errorf("check %s failed: %s", check.Name, check.Message)
+		case constant.CredentialCheckSkippedStatus:
+			return fmt.Errorf("check %s was skipped: %s", check.Name, check.Message)
+		}
+	}
+	for _, name := range bindingChecks {
+		if !slices.ContainsFunc(result.Checks, func(check *dto.CredentialCheckDto) bool {
+			return check.Name == name && check.Status == constant.CredentialCheckPassedStatus
+		}) {
+			return fmt.Errorf("check %s did not pass", name)
+		}
+	}
+	if !result.Valid {
+		return errors.New("credential is not valid")
+	}
+	return nil
+}
+
+// GetRoleApplications lists every application to admins, and their own applications to everyone else.
+func (s *RoleService) GetRoleApplications(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.RoleApplicationResponseDto, *helper.Pagination, error) {
+	var (
+		entities []*schema.RoleApplication
+		total    int64
+		err      error
+	)
+	if slices.Contains(claims.Roles, constant.IdentityAdminRole) {
+		entities, total, err = s.applicationRepo.FindAllRoleApplications(ctx, spec)
+	} else {
+		entities, total, err = s.applicationRepo.FindAllRoleApplicationsByDID(ctx, claims.DID, spec)
+	}
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.RoleApplicationResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToRoleApplicationResponseDto(item))
+		lastID = item.ID
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+// ReviewRoleApplication approves or rejects a pending application. Admins cannot review their own applications.
+func (s *RoleService) ReviewRoleApplication(ctx context.Context, id string, request *dto.RoleApplicationReviewedRequestDto) (*dto.RoleApplicationResponseDto, error) {
+	if request.Status != constant.RoleApplicationApprovedStatus && request.Status != constant.RoleApplicationRejectedStatus {
+		return nil, &constant.BadRequest
+	}
+
+	found, err := s.applicationRepo.FindRoleApplicationByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.RoleApplicationNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if found.DID == request.ReviewedBy {
+		return nil, &constant.Forbidden
+	}
+
+	var entity *schema.RoleApplication
+	reviewedAt := time.Now().UTC()
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		var err error
+		entity, err = s.applicationRepo.LockRoleApplication(ctx, found.ID)
+		if err != nil {
+			return err
+		}
+		if entity.Status != constant.RoleApplicationPendingStatus {
+			return &constant.RoleApplicationReviewed
+		}
+		if err := s.applicationRepo.UpdateRoleApplication(ctx, entity, map[string]interface{}{
+			"status":      request.Status,
+			"review_note": request.Note,
+			"reviewed_by": request.ReviewedBy,
+			"reviewed_at": reviewedAt,
+		}); err != nil {
+			return err
+		}
+
+		kind := constant.RoleRejectedNotification
+		message := fmt.Sprintf("Your application for the %s role was rejected", entity.Role)
+		if request.Status == constant.RoleApplicationApprovedStatus {
+			identity, err := s.findIdentity(ctx, entity.DID)
+			if err != nil {
+				return err
+			}
+			// a role granted meanwhile by other means still approves the application
+			if !slices.Contains(identity.ActiveRoles(), entity.Role) {
+				if _, err := s.grantRole(ctx, identity, entity.Role, constant.RoleGrantAdminApprovalMethod, request.ReviewedBy, &entity.ID); err != nil {
+					return err
+				}
+			}
+			kind = constant.RoleApprovedNotification
+			message = fmt.Sprintf("Your application for the %s role was approved", entity.Role)
+		}
+		if request.Note != "" {
+			message += ": " + request.Note
+		}
+		return s.notificationService.Notify(ctx, entity.DID, kind, message, entity.PublicID.String())
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	entity.Status = request.Status
+	entity.ReviewNote = request.Note
+	entity.ReviewedBy = request.ReviewedBy
+	entity.ReviewedAt = &reviewedAt
+	return dto.ToRoleApplicationResponseDto(entity), nil
+}
+
+// GetRoleGrants lists the grant history: all of it to admins, optionally narrowed by DID, and their own to everyone
+// else.
+func (s *RoleService) GetRoleGrants(ctx context.Context, claims *dto.ZKClaims, spec *helper.QuerySpec) ([]*dto.RoleGrantResponseDto, *helper.Pagination, error) {
+	var (
+		entities []*schema.IdentityRoleGrant
+		total    int64
+		err      error
+	)
+	if slices.Contains(claims.Roles, constant.IdentityAdminRole) {
+		entities, total, err = s.roleRepo.FindAllRoleGrants(ctx, spec)
+	} else {
+		entities, total, err = s.roleRepo.FindAllRoleGrantsByDID(ctx, claims.DID, spec)
+	}
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	resp := make([]*dto.RoleGrantResponseDto, 0, len(entities))
+	var lastID uint
+	for _, item := range entities {
+		resp = append(resp, dto.ToRoleGrantResponseDto(item))
+		lastID = item.ID
+	}
+	return resp, spec.Pagination(total, len(entities), lastID), nil
+}
+
+// GrantRole lets an admin grant a role directly, without an application. Admins cannot grant roles to themselves.
+func (s *RoleService) GrantRole(ctx context.Context, request *dto.RoleGrantCreatedRequestDto) (*dto.RoleGrantResponseDto, error) {
+	if !isOnboardedRole(request.Role) && request.Role != constant.IdentityHolderRole && request.Role != constant.IdentityAdminRole {
+		return nil, &constant.RoleInvalid
+	}
+	if request.DID == request.GrantedBy {
+		return nil, &constant.Forbidden
+	}
+	identity, err := s.findIdentity(ctx, request.DID)
+	if err != nil {
+		return nil, err
+	}
+
+	var grant *schema.IdentityRoleGrant
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		grant, err = s.grantRole(ctx, identity, request.Role, constant.RoleGrantAdminApprovalMethod, request.GrantedBy, nil)
+		return err
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+	return dto.ToRoleGrantResponseDto(grant), nil
+}
+
+// RevokeRole ends an active grant and keeps it as history. Tokens already issued keep the role until they are
+// refreshed, which reloads the roles.
+func (s *RoleService) RevokeRole(ctx context.Context, id string, request *dto.RoleGrantRevokedRequestDto) (*dto.RoleGrantResponseDto, error) {
+	var grant *schema.IdentityRoleGrant
+	revokedAt := time.Now().UTC()
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		var err error
+		grant, err = s.roleRepo.FindRoleGrantByPublicId(ctx, id)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return &constant.RoleGrantNotFound
+			}
+			return err
+		}
+		// a grant that is already revoked is history, there is nothing left to revoke
+		if grant.RevokedAt != nil {
+			return &constant.RoleGrantNotFound
+		}
+		if err := s.roleRepo.UpdateRoleGrant(ctx, grant, map[string]interface{}{
+			"revoked_by":    request.RevokedBy,
+			"revoke_reason": request.Reason,
+			"revoked_at":    revokedAt,
+		}); err != nil {
+			return err
+		}
+		message := fmt.Sprintf("Your %s role was revoked: %s", grant.Role, request.Reason)
+		return s.notificationService.Notify(ctx, grant.DID, constant.RoleRevokedNotification, message, grant.PublicID.String())
+	})
+	if err != nil {
+		return nil, toServiceError(err)
+	}
+
+	grant.RevokedBy = request.RevokedBy
+	grant.RevokeReason = request.Reason
+	grant.RevokedAt = &revokedAt
+	return dto.ToRoleGrantResponseDto(grant), nil
+}
+
+func (s *RoleService) findIdentity(ctx context.Context, did string) (*schema.Identity, error) {
+	identity, err := s.identityRepo.FindIdentityByDID(ctx, did)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.IdentityNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	return identity, nil
+}
+
+func (s *RoleService) grantRole(ctx context.Context, identity *schema.Identity, role constant.IdentityRole, method constant.RoleGrantMethod, grantedBy string, applicationID *uint) (*schema.IdentityRoleGrant, error) {
+	granted, err := s.roleRepo.ExistsActiveRoleGrant(ctx, identity.ID, role)
+	if err != nil {
+		return nil, err
+	}
+	if granted {
+		return nil, &constant.RoleAlreadyGranted
+	}
+	return s.roleRepo.CreateRoleGrant(ctx, &schema.IdentityRoleGrant{
+		PublicID:      uuid.New(),
+		IdentityID:    identity.ID,
+		DID:           identity.DID,
+		Role:          role,
+		Method:        method,
+		ApplicationID: applicationID,
+		GrantedBy:     grantedBy,
+		GrantedAt:     time.Now().UTC(),
+	})
+}
+
+// isOnboardedRole reports whether a role is obtained through an application.
+func isOnboardedRole(role constant.IdentityRole) bool {
+	return role == constant.IdentityIssuerRole || role == constant.IdentityVerifierRole
+}
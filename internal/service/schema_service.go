@@ -3,9 +3,12 @@ package service
 import (
 	"be/config"
 	"be/internal/domain/schema"
+	"be/internal/infrastructure/database/postgres"
 	"be/internal/infrastructure/ipfs"
 	"be/internal/shared/constant"
+	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
+	"be/pkg/logger"
 	"context"
 	"encoding/json"
 	"errors"
@@ -17,38 +20,58 @@ import (
 	"github.com/google/uuid"
 	core "github.com/iden3/go-iden3-core/v2"
 	"github.com/iden3/go-iden3-crypto/keccak256"
+	"go.uber.org/zap"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
 type ISchemaService interface {
-	GetSchemas(ctx context.Context) ([]*dto.SchemaResponseDto, error)
+	GetSchemas(ctx context.Context, request *dto.SchemaFilterDto) ([]*dto.SchemaResponseDto, *helper.CursorPagination, error)
 	GetSchemaByPublicId(ctx context.Context, id string) (*dto.SchemaResponseDto, error)
 	GetSchemaAttributesBySchemaId(ctx context.Context, id string) ([]*dto.SchemaAttributeDto, error)
 	CreateSchema(ctx context.Context, request *dto.SchemaBuilderDto) (*dto.SchemaResponseDto, error)
 	RemoveSchema(ctx context.Context, id string) error
+	UpdateSchemaMappings(ctx context.Context, id string, issuerDID string, request []*dto.SchemaAttributeMappingDto) ([]*dto.SchemaAttributeDto, error)
+	ReindexSchemas(ctx context.Context) (int, error)
 }
+
+const (
+	defaultSchemaPageSize = 20
+	maxSchemaPageSize     = 100
+	schemaReindexPageSize = 100
+)
+
 type SchemaService struct {
 	config              *config.Config
+	logger              *logger.ZapLogger
+	db                  *postgres.PostgresDB
 	ipfs                *ipfs.Pinata
 	identityRepo        schema.IIdentityRepository
 	schemaRepo          schema.ISchemaRepository
 	schemaAttributeRepo schema.ISchemaAttributeRepository
+	schemaSearchRepo    schema.ISchemaSearchRepository
 }
 
 func NewSchemaService(
 	config *config.Config,
+	logger *logger.ZapLogger,
+	db *postgres.PostgresDB,
 	pinata *ipfs.Pinata,
 	identityRepo schema.IIdentityRepository,
 	schemaRepo schema.ISchemaRepository,
 	schemaAttributeRepo schema.ISchemaAttributeRepository,
+	schemaSearchRepo schema.ISchemaSearchRepository,
 ) ISchemaService {
 
 	return &SchemaService{
 		config:              config,
+		logger:              logger,
+		db:                  db,
 		ipfs:                pinata,
 		identityRepo:        identityRepo,
 		schemaRepo:          schemaRepo,
 		schemaAttributeRepo: schemaAttributeRepo,
+		schemaSearchRepo:    schemaSearchRepo,
 	}
 }
 
@@ -64,20 +87,52 @@ func (s *SchemaService) GetSchemaByPublicId(ctx context.Context, id string) (*dt
 	return dto.ToSchemaResponseDto(schema), nil
 }
 
-func (s *SchemaService) GetSchemas(ctx context.Context) ([]*dto.SchemaResponseDto, error) {
-	schemas, err := s.schemaRepo.FindAllSchemas(ctx)
+func (s *SchemaService) GetSchemas(ctx context.Context, request *dto.SchemaFilterDto) ([]*dto.SchemaResponseDto, *helper.CursorPagination, error) {
+	cursor, err := helper.DecodeCursor(request.Cursor)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, &constant.SchemaNotFound
-		}
-		return nil, &constant.InternalServer
+		return nil, nil, &constant.BadRequest
+	}
+	limit := request.Limit
+	if limit <= 0 || limit > maxSchemaPageSize {
+		limit = defaultSchemaPageSize
+	}
+
+	schemas, err := s.searchSchemas(ctx, &schema.SchemaFilter{
+		IssuerDID:    request.IssuerDID,
+		DocumentType: request.DocumentType,
+		Status:       request.Status,
+		Type:         request.Type,
+		IsMerklized:  request.IsMerklized,
+		Query:        strings.TrimSpace(request.Query),
+		Cursor:       cursor,
+		Limit:        limit + 1,
+	})
+	if err != nil {
+		return nil, nil, &constant.InternalServer
+	}
+
+	pagination := &helper.CursorPagination{Limit: limit}
+	if len(schemas) > limit {
+		schemas = schemas[:limit]
+		pagination.NextCursor = helper.EncodeCursor(schemas[limit-1].ID)
 	}
 
 	var schemaDtos []*dto.SchemaResponseDto
-	for _, schema := range schemas {
-		schemaDtos = append(schemaDtos, dto.ToSchemaResponseDto(schema))
+	for _, item := range schemas {
+		schemaDtos = append(schemaDtos, dto.ToSchemaResponseDto(item))
+	}
+	return schemaDtos, pagination, nil
+}
+
+// searchSchemas prefers the Elasticsearch index for text queries and falls back to
+// Postgres full-text search when the index is disabled or unreachable.
+func (s *SchemaService) searchSchemas(ctx context.Context, filter *schema.SchemaFilter) ([]*schema.Schema, error) {
+	if filter.Query != "" && s.schemaSearchRepo.IsAvailable() {
+		if ids, err := s.schemaSearchRepo.SearchSchemaIds(ctx, filter); err == nil {
+			return s.schemaRepo.FindSchemasByIds(ctx, ids)
+		}
 	}
-	return schemaDtos, nil
+	return s.schemaRepo.SearchSchemas(ctx, filter)
 }
 
 func (s *SchemaService) GetSchemaAttributesBySchemaId(ctx context.Context, id string) ([]*dto.SchemaAttributeDto, error) {
@@ -91,11 +146,14 @@ func (s *SchemaService) GetSchemaAttributesBySchemaId(ctx context.Context, id st
 	var resp []*dto.SchemaAttributeDto
 	for _, item := range schema.SchemaAttributes {
 		resp = append(resp, &dto.SchemaAttributeDto{
-			Name:        item.Name,
-			Title:       item.Title,
-			Type:        item.Type,
-			Description: item.Description,
-			Slot:        item.Slot,
+			Name:          item.Name,
+			Title:         item.Title,
+			Type:          item.Type,
+			Description:   item.Description,
+			Slot:          item.Slot,
+			DocumentField: item.DocumentField,
+			Transform:     item.Transform,
+			EnumCodes:     item.EnumCodes,
 		})
 	}
 	return resp, nil
@@ -155,13 +213,16 @@ func (s *SchemaService) CreateSchema(ctx context.Context, request *dto.SchemaBui
 	var attributeEntities []*schema.SchemaAttribute
 	for _, item := range request.Attributes {
 		attributeEntities = append(attributeEntities, &schema.SchemaAttribute{
-			PublicID:    uuid.New(),
-			Name:        item.Name,
-			Title:       item.Title,
-			Type:        item.Type,
-			Required:    item.Required,
-			Description: item.Description,
-			Slot:        item.Slot,
+			PublicID:      uuid.New(),
+			Name:          item.Name,
+			Title:         item.Title,
+			Type:          item.Type,
+			Required:      item.Required,
+			Description:   item.Description,
+			Slot:          item.Slot,
+			DocumentField: item.DocumentField,
+			Transform:     mappingTransform(item.Transform),
+			EnumCodes:     item.EnumCodes,
 		})
 	}
 
@@ -171,6 +232,7 @@ func (s *SchemaService) CreateSchema(ctx context.Context, request *dto.SchemaBui
 		return nil, &constant.InternalServer
 	}
 	schemaCreated.Issuer = issuer
+	s.indexSchema(ctx, schemaCreated)
 	return dto.ToSchemaResponseDto(schemaCreated), nil
 }
 
@@ -204,7 +266,137 @@ func (s *SchemaService) RemoveSchema(ctx context.Context, id string) error {
 	if err := s.schemaRepo.UpdateSchema(ctx, schema, changes); err != nil {
 		return err
 	}
+	schema.Status = constant.SchemaRevokeStatus
+	s.indexSchema(ctx, schema)
+
+	return nil
+}
+
+// indexSchema updates the search document of a schema. A failure only leaves search stale until the next
+// reindex, so it is logged rather than returned.
+func (s *SchemaService) indexSchema(ctx context.Context, entity *schema.Schema) {
+	if err := s.schemaSearchRepo.IndexSchema(ctx, entity); err != nil {
+		s.logger.WithContext(ctx).Warn("failed to index schema", zap.String("schema_id", entity.PublicID.String()), zap.Error(err))
+	}
+}
+
+// ReindexSchemas writes every schema to the search index, repairing the documents whose indexing failed when the
+// schema was created or revoked. It does nothing while the index is unavailable.
+func (s *SchemaService) ReindexSchemas(ctx context.Context) (int, error) {
+	if !s.schemaSearchRepo.IsAvailable() {
+		return 0, nil
+	}
+	var (
+		afterId uint
+		indexed int
+	)
+	for {
+		schemas, err := s.schemaRepo.FindSchemasAfterId(ctx, afterId, schemaReindexPageSize)
+		if err != nil {
+			return indexed, err
+		}
+		for _, item := range schemas {
+			if err := s.schemaSearchRepo.IndexSchema(ctx, item); err != nil {
+				return indexed, err
+			}
+			indexed++
+		}
+		if len(schemas) < schemaReindexPageSize {
+			return indexed, nil
+		}
+		afterId = schemas[len(schemas)-1].ID
+	}
+}
+
+// UpdateSchemaMappings maps the attributes of one of the issuer's schemas to document fields. Either every mapping is
+// updated or none is; schemas of other issuers are reported as not found.
+func (s *SchemaService) UpdateSchemaMappings(ctx context.Context, id string, issuerDID string, request []*dto.SchemaAttributeMappingDto) ([]*dto.SchemaAttributeDto, error) {
+	schemaEntity, err := s.schemaRepo.FindSchemaByPublicId(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, &constant.SchemaNotFound
+		}
+		return nil, &constant.InternalServer
+	}
+	if schemaEntity.IssuerDID != issuerDID {
+		return nil, &constant.SchemaNotFound
+	}
+
+	attributes := make(map[string]*schema.SchemaAttribute, len(schemaEntity.SchemaAttributes))
+	for _, item := range schemaEntity.SchemaAttributes {
+		attributes[item.Name] = item
+	}
+	for _, item := range request {
+		if _, ok := attributes[item.Name]; !ok {
+			return nil, &constant.SchemaAttributeNotFound
+		}
+		if err := validateMapping(item.DocumentField, item.Transform, item.EnumCodes); err != nil {
+			return nil, err
+		}
+	}
+
+	err = helper.WithTx(ctx, s.db.GetGormDB()).Transaction(func(tx *gorm.DB) error {
+		txCtx := helper.InjectTx(ctx, tx)
+		for _, item := range request {
+			changes := map[string]interface{}{
+				"document_field": item.DocumentField,
+				"transform":      mappingTransform(item.Transform),
+				"enum_codes":     datatypes.JSONMap(item.EnumCodes),
+			}
+			if err := s.schemaAttributeRepo.UpdateSchemaAttribute(txCtx, attributes[item.Name], changes); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, &constant.InternalServer
+	}
+	for _, item := range request {
+		attribute := attributes[item.Name]
+		attribute.DocumentField = item.DocumentField
+		attribute.Transform = mappingTransform(item.Transform)
+		attribute.EnumCodes = item.EnumCodes
+	}
 
+	var resp []*dto.SchemaAttributeDto
+	for _, item := range schemaEntity.SchemaAttributes {
+		resp = append(resp, &dto.SchemaAttributeDto{
+			Name:          item.Name,
+			Title:         item.Title,
+			Type:          item.Type,
+			Description:   item.Description,
+			Slot:          item.Slot,
+			DocumentField: item.DocumentField,
+			Transform:     item.Transform,
+			EnumCodes:     item.EnumCodes,
+		})
+	}
+	return resp, nil
+}
+
+// mappingTransform returns the transform stored for an attribute; an attribute without one is stored as none.
+func mappingTransform(transform constant.MappingTransform) constant.MappingTransform {
+	if transform == "" {
+		return constant.MappingTransformNone
+	}
+	return transform
+}
+
+func validateMapping(documentField string, transform constant.MappingTransform, enumCodes map[string]interface{}) error {
+	transform = mappingTransform(transform)
+	switch transform {
+	case constant.MappingTransformNone, constant.MappingTransformDate:
+	case constant.MappingTransformEnum:
+		if len(enumCodes) == 0 {
+			return &constant.SchemaMappingInvalid
+		}
+	default:
+		return &constant.SchemaMappingInvalid
+	}
+	if documentField == "" && transform != constant.MappingTransformNone {
+		return &constant.SchemaMappingInvalid
+	}
 	return nil
 }
 
@@ -234,6 +426,10 @@ func (s *SchemaService) validate(request *dto.SchemaBuilderDto) error {
 			return fmt.Errorf("duplicate attribute name: %s", attr.Name)
 		}
 		seen[attr.Name] = true
+
+		if err := validateMapping(attr.DocumentField, attr.Transform, attr.EnumCodes); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,183 @@
+package service
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeSchemaRepository struct {
+	schema.ISchemaRepository
+	schemas []*schema.Schema
+}
+
+func (r *fakeSchemaRepository) FindSchemaByPublicId(_ context.Context, publicId string) (*schema.Schema, error) {
+	for _, entity := range r.schemas {
+		if entity.PublicID.String() == publicId {
+			return entity, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeSchemaRepository) FindSchemasAfterId(_ context.Context, afterId uint, limit int) ([]*schema.Schema, error) {
+	page := make([]*schema.Schema, 0, limit)
+	for _, entity := range r.schemas {
+		if entity.ID > afterId && len(page) < limit {
+			page = append(page, entity)
+		}
+	}
+	return page, nil
+}
+
+type fakeSchemaSearchRepository struct {
+	schema.ISchemaSearchRepository
+	unavailable bool
+	indexed     []uint
+	failOn      uint
+}
+
+func (r *fakeSchemaSearchRepository) IsAvailable() bool {
+	return !r.unavailable
+}
+
+func (r *fakeSchemaSearchRepository) IndexSchema(_ context.Context, entity *schema.Schema) error {
+	if entity.ID == r.failOn {
+		return errors.New("index unreachable")
+	}
+	r.indexed = append(r.indexed, entity.ID)
+	return nil
+}
+
+type fakeSchemaAttributeRepository struct {
+	schema.ISchemaAttributeRepository
+	updates []map[string]interface{}
+}
+
+func (r *fakeSchemaAttributeRepository) UpdateSchemaAttribute(_ context.Context, _ *schema.SchemaAttribute, changes map[string]interface{}) error {
+	r.updates = append(r.updates, changes)
+	return nil
+}
+
+func TestValidateMapping(t *testing.T) {
+	tests := []struct {
+		name          string
+		documentField string
+		transform     constant.MappingTransform
+		enumCodes     map[string]interface{}
+		wantErr       bool
+	}{
+		{name: "unmapped"},
+		{name: "unmapped with none", transform: constant.MappingTransformNone},
+		{name: "field without transform", documentField: "first_name"},
+		{name: "date", documentField: "date_of_birth", transform: constant.MappingTransformDate},
+		{name: "enum", documentField: "gender", transform: constant.MappingTransformEnum, enumCodes: map[string]interface{}{"male": 1}},
+		{name: "enum without codes", documentField: "gender", transform: constant.MappingTransformEnum, wantErr: true},
+		{name: "transform without field", transform: constant.MappingTransformDate, wantErr: true},
+		{name: "unknown transform", documentField: "first_name", transform: "upper", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMapping(tt.documentField, tt.transform, tt.enumCodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateMapping(%q, %q) error = %v, want error %v", tt.documentField, tt.transform, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUpdateSchemaMappings(t *testing.T) {
+	tests := []struct {
+		name      string
+		issuerDID string
+		request   []*dto.SchemaAttributeMappingDto
+		wantError error
+		// wantTransforms are the transforms stored, in request order
+		wantTransforms []constant.MappingTransform
+	}{
+		{name: "stores no transform as none", issuerDID: testIssuerDID, request: []*dto.SchemaAttributeMappingDto{
+			{Name: "firstName", DocumentField: "first_name"},
+			{Name: "birthday", DocumentField: "date_of_birth", Transform: constant.MappingTransformDate},
+		}, wantTransforms: []constant.MappingTransform{constant.MappingTransformNone, constant.MappingTransformDate}},
+		{name: "schema of another issuer", issuerDID: "did:example:other", request: []*dto.SchemaAttributeMappingDto{
+			{Name: "firstName", DocumentField: "first_name"},
+		}, wantError: &constant.SchemaNotFound},
+		{name: "unknown attribute updates nothing", issuerDID: testIssuerDID, request: []*dto.SchemaAttributeMappingDto{
+			{Name: "firstName", DocumentField: "first_name"},
+			{Name: "nickname", DocumentField: "nickname"},
+		}, wantError: &constant.SchemaAttributeNotFound},
+		{name: "invalid mapping updates nothing", issuerDID: testIssuerDID, request: []*dto.SchemaAttributeMappingDto{
+			{Name: "firstName", DocumentField: "first_name"},
+			{Name: "birthday", Transform: constant.MappingTransformDate},
+		}, wantError: &constant.SchemaMappingInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schemaEntity := &schema.Schema{PublicID: uuid.New(), IssuerDID: testIssuerDID, SchemaAttributes: []*schema.SchemaAttribute{
+				{Name: "firstName"}, {Name: "birthday"},
+			}}
+			attributes := &fakeSchemaAttributeRepository{}
+			s := &SchemaService{
+				db:                  newTestDB(t),
+				schemaRepo:          &fakeSchemaRepository{schemas: []*schema.Schema{schemaEntity}},
+				schemaAttributeRepo: attributes,
+			}
+
+			_, err := s.UpdateSchemaMappings(context.Background(), schemaEntity.PublicID.String(), tt.issuerDID, tt.request)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("UpdateSchemaMappings() error = %v, want %v", err, tt.wantError)
+			}
+			if len(attributes.updates) != len(tt.wantTransforms) {
+				t.Fatalf("%d attributes updated, want %d", len(attributes.updates), len(tt.wantTransforms))
+			}
+			for i, changes := range attributes.updates {
+				if changes["transform"] != tt.wantTransforms[i] {
+					t.Fatalf("update %d stores transform %v, want %v", i, changes["transform"], tt.wantTransforms[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReindexSchemas(t *testing.T) {
+	schemas := make([]*schema.Schema, 0, 2*schemaReindexPageSize+1)
+	for id := uint(1); id <= 2*schemaReindexPageSize+1; id++ {
+		schemas = append(schemas, &schema.Schema{ID: id, PublicID: uuid.New()})
+	}
+	tests := []struct {
+		name        string
+		schemas     []*schema.Schema
+		unavailable bool
+		failOn      uint
+		want        int
+		wantErr     bool
+	}{
+		{name: "every page", schemas: schemas, want: len(schemas)},
+		{name: "exactly one page", schemas: schemas[:schemaReindexPageSize], want: schemaReindexPageSize},
+		{name: "no schemas", want: 0},
+		{name: "index unavailable", schemas: schemas, unavailable: true, want: 0},
+		{name: "index failure stops the run", schemas: schemas, failOn: schemaReindexPageSize + 2, want: schemaReindexPageSize + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searchRepo := &fakeSchemaSearchRepository{unavailable: tt.unavailable, failOn: tt.failOn}
+			s := &SchemaService{schemaRepo: &fakeSchemaRepository{schemas: tt.schemas}, schemaSearchRepo: searchRepo}
+
+			got, err := s.ReindexSchemas(context.Background())
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Fatalf("ReindexSchemas() = %d, %v, want %d and error %v", got, err, tt.want, tt.wantErr)
+			}
+			for i, id := range searchRepo.indexed {
+				if id != uint(i+1) {
+					t.Fatalf("schema %d indexed in place %d", id, i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/infrastructure/database/postgres"
+	"be/pkg/logger"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/iden3/go-merkletree-sql/v2"
+	"github.com/iden3/go-merkletree-sql/v2/db/memory"
+	gormpostgres "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// nopDriver is a database/sql driver whose transactions begin and commit without a database. Services under test
+// run their transactions on it while fake repositories hold the data; any statement that reaches it fails.
+type nopDriver struct{}
+
+type nopConn struct{}
+
+type nopTx struct{}
+
+func (nopDriver) Open(string) (driver.Conn, error) { return nopConn{}, nil }
+
+func (nopConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("unexpected statement: " + query)
+}
+
+func (nopConn) Close() error { return nil }
+
+func (nopConn) Begin() (driver.Tx, error) { return nopTx{}, nil }
+
+func (nopTx) Commit() error { return nil }
+
+func (nopTx) Rollback() error { return nil }
+
+var registerNopDriver sync.Once
+
+func newTestDB(t *testing.T) *postgres.PostgresDB {
+	t.Helper()
+	registerNopDriver.Do(func() { sql.Register("nop", nopDriver{}) })
+	sqlDB, err := sql.Open("nop", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gormDB, err := gorm.Open(gormpostgres.New(gormpostgres.Config{Conn: sqlDB}), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return postgres.NewGormOnlyDB(gormDB)
+}
+
+func newTestLogger(t *testing.T) *logger.ZapLogger {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Zap.Level = "fatal"
+	log, err := logger.NewLogger(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return log
+}
+
+// newTestIdentityState returns an identity whose trees are kept in memory.
+func newTestIdentityState(t *testing.T) *IdentityState {
+	t.Helper()
+	newTree := func() *merkletree.MerkleTree {
+		mt, err := merkletree.NewMerkleTree(context.Background(), memory.NewMemoryStorage(), 40)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mt
+	}
+	return &IdentityState{ClaimsTree: newTree(), RevTree: newTree(), RootsTree: newTree()}
+}
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"be/config"
+	"be/internal/domain/auth"
+	"be/internal/shared/utils"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	signingKeySealPurpose = "jwt-signing-keys"
+	// how long the loaded key ring is trusted before it is read again, so instances pick up rotated keys
+	signingKeyReloadInterval = time.Minute
+	// an unknown kid reloads the ring at most this often, so forged tokens cannot hammer the database
+	signingKeyMissReloadInterval = 5 * time.Second
+
+	defaultSigningAlgorithm  = "ES256"
+	defaultKeyRotationPeriod = 30 * 24 * time.Hour
+	defaultKeyPublishDelay   = 15 * time.Minute
+)
+
+var signingMethods = map[string]jwt.SigningMethod{
+	jwt.SigningMethodES256.Alg(): jwt.SigningMethodES256,
+	jwt.SigningMethodEdDSA.Alg(): jwt.SigningMethodEdDSA,
+}
+
+type ISigningKeyService interface {
+	SignToken(claims jwt.Claims) (string, error)
+	ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error)
+	GetJWKS(ctx context.Context) (*dto.JWKSDto, error)
+	RotateKeys(ctx context.Context) (bool, error)
+}
+
+type signingKey struct {
+	kid         string
+	method      jwt.SigningMethod
+	private     crypto.Signer
+	activatedAt time.Time
+}
+
+type SigningKeyService struct {
+	config  *config.Config
+	logger  *logger.ZapLogger
+	keyRepo auth.ISigningKeyRepository
+	sealKey []byte
+
+	mu       sync.RWMutex
+	keys     []*signingKey
+	loadedAt time.Time
+}
+
+func NewSigningKeyService(
+	config *config.Config,
+	logger *logger.ZapLogger,
+	keyRepo auth.ISigningKeyRepository,
+) (ISigningKeyService, error) {
+	sealKey, err := utils.DeriveSealKey(config.JWT.Secret, signingKeySealPurpose)
+	if err != nil {
+		return nil, fmt.Errorf("jwt.secret is required to protect the signing keys: %w", err)
+	}
+	if _, ok := signingMethods[signingAlgorithm(config)]; !ok {
+		return nil, fmt.Errorf("unsupported jwt.signing_algorithm %q", config.JWT.SigningAlgorithm)
+	}
+	return &SigningKeyService{
+		config:  config,
+		logger:  logger,
+		keyRepo: keyRepo,
+		sealKey: sealKey,
+	}, nil
+}
+
+func signingAlgorithm(config *config.Config) string {
+	if config.JWT.SigningAlgorithm == "" {
+		return defaultSigningAlgorithm
+	}
+	return config.JWT.SigningAlgorithm
+}
+
+func durationOr(value, fallback time.Duration) time.Duration {
+	if value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// SignToken signs claims with the newest key that is already active and names it in the kid header.
+func (s *SigningKeyService) SignToken(claims jwt.Claims) (string, error) {
+	keys, err := s.loadKeys(context.Background(), false)
+	if err != nil {
+		return "", err
+	}
+	key := currentSigningKey(keys, time.Now())
+	if key == nil {
+		// first start, or every key expired: create one that signs right away
+		if _, err := s.createKey(context.Background(), time.Now().UTC()); err != nil {
+			return "", err
+		}
+		if keys, err = s.loadKeys(context.Background(), true); err != nil {
+			return "", err
+		}
+		if key = currentSigningKey(keys, time.Now()); key == nil {
+			return "", errors.New("no active signing key")
+		}
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	return token.SignedString(key.private)
+}
+
+// ParseToken verifies a token against the key its kid names. Only the asymmetric algorithms of the ring are
+// accepted, so a token signed with a shared secret is always rejected.
+func (s *SigningKeyService) ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, s.verificationKey, jwt.WithValidMethods(validSigningAlgorithms()))
+}
+
+func validSigningAlgorithms() []string {
+	algorithms := make([]string, 0, len(signingMethods))
+	for alg := range signingMethods {
+		algorithms = append(algorithms, alg)
+	}
+	return algorithms
+}
+
+func (s *SigningKeyService) verificationKey(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no kid")
+	}
+
+	keys, err := s.loadKeys(context.Background(), false)
+	if err != nil {
+		return nil, err
+	}
+	key := findSigningKey(keys, kid)
+	if key == nil && s.reloadAllowed() {
+		if keys, err = s.loadKeys(context.Background(), true); err != nil {
+			return nil, err
+		}
+		key = findSigningKey(keys, kid)
+	}
+	if key == nil {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("signing key %q does not sign %s", kid, token.Method.Alg())
+	}
+	return key.private.Public(), nil
+}
+
+// GetJWKS publishes every key that has not expired, including keys that do not sign yet.
+func (s *SigningKeyService) GetJWKS(ctx context.Context) (*dto.JWKSDto, error) {
+	keys, err := s.loadKeys(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	jwks := &dto.JWKSDto{Keys: make([]dto.JWKDto, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := toJWK(key)
+		if err != nil {
+			return nil, err
+		}
+		jwks.Keys = append(jwks.Keys, *jwk)
+	}
+	return jwks, nil
+}
+
+// RotateKeys creates the next key once the signing key is older than the rotation period. The new key is published
+// first and signs after the publish delay; the keys it replaces stay verifiable until every token they signed has
+// expired.
+func (s *SigningKeyService) RotateKeys(ctx context.Context) (bool, error) {
+	keys, err := s.loadKeys(ctx, true)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now().UTC()
+	current := currentSigningKey(keys, now)
+	for _, key := range keys {
+		if key.activatedAt.After(now) {
+			// the previous rotation is still being published
+			return false, nil
+		}
+	}
+	if current != nil && now.Sub(current.activatedAt) < durationOr(s.config.JWT.KeyRotationPeriod, defaultKeyRotationPeriod) {
+		return false, nil
+	}
+
+	activatedAt := now
+	if current != nil {
+		activatedAt = now.Add(durationOr(s.config.JWT.KeyPublishDelay, defaultKeyPublishDelay))
+	}
+	kid, err := s.createKey(ctx, activatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	tokenTTL := max(s.config.JWT.AccessTokenTTL, s.config.JWT.RefreshTokenTTL)
+	if _, err := s.keyRepo.ExpireSigningKeys(ctx, kid, activatedAt.Add(tokenTTL)); err != nil {
+		return false, err
+	}
+	if _, err := s.loadKeys(ctx, true); err != nil {
+		return false, err
+	}
+	s.logger.WithContext(ctx).Info("rotated signing key", zap.String("kid", kid), zap.Time("activated_at", activatedAt))
+	return true, nil
+}
+
+func (s *SigningKeyService) createKey(ctx context.Context, activatedAt time.Time) (string, error) {
+	algorithm := signingAlgorithm(s.config)
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case jwt.SigningMethodES256.Alg():
+		private, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case jwt.SigningMethodEdDSA.Alg():
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		err = fmt.Errorf("unsupported signing algorithm %q", algorithm)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return "", err
+	}
+	kid := uuid.NewString()
+	sealed, err := utils.SealWithKey(der, s.sealKey, []byte(kid))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = s.keyRepo.CreateSigningKey(ctx, &auth.SigningKey{
+		KID:         kid,
+		Algorithm:   algorithm,
+		PrivateKey:  sealed,
+		ActivatedAt: activatedAt,
+	})
+	if err != nil {
+		return "", err
+	}
+	return kid, nil
+}
+
+func (s *SigningKeyService) reloadAllowed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.loadedAt) >= signingKeyMissReloadInterval
+}
+
+// loadKeys returns the cached ring, reading it from the database when it is stale or force is set.
+func (s *SigningKeyService) loadKeys(ctx context.Context, force bool) ([]*signingKey, error) {
+	s.mu.RLock()
+	if !force && s.keys != nil && time.Since(s.loadedAt) < signingKeyReloadInterval {
+		keys := s.keys
+		s.mu.RUnlock()
+		return keys, nil
+	}
+	s.mu.RUnlock()
+
+	entities, err := s.keyRepo.FindUnexpiredSigningKeys(ctx, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]*signingKey, 0, len(entities))
+	for _, entity := range entities {
+		key, err := s.openKey(entity)
+		if err != nil {
+			// a key that cannot be opened is left out rather than taking every token down with it
+			s.logger.WithContext(ctx).Error("failed to open signing key", zap.String("kid", entity.KID), zap.Error(err))
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return keys, nil
+}
+
+func (s *SigningKeyService) openKey(entity *auth.SigningKey) (*signingKey, error) {
+	method, ok := signingMethods[entity.Algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signing algorithm %q", entity.Algorithm)
+	}
+	der, err := utils.OpenWithKey(entity.PrivateKey, s.sealKey, []byte(entity.KID))
+	if err != nil {
+		return nil, err
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("signing key is not a signer")
+	}
+	return &signingKey{
+		kid:         entity.KID,
+		method:      method,
+		private:     private,
+		activatedAt: entity.ActivatedAt,
+	}, nil
+}
+
+// currentSigningKey picks the most recently activated key that is active at now.
+func currentSigningKey(keys []*signingKey, now time.Time) *signingKey {
+	var current *signingKey
+	for _, key := range keys {
+		if key.activatedAt.After(now) {
+			continue
+		}
+		if current == nil || key.activatedAt.After(current.activatedAt) {
+			current = key
+		}
+	}
+	return current
+}
+
+func findSigningKey(keys []*signingKey, kid string) *signingKey {
+	for _, key := range keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	return nil
+}
+
+func toJWK(key *signingKey) (*dto.JWKDto, error) {
+	jwk := &dto.JWKDto{Kid: key.kid, Alg: key.method.Alg(), Use: "sig"}
+	switch public := key.private.Public().(type) {
+	case *ecdsa.PublicKey:
+		x, y := make([]byte, 32), make([]byte, 32)
+		public.X.FillBytes(x)
+		public.Y.FillBytes(y)
+		jwk.Kty, jwk.Crv = "EC", "P-256"
+		jwk.X, jwk.Y = base64.RawURLEncoding.EncodeToString(x), base64.RawURLEncoding.EncodeToString(y)
+	case ed25519.PublicKey:
+		jwk.Kty, jwk.Crv = "OKP", "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(public)
+	default:
+		return nil, fmt.Errorf("unsupported public key %T", public)
+	}
+	return jwk, nil
+}
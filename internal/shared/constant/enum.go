@@ -24,6 +24,38 @@ const (
 	IdentityHolderRole   IdentityRole = "holder"
 	IdentityVerifierRole IdentityRole = "verifier"
 	IdentityIssuerRole   IdentityRole = "issuer"
+	// IdentityAdminRole approves role applications; it is only ever granted, never registered for.
+	IdentityAdminRole IdentityRole = "admin"
+)
+
+// APIKeyScope is what an API key may be used for; keys are only accepted on routes that name one of their scopes.
+type APIKeyScope string
+
+const (
+	APIKeyProofCreateScope APIKeyScope = "proof:create"
+	APIKeyProofReadScope   APIKeyScope = "proof:read"
+	// APIKeyProofVerifyScope lets a key verify submissions, which records the outcome on them.
+	APIKeyProofVerifyScope APIKeyScope = "proof:verify"
+)
+
+// role onboarding
+type RoleGrantMethod string
+
+const (
+	RoleGrantRegistrationMethod  RoleGrantMethod = "registration"
+	RoleGrantAdminApprovalMethod RoleGrantMethod = "admin_approval"
+	RoleGrantAccreditationMethod RoleGrantMethod = "accreditation_credential"
+	RoleGrantBootstrapMethod     RoleGrantMethod = "bootstrap"
+	// RoleGrantLegacyMethod marks roles carried over from self-declared registration.
+	RoleGrantLegacyMethod RoleGrantMethod = "legacy"
+)
+
+type RoleApplicationStatus string
+
+const (
+	RoleApplicationPendingStatus  RoleApplicationStatus = "pending"
+	RoleApplicationApprovedStatus RoleApplicationStatus = "approved"
+	RoleApplicationRejectedStatus RoleApplicationStatus = "rejected"
 )
 
 // Document
@@ -68,9 +100,29 @@ const (
 type DocumentStatus string
 
 const (
-	DocumentActiveStatus  DocumentStatus = "active"
-	DocumentRevokeStatus  DocumentStatus = "revoke"
-	DocumentExpiredStatus DocumentStatus = "expired"
+	DocumentActiveStatus     DocumentStatus = "active"
+	DocumentRevokeStatus     DocumentStatus = "revoke"
+	DocumentExpiredStatus    DocumentStatus = "expired"
+	DocumentSupersededStatus DocumentStatus = "superseded"
+	DocumentSuspendedStatus  DocumentStatus = "suspended"
+)
+
+type DocumentRevisionAction string
+
+const (
+	DocumentCreatedAction    DocumentRevisionAction = "created"
+	DocumentAmendedAction    DocumentRevisionAction = "amended"
+	DocumentRevokedAction    DocumentRevisionAction = "revoked"
+	DocumentRenewedAction    DocumentRevisionAction = "renewed"
+	DocumentSupersededAction DocumentRevisionAction = "superseded"
+)
+
+// driver license points
+type LicensePointEntryKind string
+
+const (
+	LicensePointDeductionKind   LicensePointEntryKind = "deduction"
+	LicensePointRestorationKind LicensePointEntryKind = "restoration"
 )
 
 type PassportType string
@@ -114,16 +166,44 @@ const (
 	VerifiableCredentialIssuedStatus  VerifiableCredentialStatus = "issued"
 	VerifiableCredentialRevokedStatus VerifiableCredentialStatus = "revoked"
 	VerifiableCredentialExpiredStatus VerifiableCredentialStatus = "expired"
+	// imported credentials whose proofs could not be verified against an issuer managed here
+	VerifiableCredentialUnverifiedStatus VerifiableCredentialStatus = "unverified"
+)
+
+// credential verification
+type CredentialCheckStatus string
+
+const (
+	CredentialCheckPassedStatus  CredentialCheckStatus = "passed"
+	CredentialCheckFailedStatus  CredentialCheckStatus = "failed"
+	CredentialCheckSkippedStatus CredentialCheckStatus = "skipped"
 )
 
 // credential request
 type CredentialRequestStatus string
 
 const (
-	CredentialRequestPendingStatus  CredentialRequestStatus = "pending"
-	CredentialRequestApprovedStatus CredentialRequestStatus = "approved"
-	CredentialRequestRejectedStatus CredentialRequestStatus = "rejected"
-	CredentialRequestExpiredStatus  CredentialRequestStatus = "expired"
+	CredentialRequestPendingStatus     CredentialRequestStatus = "pending"
+	CredentialRequestUnderReviewStatus CredentialRequestStatus = "under_review"
+	CredentialRequestApprovedStatus    CredentialRequestStatus = "approved"
+	CredentialRequestRejectedStatus    CredentialRequestStatus = "rejected"
+	CredentialRequestIssuedStatus      CredentialRequestStatus = "issued"
+	CredentialRequestExpiredStatus     CredentialRequestStatus = "expired"
+)
+
+// auto approval
+type AutoApprovalCondition string
+
+const (
+	// AutoApprovalActiveDocumentCondition holds when the holder has a current, issuable document of the schema's type.
+	AutoApprovalActiveDocumentCondition AutoApprovalCondition = "active_document"
+)
+
+type AutoApprovalOutcome string
+
+const (
+	AutoApprovalApprovedOutcome AutoApprovalOutcome = "approved"
+	AutoApprovalSkippedOutcome  AutoApprovalOutcome = "skipped"
 )
 
 // schema
@@ -154,3 +234,61 @@ const (
 	AttributeObjectType  AttributeType = "object"
 	AttributeArrayType   AttributeType = "array"
 )
+
+type MappingTransform string
+
+const (
+	MappingTransformNone MappingTransform = "none"
+	MappingTransformDate MappingTransform = "date_yyyymmdd"
+	MappingTransformEnum MappingTransform = "enum_code"
+)
+
+// import job
+type ImportJobStatus string
+
+const (
+	ImportJobPendingStatus    ImportJobStatus = "pending"
+	ImportJobProcessingStatus ImportJobStatus = "processing"
+	ImportJobCompletedStatus  ImportJobStatus = "completed"
+	ImportJobFailedStatus     ImportJobStatus = "failed"
+)
+
+// issuance batch
+type IssuanceBatchStatus string
+
+const (
+	IssuanceBatchPendingStatus    IssuanceBatchStatus = "pending"
+	IssuanceBatchProcessingStatus IssuanceBatchStatus = "processing"
+	IssuanceBatchCompletedStatus  IssuanceBatchStatus = "completed"
+	IssuanceBatchFailedStatus     IssuanceBatchStatus = "failed"
+)
+
+type IssuanceBatchItemStatus string
+
+const (
+	IssuanceBatchItemPendingStatus  IssuanceBatchItemStatus = "pending"
+	IssuanceBatchItemPreparedStatus IssuanceBatchItemStatus = "prepared"
+	IssuanceBatchItemAddedStatus    IssuanceBatchItemStatus = "added"
+	IssuanceBatchItemIssuedStatus   IssuanceBatchItemStatus = "issued"
+	IssuanceBatchItemFailedStatus   IssuanceBatchItemStatus = "failed"
+)
+
+// correction request
+type CorrectionRequestStatus string
+
+const (
+	CorrectionRequestPendingStatus  CorrectionRequestStatus = "pending"
+	CorrectionRequestApprovedStatus CorrectionRequestStatus = "approved"
+	CorrectionRequestRejectedStatus CorrectionRequestStatus = "rejected"
+)
+
+// notification
+type NotificationKind string
+
+const (
+	CorrectionApprovedNotification NotificationKind = "correction_approved"
+	CorrectionRejectedNotification NotificationKind = "correction_rejected"
+	RoleApprovedNotification       NotificationKind = "role_approved"
+	RoleRejectedNotification       NotificationKind = "role_rejected"
+	RoleRevokedNotification        NotificationKind = "role_revoked"
+)
@@ -26,6 +26,24 @@ var (
 		Status:  http.StatusBadGateway,
 	}
 
+	RequestTooLarge = Errors{
+		Code:    "REQUEST_TOO_LARGE",
+		Message: "Request body is too large",
+		Status:  http.StatusRequestEntityTooLarge,
+	}
+
+	UnsupportedMediaType = Errors{
+		Code:    "UNSUPPORTED_MEDIA_TYPE",
+		Message: "Content type is not accepted by this endpoint",
+		Status:  http.StatusUnsupportedMediaType,
+	}
+
+	RateLimitExceeded = Errors{
+		Code:    "RATE_LIMIT_EXCEEDED",
+		Message: "Too many requests, try again later",
+		Status:  http.StatusTooManyRequests,
+	}
+
 	// Auth
 	Unauthorized = Errors{
 		Code:    "UNAUTHORIZED",
@@ -47,6 +65,21 @@ var (
 		Message: "Invalid token",
 		Status:  http.StatusUnauthorized,
 	}
+	ChallengeInvalid = Errors{
+		Code:    "CHALLENGE_INVALID",
+		Message: "Login challenge is invalid, expired or already used",
+		Status:  http.StatusUnauthorized,
+	}
+	LoginAttemptsExceeded = Errors{
+		Code:    "LOGIN_ATTEMPTS_EXCEEDED",
+		Message: "Too many failed login attempts, try again later",
+		Status:  http.StatusTooManyRequests,
+	}
+	SessionNotFound = Errors{
+		Code:    "SESSION_NOT_FOUND",
+		Message: "Session not found error",
+		Status:  http.StatusNotFound,
+	}
 	UserNotFound = Errors{
 		Code:    "USER_NOT_FOUND",
 		Message: "User not found error",
@@ -57,6 +90,105 @@ var (
 		Message: "User existed",
 		Status:  http.StatusConflict,
 	}
+	TOTPRequired = Errors{
+		Code:    "TOTP_REQUIRED",
+		Message: "Operator APIs require an account with TOTP enabled and a TOTP login",
+		Status:  http.StatusForbidden,
+	}
+	TOTPInvalid = Errors{
+		Code:    "TOTP_INVALID",
+		Message: "TOTP code is invalid or already used",
+		Status:  http.StatusUnauthorized,
+	}
+	TOTPAlreadyEnabled = Errors{
+		Code:    "TOTP_ALREADY_ENABLED",
+		Message: "TOTP is already enabled for this account",
+		Status:  http.StatusConflict,
+	}
+	TOTPNotEnrolled = Errors{
+		Code:    "TOTP_NOT_ENROLLED",
+		Message: "TOTP enrollment has not been started for this account",
+		Status:  http.StatusConflict,
+	}
+	IdentityLinkInvalid = Errors{
+		Code:    "IDENTITY_LINK_INVALID",
+		Message: "Identity link code is invalid, expired or already used",
+		Status:  http.StatusBadRequest,
+	}
+	IdentityLinkNotFound = Errors{
+		Code:    "IDENTITY_LINK_NOT_FOUND",
+		Message: "Identity is not linked to this account",
+		Status:  http.StatusNotFound,
+	}
+
+	// API keys
+	APIKeyInvalid = Errors{
+		Code:    "API_KEY_INVALID",
+		Message: "API key is invalid, expired or revoked",
+		Status:  http.StatusUnauthorized,
+	}
+	APIKeyNotFound = Errors{
+		Code:    "API_KEY_NOT_FOUND",
+		Message: "API key not found error",
+		Status:  http.StatusNotFound,
+	}
+	APIKeyScopeInvalid = Errors{
+		Code:    "API_KEY_SCOPE_INVALID",
+		Message: "API key scope is unknown",
+		Status:  http.StatusBadRequest,
+	}
+	APIKeyScopeMissing = Errors{
+		Code:    "API_KEY_SCOPE_MISSING",
+		Message: "API key does not carry the scope this endpoint requires",
+		Status:  http.StatusForbidden,
+	}
+	APIKeyRateLimitInvalid = Errors{
+		Code:    "API_KEY_RATE_LIMIT_INVALID",
+		Message: "API key rate limit is out of range",
+		Status:  http.StatusBadRequest,
+	}
+	APIKeyLimitReached = Errors{
+		Code:    "API_KEY_LIMIT_REACHED",
+		Message: "Identity already holds the maximum number of API keys",
+		Status:  http.StatusConflict,
+	}
+	APIKeyRateLimited = Errors{
+		Code:    "API_KEY_RATE_LIMITED",
+		Message: "API key rate limit exceeded, try again later",
+		Status:  http.StatusTooManyRequests,
+	}
+
+	// OpenID Connect provider
+	OIDCClientInvalid = Errors{
+		Code:    "OIDC_INVALID_CLIENT",
+		Message: "Client is unknown, disabled or failed to authenticate",
+		Status:  http.StatusUnauthorized,
+	}
+	OIDCClientNotFound = Errors{
+		Code:    "OIDC_CLIENT_NOT_FOUND",
+		Message: "OIDC client not found error",
+		Status:  http.StatusNotFound,
+	}
+	OIDCRedirectURIInvalid = Errors{
+		Code:    "OIDC_INVALID_REDIRECT_URI",
+		Message: "Redirect URI is not registered for the client",
+		Status:  http.StatusBadRequest,
+	}
+	OIDCRequestInvalid = Errors{
+		Code:    "OIDC_INVALID_REQUEST",
+		Message: "Authorization request is malformed or misses PKCE",
+		Status:  http.StatusBadRequest,
+	}
+	OIDCScopeInvalid = Errors{
+		Code:    "OIDC_INVALID_SCOPE",
+		Message: "Scope is unknown or not allowed for the client",
+		Status:  http.StatusBadRequest,
+	}
+	OIDCGrantInvalid = Errors{
+		Code:    "OIDC_INVALID_GRANT",
+		Message: "Authorization code is invalid, expired, already used or does not match the request",
+		Status:  http.StatusBadRequest,
+	}
 
 	// documents
 	CitizenIdentityNotFound = Errors{
@@ -65,6 +197,60 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	IdNumberInvalid = Errors{
+		Code:    "ID_NUMBER_INVALID",
+		Message: "Id number does not match the citizen's province, gender and birth year",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	ProvinceUnknown = Errors{
+		Code:    "PROVINCE_UNKNOWN",
+		Message: "Place of birth is not a known province",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	CorrectionRequestNotFound = Errors{
+		Code:    "CORRECTION_REQUEST_NOT_FOUND",
+		Message: "Correction request not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	CorrectionFieldInvalid = Errors{
+		Code:    "CORRECTION_FIELD_INVALID",
+		Message: "Field cannot be corrected or proposed value is invalid",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	CorrectionRequestPending = Errors{
+		Code:    "CORRECTION_REQUEST_PENDING",
+		Message: "A correction of this field is already pending",
+		Status:  http.StatusConflict,
+	}
+
+	CorrectionRequestReviewed = Errors{
+		Code:    "CORRECTION_REQUEST_REVIEWED",
+		Message: "Correction request has already been reviewed",
+		Status:  http.StatusConflict,
+	}
+
+	NotificationNotFound = Errors{
+		Code:    "NOTIFICATION_NOT_FOUND",
+		Message: "Notification not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	DocumentNumberExisted = Errors{
+		Code:    "DOCUMENT_NUMBER_EXISTED",
+		Message: "Document number already exists",
+		Status:  http.StatusConflict,
+	}
+
+	DocumentNumberExhausted = Errors{
+		Code:    "DOCUMENT_NUMBER_EXHAUSTED",
+		Message: "Could not reserve a unique document number",
+		Status:  http.StatusServiceUnavailable,
+	}
+
 	AcademicDegreeNotFound = Errors{
 		Code:    "ACADEMIC_DEGREE_NOT_FOUND",
 		Message: "Academic degree not found error",
@@ -89,12 +275,121 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	PassportMRZInvalid = Errors{
+		Code:    "PASSPORT_MRZ_INVALID",
+		Message: "Passport MRZ is not a valid TD3 machine readable zone",
+		Status:  http.StatusBadRequest,
+	}
+
+	PassportMRZMismatch = Errors{
+		Code:    "PASSPORT_MRZ_MISMATCH",
+		Message: "Passport MRZ does not match the passport and citizen identity",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	DocumentRevoked = Errors{
+		Code:    "DOCUMENT_REVOKED",
+		Message: "Document revoked error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	DocumentExpired = Errors{
+		Code:    "DOCUMENT_EXPIRED",
+		Message: "Document expired error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	DocumentSuperseded = Errors{
+		Code:    "DOCUMENT_SUPERSEDED",
+		Message: "Document superseded error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	DocumentSuspended = Errors{
+		Code:    "DOCUMENT_SUSPENDED",
+		Message: "Document suspended error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	DocumentChanged = Errors{
+		Code:    "DOCUMENT_CHANGED",
+		Message: "Document was changed by another request, reload it and try again",
+		Status:  http.StatusConflict,
+	}
+
+	LicensePointsInvalid = Errors{
+		Code:    "LICENSE_POINTS_INVALID",
+		Message: "License points invalid error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	// import job
+	ImportJobNotFound = Errors{
+		Code:    "IMPORT_JOB_NOT_FOUND",
+		Message: "Import job not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	ImportFileInvalid = Errors{
+		Code:    "IMPORT_FILE_INVALID",
+		Message: "Import file invalid error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
 	// identity
 	IdentityNotFound = Errors{
 		Code:    "IDENTITY_NOT_FOUND",
 		Message: "Identity not found error",
 		Status:  http.StatusNotFound,
 	}
+	IdentitySuspended = Errors{
+		Code:    "IDENTITY_SUSPENDED",
+		Message: "Identity is suspended",
+		Status:  http.StatusForbidden,
+	}
+
+	// role onboarding
+	RoleInvalid = Errors{
+		Code:    "ROLE_INVALID",
+		Message: "Role cannot be requested or granted this way",
+		Status:  http.StatusBadRequest,
+	}
+
+	RoleAlreadyGranted = Errors{
+		Code:    "ROLE_ALREADY_GRANTED",
+		Message: "Role already granted error",
+		Status:  http.StatusConflict,
+	}
+
+	RoleGrantNotFound = Errors{
+		Code:    "ROLE_GRANT_NOT_FOUND",
+		Message: "Role grant not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	RoleApplicationNotFound = Errors{
+		Code:    "ROLE_APPLICATION_NOT_FOUND",
+		Message: "Role application not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	RoleApplicationPending = Errors{
+		Code:    "ROLE_APPLICATION_PENDING",
+		Message: "A role application for this role is already pending",
+		Status:  http.StatusConflict,
+	}
+
+	RoleApplicationReviewed = Errors{
+		Code:    "ROLE_APPLICATION_REVIEWED",
+		Message: "Role application already reviewed error",
+		Status:  http.StatusConflict,
+	}
+
+	AccreditationCredentialInvalid = Errors{
+		Code:    "ACCREDITATION_CREDENTIAL_INVALID",
+		Message: "Credential does not accredit this identity for the role",
+		Status:  http.StatusUnprocessableEntity,
+	}
 
 	// schema
 	SchemaNotFound = Errors{
@@ -109,6 +404,12 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	SchemaMappingInvalid = Errors{
+		Code:    "SCHEMA_MAPPING_INVALID",
+		Message: "Schema mapping invalid error",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
 	// credential_requests
 	CredentialRequestNotFound = Errors{
 		Code:    "CREDENTIAL_REQUEST_NOT_FOUND",
@@ -116,6 +417,54 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	CredentialRequestTransitionInvalid = Errors{
+		Code:    "CREDENTIAL_REQUEST_TRANSITION_INVALID",
+		Message: "Credential request cannot move to this status",
+		Status:  http.StatusConflict,
+	}
+
+	CredentialRequestReasonRequired = Errors{
+		Code:    "CREDENTIAL_REQUEST_REASON_REQUIRED",
+		Message: "A reason is required to change the credential request status",
+		Status:  http.StatusBadRequest,
+	}
+
+	CredentialRequestAlreadyReviewed = Errors{
+		Code:    "CREDENTIAL_REQUEST_ALREADY_REVIEWED",
+		Message: "Reviewer has already approved this credential request",
+		Status:  http.StatusConflict,
+	}
+
+	CredentialReviewerNotAllowed = Errors{
+		Code:    "CREDENTIAL_REVIEWER_NOT_ALLOWED",
+		Message: "Identity is not a reviewer of this issuer",
+		Status:  http.StatusForbidden,
+	}
+
+	AutoApprovalRuleNotFound = Errors{
+		Code:    "AUTO_APPROVAL_RULE_NOT_FOUND",
+		Message: "Auto approval rule not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	AutoApprovalRuleExisted = Errors{
+		Code:    "AUTO_APPROVAL_RULE_EXISTED",
+		Message: "Schema already has an auto approval rule",
+		Status:  http.StatusConflict,
+	}
+
+	AutoApprovalRuleInvalid = Errors{
+		Code:    "AUTO_APPROVAL_RULE_INVALID",
+		Message: "Auto approval rule condition is not supported",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	CredentialReviewPolicyInvalid = Errors{
+		Code:    "CREDENTIAL_REVIEW_POLICY_INVALID",
+		Message: "Review policy is invalid",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
 	// verifiable_credential
 	VerifiableCredentialNotFound = Errors{
 		Code:    "VERIFIABLE_CREDENTIAL_NOT_FOUND",
@@ -123,6 +472,30 @@ var (
 		Status:  http.StatusNotFound,
 	}
 
+	VerifiableCredentialNotRefreshable = Errors{
+		Code:    "VERIFIABLE_CREDENTIAL_NOT_REFRESHABLE",
+		Message: "Verifiable credential is neither flagged for reissue nor close to expiry",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
+	VerifiableCredentialRefreshExisted = Errors{
+		Code:    "VERIFIABLE_CREDENTIAL_REFRESH_EXISTED",
+		Message: "Verifiable credential already has an open refresh request",
+		Status:  http.StatusConflict,
+	}
+
+	CredentialBundlePassphraseWeak = Errors{
+		Code:    "CREDENTIAL_BUNDLE_PASSPHRASE_WEAK",
+		Message: "Bundle passphrase must be at least 12 characters",
+		Status:  http.StatusBadRequest,
+	}
+
+	CredentialBundleInvalid = Errors{
+		Code:    "CREDENTIAL_BUNDLE_INVALID",
+		Message: "Credential bundle cannot be opened with this passphrase or is not for this holder",
+		Status:  http.StatusUnprocessableEntity,
+	}
+
 	VerifiableCredentialNotSig = Errors{
 		Code:    "VERIFIABLE_CREDENTIAL_NOT_SIG",
 		Message: "Verifiable credential not sig error",
@@ -135,6 +508,25 @@ var (
 		Status:  http.StatusNotAcceptable,
 	}
 
+	// issuance batch
+	IssuanceBatchNotFound = Errors{
+		Code:    "ISSUANCE_BATCH_NOT_FOUND",
+		Message: "Issuance batch not found error",
+		Status:  http.StatusNotFound,
+	}
+
+	IssuanceBatchInProgress = Errors{
+		Code:    "ISSUANCE_BATCH_IN_PROGRESS",
+		Message: "Issuance batch in progress error",
+		Status:  http.StatusConflict,
+	}
+
+	IssuanceBatchNotResumable = Errors{
+		Code:    "ISSUANCE_BATCH_NOT_RESUMABLE",
+		Message: "Issuance batch not resumable error",
+		Status:  http.StatusConflict,
+	}
+
 	// proof
 	ProofRequestNotFound = Errors{
 		Code:    "PROOF_REQUEST_NOT_FOUND",
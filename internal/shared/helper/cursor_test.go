@@ -0,0 +1,43 @@
+package helper
+
+import "testing"
+
+func TestCursorRoundTrip(t *testing.T) {
+	for _, id := range []uint{1, 42, 1<<32 + 7} {
+		cursor := EncodeCursor(id)
+		got, err := DecodeCursor(cursor)
+		if err != nil {
+			t.Fatalf("DecodeCursor(%q) error = %v", cursor, err)
+		}
+		if got != id {
+			t.Fatalf("DecodeCursor(EncodeCursor(%d)) = %d", id, got)
+		}
+	}
+}
+
+func TestDecodeCursor(t *testing.T) {
+	tests := []struct {
+		name    string
+		cursor  string
+		want    uint
+		wantErr bool
+	}{
+		{name: "empty starts from the top", cursor: "", want: 0},
+		{name: "valid", cursor: "MTIz", want: 123},
+		{name: "padded base64", cursor: "MTIz=", wantErr: true},
+		{name: "not base64", cursor: "***", wantErr: true},
+		{name: "not a number", cursor: "YWJj", wantErr: true},
+		{name: "negative", cursor: "LTE", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(tt.cursor)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeCursor(%q) error = %v, wantErr %v", tt.cursor, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("DecodeCursor(%q) = %d, want %d", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/iden3/go-schema-processor/v2/loaders"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/piprate/json-gold/ld"
+	"go.uber.org/zap"
 )
 
 // Embed context files vào binary
@@ -116,13 +117,39 @@ func NewCacheLoader(opts *CacheLoaderOptions) ld.DocumentLoader {
 		loaderOpts...,
 	)
 
-	// Initialize cache with embedded contexts
+	return &cachedDocumentLoader{
+		cache:      embeddedDocuments(),
+		baseLoader: baseLoader,
+	}
+}
+
+// NewEmbeddedLoader creates a document loader that never goes to the network: it resolves the embedded contexts and
+// hands any other URL to fallback, which may be nil.
+func NewEmbeddedLoader(fallback ld.DocumentLoader) ld.DocumentLoader {
+	if fallback == nil {
+		fallback = offlineLoader{}
+	}
+	return &cachedDocumentLoader{
+		cache:      embeddedDocuments(),
+		baseLoader: fallback,
+	}
+}
+
+// offlineLoader refuses every document.
+type offlineLoader struct{}
+
+func (offlineLoader) LoadDocument(url string) (*ld.RemoteDocument, error) {
+	return nil, fmt.Errorf("document %s is not available offline", url)
+}
+
+// embeddedDocuments parses the contexts embedded in the binary, keyed by their URL.
+func embeddedDocuments() map[string]*ld.RemoteDocument {
 	cache := make(map[string]*ld.RemoteDocument)
 
 	// Load and cache W3C Credential 2018 context
 	w3cContext, err := loadJSONLDContext("W3CCredential2018.jsonld")
 	if err != nil {
-		fmt.Printf("Warning: failed to load W3C context: %v\n", err)
+		zap.L().Warn("failed to load W3C context", zap.Error(err))
 	} else {
 		cache[verifiable.JSONLDSchemaW3CCredential2018] = &ld.RemoteDocument{
 			DocumentURL: verifiable.JSONLDSchemaW3CCredential2018,
@@ -133,7 +160,7 @@ func NewCacheLoader(opts *CacheLoaderOptions) ld.DocumentLoader {
 	// Load and cache Iden3 Proofs context
 	iden3ProofsContext, err := loadJSONLDContext("Iden3Proofs.jsonld")
 	if err != nil {
-		fmt.Printf("Warning: failed to load Iden3 Proofs context: %v\n", err)
+		zap.L().Warn("failed to load Iden3 Proofs context", zap.Error(err))
 	} else {
 		cache[verifiable.JSONLDSchemaIden3Credential] = &ld.RemoteDocument{
 			DocumentURL: verifiable.JSONLDSchemaIden3Credential,
@@ -144,7 +171,7 @@ func NewCacheLoader(opts *CacheLoaderOptions) ld.DocumentLoader {
 	// Load and cache Iden3 Display Method context
 	iden3DisplayContext, err := loadJSONLDContext("Iden3DisplayMethod.jsonld")
 	if err != nil {
-		fmt.Printf("Warning: failed to load Iden3 Display Method context: %v\n", err)
+		zap.L().Warn("failed to load Iden3 Display Method context", zap.Error(err))
 	} else {
 		cache[verifiable.JSONLDSchemaIden3DisplayMethod] = &ld.RemoteDocument{
 			DocumentURL: verifiable.JSONLDSchemaIden3DisplayMethod,
@@ -152,8 +179,5 @@ func NewCacheLoader(opts *CacheLoaderOptions) ld.DocumentLoader {
 		}
 	}
 
-	return &cachedDocumentLoader{
-		cache:      cache,
-		baseLoader: baseLoader,
-	}
+	return cache
 }
@@ -0,0 +1,175 @@
+package helper
+
+import (
+	"be/internal/shared/constant"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// QuerySpec carries paging, sorting and filtering options of a list endpoint.
+// Cursor mode is enabled by the presence of the cursor parameter and always pages by id.
+type QuerySpec struct {
+	Page       int
+	Limit      int
+	CursorMode bool
+	Cursor     uint
+	Sort       string
+	Desc       bool
+	Status     string
+	DID        string
+	From       *time.Time
+	To         *time.Time
+}
+
+// QueryColumns whitelists the columns of a table that a QuerySpec may touch.
+type QueryColumns struct {
+	Table        string
+	StatusColumn string
+	Sortable     []string
+	DIDColumns   []string
+	DateColumn   string
+}
+
+// ParseQuerySpec reads page, limit, cursor, sort, status, did, from and to query parameters.
+// Sort accepts a column name, prefixed with "-" for descending order.
+func ParseQuerySpec(c *gin.Context) (*QuerySpec, error) {
+	spec := &QuerySpec{
+		Page:   1,
+		Limit:  defaultPageLimit,
+		Sort:   "id",
+		Desc:   true,
+		Status: c.Query("status"),
+		DID:    c.Query("did"),
+	}
+
+	if page := c.Query("page"); page != "" {
+		value, err := strconv.Atoi(page)
+		if err != nil || value < 1 {
+			return nil, &constant.BadRequest
+		}
+		spec.Page = value
+	}
+
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return nil, &constant.BadRequest
+		}
+		spec.Limit = min(value, maxPageLimit)
+	}
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		value, err := DecodeCursor(cursor)
+		if err != nil {
+			return nil, &constant.BadRequest
+		}
+		spec.CursorMode = true
+		spec.Cursor = value
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		spec.Desc = strings.HasPrefix(sort, "-")
+		spec.Sort = strings.TrimPrefix(sort, "-")
+	}
+
+	var err error
+	if spec.From, err = parseQueryTime(c.Query("from")); err != nil {
+		return nil, &constant.BadRequest
+	}
+	if spec.To, err = parseQueryTime(c.Query("to")); err != nil {
+		return nil, &constant.BadRequest
+	}
+
+	return spec, nil
+}
+
+// parseQueryTime accepts either RFC3339 or unix seconds.
+func parseQueryTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		t := time.Unix(unix, 0).UTC()
+		return &t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// Filter is a gorm scope applying the status, DID and date range filters.
+func (spec *QuerySpec) Filter(columns *QueryColumns) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if spec.Status != "" && columns.StatusColumn != "" {
+			db = db.Where(clause.Eq{Column: columns.column(columns.StatusColumn), Value: spec.Status})
+		}
+		if spec.DID != "" && len(columns.DIDColumns) > 0 {
+			conditions := make([]clause.Expression, 0, len(columns.DIDColumns))
+			for _, name := range columns.DIDColumns {
+				conditions = append(conditions, clause.Eq{Column: columns.column(name), Value: spec.DID})
+			}
+			db = db.Where(clause.Or(conditions...))
+		}
+		dateColumn := columns.column(columns.dateColumn())
+		if spec.From != nil {
+			db = db.Where(clause.Gte{Column: dateColumn, Value: *spec.From})
+		}
+		if spec.To != nil {
+			db = db.Where(clause.Lte{Column: dateColumn, Value: *spec.To})
+		}
+		return db
+	}
+}
+
+// Paginate is a gorm scope applying ordering together with offset or cursor paging.
+func (spec *QuerySpec) Paginate(columns *QueryColumns) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if spec.CursorMode {
+			if spec.Cursor > 0 {
+				db = db.Where(clause.Lt{Column: columns.column("id"), Value: spec.Cursor})
+			}
+			return db.Order(clause.OrderByColumn{Column: columns.column("id"), Desc: true}).Limit(spec.Limit)
+		}
+
+		sort := "id"
+		if spec.Sort == "id" || slices.Contains(columns.Sortable, spec.Sort) {
+			sort = spec.Sort
+		}
+		return db.Order(clause.OrderByColumn{Column: columns.column(sort), Desc: spec.Desc}).
+			Offset((spec.Page - 1) * spec.Limit).
+			Limit(spec.Limit)
+	}
+}
+
+// Pagination builds the response metadata; lastID is the id of the last returned row.
+func (spec *QuerySpec) Pagination(total int64, count int, lastID uint) *Pagination {
+	pagination := &Pagination{Page: spec.Page, Limit: spec.Limit, Total: int(total)}
+	if spec.CursorMode && count == spec.Limit && lastID > 0 {
+		pagination.NextCursor = EncodeCursor(lastID)
+	}
+	return pagination
+}
+
+func (columns *QueryColumns) column(name string) clause.Column {
+	return clause.Column{Table: columns.Table, Name: name}
+}
+
+func (columns *QueryColumns) dateColumn() string {
+	if columns.DateColumn != "" {
+		return columns.DateColumn
+	}
+	return "created_at"
+}
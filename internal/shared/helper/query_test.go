@@ -0,0 +1,161 @@
+package helper
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func parseQuery(t *testing.T, query string) (*QuerySpec, error) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/items?"+query, nil)
+	return ParseQuerySpec(c)
+}
+
+func TestParseQuerySpec(t *testing.T) {
+	from := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, spec *QuerySpec)
+	}{
+		{name: "defaults", query: "", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Page != 1 || spec.Limit != defaultPageLimit || spec.Sort != "id" || !spec.Desc || spec.CursorMode {
+				t.Fatalf("unexpected defaults %+v", spec)
+			}
+		}},
+		{name: "page and limit", query: "page=3&limit=50", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Page != 3 || spec.Limit != 50 {
+				t.Fatalf("got page %d limit %d", spec.Page, spec.Limit)
+			}
+		}},
+		{name: "limit is capped", query: "limit=1000", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Limit != maxPageLimit {
+				t.Fatalf("got limit %d", spec.Limit)
+			}
+		}},
+		{name: "ascending sort", query: "sort=name", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Sort != "name" || spec.Desc {
+				t.Fatalf("got sort %q desc %v", spec.Sort, spec.Desc)
+			}
+		}},
+		{name: "descending sort", query: "sort=-created_at", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Sort != "created_at" || !spec.Desc {
+				t.Fatalf("got sort %q desc %v", spec.Sort, spec.Desc)
+			}
+		}},
+		{name: "empty cursor enables cursor mode", query: "cursor=", check: func(t *testing.T, spec *QuerySpec) {
+			if !spec.CursorMode || spec.Cursor != 0 {
+				t.Fatalf("got cursor mode %v cursor %d", spec.CursorMode, spec.Cursor)
+			}
+		}},
+		{name: "cursor", query: "cursor=" + EncodeCursor(77), check: func(t *testing.T, spec *QuerySpec) {
+			if !spec.CursorMode || spec.Cursor != 77 {
+				t.Fatalf("got cursor mode %v cursor %d", spec.CursorMode, spec.Cursor)
+			}
+		}},
+		{name: "filters", query: "status=active&did=did:example:1&from=2025-01-02T03:04:05Z&to=1735787045", check: func(t *testing.T, spec *QuerySpec) {
+			if spec.Status != "active" || spec.DID != "did:example:1" {
+				t.Fatalf("got status %q did %q", spec.Status, spec.DID)
+			}
+			if spec.From == nil || !spec.From.Equal(from) || spec.To == nil || !spec.To.Equal(from) {
+				t.Fatalf("got from %v to %v", spec.From, spec.To)
+			}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec, err := parseQuery(t, tt.query)
+			if err != nil {
+				t.Fatalf("ParseQuerySpec(%q) error = %v", tt.query, err)
+			}
+			tt.check(t, spec)
+		})
+	}
+}
+
+func TestParseQuerySpecErrors(t *testing.T) {
+	for _, query := range []string{"page=0", "page=x", "limit=0", "limit=-5", "cursor=***", "from=yesterday", "to=2025-13-01"} {
+		t.Run(query, func(t *testing.T) {
+			if _, err := parseQuery(t, query); err == nil {
+				t.Fatalf("ParseQuerySpec(%q) error = nil", query)
+			}
+		})
+	}
+}
+
+func TestQuerySpecPagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		spec       *QuerySpec
+		count      int
+		lastID     uint
+		wantCursor string
+	}{
+		{name: "offset mode has no cursor", spec: &QuerySpec{Page: 1, Limit: 2}, count: 2, lastID: 9},
+		{name: "full cursor page", spec: &QuerySpec{Page: 1, Limit: 2, CursorMode: true}, count: 2, lastID: 9, wantCursor: EncodeCursor(9)},
+		{name: "last cursor page", spec: &QuerySpec{Page: 1, Limit: 2, CursorMode: true}, count: 1, lastID: 9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pagination := tt.spec.Pagination(5, tt.count, tt.lastID)
+			if pagination.NextCursor != tt.wantCursor || pagination.Total != 5 {
+				t.Fatalf("got %+v, want next cursor %q", pagination, tt.wantCursor)
+			}
+		})
+	}
+}
+
+func TestQuerySpecScopes(t *testing.T) {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns := &QueryColumns{Table: "items", StatusColumn: "status", Sortable: []string{"name"}, DIDColumns: []string{"holder_did", "issuer_did"}}
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		spec *QuerySpec
+		want []string
+	}{
+		{
+			name: "sortable column",
+			spec: &QuerySpec{Page: 3, Limit: 10, Sort: "name"},
+			want: []string{`ORDER BY "items"."name" LIMIT $1 OFFSET $2`},
+		},
+		{
+			name: "unknown sort falls back to id",
+			spec: &QuerySpec{Page: 1, Limit: 10, Sort: "password", Desc: true},
+			want: []string{`ORDER BY "items"."id" DESC`},
+		},
+		{
+			name: "cursor pages by id",
+			spec: &QuerySpec{Limit: 10, CursorMode: true, Cursor: 40, Sort: "name"},
+			want: []string{`"items"."id" < $1`, `ORDER BY "items"."id" DESC`},
+		},
+		{
+			name: "filters",
+			spec: &QuerySpec{Page: 1, Limit: 10, Sort: "id", Status: "active", DID: "did:example:1", From: &from},
+			want: []string{`"items"."status" = $1`, `("items"."holder_did" = $2 OR "items"."issuer_did" = $3)`, `"items"."created_at" >= $4`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rows []map[string]interface{}
+			stmt := db.Table("items").Scopes(tt.spec.Filter(columns), tt.spec.Paginate(columns)).Find(&rows).Statement
+			sql := stmt.SQL.String()
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Fatalf("sql %q does not contain %q", sql, want)
+				}
+			}
+		})
+	}
+}
@@ -2,24 +2,35 @@ package helper
 
 import (
 	"be/internal/shared/constant"
+	"be/pkg/logger"
 	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RequestIDHeader carries the ID a request is logged under; error responses repeat it so clients can report it.
+const RequestIDHeader = logger.RequestIDHeader
+
 type Response struct {
-	Status   int         `json:"status"`
-	Code     string      `json:"code"`
-	Message  string      `json:"message"`
-	Data     interface{} `json:"data,omitempty"`
-	Metadata interface{} `json:"metadata,omitempty"`
+	Status    int         `json:"status"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	Metadata  interface{} `json:"metadata,omitempty"`
+	RequestID string      `json:"requestId,omitempty"`
 }
 
 type Pagination struct {
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
-	Total int `json:"total"`
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int    `json:"total"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+type CursorPagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 func RespondSuccess(ctx *gin.Context, data interface{}) {
@@ -32,18 +43,22 @@ func RespondSuccess(ctx *gin.Context, data interface{}) {
 }
 
 func RespondError(ctx *gin.Context, err error) {
+	// recorded so TxMiddleware rolls the request transaction back
+	_ = ctx.Error(err)
 	var appErrors *constant.Errors
 	if errors.As(err, &appErrors) {
 		ctx.JSON(appErrors.Status, &Response{
-			Status:  appErrors.Status,
-			Code:    appErrors.Code,
-			Message: appErrors.Message,
+			Status:    appErrors.Status,
+			Code:      appErrors.Code,
+			Message:   appErrors.Message,
+			RequestID: ctx.Writer.Header().Get(RequestIDHeader),
 		})
 	} else {
 		ctx.JSON(http.StatusInternalServerError, Response{
-			Status:  http.StatusInternalServerError,
-			Code:    constant.InternalServer.Code,
-			Message: constant.InternalServer.Message,
+			Status:    http.StatusInternalServerError,
+			Code:      constant.InternalServer.Code,
+			Message:   constant.InternalServer.Message,
+			RequestID: ctx.Writer.Header().Get(RequestIDHeader),
 		})
 	}
 
@@ -53,7 +68,7 @@ func RespondWithPaginationSuccess(ctx *gin.Context, data interface{}, metadata i
 	ctx.JSON(http.StatusOK, &Response{
 		Code:     "SUCCESS",
 		Message:  "Success",
-		Status:   http.StatusInternalServerError,
+		Status:   http.StatusOK,
 		Data:     data,
 		Metadata: metadata,
 	})
@@ -1,9 +1,13 @@
 package utils
 
 import (
+	"be/internal/shared/constant"
 	"crypto/rand"
+	"errors"
+	"fmt"
 	"math/big"
 	"strings"
+	"time"
 
 	"github.com/mozillazg/go-unidecode"
 )
@@ -45,6 +49,75 @@ var provinces = map[string]uint{
 	"ca_mau":      96,
 }
 
+// idNumberProvinces are the province codes of CCCD numbers, as assigned by Circular 59/2021/TT-BCA. They differ from
+// the codes above. Provinces merged in 2025 keep the codes of the former provinces, so both old and new names resolve.
+var idNumberProvinces = map[string]uint{
+	"ha_noi":          1,
+	"ha_giang":        2,
+	"cao_bang":        4,
+	"bac_kan":         6,
+	"tuyen_quang":     8,
+	"lao_cai":         10,
+	"dien_bien":       11,
+	"lai_chau":        12,
+	"son_la":          14,
+	"yen_bai":         15,
+	"hoa_binh":        17,
+	"thai_nguyen":     19,
+	"lang_son":        20,
+	"quang_ninh":      22,
+	"bac_giang":       24,
+	"phu_tho":         25,
+	"vinh_phuc":       26,
+	"bac_ninh":        27,
+	"hai_duong":       30,
+	"hai_phong":       31,
+	"hung_yen":        33,
+	"thai_binh":       34,
+	"ha_nam":          35,
+	"nam_dinh":        36,
+	"ninh_binh":       37,
+	"thanh_hoa":       38,
+	"nghe_an":         40,
+	"ha_tinh":         42,
+	"quang_binh":      44,
+	"quang_tri":       45,
+	"thua_thien_hue":  46,
+	"hue":             46,
+	"da_nang":         48,
+	"quang_nam":       49,
+	"quang_ngai":      51,
+	"binh_dinh":       52,
+	"phu_yen":         54,
+	"khanh_hoa":       56,
+	"ninh_thuan":      58,
+	"binh_thuan":      60,
+	"kon_tum":         62,
+	"gia_lai":         64,
+	"dak_lak":         66,
+	"dak_nong":        67,
+	"lam_dong":        68,
+	"binh_phuoc":      70,
+	"tay_ninh":        72,
+	"binh_duong":      74,
+	"dong_nai":        75,
+	"ba_ria_vung_tau": 77,
+	"ho_chi_minh":     79,
+	"long_an":         80,
+	"tien_giang":      82,
+	"ben_tre":         83,
+	"tra_vinh":        84,
+	"vinh_long":       86,
+	"dong_thap":       87,
+	"an_giang":        89,
+	"kien_giang":      91,
+	"can_tho":         92,
+	"hau_giang":       93,
+	"soc_trang":       94,
+	"bac_lieu":        95,
+	"ca_mau":          96,
+}
+
 var universities = map[string]string{
 	"hanoi_university_of_science_and_technology": "HUST",
 	"national_economics_university":              "NEU",
@@ -157,31 +230,133 @@ func normalize(s string) string {
 	return s
 }
 
-func GetIdNumber() (string, error) {
+// Serial lengths appended to each number prefix. The serial is the random part of a number; the prefix
+// encodes the document's own fields.
+const (
+	IdNumberSerialLength        = 6
+	DegreeNumberSerialLength    = 6
+	InsuranceNumberSerialLength = 10
+	LicenseNumberSerialLength   = 6
+	PassportNumberSerialLength  = 7
+)
 
-	serial, err := RandomDigits(12)
+var (
+	ErrUnknownProvince = errors.New("place of birth is not a known province")
+	ErrInvalidIdNumber = errors.New("id number does not match the citizen's province, gender and birth year")
+)
 
-	return serial, err
+var passportPrefixes = map[constant.PassportType]string{
+	constant.PassportOrdinaryType:   "C",
+	constant.PassportOfficialType:   "B",
+	constant.PassportDiplomaticType: "A",
 }
 
-func GetDegreeNumber() (string, error) {
+// ProvinceCode finds the CCCD code of the province named in a place, either exactly or as part of a longer address.
+// When an address names several provinces the longest name wins, then the one named first, so "Thua Thien Hue" is
+// not read as "Hue" and the result does not depend on map order.
+func ProvinceCode(place string) (uint, error) {
+	normalized := normalize(place)
+	if code, ok := idNumberProvinces[normalized]; ok {
+		return code, nil
+	}
+	words := "_" + strings.Join(strings.FieldsFunc(normalized, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_") + "_"
+	match, matchIndex := "", -1
+	for name := range idNumberProvinces {
+		index := strings.Index(words, "_"+name+"_")
+		if index < 0 {
+			continue
+		}
+		if match == "" || len(name) > len(match) || len(name) == len(match) && index < matchIndex {
+			match, matchIndex = name, index
+		}
+	}
+	if match == "" {
+		return 0, ErrUnknownProvince
+	}
+	return idNumberProvinces[match], nil
+}
 
-	serial, err := RandomDigits(6)
+// IdNumberPrefix builds the first 6 digits of a CCCD number: the 3 digit province of birth, a digit for gender
+// and century of birth (0/1 for 1900s male/female, 2/3 for 2000s and so on) and the last 2 digits of the birth year.
+func IdNumberPrefix(placeOfBirth string, gender constant.Gender, dateOfBirth int64) (string, error) {
+	province, err := ProvinceCode(placeOfBirth)
+	if err != nil {
+		return "", err
+	}
+	year := time.Unix(dateOfBirth, 0).UTC().Year()
+	century := year/100 - 19
+	if century < 0 || century > 4 {
+		return "", fmt.Errorf("birth year %d cannot be encoded in an id number", year)
+	}
+	genderDigit := century * 2
+	if gender == constant.FemaleGender {
+		genderDigit++
+	}
+	return fmt.Sprintf("%03d%d%02d", province, genderDigit, year%100), nil
+}
 
-	return serial, err
+// ValidateIdNumber checks a 12 digit CCCD number against the citizen it is issued to.
+func ValidateIdNumber(idNumber string, placeOfBirth string, gender constant.Gender, dateOfBirth int64) error {
+	if len(idNumber) != 6+IdNumberSerialLength || !isDigits(idNumber) {
+		return ErrInvalidIdNumber
+	}
+	prefix, err := IdNumberPrefix(placeOfBirth, gender, dateOfBirth)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(idNumber, prefix) {
+		return ErrInvalidIdNumber
+	}
+	return nil
+}
+
+// DegreeNumberPrefix is the university code followed by the 2 digit graduation year. Universities without a
+// registered code use the initials of their name.
+func DegreeNumberPrefix(university string, graduateYear uint) string {
+	code, ok := universities[normalize(university)]
+	if !ok {
+		for _, word := range strings.Split(normalize(university), "_") {
+			if word != "" {
+				code += strings.ToUpper(word[:1])
+			}
+		}
+	}
+	return fmt.Sprintf("%s%02d", code, graduateYear%100)
+}
+
+// InsuranceNumberPrefix is the holder's 3 digit province, taken from their id number, followed by the 2 digit
+// start year, leaving a 15 digit number.
+func InsuranceNumberPrefix(idNumber string, startDate int64) string {
+	return fmt.Sprintf("%s%02d", idNumberProvince(idNumber), time.Unix(startDate, 0).UTC().Year()%100)
 }
 
-func GetInsuranceNumber(insuranceType string) (string, error) {
-	serial, err := RandomDigits(6)
-	return serial, err
+// LicenseNumberPrefix is the licence class, then the holder's 3 digit province and the 2 digit issue year.
+func LicenseNumberPrefix(class string, idNumber string, issueDate int64) string {
+	return fmt.Sprintf("%s-%s%02d", class, idNumberProvince(idNumber), time.Unix(issueDate, 0).UTC().Year()%100)
 }
 
-func GetLicenseNumber(class string) (string, error) {
-	serial, err := RandomDigits(11)
-	return class + "-" + serial, err
+// PassportNumberPrefix is a letter for the passport type; with its serial the number fits the 9 character MRZ field.
+func PassportNumberPrefix(passportType constant.PassportType) string {
+	if prefix, ok := passportPrefixes[passportType]; ok {
+		return prefix
+	}
+	return passportPrefixes[constant.PassportOrdinaryType]
 }
 
-func GetPassportNumber() (string, error) {
-	serial, err := RandomDigits(15)
-	return serial, err
+func idNumberProvince(idNumber string) string {
+	if len(idNumber) < 3 || !isDigits(idNumber[:3]) {
+		return "000"
+	}
+	return idNumber[:3]
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
 }
@@ -0,0 +1,124 @@
+package utils
+
+import (
+	"be/internal/shared/constant"
+	"errors"
+	"testing"
+	"time"
+)
+
+func unixDate(year int, month time.Month, day int) int64 {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC).Unix()
+}
+
+func TestProvinceCode(t *testing.T) {
+	tests := []struct {
+		place string
+		want  uint
+		err   error
+	}{
+		{place: "Hà Nội", want: 1},
+		{place: "Cao Bằng", want: 4},
+		{place: "Tuyên Quang", want: 8},
+		{place: "TP. Hồ Chí Minh", want: 79},
+		{place: "Số 1 Đại Cồ Việt, Hai Bà Trưng, Hà Nội", want: 1},
+		{place: "Thừa Thiên Huế", want: 46},
+		{place: "Huế", want: 46},
+		// the longest province name wins, then the first one named
+		{place: "Hà Nam, Hà Nội", want: 35},
+		{place: "Hà Nội, Hà Nam", want: 1},
+		{place: "Quảng Nam, Quảng Ngãi", want: 51},
+		{place: "Atlantis", err: ErrUnknownProvince},
+		{place: "", err: ErrUnknownProvince},
+	}
+	for _, tt := range tests {
+		t.Run(tt.place, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				got, err := ProvinceCode(tt.place)
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("ProvinceCode(%q) error = %v, want %v", tt.place, err, tt.err)
+				}
+				if got != tt.want {
+					t.Fatalf("ProvinceCode(%q) = %d, want %d", tt.place, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestIdNumberPrefix(t *testing.T) {
+	tests := []struct {
+		name        string
+		place       string
+		gender      constant.Gender
+		dateOfBirth int64
+		want        string
+		wantErr     bool
+	}{
+		{name: "male 1900s", place: "Hà Nội", gender: constant.MaleGender, dateOfBirth: unixDate(1990, 5, 1), want: "001090"},
+		{name: "female 1900s", place: "Cao Bằng", gender: constant.FemaleGender, dateOfBirth: unixDate(1985, 1, 1), want: "004185"},
+		{name: "male 2000s", place: "Tuyên Quang", gender: constant.MaleGender, dateOfBirth: unixDate(2003, 7, 9), want: "008203"},
+		{name: "female 2000s", place: "Cà Mau", gender: constant.FemaleGender, dateOfBirth: unixDate(2010, 12, 31), want: "096310"},
+		{name: "unknown province", place: "Atlantis", gender: constant.MaleGender, dateOfBirth: unixDate(1990, 1, 1), wantErr: true},
+		{name: "before 1900", place: "Hà Nội", gender: constant.MaleGender, dateOfBirth: unixDate(1899, 1, 1), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := IdNumberPrefix(tt.place, tt.gender, tt.dateOfBirth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("IdNumberPrefix() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("IdNumberPrefix() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateIdNumber(t *testing.T) {
+	dateOfBirth := unixDate(1990, 5, 1)
+	tests := []struct {
+		name     string
+		idNumber string
+		wantErr  bool
+	}{
+		{name: "valid", idNumber: "001090123456"},
+		{name: "plate code prefix", idNumber: "010090123456", wantErr: true},
+		{name: "wrong gender digit", idNumber: "001190123456", wantErr: true},
+		{name: "wrong year", idNumber: "001091123456", wantErr: true},
+		{name: "too short", idNumber: "00109012345", wantErr: true},
+		{name: "not digits", idNumber: "00109012345A", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateIdNumber(tt.idNumber, "Hà Nội", constant.MaleGender, dateOfBirth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateIdNumber(%q) error = %v, wantErr %v", tt.idNumber, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNumberPrefixes(t *testing.T) {
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "registered university", got: DegreeNumberPrefix("Hanoi University of Science and Technology", 2024), want: "HUST24"},
+		{name: "unregistered university", got: DegreeNumberPrefix("Da Nang University", 2019), want: "DNU19"},
+		{name: "insurance", got: InsuranceNumberPrefix("001090123456", unixDate(2021, 3, 1)), want: "00121"},
+		{name: "insurance without id number", got: InsuranceNumberPrefix("", unixDate(2021, 3, 1)), want: "00021"},
+		{name: "licence", got: LicenseNumberPrefix("B2", "079203123456", unixDate(2022, 6, 1)), want: "B2-07922"},
+		{name: "ordinary passport", got: PassportNumberPrefix(constant.PassportOrdinaryType), want: "C"},
+		{name: "diplomatic passport", got: PassportNumberPrefix(constant.PassportDiplomaticType), want: "A"},
+		{name: "unknown passport type", got: PassportNumberPrefix("other"), want: "C"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.want {
+				t.Fatalf("got %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"be/internal/shared/constant"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// DocumentToMap flattens a document entity into a map keyed by its column names.
+// Integer values are kept as int64 so that dates and numbers survive the round trip.
+func DocumentToMap(entity interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]interface{}, len(decoded))
+	for key, value := range decoded {
+		result[key] = normalizeNumber(value)
+	}
+	return result, nil
+}
+
+// documentBookkeepingFields change on every save and are left out of revision diffs.
+var documentBookkeepingFields = map[string]bool{"id": true, "revision": true, "created_at": true, "updated_at": true}
+
+// DocumentSnapshot is DocumentToMap without the nested relations, as stored in a document revision.
+func DocumentSnapshot(entity interface{}) (map[string]interface{}, error) {
+	fields, err := DocumentToMap(entity)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+// DiffDocuments returns the fields that differ between two snapshots as {"from": old, "to": new}.
+func DiffDocuments(before, after map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for key, value := range after {
+		if documentBookkeepingFields[key] {
+			continue
+		}
+		if previous, ok := before[key]; !ok || previous != value {
+			changes[key] = map[string]interface{}{"from": before[key], "to": value}
+		}
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok && !documentBookkeepingFields[key] {
+			changes[key] = map[string]interface{}{"from": value, "to": nil}
+		}
+	}
+	return changes
+}
+
+func normalizeNumber(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+		return v
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = normalizeNumber(item)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// DateToYYYYMMDD converts a unix timestamp (seconds) into an integer such as 19960131,
+// the format iden3 circuits expect for date comparisons.
+func DateToYYYYMMDD(unix int64) (int64, error) {
+	return strconv.ParseInt(time.Unix(unix, 0).UTC().Format("20060102"), 10, 64)
+}
+
+// TransformDocumentValue applies a mapping transform to a single document value.
+func TransformDocumentValue(value interface{}, transform constant.MappingTransform, enumCodes map[string]interface{}) (interface{}, error) {
+	switch transform {
+	case "", constant.MappingTransformNone:
+		return value, nil
+
+	case constant.MappingTransformDate:
+		unix, ok := value.(int64)
+		if !ok {
+			return nil, fmt.Errorf("value %v is not a unix timestamp", value)
+		}
+		return DateToYYYYMMDD(unix)
+
+	case constant.MappingTransformEnum:
+		code, ok := enumCodes[fmt.Sprint(value)]
+		if !ok {
+			return nil, fmt.Errorf("no enum code for value %v", value)
+		}
+		return normalizeNumber(code), nil
+
+	default:
+		return nil, fmt.Errorf("unsupported transform %s", transform)
+	}
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"be/internal/shared/constant"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDateToYYYYMMDD(t *testing.T) {
+	tests := []struct {
+		name string
+		unix int64
+		want int64
+	}{
+		{name: "epoch", unix: 0, want: 19700101},
+		{name: "date", unix: unixDate(1996, time.January, 31), want: 19960131},
+		{name: "end of day stays on the day", unix: unixDate(2000, time.February, 29) + 86399, want: 20000229},
+		{name: "before epoch", unix: unixDate(1965, time.December, 1), want: 19651201},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DateToYYYYMMDD(tt.unix)
+			if err != nil || got != tt.want {
+				t.Fatalf("DateToYYYYMMDD(%d) = %d, %v, want %d", tt.unix, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransformDocumentValue(t *testing.T) {
+	enumCodes := map[string]interface{}{"male": float64(1), "female": float64(2)}
+	tests := []struct {
+		name      string
+		value     interface{}
+		transform constant.MappingTransform
+		want      interface{}
+		wantErr   bool
+	}{
+		{name: "no transform", value: "Nguyen", transform: "", want: "Nguyen"},
+		{name: "none", value: int64(42), transform: constant.MappingTransformNone, want: int64(42)},
+		{name: "date", value: unixDate(1996, time.January, 31), transform: constant.MappingTransformDate, want: int64(19960131)},
+		{name: "date of a string", value: "1996-01-31", transform: constant.MappingTransformDate, wantErr: true},
+		{name: "enum code", value: "female", transform: constant.MappingTransformEnum, want: int64(2)},
+		{name: "enum code of a missing value", value: "other", transform: constant.MappingTransformEnum, wantErr: true},
+		{name: "unsupported", value: "x", transform: "upper", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TransformDocumentValue(tt.value, tt.transform, enumCodes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TransformDocumentValue(%v, %q) error = %v, want error %v", tt.value, tt.transform, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("TransformDocumentValue(%v, %q) = %#v, want %#v", tt.value, tt.transform, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffDocuments(t *testing.T) {
+	tests := []struct {
+		name   string
+		before map[string]interface{}
+		after  map[string]interface{}
+		want   map[string]interface{}
+	}{
+		{
+			name:   "unchanged",
+			before: map[string]interface{}{"first_name": "An", "point": int64(12)},
+			after:  map[string]interface{}{"first_name": "An", "point": int64(12)},
+			want:   map[string]interface{}{},
+		},
+		{
+			name:   "changed field",
+			before: map[string]interface{}{"first_name": "An", "point": int64(12)},
+			after:  map[string]interface{}{"first_name": "An", "point": int64(9)},
+			want:   map[string]interface{}{"point": map[string]interface{}{"from": int64(12), "to": int64(9)}},
+		},
+		{
+			name:   "added field",
+			before: map[string]interface{}{"status": "active"},
+			after:  map[string]interface{}{"status": "superseded", "superseded_by": "b1f0"},
+			want: map[string]interface{}{
+				"status":        map[string]interface{}{"from": "active", "to": "superseded"},
+				"superseded_by": map[string]interface{}{"from": nil, "to": "b1f0"},
+			},
+		},
+		{
+			name:   "removed field",
+			before: map[string]interface{}{"revoked_at": "2026-01-01T00:00:00Z"},
+			after:  map[string]interface{}{},
+			want:   map[string]interface{}{"revoked_at": map[string]interface{}{"from": "2026-01-01T00:00:00Z", "to": nil}},
+		},
+		{
+			name:   "bookkeeping fields are left out",
+			before: map[string]interface{}{"id": int64(1), "revision": int64(1), "created_at": "a", "updated_at": "a"},
+			after:  map[string]interface{}{"id": int64(2), "revision": int64(2), "created_at": "b"},
+			want:   map[string]interface{}{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DiffDocuments(tt.before, tt.after); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("DiffDocuments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
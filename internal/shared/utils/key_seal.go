@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrKeySealInvalid = errors.New("sealed key cannot be opened")
+
+// DeriveSealKey derives a 256-bit key for SealWithKey from a configured secret. The purpose keeps keys derived
+// from the same secret for different uses apart.
+func DeriveSealKey(secret string, purpose string) ([]byte, error) {
+	if secret == "" {
+		return nil, errors.New("seal secret is empty")
+	}
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secret), nil, []byte(purpose)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive seal key: %w", err)
+	}
+	return key, nil
+}
+
+func keyAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealWithKey encrypts plaintext with AES-GCM and returns the nonce followed by the ciphertext.
+func SealWithKey(plaintext, key, associatedData []byte) ([]byte, error) {
+	aead, err := keyAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// OpenWithKey reverses SealWithKey.
+func OpenWithKey(sealed, key, associatedData []byte) ([]byte, error) {
+	aead, err := keyAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrKeySealInvalid
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, ErrKeySealInvalid
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,243 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/mozillazg/go-unidecode"
+)
+
+// TD3 is the machine readable zone of a passport as defined by ICAO 9303 part 4: two lines of 44 characters.
+const (
+	mrzLineLength     = 44
+	mrzNameLength     = 39
+	mrzNumberLength   = 9
+	mrzPersonalLength = 14
+	mrzDateLayout     = "060102"
+)
+
+var (
+	ErrMRZFormat     = errors.New("mrz must be two lines of 44 characters")
+	ErrMRZCharacters = errors.New("mrz may only contain A-Z, 0-9 and <")
+)
+
+// PassportMRZ holds the fields of a TD3 machine readable zone. Names use spaces between words,
+// dates are YYMMDD and Sex is M, F or < when unspecified.
+type PassportMRZ struct {
+	DocumentCode   string
+	IssuingState   string
+	Surname        string
+	GivenNames     string
+	DocumentNumber string
+	Nationality    string
+	DateOfBirth    string
+	Sex            string
+	ExpiryDate     string
+	PersonalNumber string
+}
+
+// MRZDate formats a unix timestamp as an MRZ date.
+func MRZDate(unix int64) string {
+	return time.Unix(unix, 0).UTC().Format(mrzDateLayout)
+}
+
+// ParsePassportMRZ parses a TD3 MRZ, given either as two lines or as the 88 characters run together,
+// and validates its check digits.
+func ParsePassportMRZ(mrz string) (*PassportMRZ, error) {
+	line1, line2, err := splitMRZ(mrz)
+	if err != nil {
+		return nil, err
+	}
+	if line1[0] != 'P' {
+		return nil, fmt.Errorf("mrz document code %q is not a passport", line1[0:2])
+	}
+
+	surname, givenNames, _ := strings.Cut(line1[5:], "<<")
+	m := &PassportMRZ{
+		DocumentCode:   line1[0:2],
+		IssuingState:   strings.TrimRight(line1[2:5], "<"),
+		Surname:        fromMRZField(surname),
+		GivenNames:     fromMRZField(givenNames),
+		DocumentNumber: strings.TrimRight(line2[0:9], "<"),
+		Nationality:    strings.TrimRight(line2[10:13], "<"),
+		DateOfBirth:    line2[13:19],
+		Sex:            line2[20:21],
+		ExpiryDate:     line2[21:27],
+		PersonalNumber: strings.TrimRight(line2[28:42], "<"),
+	}
+
+	checks := []struct {
+		name  string
+		value string
+		digit byte
+	}{
+		{"document number", line2[0:9], line2[9]},
+		{"date of birth", line2[13:19], line2[19]},
+		{"expiry date", line2[21:27], line2[27]},
+		{"composite", line2[0:10] + line2[13:20] + line2[21:43], line2[43]},
+	}
+	// the personal number check digit may be left as a filler when the field is empty
+	if m.PersonalNumber != "" || line2[42] != '<' {
+		checks = append(checks, struct {
+			name  string
+			value string
+			digit byte
+		}{"personal number", line2[28:42], line2[42]})
+	}
+	for _, check := range checks {
+		if MRZCheckDigit(check.value) != check.digit {
+			return nil, fmt.Errorf("mrz %s check digit is invalid", check.name)
+		}
+	}
+
+	for name, date := range map[string]string{"date of birth": m.DateOfBirth, "expiry date": m.ExpiryDate} {
+		if _, err := time.Parse(mrzDateLayout, date); err != nil {
+			return nil, fmt.Errorf("mrz %s %q is not a valid date", name, date)
+		}
+	}
+	if !strings.Contains("MF<", m.Sex) {
+		return nil, fmt.Errorf("mrz sex %q is invalid", m.Sex)
+	}
+	return m, nil
+}
+
+// FormatPassportMRZ builds the two MRZ lines, separated by a newline, with their check digits.
+// Names are transliterated to latin capitals and truncated to fit the name field.
+func FormatPassportMRZ(m *PassportMRZ) (string, error) {
+	documentNumber := toMRZField(m.DocumentNumber)
+	if len(documentNumber) > mrzNumberLength {
+		return "", fmt.Errorf("document number %q is longer than %d characters", m.DocumentNumber, mrzNumberLength)
+	}
+	personalNumber := toMRZField(m.PersonalNumber)
+	if len(personalNumber) > mrzPersonalLength {
+		return "", fmt.Errorf("personal number %q is longer than %d characters", m.PersonalNumber, mrzPersonalLength)
+	}
+	if len(m.DocumentCode) == 0 || m.DocumentCode[0] != 'P' {
+		return "", fmt.Errorf("document code %q is not a passport", m.DocumentCode)
+	}
+
+	name := toMRZField(m.Surname) + "<<" + toMRZField(m.GivenNames)
+	line1 := padMRZ(m.DocumentCode, 2) + padMRZ(toMRZField(m.IssuingState), 3) + padMRZ(truncateMRZ(name, mrzNameLength), mrzNameLength)
+
+	number := padMRZ(documentNumber, mrzNumberLength)
+	personal := padMRZ(personalNumber, mrzPersonalLength)
+	personalDigit := MRZCheckDigit(personal)
+	if personalNumber == "" {
+		personalDigit = '<'
+	}
+	line2 := number + string(MRZCheckDigit(number)) +
+		padMRZ(toMRZField(m.Nationality), 3) +
+		m.DateOfBirth + string(MRZCheckDigit(m.DateOfBirth)) +
+		padMRZ(m.Sex, 1) +
+		m.ExpiryDate + string(MRZCheckDigit(m.ExpiryDate)) +
+		personal + string(personalDigit)
+	if len(line2) != mrzLineLength-1 {
+		return "", fmt.Errorf("mrz dates must be formatted as YYMMDD")
+	}
+	line2 += string(MRZCheckDigit(line2[0:10] + line2[13:20] + line2[21:43]))
+
+	if _, err := ParsePassportMRZ(line1 + "\n" + line2); err != nil {
+		return "", err
+	}
+	return line1 + "\n" + line2, nil
+}
+
+// ComparePassportMRZ returns the first field of got that differs from want, or an empty string when they agree.
+// The issuing state is not compared, and the personal number only when got carries one.
+func ComparePassportMRZ(got, want *PassportMRZ) string {
+	gotName := truncateMRZ(toMRZField(got.Surname)+"<<"+toMRZField(got.GivenNames), mrzNameLength)
+	wantName := truncateMRZ(toMRZField(want.Surname)+"<<"+toMRZField(want.GivenNames), mrzNameLength)
+	switch {
+	case got.DocumentCode != padMRZ(want.DocumentCode, 2):
+		return "passport_type"
+	case got.DocumentNumber != toMRZField(want.DocumentNumber):
+		return "passport_number"
+	case got.Nationality != toMRZField(want.Nationality):
+		return "nationality"
+	case strings.TrimRight(gotName, "<") != strings.TrimRight(wantName, "<"):
+		return "name"
+	case got.DateOfBirth != want.DateOfBirth:
+		return "date_of_birth"
+	case got.Sex != padMRZ(want.Sex, 1):
+		return "gender"
+	case got.ExpiryDate != want.ExpiryDate:
+		return "expiry_date"
+	case got.PersonalNumber != "" && got.PersonalNumber != toMRZField(want.PersonalNumber):
+		return "id_number"
+	}
+	return ""
+}
+
+// MRZCheckDigit computes the ICAO 9303 check digit: character values weighted 7, 3, 1 summed modulo 10.
+func MRZCheckDigit(value string) byte {
+	weights := [3]int{7, 3, 1}
+	sum := 0
+	for i := 0; i < len(value); i++ {
+		sum += mrzCharValue(value[i]) * weights[i%3]
+	}
+	return byte('0' + sum%10)
+}
+
+func mrzCharValue(c byte) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'A' && c <= 'Z':
+		return int(c-'A') + 10
+	}
+	return 0
+}
+
+func splitMRZ(mrz string) (string, string, error) {
+	lines := strings.Fields(strings.ToUpper(mrz))
+	switch {
+	case len(lines) == 2 && len(lines[0]) == mrzLineLength && len(lines[1]) == mrzLineLength:
+	case len(lines) == 1 && len(lines[0]) == 2*mrzLineLength:
+		lines = []string{lines[0][:mrzLineLength], lines[0][mrzLineLength:]}
+	default:
+		return "", "", ErrMRZFormat
+	}
+	for _, line := range lines {
+		for i := 0; i < len(line); i++ {
+			if c := line[i]; !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '<') {
+				return "", "", ErrMRZCharacters
+			}
+		}
+	}
+	return lines[0], lines[1], nil
+}
+
+// toMRZField transliterates a value to the MRZ character set: latin capitals and digits, with < between words.
+func toMRZField(value string) string {
+	value = strings.ToUpper(unidecode.Unidecode(strings.TrimSpace(value)))
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(value, func(r rune) bool {
+		return !(r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '\'')
+	}) {
+		if b.Len() > 0 {
+			b.WriteByte('<')
+		}
+		b.WriteString(strings.ReplaceAll(word, "'", ""))
+	}
+	return b.String()
+}
+
+func fromMRZField(value string) string {
+	return strings.Join(strings.FieldsFunc(value, func(r rune) bool { return r == '<' }), " ")
+}
+
+func padMRZ(value string, length int) string {
+	if len(value) >= length {
+		return value
+	}
+	return value + strings.Repeat("<", length-len(value))
+}
+
+func truncateMRZ(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// ICAO 9303 part 4 appendix specimen
+const (
+	specimenLine1 = "P<UTOERIKSSON<<ANNA<MARIA<<<<<<<<<<<<<<<<<<<"
+	specimenLine2 = "L898902C36UTO7408122F1204159ZE184226B<<<<<10"
+)
+
+func TestMRZCheckDigit(t *testing.T) {
+	tests := []struct {
+		value string
+		want  byte
+	}{
+		{value: "L898902C3", want: '6'},
+		{value: "740812", want: '2'},
+		{value: "120415", want: '9'},
+		{value: "ZE184226B<<<<<", want: '1'},
+		{value: "L898902C3674081221204159ZE184226B<<<<<1", want: '0'},
+		{value: "<<<<<<<<<", want: '0'},
+		{value: "", want: '0'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := MRZCheckDigit(tt.value); got != tt.want {
+				t.Fatalf("MRZCheckDigit(%q) = %c, want %c", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePassportMRZ(t *testing.T) {
+	want := &PassportMRZ{
+		DocumentCode:   "P<",
+		IssuingState:   "UTO",
+		Surname:        "ERIKSSON",
+		GivenNames:     "ANNA MARIA",
+		DocumentNumber: "L898902C3",
+		Nationality:    "UTO",
+		DateOfBirth:    "740812",
+		Sex:            "F",
+		ExpiryDate:     "120415",
+		PersonalNumber: "ZE184226B",
+	}
+	for name, mrz := range map[string]string{
+		"two lines": specimenLine1 + "\n" + specimenLine2,
+		"run on":    specimenLine1 + specimenLine2,
+		"lowercase": strings.ToLower(specimenLine1 + "\n" + specimenLine2),
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePassportMRZ(mrz)
+			if err != nil {
+				t.Fatalf("ParsePassportMRZ() error = %v", err)
+			}
+			if *got != *want {
+				t.Fatalf("ParsePassportMRZ() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParsePassportMRZErrors(t *testing.T) {
+	replace := func(line string, i int, c string) string {
+		return line[:i] + c + line[i+1:]
+	}
+	tests := []struct {
+		name string
+		mrz  string
+		err  error
+	}{
+		{name: "short line", mrz: specimenLine1 + "\n" + specimenLine2[:43], err: ErrMRZFormat},
+		{name: "three lines", mrz: specimenLine1 + "\n" + specimenLine2 + "\n" + specimenLine2, err: ErrMRZFormat},
+		{name: "invalid character", mrz: replace(specimenLine1, 10, "-") + "\n" + specimenLine2, err: ErrMRZCharacters},
+		{name: "not a passport", mrz: replace(specimenLine1, 0, "I") + "\n" + specimenLine2},
+		{name: "document number digit", mrz: specimenLine1 + "\n" + replace(specimenLine2, 9, "7")},
+		{name: "date of birth digit", mrz: specimenLine1 + "\n" + replace(specimenLine2, 19, "3")},
+		{name: "expiry date digit", mrz: specimenLine1 + "\n" + replace(specimenLine2, 27, "0")},
+		{name: "personal number digit", mrz: specimenLine1 + "\n" + replace(specimenLine2, 42, "2")},
+		{name: "composite digit", mrz: specimenLine1 + "\n" + replace(specimenLine2, 43, "1")},
+		{name: "invalid sex", mrz: specimenLine1 + "\n" + replace(specimenLine2, 20, "X")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePassportMRZ(tt.mrz)
+			if err == nil {
+				t.Fatal("ParsePassportMRZ() error = nil")
+			}
+			if tt.err != nil && !errors.Is(err, tt.err) {
+				t.Fatalf("ParsePassportMRZ() error = %v, want %v", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestFormatPassportMRZ(t *testing.T) {
+	tests := []struct {
+		name    string
+		mrz     *PassportMRZ
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "specimen",
+			mrz: &PassportMRZ{DocumentCode: "P", IssuingState: "UTO", Surname: "Eriksson", GivenNames: "Anna Maria",
+				DocumentNumber: "L898902C3", Nationality: "UTO", DateOfBirth: "740812", Sex: "F", ExpiryDate: "120415",
+				PersonalNumber: "ZE184226B"},
+			want: specimenLine1 + "\n" + specimenLine2,
+		},
+		{
+			name: "transliterated without personal number",
+			mrz: &PassportMRZ{DocumentCode: "P", IssuingState: "VNM", Surname: "Nguyễn", GivenNames: "Văn Đức",
+				DocumentNumber: "C1234567", Nationality: "VNM", DateOfBirth: "900501", Sex: "M", ExpiryDate: "300501"},
+			want: "P<VNMNGUYEN<<VAN<DUC<<<<<<<<<<<<<<<<<<<<<<<<\nC1234567<0VNM9005019M3005017<<<<<<<<<<<<<<<4",
+		},
+		{
+			name:    "document number too long",
+			mrz:     &PassportMRZ{DocumentCode: "P", DocumentNumber: "C12345678X", DateOfBirth: "900501", Sex: "M", ExpiryDate: "300501"},
+			wantErr: true,
+		},
+		{
+			name:    "not a passport",
+			mrz:     &PassportMRZ{DocumentCode: "I", DocumentNumber: "C1234567", DateOfBirth: "900501", Sex: "M", ExpiryDate: "300501"},
+			wantErr: true,
+		},
+		{
+			name:    "bad date",
+			mrz:     &PassportMRZ{DocumentCode: "P", DocumentNumber: "C1234567", DateOfBirth: "1990-05-01", Sex: "M", ExpiryDate: "300501"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatPassportMRZ(tt.mrz)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FormatPassportMRZ() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("FormatPassportMRZ() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComparePassportMRZ(t *testing.T) {
+	want := &PassportMRZ{DocumentCode: "P", Surname: "Nguyễn", GivenNames: "Văn Đức", DocumentNumber: "C1234567",
+		Nationality: "VNM", DateOfBirth: "900501", Sex: "M", ExpiryDate: "300501", PersonalNumber: "001090123456"}
+	parsed := func(mutate func(m *PassportMRZ)) *PassportMRZ {
+		m := &PassportMRZ{DocumentCode: "P<", Surname: "NGUYEN", GivenNames: "VAN DUC", DocumentNumber: "C1234567",
+			Nationality: "VNM", DateOfBirth: "900501", Sex: "M", ExpiryDate: "300501", PersonalNumber: "001090123456"}
+		mutate(m)
+		return m
+	}
+	tests := []struct {
+		name string
+		got  *PassportMRZ
+		want string
+	}{
+		{name: "match", got: parsed(func(m *PassportMRZ) {}), want: ""},
+		{name: "no personal number", got: parsed(func(m *PassportMRZ) { m.PersonalNumber = "" }), want: ""},
+		{name: "number", got: parsed(func(m *PassportMRZ) { m.DocumentNumber = "C7654321" }), want: "passport_number"},
+		{name: "name", got: parsed(func(m *PassportMRZ) { m.GivenNames = "VAN AN" }), want: "name"},
+		{name: "sex", got: parsed(func(m *PassportMRZ) { m.Sex = "F" }), want: "gender"},
+		{name: "personal number", got: parsed(func(m *PassportMRZ) { m.PersonalNumber = "001090654321" }), want: "id_number"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComparePassportMRZ(tt.got, want); got != tt.want {
+				t.Fatalf("ComparePassportMRZ() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+	passphraseKeyLength = 32
+	passphraseSaltSize  = 16
+	// passphraseMemory is the Argon2id memory of new payloads in KiB
+	passphraseMemory = 64 * 1024
+	// upper bounds for parameters read back from a sealed payload, so a crafted payload cannot exhaust the server;
+	// the memory cap is the export default, so each import holds at most what an export does
+	maxPassphraseTime    = 10
+	maxPassphraseMemory  = passphraseMemory
+	maxPassphraseThreads = 16
+)
+
+var ErrPassphraseSealInvalid = errors.New("sealed payload cannot be opened")
+
+// PassphraseKDF holds the Argon2id parameters a payload was sealed with. They travel with the payload so they
+// can be raised later without breaking what was sealed before.
+type PassphraseKDF struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+	Salt    []byte
+}
+
+func NewPassphraseKDF() (*PassphraseKDF, error) {
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return &PassphraseKDF{Time: 3, Memory: passphraseMemory, Threads: 4, Salt: salt}, nil
+}
+
+func (kdf *PassphraseKDF) aead(passphrase string) (cipher.AEAD, error) {
+	if kdf.Time == 0 || kdf.Time > maxPassphraseTime ||
+		kdf.Memory == 0 || kdf.Memory > maxPassphraseMemory ||
+		kdf.Threads == 0 || kdf.Threads > maxPassphraseThreads ||
+		len(kdf.Salt) < passphraseSaltSize {
+		return nil, ErrPassphraseSealInvalid
+	}
+	key := argon2.IDKey([]byte(passphrase), kdf.Salt, kdf.Time, kdf.Memory, kdf.Threads, passphraseKeyLength)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealWithPassphrase encrypts plaintext with AES-256-GCM under a key derived from the passphrase. The associated
+// data is authenticated but not encrypted, and must be given again to open the payload.
+func SealWithPassphrase(plaintext []byte, passphrase string, kdf *PassphraseKDF, associatedData []byte) ([]byte, []byte, error) {
+	aead, err := kdf.aead(passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return nonce, aead.Seal(nil, nonce, plaintext, associatedData), nil
+}
+
+// OpenWithPassphrase reverses SealWithPassphrase. A wrong passphrase and a tampered payload both return
+// ErrPassphraseSealInvalid.
+func OpenWithPassphrase(ciphertext, nonce []byte, passphrase string, kdf *PassphraseKDF, associatedData []byte) ([]byte, error) {
+	aead, err := kdf.aead(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, ErrPassphraseSealInvalid
+	}
+	plaintext, err := aead.Open(nil, nonce, ciphertext, associatedData)
+	if err != nil {
+		return nil, ErrPassphraseSealInvalid
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestOpenWithPassphrase(t *testing.T) {
+	kdf, err := NewPassphraseKDF()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, associatedData := []byte("credential bundle"), []byte("holder")
+	nonce, ciphertext, err := SealWithPassphrase(plaintext, "correct horse", kdf, associatedData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tampered := bytes.Clone(ciphertext)
+	tampered[0] ^= 1
+	withKDF := func(change func(kdf *PassphraseKDF)) *PassphraseKDF {
+		copied := *kdf
+		change(&copied)
+		return &copied
+	}
+	tests := []struct {
+		name           string
+		ciphertext     []byte
+		passphrase     string
+		kdf            *PassphraseKDF
+		associatedData []byte
+		wantErr        bool
+	}{
+		{name: "sealed payload", ciphertext: ciphertext, passphrase: "correct horse", kdf: kdf, associatedData: associatedData},
+		{name: "wrong passphrase", ciphertext: ciphertext, passphrase: "battery staple", kdf: kdf, associatedData: associatedData, wantErr: true},
+		{name: "tampered ciphertext", ciphertext: tampered, passphrase: "correct horse", kdf: kdf, associatedData: associatedData, wantErr: true},
+		{name: "other associated data", ciphertext: ciphertext, passphrase: "correct horse", kdf: kdf, associatedData: []byte("verifier"), wantErr: true},
+		{name: "memory above the export default", ciphertext: ciphertext, passphrase: "correct horse", associatedData: associatedData, wantErr: true,
+			kdf: withKDF(func(kdf *PassphraseKDF) { kdf.Memory = passphraseMemory + 1 })},
+		{name: "no time", ciphertext: ciphertext, passphrase: "correct horse", associatedData: associatedData, wantErr: true,
+			kdf: withKDF(func(kdf *PassphraseKDF) { kdf.Time = 0 })},
+		{name: "too many threads", ciphertext: ciphertext, passphrase: "correct horse", associatedData: associatedData, wantErr: true,
+			kdf: withKDF(func(kdf *PassphraseKDF) { kdf.Threads = maxPassphraseThreads + 1 })},
+		{name: "short salt", ciphertext: ciphertext, passphrase: "correct horse", associatedData: associatedData, wantErr: true,
+			kdf: withKDF(func(kdf *PassphraseKDF) { kdf.Salt = kdf.Salt[:passphraseSaltSize-1] })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := OpenWithPassphrase(tt.ciphertext, nonce, tt.passphrase, tt.kdf, tt.associatedData)
+			if tt.wantErr {
+				if !errors.Is(err, ErrPassphraseSealInvalid) {
+					t.Fatalf("OpenWithPassphrase() error = %v, want %v", err, ErrPassphraseSealInvalid)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(got, plaintext) {
+				t.Fatalf("OpenWithPassphrase() = %q, %v, want %q", got, err, plaintext)
+			}
+		})
+	}
+}
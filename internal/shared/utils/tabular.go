@@ -0,0 +1,356 @@
+package utils
+
+import (
+	"archive/zip"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxXLSXEntrySize bounds the uncompressed size of every file read from a workbook, so a small upload cannot
+	// expand into gigabytes of XML.
+	maxXLSXEntrySize = 64 << 20
+	// maxTabularColumns bounds the width of a row; no document has nearly as many fields.
+	maxTabularColumns = 256
+)
+
+var (
+	ErrTooManyRows    = errors.New("too many rows")
+	ErrTooManyColumns = errors.New("too many columns")
+	ErrEntryTooLarge  = errors.New("workbook entry too large")
+)
+
+// ReadCSV reads the records of a CSV file, at most maxRows of them. Rows may have a varying number of fields.
+func ReadCSV(r io.Reader, maxRows int) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == maxRows {
+			return nil, ErrTooManyRows
+		}
+		if len(record) > maxTabularColumns {
+			return nil, ErrTooManyColumns
+		}
+		rows = append(rows, record)
+	}
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxRichText struct {
+	Text string        `xml:"t"`
+	Runs []xlsxTextRun `xml:"r"`
+}
+
+type xlsxTextRun struct {
+	Text string `xml:"t"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.Text)
+	}
+	return b.String()
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Items []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string       `xml:"r,attr"`
+		Type   string       `xml:"t,attr"`
+		Value  string       `xml:"v"`
+		Inline xlsxRichText `xml:"is"`
+	} `xml:"c"`
+}
+
+// ReadXLSX reads the cell values of the first worksheet of an XLSX workbook, at most maxRows rows of it.
+// Only the values are read; styles and formulas are ignored, so dates must be stored as text or unix seconds.
+// Rows are decoded one at a time, so reading stops at the first row past the limit.
+func ReadXLSX(r io.ReaderAt, size int64, maxRows int) ([][]string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	var shared xlsxSharedStrings
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if err := decodeZipXML(file, &shared); err != nil {
+			return nil, err
+		}
+	}
+
+	sheet, ok := files[firstSheetPath(files)]
+	if !ok {
+		return nil, fmt.Errorf("workbook has no worksheet")
+	}
+	reader, err := openZipEntry(sheet)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	var rows [][]string
+	decoder := xml.NewDecoder(reader)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		if len(rows) == maxRows {
+			return nil, ErrTooManyRows
+		}
+
+		var row xlsxRow
+		if err := decoder.DecodeElement(&row, &start); err != nil {
+			return nil, err
+		}
+		values, err := xlsxRowValues(row, shared)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, values)
+	}
+}
+
+func xlsxRowValues(row xlsxRow, shared xlsxSharedStrings) ([]string, error) {
+	var values []string
+	for i, cell := range row.Cells {
+		column := i
+		if cell.Ref != "" {
+			column = cellColumn(cell.Ref)
+		}
+		if column >= maxTabularColumns {
+			return nil, ErrTooManyColumns
+		}
+		for len(values) <= column {
+			values = append(values, "")
+		}
+
+		switch cell.Type {
+		case "s":
+			index, err := strconv.Atoi(cell.Value)
+			if err != nil || index < 0 || index >= len(shared.Items) {
+				return nil, fmt.Errorf("invalid shared string reference in cell %s", cell.Ref)
+			}
+			values[column] = shared.Items[index].String()
+		case "inlineStr":
+			values[column] = cell.Inline.String()
+		default:
+			values[column] = cell.Value
+		}
+	}
+	return values, nil
+}
+
+func firstSheetPath(files map[string]*zip.File) string {
+	const fallback = "xl/worksheets/sheet1.xml"
+
+	var workbook xlsxWorkbook
+	var rels xlsxRelationships
+	workbookFile, ok := files["xl/workbook.xml"]
+	relsFile, relsOk := files["xl/_rels/workbook.xml.rels"]
+	if !ok || !relsOk || decodeZipXML(workbookFile, &workbook) != nil || decodeZipXML(relsFile, &rels) != nil || len(workbook.Sheets) == 0 {
+		return fallback
+	}
+	for _, rel := range rels.Items {
+		if rel.ID != workbook.Sheets[0].RID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/")
+		}
+		return path.Join("xl", rel.Target)
+	}
+	return fallback
+}
+
+func decodeZipXML(file *zip.File, v interface{}) error {
+	reader, err := openZipEntry(file)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
+
+// openZipEntry opens a workbook entry that fails with ErrEntryTooLarge past maxXLSXEntrySize. The size in the
+// entry header is checked first, but only the bytes actually read are trusted.
+func openZipEntry(file *zip.File) (io.ReadCloser, error) {
+	if file.UncompressedSize64 > maxXLSXEntrySize {
+		return nil, ErrEntryTooLarge
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	return &limitedEntry{ReadCloser: reader, remaining: maxXLSXEntrySize}, nil
+}
+
+type limitedEntry struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (e *limitedEntry) Read(p []byte) (int, error) {
+	if e.remaining <= 0 {
+		// one byte more tells an entry of exactly the limit from a larger one
+		var probe [1]byte
+		if n, _ := e.ReadCloser.Read(probe[:]); n > 0 {
+			return 0, ErrEntryTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > e.remaining {
+		p = p[:e.remaining]
+	}
+	n, err := e.ReadCloser.Read(p)
+	e.remaining -= int64(n)
+	return n, err
+}
+
+// cellColumn converts a cell reference such as "AB12" into a zero based column index.
+func cellColumn(ref string) int {
+	column := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		column = column*26 + int(ch-'A'+1)
+	}
+	return column - 1
+}
+
+// RowsToRecords turns a header row followed by data rows into one map per data row.
+// Header names are normalised to snake_case and fully empty rows are kept as nil so row numbers stay stable.
+func RowsToRecords(rows [][]string) ([]string, []map[string]string) {
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	header := make([]string, len(rows[0]))
+	for i, name := range rows[0] {
+		header[i] = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff"))), " ", "_")
+	}
+
+	records := make([]map[string]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		var record map[string]string
+		for i, value := range row {
+			value = strings.TrimSpace(value)
+			if i >= len(header) || header[i] == "" || value == "" {
+				continue
+			}
+			if record == nil {
+				record = make(map[string]string, len(header))
+			}
+			record[header[i]] = value
+		}
+		records = append(records, record)
+	}
+	return header, records
+}
+
+// AssignRecord copies record values into the struct pointed to by entity, matching keys against json tags.
+// Integer fields also accept dates written as YYYY-MM-DD, which are stored as unix seconds.
+// On failure the offending field name is returned alongside the error.
+func AssignRecord(entity interface{}, record map[string]string) (string, error) {
+	value := reflect.ValueOf(entity).Elem()
+	valueType := value.Type()
+
+	for i := 0; i < valueType.NumField(); i++ {
+		name := strings.Split(valueType.Field(i).Tag.Get("json"), ",")[0]
+		raw, ok := record[name]
+		if name == "" || name == "-" || !ok {
+			continue
+		}
+
+		field := value.Field(i)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(raw)
+		case reflect.Int, reflect.Int32, reflect.Int64:
+			parsed, err := parseIntOrDate(raw)
+			if err != nil {
+				return name, err
+			}
+			field.SetInt(parsed)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil {
+				return name, fmt.Errorf("%s is not a positive integer", raw)
+			}
+			field.SetUint(parsed)
+		case reflect.Float32, reflect.Float64:
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				return name, fmt.Errorf("%s is not a number", raw)
+			}
+			field.SetFloat(parsed)
+		case reflect.Bool:
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				return name, fmt.Errorf("%s is not a boolean", raw)
+			}
+			field.SetBool(parsed)
+		}
+	}
+	return "", nil
+}
+
+func parseIntOrDate(raw string) (int64, error) {
+	if parsed, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return parsed, nil
+	}
+	parsed, err := time.Parse("2006-01-02", raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s is neither an integer nor a YYYY-MM-DD date", raw)
+	}
+	return parsed.Unix(), nil
+}
@@ -0,0 +1,232 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		maxRows int
+		want    [][]string
+		wantErr error
+	}{
+		{name: "rows", content: "first_name,last_name\nAn,Nguyen\n", maxRows: 10, want: [][]string{{"first_name", "last_name"}, {"An", "Nguyen"}}},
+		{name: "varying fields", content: "a,b,c\n1\n1,2,3,4\n", maxRows: 10, want: [][]string{{"a", "b", "c"}, {"1"}, {"1", "2", "3", "4"}}},
+		{name: "leading space and quotes", content: "a, \"b, c\"\n", maxRows: 10, want: [][]string{{"a", "b, c"}}},
+		{name: "exactly the limit", content: "a\n1\n2\n", maxRows: 3, want: [][]string{{"a"}, {"1"}, {"2"}}},
+		{name: "past the limit", content: "a\n1\n2\n3\n", maxRows: 3, wantErr: ErrTooManyRows},
+		{name: "too many columns", content: strings.Repeat("a,", maxTabularColumns) + "a\n", maxRows: 10, wantErr: ErrTooManyColumns},
+		{name: "empty", content: "", maxRows: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadCSV(strings.NewReader(tt.content), tt.maxRows)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadCSV() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadCSV(strings.NewReader("a,\"b\n"), 10); err == nil {
+		t.Fatal("ReadCSV() of an unterminated quote succeeded")
+	}
+}
+
+// newXLSX zips the given files into a workbook.
+func newXLSX(t *testing.T, files map[string]string) *bytes.Reader {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func xlsxSheet(rows ...string) string {
+	return `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` + strings.Join(rows, "") + `</sheetData></worksheet>`
+}
+
+func TestReadXLSX(t *testing.T) {
+	sharedStrings := `<sst><si><t>first_name</t></si><si><r><t>Ng</t></r><r><t>uyen</t></r></si></sst>`
+	workbook := `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet r:id="rId2"/></sheets></workbook>`
+	rels := `<Relationships><Relationship Id="rId1" Target="worksheets/other.xml"/><Relationship Id="rId2" Target="worksheets/people.xml"/></Relationships>`
+
+	tests := []struct {
+		name    string
+		files   map[string]string
+		maxRows int
+		want    [][]string
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "shared, inline and plain cells", maxRows: 10, files: map[string]string{
+			"xl/sharedStrings.xml": sharedStrings,
+			"xl/worksheets/sheet1.xml": xlsxSheet(
+				`<row><c r="A1" t="s"><v>0</v></c><c r="B1" t="inlineStr"><is><t>age</t></is></c></row>`,
+				`<row><c r="A2" t="s"><v>1</v></c><c r="C2"><v>30</v></c></row>`,
+			),
+		}, want: [][]string{{"first_name", "age"}, {"Nguyen", "", "30"}}},
+		{name: "first sheet of the workbook", maxRows: 10, files: map[string]string{
+			"xl/workbook.xml":            workbook,
+			"xl/_rels/workbook.xml.rels": rels,
+			"xl/worksheets/other.xml":    xlsxSheet(`<row><c><v>other</v></c></row>`),
+			"xl/worksheets/people.xml":   xlsxSheet(`<row><c><v>people</v></c></row>`),
+		}, want: [][]string{{"people"}}},
+		{name: "past the limit", maxRows: 2, files: map[string]string{
+			"xl/worksheets/sheet1.xml": xlsxSheet(`<row><c><v>1</v></c></row>`, `<row><c><v>2</v></c></row>`, `<row><c><v>3</v></c></row>`),
+		}, wantErr: ErrTooManyRows},
+		{name: "cell past the last column", maxRows: 10, files: map[string]string{
+			"xl/worksheets/sheet1.xml": xlsxSheet(`<row><c r="XFD1"><v>1</v></c></row>`),
+		}, wantErr: ErrTooManyColumns},
+		{name: "missing shared string", maxRows: 10, files: map[string]string{
+			"xl/worksheets/sheet1.xml": xlsxSheet(`<row><c r="A1" t="s"><v>3</v></c></row>`),
+		}, anyErr: true},
+		{name: "no worksheet", maxRows: 10, files: map[string]string{"xl/styles.xml": `<styleSheet/>`}, anyErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := newXLSX(t, tt.files)
+			got, err := ReadXLSX(content, content.Size(), tt.maxRows)
+			if tt.anyErr {
+				if err == nil {
+					t.Fatalf("ReadXLSX() = %q, want an error", got)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadXLSX() error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ReadXLSX() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ReadXLSX(strings.NewReader("not a zip"), 9, 10); err == nil {
+		t.Fatal("ReadXLSX() of a file that is not a zip succeeded")
+	}
+}
+
+func TestLimitedEntry(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		limit   int64
+		wantErr error
+	}{
+		{name: "under the limit", content: "abc", limit: 4},
+		{name: "exactly the limit", content: "abcd", limit: 4},
+		{name: "past the limit", content: "abcde", limit: 4, wantErr: ErrEntryTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &limitedEntry{ReadCloser: io.NopCloser(strings.NewReader(tt.content)), remaining: tt.limit}
+			got, err := io.ReadAll(entry)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ReadAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && string(got) != tt.content {
+				t.Fatalf("ReadAll() = %q, want %q", got, tt.content)
+			}
+		})
+	}
+}
+
+func TestRowsToRecords(t *testing.T) {
+	tests := []struct {
+		name        string
+		rows        [][]string
+		wantHeader  []string
+		wantRecords []map[string]string
+	}{
+		{name: "no rows"},
+		{name: "header only", rows: [][]string{{"a"}}, wantHeader: []string{"a"}, wantRecords: []map[string]string{}},
+		{
+			name:        "normalised header",
+			rows:        [][]string{{"\ufeffFirst Name", " Last Name ", ""}, {" An ", "Nguyen", "ignored"}},
+			wantHeader:  []string{"first_name", "last_name", ""},
+			wantRecords: []map[string]string{{"first_name": "An", "last_name": "Nguyen"}},
+		},
+		{
+			name:        "empty rows keep their place",
+			rows:        [][]string{{"a", "b"}, {"1", ""}, {"", " "}, {}, {"2", "3", "extra"}},
+			wantHeader:  []string{"a", "b"},
+			wantRecords: []map[string]string{{"a": "1"}, nil, nil, {"a": "2", "b": "3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, records := RowsToRecords(tt.rows)
+			if !reflect.DeepEqual(header, tt.wantHeader) {
+				t.Fatalf("header = %q, want %q", header, tt.wantHeader)
+			}
+			if !reflect.DeepEqual(records, tt.wantRecords) {
+				t.Fatalf("records = %v, want %v", records, tt.wantRecords)
+			}
+		})
+	}
+}
+
+func TestAssignRecord(t *testing.T) {
+	type entity struct {
+		Name     string  `json:"name"`
+		Born     int64   `json:"born,omitempty"`
+		Points   uint    `json:"points"`
+		Score    float64 `json:"score"`
+		Verified bool    `json:"verified"`
+		Secret   string  `json:"-"`
+		Untagged string
+	}
+	tests := []struct {
+		name      string
+		record    map[string]string
+		want      entity
+		wantField string
+	}{
+		{
+			name:   "every kind",
+			record: map[string]string{"name": "An", "born": "1996-01-31", "points": "12", "score": "7.5", "verified": "true", "-": "x", "Untagged": "x"},
+			want:   entity{Name: "An", Born: time.Date(1996, time.January, 31, 0, 0, 0, 0, time.UTC).Unix(), Points: 12, Score: 7.5, Verified: true},
+		},
+		{name: "unix seconds", record: map[string]string{"born": "822960000"}, want: entity{Born: 822960000}},
+		{name: "missing keys are left alone", record: map[string]string{}, want: entity{}},
+		{name: "bad date", record: map[string]string{"born": "31/01/1996"}, wantField: "born"},
+		{name: "negative points", record: map[string]string{"points": "-1"}, wantField: "points"},
+		{name: "bad score", record: map[string]string{"score": "high"}, wantField: "score"},
+		{name: "bad boolean", record: map[string]string{"verified": "maybe"}, wantField: "verified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got entity
+			field, err := AssignRecord(&got, tt.record)
+			if field != tt.wantField || (err != nil) != (tt.wantField != "") {
+				t.Fatalf("AssignRecord() = %q, %v, want field %q", field, err, tt.wantField)
+			}
+			if tt.wantField == "" && got != tt.want {
+				t.Fatalf("AssignRecord() assigned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
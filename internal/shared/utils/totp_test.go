@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+// RFC 6238 appendix B, SHA1, truncated to six digits
+var rfc6238Secret = []byte("12345678901234567890")
+
+func TestTOTPCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			if got := totpCode(rfc6238Secret, tt.unix/30); got != tt.want {
+				t.Fatalf("totpCode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := now.Unix() / 30
+	tests := []struct {
+		name        string
+		code        string
+		lastCounter int64
+		want        int64
+		wantOK      bool
+	}{
+		{name: "current step", code: totpCode(rfc6238Secret, current), want: current, wantOK: true},
+		{name: "previous step", code: totpCode(rfc6238Secret, current-1), want: current - 1, wantOK: true},
+		{name: "next step", code: totpCode(rfc6238Secret, current+1), want: current + 1, wantOK: true},
+		{name: "surrounding spaces", code: " " + totpCode(rfc6238Secret, current) + " ", want: current, wantOK: true},
+		{name: "two steps back", code: totpCode(rfc6238Secret, current-2)},
+		{name: "two steps ahead", code: totpCode(rfc6238Secret, current+2)},
+		{name: "replayed step", code: totpCode(rfc6238Secret, current), lastCounter: current},
+		{name: "earlier than last step", code: totpCode(rfc6238Secret, current-1), lastCounter: current - 1},
+		{name: "later step after last", code: totpCode(rfc6238Secret, current+1), lastCounter: current, want: current + 1, wantOK: true},
+		{name: "too short", code: "12345"},
+		{name: "too long", code: "1234567"},
+		{name: "empty", code: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := VerifyTOTP(rfc6238Secret, tt.code, now, tt.lastCounter)
+			if ok != tt.wantOK || got != tt.want {
+				t.Fatalf("VerifyTOTP() = %d, %v, want %d, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("be", "ops@example.com", rfc6238Secret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/be:ops@example.com" {
+		t.Fatalf("TOTPURI() = %s", uri)
+	}
+	query := uri.Query()
+	want := map[string]string{
+		"secret":    "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ",
+		"issuer":    "be",
+		"algorithm": "SHA1",
+		"digits":    "6",
+		"period":    "30",
+	}
+	for key, value := range want {
+		if query.Get(key) != value {
+			t.Fatalf("TOTPURI() %s = %q, want %q", key, query.Get(key), value)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package dto
+
+import (
+	"be/internal/domain/schema"
+	"be/internal/shared/constant"
+	"time"
+)
+
+type APIKeyCreatedRequestDto struct {
+	Name   string                 `json:"name" binding:"required,max=255"`
+	Scopes []constant.APIKeyScope `json:"scopes" binding:"required,min=1"`
+	// RateLimit is the number of requests per minute the key may make; zero takes the configured default.
+	RateLimit int        `json:"rateLimit" binding:"omitempty,min=1"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	DID       string     `json:"-"`
+}
+
+type APIKeyResponseDto struct {
+	PublicID string `json:"id"`
+	// Key is the full API key. It is only returned when the key is created.
+	Key        string                 `json:"key,omitempty"`
+	Prefix     string                 `json:"prefix"`
+	Name       string                 `json:"name"`
+	DID        string                 `json:"did"`
+	Scopes     []constant.APIKeyScope `json:"scopes"`
+	RateLimit  int                    `json:"rateLimit"`
+	LastUsedAt *time.Time             `json:"lastUsedAt,omitempty"`
+	LastUsedIP string                 `json:"lastUsedIp,omitempty"`
+	ExpiresAt  *time.Time             `json:"expiresAt,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	RevokedAt  *time.Time             `json:"revokedAt,omitempty"`
+}
+
+func ToAPIKeyResponseDto(entity *schema.IdentityAPIKey) *APIKeyResponseDto {
+	scopes := make([]constant.APIKeyScope, 0, len(entity.Scopes))
+	for _, scope := range entity.Scopes {
+		scopes = append(scopes, constant.APIKeyScope(scope))
+	}
+	return &APIKeyResponseDto{
+		PublicID:   entity.PublicID.String(),
+		Prefix:     entity.Prefix,
+		Name:       entity.Name,
+		DID:        entity.DID,
+		Scopes:     scopes,
+		RateLimit:  entity.RateLimit,
+		LastUsedAt: entity.LastUsedAt,
+		LastUsedIP: entity.LastUsedIP,
+		ExpiresAt:  entity.ExpiresAt,
+		CreatedAt:  entity.CreatedAt,
+		RevokedAt:  entity.RevokedAt,
+	}
+}
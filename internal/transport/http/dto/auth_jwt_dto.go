@@ -1,7 +1,9 @@
 package dto
 
 import (
+	"be/internal/domain/auth"
 	"be/internal/shared/constant"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -23,9 +25,31 @@ type UserRequest struct {
 }
 
 type UserResponse struct {
-	ID    string `json:"id" binding:"required,uuid"`
-	Email string `json:"email" binding:"required"`
-	Name  string `json:"name" binding:"required"`
+	ID          string            `json:"id" binding:"required,uuid"`
+	Email       string            `json:"email" binding:"required"`
+	Name        string            `json:"name" binding:"required"`
+	Role        constant.UserRole `json:"role"`
+	TOTPEnabled bool              `json:"totp_enabled"`
+	DIDs        []string          `json:"dids"`
+}
+
+func ToUserResponse(user *auth.User) *UserResponse {
+	dids := make([]string, 0, len(user.Identities))
+	for _, link := range user.Identities {
+		dids = append(dids, link.DID)
+	}
+	return &UserResponse{
+		ID:          user.PublicID.String(),
+		Email:       user.Email,
+		Name:        user.Name,
+		Role:        user.Role,
+		TOTPEnabled: user.TOTPEnabledAt != nil,
+		DIDs:        dids,
+	}
+}
+
+type UserRoleRequest struct {
+	Role constant.UserRole `json:"role" binding:"required,oneof=user admin"`
 }
 
 type TokenResponse struct {
@@ -33,10 +57,60 @@ type TokenResponse struct {
 	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
+// LoginResponse carries the tokens of a completed login, or the MFA token to finish it with a TOTP code.
+type LoginResponse struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+type TOTPLoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+type TOTPEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TOTPConfirmRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type IdentityLinkCodeResponse struct {
+	Code      string    `json:"code"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type IdentityLinkRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Claims are the claims of an operator token. MFA is only set by a login that passed TOTP; the admin APIs
+// require it.
 type Claims struct {
 	ID    string            `json:"id"`
 	Email string            `json:"email"`
 	Name  string            `json:"name"`
 	Role  constant.UserRole `json:"role"`
+	MFA   bool              `json:"mfa"`
+	DIDs  []string          `json:"dids"`
 	jwt.RegisteredClaims
 }
+
+// JWKDto is a public signing key in RFC 7517 form. X and Y are base64url encoded; OKP keys have no Y.
+type JWKDto struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+type JWKSDto struct {
+	Keys []JWKDto `json:"keys"`
+}
@@ -2,6 +2,7 @@ package dto
 
 import (
 	"be/internal/shared/constant"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -12,6 +13,12 @@ type ZKClaims struct {
 	DID   string                `json:"did"`
 	State string                `json:"state"`
 	Role  constant.IdentityRole `json:"role"`
+	// Roles are every role the identity held when the token was issued; Role is the one it acts in.
+	Roles []constant.IdentityRole `json:"roles"`
+	// SessionID ties the token to one login; the token ID (jti) tells the tokens of a session apart.
+	SessionID string `json:"sid"`
+	// APIKeyID is set instead of SessionID when the request was authenticated with an API key.
+	APIKeyID string `json:"-"`
 	jwt.RegisteredClaims
 }
 
@@ -30,3 +37,20 @@ type RefreshTokenResponseDto struct {
 	AccessToken  string `json:"accessToken"`
 	RefreshToken string `json:"refreshToken"`
 }
+
+// ZKSessionClientDto describes the device a login came from.
+type ZKSessionClientDto struct {
+	UserAgent string
+	IP        string
+	// ChallengeBinding is the binding the client received with its login challenge.
+	ChallengeBinding string
+}
+
+type ZKSessionResponseDto struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Current    bool      `json:"current"`
+}
@@ -4,16 +4,18 @@ import (
 	"be/internal/domain/credential"
 	"be/internal/shared/constant"
 	"encoding/hex"
-	"fmt"
 	"time"
 
 	"github.com/iden3/go-iden3-crypto/babyjub"
 	"github.com/iden3/go-merkletree-sql/v2"
 	"github.com/iden3/go-schema-processor/v2/verifiable"
+	"go.uber.org/zap"
 )
 
 type CredentialRequestUpdatedRequestDto struct {
-	Status string `json:"status"`
+	Status      constant.CredentialRequestStatus `json:"status"`
+	Reason      string                           `json:"reason"`
+	ReviewerDID string                           `json:"-"`
 }
 
 type CredentialRequestResponseDto struct {
@@ -32,12 +34,18 @@ type CredentialRequestResponseDto struct {
 	DocumentType constant.DocumentType            `json:"documentType"`
 	IsMerklized  bool                             `json:"isMerklized"`
 	Status       constant.CredentialRequestStatus `json:"status"`
+	StatusReason string                           `json:"statusReason,omitempty"`
 	Expiration   int64                            `json:"expiration"`
 	CreatedTime  *int64                           `json:"createdTime"`
 	ExpiresTime  *int64                           `json:"expiresTime"`
+	RefreshOf    string                           `json:"refreshOf,omitempty"`
 }
 
 func ToCredentialRequestResponseDto(credentialRequest *credential.CredentialRequest) *CredentialRequestResponseDto {
+	var refreshOf string
+	if credentialRequest.RefreshOf != nil {
+		refreshOf = credentialRequest.RefreshOf.CredentialID
+	}
 	return &CredentialRequestResponseDto{
 		PublicID:     credentialRequest.PublicID.String(),
 		ThreadID:     credentialRequest.ThreadID,
@@ -54,9 +62,53 @@ func ToCredentialRequestResponseDto(credentialRequest *credential.CredentialRequ
 		DocumentType: credentialRequest.Schema.DocumentType,
 		IsMerklized:  credentialRequest.Schema.IsMerklized,
 		Status:       credentialRequest.Status,
+		StatusReason: credentialRequest.StatusReason,
 		Expiration:   credentialRequest.Expiration,
 		CreatedTime:  credentialRequest.CreatedTime,
 		ExpiresTime:  credentialRequest.ExpiresTime,
+		RefreshOf:    refreshOf,
+	}
+}
+
+type CredentialRequestReviewResponseDto struct {
+	PublicID    string                           `json:"id"`
+	Decision    constant.CredentialRequestStatus `json:"decision"`
+	FromStatus  constant.CredentialRequestStatus `json:"fromStatus"`
+	ToStatus    constant.CredentialRequestStatus `json:"toStatus"`
+	ReviewerDID string                           `json:"reviewerDID"`
+	Reason      string                           `json:"reason"`
+	CreatedAt   time.Time                        `json:"createdAt"`
+}
+
+func ToCredentialRequestReviewResponseDto(entity *credential.CredentialRequestReview) *CredentialRequestReviewResponseDto {
+	return &CredentialRequestReviewResponseDto{
+		PublicID:    entity.PublicID.String(),
+		Decision:    entity.Decision,
+		FromStatus:  entity.FromStatus,
+		ToStatus:    entity.ToStatus,
+		ReviewerDID: entity.ReviewerDID,
+		Reason:      entity.Reason,
+		CreatedAt:   entity.CreatedAt,
+	}
+}
+
+type CredentialReviewPolicyRequestDto struct {
+	RequiredApprovals int      `json:"requiredApprovals"`
+	Reviewers         []string `json:"reviewers"`
+	IssuerDID         string   `json:"-"`
+}
+
+type CredentialReviewPolicyResponseDto struct {
+	IssuerDID         string   `json:"issuerDID"`
+	RequiredApprovals int      `json:"requiredApprovals"`
+	Reviewers         []string `json:"reviewers"`
+}
+
+func ToCredentialReviewPolicyResponseDto(entity *credential.CredentialReviewPolicy) *CredentialReviewPolicyResponseDto {
+	return &CredentialReviewPolicyResponseDto{
+		IssuerDID:         entity.IssuerDID,
+		RequiredApprovals: entity.RequiredApprovals,
+		Reviewers:         entity.Reviewers,
 	}
 }
 
@@ -67,10 +119,79 @@ type IssueVerifiableCredentialRequestDto struct {
 	Signature         string                      `json:"signature"`
 }
 
+type IssueVerifiableCredentialBatchItemDto struct {
+	RequestID         string                      `json:"requestId"`
+	CredentialStatus  verifiable.CredentialStatus `json:"credentialStatus"`
+	CredentialSubject map[string]interface{}      `json:"credentialSubject"`
+	Signature         string                      `json:"signature"`
+}
+
+type IssueVerifiableCredentialBatchRequestDto struct {
+	Items []*IssueVerifiableCredentialBatchItemDto `json:"items"`
+}
+
+type IssuanceBatchItemResponseDto struct {
+	RequestID    string                           `json:"requestId"`
+	Status       constant.IssuanceBatchItemStatus `json:"status"`
+	CredentialID string                           `json:"credentialId,omitempty"`
+	Error        string                           `json:"error,omitempty"`
+}
+
+type IssuanceBatchResponseDto struct {
+	PublicID    string                          `json:"id"`
+	IssuerDID   string                          `json:"issuerDID"`
+	Status      constant.IssuanceBatchStatus    `json:"status"`
+	TotalItems  int                             `json:"totalItems"`
+	IssuedItems int                             `json:"issuedItems"`
+	FailedItems int                             `json:"failedItems"`
+	OldState    string                          `json:"oldState,omitempty"`
+	NewState    string                          `json:"newState,omitempty"`
+	Error       string                          `json:"error,omitempty"`
+	CreatedAt   time.Time                       `json:"createdAt"`
+	CompletedAt *time.Time                      `json:"completedAt,omitempty"`
+	Items       []*IssuanceBatchItemResponseDto `json:"items,omitempty"`
+}
+
+func ToIssuanceBatchResponseDto(batch *credential.IssuanceBatch) *IssuanceBatchResponseDto {
+	items := make([]*IssuanceBatchItemResponseDto, 0, len(batch.Items))
+	for _, item := range batch.Items {
+		resp := &IssuanceBatchItemResponseDto{
+			Status:       item.Status,
+			CredentialID: item.CredentialID,
+			Error:        item.Error,
+		}
+		if item.CredentialRequest != nil {
+			resp.RequestID = item.CredentialRequest.PublicID.String()
+		}
+		items = append(items, resp)
+	}
+
+	return &IssuanceBatchResponseDto{
+		PublicID:    batch.PublicID.String(),
+		IssuerDID:   batch.IssuerDID,
+		Status:      batch.Status,
+		TotalItems:  batch.TotalItems,
+		IssuedItems: batch.IssuedItems,
+		FailedItems: batch.FailedItems,
+		OldState:    batch.OldState,
+		NewState:    batch.NewState,
+		Error:       batch.Error,
+		CreatedAt:   batch.CreatedAt,
+		CompletedAt: batch.CompletedAt,
+		Items:       items,
+	}
+}
+
 type VerifiableUpdatedRequestDto struct {
 	Status string `json:"status"`
 }
 
+// CredentialRefreshRequestDto asks the issuer to reissue a credential; CredentialID is the credential's own id.
+type CredentialRefreshRequestDto struct {
+	CredentialID string `json:"credentialId" binding:"required"`
+	HolderDID    string `json:"-"`
+}
+
 type VerifiableCredentialResponseDto struct {
 	PublicID          string                              `json:"id"`
 	CredentialID      string                              `json:"credentialId"`
@@ -107,15 +228,15 @@ func ToVerifiableCredentialResponseDto(vc *credential.VerifiableCredential) *Ver
 	var authProof merkletree.Proof
 	err := claimProof.UnmarshalJSON(vc.ClaimMTP)
 	if err != nil {
-		fmt.Println("claim proof unmarshal false %w", err)
+		zap.L().Warn("failed to unmarshal claim proof", zap.String("credential_id", vc.CredentialID), zap.Error(err))
 	}
 	err = authProof.UnmarshalJSON(vc.AuthClaimMTP)
 	if err != nil {
-		fmt.Println("auth claim proof unmarshal false %w", err)
+		zap.L().Warn("failed to unmarshal auth claim proof", zap.String("credential_id", vc.CredentialID), zap.Error(err))
 	}
 	signature, err := DecodeSignatureString(vc.Signature)
 	if err != nil {
-		fmt.Println("decode error %w", err)
+		zap.L().Warn("failed to decode credential signature", zap.String("credential_id", vc.CredentialID), zap.Error(err))
 	}
 
 	return &VerifiableCredentialResponseDto{
@@ -180,11 +301,11 @@ func ToW3CCredential(vc *credential.VerifiableCredential) *verifiable.W3CCredent
 
 	err := authIncProof.UnmarshalJSON(vc.AuthClaimMTP)
 	if err != nil {
-		fmt.Println("Unmarshal auth claim proof error: %w", err)
+		zap.L().Warn("failed to unmarshal auth claim proof", zap.String("credential_id", vc.CredentialID), zap.Error(err))
 	}
 	err = incProof.UnmarshalJSON(vc.ClaimMTP)
 	if err != nil {
-		fmt.Println("Unmarshal core claim proof error: %w", err)
+		zap.L().Warn("failed to unmarshal claim proof", zap.String("credential_id", vc.CredentialID), zap.Error(err))
 	}
 
 	iden3SparseMerkleProof := &verifiable.Iden3SparseMerkleTreeProof{
@@ -205,6 +326,14 @@ func ToW3CCredential(vc *credential.VerifiableCredential) *verifiable.W3CCredent
 		CoreClaim: vc.ClaimHex,
 		MTP:       &incProof,
 	}
+	var refreshService *verifiable.RefreshService
+	if vc.RefreshService != "" {
+		refreshService = &verifiable.RefreshService{
+			ID:   vc.RefreshService,
+			Type: verifiable.Iden3RefreshService2023,
+		}
+	}
+
 	bjjSignatureProof := &verifiable.BJJSignatureProof2021{
 		Type: verifiable.BJJSignatureProofType,
 		IssuerData: verifiable.IssuerData{
@@ -243,6 +372,165 @@ func ToW3CCredential(vc *credential.VerifiableCredential) *verifiable.W3CCredent
 			Type: verifiable.JSONSchema2023,
 		},
 		CredentialSubject: vc.CredentialSubject,
+		RefreshService:    refreshService,
 		Proof:             []verifiable.CredentialProof{iden3SparseMerkleProof, bjjSignatureProof},
 	}
 }
+
+type AutoApprovalRuleCreatedRequestDto struct {
+	SchemaID  string                         `json:"schemaId"`
+	Condition constant.AutoApprovalCondition `json:"condition"`
+	Enabled   *bool                          `json:"enabled,omitempty"`
+	IssuerDID string                         `json:"-"`
+}
+
+type AutoApprovalRuleUpdatedRequestDto struct {
+	Enabled   bool   `json:"enabled"`
+	IssuerDID string `json:"-"`
+}
+
+type AutoApprovalRuleResponseDto struct {
+	PublicID     string                         `json:"id"`
+	SchemaID     string                         `json:"schemaId"`
+	SchemaTitle  string                         `json:"schemaTitle"`
+	DocumentType constant.DocumentType          `json:"documentType"`
+	Condition    constant.AutoApprovalCondition `json:"condition"`
+	Enabled      bool                           `json:"enabled"`
+	IssuerDID    string                         `json:"issuerDID"`
+	CreatedAt    time.Time                      `json:"createdAt"`
+}
+
+func ToAutoApprovalRuleResponseDto(entity *credential.AutoApprovalRule) *AutoApprovalRuleResponseDto {
+	return &AutoApprovalRuleResponseDto{
+		PublicID:     entity.PublicID.String(),
+		SchemaID:     entity.Schema.PublicID.String(),
+		SchemaTitle:  entity.Schema.Title,
+		DocumentType: entity.Schema.DocumentType,
+		Condition:    entity.Condition,
+		Enabled:      entity.Enabled,
+		IssuerDID:    entity.IssuerDID,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
+type AutoApprovalDecisionResponseDto struct {
+	PublicID  string                       `json:"id"`
+	RequestID string                       `json:"requestId"`
+	HolderDID string                       `json:"holderDID"`
+	Outcome   constant.AutoApprovalOutcome `json:"outcome"`
+	Reason    string                       `json:"reason"`
+	// RequestStatus is the status the decision left the request in
+	RequestStatus constant.CredentialRequestStatus `json:"requestStatus,omitempty"`
+	CreatedAt     time.Time                        `json:"createdAt"`
+}
+
+func ToAutoApprovalDecisionResponseDto(entity *credential.AutoApprovalDecision) *AutoApprovalDecisionResponseDto {
+	return &AutoApprovalDecisionResponseDto{
+		PublicID:      entity.PublicID.String(),
+		RequestID:     entity.CredentialRequest.PublicID.String(),
+		HolderDID:     entity.CredentialRequest.HolderDID,
+		Outcome:       entity.Outcome,
+		Reason:        entity.Reason,
+		RequestStatus: entity.RequestStatus,
+		CreatedAt:     entity.CreatedAt,
+	}
+}
+
+type CredentialBundleExportRequestDto struct {
+	Passphrase string `json:"passphrase" binding:"required"`
+	HolderDID  string `json:"-"`
+}
+
+type CredentialBundleImportRequestDto struct {
+	Bundle     *CredentialBundleDto `json:"bundle" binding:"required"`
+	Passphrase string               `json:"passphrase" binding:"required"`
+	HolderDID  string               `json:"-"`
+}
+
+// CredentialBundleDto is the portable export of a holder's credentials. The content is encrypted under a key
+// derived from the holder's passphrase; the holder DID and version are authenticated with it.
+type CredentialBundleDto struct {
+	Version    int                    `json:"version"`
+	HolderDID  string                 `json:"holderDID"`
+	ExportedAt time.Time              `json:"exportedAt"`
+	KDF        CredentialBundleKDFDto `json:"kdf"`
+	Nonce      []byte                 `json:"nonce"`
+	Ciphertext []byte                 `json:"ciphertext"`
+}
+
+type CredentialBundleKDFDto struct {
+	Algorithm string `json:"algorithm"`
+	Time      uint32 `json:"time"`
+	Memory    uint32 `json:"memory"`
+	Threads   uint8  `json:"threads"`
+	Salt      []byte `json:"salt"`
+}
+
+// CredentialBundleContentDto is the decrypted bundle. Documents holds the JSON schema and JSON-LD context of
+// every credential, keyed by URL, so the bundle can be verified without the exporting deployment.
+type CredentialBundleContentDto struct {
+	HolderDID   string                            `json:"holderDID"`
+	ExportedAt  time.Time                         `json:"exportedAt"`
+	Credentials []*CredentialBundleEntryDto       `json:"credentials"`
+	Documents   map[string]map[string]interface{} `json:"documents"`
+}
+
+type CredentialBundleEntryDto struct {
+	Credential  *verifiable.W3CCredential           `json:"credential"`
+	Status      constant.VerifiableCredentialStatus `json:"status"`
+	IssuerState CredentialBundleIssuerStateDto      `json:"issuerState"`
+}
+
+// CredentialBundleIssuerStateDto is the issuer state the credential was proven against at issuance.
+type CredentialBundleIssuerStateDto struct {
+	State          string `json:"state"`
+	ClaimsTreeRoot string `json:"claimsTreeRoot"`
+	RevTreeRoot    string `json:"revTreeRoot"`
+	RootsTreeRoot  string `json:"rootsTreeRoot"`
+}
+
+type CredentialBundleImportResponseDto struct {
+	Imported int      `json:"imported"`
+	Skipped  []string `json:"skipped"`
+}
+
+type ImportedCredentialResponseDto struct {
+	PublicID     string                              `json:"id"`
+	CredentialID string                              `json:"credentialId"`
+	IssuerDID    string                              `json:"issuerDID"`
+	SchemaType   string                              `json:"schemaType"`
+	Status       constant.VerifiableCredentialStatus `json:"status"`
+	Credential   map[string]interface{}              `json:"credential"`
+	IssuerState  map[string]interface{}              `json:"issuerState"`
+	ExportedAt   time.Time                           `json:"exportedAt"`
+	CreatedAt    time.Time                           `json:"createdAt"`
+}
+
+func ToImportedCredentialResponseDto(entity *credential.ImportedCredential) *ImportedCredentialResponseDto {
+	return &ImportedCredentialResponseDto{
+		PublicID:     entity.PublicID.String(),
+		CredentialID: entity.CredentialID,
+		IssuerDID:    entity.IssuerDID,
+		SchemaType:   entity.SchemaType,
+		Status:       entity.Status,
+		Credential:   entity.Credential,
+		IssuerState:  entity.IssuerState,
+		ExportedAt:   entity.ExportedAt,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
+// CredentialVerificationResponseDto lists every check run on a credential. Valid is true only when no check failed
+// and the checks tying the credential to its issuer ran. Unverifiable marks a credential that failed no check but
+// could not be tied to its issuer here, such as one from an issuer this deployment does not manage.
+type CredentialVerificationResponseDto struct {
+	Valid        bool                  `json:"valid"`
+	Unverifiable bool                  `json:"unverifiable,omitempty"`
+	Checks       []*CredentialCheckDto `json:"checks"`
+}
+
+type CredentialCheckDto struct {
+	Name    string                         `json:"name"`
+	Status  constant.CredentialCheckStatus `json:"status"`
+	Message string                         `json:"message,omitempty"`
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"be/internal/domain/document"
 	"be/internal/shared/constant"
+	"time"
 )
 
 // Citizen Identity
@@ -26,16 +27,19 @@ type CitizenIdentityUpdatedRequestDto struct {
 	PlaceOfBirth string          `json:"placeOfBirth,omitempty"`
 	IssueDate    int64           `json:"issueDate,omitempty"`
 	ExpiryDate   int64           `json:"expiryDate,omitempty"`
+	ChangedBy    string          `json:"-"`
 }
 
 type CitizenIdentityOptionRequestDto struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	ChangedBy string `json:"-"`
 }
 
 type CitizenIdentityResponseDto struct {
 	PublicID     string                  `json:"id"`
 	IDNumber     string                  `json:"idNumber"`
 	Status       constant.DocumentStatus `json:"status"`
+	Revision     int                     `json:"revision"`
 	FirstName    string                  `json:"firstName"`
 	LastName     string                  `json:"lastName"`
 	Gender       constant.Gender         `json:"gender"`
@@ -68,16 +72,19 @@ type AcademicDegreeUpdatedRequestDto struct {
 	GPA            float32             `json:"gpa,omitempty"`
 	Classification string              `json:"classification,omitempty"`
 	IssueDate      int64               `json:"issueDate,omitempty"`
+	ChangedBy      string              `json:"-"`
 }
 
 type AcademicDegreeOptionRequestDto struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	ChangedBy string `json:"-"`
 }
 
 type AcademicDegreeResponseDto struct {
 	PublicID       string                  `json:"id"`
 	DegreeNumber   string                  `json:"degreeNumber"`
 	Status         constant.DocumentStatus `json:"status"`
+	Revision       int                     `json:"revision"`
 	DegreeType     constant.DegreeType     `json:"degreeType"`
 	Major          string                  `json:"major"`
 	University     string                  `json:"university"`
@@ -104,16 +111,19 @@ type HealthInsuranceUpdatedRequestDto struct {
 	Hospital      string `json:"hospital,omitempty"`
 	StartDate     int64  `json:"startDate,omitempty"`
 	ExpiryDate    int64  `json:"expiryDate,omitempty"`
+	ChangedBy     string `json:"-"`
 }
 
 type HealthInsuranceOptionRequestDto struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	ChangedBy string `json:"-"`
 }
 
 type HealthInsuranceResponseDto struct {
 	PublicID        string                  `json:"id"`
 	InsuranceNumber string                  `json:"insuranceNumber"`
 	Status          constant.DocumentStatus `json:"status"`
+	Revision        int                     `json:"revision"`
 	InsuranceType   string                  `json:"insuranceType"`
 	Hospital        string                  `json:"hospital"`
 	StartDate       int64                   `json:"startDate"`
@@ -131,21 +141,53 @@ type DriverLicenseCreatedRequestDto struct {
 	IssuerDID  string `json:"issuerDID"`
 }
 
+// DriverLicenseUpdatedRequestDto amends a licence. The points balance is not part of it: points only change
+// through the points ledger.
 type DriverLicenseUpdatedRequestDto struct {
-	Point      uint   `json:"point,omitempty"`
 	Class      string `json:"class,omitempty"`
 	IssueDate  int64  `json:"issueDate,omitempty"`
 	ExpiryDate int64  `json:"expiryDate,omitempty"`
+	ChangedBy  string `json:"-"`
 }
 
 type DriverLicenseOptionRequestDto struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	ChangedBy string `json:"-"`
+}
+
+type DriverLicenseRenewedRequestDto struct {
+	Class      string `json:"class,omitempty"`
+	IssueDate  int64  `json:"issueDate"`
+	ExpiryDate int64  `json:"expiryDate"`
+	IssuerDID  string `json:"-"`
+}
+
+type DriverLicensePointRequestDto struct {
+	Points    int    `json:"points"`
+	Reason    string `json:"reason"`
+	Reference string `json:"reference,omitempty"`
+	CreatedBy string `json:"-"`
+}
+
+type DriverLicensePointEntryResponseDto struct {
+	PublicID   string                         `json:"id"`
+	Kind       constant.LicensePointEntryKind `json:"kind"`
+	Points     int                            `json:"points"`
+	Balance    int                            `json:"balance"`
+	Reason     string                         `json:"reason"`
+	Reference  string                         `json:"reference,omitempty"`
+	RestoreAt  *time.Time                     `json:"restoreAt,omitempty"`
+	RestoredAt *time.Time                     `json:"restoredAt,omitempty"`
+	CreatedBy  string                         `json:"createdBy"`
+	CreatedAt  time.Time                      `json:"createdAt"`
 }
 
 type DriverLicenseResponseDto struct {
 	PublicID      string                  `json:"id"`
 	LicenseNumber string                  `json:"licenseNumber"`
 	Status        constant.DocumentStatus `json:"status"`
+	Revision      int                     `json:"revision"`
+	SupersededBy  string                  `json:"supersededBy,omitempty"`
 	Point         uint                    `json:"point"`
 	Class         string                  `json:"class"`
 	IssueDate     int64                   `json:"issueDate"`
@@ -171,16 +213,28 @@ type PassportUpdatedRequestDto struct {
 	MRZ          string                `json:"mrz,omitempty"`
 	IssueDate    int64                 `json:"issueDate,omitempty"`
 	ExpiryDate   int64                 `json:"expiryDate,omitempty"`
+	ChangedBy    string                `json:"-"`
 }
 
 type PassportOptionRequestDto struct {
-	Status string `json:"status"`
+	Status    string `json:"status"`
+	ChangedBy string `json:"-"`
+}
+
+type PassportRenewedRequestDto struct {
+	PassportType constant.PassportType `json:"passportType,omitempty"`
+	MRZ          string                `json:"mrz"`
+	IssueDate    int64                 `json:"issueDate"`
+	ExpiryDate   int64                 `json:"expiryDate"`
+	IssuerDID    string                `json:"-"`
 }
 
 type PassportResponseDto struct {
 	PublicID       string                  `json:"id"`
 	PassportNumber string                  `json:"passportNumber"`
 	Status         constant.DocumentStatus `json:"status"`
+	Revision       int                     `json:"revision"`
+	SupersededBy   string                  `json:"supersededBy,omitempty"`
 	PassportType   constant.PassportType   `json:"passportType"`
 	Nationality    string                  `json:"nationality"`
 	MRZ            string                  `json:"mrz"`
@@ -190,12 +244,82 @@ type PassportResponseDto struct {
 	IssuerDID      string                  `json:"issuerDID"`
 }
 
+// Document Revision
+type DocumentRevisionResponseDto struct {
+	PublicID     string                          `json:"id"`
+	DocumentType constant.DocumentType           `json:"documentType"`
+	Revision     int                             `json:"revision"`
+	Action       constant.DocumentRevisionAction `json:"action"`
+	Changes      map[string]interface{}          `json:"changes,omitempty"`
+	Snapshot     map[string]interface{}          `json:"snapshot"`
+	ChangedBy    string                          `json:"changedBy"`
+	CreatedAt    time.Time                       `json:"createdAt"`
+}
+
+// Import Job
+type ImportJobResponseDto struct {
+	PublicID      string                   `json:"id"`
+	DocumentType  constant.DocumentType    `json:"documentType"`
+	FileName      string                   `json:"fileName"`
+	Status        constant.ImportJobStatus `json:"status"`
+	TotalRows     int                      `json:"totalRows"`
+	ProcessedRows int                      `json:"processedRows"`
+	CreatedRows   int                      `json:"createdRows"`
+	UpdatedRows   int                      `json:"updatedRows"`
+	FailedRows    int                      `json:"failedRows"`
+	IssuerDID     string                   `json:"issuerDID"`
+	CreatedAt     time.Time                `json:"createdAt"`
+	CompletedAt   *time.Time               `json:"completedAt,omitempty"`
+}
+
+// Holder Documents
+type HolderDocumentsResponseDto struct {
+	CitizenIdentity *CitizenIdentityResponseDto `json:"citizenIdentity,omitempty"`
+	AcademicDegree  *AcademicDegreeResponseDto  `json:"academicDegree,omitempty"`
+	HealthInsurance *HealthInsuranceResponseDto `json:"healthInsurance,omitempty"`
+	DriverLicense   *DriverLicenseResponseDto   `json:"driverLicense,omitempty"`
+	Passport        *PassportResponseDto        `json:"passport,omitempty"`
+}
+
+// Correction Request
+type CorrectionRequestCreatedRequestDto struct {
+	DocumentType  constant.DocumentType `json:"documentType"`
+	Field         string                `json:"field"`
+	ProposedValue string                `json:"proposedValue"`
+	Evidence      string                `json:"evidence,omitempty"`
+	HolderDID     string                `json:"-"`
+}
+
+type CorrectionRequestReviewedRequestDto struct {
+	Status     constant.CorrectionRequestStatus `json:"status"`
+	Note       string                           `json:"note,omitempty"`
+	ReviewedBy string                           `json:"-"`
+}
+
+type CorrectionRequestResponseDto struct {
+	PublicID         string                           `json:"id"`
+	DocumentType     constant.DocumentType            `json:"documentType"`
+	DocumentPublicID string                           `json:"documentId"`
+	Field            string                           `json:"field"`
+	CurrentValue     string                           `json:"currentValue"`
+	ProposedValue    string                           `json:"proposedValue"`
+	Evidence         string                           `json:"evidence,omitempty"`
+	Status           constant.CorrectionRequestStatus `json:"status"`
+	ReviewNote       string                           `json:"reviewNote,omitempty"`
+	HolderDID        string                           `json:"holderDID"`
+	IssuerDID        string                           `json:"issuerDID"`
+	ReviewedBy       string                           `json:"reviewedBy,omitempty"`
+	CreatedAt        time.Time                        `json:"createdAt"`
+	ReviewedAt       *time.Time                       `json:"reviewedAt,omitempty"`
+}
+
 // Convert
 func CitizenIdentityToResponse(entity *document.CitizenIdentity) *CitizenIdentityResponseDto {
 	return &CitizenIdentityResponseDto{
 		PublicID:     entity.PublicID.String(),
 		IDNumber:     entity.IDNumber,
 		Status:       entity.Status,
+		Revision:     entity.Revision,
 		FirstName:    entity.FirstName,
 		LastName:     entity.LastName,
 		Gender:       entity.Gender,
@@ -213,6 +337,7 @@ func AcademicDegreeToResponse(entity *document.AcademicDegree) *AcademicDegreeRe
 		PublicID:       entity.PublicID.String(),
 		DegreeNumber:   entity.DegreeNumber,
 		Status:         entity.Status,
+		Revision:       entity.Revision,
 		DegreeType:     entity.DegreeType,
 		Major:          entity.Major,
 		University:     entity.University,
@@ -230,6 +355,7 @@ func HealthInsuranceToResponse(entity *document.HealthInsurance) *HealthInsuranc
 		PublicID:        entity.PublicID.String(),
 		InsuranceNumber: entity.InsuranceNumber,
 		Status:          entity.Status,
+		Revision:        entity.Revision,
 		InsuranceType:   entity.InsuranceType,
 		Hospital:        entity.Hospital,
 		StartDate:       entity.StartDate,
@@ -240,10 +366,11 @@ func HealthInsuranceToResponse(entity *document.HealthInsurance) *HealthInsuranc
 }
 
 func DriverLicenseToResponse(entity *document.DriverLicense) *DriverLicenseResponseDto {
-	return &DriverLicenseResponseDto{
+	resp := &DriverLicenseResponseDto{
 		PublicID:      entity.PublicID.String(),
 		LicenseNumber: entity.LicenseNumber,
 		Status:        entity.Status,
+		Revision:      entity.Revision,
 		Class:         entity.Class,
 		Point:         entity.Point,
 		IssueDate:     entity.IssueDate,
@@ -251,14 +378,19 @@ func DriverLicenseToResponse(entity *document.DriverLicense) *DriverLicenseRespo
 		HolderDID:     entity.HolderDID,
 		IssuerDID:     entity.IssuerDID,
 	}
+	if entity.SupersededBy != nil {
+		resp.SupersededBy = entity.SupersededBy.String()
+	}
+	return resp
 }
 
 func PassportToResponse(entity *document.Passport) *PassportResponseDto {
-	return &PassportResponseDto{
+	resp := &PassportResponseDto{
 		PublicID:       entity.PublicID.String(),
 		PassportNumber: entity.PassportNumber,
 		PassportType:   entity.PassportType,
 		Status:         entity.Status,
+		Revision:       entity.Revision,
 		Nationality:    entity.Nationality,
 		MRZ:            entity.MRZ,
 		IssueDate:      entity.IssueDate,
@@ -266,4 +398,72 @@ func PassportToResponse(entity *document.Passport) *PassportResponseDto {
 		HolderDID:      entity.HolderDID,
 		IssuerDID:      entity.IssuerDID,
 	}
+	if entity.SupersededBy != nil {
+		resp.SupersededBy = entity.SupersededBy.String()
+	}
+	return resp
+}
+
+func DocumentRevisionToResponse(entity *document.DocumentRevision) *DocumentRevisionResponseDto {
+	return &DocumentRevisionResponseDto{
+		PublicID:     entity.PublicID.String(),
+		DocumentType: entity.DocumentType,
+		Revision:     entity.Revision,
+		Action:       entity.Action,
+		Changes:      entity.Changes,
+		Snapshot:     entity.Snapshot,
+		ChangedBy:    entity.ChangedBy,
+		CreatedAt:    entity.CreatedAt,
+	}
+}
+
+func DriverLicensePointEntryToResponse(entity *document.DriverLicensePointEntry) *DriverLicensePointEntryResponseDto {
+	return &DriverLicensePointEntryResponseDto{
+		PublicID:   entity.PublicID.String(),
+		Kind:       entity.Kind,
+		Points:     entity.Points,
+		Balance:    entity.Balance,
+		Reason:     entity.Reason,
+		Reference:  entity.Reference,
+		RestoreAt:  entity.RestoreAt,
+		RestoredAt: entity.RestoredAt,
+		CreatedBy:  entity.CreatedBy,
+		CreatedAt:  entity.CreatedAt,
+	}
+}
+
+func ImportJobToResponse(entity *document.ImportJob) *ImportJobResponseDto {
+	return &ImportJobResponseDto{
+		PublicID:      entity.PublicID.String(),
+		DocumentType:  entity.DocumentType,
+		FileName:      entity.FileName,
+		Status:        entity.Status,
+		TotalRows:     entity.TotalRows,
+		ProcessedRows: entity.ProcessedRows,
+		CreatedRows:   entity.CreatedRows,
+		UpdatedRows:   entity.UpdatedRows,
+		FailedRows:    entity.FailedRows,
+		IssuerDID:     entity.IssuerDID,
+		CreatedAt:     entity.CreatedAt,
+		CompletedAt:   entity.CompletedAt,
+	}
+}
+
+func CorrectionRequestToResponse(entity *document.CorrectionRequest) *CorrectionRequestResponseDto {
+	return &CorrectionRequestResponseDto{
+		PublicID:         entity.PublicID.String(),
+		DocumentType:     entity.DocumentType,
+		DocumentPublicID: entity.DocumentPublicID.String(),
+		Field:            entity.Field,
+		CurrentValue:     entity.CurrentValue,
+		ProposedValue:    entity.ProposedValue,
+		Evidence:         entity.Evidence,
+		Status:           entity.Status,
+		ReviewNote:       entity.ReviewNote,
+		HolderDID:        entity.HolderDID,
+		IssuerDID:        entity.IssuerDID,
+		ReviewedBy:       entity.ReviewedBy,
+		CreatedAt:        entity.CreatedAt,
+		ReviewedAt:       entity.ReviewedAt,
+	}
 }
@@ -3,6 +3,9 @@ package dto
 import (
 	"be/internal/domain/schema"
 	"be/internal/shared/constant"
+	"time"
+
+	"github.com/iden3/go-schema-processor/v2/verifiable"
 )
 
 type IdentityCreatedRequestDto struct {
@@ -20,16 +23,109 @@ type IdentityResponseDto struct {
 	Role       constant.IdentityRole `json:"role"`
 	DID        string                `json:"did"`
 	State      string                `json:"state"`
+	// Roles are the roles the identity holds now; Role is the one it acts in.
+	Roles         []constant.IdentityRole `json:"roles"`
+	SuspendedAt   *time.Time              `json:"suspendedAt,omitempty"`
+	SuspendReason string                  `json:"suspendReason,omitempty"`
 }
 
 func ToIdentityResponseDto(entity *schema.Identity) *IdentityResponseDto {
 	return &IdentityResponseDto{
+		PublicID:      entity.PublicID.String(),
+		PublicKeyX:    entity.PublicKeyX,
+		PublicKeyY:    entity.PublicKeyY,
+		Role:          entity.ActingRole(),
+		Name:          entity.Name,
+		DID:           string(entity.DID),
+		State:         string(entity.State),
+		Roles:         entity.ActiveRoles(),
+		SuspendedAt:   entity.SuspendedAt,
+		SuspendReason: entity.SuspendReason,
+	}
+}
+
+// Identity moderation
+type IdentitySuspendedRequestDto struct {
+	Reason      string `json:"reason" binding:"required,max=2000"`
+	SuspendedBy string `json:"-"`
+}
+
+// Role onboarding
+type RoleApplicationCreatedRequestDto struct {
+	Role constant.IdentityRole `json:"role"`
+	Note string                `json:"note,omitempty"`
+	// Credential is an optional accreditation credential; a valid one approves the application at once.
+	Credential *verifiable.W3CCredential `json:"credential,omitempty"`
+	DID        string                    `json:"-"`
+}
+
+type RoleApplicationReviewedRequestDto struct {
+	Status     constant.RoleApplicationStatus `json:"status"`
+	Note       string                         `json:"note,omitempty"`
+	ReviewedBy string                         `json:"-"`
+}
+
+type RoleApplicationResponseDto struct {
+	PublicID   string                         `json:"id"`
+	DID        string                         `json:"did"`
+	Role       constant.IdentityRole          `json:"role"`
+	Status     constant.RoleApplicationStatus `json:"status"`
+	Note       string                         `json:"note,omitempty"`
+	Accredited bool                           `json:"accredited"`
+	ReviewNote string                         `json:"reviewNote,omitempty"`
+	ReviewedBy string                         `json:"reviewedBy,omitempty"`
+	CreatedAt  time.Time                      `json:"createdAt"`
+	ReviewedAt *time.Time                     `json:"reviewedAt,omitempty"`
+}
+
+type RoleGrantCreatedRequestDto struct {
+	DID       string                `json:"did" binding:"required"`
+	Role      constant.IdentityRole `json:"role" binding:"required"`
+	GrantedBy string                `json:"-"`
+}
+
+type RoleGrantRevokedRequestDto struct {
+	Reason    string `json:"reason" binding:"required"`
+	RevokedBy string `json:"-"`
+}
+
+type RoleGrantResponseDto struct {
+	PublicID     string                   `json:"id"`
+	DID          string                   `json:"did"`
+	Role         constant.IdentityRole    `json:"role"`
+	Method       constant.RoleGrantMethod `json:"method"`
+	GrantedBy    string                   `json:"grantedBy"`
+	GrantedAt    time.Time                `json:"grantedAt"`
+	RevokedBy    string                   `json:"revokedBy,omitempty"`
+	RevokeReason string                   `json:"revokeReason,omitempty"`
+	RevokedAt    *time.Time               `json:"revokedAt,omitempty"`
+}
+
+func ToRoleApplicationResponseDto(entity *schema.RoleApplication) *RoleApplicationResponseDto {
+	return &RoleApplicationResponseDto{
 		PublicID:   entity.PublicID.String(),
-		PublicKeyX: entity.PublicKeyX,
-		PublicKeyY: entity.PublicKeyY,
+		DID:        entity.DID,
 		Role:       entity.Role,
-		Name:       entity.Name,
-		DID:        string(entity.DID),
-		State:      string(entity.State),
+		Status:     entity.Status,
+		Note:       entity.Note,
+		Accredited: len(entity.Credential) > 0,
+		ReviewNote: entity.ReviewNote,
+		ReviewedBy: entity.ReviewedBy,
+		CreatedAt:  entity.CreatedAt,
+		ReviewedAt: entity.ReviewedAt,
+	}
+}
+
+func ToRoleGrantResponseDto(entity *schema.IdentityRoleGrant) *RoleGrantResponseDto {
+	return &RoleGrantResponseDto{
+		PublicID:     entity.PublicID.String(),
+		DID:          entity.DID,
+		Role:         entity.Role,
+		Method:       entity.Method,
+		GrantedBy:    entity.GrantedBy,
+		GrantedAt:    entity.GrantedAt,
+		RevokedBy:    entity.RevokedBy,
+		RevokeReason: entity.RevokeReason,
+		RevokedAt:    entity.RevokedAt,
 	}
 }
@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"be/internal/domain/notification"
+	"be/internal/shared/constant"
+	"time"
+)
+
+type NotificationResponseDto struct {
+	PublicID  string                    `json:"id"`
+	Kind      constant.NotificationKind `json:"kind"`
+	Message   string                    `json:"message"`
+	Reference string                    `json:"reference,omitempty"`
+	CreatedAt time.Time                 `json:"createdAt"`
+	ReadAt    *time.Time                `json:"readAt,omitempty"`
+}
+
+func NotificationToResponse(entity *notification.Notification) *NotificationResponseDto {
+	return &NotificationResponseDto{
+		PublicID:  entity.PublicID.String(),
+		Kind:      entity.Kind,
+		Message:   entity.Message,
+		Reference: entity.Reference,
+		CreatedAt: entity.CreatedAt,
+		ReadAt:    entity.ReadAt,
+	}
+}
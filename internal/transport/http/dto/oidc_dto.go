@@ -0,0 +1,108 @@
+package dto
+
+import (
+	"be/internal/domain/auth"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/iden3/iden3comm/v2/protocol"
+)
+
+// OIDCDiscoveryDto is the OpenID provider metadata served at /.well-known/openid-configuration.
+type OIDCDiscoveryDto struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+}
+
+// OIDCAuthorizeRequestDto is the authorization request a relying party sends the browser to.
+type OIDCAuthorizeRequestDto struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// OIDCAuthorizationDto is what the wallet is shown to sign in to a relying party: the login challenge, with a
+// credential proof request for every claim scope asked for.
+type OIDCAuthorizationDto struct {
+	ClientName string                                `json:"clientName"`
+	Scopes     []string                              `json:"scopes"`
+	Request    *protocol.AuthorizationRequestMessage `json:"request"`
+}
+
+type OIDCRedirectDto struct {
+	RedirectURI string `json:"redirectUri"`
+}
+
+type OIDCTokenRequestDto struct {
+	GrantType    string `form:"grant_type"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	CodeVerifier string `form:"code_verifier"`
+}
+
+type OIDCTokenResponseDto struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	IDToken     string `json:"id_token"`
+	Scope       string `json:"scope"`
+}
+
+// OIDCAccessClaims are the claims of an access token for the userinfo endpoint.
+type OIDCAccessClaims struct {
+	Scope    string `json:"scope"`
+	ClientID string `json:"client_id"`
+	jwt.RegisteredClaims
+}
+
+type OIDCClientCreatedRequestDto struct {
+	Name         string   `json:"name" binding:"required,max=255"`
+	RedirectURIs []string `json:"redirectUris" binding:"required,min=1,dive,url"`
+	Scopes       []string `json:"scopes" binding:"required,min=1"`
+	// Public clients, such as single-page apps, get no secret and authenticate with PKCE alone.
+	Public    bool   `json:"public"`
+	CreatedBy string `json:"-"`
+}
+
+type OIDCClientResponseDto struct {
+	ClientID     string     `json:"clientId"`
+	ClientSecret string     `json:"clientSecret,omitempty"`
+	Name         string     `json:"name"`
+	RedirectURIs []string   `json:"redirectUris"`
+	Scopes       []string   `json:"scopes"`
+	Public       bool       `json:"public"`
+	CreatedBy    string     `json:"createdBy"`
+	CreatedAt    time.Time  `json:"createdAt"`
+	DisabledAt   *time.Time `json:"disabledAt,omitempty"`
+}
+
+func ToOIDCClientResponseDto(entity *auth.OIDCClient) *OIDCClientResponseDto {
+	return &OIDCClientResponseDto{
+		ClientID:     entity.ClientID,
+		Name:         entity.Name,
+		RedirectURIs: entity.RedirectURIs,
+		Scopes:       entity.Scopes,
+		Public:       entity.SecretHash == "",
+		CreatedBy:    entity.CreatedBy,
+		CreatedAt:    entity.CreatedAt,
+		DisabledAt:   entity.DisabledAt,
+	}
+}
@@ -4,12 +4,12 @@ import (
 	"be/internal/domain/proof"
 	"be/internal/shared/constant"
 	"encoding/json"
-	"fmt"
 	"time"
 
 	"github.com/iden3/go-rapidsnark/types"
 	"github.com/iden3/iden3comm/v2/packers"
 	"github.com/iden3/iden3comm/v2/protocol"
+	"go.uber.org/zap"
 )
 
 type ProofRequestUpdatedRequestDto struct {
@@ -125,7 +125,7 @@ func ToAuthorizationResponse(ps *proof.ProofSubmission) protocol.AuthorizationRe
 	var zkProof types.ZKProof
 	err := json.Unmarshal(ps.ZKProof, &zkProof)
 	if err != nil {
-		fmt.Println("zk proof unmarshal false %w", err)
+		zap.L().Warn("failed to unmarshal zk proof", zap.String("thread_id", ps.ThreadID), zap.Error(err))
 	}
 
 	return protocol.AuthorizationResponseMessage{
@@ -154,7 +154,7 @@ func ToProofSubmissionResponseDto(entity *proof.ProofSubmission) *ProofSubmissio
 	var zkProof types.ZKProof
 	err := json.Unmarshal(entity.ZKProof, &zkProof)
 	if err != nil {
-		fmt.Println("zk proof unmarshal false %w", err)
+		zap.L().Warn("failed to unmarshal zk proof", zap.String("thread_id", entity.ThreadID), zap.Error(err))
 	}
 	return &ProofSubmissionResponseDto{
 		PublicID:     entity.PublicID.String(),
@@ -17,20 +17,41 @@ type SchemaBuilderDto struct {
 }
 
 type SchemaAttributeDto struct {
-	Name        string                 `json:"name"`
-	Title       string                 `json:"title"`
-	Type        constant.AttributeType `json:"type"`
-	Description string                 `json:"description"`
-	Required    bool                   `json:"required"`
-	Slot        constant.Slot          `json:"slot"`
-	Format      string                 `json:"format,omitempty"`
-	Pattern     string                 `json:"pattern,omitempty"`
-	MinLength   *int                   `json:"minLength,omitempty"`
-	MaxLength   *int                   `json:"maxLength,omitempty"`
-	Minimum     *float64               `json:"minimum,omitempty"`
-	Maximum     *float64               `json:"maximum,omitempty"`
-	Enum        map[string]interface{} `json:"enum,omitempty"`
+	Name          string                    `json:"name"`
+	Title         string                    `json:"title"`
+	Type          constant.AttributeType    `json:"type"`
+	Description   string                    `json:"description"`
+	Required      bool                      `json:"required"`
+	Slot          constant.Slot             `json:"slot"`
+	Format        string                    `json:"format,omitempty"`
+	Pattern       string                    `json:"pattern,omitempty"`
+	MinLength     *int                      `json:"minLength,omitempty"`
+	MaxLength     *int                      `json:"maxLength,omitempty"`
+	Minimum       *float64                  `json:"minimum,omitempty"`
+	Maximum       *float64                  `json:"maximum,omitempty"`
+	Enum          map[string]interface{}    `json:"enum,omitempty"`
+	DocumentField string                    `json:"documentField,omitempty"`
+	Transform     constant.MappingTransform `json:"transform,omitempty"`
+	EnumCodes     map[string]interface{}    `json:"enumCodes,omitempty"`
 }
+
+type SchemaAttributeMappingDto struct {
+	Name          string                    `json:"name"`
+	DocumentField string                    `json:"documentField"`
+	Transform     constant.MappingTransform `json:"transform"`
+	EnumCodes     map[string]interface{}    `json:"enumCodes,omitempty"`
+}
+type SchemaFilterDto struct {
+	IssuerDID    string                `form:"issuerDID"`
+	DocumentType constant.DocumentType `form:"documentType"`
+	Status       constant.SchemaStatus `form:"status"`
+	Type         string                `form:"type"`
+	IsMerklized  *bool                 `form:"isMerklized"`
+	Query        string                `form:"q"`
+	Cursor       string                `form:"cursor"`
+	Limit        int                   `form:"limit"`
+}
+
 type SchemaResponseDto struct {
 	PublicID     string                `json:"id"`
 	IssuerDID    string                `json:"issuerDID"`
@@ -52,12 +73,15 @@ func ToSchemaResponseDto(schema *schema.Schema) *SchemaResponseDto {
 	var attributesDtos []SchemaAttributeDto
 	for _, item := range schema.SchemaAttributes {
 		attributesDtos = append(attributesDtos, SchemaAttributeDto{
-			Name:        item.Name,
-			Title:       item.Title,
-			Type:        item.Type,
-			Description: item.Description,
-			Required:    item.Required,
-			Slot:        item.Slot,
+			Name:          item.Name,
+			Title:         item.Title,
+			Type:          item.Type,
+			Description:   item.Description,
+			Required:      item.Required,
+			Slot:          item.Slot,
+			DocumentField: item.DocumentField,
+			Transform:     item.Transform,
+			EnumCodes:     item.EnumCodes,
 		})
 	}
 	return &SchemaResponseDto{
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler serves the moderation APIs of operator admins.
+type AdminHandler struct {
+	moderationService service.IModerationService
+}
+
+func NewAdminHandler(ms service.IModerationService) *AdminHandler {
+	return &AdminHandler{
+		moderationService: ms,
+	}
+}
+
+func (h *AdminHandler) GetIdentities(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	identities, pagination, err := h.moderationService.GetIdentities(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, identities, pagination)
+}
+
+func (h *AdminHandler) SuspendIdentity(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	var suspendRequest dto.IdentitySuspendedRequestDto
+	if err := c.ShouldBindJSON(&suspendRequest); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	suspendRequest.SuspendedBy = claims.Email
+
+	identity, err := h.moderationService.SuspendIdentity(c.Request.Context(), id, &suspendRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, identity)
+}
+
+func (h *AdminHandler) ReinstateIdentity(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	identity, err := h.moderationService.ReinstateIdentity(c.Request.Context(), id)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, identity)
+}
+
+func (h *AdminHandler) RevokeSchema(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	if err := h.moderationService.RevokeSchema(c.Request.Context(), id); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
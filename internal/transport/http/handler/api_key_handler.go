@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler lets verifiers manage the API keys of their backends. Every call acts on the keys of the signed
+// in identity.
+type APIKeyHandler struct {
+	apiKeyService service.IAPIKeyService
+}
+
+func NewAPIKeyHandler(as service.IAPIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: as,
+	}
+}
+
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var apiKeyRequest dto.APIKeyCreatedRequestDto
+	if err := c.ShouldBindJSON(&apiKeyRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	apiKeyRequest.DID = claims.DID
+
+	apiKey, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), &apiKeyRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, apiKey)
+}
+
+func (h *APIKeyHandler) GetAPIKeys(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	apiKeys, pagination, err := h.apiKeyService.GetAPIKeys(c.Request.Context(), claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, apiKeys, pagination)
+}
+
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), claims.DID, id); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
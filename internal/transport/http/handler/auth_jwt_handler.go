@@ -6,55 +6,124 @@ import (
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
 	"be/pkg/logger"
+	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthJWTHandler struct {
-	authService service.IAuthJWTService
-	logger      *logger.ZapLogger
+	authService       service.IAuthJWTService
+	signingKeyService service.ISigningKeyService
+	logger            *logger.ZapLogger
 }
 
-func NewAuthJWTHandler(as service.IAuthJWTService, logger *logger.ZapLogger) *AuthJWTHandler {
-	return &AuthJWTHandler{authService: as, logger: logger}
+func NewAuthJWTHandler(as service.IAuthJWTService, signingKeyService service.ISigningKeyService, logger *logger.ZapLogger) *AuthJWTHandler {
+	return &AuthJWTHandler{authService: as, signingKeyService: signingKeyService, logger: logger}
+}
+
+// GetJWKS serves the public signing keys as a bare JWK set, the format verifiers expect, rather than in the API
+// envelope.
+func (h *AuthJWTHandler) GetJWKS(c *gin.Context) {
+	jwks, err := h.signingKeyService.GetJWKS(c.Request.Context())
+	if err != nil {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
 }
 
 func (h *AuthJWTHandler) GetAllUser(c *gin.Context) {
-	users, err := h.authService.GetAllUsers(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	users, pagination, err := h.authService.GetAllUsers(c.Request.Context(), spec)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
 	}
-	helper.RespondSuccess(c, users)
+	helper.RespondWithPaginationSuccess(c, users, pagination)
 }
 
-func (h *AuthJWTHandler) GetProfile(c *gin.Context) {
+func (h *AuthJWTHandler) UpdateUserRole(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
 		helper.RespondError(c, &constant.BadRequest)
 		return
 	}
-	user, err := h.authService.GetProfile(c.Request.Context(), id)
+	var roleRequest dto.UserRoleRequest
+	if err := c.ShouldBindJSON(&roleRequest); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	userResponse, err := h.authService.UpdateUserRole(c.Request.Context(), id, roleRequest.Role, claims.ID)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, userResponse)
+}
+
+func (h *AuthJWTHandler) GetProfile(c *gin.Context) {
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	user, err := h.authService.GetProfile(c.Request.Context(), claims.ID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
 	}
 	helper.RespondSuccess(c, user)
 }
 
 func (h *AuthJWTHandler) UpdateProfile(c *gin.Context) {
-	id := c.Param("id")
-	if id == "" {
+	var userRequest dto.UserRequest
+	if err := c.ShouldBindJSON(&userRequest); err != nil {
 		helper.RespondError(c, &constant.BadRequest)
 		return
 	}
-	var userRequest dto.UserRequest
-	if err := c.ShouldBindJSON(userRequest); err != nil {
-		helper.RespondError(c, &constant.BadRequest)
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
 	}
 
-	userResponse, err := h.authService.UpdateProfile(c.Request.Context(), id, &userRequest)
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
 
+	userResponse, err := h.authService.UpdateProfile(c.Request.Context(), claims.ID, &userRequest)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
 	}
 	helper.RespondSuccess(c, userResponse)
 }
@@ -63,11 +132,13 @@ func (h *AuthJWTHandler) Register(c *gin.Context) {
 	var registerRequest dto.RegisterRequest
 	if err := c.ShouldBindJSON(&registerRequest); err != nil {
 		helper.RespondError(c, &constant.BadRequest)
+		return
 	}
 
 	accessToken, refreshToken, err := h.authService.Register(c.Request.Context(), registerRequest.Email, registerRequest.Password, registerRequest.Name)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
 	}
 	helper.RespondSuccess(c, dto.TokenResponse{
 		AccessToken:  accessToken,
@@ -79,26 +150,43 @@ func (h *AuthJWTHandler) Login(c *gin.Context) {
 	var loginRequest dto.LoginRequest
 	if err := c.ShouldBindJSON(&loginRequest); err != nil {
 		helper.RespondError(c, &constant.BadRequest)
+		return
 	}
 
-	accessToken, refreshToken, err := h.authService.Login(c.Request.Context(), loginRequest.Email, loginRequest.Password)
+	loginResponse, err := h.authService.Login(c.Request.Context(), loginRequest.Email, loginRequest.Password)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
 	}
-	helper.RespondSuccess(c, dto.TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-	})
+	helper.RespondSuccess(c, loginResponse)
 }
 
+func (h *AuthJWTHandler) LoginTOTP(c *gin.Context) {
+	var totpRequest dto.TOTPLoginRequest
+	if err := c.ShouldBindJSON(&totpRequest); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	loginResponse, err := h.authService.LoginTOTP(c.Request.Context(), totpRequest.MFAToken, totpRequest.Code)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, loginResponse)
+}
+
+// RefreshToken takes the refresh token as the bearer token.
 func (h *AuthJWTHandler) RefreshToken(c *gin.Context) {
-	tokenString := c.GetString("token")
-	if tokenString == "" {
+	tokenString, found := strings.CutPrefix(c.Request.Header.Get("Authorization"), "Bearer ")
+	if !found || tokenString == "" {
 		helper.RespondError(c, &constant.InvalidToken)
+		return
 	}
 	accessToken, refreshToken, err := h.authService.RefreshToken(c.Request.Context(), tokenString)
 	if err != nil {
 		helper.RespondError(c, err)
+		return
 	}
 	helper.RespondSuccess(c, dto.TokenResponse{
 		AccessToken:  accessToken,
@@ -106,6 +194,128 @@ func (h *AuthJWTHandler) RefreshToken(c *gin.Context) {
 	})
 }
 
+func (h *AuthJWTHandler) EnrollTOTP(c *gin.Context) {
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	enrollment, err := h.authService.EnrollTOTP(c.Request.Context(), claims.ID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, enrollment)
+}
+
+func (h *AuthJWTHandler) ConfirmTOTP(c *gin.Context) {
+	var confirmRequest dto.TOTPConfirmRequest
+	if err := c.ShouldBindJSON(&confirmRequest); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	if err := h.authService.ConfirmTOTP(c.Request.Context(), claims.ID, confirmRequest.Code); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
+
+func (h *AuthJWTHandler) CreateIdentityLinkCode(c *gin.Context) {
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	linkCode, err := h.authService.CreateIdentityLinkCode(c.Request.Context(), claims.ID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, linkCode)
+}
+
+// LinkIdentity is called from a ZK session: the DID of the session is linked to the account that issued the code.
+func (h *AuthJWTHandler) LinkIdentity(c *gin.Context) {
+	var linkRequest dto.IdentityLinkRequest
+	if err := c.ShouldBindJSON(&linkRequest); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	userResponse, err := h.authService.LinkIdentity(c.Request.Context(), linkRequest.Code, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, userResponse)
+}
+
+func (h *AuthJWTHandler) UnlinkIdentity(c *gin.Context) {
+	did := c.Param("did")
+	if did == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	if err := h.authService.UnlinkIdentity(c.Request.Context(), claims.ID, did); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
+
 func (h *AuthJWTHandler) GetAuthService() service.IAuthJWTService {
 	return h.authService
 }
@@ -7,6 +7,7 @@ import (
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
 	"be/pkg/logger"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/iden3/iden3comm/v2/protocol"
@@ -37,12 +38,23 @@ func (h *AuthZkHandler) Register(c *gin.Context) {
 	helper.RespondSuccess(c, identity)
 }
 
+// challengeBindingCookie carries the binding of a login challenge, so only the client that asked for the
+// challenge can answer it.
+const challengeBindingCookie = "zkChallengeBinding"
+
+// setCookie sets an http-only cookie on the whole API, or clears it with a negative maxAge. Outside development it
+// is only sent over https.
+func setCookie(c *gin.Context, config *config.Config, name, value string, maxAge int) {
+	c.SetCookie(name, value, maxAge, "/", "", !config.IsDevelopment(), true)
+}
+
 func (h *AuthZkHandler) Challenge(c *gin.Context) {
-	res, err := h.authZkService.Challenge(c.Request.Context())
+	res, binding, err := h.authZkService.Challenge(c.Request.Context())
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
+	setCookie(c, h.config, challengeBindingCookie, binding, int((5 * time.Minute).Seconds()))
 	helper.RespondSuccess(c, res)
 }
 
@@ -53,13 +65,16 @@ func (h *AuthZkHandler) Login(c *gin.Context) {
 		return
 	}
 
-	res, err := h.authZkService.Login(c.Request.Context(), &authResponse)
+	binding, _ := c.Cookie(challengeBindingCookie)
+	client := dto.ZKSessionClientDto{UserAgent: c.Request.UserAgent(), IP: c.ClientIP(), ChallengeBinding: binding}
+	res, err := h.authZkService.Login(c.Request.Context(), &authResponse, client)
+	setCookie(c, h.config, challengeBindingCookie, "", -1)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
 	age := h.config.JWT.AccessTokenTTL.Milliseconds()
-	c.SetCookie("refreshToken", res.RefreshToken, int(age), "/", "", false, true)
+	setCookie(c, h.config, "refreshToken", res.RefreshToken, int(age))
 	helper.RespondSuccess(c, res)
 }
 
@@ -71,7 +86,7 @@ func (h *AuthZkHandler) RefreshZKToken(c *gin.Context) {
 		return
 	}
 	age := h.config.JWT.AccessTokenTTL.Milliseconds()
-	c.SetCookie("refreshToken", res.RefreshToken, int(age), "/", "", false, true)
+	setCookie(c, h.config, "refreshToken", res.RefreshToken, int(age))
 	helper.RespondSuccess(c, res)
 }
 
@@ -88,7 +103,49 @@ func (h *AuthZkHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	err := h.authZkService.Logout(c.Request.Context(), claims.ID)
+	err := h.authZkService.Logout(c.Request.Context(), claims.ID, claims.SessionID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
+
+func (h *AuthZkHandler) GetSessions(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	res, err := h.authZkService.GetSessions(c.Request.Context(), claims)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *AuthZkHandler) RevokeSession(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	err := h.authZkService.RevokeSession(c.Request.Context(), claims.ID, c.Param("id"))
 	if err != nil {
 		helper.RespondError(c, err)
 		return
@@ -7,7 +7,6 @@ import (
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
 	"be/pkg/logger"
-	"fmt"
 
 	"github.com/gin-gonic/gin"
 )
@@ -49,7 +48,6 @@ func (h *CircuitHandler) GenerateCredentialAtomicQueryV3Input(c *gin.Context) {
 	}
 	res, err := h.circuitService.GetCredentialAtomicQueryV3Input(c.Request.Context(), &request, claims)
 	if err != nil {
-		fmt.Println(err)
 		helper.RespondError(c, err)
 		return
 	}
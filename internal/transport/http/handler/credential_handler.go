@@ -5,19 +5,30 @@ import (
 	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
-	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/iden3/go-schema-processor/v2/verifiable"
 	"github.com/iden3/iden3comm/v2/protocol"
 )
 
 type CredentialHandler struct {
-	credentialService service.ICredentialService
+	credentialService   service.ICredentialService
+	autoApprovalService service.IAutoApprovalService
+	bundleService       service.ICredentialBundleService
+	verificationService service.ICredentialVerificationService
 }
 
-func NewCredentialHandler(credentialService service.ICredentialService) *CredentialHandler {
+func NewCredentialHandler(
+	credentialService service.ICredentialService,
+	autoApprovalService service.IAutoApprovalService,
+	bundleService service.ICredentialBundleService,
+	verificationService service.ICredentialVerificationService,
+) *CredentialHandler {
 	return &CredentialHandler{
-		credentialService: credentialService,
+		credentialService:   credentialService,
+		autoApprovalService: autoApprovalService,
+		bundleService:       bundleService,
+		verificationService: verificationService,
 	}
 }
 
@@ -48,12 +59,18 @@ func (h *CredentialHandler) GetCredentialRequests(c *gin.Context) {
 		return
 	}
 
-	credentials, err := h.credentialService.GetCredentialRequests(c.Request.Context(), claims)
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, credentials)
+
+	credentials, pagination, err := h.credentialService.GetCredentialRequests(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, credentials, pagination)
 }
 
 func (h *CredentialHandler) UpdateCredentialRequest(c *gin.Context) {
@@ -68,15 +85,35 @@ func (h *CredentialHandler) UpdateCredentialRequest(c *gin.Context) {
 		return
 	}
 
-	err := h.credentialService.UpdateCredentialRequest(c.Request.Context(), id, &request)
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.ReviewerDID = claims.DID
+
+	res, err := h.credentialService.UpdateCredentialRequest(c.Request.Context(), id, &request)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, "")
+	helper.RespondSuccess(c, res)
 }
 
-func (h *CredentialHandler) GetVerifiableCredentials(c *gin.Context) {
+func (h *CredentialHandler) GetCredentialRequestReviews(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
 	user, ok := c.Get("user")
 	if !ok {
 		helper.RespondError(c, &constant.InternalServer)
@@ -89,15 +126,92 @@ func (h *CredentialHandler) GetVerifiableCredentials(c *gin.Context) {
 		return
 	}
 
-	res, err := h.credentialService.GetVerifiableCredentials(c.Request.Context(), claims)
+	res, err := h.credentialService.GetCredentialRequestReviews(c.Request.Context(), id, claims)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
+	helper.RespondSuccess(c, res)
+}
 
+func (h *CredentialHandler) GetReviewPolicy(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	res, err := h.credentialService.GetReviewPolicy(c.Request.Context(), claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
 	helper.RespondSuccess(c, res)
 }
 
+func (h *CredentialHandler) UpdateReviewPolicy(c *gin.Context) {
+	var request dto.CredentialReviewPolicyRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.IssuerDID = claims.DID
+
+	res, err := h.credentialService.UpdateReviewPolicy(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) GetVerifiableCredentials(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	res, pagination, err := h.credentialService.GetVerifiableCredentials(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	helper.RespondWithPaginationSuccess(c, res, pagination)
+}
+
 func (h *CredentialHandler) GetVerifiableCredentialById(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -131,6 +245,61 @@ func (h *CredentialHandler) UpdateVerifiableCredential(c *gin.Context) {
 	helper.RespondSuccess(c, "")
 }
 
+// RefreshVerifiableCredential is the refresh service named in issued credentials. The holder sends the id of a
+// credential and gets back the request the issuer signs to reissue it.
+func (h *CredentialHandler) RefreshVerifiableCredential(c *gin.Context) {
+	var request dto.CredentialRefreshRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.HolderDID = claims.DID
+
+	res, err := h.credentialService.RefreshVerifiableCredential(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) GetCredentialSubject(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	res, err := h.credentialService.GetCredentialSubject(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
 func (h *CredentialHandler) IssueVerifiableCredential(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -146,10 +315,300 @@ func (h *CredentialHandler) IssueVerifiableCredential(c *gin.Context) {
 
 	res, err := h.credentialService.IssueVerifiableCredential(c.Request.Context(), id, &request)
 	if err != nil {
-		fmt.Println(err)
 		helper.RespondError(c, err)
 		return
 	}
 	helper.RespondSuccess(c, res)
 
 }
+
+func (h *CredentialHandler) IssueVerifiableCredentialBatch(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	var request dto.IssueVerifiableCredentialBatchRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	batch, err := h.credentialService.IssueVerifiableCredentialBatch(c.Request.Context(), claims.DID, &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, batch)
+}
+
+func (h *CredentialHandler) GetIssuanceBatch(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	batch, err := h.credentialService.GetIssuanceBatch(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, batch)
+}
+
+func (h *CredentialHandler) ResumeIssuanceBatch(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	batch, err := h.credentialService.ResumeIssuanceBatch(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, batch)
+}
+
+func (h *CredentialHandler) GetAutoApprovalRules(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	res, err := h.autoApprovalService.GetRules(c.Request.Context(), claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) CreateAutoApprovalRule(c *gin.Context) {
+	var request dto.AutoApprovalRuleCreatedRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.IssuerDID = claims.DID
+
+	res, err := h.autoApprovalService.CreateRule(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) UpdateAutoApprovalRule(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	var request dto.AutoApprovalRuleUpdatedRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.IssuerDID = claims.DID
+
+	res, err := h.autoApprovalService.UpdateRule(c.Request.Context(), id, &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) GetAutoApprovalDecisions(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	res, pagination, err := h.autoApprovalService.GetRuleDecisions(c.Request.Context(), id, claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, res, pagination)
+}
+
+func (h *CredentialHandler) ExportCredentialBundle(c *gin.Context) {
+	var request dto.CredentialBundleExportRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.HolderDID = claims.DID
+
+	res, err := h.bundleService.ExportBundle(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) ImportCredentialBundle(c *gin.Context) {
+	var request dto.CredentialBundleImportRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.HolderDID = claims.DID
+
+	res, err := h.bundleService.ImportBundle(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *CredentialHandler) GetImportedCredentials(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	res, pagination, err := h.bundleService.GetImportedCredentials(c.Request.Context(), claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, res, pagination)
+}
+
+func (h *CredentialHandler) VerifyCredential(c *gin.Context) {
+	var request verifiable.W3CCredential
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	res, err := h.verificationService.VerifyCredential(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
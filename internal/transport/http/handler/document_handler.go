@@ -5,17 +5,27 @@ import (
 	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"be/internal/transport/http/dto"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
 type DocumentHandler struct {
-	documentService service.IDocumentService
+	documentService     service.IDocumentService
+	importService       service.IImportService
+	licensePointService service.ILicensePointService
+	correctionService   service.ICorrectionService
 }
 
-func NewDocumentHandler(cs service.IDocumentService) *DocumentHandler {
+func NewDocumentHandler(cs service.IDocumentService, is service.IImportService, ps service.ILicensePointService, crs service.ICorrectionService) *DocumentHandler {
 	return &DocumentHandler{
-		documentService: cs,
+		documentService:     cs,
+		importService:       is,
+		licensePointService: ps,
+		correctionService:   crs,
 	}
 }
 
@@ -111,6 +121,20 @@ func (h *DocumentHandler) UpdateCitizenIdentity(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	citizenIdentityRequest.ChangedBy = claims.DID
+
 	citizenIdentityResponse, err := h.documentService.UpdateCitizenIdentity(c.Request.Context(), id, &citizenIdentityRequest)
 
 	if err != nil {
@@ -133,6 +157,20 @@ func (h *DocumentHandler) RevokeCitizenIdentity(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	citizenIdentityRevokedRequest.ChangedBy = claims.DID
+
 	err := h.documentService.RevokeCitizenIdentity(c.Request.Context(), id, &citizenIdentityRevokedRequest)
 	if err != nil {
 		helper.RespondError(c, err)
@@ -157,12 +195,17 @@ func (h *DocumentHandler) GetCitizenIdentity(c *gin.Context) {
 }
 
 func (h *DocumentHandler) GetCitizenIdentities(c *gin.Context) {
-	citizenIdentities, err := h.documentService.GetCitizenIdentities(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, citizenIdentities)
+	citizenIdentities, pagination, err := h.documentService.GetCitizenIdentities(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, citizenIdentities, pagination)
 }
 
 func (h *DocumentHandler) CreateAcademicDegree(c *gin.Context) {
@@ -171,6 +214,7 @@ func (h *DocumentHandler) CreateAcademicDegree(c *gin.Context) {
 		helper.RespondError(c, err)
 		return
 	}
+
 	user, ok := c.Get("user")
 	if !ok {
 		helper.RespondError(c, &constant.InternalServer)
@@ -204,6 +248,20 @@ func (h *DocumentHandler) UpdateAcademicDegree(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	academicDegreeRequest.ChangedBy = claims.DID
+
 	academicDegreeResponse, err := h.documentService.UpdateAcademicDegree(c.Request.Context(), id, &academicDegreeRequest)
 
 	if err != nil {
@@ -226,6 +284,20 @@ func (h *DocumentHandler) RevokeAcademicDegree(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	academicDegreeRevokedRequest.ChangedBy = claims.DID
+
 	err := h.documentService.RevokeAcademicDegree(c.Request.Context(), id, &academicDegreeRevokedRequest)
 	if err != nil {
 		helper.RespondError(c, err)
@@ -250,12 +322,17 @@ func (h *DocumentHandler) GetAcademicDegree(c *gin.Context) {
 }
 
 func (h *DocumentHandler) GetAcademicDegrees(c *gin.Context) {
-	academicDegrees, err := h.documentService.GetAcademicDegrees(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, academicDegrees)
+	academicDegrees, pagination, err := h.documentService.GetAcademicDegrees(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, academicDegrees, pagination)
 }
 
 func (h *DocumentHandler) CreateHealthInsurance(c *gin.Context) {
@@ -301,6 +378,20 @@ func (h *DocumentHandler) UpdateHealthInsurance(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	healthInsuranceRequest.ChangedBy = claims.DID
+
 	healthInsuranceResponse, err := h.documentService.UpdateHealthInsurance(c.Request.Context(), id, &healthInsuranceRequest)
 
 	if err != nil {
@@ -323,6 +414,20 @@ func (h *DocumentHandler) RevokeHealthInsurance(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	healthInsuranceRevokedRequest.ChangedBy = claims.DID
+
 	err := h.documentService.RevokeHealthInsurance(c.Request.Context(), id, &healthInsuranceRevokedRequest)
 	if err != nil {
 		helper.RespondError(c, err)
@@ -347,12 +452,17 @@ func (h *DocumentHandler) GetHealthInsurance(c *gin.Context) {
 }
 
 func (h *DocumentHandler) GetHealthInsurances(c *gin.Context) {
-	healthInsurances, err := h.documentService.GetHealthInsurances(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, healthInsurances)
+	healthInsurances, pagination, err := h.documentService.GetHealthInsurances(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, healthInsurances, pagination)
 }
 
 func (h *DocumentHandler) CreateDriverLicense(c *gin.Context) {
@@ -396,6 +506,20 @@ func (h *DocumentHandler) UpdateDriverLicense(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	driverLicenseRequest.ChangedBy = claims.DID
+
 	driverLicenseResponse, err := h.documentService.UpdateDriverLicense(c.Request.Context(), id, &driverLicenseRequest)
 
 	if err != nil {
@@ -418,6 +542,20 @@ func (h *DocumentHandler) RevokeDriverLicense(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	driverLicenseRevokedRequest.ChangedBy = claims.DID
+
 	err := h.documentService.RevokeDriverLicense(c.Request.Context(), id, &driverLicenseRevokedRequest)
 	if err != nil {
 		helper.RespondError(c, err)
@@ -442,12 +580,17 @@ func (h *DocumentHandler) GetDriverLicense(c *gin.Context) {
 }
 
 func (h *DocumentHandler) GetDriverLicenses(c *gin.Context) {
-	driverLicenses, err := h.documentService.GetDriverLicenses(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	driverLicenses, pagination, err := h.documentService.GetDriverLicenses(c.Request.Context(), spec)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, driverLicenses)
+	helper.RespondWithPaginationSuccess(c, driverLicenses, pagination)
 }
 
 func (h *DocumentHandler) CreatePassport(c *gin.Context) {
@@ -492,6 +635,20 @@ func (h *DocumentHandler) UpdatePassport(c *gin.Context) {
 		return
 	}
 
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	passportRequest.ChangedBy = claims.DID
+
 	passportResponse, err := h.documentService.UpdatePassport(c.Request.Context(), id, &passportRequest)
 
 	if err != nil {
@@ -513,6 +670,21 @@ func (h *DocumentHandler) RevokePassport(c *gin.Context) {
 		helper.RespondError(c, err)
 		return
 	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	passportRevokedRequest.ChangedBy = claims.DID
+
 	err := h.documentService.RevokePassport(c.Request.Context(), id, &passportRevokedRequest)
 	if err != nil {
 		helper.RespondError(c, err)
@@ -537,10 +709,321 @@ func (h *DocumentHandler) GetPassport(c *gin.Context) {
 }
 
 func (h *DocumentHandler) GetPassports(c *gin.Context) {
-	passports, err := h.documentService.GetPassports(c.Request.Context())
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	passports, pagination, err := h.documentService.GetPassports(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, passports, pagination)
+}
+
+func (h *DocumentHandler) CreateImportJob(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		helper.RespondError(c, &constant.ImportFileInvalid)
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		helper.RespondError(c, &constant.ImportFileInvalid)
+		return
+	}
+
+	documentType := constant.DocumentType(c.PostForm("documentType"))
+	job, err := h.importService.CreateImportJob(c.Request.Context(), claims.DID, documentType, fileHeader.Filename, content)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, job)
+}
+
+func (h *DocumentHandler) GetImportJob(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	job, err := h.importService.GetImportJob(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, job)
+}
+
+func (h *DocumentHandler) GetImportJobErrorReport(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	report, err := h.importService.GetImportJobErrorReport(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"import-%s-errors.csv\"", id))
+	c.Data(http.StatusOK, "text/csv", report)
+}
+
+func (h *DocumentHandler) RenewDriverLicense(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	var driverLicenseRequest dto.DriverLicenseRenewedRequestDto
+	if err := c.ShouldBindJSON(&driverLicenseRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	driverLicenseRequest.IssuerDID = claims.DID
+
+	driverLicenseResponse, err := h.documentService.RenewDriverLicense(c.Request.Context(), id, &driverLicenseRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, driverLicenseResponse)
+}
+
+func (h *DocumentHandler) RenewPassport(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	var passportRequest dto.PassportRenewedRequestDto
+	if err := c.ShouldBindJSON(&passportRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	passportRequest.IssuerDID = claims.DID
+
+	passportResponse, err := h.documentService.RenewPassport(c.Request.Context(), id, &passportRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, passportResponse)
+}
+
+func (h *DocumentHandler) DeductDriverLicensePoints(c *gin.Context) {
+	h.changeDriverLicensePoints(c, h.licensePointService.DeductPoints)
+}
+
+func (h *DocumentHandler) RestoreDriverLicensePoints(c *gin.Context) {
+	h.changeDriverLicensePoints(c, h.licensePointService.RestorePoints)
+}
+
+func (h *DocumentHandler) changeDriverLicensePoints(c *gin.Context, change func(ctx context.Context, id string, request *dto.DriverLicensePointRequestDto) (*dto.DriverLicensePointEntryResponseDto, error)) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	var pointRequest dto.DriverLicensePointRequestDto
+	if err := c.ShouldBindJSON(&pointRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	pointRequest.CreatedBy = claims.DID
+
+	entryResponse, err := change(c.Request.Context(), id, &pointRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, entryResponse)
+}
+
+func (h *DocumentHandler) GetDriverLicensePoints(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	entries, pagination, err := h.licensePointService.GetPointHistory(c.Request.Context(), id, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, entries, pagination)
+}
+
+// GetDocumentRevisions lists the revision history of a document of the given type.
+func (h *DocumentHandler) GetDocumentRevisions(documentType constant.DocumentType) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			helper.RespondError(c, &constant.BadRequest)
+			return
+		}
+		spec, err := helper.ParseQuerySpec(c)
+		if err != nil {
+			helper.RespondError(c, err)
+			return
+		}
+
+		revisions, pagination, err := h.documentService.GetDocumentRevisions(c.Request.Context(), documentType, id, spec)
+		if err != nil {
+			helper.RespondError(c, err)
+			return
+		}
+		helper.RespondWithPaginationSuccess(c, revisions, pagination)
+	}
+}
+
+// GetCorrectionRequests lists the correction requests addressed to the authenticated issuer.
+func (h *DocumentHandler) GetCorrectionRequests(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	corrections, pagination, err := h.correctionService.GetIssuerCorrectionRequests(c.Request.Context(), claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, corrections, pagination)
+}
+
+func (h *DocumentHandler) ReviewCorrectionRequest(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	var reviewRequest dto.CorrectionRequestReviewedRequestDto
+	if err := c.ShouldBindJSON(&reviewRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	reviewRequest.ReviewedBy = claims.DID
+
+	correctionResponse, err := h.correctionService.ReviewCorrectionRequest(c.Request.Context(), id, &reviewRequest)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, passports)
+	helper.RespondSuccess(c, correctionResponse)
 }
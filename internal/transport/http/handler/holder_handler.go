@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HolderHandler serves the holder's own view of the documents issued to them. Every request is scoped to the
+// DID of the authenticated holder.
+type HolderHandler struct {
+	documentService     service.IDocumentService
+	correctionService   service.ICorrectionService
+	notificationService service.INotificationService
+}
+
+func NewHolderHandler(ds service.IDocumentService, cs service.ICorrectionService, ns service.INotificationService) *HolderHandler {
+	return &HolderHandler{
+		documentService:     ds,
+		correctionService:   cs,
+		notificationService: ns,
+	}
+}
+
+func (h *HolderHandler) GetDocuments(c *gin.Context) {
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	documentsResponse, err := h.documentService.GetHolderDocuments(c.Request.Context(), claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, documentsResponse)
+}
+
+func (h *HolderHandler) CreateCorrectionRequest(c *gin.Context) {
+	var correctionRequest dto.CorrectionRequestCreatedRequestDto
+	if err := c.ShouldBindJSON(&correctionRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	correctionRequest.HolderDID = claims.DID
+
+	correctionResponse, err := h.correctionService.CreateCorrectionRequest(c.Request.Context(), &correctionRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, correctionResponse)
+}
+
+func (h *HolderHandler) GetCorrectionRequests(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	corrections, pagination, err := h.correctionService.GetHolderCorrectionRequests(c.Request.Context(), claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, corrections, pagination)
+}
+
+func (h *HolderHandler) GetNotifications(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	notifications, pagination, err := h.notificationService.GetNotifications(c.Request.Context(), claims.DID, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, notifications, pagination)
+}
+
+func (h *HolderHandler) MarkNotificationRead(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	notificationResponse, err := h.notificationService.MarkNotificationRead(c.Request.Context(), id, claims.DID)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, notificationResponse)
+}
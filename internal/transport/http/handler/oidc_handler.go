@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"be/config"
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/iden3/iden3comm/v2/protocol"
+)
+
+// OIDCHandler serves the OpenID Connect provider. Discovery, token and userinfo answer in the bare formats of the
+// OAuth specs rather than in the API envelope, since relying parties read them with off-the-shelf libraries.
+type OIDCHandler struct {
+	config      *config.Config
+	oidcService service.IOIDCService
+}
+
+func NewOIDCHandler(config *config.Config, oidcService service.IOIDCService) *OIDCHandler {
+	return &OIDCHandler{
+		config:      config,
+		oidcService: oidcService,
+	}
+}
+
+// respondOAuthError writes an error response of RFC 6749 section 5.2, deriving the error code from the OIDC_ error
+// codes of the service.
+func respondOAuthError(c *gin.Context, err error) {
+	status, code := http.StatusInternalServerError, "server_error"
+	var appErr *constant.Errors
+	if errors.As(err, &appErr) {
+		status = appErr.Status
+		switch {
+		case strings.HasPrefix(appErr.Code, "OIDC_"):
+			code = strings.ToLower(strings.TrimPrefix(appErr.Code, "OIDC_"))
+		case appErr.Status == http.StatusUnauthorized:
+			code = "invalid_token"
+		case appErr.Status < http.StatusInternalServerError:
+			code = "invalid_request"
+		}
+	}
+	if code == "invalid_token" {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	}
+	c.JSON(status, gin.H{"error": code, "error_description": err.Error()})
+}
+
+func (h *OIDCHandler) GetDiscovery(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.oidcService.GetDiscovery())
+}
+
+// Authorize answers an authorization request with the login challenge the wallet signs in with. The challenge is
+// bound to this browser the same way a direct ZK login is.
+func (h *OIDCHandler) Authorize(c *gin.Context) {
+	var request dto.OIDCAuthorizeRequestDto
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helper.RespondError(c, &constant.OIDCRequestInvalid)
+		return
+	}
+
+	res, binding, err := h.oidcService.Authorize(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	setCookie(c, h.config, challengeBindingCookie, binding, int((5 * time.Minute).Seconds()))
+	helper.RespondSuccess(c, res)
+}
+
+func (h *OIDCHandler) AuthorizeCallback(c *gin.Context) {
+	var authResponse protocol.AuthorizationResponseMessage
+	if err := c.ShouldBindJSON(&authResponse); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	binding, _ := c.Cookie(challengeBindingCookie)
+	client := dto.ZKSessionClientDto{UserAgent: c.Request.UserAgent(), IP: c.ClientIP(), ChallengeBinding: binding}
+	res, err := h.oidcService.Approve(c.Request.Context(), &authResponse, client)
+	setCookie(c, h.config, challengeBindingCookie, "", -1)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, res)
+}
+
+func (h *OIDCHandler) Token(c *gin.Context) {
+	var request dto.OIDCTokenRequestDto
+	if err := c.ShouldBind(&request); err != nil {
+		respondOAuthError(c, &constant.OIDCRequestInvalid)
+		return
+	}
+
+	res, err := h.oidcService.Token(c.Request.Context(), &request)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, res)
+}
+
+func (h *OIDCHandler) UserInfo(c *gin.Context) {
+	accessToken, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !found || accessToken == "" {
+		respondOAuthError(c, &constant.InvalidToken)
+		return
+	}
+
+	userInfo, err := h.oidcService.UserInfo(c.Request.Context(), accessToken)
+	if err != nil {
+		respondOAuthError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, userInfo)
+}
+
+func (h *OIDCHandler) GetClients(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	clients, pagination, err := h.oidcService.GetClients(c.Request.Context(), spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, clients, pagination)
+}
+
+func (h *OIDCHandler) CreateClient(c *gin.Context) {
+	var request dto.OIDCClientCreatedRequestDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	operator, ok := c.Get("operator")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := operator.(*dto.Claims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	request.CreatedBy = claims.Email
+
+	client, err := h.oidcService.CreateClient(c.Request.Context(), &request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, client)
+}
+
+func (h *OIDCHandler) DisableClient(c *gin.Context) {
+	clientID := c.Param("id")
+	if clientID == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	if err := h.oidcService.DisableClient(c.Request.Context(), clientID); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, "")
+}
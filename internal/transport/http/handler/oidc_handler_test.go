@@ -0,0 +1,460 @@
+package handler
+
+import (
+	"be/config"
+	"be/internal/domain/auth"
+	"be/internal/infrastructure/cache/redis"
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/iden3/iden3comm/v2/protocol"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+const (
+	testClientID = "relying-party"
+	testDID      = "did:iden3:privado:main:2Scn2RfosbkQDMQzQM5nCz3Nk5GnbzZCWzGCd3tc2G"
+	// the code verifier of RFC 7636 appendix B
+	testCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// fakeRedis is a RESP2 server holding the few commands the OIDC flow runs, since tests have no Redis to talk to.
+type fakeRedis struct {
+	listener net.Listener
+	mu       sync.Mutex
+	values   map[string]string
+}
+
+func startFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	f := &fakeRedis{listener: listener, values: make(map[string]string)}
+	t.Cleanup(func() { _ = listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		args, err := readRESPCommand(reader)
+		if err != nil {
+			return
+		}
+		if _, err := io.WriteString(conn, f.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+// exec runs a command and returns its reply. Expirations are accepted and ignored.
+func (f *fakeRedis) exec(args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "SET":
+		f.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "GET", "GETDEL":
+		value, ok := f.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		if strings.EqualFold(args[0], "GETDEL") {
+			delete(f.values, args[1])
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "DEL":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := f.values[key]; ok {
+				delete(f.values, key)
+				deleted++
+			}
+		}
+		return fmt.Sprintf(":%d\r\n", deleted)
+	default:
+		// HELLO and CLIENT SETINFO included, which makes go-redis fall back to RESP2
+		return "-ERR unknown command '" + args[0] + "'\r\n"
+	}
+}
+
+func readRESPCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("unexpected command header %q", line)
+	}
+	args := make([]string, count)
+	for i := range args {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected argument header %q", line)
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, arg); err != nil {
+			return nil, err
+		}
+		args[i] = string(arg[:size])
+	}
+	return args, nil
+}
+
+type fakeOIDCClientRepository struct {
+	auth.IOIDCClientRepository
+	clients map[string]*auth.OIDCClient
+}
+
+func (r *fakeOIDCClientRepository) FindOIDCClientByClientID(_ context.Context, clientID string) (*auth.OIDCClient, error) {
+	client, ok := r.clients[clientID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return client, nil
+}
+
+// fakeAuthZkService issues challenges bound the way the real service binds them, and accepts any answer to a
+// challenge it issued from the client it issued it to.
+type fakeAuthZkService struct {
+	service.IAuthZkService
+	mu       sync.Mutex
+	bindings map[string]string
+}
+
+func (s *fakeAuthZkService) CreateChallenge(_ context.Context, callbackURL string, scopes ...protocol.ZeroKnowledgeProofRequest) (*protocol.AuthorizationRequestMessage, string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id, binding := uuid.NewString(), uuid.NewString()
+	s.bindings[id] = binding
+	return &protocol.AuthorizationRequestMessage{
+		ID:       id,
+		ThreadID: id,
+		Type:     protocol.AuthorizationRequestMessageType,
+		Body:     protocol.AuthorizationRequestMessageBody{CallbackURL: callbackURL, Reason: "Authenticate", Scope: scopes},
+	}, binding, nil
+}
+
+func (s *fakeAuthZkService) Authenticate(_ context.Context, authResponse *protocol.AuthorizationResponseMessage, client dto.ZKSessionClientDto) (*dto.IdentityResponseDto, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	binding, ok := s.bindings[authResponse.ID]
+	delete(s.bindings, authResponse.ID)
+	if !ok || binding != client.ChallengeBinding {
+		return nil, &constant.ChallengeInvalid
+	}
+	return &dto.IdentityResponseDto{DID: authResponse.From, Name: "Alice"}, nil
+}
+
+type fakeSigningKeyService struct {
+	service.ISigningKeyService
+	secret []byte
+}
+
+func (s *fakeSigningKeyService) SignToken(claims jwt.Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func (s *fakeSigningKeyService) ParseToken(tokenString string, claims jwt.Claims) (*jwt.Token, error) {
+	return jwt.ParseWithClaims(tokenString, claims, func(*jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+}
+
+// relyingParty is a public client that signs its users in with the provider. Its redirect endpoint redeems the
+// code with tokenVerifier, or with the verifier its challenge was made from when that is empty, and answers with
+// the user info the access token grants.
+type relyingParty struct {
+	server        *httptest.Server
+	provider      string
+	state         string
+	verifier      string
+	tokenVerifier string
+	lastCode      string
+}
+
+func newRelyingParty(t *testing.T, provider string) *relyingParty {
+	rp := &relyingParty{provider: provider, state: uuid.NewString(), verifier: testCodeVerifier}
+	rp.server = httptest.NewServer(http.HandlerFunc(rp.callback))
+	t.Cleanup(rp.server.Close)
+	return rp
+}
+
+func (rp *relyingParty) redirectURI() string {
+	return rp.server.URL + "/callback"
+}
+
+func (rp *relyingParty) authorizeURL() string {
+	hash := sha256.Sum256([]byte(rp.verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {testClientID},
+		"redirect_uri":          {rp.redirectURI()},
+		"scope":                 {"openid profile"},
+		"state":                 {rp.state},
+		"nonce":                 {uuid.NewString()},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(hash[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return rp.provider + "/oauth2/authorize?" + query.Encode()
+}
+
+func (rp *relyingParty) callback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("state") != rp.state || query.Get("iss") != rp.provider {
+		http.Error(w, "state or issuer mismatch", http.StatusBadRequest)
+		return
+	}
+	rp.lastCode = query.Get("code")
+	verifier := rp.tokenVerifier
+	if verifier == "" {
+		verifier = rp.verifier
+	}
+
+	status, body := rp.redeem(rp.lastCode, verifier)
+	if status == http.StatusOK {
+		status, body = rp.userInfo(body["access_token"].(string))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (rp *relyingParty) redeem(code, verifier string) (int, map[string]interface{}) {
+	res, err := http.PostForm(rp.provider+"/oauth2/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {rp.redirectURI()},
+		"client_id":     {testClientID},
+		"code_verifier": {verifier},
+	})
+	return decodeJSON(res, err)
+}
+
+func (rp *relyingParty) userInfo(accessToken string) (int, map[string]interface{}) {
+	req, err := http.NewRequest(http.MethodGet, rp.provider+"/oauth2/userinfo", nil)
+	if err != nil {
+		return http.StatusInternalServerError, nil
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	return decodeJSON(http.DefaultClient.Do(req))
+}
+
+func decodeJSON(res *http.Response, err error) (int, map[string]interface{}) {
+	if err != nil {
+		return http.StatusBadGateway, map[string]interface{}{"error": err.Error()}
+	}
+	defer res.Body.Close()
+	var body map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return http.StatusBadGateway, map[string]interface{}{"error": err.Error()}
+	}
+	return res.StatusCode, body
+}
+
+// newOIDCProvider serves the OIDC routes of SetupOIDCRouter, rate limits aside, over fakes of their dependencies.
+func newOIDCProvider(t *testing.T) (*httptest.Server, *auth.OIDCClient) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	fake := startFakeRedis(t)
+	host, port, _ := net.SplitHostPort(fake.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+
+	cfg := &config.Config{}
+	cfg.App.Env = "development"
+	cfg.Zap.Level = "fatal"
+	cfg.Redis = config.RedisConfig{Host: host, Port: portNumber, MaxConnections: 4, Timeout: time.Second}
+	log, err := logger.NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("logger: %v", err)
+	}
+	cache, err := redis.NewCache(cfg, log)
+	if err != nil {
+		t.Fatalf("redis: %v", err)
+	}
+	t.Cleanup(func() { _ = cache.Close() })
+
+	client := &auth.OIDCClient{ClientID: testClientID, Name: "Relying party", Scopes: datatypes.JSONSlice[string]{"profile"}}
+	clients := &fakeOIDCClientRepository{clients: map[string]*auth.OIDCClient{testClientID: client}}
+	oidcService := service.NewOIDCService(cfg, log, cache, clients,
+		&fakeAuthZkService{bindings: make(map[string]string)}, &fakeSigningKeyService{secret: []byte("test-secret")})
+	oidcHandler := NewOIDCHandler(cfg, oidcService)
+
+	engine := gin.New()
+	engine.GET("/.well-known/openid-configuration", oidcHandler.GetDiscovery)
+	oauthGroup := engine.Group("oauth2")
+	oauthGroup.GET("/authorize", oidcHandler.Authorize)
+	oauthGroup.POST("/authorize/callback", oidcHandler.AuthorizeCallback)
+	oauthGroup.POST("/token", oidcHandler.Token)
+	oauthGroup.GET("/userinfo", oidcHandler.UserInfo)
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+
+	cfg.OIDC.Issuer = server.URL
+	return server, client
+}
+
+// signIn walks a browser through the flow: it fetches the login challenge, answers it as the wallet would and
+// follows the redirect back to the relying party, returning what the relying party answered.
+func signIn(t *testing.T, rp *relyingParty) (int, map[string]interface{}) {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookie jar: %v", err)
+	}
+	browser := &http.Client{Jar: jar}
+
+	var authorization struct {
+		Data dto.OIDCAuthorizationDto `json:"data"`
+	}
+	res, err := browser.Get(rp.authorizeURL())
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&authorization); err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("authorize: status %d, err %v", res.StatusCode, err)
+	}
+	res.Body.Close()
+	if authorization.Data.Request == nil || authorization.Data.Request.Body.CallbackURL != rp.provider+"/oauth2/authorize/callback" {
+		t.Fatalf("authorize: unexpected challenge %+v", authorization.Data.Request)
+	}
+
+	authResponse, _ := json.Marshal(&protocol.AuthorizationResponseMessage{
+		ID:       authorization.Data.Request.ID,
+		ThreadID: authorization.Data.Request.ThreadID,
+		Type:     protocol.AuthorizationResponseMessageType,
+		From:     testDID,
+	})
+	var redirect struct {
+		Data dto.OIDCRedirectDto `json:"data"`
+	}
+	res, err = browser.Post(rp.provider+"/oauth2/authorize/callback", "application/json", bytes.NewReader(authResponse))
+	if err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if err := json.NewDecoder(res.Body).Decode(&redirect); err != nil || res.StatusCode != http.StatusOK {
+		t.Fatalf("callback: status %d, err %v", res.StatusCode, err)
+	}
+	res.Body.Close()
+	if !strings.HasPrefix(redirect.Data.RedirectURI, rp.redirectURI()+"?") {
+		t.Fatalf("callback: redirect to %q, want %q", redirect.Data.RedirectURI, rp.redirectURI())
+	}
+
+	return decodeJSON(browser.Get(redirect.Data.RedirectURI))
+}
+
+func TestOIDCAuthorizationCodeFlow(t *testing.T) {
+	provider, client := newOIDCProvider(t)
+
+	t.Run("pkce s256", func(t *testing.T) {
+		rp := newRelyingParty(t, provider.URL)
+		client.RedirectURIs = datatypes.JSONSlice[string]{rp.redirectURI()}
+
+		status, userInfo := signIn(t, rp)
+		if status != http.StatusOK {
+			t.Fatalf("relying party answered %d: %v", status, userInfo)
+		}
+		if userInfo["sub"] != testDID || userInfo["name"] != "Alice" {
+			t.Fatalf("userinfo = %v, want sub %s and name Alice", userInfo, testDID)
+		}
+	})
+
+	t.Run("wrong verifier", func(t *testing.T) {
+		rp := newRelyingParty(t, provider.URL)
+		rp.tokenVerifier = strings.Repeat("x", len(testCodeVerifier))
+		client.RedirectURIs = datatypes.JSONSlice[string]{rp.redirectURI()}
+
+		status, body := signIn(t, rp)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("token with a wrong verifier = %d %v, want 400 invalid_grant", status, body)
+		}
+		// the failed attempt spent the code, so the right verifier no longer redeems it
+		status, body = rp.redeem(rp.lastCode, rp.verifier)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("token after a wrong verifier = %d %v, want 400 invalid_grant", status, body)
+		}
+	})
+
+	t.Run("reused code", func(t *testing.T) {
+		rp := newRelyingParty(t, provider.URL)
+		client.RedirectURIs = datatypes.JSONSlice[string]{rp.redirectURI()}
+
+		if status, body := signIn(t, rp); status != http.StatusOK {
+			t.Fatalf("relying party answered %d: %v", status, body)
+		}
+		status, body := rp.redeem(rp.lastCode, rp.verifier)
+		if status != http.StatusBadRequest || body["error"] != "invalid_grant" {
+			t.Fatalf("token with a used code = %d %v, want 400 invalid_grant", status, body)
+		}
+	})
+}
+
+func TestSetCookieSecureOutsideDevelopment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		env        string
+		wantSecure bool
+	}{
+		{env: "development", wantSecure: false},
+		{env: "local", wantSecure: false},
+		{env: "production", wantSecure: true},
+		{env: "", wantSecure: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			cfg := &config.Config{}
+			cfg.App.Env = tt.env
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			setCookie(c, cfg, challengeBindingCookie, "binding", 60)
+
+			cookies := recorder.Result().Cookies()
+			if len(cookies) != 1 || !cookies[0].HttpOnly || cookies[0].Secure != tt.wantSecure {
+				t.Fatalf("cookies = %+v, want one http-only cookie with secure %v", cookies, tt.wantSecure)
+			}
+		})
+	}
+}
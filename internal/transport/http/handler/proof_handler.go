@@ -64,13 +64,19 @@ func (h *ProofHandler) GetProofRequests(c *gin.Context) {
 		return
 	}
 
-	res, err := h.proofService.GetProofRequests(c.Request.Context(), claims)
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
 
-	helper.RespondSuccess(c, res)
+	res, pagination, err := h.proofService.GetProofRequests(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	helper.RespondWithPaginationSuccess(c, res, pagination)
 }
 
 func (h *ProofHandler) UpdateProofRequest(c *gin.Context) {
@@ -79,12 +85,22 @@ func (h *ProofHandler) UpdateProofRequest(c *gin.Context) {
 		helper.RespondError(c, &constant.BadRequest)
 		return
 	}
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
 	var request dto.ProofRequestUpdatedRequestDto
 	if err := c.ShouldBindJSON(&request); err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	err := h.proofService.UpdateProofRequest(c.Request.Context(), id, &request)
+	err := h.proofService.UpdateProofRequest(c.Request.Context(), id, claims, &request)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
@@ -99,7 +115,17 @@ func (h *ProofHandler) VerifyZKProof(c *gin.Context) {
 		helper.RespondError(c, &constant.BadRequest)
 		return
 	}
-	resp, err := h.proofService.VerifyZKProof(c.Request.Context(), id)
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	resp, err := h.proofService.VerifyZKProof(c.Request.Context(), id, claims)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
@@ -136,11 +162,17 @@ func (h *ProofHandler) GetProofSubmissions(c *gin.Context) {
 		return
 	}
 
-	res, err := h.proofService.GetProofSubmissions(c.Request.Context(), claims)
+	spec, err := helper.ParseQuerySpec(c)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
 
-	helper.RespondSuccess(c, res)
+	res, pagination, err := h.proofService.GetProofSubmissions(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	helper.RespondWithPaginationSuccess(c, res, pagination)
 }
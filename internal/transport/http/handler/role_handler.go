@@ -0,0 +1,204 @@
+package handler
+
+import (
+	"be/internal/service"
+	"be/internal/shared/constant"
+	"be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoleHandler serves role applications and the history of role grants. Identities apply for roles and see their
+// own records; admins review applications and grant or revoke roles.
+type RoleHandler struct {
+	roleService service.IRoleService
+}
+
+func NewRoleHandler(rs service.IRoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: rs,
+	}
+}
+
+func (h *RoleHandler) ApplyForRole(c *gin.Context) {
+	var applicationRequest dto.RoleApplicationCreatedRequestDto
+	if err := c.ShouldBindJSON(&applicationRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	applicationRequest.DID = claims.DID
+
+	applicationResponse, err := h.roleService.ApplyForRole(c.Request.Context(), &applicationRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, applicationResponse)
+}
+
+func (h *RoleHandler) GetRoleApplications(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	applications, pagination, err := h.roleService.GetRoleApplications(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, applications, pagination)
+}
+
+func (h *RoleHandler) ReviewRoleApplication(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	var reviewRequest dto.RoleApplicationReviewedRequestDto
+	if err := c.ShouldBindJSON(&reviewRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	reviewRequest.ReviewedBy = claims.DID
+
+	applicationResponse, err := h.roleService.ReviewRoleApplication(c.Request.Context(), id, &reviewRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, applicationResponse)
+}
+
+func (h *RoleHandler) GetRoleGrants(c *gin.Context) {
+	spec, err := helper.ParseQuerySpec(c)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	grants, pagination, err := h.roleService.GetRoleGrants(c.Request.Context(), claims, spec)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondWithPaginationSuccess(c, grants, pagination)
+}
+
+func (h *RoleHandler) GrantRole(c *gin.Context) {
+	var grantRequest dto.RoleGrantCreatedRequestDto
+	if err := c.ShouldBindJSON(&grantRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	grantRequest.GrantedBy = claims.DID
+
+	grantResponse, err := h.roleService.GrantRole(c.Request.Context(), &grantRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, grantResponse)
+}
+
+func (h *RoleHandler) RevokeRole(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+
+	var revokeRequest dto.RoleGrantRevokedRequestDto
+	if err := c.ShouldBindJSON(&revokeRequest); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+
+	revokeRequest.RevokedBy = claims.DID
+
+	grantResponse, err := h.roleService.RevokeRole(c.Request.Context(), id, &revokeRequest)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, grantResponse)
+}
@@ -18,12 +18,17 @@ func NewSchemaHandler(schemaService service.ISchemaService) *SchemaHandler {
 }
 
 func (h *SchemaHandler) GetSchemas(c *gin.Context) {
-	schemas, err := h.schemaService.GetSchemas(c.Request.Context())
+	var request dto.SchemaFilterDto
+	if err := c.ShouldBindQuery(&request); err != nil {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	schemas, pagination, err := h.schemaService.GetSchemas(c.Request.Context(), &request)
 	if err != nil {
 		helper.RespondError(c, err)
 		return
 	}
-	helper.RespondSuccess(c, schemas)
+	helper.RespondWithPaginationSuccess(c, schemas, pagination)
 }
 
 func (h *SchemaHandler) GetSchemaByPublicId(c *gin.Context) {
@@ -95,3 +100,32 @@ func (h *SchemaHandler) RemoveSchema(c *gin.Context) {
 	}
 	helper.RespondSuccess(c, nil)
 }
+
+func (h *SchemaHandler) UpdateSchemaMappings(c *gin.Context) {
+	id := c.Param("id")
+	if id == "" {
+		helper.RespondError(c, &constant.BadRequest)
+		return
+	}
+	user, ok := c.Get("user")
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	claims, ok := user.(*dto.ZKClaims)
+	if !ok {
+		helper.RespondError(c, &constant.InternalServer)
+		return
+	}
+	var request []*dto.SchemaAttributeMappingDto
+	if err := c.ShouldBindJSON(&request); err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	schemaAttributes, err := h.schemaService.UpdateSchemaMappings(c.Request.Context(), id, claims.DID, request)
+	if err != nil {
+		helper.RespondError(c, err)
+		return
+	}
+	helper.RespondSuccess(c, schemaAttributes)
+}
@@ -4,37 +4,83 @@ import (
 	"be/internal/service"
 	"be/internal/shared/constant"
 	response "be/internal/shared/helper"
+	"be/pkg/logger"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AuthenticateMiddleware(authService service.IAuthZkService) gin.HandlerFunc {
+// APIKeyHeader carries an API key for clients that keep the Authorization header for something else.
+const APIKeyHeader = "X-API-Key"
+
+// AuthenticateMiddleware accepts a ZK access token, or an API key on routes that name the scopes a key must hold.
+// Either way the claims of the identity are stored under "user"; API keys are refused where no scope is named.
+func AuthenticateMiddleware(authService service.IAuthZkService, apiKeyService service.IAPIKeyService, scopes ...constant.APIKeyScope) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		apiKey := c.Request.Header.Get(APIKeyHeader)
 		authHeader := c.Request.Header.Get("Authorization")
-		if authHeader == "" {
+		if apiKey == "" && authHeader == "" {
 			response.RespondError(c, &constant.InvalidAuthHeader)
 			c.Abort()
 			return
 		}
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			response.RespondError(c, &constant.InvalidAuthHeader)
+		if apiKey == "" {
+			parts := strings.Split(authHeader, " ")
+			if len(parts) != 2 || parts[0] != "Bearer" {
+				response.RespondError(c, &constant.InvalidAuthHeader)
+				c.Abort()
+				return
+			}
+
+			tokenString := parts[1]
+			if !strings.HasPrefix(tokenString, service.APIKeyPrefix) {
+				claims, err := authService.VerifyZKToken(c.Request.Context(), tokenString, constant.AccessToken)
+				if err != nil {
+					response.RespondError(c, &constant.InvalidToken)
+					c.Abort()
+					return
+				}
+				c.Set("user", claims)
+				setCallerDID(c, claims.DID)
+
+				c.Next()
+				return
+			}
+			apiKey = tokenString
+		}
+
+		if len(scopes) == 0 {
+			response.RespondError(c, &constant.InvalidToken)
 			c.Abort()
 			return
 		}
-
-		tokenString := parts[1]
-
-		claims, err := authService.VerifyZKToken(tokenString, constant.AccessToken)
+		claims, key, err := apiKeyService.AuthenticateAPIKey(c.Request.Context(), apiKey, c.ClientIP())
 		if err != nil {
-			response.RespondError(c, &constant.InvalidToken)
+			response.RespondError(c, err)
 			c.Abort()
 			return
 		}
+		for _, scope := range scopes {
+			if !slices.Contains(key.Scopes, scope) {
+				response.RespondError(c, &constant.APIKeyScopeMissing)
+				c.Abort()
+				return
+			}
+		}
 		c.Set("user", claims)
+		c.Set("apiKey", key)
+		setCallerDID(c, claims.DID)
 
 		c.Next()
 	}
 }
+
+// setCallerDID stores the DID of the caller in the request context, where the loggers of services and repositories
+// pick it up.
+func setCallerDID(c *gin.Context, did string) {
+	if did != "" {
+		c.Request = c.Request.WithContext(logger.WithDID(c.Request.Context(), did))
+	}
+}
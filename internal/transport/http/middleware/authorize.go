@@ -4,6 +4,7 @@ import (
 	"be/internal/shared/constant"
 	response "be/internal/shared/helper"
 	"be/internal/transport/http/dto"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,7 +28,7 @@ func AuthorizeMiddleware(allowedRoles []constant.IdentityRole) gin.HandlerFunc {
 		hasRole := false
 
 		for _, role := range allowedRoles {
-			if role == claims.Role {
+			if slices.Contains(claims.Roles, role) {
 				hasRole = true
 				break
 			}
@@ -1,20 +1,37 @@
 package middleware
 
 import (
-	"be/config"
+	"net/http"
+	"slices"
 
 	"github.com/gin-gonic/gin"
 )
 
-func CORSMiddleware(config *config.Config) gin.HandlerFunc {
-	allowedOrigins := config.App.AllowedOrigins
+// CORSMiddleware answers cross-origin requests from the origins the route allows. A listed origin is echoed back,
+// with credentials when the route allows them; "*" admits any origin, but never with credentials.
+func CORSMiddleware(profile *SecurityProfile) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", allowedOrigins)
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+		origin := c.Request.Header.Get("Origin")
+		if origin != "" {
+			policy := profile.policy(c.Request.URL.Path)
+			header := c.Writer.Header()
+			header.Add("Vary", "Origin")
+			switch {
+			case slices.Contains(policy.allowedOrigins, origin):
+				header.Set("Access-Control-Allow-Origin", origin)
+				if policy.allowCredentials {
+					header.Set("Access-Control-Allow-Credentials", "true")
+				}
+			case slices.Contains(policy.allowedOrigins, "*"):
+				header.Set("Access-Control-Allow-Origin", "*")
+			}
+			header.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, PATCH, OPTIONS")
+			header.Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID")
+			header.Set("Access-Control-Expose-Headers", "RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset, RateLimit-Policy, Retry-After, X-Request-ID")
+			header.Set("Access-Control-Max-Age", "600")
+		}
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
 			return
 		}
 		c.Next()
@@ -8,6 +8,8 @@ import (
 	"go.uber.org/zap"
 )
 
+// LogMiddleware writes one access log entry per request. It reads the context after the handlers ran, so the
+// entry carries the request ID and the DID authentication stored there.
 func LogMiddleware(logger *logger.ZapLogger) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		startTime := time.Now().UTC()
@@ -24,12 +26,21 @@ func LogMiddleware(logger *logger.ZapLogger) gin.HandlerFunc {
 		endTime := time.Now().UTC()
 		status := ctx.Writer.Status()
 
-		logger.Info("HTTP request",
+		fields := []zap.Field{
 			zap.String("method", method),
 			zap.String("path", path),
+			zap.String("route", ctx.FullPath()),
 			zap.Int("status", status),
+			zap.Int("bytes", ctx.Writer.Size()),
+			zap.String("client_ip", ctx.ClientIP()),
+			zap.String("user_agent", ctx.Request.UserAgent()),
 			zap.Time("start_time", startTime),
 			zap.Time("end_time", endTime),
-		)
+			zap.Duration("latency", endTime.Sub(startTime)),
+		}
+		if errs := ctx.Errors.ByType(gin.ErrorTypePrivate); len(errs) > 0 {
+			fields = append(fields, zap.String("error", errs.String()))
+		}
+		logger.WithContext(ctx.Request.Context()).Info("HTTP request", fields...)
 	}
 }
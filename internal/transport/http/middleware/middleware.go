@@ -8,15 +8,24 @@ import (
 )
 
 type Middleware struct {
-	config *config.Config
-	logger *logger.ZapLogger
+	config  *config.Config
+	logger  *logger.ZapLogger
+	profile *SecurityProfile
 }
 
 func NewMiddleware(cfg *config.Config, logger *logger.ZapLogger) *Middleware {
-	return &Middleware{config: cfg, logger: logger}
+	return &Middleware{config: cfg, logger: logger, profile: NewSecurityProfile(cfg)}
 }
 
+// SetupGlobalMiddlewares installs the middlewares every request passes. The request ID is assigned before anything
+// logs, logging comes next so it records the status of recovered panics, and the size and type checks run before
+// any handler reads the body.
 func (m *Middleware) SetupGlobalMiddlewares(engine *gin.Engine) {
-	engine.Use(CORSMiddleware(m.config))
+	engine.Use(RequestIDMiddleware())
 	engine.Use(LogMiddleware(m.logger))
+	engine.Use(CORSMiddleware(m.profile))
+	engine.Use(SecurityHeaderMiddleware(m.profile))
+	engine.Use(RecoveryMiddleware(m.logger))
+	engine.Use(BodyLimitMiddleware(m.profile))
+	engine.Use(ContentTypeMiddleware(m.profile))
 }
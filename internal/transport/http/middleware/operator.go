@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"be/internal/service"
+	"be/internal/shared/constant"
+	response "be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// OperatorAuthenticateMiddleware accepts operator access tokens and stores their claims under "operator". ZK
+// session tokens are refused.
+func OperatorAuthenticateMiddleware(authService service.IAuthJWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		authHeader := c.Request.Header.Get("Authorization")
+		if authHeader == "" {
+			response.RespondError(c, &constant.InvalidAuthHeader)
+			c.Abort()
+			return
+		}
+
+		parts := strings.Split(authHeader, " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			response.RespondError(c, &constant.InvalidAuthHeader)
+			c.Abort()
+			return
+		}
+
+		claims, err := authService.VerifyToken(parts[1], constant.AccessToken)
+		if err != nil {
+			response.RespondError(c, &constant.InvalidToken)
+			c.Abort()
+			return
+		}
+		c.Set("operator", claims)
+
+		c.Next()
+	}
+}
+
+// OperatorAuthorizeMiddleware admits operators holding one of the roles whose login passed TOTP.
+func OperatorAuthorizeMiddleware(allowedRoles []constant.UserRole) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		operator, existed := c.Get("operator")
+		if !existed {
+			response.RespondError(c, &constant.InternalServer)
+			c.Abort()
+			return
+		}
+
+		claims, ok := operator.(*dto.Claims)
+		if !ok {
+			response.RespondError(c, &constant.InternalServer)
+			c.Abort()
+			return
+		}
+
+		if !slices.Contains(allowedRoles, claims.Role) {
+			response.RespondError(c, &constant.Forbidden)
+			c.Abort()
+			return
+		}
+
+		if !claims.MFA {
+			response.RespondError(c, &constant.TOTPRequired)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -1 +1,138 @@
 package middleware
+
+import (
+	"be/config"
+	"be/internal/infrastructure/cache/redis"
+	"be/internal/shared/constant"
+	response "be/internal/shared/helper"
+	"be/internal/transport/http/dto"
+	"be/pkg/logger"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Names of the rate limit policies route groups are limited by.
+const (
+	DefaultRateLimitPolicy         = "default"
+	AuthZkChallengeRateLimitPolicy = "authzk_challenge"
+	AuthZkLoginRateLimitPolicy     = "authzk_login"
+	ProofSubmissionRateLimitPolicy = "proof_submission"
+	// ProofAPIRateLimitPolicy limits the proof routes API keys are accepted on, counted per key.
+	ProofAPIRateLimitPolicy = "proof_api"
+)
+
+// Keys a policy can count requests by.
+const (
+	rateLimitByIP     = "ip"
+	rateLimitByDID    = "did"
+	rateLimitByAPIKey = "api_key"
+)
+
+// defaultRateLimitPolicies apply unless the configuration overrides them. Endpoints that verify proofs cost far
+// more than a lookup, so they get much tighter limits than the rest of the API.
+var defaultRateLimitPolicies = map[string]config.RateLimitPolicy{
+	DefaultRateLimitPolicy:         {Limit: 300, Window: time.Minute, Key: rateLimitByIP},
+	AuthZkChallengeRateLimitPolicy: {Limit: 20, Window: time.Minute, Key: rateLimitByIP},
+	AuthZkLoginRateLimitPolicy:     {Limit: 10, Window: time.Minute, Key: rateLimitByIP},
+	ProofSubmissionRateLimitPolicy: {Limit: 30, Window: time.Minute, Key: rateLimitByDID},
+	ProofAPIRateLimitPolicy:        {Limit: 600, Window: time.Minute, Key: rateLimitByAPIKey},
+}
+
+// RateLimiter limits requests with a sliding window kept in Redis, so every replica of the API enforces the same
+// limit.
+type RateLimiter struct {
+	config *config.Config
+	logger *logger.ZapLogger
+	redis  *redis.RedisCache
+}
+
+func NewRateLimiter(config *config.Config, logger *logger.ZapLogger, redis *redis.RedisCache) *RateLimiter {
+	return &RateLimiter{config: config, logger: logger, redis: redis}
+}
+
+func (l *RateLimiter) policy(name string) config.RateLimitPolicy {
+	policy, ok := l.config.RateLimit.Policies[name]
+	if !ok {
+		if policy, ok = defaultRateLimitPolicies[name]; !ok {
+			policy = defaultRateLimitPolicies[DefaultRateLimitPolicy]
+		}
+	}
+	if policy.Limit <= 0 {
+		policy.Limit = defaultRateLimitPolicies[DefaultRateLimitPolicy].Limit
+	}
+	if policy.Window <= 0 {
+		policy.Window = time.Minute
+	}
+	return policy
+}
+
+// Limit returns a middleware that limits requests by the named policy. Policies counting by DID or API key must run
+// after AuthenticateMiddleware; until then, and for anonymous requests, they count by IP. When Redis cannot be
+// reached requests are let through rather than failing the API.
+func (l *RateLimiter) Limit(name string) gin.HandlerFunc {
+	policy := l.policy(name)
+	return func(c *gin.Context) {
+		if l.config.RateLimit.Disabled {
+			c.Next()
+			return
+		}
+
+		key := "ratelimit:" + name + ":" + rateLimitSubject(c, policy.Key)
+		result, err := l.redis.AllowSlidingWindow(c.Request.Context(), key, policy.Limit, policy.Window)
+		if err != nil {
+			l.logger.WithContext(c.Request.Context()).Warn("rate limiter unavailable", zap.String("policy", name), zap.Error(err))
+			c.Next()
+			return
+		}
+
+		setRateLimitHeaders(c, policy, result)
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+			response.RespondError(c, &constant.RateLimitExceeded)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func rateLimitSubject(c *gin.Context, by string) string {
+	switch by {
+	case rateLimitByAPIKey:
+		if value, ok := c.Get("apiKey"); ok {
+			if key, ok := value.(*dto.APIKeyResponseDto); ok {
+				return "key:" + key.PublicID
+			}
+		}
+	case rateLimitByDID:
+		if value, ok := c.Get("user"); ok {
+			if claims, ok := value.(*dto.ZKClaims); ok && claims.DID != "" {
+				return "did:" + claims.DID
+			}
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// setRateLimitHeaders writes the RateLimit-* headers of the IETF draft. When several policies apply to a request
+// the one with the fewest requests left is reported.
+func setRateLimitHeaders(c *gin.Context, policy config.RateLimitPolicy, result *redis.RateLimitResult) {
+	if current := c.Writer.Header().Get("RateLimit-Remaining"); current != "" {
+		if remaining, err := strconv.Atoi(current); err == nil && remaining < result.Remaining {
+			return
+		}
+	}
+	c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+	c.Header("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(ceilSeconds(policy.Window)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -1,13 +1,30 @@
 package middleware
 
 import (
+	"be/internal/shared/constant"
+	response "be/internal/shared/helper"
+	"be/pkg/logger"
+	"runtime/debug"
+
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
-func RecoveryMiddleware() gin.HandlerFunc {
+// RecoveryMiddleware turns a panic into the standard internal error envelope. The panic is logged with its stack
+// under the request ID the response carries, so a report from a client can be traced.
+func RecoveryMiddleware(logger *logger.ZapLogger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if r := recover(); r != nil {
+				logger.WithContext(c.Request.Context()).Error("panic recovered",
+					zap.String("method", c.Request.Method),
+					zap.String("path", c.Request.URL.Path),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()),
+				)
+				if !c.Writer.Written() {
+					response.RespondError(c, &constant.InternalServer)
+				}
 				c.Abort()
 			}
 		}()
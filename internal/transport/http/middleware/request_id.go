@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	response "be/internal/shared/helper"
+	"be/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDMiddleware gives every request an ID, echoed in the X-Request-ID response header and stored in the
+// request context, so the services, repositories and queued messages handling it log under the same ID.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := ensureRequestID(c)
+		c.Request = c.Request.WithContext(logger.WithRequestID(c.Request.Context(), requestID))
+		c.Next()
+	}
+}
+
+// ensureRequestID returns the ID of the request, taking the one the client sent when it is usable and generating
+// one otherwise, and echoes it in the response.
+func ensureRequestID(c *gin.Context) string {
+	if requestID := c.Writer.Header().Get(response.RequestIDHeader); requestID != "" {
+		return requestID
+	}
+	requestID := c.Request.Header.Get(response.RequestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = uuid.NewString()
+	}
+	c.Header(response.RequestIDHeader, requestID)
+	return requestID
+}
+
+// validRequestID accepts short IDs of visible ASCII, so a client cannot inject anything into logs.
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for _, r := range requestID {
+		if r < '!' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
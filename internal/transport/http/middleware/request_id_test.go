@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	response "be/internal/shared/helper"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		want      bool
+	}{
+		{name: "uuid", requestID: uuid.NewString(), want: true},
+		{name: "visible ascii", requestID: "req-42_!~", want: true},
+		{name: "longest", requestID: strings.Repeat("a", 128), want: true},
+		{name: "empty", requestID: ""},
+		{name: "too long", requestID: strings.Repeat("a", 129)},
+		{name: "space", requestID: "req 42"},
+		{name: "newline", requestID: "req\nlevel=error"},
+		{name: "control character", requestID: "req\x1b[31m"},
+		{name: "non ascii", requestID: "réq"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validRequestID(tt.requestID); got != tt.want {
+				t.Fatalf("validRequestID(%q) = %v, want %v", tt.requestID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnsureRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name string
+		// sent is the ID the client sends; echoed the one already set on the response
+		sent     string
+		echoed   string
+		wantSent bool
+	}{
+		{name: "keeps a valid client id", sent: "client-id", wantSent: true},
+		{name: "replaces an invalid client id", sent: "bad id\r\n"},
+		{name: "generates a missing id"},
+		{name: "reuses the id already echoed", sent: "client-id", echoed: "earlier-id"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.sent != "" {
+				c.Request.Header.Set(response.RequestIDHeader, tt.sent)
+			}
+			if tt.echoed != "" {
+				c.Header(response.RequestIDHeader, tt.echoed)
+			}
+
+			got := ensureRequestID(c)
+			switch {
+			case tt.echoed != "":
+				if got != tt.echoed {
+					t.Fatalf("ensureRequestID() = %q, want the echoed %q", got, tt.echoed)
+				}
+			case tt.wantSent:
+				if got != tt.sent {
+					t.Fatalf("ensureRequestID() = %q, want the sent %q", got, tt.sent)
+				}
+			default:
+				if _, err := uuid.Parse(got); err != nil {
+					t.Fatalf("ensureRequestID() = %q, want a generated uuid", got)
+				}
+			}
+			if header := recorder.Header().Get(response.RequestIDHeader); header != got {
+				t.Fatalf("%s header = %q, want %q", response.RequestIDHeader, header, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"be/internal/shared/constant"
+	response "be/internal/shared/helper"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BodyLimitMiddleware refuses bodies larger than the route allows. Declared lengths are checked up front; bodies
+// of unknown length fail to read once they pass the limit.
+func BodyLimitMiddleware(profile *SecurityProfile) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := profile.policy(c.Request.URL.Path).maxBodySize
+		if c.Request.ContentLength > limit {
+			response.RespondError(c, &constant.RequestTooLarge)
+			c.Abort()
+			return
+		}
+		if c.Request.Body != nil {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		}
+
+		c.Next()
+	}
+}
+
+// ContentTypeMiddleware refuses requests that carry a body of a media type the route does not accept.
+func ContentTypeMiddleware(profile *SecurityProfile) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
+		default:
+			c.Next()
+			return
+		}
+		if c.Request.ContentLength == 0 && len(c.Request.TransferEncoding) == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.Request.Header.Get("Content-Type"))
+		if err != nil || !slices.Contains(profile.policy(c.Request.URL.Path).contentTypes, strings.ToLower(mediaType)) {
+			response.RespondError(c, &constant.UnsupportedMediaType)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"be/config"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultHSTSMaxAge            = 2 * 365 * 24 * time.Hour
+	defaultContentSecurityPolicy = "default-src 'none'; frame-ancestors 'none'"
+	defaultFrameOptions          = "DENY"
+	defaultReferrerPolicy        = "strict-origin-when-cross-origin"
+	defaultMaxBodySize           = 1 << 20
+
+	// proof payloads carry whole zk proofs and verifiable presentations
+	proofMaxBodySize  = 10 << 20
+	importMaxBodySize = 20 << 20
+)
+
+var noCredentials = false
+
+// defaultSecurityRoutes apply unless the configuration names the same path prefix. The OpenID endpoints relying
+// parties call from their own origins are open to every origin, without credentials.
+var defaultSecurityRoutes = []config.SecurityRoute{
+	{PathPrefix: "/.well-known", AllowedOrigins: []string{"*"}, AllowCredentials: &noCredentials},
+	{PathPrefix: "/oauth2/token", AllowedOrigins: []string{"*"}, AllowCredentials: &noCredentials,
+		ContentTypes: []string{"application/x-www-form-urlencoded", "application/json"}},
+	{PathPrefix: "/oauth2/userinfo", AllowedOrigins: []string{"*"}, AllowCredentials: &noCredentials},
+	{PathPrefix: "/oauth2/authorize/callback", MaxBodySize: proofMaxBodySize},
+	{PathPrefix: "/api/v1/authzk/login", MaxBodySize: proofMaxBodySize},
+	{PathPrefix: "/api/v1/proofs/submissions", MaxBodySize: proofMaxBodySize},
+	{PathPrefix: "/api/v1/documents/imports", MaxBodySize: importMaxBodySize, ContentTypes: []string{"multipart/form-data"}},
+}
+
+// securityPolicy is the security profile resolved for one path prefix.
+type securityPolicy struct {
+	pathPrefix       string
+	allowedOrigins   []string
+	allowCredentials bool
+	maxBodySize      int64
+	contentTypes     []string
+}
+
+// SecurityProfile holds the response headers every request gets and the CORS, body size and content type policy
+// of each route.
+type SecurityProfile struct {
+	headers  map[string]string
+	defaults *securityPolicy
+	// policies are sorted by descending prefix length, so the first match is the most specific
+	policies []*securityPolicy
+}
+
+func NewSecurityProfile(cfg *config.Config) *SecurityProfile {
+	security := cfg.Security
+
+	headers := map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		// the legacy XSS auditor is disabled on purpose: it opened more holes than it closed
+		"X-XSS-Protection":        "0",
+		"Content-Security-Policy": valueOr(security.ContentSecurityPolicy, defaultContentSecurityPolicy),
+		"X-Frame-Options":         valueOr(security.FrameOptions, defaultFrameOptions),
+		"Referrer-Policy":         valueOr(security.ReferrerPolicy, defaultReferrerPolicy),
+	}
+	hstsMaxAge := security.HSTSMaxAge
+	if hstsMaxAge == 0 {
+		hstsMaxAge = defaultHSTSMaxAge
+	}
+	if hstsMaxAge > 0 {
+		hsts := "max-age=" + strconv.FormatInt(int64(hstsMaxAge.Seconds()), 10)
+		if security.HSTSIncludeSubdomains {
+			hsts += "; includeSubDomains"
+		}
+		if security.HSTSPreload {
+			hsts += "; preload"
+		}
+		headers["Strict-Transport-Security"] = hsts
+	}
+
+	defaults := &securityPolicy{
+		allowedOrigins:   cfg.GetAllowedOrigins(),
+		allowCredentials: true,
+		maxBodySize:      security.MaxBodySize,
+		contentTypes:     security.ContentTypes,
+	}
+	if defaults.maxBodySize <= 0 {
+		defaults.maxBodySize = defaultMaxBodySize
+	}
+	if len(defaults.contentTypes) == 0 {
+		defaults.contentTypes = []string{"application/json"}
+	}
+
+	routes := make(map[string]config.SecurityRoute)
+	for _, route := range defaultSecurityRoutes {
+		routes[route.PathPrefix] = route
+	}
+	for _, route := range security.Routes {
+		routes[route.PathPrefix] = route
+	}
+	policies := make([]*securityPolicy, 0, len(routes))
+	for prefix, route := range routes {
+		policy := *defaults
+		policy.pathPrefix = strings.TrimSuffix(prefix, "/")
+		if len(route.AllowedOrigins) > 0 {
+			policy.allowedOrigins = route.AllowedOrigins
+		}
+		if route.AllowCredentials != nil {
+			policy.allowCredentials = *route.AllowCredentials
+		}
+		if route.MaxBodySize > 0 {
+			policy.maxBodySize = route.MaxBodySize
+		}
+		if len(route.ContentTypes) > 0 {
+			policy.contentTypes = route.ContentTypes
+		}
+		policies = append(policies, &policy)
+	}
+	sort.Slice(policies, func(i, j int) bool {
+		return len(policies[i].pathPrefix) > len(policies[j].pathPrefix)
+	})
+
+	return &SecurityProfile{headers: headers, defaults: defaults, policies: policies}
+}
+
+// policy returns the policy of the longest prefix that matches path on a segment boundary.
+func (p *SecurityProfile) policy(path string) *securityPolicy {
+	for _, policy := range p.policies {
+		if path == policy.pathPrefix || strings.HasPrefix(path, policy.pathPrefix+"/") {
+			return policy
+		}
+	}
+	return p.defaults
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"be/internal/shared/constant"
+	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Router) SetupAdminRouter(apiGroup *gin.RouterGroup, adminHandler *handler.AdminHandler) {
+	adminGroup := apiGroup.Group("admin")
+	adminGroup.Use(middleware.OperatorAuthenticateMiddleware(r.authJWTHandler.GetAuthService()))
+	adminGroup.Use(middleware.OperatorAuthorizeMiddleware([]constant.UserRole{constant.UserRoleAdmin}))
+
+	adminGroup.GET("/users", r.authJWTHandler.GetAllUser)
+	adminGroup.PATCH("/users/:id/role", r.authJWTHandler.UpdateUserRole)
+
+	adminGroup.GET("/identities", adminHandler.GetIdentities)
+	adminGroup.POST("/identities/:id/suspend", adminHandler.SuspendIdentity)
+	adminGroup.POST("/identities/:id/reinstate", adminHandler.ReinstateIdentity)
+
+	adminGroup.POST("/schemas/:id/revoke", adminHandler.RevokeSchema)
+
+	adminGroup.GET("/oidc/clients", r.oidcHandler.GetClients)
+	adminGroup.POST("/oidc/clients", r.oidcHandler.CreateClient)
+	adminGroup.DELETE("/oidc/clients/:id", r.oidcHandler.DisableClient)
+
+	adminGroup.GET("/statistic/issuer/:did", r.statisticHandler.GetIssuerStatisticByIssuerDID)
+	adminGroup.GET("/statistic/holder/:did", r.statisticHandler.GetHolderStatisticByHolderDID)
+	adminGroup.GET("/statistic/verifier/:did", r.statisticHandler.GetVerifierStatisticByVerifierDID)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"be/internal/shared/constant"
+	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Router) SetupAPIKeyRouter(apiGroup *gin.RouterGroup, apiKeyHandler *handler.APIKeyHandler) {
+	apiKeyGroup := apiGroup.Group("api-keys")
+	// keys are managed with a ZK login only; an API key cannot mint or revoke keys
+	apiKeyGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
+	apiKeyGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityVerifierRole}))
+
+	apiKeyGroup.POST("", apiKeyHandler.CreateAPIKey)
+	apiKeyGroup.GET("", apiKeyHandler.GetAPIKeys)
+	apiKeyGroup.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
+}
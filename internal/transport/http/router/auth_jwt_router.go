@@ -2,6 +2,7 @@ package router
 
 import (
 	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +13,17 @@ func (r *Router) SetupAuthJWTRouter(apiGroup *gin.RouterGroup, authJWTHandler *h
 	authJWTGroup.GET("refresh-token", authJWTHandler.RefreshToken)
 	authJWTGroup.POST("register", authJWTHandler.Register)
 	authJWTGroup.POST("login", authJWTHandler.Login)
-
-	adminGroup := apiGroup.Group("admin")
-	adminGroup.GET("", authJWTHandler.GetAllUser)
+	authJWTGroup.POST("login/totp", authJWTHandler.LoginTOTP)
+	// a DID confirms a link to an operator account from its own ZK session
+	authJWTGroup.POST("identity-links", middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService), authJWTHandler.LinkIdentity)
 
 	userGroup := apiGroup.Group("users")
-	userGroup.GET("profile/:id", authJWTHandler.GetProfile)
-	userGroup.PUT("profile/:id", authJWTHandler.UpdateProfile)
+	userGroup.Use(middleware.OperatorAuthenticateMiddleware(authJWTHandler.GetAuthService()))
+
+	userGroup.GET("me", authJWTHandler.GetProfile)
+	userGroup.PUT("me", authJWTHandler.UpdateProfile)
+	userGroup.POST("me/totp", authJWTHandler.EnrollTOTP)
+	userGroup.POST("me/totp/confirm", authJWTHandler.ConfirmTOTP)
+	userGroup.POST("me/identity-links", authJWTHandler.CreateIdentityLinkCode)
+	userGroup.DELETE("me/identities/:did", authJWTHandler.UnlinkIdentity)
 }
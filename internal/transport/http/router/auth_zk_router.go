@@ -12,10 +12,12 @@ func (r *Router) SetupAuthZkRouter(apiGroup *gin.RouterGroup, authZKHandler *han
 
 	authZkGroup.GET("", authZKHandler.GetIdentityByRole)
 	authZkGroup.GET("/:did", authZKHandler.GetIdentityByDID)
-	authZkGroup.GET("logout", middleware.AuthenticateMiddleware(r.authZkService), authZKHandler.Logout)
-	authZkGroup.GET("challenge", authZKHandler.Challenge)
+	authZkGroup.GET("logout", middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService), authZKHandler.Logout)
+	authZkGroup.GET("sessions", middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService), authZKHandler.GetSessions)
+	authZkGroup.DELETE("sessions/:id", middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService), authZKHandler.RevokeSession)
+	authZkGroup.GET("challenge", r.rateLimiter.Limit(middleware.AuthZkChallengeRateLimitPolicy), authZKHandler.Challenge)
 	authZkGroup.GET("refresh-token", authZKHandler.RefreshZKToken)
 	authZkGroup.POST("register", authZKHandler.Register)
-	authZkGroup.POST("login", authZKHandler.Login)
+	authZkGroup.POST("login", r.rateLimiter.Limit(middleware.AuthZkLoginRateLimitPolicy), authZKHandler.Login)
 
 }
@@ -9,6 +9,6 @@ import (
 
 func (r *Router) SetupCircuitRouter(apiGroup *gin.RouterGroup, circuitHandler *handler.CircuitHandler) {
 	circuitGroup := apiGroup.Group("circuits")
-	circuitGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+	circuitGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
 	circuitGroup.POST("credentialAtomicQueryV3", circuitHandler.GenerateCredentialAtomicQueryV3Input)
 }
@@ -2,6 +2,7 @@ package router
 
 import (
 	"be/internal/infrastructure/database/postgres"
+	"be/internal/shared/constant"
 	"be/internal/shared/helper"
 	"be/internal/transport/http/handler"
 	"be/internal/transport/http/middleware"
@@ -11,17 +12,46 @@ import (
 
 func (r *Router) SetupCredentialRouter(apiGroup *gin.RouterGroup, credentialHandler *handler.CredentialHandler, db *postgres.PostgresDB) {
 	credentialGroup := apiGroup.Group("credentials")
-	credentialGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+	credentialGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
 
 	verifiableGroup := credentialGroup.Group("verifiable")
 	requestGroup := credentialGroup.Group("request")
+	batchGroup := credentialGroup.Group("batches")
+	batchGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}))
+	reviewPolicyGroup := credentialGroup.Group("review-policy")
+	reviewPolicyGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}))
+	autoApprovalGroup := credentialGroup.Group("auto-approval-rules")
+	autoApprovalGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}))
+	bundleGroup := credentialGroup.Group("bundle")
+	bundleGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityHolderRole}))
 
 	requestGroup.GET("", credentialHandler.GetCredentialRequests)
 	requestGroup.POST("", credentialHandler.CreateCredentialRequest)
-	requestGroup.PATCH("/:id", credentialHandler.UpdateCredentialRequest)
+	requestGroup.PATCH("/:id", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}), credentialHandler.UpdateCredentialRequest)
+	requestGroup.GET("/:id/reviews", credentialHandler.GetCredentialRequestReviews)
+	requestGroup.GET("/:id/subject", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}), credentialHandler.GetCredentialSubject)
 
 	verifiableGroup.GET("", credentialHandler.GetVerifiableCredentials)
 	verifiableGroup.GET("/:id", credentialHandler.GetVerifiableCredentialById)
 	verifiableGroup.PATCH("/:id", credentialHandler.UpdateVerifiableCredential)
 	verifiableGroup.POST("/:id", helper.TxMiddleware(db.GetGormDB()), credentialHandler.IssueVerifiableCredential)
+
+	credentialGroup.POST("/refresh", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityHolderRole}), credentialHandler.RefreshVerifiableCredential)
+	credentialGroup.POST("/verify", credentialHandler.VerifyCredential)
+
+	bundleGroup.POST("/export", credentialHandler.ExportCredentialBundle)
+	bundleGroup.POST("/import", credentialHandler.ImportCredentialBundle)
+	bundleGroup.GET("/imported", credentialHandler.GetImportedCredentials)
+
+	batchGroup.POST("", credentialHandler.IssueVerifiableCredentialBatch)
+	batchGroup.GET("/:id", credentialHandler.GetIssuanceBatch)
+	batchGroup.POST("/:id/resume", credentialHandler.ResumeIssuanceBatch)
+
+	reviewPolicyGroup.GET("", credentialHandler.GetReviewPolicy)
+	reviewPolicyGroup.PUT("", credentialHandler.UpdateReviewPolicy)
+
+	autoApprovalGroup.GET("", credentialHandler.GetAutoApprovalRules)
+	autoApprovalGroup.POST("", credentialHandler.CreateAutoApprovalRule)
+	autoApprovalGroup.PATCH("/:id", credentialHandler.UpdateAutoApprovalRule)
+	autoApprovalGroup.GET("/:id/decisions", credentialHandler.GetAutoApprovalDecisions)
 }
@@ -10,7 +10,7 @@ import (
 
 func (r *Router) SetupDocumentRouter(apiGroup *gin.RouterGroup, documentHandler *handler.DocumentHandler) {
 	credentialGroup := apiGroup.Group("documents")
-	credentialGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+	credentialGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
 	credentialGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}))
 
 	credentialGroup.GET("/:did", documentHandler.GetDocumentByHolderDID)
@@ -20,34 +20,53 @@ func (r *Router) SetupDocumentRouter(apiGroup *gin.RouterGroup, documentHandler
 	healthInsuranceGroup := credentialGroup.Group("health_insurance")
 	driverLicenseGroup := credentialGroup.Group("driver_license")
 	passportGroup := credentialGroup.Group("passport")
+	importGroup := credentialGroup.Group("imports")
+	correctionGroup := credentialGroup.Group("corrections")
 
 	citizenIdentityGroup.GET("/:id", documentHandler.GetCitizenIdentity)
 	citizenIdentityGroup.GET("", documentHandler.GetCitizenIdentities)
 	citizenIdentityGroup.POST("", documentHandler.CreateCitizenIdentity)
 	citizenIdentityGroup.PUT("/:id", documentHandler.UpdateCitizenIdentity)
 	citizenIdentityGroup.PATCH("/:id", documentHandler.RevokeCitizenIdentity)
+	citizenIdentityGroup.GET("/:id/revisions", documentHandler.GetDocumentRevisions(constant.CitizenIdentity))
 
 	academicDegreeGroup.GET("/:id", documentHandler.GetAcademicDegree)
 	academicDegreeGroup.GET("", documentHandler.GetAcademicDegrees)
 	academicDegreeGroup.POST("", documentHandler.CreateAcademicDegree)
 	academicDegreeGroup.PUT("/:id", documentHandler.UpdateAcademicDegree)
 	academicDegreeGroup.PATCH("/:id", documentHandler.RevokeAcademicDegree)
+	academicDegreeGroup.GET("/:id/revisions", documentHandler.GetDocumentRevisions(constant.AcademicDegree))
 
 	healthInsuranceGroup.GET("/:id", documentHandler.GetHealthInsurance)
 	healthInsuranceGroup.GET("", documentHandler.GetHealthInsurances)
 	healthInsuranceGroup.POST("", documentHandler.CreateHealthInsurance)
 	healthInsuranceGroup.PUT("/:id", documentHandler.UpdateHealthInsurance)
 	healthInsuranceGroup.PATCH("/:id", documentHandler.RevokeHealthInsurance)
+	healthInsuranceGroup.GET("/:id/revisions", documentHandler.GetDocumentRevisions(constant.HealthInsurance))
 
 	driverLicenseGroup.GET("/:id", documentHandler.GetDriverLicense)
 	driverLicenseGroup.GET("", documentHandler.GetDriverLicenses)
 	driverLicenseGroup.POST("", documentHandler.CreateDriverLicense)
 	driverLicenseGroup.PUT("/:id", documentHandler.UpdateDriverLicense)
 	driverLicenseGroup.PATCH("/:id", documentHandler.RevokeDriverLicense)
+	driverLicenseGroup.GET("/:id/revisions", documentHandler.GetDocumentRevisions(constant.DriverLicense))
+	driverLicenseGroup.POST("/:id/renew", documentHandler.RenewDriverLicense)
+	driverLicenseGroup.GET("/:id/points", documentHandler.GetDriverLicensePoints)
+	driverLicenseGroup.POST("/:id/points/deductions", documentHandler.DeductDriverLicensePoints)
+	driverLicenseGroup.POST("/:id/points/restorations", documentHandler.RestoreDriverLicensePoints)
 
 	passportGroup.GET("/:id", documentHandler.GetPassport)
 	passportGroup.GET("", documentHandler.GetPassports)
 	passportGroup.POST("", documentHandler.CreatePassport)
 	passportGroup.PUT("/:id", documentHandler.UpdatePassport)
 	passportGroup.PATCH("/:id", documentHandler.RevokePassport)
+	passportGroup.GET("/:id/revisions", documentHandler.GetDocumentRevisions(constant.Passport))
+	passportGroup.POST("/:id/renew", documentHandler.RenewPassport)
+
+	importGroup.POST("", documentHandler.CreateImportJob)
+	importGroup.GET("/:id", documentHandler.GetImportJob)
+	importGroup.GET("/:id/errors", documentHandler.GetImportJobErrorReport)
+
+	correctionGroup.GET("", documentHandler.GetCorrectionRequests)
+	correctionGroup.PATCH("/:id", documentHandler.ReviewCorrectionRequest)
 }
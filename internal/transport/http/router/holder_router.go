@@ -0,0 +1,23 @@
+package router
+
+import (
+	"be/internal/shared/constant"
+	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Router) SetupHolderRouter(apiGroup *gin.RouterGroup, holderHandler *handler.HolderHandler) {
+	holderGroup := apiGroup.Group("holder")
+	holderGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
+	holderGroup.Use(middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityHolderRole}))
+
+	holderGroup.GET("/documents", holderHandler.GetDocuments)
+
+	holderGroup.GET("/corrections", holderHandler.GetCorrectionRequests)
+	holderGroup.POST("/corrections", holderHandler.CreateCorrectionRequest)
+
+	holderGroup.GET("/notifications", holderHandler.GetNotifications)
+	holderGroup.PATCH("/notifications/:id/read", holderHandler.MarkNotificationRead)
+}
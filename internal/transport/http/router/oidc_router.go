@@ -0,0 +1,21 @@
+package router
+
+import (
+	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOIDCRouter mounts the OpenID Connect provider at the root of the server, where relying parties expect the
+// well-known discovery document next to the issuer.
+func (r *Router) SetupOIDCRouter(engine *gin.Engine, oidcHandler *handler.OIDCHandler) {
+	engine.GET("/.well-known/openid-configuration", oidcHandler.GetDiscovery)
+
+	oauthGroup := engine.Group("oauth2")
+	oauthGroup.Use(r.rateLimiter.Limit(middleware.DefaultRateLimitPolicy))
+	oauthGroup.GET("/authorize", r.rateLimiter.Limit(middleware.AuthZkChallengeRateLimitPolicy), oidcHandler.Authorize)
+	oauthGroup.POST("/authorize/callback", r.rateLimiter.Limit(middleware.AuthZkLoginRateLimitPolicy), oidcHandler.AuthorizeCallback)
+	oauthGroup.POST("/token", oidcHandler.Token)
+	oauthGroup.GET("/userinfo", oidcHandler.UserInfo)
+}
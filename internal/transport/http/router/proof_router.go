@@ -10,16 +10,22 @@ import (
 
 func (r *Router) SetupProofRouter(apiGroup *gin.RouterGroup, proofHandler *handler.ProofHandler) {
 	proofGroup := apiGroup.Group("proofs")
-	proofGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+
+	// each route names the API key scope it accepts; routes naming none are for ZK logins only
+	authenticate := func(scopes ...constant.APIKeyScope) gin.HandlerFunc {
+		return middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService, scopes...)
+	}
+	// routes accepting API keys are also limited per key, ZK logins per IP
+	limitByKey := r.rateLimiter.Limit(middleware.ProofAPIRateLimitPolicy)
 
 	proofRequestGroup := proofGroup.Group("requests")
 	proofSubmissionGroup := proofGroup.Group("submissions")
 
-	proofRequestGroup.POST("", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityVerifierRole}), proofHandler.CreateProofRequest)
-	proofRequestGroup.PATCH("/:id", proofHandler.UpdateProofRequest)
-	proofRequestGroup.GET("", proofHandler.GetProofRequests)
+	proofRequestGroup.POST("", authenticate(constant.APIKeyProofCreateScope), limitByKey, middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityVerifierRole}), proofHandler.CreateProofRequest)
+	proofRequestGroup.PATCH("/:id", authenticate(constant.APIKeyProofCreateScope), limitByKey, middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityVerifierRole}), proofHandler.UpdateProofRequest)
+	proofRequestGroup.GET("", authenticate(constant.APIKeyProofReadScope), limitByKey, proofHandler.GetProofRequests)
 
-	proofSubmissionGroup.POST("", proofHandler.CreateProofSubmission)
-	proofSubmissionGroup.PATCH("/:id", proofHandler.VerifyZKProof)
-	proofSubmissionGroup.GET("", proofHandler.GetProofSubmissions)
+	proofSubmissionGroup.POST("", authenticate(), r.rateLimiter.Limit(middleware.ProofSubmissionRateLimitPolicy), proofHandler.CreateProofSubmission)
+	proofSubmissionGroup.PATCH("/:id", authenticate(constant.APIKeyProofVerifyScope), limitByKey, proofHandler.VerifyZKProof)
+	proofSubmissionGroup.GET("", authenticate(constant.APIKeyProofReadScope), limitByKey, proofHandler.GetProofSubmissions)
 }
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"be/internal/shared/constant"
+	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (r *Router) SetupRoleRouter(apiGroup *gin.RouterGroup, roleHandler *handler.RoleHandler) {
+	roleGroup := apiGroup.Group("roles")
+	roleGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
+
+	adminOnly := middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityAdminRole})
+
+	roleGroup.POST("/applications", roleHandler.ApplyForRole)
+	roleGroup.GET("/applications", roleHandler.GetRoleApplications)
+	roleGroup.PATCH("/applications/:id", adminOnly, roleHandler.ReviewRoleApplication)
+
+	roleGroup.GET("/grants", roleHandler.GetRoleGrants)
+	roleGroup.POST("/grants", adminOnly, roleHandler.GrantRole)
+	roleGroup.POST("/grants/:id/revoke", adminOnly, roleHandler.RevokeRole)
+}
@@ -4,6 +4,7 @@ import (
 	"be/internal/infrastructure/database/postgres"
 	"be/internal/service"
 	"be/internal/transport/http/handler"
+	"be/internal/transport/http/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,7 +19,14 @@ type Router struct {
 	proofHandler      *handler.ProofHandler
 	circuitHandler    *handler.CircuitHandler
 	statisticHandler  *handler.StatisticHandler
+	holderHandler     *handler.HolderHandler
+	roleHandler       *handler.RoleHandler
+	adminHandler      *handler.AdminHandler
+	oidcHandler       *handler.OIDCHandler
+	apiKeyHandler     *handler.APIKeyHandler
 	authZkService     service.IAuthZkService
+	apiKeyService     service.IAPIKeyService
+	rateLimiter       *middleware.RateLimiter
 }
 
 func NewRouter(
@@ -31,7 +39,14 @@ func NewRouter(
 	proofHandler *handler.ProofHandler,
 	circuitHandler *handler.CircuitHandler,
 	statisticHandler *handler.StatisticHandler,
+	holderHandler *handler.HolderHandler,
+	roleHandler *handler.RoleHandler,
+	adminHandler *handler.AdminHandler,
+	oidcHandler *handler.OIDCHandler,
+	apiKeyHandler *handler.APIKeyHandler,
 	authZkService service.IAuthZkService,
+	apiKeyService service.IAPIKeyService,
+	rateLimiter *middleware.RateLimiter,
 ) *Router {
 	return &Router{
 		db:                db,
@@ -43,12 +58,23 @@ func NewRouter(
 		proofHandler:      proofHandler,
 		circuitHandler:    circuitHandler,
 		statisticHandler:  statisticHandler,
+		holderHandler:     holderHandler,
+		roleHandler:       roleHandler,
+		adminHandler:      adminHandler,
+		oidcHandler:       oidcHandler,
+		apiKeyHandler:     apiKeyHandler,
 		authZkService:     authZkService,
+		apiKeyService:     apiKeyService,
+		rateLimiter:       rateLimiter,
 	}
 }
 
 func (r *Router) SetupRoutes(engine *gin.Engine) {
+	engine.GET("/.well-known/jwks.json", r.authJWTHandler.GetJWKS)
+	r.SetupOIDCRouter(engine, r.oidcHandler)
+
 	apiGroup := engine.Group("api/v1")
+	apiGroup.Use(r.rateLimiter.Limit(middleware.DefaultRateLimitPolicy))
 	r.SetupAuthJWTRouter(apiGroup, r.authJWTHandler)
 	r.SetupAuthZkRouter(apiGroup, r.authZkHandler)
 	r.SetupDocumentRouter(apiGroup, r.documentHandler)
@@ -57,4 +83,8 @@ func (r *Router) SetupRoutes(engine *gin.Engine) {
 	r.SetupProofRouter(apiGroup, r.proofHandler)
 	r.SetupCircuitRouter(apiGroup, r.circuitHandler)
 	r.SetupStatisticRouter(apiGroup, r.statisticHandler)
+	r.SetupHolderRouter(apiGroup, r.holderHandler)
+	r.SetupRoleRouter(apiGroup, r.roleHandler)
+	r.SetupAPIKeyRouter(apiGroup, r.apiKeyHandler)
+	r.SetupAdminRouter(apiGroup, r.adminHandler)
 }
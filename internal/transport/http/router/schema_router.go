@@ -12,11 +12,12 @@ import (
 
 func (r *Router) SetupSchemaRouter(apiGroup *gin.RouterGroup, schemaHandler *handler.SchemaHandler, db *postgres.PostgresDB) {
 	schemaGroup := apiGroup.Group("schemas")
-	schemaGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+	schemaGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
 
 	schemaGroup.GET("", schemaHandler.GetSchemas)
 	schemaGroup.GET("/:id", schemaHandler.GetSchemaByPublicId)
 	schemaGroup.GET("/attributes/:id", schemaHandler.GetSchemaAttributeByPublicId)
 	schemaGroup.POST("", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}), helper.TxMiddleware(db.GetGormDB()), schemaHandler.CreateSchema)
 	schemaGroup.PATCH("/:id", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}), schemaHandler.RemoveSchema)
+	schemaGroup.PUT("/:id/mappings", middleware.AuthorizeMiddleware([]constant.IdentityRole{constant.IdentityIssuerRole}), schemaHandler.UpdateSchemaMappings)
 }
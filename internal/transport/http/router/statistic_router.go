@@ -9,7 +9,7 @@ import (
 
 func (r *Router) SetupStatisticRouter(apiGroup *gin.RouterGroup, statisticHandler *handler.StatisticHandler) {
 	statisticGroup := apiGroup.Group("statistic")
-	statisticGroup.Use(middleware.AuthenticateMiddleware(r.authZkService))
+	statisticGroup.Use(middleware.AuthenticateMiddleware(r.authZkService, r.apiKeyService))
 
 	statisticGroup.GET("/issuer/:did", statisticHandler.GetIssuerStatisticByIssuerDID)
 	statisticGroup.GET("/holder/:did", statisticHandler.GetHolderStatisticByHolderDID)
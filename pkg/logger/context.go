@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	didKey
+)
+
+// RequestIDHeader carries the request ID in message headers, the same name HTTP uses.
+const RequestIDHeader = "X-Request-ID"
+
+// WithRequestID returns a context carrying the ID of the request it serves.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// WithDID returns a context carrying the DID of the caller.
+func WithDID(ctx context.Context, did string) context.Context {
+	return context.WithValue(ctx, didKey, did)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+func DIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	did, _ := ctx.Value(didKey).(string)
+	return did
+}
+
+// WithContext returns a logger that adds the request ID and caller DID of ctx to every entry. Without either it
+// returns the logger itself.
+func (zl *ZapLogger) WithContext(ctx context.Context) *ZapLogger {
+	var fields []zap.Field
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		fields = append(fields, zap.String("request_id", requestID))
+	}
+	if did := DIDFromContext(ctx); did != "" {
+		fields = append(fields, zap.String("did", did))
+	}
+	if len(fields) == 0 {
+		return zl
+	}
+	return &ZapLogger{zl.logger.With(fields...)}
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger writes GORM logs through zap. Repositories run their queries with the request context, so failed and
+// slow queries are logged with the request ID and caller DID of the request that issued them.
+type gormLogger struct {
+	logger *ZapLogger
+	level  gormlogger.LogLevel
+}
+
+// GormLogger returns a GORM logger that logs failed queries as errors and slow ones as warnings.
+func (zl *ZapLogger) GormLogger() gormlogger.Interface {
+	return &gormLogger{logger: zl, level: gormlogger.Warn}
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &gormLogger{logger: l.logger, level: level}
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		l.logger.WithContext(ctx).Info(fmt.Sprintf(msg, data...), zap.String("source", utils.FileWithLineNum()))
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		l.logger.WithContext(ctx).Warn(fmt.Sprintf(msg, data...), zap.String("source", utils.FileWithLineNum()))
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		l.logger.WithContext(ctx).Error(fmt.Sprintf(msg, data...), zap.String("source", utils.FileWithLineNum()))
+	}
+}
+
+// Trace logs a finished query. A missing record is an expected outcome the repositories handle, not an error.
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && l.level >= gormlogger.Error && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.WithContext(ctx).Error("query failed", l.queryFields(sql, rows, elapsed, zap.Error(err))...)
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		l.logger.WithContext(ctx).Warn("slow query", l.queryFields(sql, rows, elapsed)...)
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		l.logger.WithContext(ctx).Debug("query", l.queryFields(sql, rows, elapsed)...)
+	}
+}
+
+func (l *gormLogger) queryFields(sql string, rows int64, elapsed time.Duration, fields ...zap.Field) []zap.Field {
+	return append(fields,
+		zap.String("sql", sql),
+		zap.Int64("rows", rows),
+		zap.Duration("elapsed", elapsed),
+		zap.String("source", utils.FileWithLineNum()),
+	)
+}
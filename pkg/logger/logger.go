@@ -16,7 +16,7 @@ type ZapLogger struct {
 func NewLogger(cfg *config.Config) (*ZapLogger, error) {
 	var level, err = zapcore.ParseLevel(cfg.Zap.Level)
 	if err != nil {
-		return nil, fmt.Errorf("invalid log level %w", cfg.Zap.Level)
+		return nil, fmt.Errorf("invalid log level %s: %w", cfg.Zap.Level, err)
 	}
 
 	encoderConfig := zapcore.EncoderConfig{
@@ -61,6 +61,8 @@ func NewLogger(cfg *config.Config) (*ZapLogger, error) {
 		zap.String("version", cfg.App.Version),
 		zap.String("env", cfg.App.Env),
 	)
+	// code without an injected logger, such as the DTO mappers, logs through zap.L()
+	zap.ReplaceGlobals(logger)
 	return &ZapLogger{logger}, nil
 }
 